@@ -3,7 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,17 +12,22 @@ import (
 	"time"
 
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/grpcapi"
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/httpapi"
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/logging"
 )
 
 func main() {
-	logger := log.New(os.Stdout, "", log.LstdFlags|log.LUTC)
+	logger := logging.New(os.Stdout, logging.FormatText)
 
 	cfg, err := config.Load()
 	if err != nil {
-		logger.Fatalf("load config: %v", err)
+		fatal(logger, "load config", err)
 	}
+	logging.SetLevel(cfg.LogLevel)
+	logger = logging.New(os.Stdout, cfg.LogFormat)
+	slog.SetDefault(logger)
 
 	manager := jobs.NewManager(cfg, logger)
 	defer manager.Close()
@@ -30,15 +36,66 @@ func main() {
 		Addr:              fmt.Sprintf(":%d", cfg.Port),
 		Handler:           httpapi.NewServer(cfg, manager, logger),
 		ReadHeaderTimeout: 10 * time.Second,
+		// Outer bounds only: the handler sets per-route deadlines, lifting
+		// them for event streams and downloads.
+		ReadTimeout:  cfg.HTTPSlowAPITimeout,
+		WriteTimeout: cfg.HTTPSlowAPITimeout,
+		IdleTimeout:  2 * time.Minute,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
 	}
 
+	tlsConfig, redirectHandler, err := httpapi.NewTLSConfig(cfg, logger)
+	if err != nil {
+		fatal(logger, "tls", err)
+	}
+	server.TLSConfig = tlsConfig
+
 	go func() {
-		logger.Printf("backend listening on %s", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("http server failed: %v", err)
+		var err error
+		if tlsConfig != nil {
+			logger.Info("backend listening", "addr", server.Addr, "tls", true)
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			logger.Info("backend listening", "addr", server.Addr)
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal(logger, "http server failed", err)
 		}
 	}()
 
+	var redirectServer *http.Server
+	if cfg.TLSRedirectPort > 0 {
+		redirectServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.TLSRedirectPort),
+			Handler:           redirectHandler,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+		}
+		go func() {
+			logger.Info("redirecting http to https", "addr", redirectServer.Addr)
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal(logger, "http redirect server failed", err)
+			}
+		}()
+	}
+
+	if cfg.GRPCPort > 0 {
+		grpcServer := grpcapi.NewServer(cfg, manager, logger)
+		defer grpcServer.Stop()
+
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			fatal(logger, "grpc listen", err)
+		}
+		go func() {
+			logger.Info("grpc listening", "addr", listener.Addr().String())
+			if err := grpcServer.Serve(listener); err != nil {
+				fatal(logger, "grpc server failed", err)
+			}
+		}()
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
@@ -46,7 +103,16 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if redirectServer != nil {
+		_ = redirectServer.Shutdown(shutdownCtx)
+	}
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.Printf("graceful shutdown failed: %v", err)
+		logger.Error("graceful shutdown failed", "error", err)
 	}
 }
+
+// fatal logs err and exits.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the audit log. Filters match an action exactly or by
+// its prefix before the dot, so "auth" selects every "auth.*" action.
+const (
+	ActionJobCreated    = "job.created"
+	ActionJobCancelled  = "job.cancelled"
+	ActionJobApproved   = "job.approved"
+	ActionJobRejected   = "job.rejected"
+	ActionAdminRequest  = "admin.request"
+	ActionAuthFailed    = "auth.failed"
+	ActionRateLimited   = "limit.exceeded"
+	ActionRepoRejected  = "repo.rejected"
+	ActionSecretStored  = "secret.stored"
+	ActionSecretDeleted = "secret.deleted"
+)
+
+// ErrNoFileSink is returned by Query when events are not written to a file.
+var ErrNoFileSink = errors.New("audit log has no file sink")
+
+// Event is one security-relevant action. Actor is the signed-in user, or
+// "admin", "webhook" or "grpc" for requests authenticated another way.
+type Event struct {
+	Timestamp time.Time         `json:"ts"`
+	Action    string            `json:"action"`
+	Actor     string            `json:"actor,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+	JobID     string            `json:"jobId,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// Options selects the sinks of a Log: "file" appends JSON lines to
+// FilePath, "syslog" sends them to the local syslog daemon or, when set, to
+// SyslogAddress ("udp://host:514", "tcp://host:601").
+type Options struct {
+	Sinks         []string
+	FilePath      string
+	SyslogAddress string
+}
+
+type sink interface {
+	write(event Event, line []byte) error
+}
+
+// Log writes audit events to its sinks. Events are only ever appended; the
+// file is not rotated or rewritten by the server.
+type Log struct {
+	mu       sync.Mutex
+	sinks    []sink
+	filePath string
+	logger   *slog.Logger
+}
+
+// New opens the sinks in opts. It returns nil when no sink is configured;
+// a nil *Log discards events. A sink that cannot be opened is logged and
+// skipped, so a missing syslog daemon does not keep the server from starting.
+func New(opts Options, logger *slog.Logger) *Log {
+	l := &Log{logger: logger}
+	for _, name := range opts.Sinks {
+		switch name {
+		case "file":
+			l.sinks = append(l.sinks, &fileSink{path: opts.FilePath})
+			l.filePath = opts.FilePath
+		case "syslog":
+			writer, err := dialSyslog(opts.SyslogAddress)
+			if err != nil {
+				logger.Error("audit: syslog sink disabled", "error", err)
+				continue
+			}
+			l.sinks = append(l.sinks, writer)
+		}
+	}
+	if len(l.sinks) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Record appends event to every sink. Failures are logged, never returned:
+// the audited action has already happened.
+func (l *Log) Record(event Event) {
+	if l == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		l.logger.Error("audit: marshal event", "error", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, sink := range l.sinks {
+		if err := sink.write(event, line); err != nil {
+			l.logger.Error("audit: write event", "error", err)
+		}
+	}
+}
+
+// Filter selects events for Query. Zero fields match everything.
+type Filter struct {
+	Action string
+	Actor  string
+	IP     string
+	JobID  string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (f Filter) matches(event Event) bool {
+	if f.Action != "" && event.Action != f.Action && !strings.HasPrefix(event.Action, f.Action+".") {
+		return false
+	}
+	if (f.Actor != "" && event.Actor != f.Actor) || (f.IP != "" && event.IP != f.IP) || (f.JobID != "" && event.JobID != f.JobID) {
+		return false
+	}
+	if (!f.Since.IsZero() && event.Timestamp.Before(f.Since)) || (!f.Until.IsZero() && !event.Timestamp.Before(f.Until)) {
+		return false
+	}
+	return true
+}
+
+// Query returns up to filter.Limit (100 by default) matching events from the
+// file sink, newest first.
+func (l *Log) Query(filter Filter) ([]Event, error) {
+	if l == nil || l.filePath == "" {
+		return nil, ErrNoFileSink
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Event{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	// Keep the newest matches in a ring while scanning the whole file.
+	ring := make([]Event, 0, filter.Limit)
+	next := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || !filter.matches(event) {
+			continue
+		}
+		if len(ring) < filter.Limit {
+			ring = append(ring, event)
+			continue
+		}
+		ring[next] = event
+		next = (next + 1) % filter.Limit
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	events := make([]Event, 0, len(ring))
+	for i := len(ring) - 1; i >= 0; i-- {
+		events = append(events, ring[(next+i)%len(ring)])
+	}
+	return events, nil
+}
+
+// fileSink appends JSON lines to a file readable only by the server user.
+type fileSink struct {
+	path string
+	file *os.File
+}
+
+func (s *fileSink) write(_ Event, line []byte) error {
+	if s.file == nil {
+		file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("open audit log: %w", err)
+		}
+		s.file = file
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogRecordAndQuery(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog := New(Options{Sinks: []string{"file"}, FilePath: path}, slog.New(slog.DiscardHandler))
+
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	actions := []string{ActionJobCreated, ActionAuthFailed, ActionJobCancelled, ActionJobCreated, ActionRateLimited}
+	for i, action := range actions {
+		auditLog.Record(Event{Timestamp: start.Add(time.Duration(i) * time.Minute), Action: action, JobID: string(rune('a' + i))})
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat audit log: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected the audit log to be private, got %v", info.Mode().Perm())
+	}
+
+	events, err := auditLog.Query(Filter{})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(events) != len(actions) || events[0].JobID != "e" || events[len(events)-1].JobID != "a" {
+		t.Fatalf("expected all events newest first, got %+v", events)
+	}
+
+	events, err = auditLog.Query(Filter{Action: "job", Limit: 2})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(events) != 2 || events[0].JobID != "d" || events[1].JobID != "c" {
+		t.Fatalf("expected the two newest job events, got %+v", events)
+	}
+
+	events, err = auditLog.Query(Filter{Action: "job.created", Since: start.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(events) != 1 || events[0].JobID != "d" {
+		t.Fatalf("expected the job created after since, got %+v", events)
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	t.Parallel()
+
+	auditLog := New(Options{}, slog.New(slog.DiscardHandler))
+	if auditLog != nil {
+		t.Fatalf("expected no log without sinks")
+	}
+	auditLog.Record(Event{Action: ActionAuthFailed})
+	if _, err := auditLog.Query(Filter{}); !errors.Is(err, ErrNoFileSink) {
+		t.Fatalf("expected ErrNoFileSink, got %v", err)
+	}
+}
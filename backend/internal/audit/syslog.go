@@ -0,0 +1,45 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"fmt"
+	"log/syslog"
+	"net/url"
+)
+
+// syslogSink sends each event as a JSON message with the auth facility, so
+// it lands next to the system's other authentication logs.
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func dialSyslog(address string) (sink, error) {
+	network, raddr := "", ""
+	if address != "" {
+		parsed, err := url.Parse(address)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid syslog address %q", address)
+		}
+		network, raddr = parsed.Scheme, parsed.Host
+	}
+	writer, err := syslog.Dial(network, raddr, syslog.LOG_AUTH|syslog.LOG_INFO, "mfb-audit")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) write(event Event, line []byte) error {
+	var err error
+	switch event.Action {
+	case ActionAuthFailed, ActionRateLimited, ActionRepoRejected:
+		err = s.writer.Warning(string(line))
+	default:
+		err = s.writer.Notice(string(line))
+	}
+	if err != nil {
+		return fmt.Errorf("write syslog: %w", err)
+	}
+	return nil
+}
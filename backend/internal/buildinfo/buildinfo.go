@@ -2,7 +2,8 @@ package buildinfo
 
 // Set via -ldflags at build time.
 var (
-	Version = "dev"
-	Commit  = ""
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
 )
 
@@ -12,31 +12,39 @@ import (
 )
 
 type BuildLog struct {
-	JobID      string     `json:"jobId"`
-	RepoURL    string     `json:"repoUrl"`
-	Ref        string     `json:"ref,omitempty"`
-	Device     string     `json:"device"`
-	ClientIP   string     `json:"clientIp,omitempty"`
-	Status     string     `json:"status"`
-	CreatedAt  time.Time  `json:"createdAt"`
-	StartedAt  *time.Time `json:"startedAt,omitempty"`
-	FinishedAt *time.Time `json:"finishedAt,omitempty"`
-	Error      string     `json:"error,omitempty"`
-	Lines      []string   `json:"lines"`
+	JobID       string     `json:"jobId"`
+	RepoURL     string     `json:"repoUrl"`
+	Ref         string     `json:"ref,omitempty"`
+	Device      string     `json:"device"`
+	ClientIP    string     `json:"clientIp,omitempty"`
+	UserID      string     `json:"userId,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	FailureKind string     `json:"failureKind,omitempty"`
+	Platform    string     `json:"platform,omitempty"`
+	CacheHit    bool       `json:"cacheHit,omitempty"`
+	Lines       []string   `json:"lines"`
 }
 
 type BuildLogEntry struct {
-	JobID      string     `json:"jobId"`
-	RepoURL    string     `json:"repoUrl"`
-	Ref        string     `json:"ref,omitempty"`
-	Device     string     `json:"device"`
-	ClientIP   string     `json:"clientIp,omitempty"`
-	Status     string     `json:"status"`
-	CreatedAt  time.Time  `json:"createdAt"`
-	StartedAt  *time.Time `json:"startedAt,omitempty"`
-	FinishedAt *time.Time `json:"finishedAt,omitempty"`
-	Error      string     `json:"error,omitempty"`
-	LineCount  int        `json:"lineCount"`
+	JobID       string     `json:"jobId"`
+	RepoURL     string     `json:"repoUrl"`
+	Ref         string     `json:"ref,omitempty"`
+	Device      string     `json:"device"`
+	ClientIP    string     `json:"clientIp,omitempty"`
+	UserID      string     `json:"userId,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	FailureKind string     `json:"failureKind,omitempty"`
+	Platform    string     `json:"platform,omitempty"`
+	CacheHit    bool       `json:"cacheHit,omitempty"`
+	LineCount   int        `json:"lineCount"`
 }
 
 type Store struct {
@@ -115,17 +123,21 @@ func (s *Store) List(limit int) ([]BuildLogEntry, error) {
 			continue
 		}
 		result = append(result, BuildLogEntry{
-			JobID:      bl.JobID,
-			RepoURL:    bl.RepoURL,
-			Ref:        bl.Ref,
-			Device:     bl.Device,
-			ClientIP:   bl.ClientIP,
-			Status:     bl.Status,
-			CreatedAt:  bl.CreatedAt,
-			StartedAt:  bl.StartedAt,
-			FinishedAt: bl.FinishedAt,
-			Error:      bl.Error,
-			LineCount:  len(bl.Lines),
+			JobID:       bl.JobID,
+			RepoURL:     bl.RepoURL,
+			Ref:         bl.Ref,
+			Device:      bl.Device,
+			ClientIP:    bl.ClientIP,
+			UserID:      bl.UserID,
+			Status:      bl.Status,
+			CreatedAt:   bl.CreatedAt,
+			StartedAt:   bl.StartedAt,
+			FinishedAt:  bl.FinishedAt,
+			Error:       bl.Error,
+			FailureKind: bl.FailureKind,
+			Platform:    bl.Platform,
+			CacheHit:    bl.CacheHit,
+			LineCount:   len(bl.Lines),
 		})
 	}
 	return result, nil
@@ -1,29 +1,89 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/netip"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
 const (
-	defaultPort                = 8080
-	defaultWorkDir             = "../build-workdir"
-	defaultFirmwareCacheDir    = "firmware-cache"
-	defaultConcurrentBuilds    = 1
-	defaultRetentionHours      = 168
-	defaultBuildTimeoutMinutes = 60
-	defaultBuilderImage        = "meshtastic-pio-builder:latest"
-	defaultPlatformIOJobs      = 1
-	defaultAllowedOrigins      = "http://localhost:5173"
-	defaultMaxLogLines         = 20000
-	defaultBuildRateLimit      = 10
-	defaultRequireCaptcha      = true
+	defaultPort                    = 8080
+	defaultWorkDir                 = "../build-workdir"
+	defaultFirmwareCacheDir        = "firmware-cache"
+	defaultConcurrentBuilds        = 1
+	defaultRetentionHours          = 168
+	defaultBuildTimeoutMinutes     = 60
+	defaultBuilderImage            = "meshtastic-pio-builder:latest"
+	defaultPlatformIOJobs          = 1
+	defaultAllowedOrigins          = "http://localhost:5173"
+	defaultMaxLogLines             = 20000
+	defaultBuildRateLimit          = 10
+	defaultAnonymousBuildRateLimit = 2
+	defaultAbuseMinFailures        = 5
+	defaultAbuseFailureRatio       = 0.8
+	defaultAbuseWindowMinutes      = 60
+	defaultAbuseBuildRateLimit     = 1
+	defaultIdempotencyMinutes      = 60
+	defaultHTTPAPITimeoutSec       = 30
+	defaultHTTPSlowTimeoutSec      = 600
+	defaultHTTPStallTimeoutSec     = 60
+	defaultRequireCaptcha          = true
+	defaultCaptchaMinScore         = 0.5
+	defaultPoWDifficulty           = 18
+	defaultPoWMaxDifficulty        = 24
+	defaultGitMirrorMinUses        = 2
+	defaultGitMirrorRefreshMin     = 10
+	defaultCloneShallowSince       = "1 year ago"
+	defaultFeaturedRepos           = "https://github.com/meshtastic/firmware"
+	defaultCatalogRefreshMin       = 360
+	defaultCatalogReleaseTags      = 3
+	defaultDiscoveryConcurrent     = 2
+	defaultDiscoveryQueueSize      = 16
+	defaultOIDCSessionHours        = 168
+	defaultMinFreeDiskMB           = 1024
+	defaultJobLogRotateMB          = 16
+	defaultJobLogMaxFiles          = 5
+	defaultDockerHealthIntervalSec = 30
+
+	defaultArtifactExtensions    = ".bin,.hex,.uf2,.elf"
+	defaultWorkspaceRetentionMin = 0
+	defaultArtifactS3Endpoint    = "https://s3.amazonaws.com"
+	defaultArtifactS3Region      = "us-east-1"
+	defaultArtifactGitHubAPIURL  = "https://api.github.com"
+	defaultOIDCScopes            = "openid,profile,email"
+	defaultGitHubOAuthScopes     = "read:user"
+
+	redactedValue = "[redacted]"
 )
 
+// DeviceRule is a device name glob, optionally limited to repositories
+// matching Repo (e.g. "github.com/meshtastic/*"). An empty Repo applies to
+// every repository.
+type DeviceRule struct {
+	Repo    string
+	Pattern string
+}
+
+// WebhookBuild is a device built when a git webhook reports a push or
+// release of a repository matching Repo ("host/owner/name" glob).
+type WebhookBuild struct {
+	Repo   string
+	Device string
+}
+
 type Config struct {
 	Port              int
 	WorkDir           string
@@ -32,21 +92,273 @@ type Config struct {
 	Retention         time.Duration
 	BuildTimeout      time.Duration
 	BuilderImage      string
-	PlatformIOJobs    int
-	AllowedOrigins    []string
-	PlatformIOCache   string
-	DockerHostCache   string
-	MaxLogLines       int
-	BuildRateLimit    int
-	RequireCaptcha    bool
-	CleanupInterval   time.Duration
+	// DockerHealthInterval is how often the docker daemon and the builder
+	// image are checked; queued jobs fail while either is unavailable (0
+	// disables the checks).
+	DockerHealthInterval time.Duration
+	PlatformIOJobs       int
+	// Build container hardening, off by default: BuildReadOnlyRootFS makes
+	// the image filesystem read-only with a tmpfs /tmp, BuildCapDropAll
+	// drops every capability and forbids privilege escalation,
+	// BuildSeccompProfile is a seccomp profile file read by the docker CLI,
+	// BuildUser runs the build as "uid[:gid]" and BuildPidsLimit caps the
+	// processes of a build (0 = unlimited).
+	BuildReadOnlyRootFS bool
+	BuildCapDropAll     bool
+	BuildSeccompProfile string
+	BuildUser           string
+	BuildPidsLimit      int
+	AllowedOrigins      []string
+	PlatformIOCache     string
+	DockerHostCache     string
+	MaxLogLines         int
+	// JobLogFiles writes the full log of each job to build.log in its
+	// workspace, rotated into gzip-compressed build.log.N.gz segments once
+	// it exceeds JobLogRotateMB (0 = never), keeping the newest
+	// JobLogMaxFiles of them; MaxLogLines then only bounds memory.
+	JobLogFiles    bool
+	JobLogRotateMB int
+	JobLogMaxFiles int
+	BuildRateLimit int
+	// BuildQuotaDaily and BuildQuotaWeekly cap the builds per client (a
+	// signed-in account or a client address) per UTC day and week; 0
+	// disables the quota.
+	BuildQuotaDaily  int
+	BuildQuotaWeekly int
+	// A client is flagged for abuse when, within AbuseWindow, at least
+	// AbuseMinFailures of its builds failed with the same error and at
+	// least AbuseFailureRatio of its builds failed. Flagged clients must
+	// solve a fresh captcha for every build and may queue
+	// AbuseBuildRateLimit builds per minute until AbuseWindow has passed
+	// without a new flag. AbuseMinFailures 0 disables detection.
+	AbuseMinFailures    int
+	AbuseFailureRatio   float64
+	AbuseWindow         time.Duration
+	AbuseBuildRateLimit int
+	IdempotencyWindow   time.Duration
+	// RedisURL moves the build rate limit and captcha sessions to Redis, so
+	// backends sharing it enforce one limit and sessions survive restarts.
+	// Keys start with RedisKeyPrefix.
+	RedisURL       string
+	RedisKeyPrefix string
+	// JobRegistry publishes the metadata of this node's jobs to Redis under
+	// NodeName, so the admin API and build histories list the jobs of every
+	// node sharing it. Logs and artifacts stay on the node that built them.
+	JobRegistry     bool
+	NodeName        string
+	RequireCaptcha  bool
+	CleanupInterval time.Duration
+	// CaptchaProvider is "math" (the built-in arithmetic challenge) or a
+	// widget verified server-side with CaptchaSecretKey: "turnstile",
+	// "hcaptcha" or "recaptcha". CaptchaMinScore applies to reCAPTCHA v3
+	// responses, which carry a score. CaptchaImage renders the math
+	// question as a distorted PNG instead of text.
+	CaptchaProvider  string
+	CaptchaSiteKey   string
+	CaptchaSecretKey string
+	CaptchaVerifyURL string
+	CaptchaMinScore  float64
+	CaptchaImage     bool
+	// CaptchaSessionCookie also hands the captcha session out as an
+	// HttpOnly SameSite=Strict cookie, which requests carrying the CSRF
+	// header may use instead of captchaSessionToken.
+	CaptchaSessionCookie bool
+	// PoWEnabled offers a hashcash-style proof-of-work challenge in place of
+	// the captcha for job creation. PoWDifficulty is the number of leading
+	// zero bits required on an idle queue; it grows with the queue length
+	// up to PoWMaxDifficulty.
+	PoWEnabled        bool
+	PoWDifficulty     int
+	PoWMaxDifficulty  int
 	DiscoveryRootPath string
 	JobsRootPath      string
 	FirmwareCachePath string
-	StatsPassword     string
-	StatsFilePath     string
-	BuildLogsPath     string
+	// MinFreeDiskMB refuses new builds while the work directory, the
+	// PlatformIO cache or the firmware cache has less free space (0
+	// disables the check).
+	MinFreeDiskMB int
+	StatsPassword string
+	AdminToken    string
+	// AdminClientCAs verifies client certificates that authenticate
+	// /api/admin/* requests as an alternative to AdminToken.
+	AdminClientCAs *x509.CertPool
+	StatsFilePath  string
+	BuildLogsPath  string
+	// AuditSinks lists where security events are recorded: "file" appends
+	// them to AuditLogPath, "syslog" sends them to the local syslog daemon
+	// or AuditSyslogAddress. Empty disables the audit log.
+	AuditSinks         []string
+	AuditLogPath       string
+	AuditSyslogAddress string
+	// SecretsKey is the base64 AES-256 key that encrypts the credential
+	// store at SecretsPath, which holds git credentials, webhook secrets
+	// and tag signing keys managed through the admin API. Empty disables
+	// the store.
+	SecretsKey        string
+	SecretsPath       string
 	TrustProxyHeaders bool
+	TrustedProxies    []netip.Prefix
+	// IPAllow and IPDeny are checked before routing: when IPAllow is set only
+	// matching clients are served, and IPDeny always wins. Clients in
+	// AnonymousRanges (Tor exits, VPN providers) that are not signed in may
+	// queue AnonymousBuildRateLimit builds per minute instead of
+	// BuildRateLimit. The admin API can replace all three lists at runtime.
+	IPAllow                 []netip.Prefix
+	IPDeny                  []netip.Prefix
+	AnonymousRanges         []netip.Prefix
+	AnonymousBuildRateLimit int
+	GitMirrorEnabled        bool
+	GitMirrorPath           string
+	GitMirrorMinUses        int
+	GitMirrorRefresh        time.Duration
+
+	// CloneStrategy selects how much history build clones fetch: "shallow"
+	// (depth 1), "shallow-since" (commits newer than CloneShallowSince),
+	// "treeless" (all commits, trees and blobs on demand) or "full".
+	CloneStrategy     string
+	CloneShallowSince string
+
+	// DiscoveryConcurrency bounds simultaneous discovery clones; up to
+	// DiscoveryQueueSize more requests wait for a free slot.
+	DiscoveryConcurrency int
+	DiscoveryQueueSize   int
+
+	// GitNetworkConcurrency bounds simultaneous git network operations
+	// (clone, fetch, ls-remote, submodule update) across discovery, refs and
+	// builds; 0 disables the limit. GitBandwidthLimit caps the combined
+	// transfer rate of http(s) remotes in bytes per second; 0 disables it.
+	GitNetworkConcurrency int
+	GitBandwidthLimit     int64
+
+	// GRPCPort serves the gRPC API alongside HTTP when non-zero. Every call
+	// must carry GRPCToken as a bearer token, since gRPC clients skip the
+	// captcha.
+	GRPCPort  int
+	GRPCToken string
+
+	// HTTPAPITimeout bounds JSON API requests and HTTPSlowAPITimeout those
+	// that clone or query remotes (discovery, refs, job creation). Streams
+	// and downloads have no total limit but are aborted once the client
+	// stops reading or sending for HTTPStallTimeout.
+	HTTPAPITimeout     time.Duration
+	HTTPSlowAPITimeout time.Duration
+	HTTPStallTimeout   time.Duration
+
+	// TLSCertFile and TLSKeyFile make the HTTP server terminate TLS itself,
+	// with HTTP/2; the files are re-read when they change. TLSAutocertDomains
+	// obtains certificates from Let's Encrypt instead, cached under
+	// <workdir>/autocert. TLSRedirectPort, when non-zero, serves plain HTTP
+	// redirects to HTTPS and ACME http-01 challenges.
+	TLSCertFile         string
+	TLSKeyFile          string
+	TLSAutocertDomains  []string
+	TLSAutocertEmail    string
+	TLSAutocertCacheDir string
+	TLSRedirectPort     int
+
+	// OIDCIssuer enables sign-in through an OpenID Connect provider: an
+	// issuer URL, "google", or "github" (GitHub OAuth, which is not OIDC).
+	// Jobs created with a session are recorded in the user's history and
+	// rate limited per account instead of per IP. Sessions are kept in
+	// memory for OIDCSessionTTL.
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCSessionTTL   time.Duration
+
+	// FeaturedRepos are discovered in the background for GET /api/devices:
+	// the default branch plus the newest CatalogReleaseTags release tags.
+	FeaturedRepos      []string
+	CatalogRefresh     time.Duration
+	CatalogReleaseTags int
+	// CatalogWebhookURL receives a JSON POST when a refresh finds devices
+	// added to or removed from a featured repository's default branch.
+	CatalogWebhookURL string
+
+	// DeviceAllow and DeviceDeny restrict which envs can be discovered and
+	// built. When allow rules apply to a repository, only matching devices
+	// are available; deny rules always win.
+	DeviceAllow []DeviceRule
+	DeviceDeny  []DeviceRule
+
+	// AllowedRepoHosts restricts the repositories users may discover and
+	// build to these "host" or "host/owner" globs. Empty allows any host.
+	AllowedRepoHosts []string
+
+	// ModerateAnonymousBuilds holds builds by clients that are not signed in
+	// as pending until an operator approves them through the admin API.
+	// Featured repositories and ModerationAllowedRepos ("host" or
+	// "host/owner" globs) are built without approval.
+	ModerateAnonymousBuilds bool
+	ModerationAllowedRepos  []string
+
+	// TagSignatureMode is "" (off), "record" or "require". Builds of tags
+	// verify the tag signature against TagGPGKeyring (armored public keys)
+	// and TagSSHAllowedSigners (git allowed signers file); "require" fails
+	// builds of tags without a trusted signature.
+	TagSignatureMode     string
+	TagGPGKeyring        string
+	TagSSHAllowedSigners string
+
+	// GitWebhookSecret enables POST /api/webhooks/git; pushes to the default
+	// branch and published releases enqueue GitWebhookBuilds.
+	GitWebhookSecret string
+	GitWebhookBuilds []WebhookBuild
+
+	FirmwareCacheCompression string
+	ArtifactIncludeMap       bool
+	// ArtifactExtensions lists the file name suffixes collected as artifacts.
+	ArtifactExtensions []string
+	// ArtifactNameTemplate renames firmware artifacts for download, e.g.
+	// "firmware-{device}-{shortCommit}-{buildType}.bin". Empty keeps the
+	// names PlatformIO produced.
+	ArtifactNameTemplate string
+	// WorkspaceRetention is how long a finished job's repository checkout is
+	// kept; artifacts themselves live for the full Retention window.
+	WorkspaceRetention time.Duration
+
+	ArtifactS3Endpoint  string
+	ArtifactS3Bucket    string
+	ArtifactS3Region    string
+	ArtifactS3Prefix    string
+	ArtifactS3AccessKey string
+	ArtifactS3SecretKey string
+	ArtifactS3PublicURL string
+
+	// ArtifactGitHubRepo ("owner/name") enables publishing every successful
+	// build as a GitHub Release in that repository.
+	ArtifactGitHubRepo       string
+	ArtifactGitHubToken      string
+	ArtifactGitHubAPIURL     string
+	ArtifactGitHubPrerelease bool
+
+	// LogFormat is "text" or "json"; LogLevel is the minimum level at
+	// startup, which the admin API can change at runtime.
+	LogFormat string
+	LogLevel  slog.Level
+	// AccessLog logs every API request; AccessLogSampleRate is the share of
+	// successful requests kept (failed ones are always logged).
+	AccessLog           bool
+	AccessLogSampleRate float64
+
+	// Notifications of finished builds. NotifyOn selects the outcomes
+	// ("success", "failure", "cancelled") sent to the Slack and Discord
+	// webhooks, the Telegram chat and the NotifyEmailTo addresses;
+	// NotifyPerJob lets POST /api/jobs name its own recipients, with the
+	// same Telegram bot and SMTP server.
+	NotifyOn                []string
+	NotifyPerJob            bool
+	NotifySlackWebhookURL   string
+	NotifyDiscordWebhookURL string
+	NotifyTelegramBotToken  string
+	NotifyTelegramChatID    string
+	NotifySMTPAddr          string
+	NotifySMTPUsername      string
+	NotifySMTPPassword      string
+	NotifySMTPFrom          string
+	NotifyEmailTo           []string
 }
 
 func Load() (Config, error) {
@@ -87,6 +399,32 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("APP_PLATFORMIO_JOBS must be >= 1")
 	}
 
+	buildReadOnlyRootFS, err := boolEnv("APP_BUILD_READ_ONLY_ROOTFS", false)
+	if err != nil {
+		return Config{}, err
+	}
+	buildCapDropAll, err := boolEnv("APP_BUILD_CAP_DROP_ALL", false)
+	if err != nil {
+		return Config{}, err
+	}
+	buildSeccompProfile := strings.TrimSpace(os.Getenv("APP_BUILD_SECCOMP_PROFILE"))
+	if buildSeccompProfile != "" {
+		if info, err := os.Stat(buildSeccompProfile); err != nil || info.IsDir() {
+			return Config{}, fmt.Errorf("APP_BUILD_SECCOMP_PROFILE must be a readable seccomp profile file")
+		}
+	}
+	buildUser := strings.TrimSpace(os.Getenv("APP_BUILD_USER"))
+	if buildUser != "" && !containerUserPattern.MatchString(buildUser) {
+		return Config{}, fmt.Errorf("APP_BUILD_USER must be a user or uid, optionally followed by :group or :gid")
+	}
+	buildPidsLimit, err := intEnv("APP_BUILD_PIDS_LIMIT", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if buildPidsLimit < 0 {
+		return Config{}, fmt.Errorf("APP_BUILD_PIDS_LIMIT must be >= 0")
+	}
+
 	maxLogLines, err := intEnv("APP_MAX_LOG_LINES", defaultMaxLogLines)
 	if err != nil {
 		return Config{}, err
@@ -95,6 +433,25 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("APP_MAX_LOG_LINES must be >= 100")
 	}
 
+	jobLogFiles, err := boolEnv("APP_JOB_LOG_FILES", true)
+	if err != nil {
+		return Config{}, err
+	}
+	jobLogRotateMB, err := intEnv("APP_JOB_LOG_ROTATE_MB", defaultJobLogRotateMB)
+	if err != nil {
+		return Config{}, err
+	}
+	if jobLogRotateMB < 0 {
+		return Config{}, fmt.Errorf("APP_JOB_LOG_ROTATE_MB must be >= 0")
+	}
+	jobLogMaxFiles, err := intEnv("APP_JOB_LOG_MAX_FILES", defaultJobLogMaxFiles)
+	if err != nil {
+		return Config{}, err
+	}
+	if jobLogMaxFiles < 0 {
+		return Config{}, fmt.Errorf("APP_JOB_LOG_MAX_FILES must be >= 0")
+	}
+
 	buildRateLimit, err := intEnv("APP_BUILD_RATE_LIMIT_PER_MINUTE", defaultBuildRateLimit)
 	if err != nil {
 		return Config{}, err
@@ -103,11 +460,151 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("APP_BUILD_RATE_LIMIT_PER_MINUTE must be >= 1")
 	}
 
+	anonymousBuildRateLimit, err := intEnv("APP_ANONYMOUS_BUILD_RATE_LIMIT_PER_MINUTE", min(defaultAnonymousBuildRateLimit, buildRateLimit))
+	if err != nil {
+		return Config{}, err
+	}
+	if anonymousBuildRateLimit < 1 {
+		return Config{}, fmt.Errorf("APP_ANONYMOUS_BUILD_RATE_LIMIT_PER_MINUTE must be >= 1")
+	}
+
+	buildQuotaDaily, err := intEnv("APP_BUILD_QUOTA_DAILY", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if buildQuotaDaily < 0 {
+		return Config{}, fmt.Errorf("APP_BUILD_QUOTA_DAILY must be >= 0")
+	}
+	buildQuotaWeekly, err := intEnv("APP_BUILD_QUOTA_WEEKLY", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if buildQuotaWeekly < 0 {
+		return Config{}, fmt.Errorf("APP_BUILD_QUOTA_WEEKLY must be >= 0")
+	}
+
+	abuseMinFailures, err := intEnv("APP_ABUSE_MIN_FAILURES", defaultAbuseMinFailures)
+	if err != nil {
+		return Config{}, err
+	}
+	if abuseMinFailures < 0 {
+		return Config{}, fmt.Errorf("APP_ABUSE_MIN_FAILURES must be >= 0")
+	}
+	abuseFailureRatio := defaultAbuseFailureRatio
+	if raw := strings.TrimSpace(os.Getenv("APP_ABUSE_FAILURE_RATIO")); raw != "" {
+		abuseFailureRatio, err = strconv.ParseFloat(raw, 64)
+		if err != nil || abuseFailureRatio <= 0 || abuseFailureRatio > 1 {
+			return Config{}, fmt.Errorf("APP_ABUSE_FAILURE_RATIO must be a number above 0 and at most 1")
+		}
+	}
+	abuseWindowMinutes, err := intEnv("APP_ABUSE_WINDOW_MINUTES", defaultAbuseWindowMinutes)
+	if err != nil {
+		return Config{}, err
+	}
+	if abuseWindowMinutes < 1 {
+		return Config{}, fmt.Errorf("APP_ABUSE_WINDOW_MINUTES must be >= 1")
+	}
+	abuseBuildRateLimit, err := intEnv("APP_ABUSE_BUILD_RATE_LIMIT_PER_MINUTE", defaultAbuseBuildRateLimit)
+	if err != nil {
+		return Config{}, err
+	}
+	if abuseBuildRateLimit < 1 {
+		return Config{}, fmt.Errorf("APP_ABUSE_BUILD_RATE_LIMIT_PER_MINUTE must be >= 1")
+	}
+
+	idempotencyMinutes, err := intEnv("APP_IDEMPOTENCY_WINDOW_MINUTES", defaultIdempotencyMinutes)
+	if err != nil {
+		return Config{}, err
+	}
+	if idempotencyMinutes < 0 {
+		return Config{}, fmt.Errorf("APP_IDEMPOTENCY_WINDOW_MINUTES must be >= 0")
+	}
+	idempotencyWindow := time.Duration(idempotencyMinutes) * time.Minute
+	if idempotencyWindow > time.Duration(retentionHours)*time.Hour {
+		idempotencyWindow = time.Duration(retentionHours) * time.Hour
+	}
+
+	httpAPITimeoutSeconds, err := intEnv("APP_HTTP_API_TIMEOUT_SECONDS", defaultHTTPAPITimeoutSec)
+	if err != nil {
+		return Config{}, err
+	}
+	if httpAPITimeoutSeconds < 1 {
+		return Config{}, fmt.Errorf("APP_HTTP_API_TIMEOUT_SECONDS must be >= 1")
+	}
+
+	httpSlowTimeoutSeconds, err := intEnv("APP_HTTP_SLOW_API_TIMEOUT_SECONDS", defaultHTTPSlowTimeoutSec)
+	if err != nil {
+		return Config{}, err
+	}
+	if httpSlowTimeoutSeconds < httpAPITimeoutSeconds {
+		return Config{}, fmt.Errorf("APP_HTTP_SLOW_API_TIMEOUT_SECONDS must be >= APP_HTTP_API_TIMEOUT_SECONDS")
+	}
+
+	httpStallTimeoutSeconds, err := intEnv("APP_HTTP_STALL_TIMEOUT_SECONDS", defaultHTTPStallTimeoutSec)
+	if err != nil {
+		return Config{}, err
+	}
+	if httpStallTimeoutSeconds < 20 {
+		// Event streams send a ping every 15 seconds.
+		return Config{}, fmt.Errorf("APP_HTTP_STALL_TIMEOUT_SECONDS must be >= 20")
+	}
+
 	requireCaptcha, err := boolEnv("APP_REQUIRE_CAPTCHA", defaultRequireCaptcha)
 	if err != nil {
 		return Config{}, err
 	}
 
+	captchaProvider := strings.ToLower(strings.TrimSpace(os.Getenv("APP_CAPTCHA_PROVIDER")))
+	switch captchaProvider {
+	case "":
+		captchaProvider = "math"
+	case "math", "turnstile", "hcaptcha", "recaptcha":
+	default:
+		return Config{}, fmt.Errorf("APP_CAPTCHA_PROVIDER must be one of: math, turnstile, hcaptcha, recaptcha")
+	}
+	captchaSiteKey := strings.TrimSpace(os.Getenv("APP_CAPTCHA_SITE_KEY"))
+	captchaSecretKey := strings.TrimSpace(os.Getenv("APP_CAPTCHA_SECRET_KEY"))
+	if captchaProvider != "math" && (captchaSiteKey == "" || captchaSecretKey == "") {
+		return Config{}, fmt.Errorf("APP_CAPTCHA_SITE_KEY and APP_CAPTCHA_SECRET_KEY are required for APP_CAPTCHA_PROVIDER=%s", captchaProvider)
+	}
+	captchaVerifyURL := strings.TrimSpace(os.Getenv("APP_CAPTCHA_VERIFY_URL"))
+	if captchaVerifyURL != "" && !strings.HasPrefix(captchaVerifyURL, "https://") && !strings.HasPrefix(captchaVerifyURL, "http://") {
+		return Config{}, fmt.Errorf("APP_CAPTCHA_VERIFY_URL must be an http(s) URL")
+	}
+	captchaMinScore := defaultCaptchaMinScore
+	if raw := strings.TrimSpace(os.Getenv("APP_CAPTCHA_MIN_SCORE")); raw != "" {
+		captchaMinScore, err = strconv.ParseFloat(raw, 64)
+		if err != nil || captchaMinScore < 0 || captchaMinScore > 1 {
+			return Config{}, fmt.Errorf("APP_CAPTCHA_MIN_SCORE must be a number between 0 and 1")
+		}
+	}
+	captchaImage, err := boolEnv("APP_CAPTCHA_IMAGE", false)
+	if err != nil {
+		return Config{}, err
+	}
+	captchaSessionCookie, err := boolEnv("APP_CAPTCHA_SESSION_COOKIE", false)
+	if err != nil {
+		return Config{}, err
+	}
+	powEnabled, err := boolEnv("APP_POW_ENABLED", false)
+	if err != nil {
+		return Config{}, err
+	}
+	powDifficulty, err := intEnv("APP_POW_DIFFICULTY", defaultPoWDifficulty)
+	if err != nil {
+		return Config{}, err
+	}
+	if powDifficulty < 1 || powDifficulty > 32 {
+		return Config{}, fmt.Errorf("APP_POW_DIFFICULTY must be between 1 and 32")
+	}
+	powMaxDifficulty, err := intEnv("APP_POW_MAX_DIFFICULTY", max(defaultPoWMaxDifficulty, powDifficulty))
+	if err != nil {
+		return Config{}, err
+	}
+	if powMaxDifficulty < powDifficulty || powMaxDifficulty > 32 {
+		return Config{}, fmt.Errorf("APP_POW_MAX_DIFFICULTY must be between APP_POW_DIFFICULTY and 32")
+	}
+
 	workDir := os.Getenv("APP_WORKDIR")
 	if strings.TrimSpace(workDir) == "" {
 		workDir = defaultWorkDir
@@ -137,6 +634,14 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("resolve APP_PLATFORMIO_CACHE_DIR: %w", err)
 	}
 
+	minFreeDiskMB, err := intEnv("APP_MIN_FREE_DISK_MB", defaultMinFreeDiskMB)
+	if err != nil {
+		return Config{}, err
+	}
+	if minFreeDiskMB < 0 {
+		return Config{}, fmt.Errorf("APP_MIN_FREE_DISK_MB must be >= 0")
+	}
+
 	dockerHostWorkDir := strings.TrimSpace(os.Getenv("APP_DOCKER_HOST_WORKDIR"))
 	if dockerHostWorkDir != "" {
 		if !filepath.IsAbs(dockerHostWorkDir) {
@@ -170,6 +675,13 @@ func Load() (Config, error) {
 	if builderImage == "" {
 		builderImage = defaultBuilderImage
 	}
+	dockerHealthSeconds, err := intEnv("APP_DOCKER_HEALTH_INTERVAL_SECONDS", defaultDockerHealthIntervalSec)
+	if err != nil {
+		return Config{}, err
+	}
+	if dockerHealthSeconds < 0 {
+		return Config{}, fmt.Errorf("APP_DOCKER_HEALTH_INTERVAL_SECONDS must be >= 0")
+	}
 
 	allowedOrigins := splitCSV(os.Getenv("APP_ALLOWED_ORIGINS"))
 	if len(allowedOrigins) == 0 {
@@ -177,35 +689,625 @@ func Load() (Config, error) {
 	}
 
 	statsPassword := strings.TrimSpace(os.Getenv("APP_STATS_PASSWORD"))
+	adminToken := strings.TrimSpace(os.Getenv("APP_ADMIN_TOKEN"))
+	adminClientCAs, err := certPoolEnv("APP_ADMIN_CLIENT_CA")
+	if err != nil {
+		return Config{}, err
+	}
+
+	redisURL := strings.TrimSpace(os.Getenv("APP_REDIS_URL"))
+	if redisURL != "" && !strings.HasPrefix(redisURL, "redis://") && !strings.HasPrefix(redisURL, "rediss://") && !strings.HasPrefix(redisURL, "unix://") {
+		return Config{}, fmt.Errorf("APP_REDIS_URL must be a redis://, rediss:// or unix:// URL")
+	}
+	redisKeyPrefix := os.Getenv("APP_REDIS_KEY_PREFIX")
+	if redisKeyPrefix == "" {
+		redisKeyPrefix = "mfb:"
+	}
+	jobRegistry, err := boolEnv("APP_JOB_REGISTRY", false)
+	if err != nil {
+		return Config{}, err
+	}
+	if jobRegistry && redisURL == "" {
+		return Config{}, fmt.Errorf("APP_JOB_REGISTRY requires APP_REDIS_URL")
+	}
+	nodeName := strings.TrimSpace(os.Getenv("APP_NODE_NAME"))
+	if nodeName == "" {
+		nodeName, _ = os.Hostname()
+	}
+
+	auditSinks, err := auditSinksEnv("APP_AUDIT_LOG")
+	if err != nil {
+		return Config{}, err
+	}
+	auditSyslogAddress := strings.TrimSpace(os.Getenv("APP_AUDIT_SYSLOG_ADDRESS"))
+	if auditSyslogAddress != "" && !strings.HasPrefix(auditSyslogAddress, "udp://") && !strings.HasPrefix(auditSyslogAddress, "tcp://") {
+		return Config{}, fmt.Errorf("APP_AUDIT_SYSLOG_ADDRESS must be a udp:// or tcp:// address")
+	}
+
+	secretsKey, err := secretsKeyEnv()
+	if err != nil {
+		return Config{}, err
+	}
 
 	trustProxyHeaders, err := boolEnv("APP_TRUST_PROXY_HEADERS", true)
 	if err != nil {
 		return Config{}, err
 	}
+	trustedProxies, err := prefixesEnv("APP_TRUSTED_PROXIES")
+	if err != nil {
+		return Config{}, err
+	}
+	ipAllow, err := prefixesEnv("APP_IP_ALLOW")
+	if err != nil {
+		return Config{}, err
+	}
+	ipDeny, err := prefixesEnv("APP_IP_DENY")
+	if err != nil {
+		return Config{}, err
+	}
+	anonymousRanges, err := prefixesEnv("APP_ANONYMOUS_RANGES")
+	if err != nil {
+		return Config{}, err
+	}
+	anonymousRangesFile, err := prefixesFileEnv("APP_ANONYMOUS_RANGES_FILE")
+	if err != nil {
+		return Config{}, err
+	}
+	anonymousRanges = append(anonymousRanges, anonymousRangesFile...)
+
+	gitMirrorEnabled, err := boolEnv("APP_GIT_MIRROR_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	gitMirrorMinUses, err := intEnv("APP_GIT_MIRROR_MIN_USES", defaultGitMirrorMinUses)
+	if err != nil {
+		return Config{}, err
+	}
+	if gitMirrorMinUses < 1 {
+		return Config{}, fmt.Errorf("APP_GIT_MIRROR_MIN_USES must be >= 1")
+	}
+
+	gitMirrorRefreshMinutes, err := intEnv("APP_GIT_MIRROR_REFRESH_MINUTES", defaultGitMirrorRefreshMin)
+	if err != nil {
+		return Config{}, err
+	}
+	if gitMirrorRefreshMinutes < 0 {
+		return Config{}, fmt.Errorf("APP_GIT_MIRROR_REFRESH_MINUTES must be >= 0")
+	}
+
+	cloneStrategy := strings.ToLower(strings.TrimSpace(os.Getenv("APP_CLONE_STRATEGY")))
+	switch cloneStrategy {
+	case "":
+		cloneStrategy = "shallow"
+	case "shallow", "shallow-since", "treeless", "full":
+	default:
+		return Config{}, fmt.Errorf("APP_CLONE_STRATEGY must be one of: shallow, shallow-since, treeless, full")
+	}
+	cloneShallowSince := strings.TrimSpace(os.Getenv("APP_CLONE_SHALLOW_SINCE"))
+	if cloneShallowSince == "" {
+		cloneShallowSince = defaultCloneShallowSince
+	}
+	if len(cloneShallowSince) > 64 || strings.HasPrefix(cloneShallowSince, "-") || strings.ContainsAny(cloneShallowSince, "\r\n\x00") {
+		return Config{}, fmt.Errorf("APP_CLONE_SHALLOW_SINCE must be a git date such as \"2024-01-01\" or \"6 months ago\"")
+	}
+
+	firmwareCacheCompression := strings.ToLower(strings.TrimSpace(os.Getenv("APP_FIRMWARE_CACHE_COMPRESSION")))
+	switch firmwareCacheCompression {
+	case "", "none", "off":
+		firmwareCacheCompression = ""
+	case "zstd":
+	default:
+		return Config{}, fmt.Errorf("APP_FIRMWARE_CACHE_COMPRESSION must be one of: none, zstd")
+	}
+
+	artifactIncludeMap, err := boolEnv("APP_ARTIFACT_INCLUDE_MAP", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	featuredRepos := splitCSV(os.Getenv("APP_FEATURED_REPOS"))
+	if len(featuredRepos) == 0 {
+		featuredRepos = splitCSV(defaultFeaturedRepos)
+	}
+	if len(featuredRepos) == 1 && strings.EqualFold(featuredRepos[0], "none") {
+		featuredRepos = nil
+	}
+	for _, repoURL := range featuredRepos {
+		if !strings.HasPrefix(repoURL, "https://") && !strings.HasPrefix(repoURL, "http://") {
+			return Config{}, fmt.Errorf("APP_FEATURED_REPOS must list http(s) repository URLs")
+		}
+	}
+
+	catalogRefreshMinutes, err := intEnv("APP_CATALOG_REFRESH_MINUTES", defaultCatalogRefreshMin)
+	if err != nil {
+		return Config{}, err
+	}
+	if catalogRefreshMinutes < 1 {
+		return Config{}, fmt.Errorf("APP_CATALOG_REFRESH_MINUTES must be >= 1")
+	}
+
+	catalogReleaseTags, err := intEnv("APP_CATALOG_RELEASE_TAGS", defaultCatalogReleaseTags)
+	if err != nil {
+		return Config{}, err
+	}
+	if catalogReleaseTags < 0 {
+		return Config{}, fmt.Errorf("APP_CATALOG_RELEASE_TAGS must be >= 0")
+	}
+
+	catalogWebhookURL := strings.TrimSpace(os.Getenv("APP_CATALOG_WEBHOOK_URL"))
+	if catalogWebhookURL != "" && !strings.HasPrefix(catalogWebhookURL, "https://") && !strings.HasPrefix(catalogWebhookURL, "http://") {
+		return Config{}, fmt.Errorf("APP_CATALOG_WEBHOOK_URL must be an http(s) URL")
+	}
+
+	discoveryConcurrency, err := intEnv("APP_DISCOVERY_CONCURRENCY", defaultDiscoveryConcurrent)
+	if err != nil {
+		return Config{}, err
+	}
+	if discoveryConcurrency < 1 {
+		return Config{}, fmt.Errorf("APP_DISCOVERY_CONCURRENCY must be >= 1")
+	}
+
+	discoveryQueueSize, err := intEnv("APP_DISCOVERY_QUEUE_SIZE", defaultDiscoveryQueueSize)
+	if err != nil {
+		return Config{}, err
+	}
+	if discoveryQueueSize < 0 {
+		return Config{}, fmt.Errorf("APP_DISCOVERY_QUEUE_SIZE must be >= 0")
+	}
+
+	gitNetworkConcurrency, err := intEnv("APP_GIT_NETWORK_CONCURRENCY", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if gitNetworkConcurrency < 0 {
+		return Config{}, fmt.Errorf("APP_GIT_NETWORK_CONCURRENCY must be >= 0")
+	}
+
+	gitBandwidthLimitKBps, err := intEnv("APP_GIT_BANDWIDTH_LIMIT_KBPS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if gitBandwidthLimitKBps < 0 {
+		return Config{}, fmt.Errorf("APP_GIT_BANDWIDTH_LIMIT_KBPS must be >= 0")
+	}
+
+	grpcPort, err := intEnv("APP_GRPC_PORT", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if grpcPort < 0 || grpcPort > 65535 {
+		return Config{}, fmt.Errorf("APP_GRPC_PORT must be between 0 and 65535")
+	}
+	grpcToken := strings.TrimSpace(os.Getenv("APP_GRPC_TOKEN"))
+	if grpcPort > 0 && grpcToken == "" {
+		return Config{}, fmt.Errorf("APP_GRPC_TOKEN is required when APP_GRPC_PORT is set")
+	}
+
+	tlsCertFile := strings.TrimSpace(os.Getenv("APP_TLS_CERT_FILE"))
+	tlsKeyFile := strings.TrimSpace(os.Getenv("APP_TLS_KEY_FILE"))
+	if (tlsCertFile == "") != (tlsKeyFile == "") {
+		return Config{}, fmt.Errorf("APP_TLS_CERT_FILE and APP_TLS_KEY_FILE must be set together")
+	}
+	if tlsCertFile != "" {
+		if _, err := tls.LoadX509KeyPair(tlsCertFile, tlsKeyFile); err != nil {
+			return Config{}, fmt.Errorf("APP_TLS_CERT_FILE/APP_TLS_KEY_FILE: %w", err)
+		}
+	}
+	tlsAutocertDomains := splitCSV(os.Getenv("APP_TLS_AUTOCERT_DOMAINS"))
+	if tlsCertFile != "" && len(tlsAutocertDomains) > 0 {
+		return Config{}, fmt.Errorf("APP_TLS_AUTOCERT_DOMAINS cannot be combined with APP_TLS_CERT_FILE")
+	}
+	tlsRedirectPort, err := intEnv("APP_TLS_REDIRECT_PORT", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if tlsRedirectPort < 0 || tlsRedirectPort > 65535 {
+		return Config{}, fmt.Errorf("APP_TLS_REDIRECT_PORT must be between 0 and 65535")
+	}
+	if tlsRedirectPort > 0 && (tlsRedirectPort == port || tlsCertFile == "" && len(tlsAutocertDomains) == 0) {
+		return Config{}, fmt.Errorf("APP_TLS_REDIRECT_PORT requires TLS on a different APP_PORT")
+	}
+
+	oidcIssuer := strings.TrimRight(strings.TrimSpace(os.Getenv("APP_OIDC_ISSUER")), "/")
+	switch strings.ToLower(oidcIssuer) {
+	case "":
+	case "github":
+		oidcIssuer = "github"
+	case "google":
+		oidcIssuer = "https://accounts.google.com"
+	default:
+		if !strings.HasPrefix(oidcIssuer, "https://") && !strings.HasPrefix(oidcIssuer, "http://") {
+			return Config{}, fmt.Errorf("APP_OIDC_ISSUER must be github, google or an http(s) issuer URL")
+		}
+	}
+	oidcClientID := strings.TrimSpace(os.Getenv("APP_OIDC_CLIENT_ID"))
+	oidcClientSecret := strings.TrimSpace(os.Getenv("APP_OIDC_CLIENT_SECRET"))
+	oidcRedirectURL := strings.TrimSpace(os.Getenv("APP_OIDC_REDIRECT_URL"))
+	oidcScopes := splitCSV(os.Getenv("APP_OIDC_SCOPES"))
+	if oidcIssuer != "" {
+		if oidcClientID == "" || oidcClientSecret == "" {
+			return Config{}, fmt.Errorf("APP_OIDC_CLIENT_ID and APP_OIDC_CLIENT_SECRET are required when APP_OIDC_ISSUER is set")
+		}
+		if !strings.HasPrefix(oidcRedirectURL, "https://") && !strings.HasPrefix(oidcRedirectURL, "http://") {
+			return Config{}, fmt.Errorf("APP_OIDC_REDIRECT_URL must be the http(s) URL of /api/auth/callback")
+		}
+		if len(oidcScopes) == 0 {
+			if oidcIssuer == "github" {
+				oidcScopes = splitCSV(defaultGitHubOAuthScopes)
+			} else {
+				oidcScopes = splitCSV(defaultOIDCScopes)
+			}
+		}
+	}
+	oidcSessionHours, err := intEnv("APP_OIDC_SESSION_HOURS", defaultOIDCSessionHours)
+	if err != nil {
+		return Config{}, err
+	}
+	if oidcSessionHours < 1 {
+		return Config{}, fmt.Errorf("APP_OIDC_SESSION_HOURS must be >= 1")
+	}
+
+	deviceAllow, err := deviceRulesEnv("APP_DEVICE_ALLOW")
+	if err != nil {
+		return Config{}, err
+	}
+	deviceDeny, err := deviceRulesEnv("APP_DEVICE_DENY")
+	if err != nil {
+		return Config{}, err
+	}
+
+	tagSignatureMode := strings.ToLower(strings.TrimSpace(os.Getenv("APP_TAG_SIGNATURE_MODE")))
+	switch tagSignatureMode {
+	case "", "off", "none":
+		tagSignatureMode = ""
+	case "record", "require":
+	default:
+		return Config{}, fmt.Errorf("APP_TAG_SIGNATURE_MODE must be one of: off, record, require")
+	}
+	tagGPGKeyring := strings.TrimSpace(os.Getenv("APP_TAG_GPG_KEYRING"))
+	tagSSHAllowedSigners := strings.TrimSpace(os.Getenv("APP_TAG_SSH_ALLOWED_SIGNERS"))
+	if tagSignatureMode != "" && tagGPGKeyring == "" && tagSSHAllowedSigners == "" && secretsKey == "" {
+		return Config{}, fmt.Errorf("APP_TAG_SIGNATURE_MODE requires APP_TAG_GPG_KEYRING, APP_TAG_SSH_ALLOWED_SIGNERS or signing keys in the credential store (APP_SECRETS_KEY)")
+	}
+	if tagGPGKeyring != "" {
+		if _, err := os.Stat(tagGPGKeyring); err != nil {
+			return Config{}, fmt.Errorf("APP_TAG_GPG_KEYRING must point to a readable file: %w", err)
+		}
+	}
+	if tagSSHAllowedSigners != "" {
+		if _, err := os.Stat(tagSSHAllowedSigners); err != nil {
+			return Config{}, fmt.Errorf("APP_TAG_SSH_ALLOWED_SIGNERS must point to a readable file: %w", err)
+		}
+	}
+
+	allowedRepoHosts, err := repoHostsEnv("APP_ALLOWED_REPO_HOSTS")
+	if err != nil {
+		return Config{}, err
+	}
+	moderateAnonymousBuilds, err := boolEnv("APP_MODERATE_ANONYMOUS_BUILDS", false)
+	if err != nil {
+		return Config{}, err
+	}
+	moderationAllowedRepos, err := repoHostsEnv("APP_MODERATION_ALLOWED_REPOS")
+	if err != nil {
+		return Config{}, err
+	}
+
+	gitWebhookSecret := strings.TrimSpace(os.Getenv("APP_GIT_WEBHOOK_SECRET"))
+	gitWebhookBuilds, err := webhookBuildsEnv("APP_GIT_WEBHOOK_BUILDS")
+	if err != nil {
+		return Config{}, err
+	}
+	if len(gitWebhookBuilds) > 0 && gitWebhookSecret == "" && secretsKey == "" {
+		return Config{}, fmt.Errorf("APP_GIT_WEBHOOK_BUILDS requires APP_GIT_WEBHOOK_SECRET or webhook secrets in the credential store (APP_SECRETS_KEY)")
+	}
+
+	artifactExtensions := splitCSV(os.Getenv("APP_ARTIFACT_EXTENSIONS"))
+	if len(artifactExtensions) == 0 {
+		artifactExtensions = splitCSV(defaultArtifactExtensions)
+	}
+	for index, extension := range artifactExtensions {
+		extension = strings.ToLower(extension)
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		if strings.ContainsAny(extension, "/\\*?[") {
+			return Config{}, fmt.Errorf("APP_ARTIFACT_EXTENSIONS entry %q is invalid", artifactExtensions[index])
+		}
+		artifactExtensions[index] = extension
+	}
+
+	artifactNameTemplate := strings.TrimSpace(os.Getenv("APP_ARTIFACT_NAME_TEMPLATE"))
+	if err := validateArtifactNameTemplate(artifactNameTemplate); err != nil {
+		return Config{}, err
+	}
+
+	workspaceRetentionMinutes, err := intEnv("APP_WORKSPACE_RETENTION_MINUTES", defaultWorkspaceRetentionMin)
+	if err != nil {
+		return Config{}, err
+	}
+	if workspaceRetentionMinutes < 0 {
+		return Config{}, fmt.Errorf("APP_WORKSPACE_RETENTION_MINUTES must be >= 0")
+	}
+	workspaceRetention := time.Duration(workspaceRetentionMinutes) * time.Minute
+	if workspaceRetention > time.Duration(retentionHours)*time.Hour {
+		workspaceRetention = time.Duration(retentionHours) * time.Hour
+	}
+
+	cleanupInterval := time.Hour
+	if workspaceRetention > 0 && workspaceRetention < cleanupInterval {
+		cleanupInterval = workspaceRetention
+	}
+
+	artifactS3Endpoint := strings.TrimRight(strings.TrimSpace(os.Getenv("APP_ARTIFACT_S3_ENDPOINT")), "/")
+	artifactS3Bucket := strings.TrimSpace(os.Getenv("APP_ARTIFACT_S3_BUCKET"))
+	if artifactS3Bucket != "" {
+		if artifactS3Endpoint == "" {
+			artifactS3Endpoint = defaultArtifactS3Endpoint
+		}
+		if !strings.HasPrefix(artifactS3Endpoint, "https://") && !strings.HasPrefix(artifactS3Endpoint, "http://") {
+			return Config{}, fmt.Errorf("APP_ARTIFACT_S3_ENDPOINT must be an http(s) URL")
+		}
+	}
+	artifactS3Region := strings.TrimSpace(os.Getenv("APP_ARTIFACT_S3_REGION"))
+	if artifactS3Region == "" {
+		artifactS3Region = defaultArtifactS3Region
+	}
+	artifactS3Prefix := strings.TrimLeft(strings.TrimSpace(os.Getenv("APP_ARTIFACT_S3_PREFIX")), "/")
+	if artifactS3Prefix != "" && !strings.HasSuffix(artifactS3Prefix, "/") {
+		artifactS3Prefix += "/"
+	}
+
+	artifactGitHubRepo := strings.Trim(strings.TrimSpace(os.Getenv("APP_ARTIFACT_GITHUB_REPO")), "/")
+	artifactGitHubToken := strings.TrimSpace(os.Getenv("APP_ARTIFACT_GITHUB_TOKEN"))
+	artifactGitHubAPIURL := strings.TrimRight(strings.TrimSpace(os.Getenv("APP_ARTIFACT_GITHUB_API_URL")), "/")
+	if artifactGitHubAPIURL == "" {
+		artifactGitHubAPIURL = defaultArtifactGitHubAPIURL
+	}
+	if artifactGitHubRepo != "" {
+		if owner, name, ok := strings.Cut(artifactGitHubRepo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return Config{}, fmt.Errorf("APP_ARTIFACT_GITHUB_REPO must look like owner/name")
+		}
+		if artifactGitHubToken == "" {
+			return Config{}, fmt.Errorf("APP_ARTIFACT_GITHUB_TOKEN is required when APP_ARTIFACT_GITHUB_REPO is set")
+		}
+		if !strings.HasPrefix(artifactGitHubAPIURL, "https://") && !strings.HasPrefix(artifactGitHubAPIURL, "http://") {
+			return Config{}, fmt.Errorf("APP_ARTIFACT_GITHUB_API_URL must be an http(s) URL")
+		}
+	}
+	artifactGitHubPrerelease, err := boolEnv("APP_ARTIFACT_GITHUB_PRERELEASE", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	logFormat := strings.ToLower(strings.TrimSpace(os.Getenv("APP_LOG_FORMAT")))
+	switch logFormat {
+	case "":
+		logFormat = "text"
+	case "text", "json":
+	default:
+		return Config{}, fmt.Errorf("APP_LOG_FORMAT must be one of: text, json")
+	}
+	var logLevel slog.Level
+	if raw := strings.TrimSpace(os.Getenv("APP_LOG_LEVEL")); raw != "" {
+		if err := logLevel.UnmarshalText([]byte(raw)); err != nil {
+			return Config{}, fmt.Errorf("APP_LOG_LEVEL must be one of: debug, info, warn, error")
+		}
+	}
+	accessLog, err := boolEnv("APP_ACCESS_LOG", true)
+	if err != nil {
+		return Config{}, err
+	}
+	accessLogSampleRate := 1.0
+	if raw := strings.TrimSpace(os.Getenv("APP_ACCESS_LOG_SAMPLE_RATE")); raw != "" {
+		accessLogSampleRate, err = strconv.ParseFloat(raw, 64)
+		if err != nil || accessLogSampleRate < 0 || accessLogSampleRate > 1 {
+			return Config{}, fmt.Errorf("APP_ACCESS_LOG_SAMPLE_RATE must be a number from 0 to 1")
+		}
+	}
+
+	notifyOn := []string{"success", "failure"}
+	if raw := strings.TrimSpace(os.Getenv("APP_NOTIFY_ON")); raw != "" {
+		notifyOn = splitCSV(strings.ToLower(raw))
+		for _, outcome := range notifyOn {
+			if outcome != "success" && outcome != "failure" && outcome != "cancelled" {
+				return Config{}, fmt.Errorf("APP_NOTIFY_ON must list: success, failure, cancelled")
+			}
+		}
+	}
+	notifyPerJob, err := boolEnv("APP_NOTIFY_PER_JOB", false)
+	if err != nil {
+		return Config{}, err
+	}
+	notifySlackWebhookURL := strings.TrimSpace(os.Getenv("APP_NOTIFY_SLACK_WEBHOOK_URL"))
+	if notifySlackWebhookURL != "" && !strings.HasPrefix(notifySlackWebhookURL, "https://") && !strings.HasPrefix(notifySlackWebhookURL, "http://") {
+		return Config{}, fmt.Errorf("APP_NOTIFY_SLACK_WEBHOOK_URL must be an http(s) URL")
+	}
+	notifyDiscordWebhookURL := strings.TrimSpace(os.Getenv("APP_NOTIFY_DISCORD_WEBHOOK_URL"))
+	if notifyDiscordWebhookURL != "" && !strings.HasPrefix(notifyDiscordWebhookURL, "https://") && !strings.HasPrefix(notifyDiscordWebhookURL, "http://") {
+		return Config{}, fmt.Errorf("APP_NOTIFY_DISCORD_WEBHOOK_URL must be an http(s) URL")
+	}
+	notifyTelegramBotToken := strings.TrimSpace(os.Getenv("APP_NOTIFY_TELEGRAM_BOT_TOKEN"))
+	notifyTelegramChatID := strings.TrimSpace(os.Getenv("APP_NOTIFY_TELEGRAM_CHAT_ID"))
+	if notifyTelegramChatID != "" && notifyTelegramBotToken == "" {
+		return Config{}, fmt.Errorf("APP_NOTIFY_TELEGRAM_CHAT_ID requires APP_NOTIFY_TELEGRAM_BOT_TOKEN")
+	}
+	notifySMTPAddr := strings.TrimSpace(os.Getenv("APP_NOTIFY_SMTP_ADDR"))
+	notifySMTPFrom := strings.TrimSpace(os.Getenv("APP_NOTIFY_SMTP_FROM"))
+	notifyEmailTo := splitCSV(os.Getenv("APP_NOTIFY_EMAIL_TO"))
+	if notifySMTPAddr != "" {
+		if _, _, err := net.SplitHostPort(notifySMTPAddr); err != nil {
+			return Config{}, fmt.Errorf("APP_NOTIFY_SMTP_ADDR must be host:port")
+		}
+		if _, err := mail.ParseAddress(notifySMTPFrom); err != nil {
+			return Config{}, fmt.Errorf("APP_NOTIFY_SMTP_FROM must be an email address when APP_NOTIFY_SMTP_ADDR is set")
+		}
+	} else if len(notifyEmailTo) > 0 {
+		return Config{}, fmt.Errorf("APP_NOTIFY_EMAIL_TO requires APP_NOTIFY_SMTP_ADDR")
+	}
+	for _, address := range notifyEmailTo {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return Config{}, fmt.Errorf("APP_NOTIFY_EMAIL_TO has an invalid address %q", address)
+		}
+	}
 
 	return Config{
-		Port:              port,
-		WorkDir:           workDir,
-		DockerHostWorkDir: dockerHostWorkDir,
-		ConcurrentBuilds:  concurrentBuilds,
-		Retention:         time.Duration(retentionHours) * time.Hour,
-		BuildTimeout:      time.Duration(buildTimeoutMinutes) * time.Minute,
-		BuilderImage:      builderImage,
-		PlatformIOJobs:    platformIOJobs,
-		AllowedOrigins:    allowedOrigins,
-		PlatformIOCache:   platformIOCache,
-		DockerHostCache:   dockerHostCache,
-		MaxLogLines:       maxLogLines,
-		BuildRateLimit:    buildRateLimit,
-		RequireCaptcha:    requireCaptcha,
-		CleanupInterval:   time.Hour,
-		DiscoveryRootPath: discoveryRoot,
-		JobsRootPath:      jobsRoot,
-		FirmwareCachePath: firmwareCachePath,
-		StatsPassword:     statsPassword,
-		StatsFilePath:     filepath.Join(workDir, "stats.jsonl"),
-		BuildLogsPath:     filepath.Join(workDir, "build-logs"),
-		TrustProxyHeaders: trustProxyHeaders,
+		Port:                    port,
+		WorkDir:                 workDir,
+		DockerHostWorkDir:       dockerHostWorkDir,
+		ConcurrentBuilds:        concurrentBuilds,
+		Retention:               time.Duration(retentionHours) * time.Hour,
+		BuildTimeout:            time.Duration(buildTimeoutMinutes) * time.Minute,
+		BuilderImage:            builderImage,
+		DockerHealthInterval:    time.Duration(dockerHealthSeconds) * time.Second,
+		PlatformIOJobs:          platformIOJobs,
+		BuildReadOnlyRootFS:     buildReadOnlyRootFS,
+		BuildCapDropAll:         buildCapDropAll,
+		BuildSeccompProfile:     buildSeccompProfile,
+		BuildUser:               buildUser,
+		BuildPidsLimit:          buildPidsLimit,
+		AllowedOrigins:          allowedOrigins,
+		PlatformIOCache:         platformIOCache,
+		DockerHostCache:         dockerHostCache,
+		MaxLogLines:             maxLogLines,
+		JobLogFiles:             jobLogFiles,
+		JobLogRotateMB:          jobLogRotateMB,
+		JobLogMaxFiles:          jobLogMaxFiles,
+		BuildRateLimit:          buildRateLimit,
+		BuildQuotaDaily:         buildQuotaDaily,
+		BuildQuotaWeekly:        buildQuotaWeekly,
+		AbuseMinFailures:        abuseMinFailures,
+		AbuseFailureRatio:       abuseFailureRatio,
+		AbuseWindow:             time.Duration(abuseWindowMinutes) * time.Minute,
+		AbuseBuildRateLimit:     abuseBuildRateLimit,
+		IdempotencyWindow:       idempotencyWindow,
+		RedisURL:                redisURL,
+		RedisKeyPrefix:          redisKeyPrefix,
+		JobRegistry:             jobRegistry,
+		NodeName:                nodeName,
+		RequireCaptcha:          requireCaptcha,
+		CleanupInterval:         cleanupInterval,
+		CaptchaProvider:         captchaProvider,
+		CaptchaSiteKey:          captchaSiteKey,
+		CaptchaSecretKey:        captchaSecretKey,
+		CaptchaVerifyURL:        captchaVerifyURL,
+		CaptchaMinScore:         captchaMinScore,
+		CaptchaImage:            captchaImage,
+		CaptchaSessionCookie:    captchaSessionCookie,
+		PoWEnabled:              powEnabled,
+		PoWDifficulty:           powDifficulty,
+		PoWMaxDifficulty:        powMaxDifficulty,
+		DiscoveryRootPath:       discoveryRoot,
+		JobsRootPath:            jobsRoot,
+		FirmwareCachePath:       firmwareCachePath,
+		MinFreeDiskMB:           minFreeDiskMB,
+		StatsPassword:           statsPassword,
+		AdminToken:              adminToken,
+		AdminClientCAs:          adminClientCAs,
+		StatsFilePath:           filepath.Join(workDir, "stats.jsonl"),
+		BuildLogsPath:           filepath.Join(workDir, "build-logs"),
+		AuditSinks:              auditSinks,
+		AuditLogPath:            filepath.Join(workDir, "audit.jsonl"),
+		AuditSyslogAddress:      auditSyslogAddress,
+		SecretsKey:              secretsKey,
+		SecretsPath:             filepath.Join(workDir, "secrets.json"),
+		TrustProxyHeaders:       trustProxyHeaders,
+		TrustedProxies:          trustedProxies,
+		IPAllow:                 ipAllow,
+		IPDeny:                  ipDeny,
+		AnonymousRanges:         anonymousRanges,
+		AnonymousBuildRateLimit: anonymousBuildRateLimit,
+		GitMirrorEnabled:        gitMirrorEnabled,
+		GitMirrorPath:           filepath.Join(workDir, "mirrors"),
+		GitMirrorMinUses:        gitMirrorMinUses,
+		GitMirrorRefresh:        time.Duration(gitMirrorRefreshMinutes) * time.Minute,
+
+		CloneStrategy:     cloneStrategy,
+		CloneShallowSince: cloneShallowSince,
+
+		DiscoveryConcurrency: discoveryConcurrency,
+		DiscoveryQueueSize:   discoveryQueueSize,
+
+		GitNetworkConcurrency: gitNetworkConcurrency,
+		GitBandwidthLimit:     int64(gitBandwidthLimitKBps) * 1024,
+
+		GRPCPort:  grpcPort,
+		GRPCToken: grpcToken,
+
+		HTTPAPITimeout:     time.Duration(httpAPITimeoutSeconds) * time.Second,
+		HTTPSlowAPITimeout: time.Duration(httpSlowTimeoutSeconds) * time.Second,
+		HTTPStallTimeout:   time.Duration(httpStallTimeoutSeconds) * time.Second,
+
+		TLSCertFile:         tlsCertFile,
+		TLSKeyFile:          tlsKeyFile,
+		TLSAutocertDomains:  tlsAutocertDomains,
+		TLSAutocertEmail:    strings.TrimSpace(os.Getenv("APP_TLS_AUTOCERT_EMAIL")),
+		TLSAutocertCacheDir: filepath.Join(workDir, "autocert"),
+		TLSRedirectPort:     tlsRedirectPort,
+
+		OIDCIssuer:       oidcIssuer,
+		OIDCClientID:     oidcClientID,
+		OIDCClientSecret: oidcClientSecret,
+		OIDCRedirectURL:  oidcRedirectURL,
+		OIDCScopes:       oidcScopes,
+		OIDCSessionTTL:   time.Duration(oidcSessionHours) * time.Hour,
+
+		FeaturedRepos:      featuredRepos,
+		CatalogRefresh:     time.Duration(catalogRefreshMinutes) * time.Minute,
+		CatalogReleaseTags: catalogReleaseTags,
+		CatalogWebhookURL:  catalogWebhookURL,
+
+		DeviceAllow: deviceAllow,
+		DeviceDeny:  deviceDeny,
+
+		AllowedRepoHosts: allowedRepoHosts,
+
+		ModerateAnonymousBuilds: moderateAnonymousBuilds,
+		ModerationAllowedRepos:  moderationAllowedRepos,
+
+		TagSignatureMode:     tagSignatureMode,
+		TagGPGKeyring:        tagGPGKeyring,
+		TagSSHAllowedSigners: tagSSHAllowedSigners,
+
+		GitWebhookSecret: gitWebhookSecret,
+		GitWebhookBuilds: gitWebhookBuilds,
+
+		FirmwareCacheCompression: firmwareCacheCompression,
+		ArtifactIncludeMap:       artifactIncludeMap,
+		ArtifactExtensions:       artifactExtensions,
+		ArtifactNameTemplate:     artifactNameTemplate,
+		WorkspaceRetention:       workspaceRetention,
+
+		ArtifactS3Endpoint:  artifactS3Endpoint,
+		ArtifactS3Bucket:    artifactS3Bucket,
+		ArtifactS3Region:    artifactS3Region,
+		ArtifactS3Prefix:    artifactS3Prefix,
+		ArtifactS3AccessKey: strings.TrimSpace(os.Getenv("APP_ARTIFACT_S3_ACCESS_KEY")),
+		ArtifactS3SecretKey: strings.TrimSpace(os.Getenv("APP_ARTIFACT_S3_SECRET_KEY")),
+		ArtifactS3PublicURL: strings.TrimRight(strings.TrimSpace(os.Getenv("APP_ARTIFACT_S3_PUBLIC_URL")), "/"),
+
+		ArtifactGitHubRepo:       artifactGitHubRepo,
+		ArtifactGitHubToken:      artifactGitHubToken,
+		ArtifactGitHubAPIURL:     artifactGitHubAPIURL,
+		ArtifactGitHubPrerelease: artifactGitHubPrerelease,
+
+		LogFormat:           logFormat,
+		LogLevel:            logLevel,
+		AccessLog:           accessLog,
+		AccessLogSampleRate: accessLogSampleRate,
+
+		NotifyOn:                notifyOn,
+		NotifyPerJob:            notifyPerJob,
+		NotifySlackWebhookURL:   notifySlackWebhookURL,
+		NotifyDiscordWebhookURL: notifyDiscordWebhookURL,
+		NotifyTelegramBotToken:  notifyTelegramBotToken,
+		NotifyTelegramChatID:    notifyTelegramChatID,
+		NotifySMTPAddr:          notifySMTPAddr,
+		NotifySMTPUsername:      strings.TrimSpace(os.Getenv("APP_NOTIFY_SMTP_USERNAME")),
+		NotifySMTPPassword:      os.Getenv("APP_NOTIFY_SMTP_PASSWORD"),
+		NotifySMTPFrom:          notifySMTPFrom,
+		NotifyEmailTo:           notifyEmailTo,
 	}, nil
 }
 
@@ -225,6 +1327,56 @@ func boolEnv(key string, fallback bool) (bool, error) {
 	}
 }
 
+// containerUserPattern matches the docker --user forms "name", "uid",
+// "name:group" and "uid:gid".
+var containerUserPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*(:[A-Za-z0-9_][A-Za-z0-9_.-]*)?$`)
+
+// artifactNamePlaceholders are the variables understood by
+// APP_ARTIFACT_NAME_TEMPLATE.
+var artifactNamePlaceholders = map[string]bool{
+	"device":      true,
+	"ref":         true,
+	"commit":      true,
+	"shortCommit": true,
+	"version":     true,
+	"buildType":   true,
+	"jobId":       true,
+	"name":        true,
+}
+
+func validateArtifactNameTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	if strings.ContainsAny(template, "/\\") {
+		return fmt.Errorf("APP_ARTIFACT_NAME_TEMPLATE must be a file name, not a path")
+	}
+
+	rest := template
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		if strings.Contains(rest[:start], "}") {
+			return fmt.Errorf("APP_ARTIFACT_NAME_TEMPLATE has an unmatched }")
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return fmt.Errorf("APP_ARTIFACT_NAME_TEMPLATE has an unterminated placeholder")
+		}
+		name := rest[start+1 : start+end]
+		if !artifactNamePlaceholders[name] {
+			return fmt.Errorf("APP_ARTIFACT_NAME_TEMPLATE uses unknown placeholder {%s}", name)
+		}
+		rest = rest[start+end+1:]
+	}
+	if strings.Contains(rest, "}") {
+		return fmt.Errorf("APP_ARTIFACT_NAME_TEMPLATE has an unmatched }")
+	}
+	return nil
+}
+
 func intEnv(key string, fallback int) (int, error) {
 	raw := strings.TrimSpace(os.Getenv(key))
 	if raw == "" {
@@ -237,6 +1389,192 @@ func intEnv(key string, fallback int) (int, error) {
 	return value, nil
 }
 
+// deviceRulesEnv parses comma-separated "pattern" or "repo=pattern" entries.
+func deviceRulesEnv(key string) ([]DeviceRule, error) {
+	entries := splitCSV(os.Getenv(key))
+	rules := make([]DeviceRule, 0, len(entries))
+	for _, entry := range entries {
+		rule := DeviceRule{Pattern: entry}
+		if repo, pattern, ok := strings.Cut(entry, "="); ok {
+			rule = DeviceRule{Repo: strings.TrimSpace(repo), Pattern: strings.TrimSpace(pattern)}
+			if rule.Repo == "" {
+				return nil, fmt.Errorf("%s entry %q has an empty repository", key, entry)
+			}
+			if _, err := path.Match(rule.Repo, ""); err != nil {
+				return nil, fmt.Errorf("%s entry %q has an invalid repository pattern", key, entry)
+			}
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("%s entry %q has an empty device pattern", key, entry)
+		}
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s entry %q has an invalid device pattern", key, entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// repoHostsEnv parses comma-separated "host" or "host/owner" globs,
+// e.g. "github.com,gitlab.com/meshtastic".
+func repoHostsEnv(key string) ([]string, error) {
+	entries := splitCSV(os.Getenv(key))
+	hosts := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		host := strings.Trim(strings.ToLower(entry), "/")
+		if host == "" || strings.Contains(host, "://") || strings.Contains(host, "@") {
+			return nil, fmt.Errorf("%s entry %q must be a host or host/owner", key, entry)
+		}
+		if _, err := path.Match(host, ""); err != nil {
+			return nil, fmt.Errorf("%s entry %q is not a valid glob", key, entry)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// secretsKeyEnv reads the credential store key from APP_SECRETS_KEY or, for
+// keys provisioned by a KMS or secret manager as a file, from
+// APP_SECRETS_KEY_FILE. The key must decode to 32 bytes.
+func secretsKeyEnv() (string, error) {
+	key := strings.TrimSpace(os.Getenv("APP_SECRETS_KEY"))
+	if path := strings.TrimSpace(os.Getenv("APP_SECRETS_KEY_FILE")); path != "" {
+		if key != "" {
+			return "", fmt.Errorf("APP_SECRETS_KEY cannot be combined with APP_SECRETS_KEY_FILE")
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("APP_SECRETS_KEY_FILE must point to a readable file: %w", err)
+		}
+		key = strings.TrimSpace(string(content))
+	}
+	if key == "" {
+		return "", nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 32 {
+		return "", fmt.Errorf("APP_SECRETS_KEY must be 32 bytes encoded as base64")
+	}
+	return key, nil
+}
+
+// auditSinksEnv parses the comma-separated audit sinks of key, "file" by
+// default; "off" disables the audit log.
+func auditSinksEnv(key string) ([]string, error) {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	switch value {
+	case "":
+		return []string{"file"}, nil
+	case "off", "none":
+		return nil, nil
+	}
+
+	var sinks []string
+	for _, sink := range splitCSV(value) {
+		if sink != "file" && sink != "syslog" {
+			return nil, fmt.Errorf("%s entry %q must be file or syslog", key, sink)
+		}
+		if !slices.Contains(sinks, sink) {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks, nil
+}
+
+// certPoolEnv loads the PEM certificates from the file named by key, or
+// returns nil when key is unset.
+func certPoolEnv(key string) (*x509.CertPool, error) {
+	path := strings.TrimSpace(os.Getenv(key))
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s must contain PEM certificates", key)
+	}
+	return pool, nil
+}
+
+// prefixesEnv parses comma-separated IPs and CIDRs, e.g.
+// "127.0.0.1,10.0.0.0/8,fd00::/8".
+func prefixesEnv(key string) ([]netip.Prefix, error) {
+	prefixes, err := ParsePrefixes(splitCSV(os.Getenv(key)))
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", key, err)
+	}
+	return prefixes, nil
+}
+
+// prefixesFileEnv parses the file named by key: one IP or CIDR per line,
+// with blank lines and "#" comments ignored, as in published Tor exit lists.
+func prefixesFileEnv(key string) ([]netip.Prefix, error) {
+	path := strings.TrimSpace(os.Getenv(key))
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	prefixes, err := ParsePrefixes(entries)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", key, err)
+	}
+	return prefixes, nil
+}
+
+// ParsePrefixes parses IPs and CIDRs. A bare IP matches that single
+// address, and IPv4-mapped IPv6 entries are stored as IPv4.
+func ParsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q must be an IP or CIDR", entry)
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// webhookBuildsEnv parses comma-separated "repo=device" entries.
+func webhookBuildsEnv(key string) ([]WebhookBuild, error) {
+	entries := splitCSV(os.Getenv(key))
+	builds := make([]WebhookBuild, 0, len(entries))
+	for _, entry := range entries {
+		repo, device, ok := strings.Cut(entry, "=")
+		build := WebhookBuild{Repo: strings.TrimSpace(repo), Device: strings.TrimSpace(device)}
+		if !ok || build.Repo == "" || build.Device == "" {
+			return nil, fmt.Errorf("%s entry %q must be repo=device", key, entry)
+		}
+		if _, err := path.Match(build.Repo, ""); err != nil {
+			return nil, fmt.Errorf("%s entry %q has an invalid repository pattern", key, entry)
+		}
+		builds = append(builds, build)
+	}
+	return builds, nil
+}
+
 func splitCSV(raw string) []string {
 	if strings.TrimSpace(raw) == "" {
 		return nil
@@ -259,3 +1597,31 @@ func ensureDir(path string) error {
 	}
 	return nil
 }
+
+// Redacted returns a copy of c with secrets replaced, for config inspection
+// through the admin API.
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{
+		&c.StatsPassword,
+		&c.AdminToken,
+		&c.CaptchaSecretKey,
+		&c.GRPCToken,
+		&c.CatalogWebhookURL,
+		&c.GitWebhookSecret,
+		&c.OIDCClientSecret,
+		&c.ArtifactS3AccessKey,
+		&c.ArtifactS3SecretKey,
+		&c.ArtifactGitHubToken,
+		&c.RedisURL,
+		&c.SecretsKey,
+		&c.NotifySlackWebhookURL,
+		&c.NotifyDiscordWebhookURL,
+		&c.NotifyTelegramBotToken,
+		&c.NotifySMTPPassword,
+	} {
+		if *secret != "" {
+			*secret = redactedValue
+		}
+	}
+	return c
+}
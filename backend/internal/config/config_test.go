@@ -1,8 +1,15 @@
 package config
 
 import (
+	"log/slog"
+	"net/netip"
+	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadDefaults(t *testing.T) {
@@ -12,6 +19,7 @@ func TestLoadDefaults(t *testing.T) {
 	t.Setenv("APP_REQUIRE_CAPTCHA", "")
 	t.Setenv("APP_PORT", "")
 	t.Setenv("APP_CONCURRENT_BUILDS", "")
+	t.Setenv("APP_GIT_MIRROR_ENABLED", "")
 
 	cfg, err := Load()
 	if err != nil {
@@ -30,6 +38,9 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.StatsPassword != "" {
 		t.Fatalf("expected empty stats password by default")
 	}
+	if !cfg.GitMirrorEnabled {
+		t.Fatalf("expected git mirrors enabled by default")
+	}
 
 	absWorkdir, err := filepath.Abs(workdir)
 	if err != nil {
@@ -116,6 +127,65 @@ func TestBoolEnv(t *testing.T) {
 	}
 }
 
+func TestLoadArtifactRetentionSettings(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_WORKSPACE_RETENTION_MINUTES", "15")
+	t.Setenv("APP_ARTIFACT_S3_BUCKET", "firmware")
+	t.Setenv("APP_ARTIFACT_S3_ENDPOINT", "")
+	t.Setenv("APP_ARTIFACT_S3_PREFIX", "/builds")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.WorkspaceRetention != 15*time.Minute {
+		t.Fatalf("expected workspace retention 15m, got %s", cfg.WorkspaceRetention)
+	}
+	if cfg.CleanupInterval != 15*time.Minute {
+		t.Fatalf("cleanup must run at least as often as workspace retention, got %s", cfg.CleanupInterval)
+	}
+	if cfg.ArtifactS3Endpoint != defaultArtifactS3Endpoint || cfg.ArtifactS3Prefix != "builds/" {
+		t.Fatalf("unexpected S3 settings: endpoint=%q prefix=%q", cfg.ArtifactS3Endpoint, cfg.ArtifactS3Prefix)
+	}
+}
+
+func TestLoadArtifactExtensions(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_ARTIFACT_EXTENSIONS", "bin, .ELF.sym ,.uf2")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := strings.Join(cfg.ArtifactExtensions, ","); got != ".bin,.elf.sym,.uf2" {
+		t.Fatalf("unexpected artifact extensions: got=%s want=.bin,.elf.sym,.uf2", got)
+	}
+
+	t.Setenv("APP_ARTIFACT_EXTENSIONS", "*.bin")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for wildcard artifact extension")
+	}
+}
+
+func TestValidateArtifactNameTemplate(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{"", "firmware-{device}-{shortCommit}-{buildType}.bin", "{name}-{version}"}
+	for _, template := range valid {
+		if err := validateArtifactNameTemplate(template); err != nil {
+			t.Fatalf("template %q must be valid: %v", template, err)
+		}
+	}
+
+	invalid := []string{"{device}/{name}", "firmware-{board}.bin", "firmware-{device", "firmware}-{device}"}
+	for _, template := range invalid {
+		if err := validateArtifactNameTemplate(template); err == nil {
+			t.Fatalf("template %q must be rejected", template)
+		}
+	}
+}
+
 func TestSplitCSV(t *testing.T) {
 	t.Parallel()
 
@@ -127,3 +197,992 @@ func TestSplitCSV(t *testing.T) {
 		t.Fatalf("unexpected values: %v", got)
 	}
 }
+
+func TestLoadArtifactGitHubSettings(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_ARTIFACT_GITHUB_REPO", "acme/firmware-builds")
+	t.Setenv("APP_ARTIFACT_GITHUB_TOKEN", "")
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error when the GitHub token is missing")
+	}
+
+	t.Setenv("APP_ARTIFACT_GITHUB_TOKEN", "token")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ArtifactGitHubAPIURL != defaultArtifactGitHubAPIURL || !cfg.ArtifactGitHubPrerelease {
+		t.Fatalf("unexpected GitHub defaults: api=%s prerelease=%v", cfg.ArtifactGitHubAPIURL, cfg.ArtifactGitHubPrerelease)
+	}
+
+	t.Setenv("APP_ARTIFACT_GITHUB_REPO", "acme")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for repository without owner")
+	}
+}
+
+func TestLoadFeaturedRepos(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := strings.Join(cfg.FeaturedRepos, ","); got != defaultFeaturedRepos {
+		t.Fatalf("unexpected featured repos: got=%s want=%s", got, defaultFeaturedRepos)
+	}
+	if cfg.CatalogRefresh != defaultCatalogRefreshMin*time.Minute || cfg.CatalogReleaseTags != defaultCatalogReleaseTags {
+		t.Fatalf("unexpected catalog defaults: refresh=%s tags=%d", cfg.CatalogRefresh, cfg.CatalogReleaseTags)
+	}
+
+	t.Setenv("APP_FEATURED_REPOS", "none")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.FeaturedRepos) != 0 {
+		t.Fatalf("expected no featured repos, got %v", cfg.FeaturedRepos)
+	}
+
+	t.Setenv("APP_FEATURED_REPOS", "git@github.com:meshtastic/firmware.git")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for non-http featured repo")
+	}
+
+	t.Setenv("APP_FEATURED_REPOS", "")
+	t.Setenv("APP_CATALOG_WEBHOOK_URL", "ftp://example.com/hook")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for non-http catalog webhook")
+	}
+}
+
+func TestLoadDeviceRules(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_DEVICE_ALLOW", "github.com/acme/fork=acme-*")
+	t.Setenv("APP_DEVICE_DENY", "*-debug, github.com/meshtastic/* = native*")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	wantAllow := []DeviceRule{{Repo: "github.com/acme/fork", Pattern: "acme-*"}}
+	wantDeny := []DeviceRule{{Pattern: "*-debug"}, {Repo: "github.com/meshtastic/*", Pattern: "native*"}}
+	if !reflect.DeepEqual(cfg.DeviceAllow, wantAllow) || !reflect.DeepEqual(cfg.DeviceDeny, wantDeny) {
+		t.Fatalf("unexpected device rules: allow=%v deny=%v", cfg.DeviceAllow, cfg.DeviceDeny)
+	}
+
+	for _, raw := range []string{"=tbeam", "github.com/a/b=", "tbeam[", "github.com/[=tbeam"} {
+		t.Setenv("APP_DEVICE_DENY", raw)
+		if _, err := Load(); err == nil {
+			t.Fatalf("expected error for APP_DEVICE_DENY=%q", raw)
+		}
+	}
+}
+
+func TestLoadDiscoveryLimits(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.DiscoveryConcurrency != defaultDiscoveryConcurrent || cfg.DiscoveryQueueSize != defaultDiscoveryQueueSize {
+		t.Fatalf("unexpected discovery defaults: concurrency=%d queue=%d", cfg.DiscoveryConcurrency, cfg.DiscoveryQueueSize)
+	}
+
+	t.Setenv("APP_DISCOVERY_QUEUE_SIZE", "0")
+	if cfg, err = Load(); err != nil || cfg.DiscoveryQueueSize != 0 {
+		t.Fatalf("expected zero queue size to be accepted: cfg=%d err=%v", cfg.DiscoveryQueueSize, err)
+	}
+
+	t.Setenv("APP_DISCOVERY_CONCURRENCY", "0")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for zero discovery concurrency")
+	}
+}
+
+func TestLoadGitWebhookBuilds(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_GIT_WEBHOOK_BUILDS", "github.com/meshtastic/firmware=tbeam, github.com/acme/*=heltec-v3")
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for webhook builds without secret")
+	}
+
+	t.Setenv("APP_GIT_WEBHOOK_SECRET", "secret")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []WebhookBuild{{Repo: "github.com/meshtastic/firmware", Device: "tbeam"}, {Repo: "github.com/acme/*", Device: "heltec-v3"}}
+	if !reflect.DeepEqual(cfg.GitWebhookBuilds, want) {
+		t.Fatalf("unexpected webhook builds: got=%v want=%v", cfg.GitWebhookBuilds, want)
+	}
+
+	t.Setenv("APP_GIT_WEBHOOK_BUILDS", "tbeam")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for entry without repo")
+	}
+}
+
+func TestLoadAllowedRepoHosts(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_ALLOWED_REPO_HOSTS", "GitHub.com, gitlab.com/meshtastic/")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []string{"github.com", "gitlab.com/meshtastic"}
+	if !reflect.DeepEqual(cfg.AllowedRepoHosts, want) {
+		t.Fatalf("unexpected allowed repo hosts: got=%v want=%v", cfg.AllowedRepoHosts, want)
+	}
+
+	for _, raw := range []string{"https://github.com", "git@github.com", "github.com/["} {
+		t.Setenv("APP_ALLOWED_REPO_HOSTS", raw)
+		if _, err := Load(); err == nil {
+			t.Fatalf("expected error for APP_ALLOWED_REPO_HOSTS=%q", raw)
+		}
+	}
+}
+
+func TestLoadTagSignatureMode(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	t.Setenv("APP_TAG_SIGNATURE_MODE", "require")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for require mode without trusted keys")
+	}
+
+	signers := filepath.Join(t.TempDir(), "allowed_signers")
+	if err := os.WriteFile(signers, []byte("release@example.com ssh-ed25519 AAAA\n"), 0o644); err != nil {
+		t.Fatalf("write allowed signers: %v", err)
+	}
+	t.Setenv("APP_TAG_SSH_ALLOWED_SIGNERS", signers)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.TagSignatureMode != "require" || cfg.TagSSHAllowedSigners != signers {
+		t.Fatalf("unexpected tag signature config: mode=%q signers=%q", cfg.TagSignatureMode, cfg.TagSSHAllowedSigners)
+	}
+
+	t.Setenv("APP_TAG_SIGNATURE_MODE", "strict")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for unknown tag signature mode")
+	}
+}
+
+func TestLoadCloneStrategy(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.CloneStrategy != "shallow" || cfg.CloneShallowSince != defaultCloneShallowSince {
+		t.Fatalf("unexpected clone defaults: strategy=%q since=%q", cfg.CloneStrategy, cfg.CloneShallowSince)
+	}
+
+	t.Setenv("APP_CLONE_STRATEGY", "Treeless")
+	if cfg, err = Load(); err != nil || cfg.CloneStrategy != "treeless" {
+		t.Fatalf("unexpected clone strategy: strategy=%q err=%v", cfg.CloneStrategy, err)
+	}
+
+	t.Setenv("APP_CLONE_STRATEGY", "blobless")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for unknown clone strategy")
+	}
+
+	t.Setenv("APP_CLONE_STRATEGY", "shallow-since")
+	t.Setenv("APP_CLONE_SHALLOW_SINCE", "--upload-pack=touch")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for option-like shallow-since date")
+	}
+}
+
+func TestLoadGitNetworkLimits(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_GIT_NETWORK_CONCURRENCY", "3")
+	t.Setenv("APP_GIT_BANDWIDTH_LIMIT_KBPS", "512")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.GitNetworkConcurrency != 3 || cfg.GitBandwidthLimit != 512*1024 {
+		t.Fatalf("unexpected git network limits: concurrency=%d bandwidth=%d", cfg.GitNetworkConcurrency, cfg.GitBandwidthLimit)
+	}
+
+	t.Setenv("APP_GIT_BANDWIDTH_LIMIT_KBPS", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for negative bandwidth limit")
+	}
+}
+
+func TestLoadGRPCRequiresToken(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_GRPC_PORT", "9090")
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error when APP_GRPC_TOKEN is missing")
+	}
+
+	t.Setenv("APP_GRPC_TOKEN", "secret")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.GRPCPort != 9090 || cfg.GRPCToken != "secret" {
+		t.Fatalf("unexpected grpc config: port=%d token=%q", cfg.GRPCPort, cfg.GRPCToken)
+	}
+}
+
+func TestLoadIdempotencyWindow(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.IdempotencyWindow != time.Hour {
+		t.Fatalf("unexpected default idempotency window: %s", cfg.IdempotencyWindow)
+	}
+
+	t.Setenv("APP_RETENTION_HOURS", "1")
+	t.Setenv("APP_IDEMPOTENCY_WINDOW_MINUTES", "600")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.IdempotencyWindow != time.Hour {
+		t.Fatalf("idempotency window must be capped at the retention, got %s", cfg.IdempotencyWindow)
+	}
+
+	t.Setenv("APP_IDEMPOTENCY_WINDOW_MINUTES", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for negative idempotency window")
+	}
+}
+
+func TestLoadHTTPTimeouts(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.HTTPAPITimeout != 30*time.Second || cfg.HTTPSlowAPITimeout != 10*time.Minute || cfg.HTTPStallTimeout != time.Minute {
+		t.Fatalf("unexpected default HTTP timeouts: api=%s slow=%s stall=%s", cfg.HTTPAPITimeout, cfg.HTTPSlowAPITimeout, cfg.HTTPStallTimeout)
+	}
+
+	t.Setenv("APP_HTTP_API_TIMEOUT_SECONDS", "900")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a slow API timeout below the API timeout")
+	}
+
+	t.Setenv("APP_HTTP_API_TIMEOUT_SECONDS", "")
+	t.Setenv("APP_HTTP_STALL_TIMEOUT_SECONDS", "10")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a stall timeout shorter than the stream ping")
+	}
+}
+
+func TestLoadTLS(t *testing.T) {
+	workDir := t.TempDir()
+	t.Setenv("APP_WORKDIR", workDir)
+
+	t.Setenv("APP_TLS_CERT_FILE", filepath.Join(workDir, "cert.pem"))
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a certificate without a key")
+	}
+	t.Setenv("APP_TLS_KEY_FILE", filepath.Join(workDir, "key.pem"))
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for missing key pair files")
+	}
+
+	t.Setenv("APP_TLS_CERT_FILE", "")
+	t.Setenv("APP_TLS_KEY_FILE", "")
+	t.Setenv("APP_TLS_REDIRECT_PORT", "80")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a redirect port without TLS")
+	}
+
+	t.Setenv("APP_TLS_AUTOCERT_DOMAINS", "builder.example.com, www.builder.example.com")
+	t.Setenv("APP_PORT", "443")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.TLSAutocertDomains) != 2 || cfg.TLSRedirectPort != 80 || cfg.TLSAutocertCacheDir != filepath.Join(workDir, "autocert") {
+		t.Fatalf("unexpected TLS config: domains=%v redirect=%d cache=%q", cfg.TLSAutocertDomains, cfg.TLSRedirectPort, cfg.TLSAutocertCacheDir)
+	}
+
+	t.Setenv("APP_TLS_REDIRECT_PORT", "443")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a redirect port equal to APP_PORT")
+	}
+}
+
+func TestLoadOIDC(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	t.Setenv("APP_OIDC_ISSUER", "google")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for an issuer without client credentials")
+	}
+	t.Setenv("APP_OIDC_CLIENT_ID", "builder")
+	t.Setenv("APP_OIDC_CLIENT_SECRET", "s3cret")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a missing redirect URL")
+	}
+	t.Setenv("APP_OIDC_REDIRECT_URL", "https://builder.example.com/api/auth/callback")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.OIDCIssuer != "https://accounts.google.com" || strings.Join(cfg.OIDCScopes, " ") != "openid profile email" || cfg.OIDCSessionTTL != 168*time.Hour {
+		t.Fatalf("unexpected OIDC config: issuer=%q scopes=%v ttl=%v", cfg.OIDCIssuer, cfg.OIDCScopes, cfg.OIDCSessionTTL)
+	}
+	if cfg.Redacted().OIDCClientSecret != redactedValue {
+		t.Fatalf("expected the client secret to be redacted")
+	}
+
+	t.Setenv("APP_OIDC_ISSUER", "GitHub")
+	t.Setenv("APP_OIDC_SESSION_HOURS", "24")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.OIDCIssuer != "github" || strings.Join(cfg.OIDCScopes, " ") != "read:user" || cfg.OIDCSessionTTL != 24*time.Hour {
+		t.Fatalf("unexpected GitHub config: issuer=%q scopes=%v ttl=%v", cfg.OIDCIssuer, cfg.OIDCScopes, cfg.OIDCSessionTTL)
+	}
+
+	t.Setenv("APP_OIDC_ISSUER", "idp.example.com")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for an issuer that is not a URL")
+	}
+}
+
+func TestLoadCaptchaProvider(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.CaptchaProvider != "math" || cfg.CaptchaMinScore != 0.5 || cfg.CaptchaImage || cfg.CaptchaSessionCookie {
+		t.Fatalf("unexpected captcha defaults: provider=%q minScore=%v image=%v cookie=%v", cfg.CaptchaProvider, cfg.CaptchaMinScore, cfg.CaptchaImage, cfg.CaptchaSessionCookie)
+	}
+
+	t.Setenv("APP_CAPTCHA_SESSION_COOKIE", "true")
+	if cfg, err = Load(); err != nil || !cfg.CaptchaSessionCookie {
+		t.Fatalf("expected the captcha session cookie to be enabled, err=%v", err)
+	}
+
+	t.Setenv("APP_CAPTCHA_PROVIDER", "Turnstile")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a widget provider without keys")
+	}
+	t.Setenv("APP_CAPTCHA_SITE_KEY", "site")
+	t.Setenv("APP_CAPTCHA_SECRET_KEY", "s3cret")
+	t.Setenv("APP_CAPTCHA_MIN_SCORE", "0.7")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.CaptchaProvider != "turnstile" || cfg.CaptchaSiteKey != "site" || cfg.CaptchaMinScore != 0.7 {
+		t.Fatalf("unexpected captcha config: %+v", cfg)
+	}
+	if cfg.Redacted().CaptchaSecretKey != redactedValue {
+		t.Fatalf("expected the captcha secret to be redacted")
+	}
+
+	t.Setenv("APP_CAPTCHA_MIN_SCORE", "2")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a score above 1")
+	}
+	t.Setenv("APP_CAPTCHA_MIN_SCORE", "")
+	t.Setenv("APP_CAPTCHA_PROVIDER", "friendly")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for an unknown provider")
+	}
+}
+
+func TestLoadBuildQuota(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_BUILD_QUOTA_DAILY", "20")
+	t.Setenv("APP_BUILD_QUOTA_WEEKLY", "50")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.BuildQuotaDaily != 20 || cfg.BuildQuotaWeekly != 50 {
+		t.Fatalf("unexpected quotas: daily=%d weekly=%d", cfg.BuildQuotaDaily, cfg.BuildQuotaWeekly)
+	}
+
+	t.Setenv("APP_BUILD_QUOTA_WEEKLY", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a negative quota")
+	}
+}
+
+func TestLoadProofOfWork(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.PoWEnabled || cfg.PoWDifficulty != 18 || cfg.PoWMaxDifficulty != 24 {
+		t.Fatalf("unexpected proof-of-work defaults: %v %d %d", cfg.PoWEnabled, cfg.PoWDifficulty, cfg.PoWMaxDifficulty)
+	}
+
+	t.Setenv("APP_POW_ENABLED", "1")
+	t.Setenv("APP_POW_DIFFICULTY", "26")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.PoWEnabled || cfg.PoWMaxDifficulty != 26 {
+		t.Fatalf("expected the maximum to follow a higher base difficulty, got %d", cfg.PoWMaxDifficulty)
+	}
+
+	t.Setenv("APP_POW_MAX_DIFFICULTY", "20")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a maximum below the base difficulty")
+	}
+	t.Setenv("APP_POW_DIFFICULTY", "40")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a difficulty above 32")
+	}
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+	t.Setenv("APP_TRUSTED_PROXIES", "127.0.0.1, 10.0.0.0/8,fd00::/8, ::ffff:192.168.0.0/112")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []string{"127.0.0.1/32", "10.0.0.0/8", "fd00::/8", "192.168.0.0/16"}
+	if len(cfg.TrustedProxies) != len(want) {
+		t.Fatalf("unexpected trusted proxies: %v", cfg.TrustedProxies)
+	}
+	for index, prefix := range cfg.TrustedProxies {
+		if prefix.String() != want[index] {
+			t.Fatalf("unexpected trusted proxy %d: got=%s want=%s", index, prefix, want[index])
+		}
+	}
+
+	t.Setenv("APP_TRUSTED_PROXIES", "proxy.local")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a host name")
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := Config{AdminToken: "admin", StatsPassword: "stats", ArtifactS3SecretKey: "s3", Port: 8080}
+
+	redacted := cfg.Redacted()
+	if redacted.AdminToken != redactedValue || redacted.StatsPassword != redactedValue || redacted.ArtifactS3SecretKey != redactedValue {
+		t.Fatalf("secrets must be redacted: %+v", redacted)
+	}
+	if redacted.GRPCToken != "" || redacted.Port != 8080 {
+		t.Fatalf("unset secrets and other fields must be kept: %+v", redacted)
+	}
+	if cfg.AdminToken != "admin" {
+		t.Fatalf("Redacted must not modify the receiver")
+	}
+}
+
+func TestLoadAdminClientCA(t *testing.T) {
+	workDir := t.TempDir()
+	t.Setenv("APP_WORKDIR", workDir)
+
+	path := filepath.Join(workDir, "ca.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatalf("write CA file: %v", err)
+	}
+	t.Setenv("APP_ADMIN_CLIENT_CA", path)
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a file without certificates")
+	}
+
+	t.Setenv("APP_ADMIN_CLIENT_CA", filepath.Join(workDir, "missing.pem"))
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a missing file")
+	}
+}
+
+func TestLoadAuditLog(t *testing.T) {
+	workDir := t.TempDir()
+	t.Setenv("APP_WORKDIR", workDir)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !slices.Equal(cfg.AuditSinks, []string{"file"}) || cfg.AuditLogPath != filepath.Join(workDir, "audit.jsonl") {
+		t.Fatalf("unexpected audit defaults: %v %q", cfg.AuditSinks, cfg.AuditLogPath)
+	}
+
+	t.Setenv("APP_AUDIT_LOG", "syslog, file")
+	t.Setenv("APP_AUDIT_SYSLOG_ADDRESS", "udp://logs.example.com:514")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !slices.Equal(cfg.AuditSinks, []string{"syslog", "file"}) || cfg.AuditSyslogAddress != "udp://logs.example.com:514" {
+		t.Fatalf("unexpected audit config: %v %q", cfg.AuditSinks, cfg.AuditSyslogAddress)
+	}
+
+	t.Setenv("APP_AUDIT_LOG", "off")
+	if cfg, err = Load(); err != nil || len(cfg.AuditSinks) != 0 {
+		t.Fatalf("expected off to disable the audit log: %v %v", cfg.AuditSinks, err)
+	}
+
+	t.Setenv("APP_AUDIT_LOG", "kafka")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for an unknown sink")
+	}
+	t.Setenv("APP_AUDIT_LOG", "syslog")
+	t.Setenv("APP_AUDIT_SYSLOG_ADDRESS", "logs.example.com:514")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a syslog address without a scheme")
+	}
+}
+
+func TestLoadRedis(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.RedisURL != "" || cfg.RedisKeyPrefix != "mfb:" {
+		t.Fatalf("unexpected redis defaults: %q %q", cfg.RedisURL, cfg.RedisKeyPrefix)
+	}
+
+	t.Setenv("APP_REDIS_URL", "redis://:secret@redis:6379/1")
+	t.Setenv("APP_REDIS_KEY_PREFIX", "builder-eu:")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.RedisURL != "redis://:secret@redis:6379/1" || cfg.RedisKeyPrefix != "builder-eu:" {
+		t.Fatalf("unexpected redis config: %q %q", cfg.RedisURL, cfg.RedisKeyPrefix)
+	}
+	if cfg.Redacted().RedisURL != redactedValue {
+		t.Fatalf("expected the redis URL to be redacted")
+	}
+
+	t.Setenv("APP_REDIS_URL", "redis:6379")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a redis address without a scheme")
+	}
+}
+
+func TestLoadJobRegistry(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if hostname, _ := os.Hostname(); cfg.JobRegistry || cfg.NodeName != hostname {
+		t.Fatalf("unexpected registry defaults: %v %q", cfg.JobRegistry, cfg.NodeName)
+	}
+
+	t.Setenv("APP_JOB_REGISTRY", "1")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected the registry to require APP_REDIS_URL")
+	}
+
+	t.Setenv("APP_REDIS_URL", "redis://redis:6379/0")
+	t.Setenv("APP_NODE_NAME", "builder-eu-1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.JobRegistry || cfg.NodeName != "builder-eu-1" {
+		t.Fatalf("unexpected registry config: %v %q", cfg.JobRegistry, cfg.NodeName)
+	}
+}
+
+func TestLoadIPRules(t *testing.T) {
+	workDir := t.TempDir()
+	t.Setenv("APP_WORKDIR", workDir)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.IPAllow) != 0 || len(cfg.IPDeny) != 0 || len(cfg.AnonymousRanges) != 0 || cfg.AnonymousBuildRateLimit != 2 {
+		t.Fatalf("unexpected ip rule defaults: %+v %+v %+v %d", cfg.IPAllow, cfg.IPDeny, cfg.AnonymousRanges, cfg.AnonymousBuildRateLimit)
+	}
+
+	t.Setenv("APP_BUILD_RATE_LIMIT_PER_MINUTE", "1")
+	if cfg, err = Load(); err != nil || cfg.AnonymousBuildRateLimit != 1 {
+		t.Fatalf("expected the anonymous limit to default to at most the build limit, got %d %v", cfg.AnonymousBuildRateLimit, err)
+	}
+	t.Setenv("APP_BUILD_RATE_LIMIT_PER_MINUTE", "")
+
+	rangesFile := filepath.Join(workDir, "tor-exits.txt")
+	if err := os.WriteFile(rangesFile, []byte("# Tor exit nodes\n185.220.101.0/24\n\n2001:db8::1 # relay\n"), 0o644); err != nil {
+		t.Fatalf("write ranges file: %v", err)
+	}
+	t.Setenv("APP_IP_ALLOW", "10.0.0.0/8, 192.0.2.0/24")
+	t.Setenv("APP_IP_DENY", "10.1.2.3")
+	t.Setenv("APP_ANONYMOUS_RANGES", "198.51.100.0/24")
+	t.Setenv("APP_ANONYMOUS_RANGES_FILE", rangesFile)
+	t.Setenv("APP_ANONYMOUS_BUILD_RATE_LIMIT_PER_MINUTE", "1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	format := func(prefixes []netip.Prefix) []string {
+		values := make([]string, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			values = append(values, prefix.String())
+		}
+		return values
+	}
+	if got := format(cfg.IPAllow); !slices.Equal(got, []string{"10.0.0.0/8", "192.0.2.0/24"}) {
+		t.Fatalf("unexpected allow list: %v", got)
+	}
+	if got := format(cfg.IPDeny); !slices.Equal(got, []string{"10.1.2.3/32"}) {
+		t.Fatalf("unexpected deny list: %v", got)
+	}
+	if got := format(cfg.AnonymousRanges); !slices.Equal(got, []string{"198.51.100.0/24", "185.220.101.0/24", "2001:db8::1/128"}) {
+		t.Fatalf("unexpected anonymous ranges: %v", got)
+	}
+	if cfg.AnonymousBuildRateLimit != 1 {
+		t.Fatalf("unexpected anonymous build limit: %d", cfg.AnonymousBuildRateLimit)
+	}
+
+	t.Setenv("APP_IP_DENY", "10.0.0.0/33")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for an invalid prefix")
+	}
+	t.Setenv("APP_IP_DENY", "")
+
+	t.Setenv("APP_ANONYMOUS_RANGES_FILE", filepath.Join(workDir, "missing.txt"))
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a missing ranges file")
+	}
+	t.Setenv("APP_ANONYMOUS_RANGES_FILE", "")
+
+	t.Setenv("APP_ANONYMOUS_BUILD_RATE_LIMIT_PER_MINUTE", "0")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a zero anonymous build limit")
+	}
+}
+
+func TestLoadAbuseDetection(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.AbuseMinFailures != 5 || cfg.AbuseFailureRatio != 0.8 || cfg.AbuseWindow != time.Hour || cfg.AbuseBuildRateLimit != 1 {
+		t.Fatalf("unexpected abuse defaults: %d %v %v %d", cfg.AbuseMinFailures, cfg.AbuseFailureRatio, cfg.AbuseWindow, cfg.AbuseBuildRateLimit)
+	}
+
+	t.Setenv("APP_ABUSE_MIN_FAILURES", "0")
+	t.Setenv("APP_ABUSE_FAILURE_RATIO", "0.5")
+	t.Setenv("APP_ABUSE_WINDOW_MINUTES", "30")
+	t.Setenv("APP_ABUSE_BUILD_RATE_LIMIT_PER_MINUTE", "2")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.AbuseMinFailures != 0 || cfg.AbuseFailureRatio != 0.5 || cfg.AbuseWindow != 30*time.Minute || cfg.AbuseBuildRateLimit != 2 {
+		t.Fatalf("unexpected abuse config: %d %v %v %d", cfg.AbuseMinFailures, cfg.AbuseFailureRatio, cfg.AbuseWindow, cfg.AbuseBuildRateLimit)
+	}
+
+	for key, value := range map[string]string{
+		"APP_ABUSE_MIN_FAILURES":                "-1",
+		"APP_ABUSE_FAILURE_RATIO":               "1.5",
+		"APP_ABUSE_WINDOW_MINUTES":              "0",
+		"APP_ABUSE_BUILD_RATE_LIMIT_PER_MINUTE": "0",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error for %s=%s", key, value)
+			}
+		})
+	}
+}
+
+func TestLoadBuildContainerHardening(t *testing.T) {
+	workDir := t.TempDir()
+	t.Setenv("APP_WORKDIR", workDir)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.BuildReadOnlyRootFS || cfg.BuildCapDropAll || cfg.BuildSeccompProfile != "" || cfg.BuildUser != "" || cfg.BuildPidsLimit != 0 {
+		t.Fatalf("expected hardening to be off by default: %+v", cfg)
+	}
+
+	profile := filepath.Join(workDir, "seccomp.json")
+	if err := os.WriteFile(profile, []byte(`{"defaultAction":"SCMP_ACT_ERRNO"}`), 0o644); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+	t.Setenv("APP_BUILD_READ_ONLY_ROOTFS", "true")
+	t.Setenv("APP_BUILD_CAP_DROP_ALL", "1")
+	t.Setenv("APP_BUILD_SECCOMP_PROFILE", profile)
+	t.Setenv("APP_BUILD_USER", "1000:1000")
+	t.Setenv("APP_BUILD_PIDS_LIMIT", "512")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.BuildReadOnlyRootFS || !cfg.BuildCapDropAll || cfg.BuildSeccompProfile != profile || cfg.BuildUser != "1000:1000" || cfg.BuildPidsLimit != 512 {
+		t.Fatalf("unexpected hardening config: %+v", cfg)
+	}
+
+	for key, value := range map[string]string{
+		"APP_BUILD_SECCOMP_PROFILE": filepath.Join(workDir, "missing.json"),
+		"APP_BUILD_USER":            "1000 --privileged",
+		"APP_BUILD_PIDS_LIMIT":      "-1",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error for %s=%q", key, value)
+			}
+		})
+	}
+}
+
+func TestLoadBuildModeration(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ModerateAnonymousBuilds || len(cfg.ModerationAllowedRepos) != 0 {
+		t.Fatalf("expected moderation to be off by default, got %t %v", cfg.ModerateAnonymousBuilds, cfg.ModerationAllowedRepos)
+	}
+
+	t.Setenv("APP_MODERATE_ANONYMOUS_BUILDS", "true")
+	t.Setenv("APP_MODERATION_ALLOWED_REPOS", "GitHub.com/Meshtastic/, gitlab.com/*")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.ModerateAnonymousBuilds || !reflect.DeepEqual(cfg.ModerationAllowedRepos, []string{"github.com/meshtastic", "gitlab.com/*"}) {
+		t.Fatalf("unexpected moderation config: %t %v", cfg.ModerateAnonymousBuilds, cfg.ModerationAllowedRepos)
+	}
+
+	t.Setenv("APP_MODERATION_ALLOWED_REPOS", "https://github.com/meshtastic")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a repository URL entry")
+	}
+}
+
+func TestLoadSecretsKey(t *testing.T) {
+	workDir := t.TempDir()
+	t.Setenv("APP_WORKDIR", workDir)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.SecretsKey != "" || cfg.SecretsPath != filepath.Join(workDir, "secrets.json") {
+		t.Fatalf("unexpected secrets defaults: key=%q path=%q", cfg.SecretsKey, cfg.SecretsPath)
+	}
+
+	key := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	keyFile := filepath.Join(workDir, "secrets.key")
+	if err := os.WriteFile(keyFile, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	t.Setenv("APP_SECRETS_KEY_FILE", keyFile)
+	t.Setenv("APP_GIT_WEBHOOK_BUILDS", "github.com/meshtastic/firmware=tbeam")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.SecretsKey != key || cfg.Redacted().SecretsKey != redactedValue {
+		t.Fatalf("expected the key from the file, redacted in inspection, got %q", cfg.SecretsKey)
+	}
+
+	t.Setenv("APP_SECRETS_KEY", key)
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for both a key and a key file")
+	}
+	t.Setenv("APP_SECRETS_KEY_FILE", "")
+	t.Setenv("APP_SECRETS_KEY", "c2hvcnQ=")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a key that is not 32 bytes")
+	}
+}
+
+func TestLoadLogging(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.LogFormat != "text" || cfg.LogLevel != slog.LevelInfo {
+		t.Fatalf("unexpected logging defaults: format=%q level=%v", cfg.LogFormat, cfg.LogLevel)
+	}
+
+	if !cfg.AccessLog || cfg.AccessLogSampleRate != 1 {
+		t.Fatalf("unexpected access log defaults: enabled=%v rate=%v", cfg.AccessLog, cfg.AccessLogSampleRate)
+	}
+
+	t.Setenv("APP_LOG_FORMAT", "JSON")
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	t.Setenv("APP_ACCESS_LOG_SAMPLE_RATE", "0.1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.LogFormat != "json" || cfg.LogLevel != slog.LevelDebug || cfg.AccessLogSampleRate != 0.1 {
+		t.Fatalf("unexpected logging config: format=%q level=%v rate=%v", cfg.LogFormat, cfg.LogLevel, cfg.AccessLogSampleRate)
+	}
+
+	t.Setenv("APP_ACCESS_LOG_SAMPLE_RATE", "2")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a sample rate above 1")
+	}
+	t.Setenv("APP_ACCESS_LOG_SAMPLE_RATE", "")
+
+	t.Setenv("APP_LOG_LEVEL", "verbose")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for an unknown log level")
+	}
+	t.Setenv("APP_LOG_LEVEL", "")
+	t.Setenv("APP_LOG_FORMAT", "logfmt")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for an unknown log format")
+	}
+}
+
+func TestLoadMinFreeDisk(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.MinFreeDiskMB != defaultMinFreeDiskMB {
+		t.Fatalf("unexpected default free disk threshold: %d", cfg.MinFreeDiskMB)
+	}
+
+	t.Setenv("APP_MIN_FREE_DISK_MB", "0")
+	if cfg, err = Load(); err != nil || cfg.MinFreeDiskMB != 0 {
+		t.Fatalf("expected the check to be disabled, got %d err=%v", cfg.MinFreeDiskMB, err)
+	}
+
+	t.Setenv("APP_MIN_FREE_DISK_MB", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a negative threshold")
+	}
+}
+
+func TestLoadDockerHealthInterval(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.DockerHealthInterval != 30*time.Second {
+		t.Fatalf("unexpected default docker health interval: %v", cfg.DockerHealthInterval)
+	}
+
+	t.Setenv("APP_DOCKER_HEALTH_INTERVAL_SECONDS", "0")
+	if cfg, err = Load(); err != nil || cfg.DockerHealthInterval != 0 {
+		t.Fatalf("expected the checks to be disabled, got %v err=%v", cfg.DockerHealthInterval, err)
+	}
+
+	t.Setenv("APP_DOCKER_HEALTH_INTERVAL_SECONDS", "-5")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a negative interval")
+	}
+}
+
+func TestLoadNotifications(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if strings.Join(cfg.NotifyOn, ",") != "success,failure" || cfg.NotifyPerJob {
+		t.Fatalf("unexpected notification defaults: on=%v perJob=%v", cfg.NotifyOn, cfg.NotifyPerJob)
+	}
+
+	t.Setenv("APP_NOTIFY_ON", "Failure,cancelled")
+	t.Setenv("APP_NOTIFY_PER_JOB", "true")
+	t.Setenv("APP_NOTIFY_TELEGRAM_BOT_TOKEN", "123:secret")
+	t.Setenv("APP_NOTIFY_SMTP_ADDR", "smtp.example.com:587")
+	t.Setenv("APP_NOTIFY_SMTP_FROM", "builder@example.com")
+	t.Setenv("APP_NOTIFY_SMTP_PASSWORD", "hunter2")
+	t.Setenv("APP_NOTIFY_EMAIL_TO", "ops@example.com, dev@example.com")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if strings.Join(cfg.NotifyOn, ",") != "failure,cancelled" || !cfg.NotifyPerJob || len(cfg.NotifyEmailTo) != 2 {
+		t.Fatalf("unexpected notification config: %+v", cfg)
+	}
+	if redacted := cfg.Redacted(); redacted.NotifyTelegramBotToken != redactedValue || redacted.NotifySMTPPassword != redactedValue {
+		t.Fatalf("notification secrets must be redacted")
+	}
+
+	for key, value := range map[string]string{
+		"APP_NOTIFY_ON":                 "started",
+		"APP_NOTIFY_SMTP_ADDR":          "smtp.example.com",
+		"APP_NOTIFY_SMTP_FROM":          "builder",
+		"APP_NOTIFY_EMAIL_TO":           "ops",
+		"APP_NOTIFY_SLACK_WEBHOOK_URL":  "hooks.slack.com/services/x",
+		"APP_NOTIFY_TELEGRAM_BOT_TOKEN": "",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv("APP_NOTIFY_TELEGRAM_CHAT_ID", "-1001")
+			t.Setenv(key, value)
+			if _, err := Load(); err == nil {
+				t.Fatalf("expected error for %s=%q", key, value)
+			}
+		})
+	}
+}
+
+func TestLoadJobLogFiles(t *testing.T) {
+	t.Setenv("APP_WORKDIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.JobLogFiles || cfg.JobLogRotateMB != defaultJobLogRotateMB || cfg.JobLogMaxFiles != defaultJobLogMaxFiles {
+		t.Fatalf("unexpected job log defaults: %t %d %d", cfg.JobLogFiles, cfg.JobLogRotateMB, cfg.JobLogMaxFiles)
+	}
+
+	t.Setenv("APP_JOB_LOG_FILES", "0")
+	t.Setenv("APP_JOB_LOG_ROTATE_MB", "0")
+	t.Setenv("APP_JOB_LOG_MAX_FILES", "0")
+	if cfg, err = Load(); err != nil || cfg.JobLogFiles || cfg.JobLogRotateMB != 0 || cfg.JobLogMaxFiles != 0 {
+		t.Fatalf("expected job log files to be disabled, got %t %d %d err=%v", cfg.JobLogFiles, cfg.JobLogRotateMB, cfg.JobLogMaxFiles, err)
+	}
+
+	t.Setenv("APP_JOB_LOG_ROTATE_MB", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for a negative rotation size")
+	}
+}
@@ -0,0 +1,1438 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        v29.3.0
+// source: builder/v1/builder.proto
+
+// Builder exposes the job, discovery and artifact APIs of the firmware
+// builder to programmatic clients. Messages mirror the JSON API; string
+// enums (job status, progress phase) use the same values.
+
+package builderv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DiscoverRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RepoUrl       string                 `protobuf:"bytes,1,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	Ref           string                 `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiscoverRequest) Reset() {
+	*x = DiscoverRequest{}
+	mi := &file_builder_v1_builder_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoverRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoverRequest) ProtoMessage() {}
+
+func (x *DiscoverRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoverRequest.ProtoReflect.Descriptor instead.
+func (*DiscoverRequest) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DiscoverRequest) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+func (x *DiscoverRequest) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+type DiscoverResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RepoUrl       string                 `protobuf:"bytes,1,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	Ref           string                 `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	Commit        string                 `protobuf:"bytes,3,opt,name=commit,proto3" json:"commit,omitempty"`
+	Devices       []*Device              `protobuf:"bytes,4,rep,name=devices,proto3" json:"devices,omitempty"`
+	Warnings      []*DiscoveryWarning    `protobuf:"bytes,5,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiscoverResponse) Reset() {
+	*x = DiscoverResponse{}
+	mi := &file_builder_v1_builder_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoverResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoverResponse) ProtoMessage() {}
+
+func (x *DiscoverResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoverResponse.ProtoReflect.Descriptor instead.
+func (*DiscoverResponse) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DiscoverResponse) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+func (x *DiscoverResponse) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *DiscoverResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *DiscoverResponse) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+func (x *DiscoverResponse) GetWarnings() []*DiscoveryWarning {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type Device struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Platform      string                 `protobuf:"bytes,2,opt,name=platform,proto3" json:"platform,omitempty"`
+	Board         string                 `protobuf:"bytes,3,opt,name=board,proto3" json:"board,omitempty"`
+	Mcu           string                 `protobuf:"bytes,4,opt,name=mcu,proto3" json:"mcu,omitempty"`
+	RelativePath  string                 `protobuf:"bytes,5,opt,name=relative_path,json=relativePath,proto3" json:"relative_path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Device) Reset() {
+	*x = Device{}
+	mi := &file_builder_v1_builder_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Device) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Device) ProtoMessage() {}
+
+func (x *Device) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Device.ProtoReflect.Descriptor instead.
+func (*Device) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Device) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Device) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *Device) GetBoard() string {
+	if x != nil {
+		return x.Board
+	}
+	return ""
+}
+
+func (x *Device) GetMcu() string {
+	if x != nil {
+		return x.Mcu
+	}
+	return ""
+}
+
+func (x *Device) GetRelativePath() string {
+	if x != nil {
+		return x.RelativePath
+	}
+	return ""
+}
+
+type DiscoveryWarning struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	File          string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`
+	Line          int32                  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+	Env           string                 `protobuf:"bytes,4,opt,name=env,proto3" json:"env,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiscoveryWarning) Reset() {
+	*x = DiscoveryWarning{}
+	mi := &file_builder_v1_builder_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoveryWarning) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoveryWarning) ProtoMessage() {}
+
+func (x *DiscoveryWarning) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoveryWarning.ProtoReflect.Descriptor instead.
+func (*DiscoveryWarning) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DiscoveryWarning) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *DiscoveryWarning) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *DiscoveryWarning) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *DiscoveryWarning) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *DiscoveryWarning) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ListRefsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RepoUrl       string                 `protobuf:"bytes,1,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRefsRequest) Reset() {
+	*x = ListRefsRequest{}
+	mi := &file_builder_v1_builder_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRefsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRefsRequest) ProtoMessage() {}
+
+func (x *ListRefsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRefsRequest.ProtoReflect.Descriptor instead.
+func (*ListRefsRequest) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListRefsRequest) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+type ListRefsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RepoUrl        string                 `protobuf:"bytes,1,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	DefaultBranch  string                 `protobuf:"bytes,2,opt,name=default_branch,json=defaultBranch,proto3" json:"default_branch,omitempty"`
+	RecentBranches []*Ref                 `protobuf:"bytes,3,rep,name=recent_branches,json=recentBranches,proto3" json:"recent_branches,omitempty"`
+	RecentTags     []*Ref                 `protobuf:"bytes,4,rep,name=recent_tags,json=recentTags,proto3" json:"recent_tags,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListRefsResponse) Reset() {
+	*x = ListRefsResponse{}
+	mi := &file_builder_v1_builder_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRefsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRefsResponse) ProtoMessage() {}
+
+func (x *ListRefsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRefsResponse.ProtoReflect.Descriptor instead.
+func (*ListRefsResponse) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListRefsResponse) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+func (x *ListRefsResponse) GetDefaultBranch() string {
+	if x != nil {
+		return x.DefaultBranch
+	}
+	return ""
+}
+
+func (x *ListRefsResponse) GetRecentBranches() []*Ref {
+	if x != nil {
+		return x.RecentBranches
+	}
+	return nil
+}
+
+func (x *ListRefsResponse) GetRecentTags() []*Ref {
+	if x != nil {
+		return x.RecentTags
+	}
+	return nil
+}
+
+type Ref struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ref) Reset() {
+	*x = Ref{}
+	mi := &file_builder_v1_builder_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ref) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ref) ProtoMessage() {}
+
+func (x *Ref) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ref.ProtoReflect.Descriptor instead.
+func (*Ref) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Ref) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Ref) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *Ref) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type SubmoduleOverride struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Commit        string                 `protobuf:"bytes,3,opt,name=commit,proto3" json:"commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmoduleOverride) Reset() {
+	*x = SubmoduleOverride{}
+	mi := &file_builder_v1_builder_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmoduleOverride) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmoduleOverride) ProtoMessage() {}
+
+func (x *SubmoduleOverride) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmoduleOverride.ProtoReflect.Descriptor instead.
+func (*SubmoduleOverride) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SubmoduleOverride) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SubmoduleOverride) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *SubmoduleOverride) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+type CreateJobRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	RepoUrl          string                 `protobuf:"bytes,1,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	Ref              string                 `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	Device           string                 `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"`
+	BuildFlags       []string               `protobuf:"bytes,4,rep,name=build_flags,json=buildFlags,proto3" json:"build_flags,omitempty"`
+	LibDeps          []string               `protobuf:"bytes,5,rep,name=lib_deps,json=libDeps,proto3" json:"lib_deps,omitempty"`
+	ArtifactPatterns []string               `protobuf:"bytes,6,rep,name=artifact_patterns,json=artifactPatterns,proto3" json:"artifact_patterns,omitempty"`
+	Patch            string                 `protobuf:"bytes,7,opt,name=patch,proto3" json:"patch,omitempty"`
+	Submodules       []*SubmoduleOverride   `protobuf:"bytes,8,rep,name=submodules,proto3" json:"submodules,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CreateJobRequest) Reset() {
+	*x = CreateJobRequest{}
+	mi := &file_builder_v1_builder_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateJobRequest) ProtoMessage() {}
+
+func (x *CreateJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateJobRequest.ProtoReflect.Descriptor instead.
+func (*CreateJobRequest) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateJobRequest) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+func (x *CreateJobRequest) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *CreateJobRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *CreateJobRequest) GetBuildFlags() []string {
+	if x != nil {
+		return x.BuildFlags
+	}
+	return nil
+}
+
+func (x *CreateJobRequest) GetLibDeps() []string {
+	if x != nil {
+		return x.LibDeps
+	}
+	return nil
+}
+
+func (x *CreateJobRequest) GetArtifactPatterns() []string {
+	if x != nil {
+		return x.ArtifactPatterns
+	}
+	return nil
+}
+
+func (x *CreateJobRequest) GetPatch() string {
+	if x != nil {
+		return x.Patch
+	}
+	return ""
+}
+
+func (x *CreateJobRequest) GetSubmodules() []*SubmoduleOverride {
+	if x != nil {
+		return x.Submodules
+	}
+	return nil
+}
+
+type GetJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_builder_v1_builder_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Job struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RepoUrl      string                 `protobuf:"bytes,2,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	Ref          string                 `protobuf:"bytes,3,opt,name=ref,proto3" json:"ref,omitempty"`
+	RequestedRef string                 `protobuf:"bytes,4,opt,name=requested_ref,json=requestedRef,proto3" json:"requested_ref,omitempty"`
+	Device       string                 `protobuf:"bytes,5,opt,name=device,proto3" json:"device,omitempty"`
+	Commit       string                 `protobuf:"bytes,6,opt,name=commit,proto3" json:"commit,omitempty"`
+	// Status is one of pending, queued, running, success, failed or cancelled.
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	QueuePosition *int32                 `protobuf:"varint,8,opt,name=queue_position,json=queuePosition,proto3,oneof" json:"queue_position,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt    *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Error         string                 `protobuf:"bytes,12,opt,name=error,proto3" json:"error,omitempty"`
+	Artifacts     []*Artifact            `protobuf:"bytes,13,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
+	Progress      *Progress              `protobuf:"bytes,14,opt,name=progress,proto3" json:"progress,omitempty"`
+	LogLines      int32                  `protobuf:"varint,15,opt,name=log_lines,json=logLines,proto3" json:"log_lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_builder_v1_builder_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+func (x *Job) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *Job) GetRequestedRef() string {
+	if x != nil {
+		return x.RequestedRef
+	}
+	return ""
+}
+
+func (x *Job) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *Job) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetQueuePosition() int32 {
+	if x != nil && x.QueuePosition != nil {
+		return *x.QueuePosition
+	}
+	return 0
+}
+
+func (x *Job) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Job) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Job) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetArtifacts() []*Artifact {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+func (x *Job) GetProgress() *Progress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *Job) GetLogLines() int32 {
+	if x != nil {
+		return x.LogLines
+	}
+	return 0
+}
+
+type Artifact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256        string                 `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Md5           string                 `protobuf:"bytes,5,opt,name=md5,proto3" json:"md5,omitempty"`
+	Url           string                 `protobuf:"bytes,6,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Artifact) Reset() {
+	*x = Artifact{}
+	mi := &file_builder_v1_builder_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Artifact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artifact) ProtoMessage() {}
+
+func (x *Artifact) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artifact.ProtoReflect.Descriptor instead.
+func (*Artifact) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Artifact) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Artifact) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Artifact) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Artifact) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *Artifact) GetMd5() string {
+	if x != nil {
+		return x.Md5
+	}
+	return ""
+}
+
+func (x *Artifact) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type Progress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Phase         string                 `protobuf:"bytes,1,opt,name=phase,proto3" json:"phase,omitempty"`
+	Stage         string                 `protobuf:"bytes,2,opt,name=stage,proto3" json:"stage,omitempty"`
+	Percent       int32                  `protobuf:"varint,3,opt,name=percent,proto3" json:"percent,omitempty"`
+	Current       int64                  `protobuf:"varint,4,opt,name=current,proto3" json:"current,omitempty"`
+	Total         int64                  `protobuf:"varint,5,opt,name=total,proto3" json:"total,omitempty"`
+	Done          bool                   `protobuf:"varint,6,opt,name=done,proto3" json:"done,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_builder_v1_builder_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Progress) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *Progress) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *Progress) GetPercent() int32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *Progress) GetCurrent() int64 {
+	if x != nil {
+		return x.Current
+	}
+	return 0
+}
+
+func (x *Progress) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Progress) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+type StreamLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamLogsRequest) Reset() {
+	*x = StreamLogsRequest{}
+	mi := &file_builder_v1_builder_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLogsRequest) ProtoMessage() {}
+
+func (x *StreamLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLogsRequest.ProtoReflect.Descriptor instead.
+func (*StreamLogsRequest) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StreamLogsRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type LogEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*LogEvent_Line
+	//	*LogEvent_Progress
+	Event         isLogEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEvent) Reset() {
+	*x = LogEvent{}
+	mi := &file_builder_v1_builder_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEvent) ProtoMessage() {}
+
+func (x *LogEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEvent.ProtoReflect.Descriptor instead.
+func (*LogEvent) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *LogEvent) GetEvent() isLogEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *LogEvent) GetLine() string {
+	if x != nil {
+		if x, ok := x.Event.(*LogEvent_Line); ok {
+			return x.Line
+		}
+	}
+	return ""
+}
+
+func (x *LogEvent) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*LogEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+type isLogEvent_Event interface {
+	isLogEvent_Event()
+}
+
+type LogEvent_Line struct {
+	Line string `protobuf:"bytes,1,opt,name=line,proto3,oneof"`
+}
+
+type LogEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,2,opt,name=progress,proto3,oneof"`
+}
+
+func (*LogEvent_Line) isLogEvent_Event() {}
+
+func (*LogEvent_Progress) isLogEvent_Event() {}
+
+type DownloadArtifactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	ArtifactId    string                 `protobuf:"bytes,2,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadArtifactRequest) Reset() {
+	*x = DownloadArtifactRequest{}
+	mi := &file_builder_v1_builder_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadArtifactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadArtifactRequest) ProtoMessage() {}
+
+func (x *DownloadArtifactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadArtifactRequest.ProtoReflect.Descriptor instead.
+func (*DownloadArtifactRequest) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DownloadArtifactRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *DownloadArtifactRequest) GetArtifactId() string {
+	if x != nil {
+		return x.ArtifactId
+	}
+	return ""
+}
+
+type ArtifactChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArtifactChunk) Reset() {
+	*x = ArtifactChunk{}
+	mi := &file_builder_v1_builder_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArtifactChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArtifactChunk) ProtoMessage() {}
+
+func (x *ArtifactChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_builder_v1_builder_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArtifactChunk.ProtoReflect.Descriptor instead.
+func (*ArtifactChunk) Descriptor() ([]byte, []int) {
+	return file_builder_v1_builder_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ArtifactChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_builder_v1_builder_proto protoreflect.FileDescriptor
+
+const file_builder_v1_builder_proto_rawDesc = "" +
+	"\n" +
+	"\x18builder/v1/builder.proto\x12\n" +
+	"builder.v1\x1a\x1fgoogle/protobuf/timestamp.proto\">\n" +
+	"\x0fDiscoverRequest\x12\x19\n" +
+	"\brepo_url\x18\x01 \x01(\tR\arepoUrl\x12\x10\n" +
+	"\x03ref\x18\x02 \x01(\tR\x03ref\"\xbf\x01\n" +
+	"\x10DiscoverResponse\x12\x19\n" +
+	"\brepo_url\x18\x01 \x01(\tR\arepoUrl\x12\x10\n" +
+	"\x03ref\x18\x02 \x01(\tR\x03ref\x12\x16\n" +
+	"\x06commit\x18\x03 \x01(\tR\x06commit\x12,\n" +
+	"\adevices\x18\x04 \x03(\v2\x12.builder.v1.DeviceR\adevices\x128\n" +
+	"\bwarnings\x18\x05 \x03(\v2\x1c.builder.v1.DiscoveryWarningR\bwarnings\"\x85\x01\n" +
+	"\x06Device\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bplatform\x18\x02 \x01(\tR\bplatform\x12\x14\n" +
+	"\x05board\x18\x03 \x01(\tR\x05board\x12\x10\n" +
+	"\x03mcu\x18\x04 \x01(\tR\x03mcu\x12#\n" +
+	"\rrelative_path\x18\x05 \x01(\tR\frelativePath\"z\n" +
+	"\x10DiscoveryWarning\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\x05R\x04line\x12\x10\n" +
+	"\x03env\x18\x04 \x01(\tR\x03env\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\",\n" +
+	"\x0fListRefsRequest\x12\x19\n" +
+	"\brepo_url\x18\x01 \x01(\tR\arepoUrl\"\xc0\x01\n" +
+	"\x10ListRefsResponse\x12\x19\n" +
+	"\brepo_url\x18\x01 \x01(\tR\arepoUrl\x12%\n" +
+	"\x0edefault_branch\x18\x02 \x01(\tR\rdefaultBranch\x128\n" +
+	"\x0frecent_branches\x18\x03 \x03(\v2\x0f.builder.v1.RefR\x0erecentBranches\x120\n" +
+	"\vrecent_tags\x18\x04 \x03(\v2\x0f.builder.v1.RefR\n" +
+	"recentTags\"l\n" +
+	"\x03Ref\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"Q\n" +
+	"\x11SubmoduleOverride\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06commit\x18\x03 \x01(\tR\x06commit\"\x95\x02\n" +
+	"\x10CreateJobRequest\x12\x19\n" +
+	"\brepo_url\x18\x01 \x01(\tR\arepoUrl\x12\x10\n" +
+	"\x03ref\x18\x02 \x01(\tR\x03ref\x12\x16\n" +
+	"\x06device\x18\x03 \x01(\tR\x06device\x12\x1f\n" +
+	"\vbuild_flags\x18\x04 \x03(\tR\n" +
+	"buildFlags\x12\x19\n" +
+	"\blib_deps\x18\x05 \x03(\tR\alibDeps\x12+\n" +
+	"\x11artifact_patterns\x18\x06 \x03(\tR\x10artifactPatterns\x12\x14\n" +
+	"\x05patch\x18\a \x01(\tR\x05patch\x12=\n" +
+	"\n" +
+	"submodules\x18\b \x03(\v2\x1d.builder.v1.SubmoduleOverrideR\n" +
+	"submodules\"\x1f\n" +
+	"\rGetJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xba\x04\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\brepo_url\x18\x02 \x01(\tR\arepoUrl\x12\x10\n" +
+	"\x03ref\x18\x03 \x01(\tR\x03ref\x12#\n" +
+	"\rrequested_ref\x18\x04 \x01(\tR\frequestedRef\x12\x16\n" +
+	"\x06device\x18\x05 \x01(\tR\x06device\x12\x16\n" +
+	"\x06commit\x18\x06 \x01(\tR\x06commit\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12*\n" +
+	"\x0equeue_position\x18\b \x01(\x05H\x00R\rqueuePosition\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"started_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x14\n" +
+	"\x05error\x18\f \x01(\tR\x05error\x122\n" +
+	"\tartifacts\x18\r \x03(\v2\x14.builder.v1.ArtifactR\tartifacts\x120\n" +
+	"\bprogress\x18\x0e \x01(\v2\x14.builder.v1.ProgressR\bprogress\x12\x1b\n" +
+	"\tlog_lines\x18\x0f \x01(\x05R\blogLinesB\x11\n" +
+	"\x0f_queue_position\"~\n" +
+	"\bArtifact\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\x12\x10\n" +
+	"\x03md5\x18\x05 \x01(\tR\x03md5\x12\x10\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\"\x94\x01\n" +
+	"\bProgress\x12\x14\n" +
+	"\x05phase\x18\x01 \x01(\tR\x05phase\x12\x14\n" +
+	"\x05stage\x18\x02 \x01(\tR\x05stage\x12\x18\n" +
+	"\apercent\x18\x03 \x01(\x05R\apercent\x12\x18\n" +
+	"\acurrent\x18\x04 \x01(\x03R\acurrent\x12\x14\n" +
+	"\x05total\x18\x05 \x01(\x03R\x05total\x12\x12\n" +
+	"\x04done\x18\x06 \x01(\bR\x04done\"*\n" +
+	"\x11StreamLogsRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"]\n" +
+	"\bLogEvent\x12\x14\n" +
+	"\x04line\x18\x01 \x01(\tH\x00R\x04line\x122\n" +
+	"\bprogress\x18\x02 \x01(\v2\x14.builder.v1.ProgressH\x00R\bprogressB\a\n" +
+	"\x05event\"Q\n" +
+	"\x17DownloadArtifactRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x1f\n" +
+	"\vartifact_id\x18\x02 \x01(\tR\n" +
+	"artifactId\"#\n" +
+	"\rArtifactChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data2\xab\x03\n" +
+	"\x0eBuilderService\x12E\n" +
+	"\bDiscover\x12\x1b.builder.v1.DiscoverRequest\x1a\x1c.builder.v1.DiscoverResponse\x12E\n" +
+	"\bListRefs\x12\x1b.builder.v1.ListRefsRequest\x1a\x1c.builder.v1.ListRefsResponse\x12:\n" +
+	"\tCreateJob\x12\x1c.builder.v1.CreateJobRequest\x1a\x0f.builder.v1.Job\x124\n" +
+	"\x06GetJob\x12\x19.builder.v1.GetJobRequest\x1a\x0f.builder.v1.Job\x12C\n" +
+	"\n" +
+	"StreamLogs\x12\x1d.builder.v1.StreamLogsRequest\x1a\x14.builder.v1.LogEvent0\x01\x12T\n" +
+	"\x10DownloadArtifact\x12#.builder.v1.DownloadArtifactRequest\x1a\x19.builder.v1.ArtifactChunk0\x01BaZ_github.com/skrashevich/meshtastic-firmware-builder/backend/internal/grpcapi/builderv1;builderv1b\x06proto3"
+
+var (
+	file_builder_v1_builder_proto_rawDescOnce sync.Once
+	file_builder_v1_builder_proto_rawDescData []byte
+)
+
+func file_builder_v1_builder_proto_rawDescGZIP() []byte {
+	file_builder_v1_builder_proto_rawDescOnce.Do(func() {
+		file_builder_v1_builder_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_builder_v1_builder_proto_rawDesc), len(file_builder_v1_builder_proto_rawDesc)))
+	})
+	return file_builder_v1_builder_proto_rawDescData
+}
+
+var file_builder_v1_builder_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_builder_v1_builder_proto_goTypes = []any{
+	(*DiscoverRequest)(nil),         // 0: builder.v1.DiscoverRequest
+	(*DiscoverResponse)(nil),        // 1: builder.v1.DiscoverResponse
+	(*Device)(nil),                  // 2: builder.v1.Device
+	(*DiscoveryWarning)(nil),        // 3: builder.v1.DiscoveryWarning
+	(*ListRefsRequest)(nil),         // 4: builder.v1.ListRefsRequest
+	(*ListRefsResponse)(nil),        // 5: builder.v1.ListRefsResponse
+	(*Ref)(nil),                     // 6: builder.v1.Ref
+	(*SubmoduleOverride)(nil),       // 7: builder.v1.SubmoduleOverride
+	(*CreateJobRequest)(nil),        // 8: builder.v1.CreateJobRequest
+	(*GetJobRequest)(nil),           // 9: builder.v1.GetJobRequest
+	(*Job)(nil),                     // 10: builder.v1.Job
+	(*Artifact)(nil),                // 11: builder.v1.Artifact
+	(*Progress)(nil),                // 12: builder.v1.Progress
+	(*StreamLogsRequest)(nil),       // 13: builder.v1.StreamLogsRequest
+	(*LogEvent)(nil),                // 14: builder.v1.LogEvent
+	(*DownloadArtifactRequest)(nil), // 15: builder.v1.DownloadArtifactRequest
+	(*ArtifactChunk)(nil),           // 16: builder.v1.ArtifactChunk
+	(*timestamppb.Timestamp)(nil),   // 17: google.protobuf.Timestamp
+}
+var file_builder_v1_builder_proto_depIdxs = []int32{
+	2,  // 0: builder.v1.DiscoverResponse.devices:type_name -> builder.v1.Device
+	3,  // 1: builder.v1.DiscoverResponse.warnings:type_name -> builder.v1.DiscoveryWarning
+	6,  // 2: builder.v1.ListRefsResponse.recent_branches:type_name -> builder.v1.Ref
+	6,  // 3: builder.v1.ListRefsResponse.recent_tags:type_name -> builder.v1.Ref
+	17, // 4: builder.v1.Ref.updated_at:type_name -> google.protobuf.Timestamp
+	7,  // 5: builder.v1.CreateJobRequest.submodules:type_name -> builder.v1.SubmoduleOverride
+	17, // 6: builder.v1.Job.created_at:type_name -> google.protobuf.Timestamp
+	17, // 7: builder.v1.Job.started_at:type_name -> google.protobuf.Timestamp
+	17, // 8: builder.v1.Job.finished_at:type_name -> google.protobuf.Timestamp
+	11, // 9: builder.v1.Job.artifacts:type_name -> builder.v1.Artifact
+	12, // 10: builder.v1.Job.progress:type_name -> builder.v1.Progress
+	12, // 11: builder.v1.LogEvent.progress:type_name -> builder.v1.Progress
+	0,  // 12: builder.v1.BuilderService.Discover:input_type -> builder.v1.DiscoverRequest
+	4,  // 13: builder.v1.BuilderService.ListRefs:input_type -> builder.v1.ListRefsRequest
+	8,  // 14: builder.v1.BuilderService.CreateJob:input_type -> builder.v1.CreateJobRequest
+	9,  // 15: builder.v1.BuilderService.GetJob:input_type -> builder.v1.GetJobRequest
+	13, // 16: builder.v1.BuilderService.StreamLogs:input_type -> builder.v1.StreamLogsRequest
+	15, // 17: builder.v1.BuilderService.DownloadArtifact:input_type -> builder.v1.DownloadArtifactRequest
+	1,  // 18: builder.v1.BuilderService.Discover:output_type -> builder.v1.DiscoverResponse
+	5,  // 19: builder.v1.BuilderService.ListRefs:output_type -> builder.v1.ListRefsResponse
+	10, // 20: builder.v1.BuilderService.CreateJob:output_type -> builder.v1.Job
+	10, // 21: builder.v1.BuilderService.GetJob:output_type -> builder.v1.Job
+	14, // 22: builder.v1.BuilderService.StreamLogs:output_type -> builder.v1.LogEvent
+	16, // 23: builder.v1.BuilderService.DownloadArtifact:output_type -> builder.v1.ArtifactChunk
+	18, // [18:24] is the sub-list for method output_type
+	12, // [12:18] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_builder_v1_builder_proto_init() }
+func file_builder_v1_builder_proto_init() {
+	if File_builder_v1_builder_proto != nil {
+		return
+	}
+	file_builder_v1_builder_proto_msgTypes[10].OneofWrappers = []any{}
+	file_builder_v1_builder_proto_msgTypes[14].OneofWrappers = []any{
+		(*LogEvent_Line)(nil),
+		(*LogEvent_Progress)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_builder_v1_builder_proto_rawDesc), len(file_builder_v1_builder_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_builder_v1_builder_proto_goTypes,
+		DependencyIndexes: file_builder_v1_builder_proto_depIdxs,
+		MessageInfos:      file_builder_v1_builder_proto_msgTypes,
+	}.Build()
+	File_builder_v1_builder_proto = out.File
+	file_builder_v1_builder_proto_goTypes = nil
+	file_builder_v1_builder_proto_depIdxs = nil
+}
@@ -0,0 +1,371 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/grpcapi/builderv1"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+const artifactChunkSize = 64 * 1024
+
+// Server implements builderv1.BuilderServiceServer on top of the job
+// manager. It serves the same operations as the HTTP API for programmatic
+// clients.
+type Server struct {
+	builderv1.UnimplementedBuilderServiceServer
+
+	manager *jobs.Manager
+	logger  *slog.Logger
+	audit   *audit.Log
+}
+
+// NewServer returns a gRPC server with the builder service registered. All
+// calls must authenticate with cfg.GRPCToken.
+func NewServer(cfg config.Config, manager *jobs.Manager, logger *slog.Logger) *grpc.Server {
+	auditLog := audit.New(audit.Options{
+		Sinks:         cfg.AuditSinks,
+		FilePath:      cfg.AuditLogPath,
+		SyslogAddress: cfg.AuditSyslogAddress,
+	}, logger)
+	auth := tokenAuth{token: cfg.GRPCToken, audit: auditLog}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.unary),
+		grpc.StreamInterceptor(auth.stream),
+	)
+	builderv1.RegisterBuilderServiceServer(server, &Server{manager: manager, logger: logger, audit: auditLog})
+	return server
+}
+
+func (s *Server) Discover(ctx context.Context, req *builderv1.DiscoverRequest) (*builderv1.DiscoverResponse, error) {
+	repoURL := jobs.NormalizeRepoURL(req.GetRepoUrl())
+	result, err := s.manager.Discover(ctx, repoURL, req.GetRef())
+	if err != nil {
+		return nil, toStatus(err, codes.FailedPrecondition)
+	}
+
+	response := &builderv1.DiscoverResponse{
+		RepoUrl:  repoURL,
+		Ref:      req.GetRef(),
+		Commit:   result.Commit,
+		Devices:  make([]*builderv1.Device, 0, len(result.Devices)),
+		Warnings: make([]*builderv1.DiscoveryWarning, 0, len(result.Warnings)),
+	}
+	for _, device := range result.Devices {
+		response.Devices = append(response.Devices, &builderv1.Device{
+			Name:         device.Name,
+			Platform:     device.Platform,
+			Board:        device.Board,
+			Mcu:          device.MCU,
+			RelativePath: device.RelativePath,
+		})
+	}
+	for _, warning := range result.Warnings {
+		response.Warnings = append(response.Warnings, &builderv1.DiscoveryWarning{
+			Code:    warning.Code,
+			File:    warning.File,
+			Line:    int32(warning.Line),
+			Env:     warning.Env,
+			Message: warning.Message,
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) ListRefs(ctx context.Context, req *builderv1.ListRefsRequest) (*builderv1.ListRefsResponse, error) {
+	refs, err := s.manager.DiscoverRefs(ctx, jobs.NormalizeRepoURL(req.GetRepoUrl()))
+	if err != nil {
+		return nil, toStatus(err, codes.FailedPrecondition)
+	}
+
+	return &builderv1.ListRefsResponse{
+		RepoUrl:        refs.RepoURL,
+		DefaultBranch:  refs.DefaultBranch,
+		RecentBranches: presentRefs(refs.RecentBranches),
+		RecentTags:     presentRefs(refs.RecentTags),
+	}, nil
+}
+
+func (s *Server) CreateJob(ctx context.Context, req *builderv1.CreateJobRequest) (*builderv1.Job, error) {
+	submodules := make([]jobs.SubmoduleOverride, 0, len(req.GetSubmodules()))
+	for _, override := range req.GetSubmodules() {
+		submodules = append(submodules, jobs.SubmoduleOverride{
+			Path:   override.GetPath(),
+			URL:    override.GetUrl(),
+			Commit: override.GetCommit(),
+		})
+	}
+
+	state, err := s.manager.CreateJob(jobs.NormalizeRepoURL(req.GetRepoUrl()), req.GetRef(), req.GetDevice(), jobs.BuildOptions{
+		BuildFlags:       req.GetBuildFlags(),
+		LibDeps:          req.GetLibDeps(),
+		ArtifactPatterns: req.GetArtifactPatterns(),
+		Patch:            req.GetPatch(),
+		Submodules:       submodules,
+	}, peerIP(ctx))
+	if err != nil {
+		return nil, toStatus(err, codes.InvalidArgument)
+	}
+	s.audit.Record(audit.Event{
+		Action:  audit.ActionJobCreated,
+		Actor:   "grpc",
+		IP:      state.ClientIP,
+		JobID:   state.ID,
+		Details: map[string]string{"repo": state.RepoURL, "ref": state.Ref, "device": state.Device, "visibility": jobVisibility(state.Private)},
+	})
+	return presentJob(state), nil
+}
+
+func (s *Server) GetJob(ctx context.Context, req *builderv1.GetJobRequest) (*builderv1.Job, error) {
+	state, err := s.readableJob(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return presentJob(state), nil
+}
+
+func (s *Server) StreamLogs(req *builderv1.StreamLogsRequest, stream grpc.ServerStreamingServer[builderv1.LogEvent]) error {
+	if _, err := s.readableJob(stream.Context(), req.GetJobId()); err != nil {
+		return err
+	}
+	events, snapshot, unsubscribe, err := s.manager.SubscribeLogs(req.GetJobId(), 0)
+	if err != nil {
+		return toStatus(err, codes.Internal)
+	}
+	defer unsubscribe()
+
+	for _, event := range snapshot {
+		if err := stream.Send(&builderv1.LogEvent{Event: &builderv1.LogEvent_Line{Line: event.Line}}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case event, open := <-events:
+			if !open {
+				return nil
+			}
+			message := &builderv1.LogEvent{Event: &builderv1.LogEvent_Line{Line: event.Line}}
+			if event.Progress != nil {
+				message.Event = &builderv1.LogEvent_Progress{Progress: presentProgress(event.Progress)}
+			}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) DownloadArtifact(req *builderv1.DownloadArtifactRequest, stream grpc.ServerStreamingServer[builderv1.ArtifactChunk]) error {
+	if _, err := s.readableJob(stream.Context(), req.GetJobId()); err != nil {
+		return err
+	}
+	artifact, err := s.manager.GetArtifact(req.GetJobId(), req.GetArtifactId())
+	if err != nil {
+		return toStatus(err, codes.Internal)
+	}
+
+	reader, err := artifact.Open()
+	if err != nil {
+		return status.Errorf(codes.Internal, "open artifact: %v", err)
+	}
+	defer reader.Close()
+
+	if err := s.manager.RecordArtifactDownload(req.GetJobId(), artifact.ID); err != nil {
+		s.logger.Error("record download", "jobId", req.GetJobId(), "artifactId", artifact.ID, "error", err)
+	}
+
+	buffer := make([]byte, artifactChunkSize)
+	for {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			if sendErr := stream.Send(&builderv1.ArtifactChunk{Data: append([]byte(nil), buffer[:n]...)}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "read artifact: %v", err)
+		}
+	}
+}
+
+// jobTokenMetadata carries the access token of a private job, like the
+// X-Job-Token header of the HTTP API.
+const jobTokenMetadata = "x-job-token"
+
+// readableJob returns the job when the caller may read it. The gRPC token
+// identifies no user, so private jobs need their access token; anyone else
+// gets NotFound, as from the HTTP API.
+func (s *Server) readableJob(ctx context.Context, jobID string) (jobs.State, error) {
+	state, err := s.manager.GetJob(jobID)
+	if err != nil {
+		return jobs.State{}, toStatus(err, codes.Internal)
+	}
+
+	accessToken := ""
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(jobTokenMetadata); len(values) > 0 {
+		accessToken = strings.TrimSpace(values[0])
+	}
+	if !state.VisibleTo("", accessToken) {
+		return jobs.State{}, toStatus(jobs.ErrJobNotFound, codes.Internal)
+	}
+	return state, nil
+}
+
+func jobVisibility(private bool) string {
+	if private {
+		return "private"
+	}
+	return "public"
+}
+
+// toStatus maps manager errors to gRPC status codes the way the HTTP API maps
+// them to status codes; unknown errors get fallback.
+func toStatus(err error, fallback codes.Code) error {
+	var noDevices *jobs.NoDevicesError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, jobs.ErrJobNotFound), errors.Is(err, jobs.ErrArtifactNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, jobs.ErrRepoNotAllowed), errors.Is(err, jobs.ErrDeviceNotAllowed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, jobs.ErrDiscoveryBusy):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, jobs.ErrDraining):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.As(err, &noDevices):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(fallback, err.Error())
+}
+
+func presentJob(state jobs.State) *builderv1.Job {
+	job := &builderv1.Job{
+		Id:           state.ID,
+		RepoUrl:      state.RepoURL,
+		Ref:          state.Ref,
+		RequestedRef: state.RequestedRef,
+		Device:       state.Device,
+		Commit:       state.Commit,
+		Status:       string(state.Status),
+		CreatedAt:    timestamppb.New(state.CreatedAt),
+		StartedAt:    presentTime(state.StartedAt),
+		FinishedAt:   presentTime(state.FinishedAt),
+		Error:        state.Error,
+		Artifacts:    make([]*builderv1.Artifact, 0, len(state.Artifacts)),
+		Progress:     presentProgress(state.Progress),
+		LogLines:     int32(state.LogLines),
+	}
+	if state.QueuePosition != nil {
+		position := int32(*state.QueuePosition)
+		job.QueuePosition = &position
+	}
+	for _, artifact := range state.Artifacts {
+		job.Artifacts = append(job.Artifacts, &builderv1.Artifact{
+			Id:     artifact.ID,
+			Name:   artifact.Name,
+			Size:   artifact.Size,
+			Sha256: artifact.SHA256,
+			Md5:    artifact.MD5,
+			Url:    artifact.URL,
+		})
+	}
+	return job
+}
+
+func presentRefs(refs []jobs.RepoRef) []*builderv1.Ref {
+	result := make([]*builderv1.Ref, 0, len(refs))
+	for _, ref := range refs {
+		result = append(result, &builderv1.Ref{Name: ref.Name, Commit: ref.Commit, UpdatedAt: presentTime(ref.UpdatedAt)})
+	}
+	return result
+}
+
+func presentProgress(progress *jobs.Progress) *builderv1.Progress {
+	if progress == nil {
+		return nil
+	}
+	return &builderv1.Progress{
+		Phase:   progress.Phase,
+		Stage:   progress.Stage,
+		Percent: int32(progress.Percent),
+		Current: progress.Current,
+		Total:   progress.Total,
+		Done:    progress.Done,
+	}
+}
+
+func presentTime(value *time.Time) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
+	return timestamppb.New(*value)
+}
+
+func peerIP(ctx context.Context) string {
+	client, ok := peer.FromContext(ctx)
+	if !ok || client.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(client.Addr.String())
+	if err != nil {
+		return client.Addr.String()
+	}
+	return host
+}
+
+// tokenAuth requires "authorization: Bearer <token>" metadata on every call.
+type tokenAuth struct {
+	token string
+	audit *audit.Log
+}
+
+func (a tokenAuth) check(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		provided, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && a.token != "" && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(a.token)) == 1 {
+			return nil
+		}
+	}
+	a.audit.Record(audit.Event{Action: audit.ActionAuthFailed, IP: peerIP(ctx), Details: map[string]string{"realm": "grpc"}})
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+func (a tokenAuth) unary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := a.check(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a tokenAuth) stream(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.check(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
@@ -0,0 +1,144 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/grpcapi/builderv1"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func newTestClient(t *testing.T) (builderv1.BuilderServiceClient, *jobs.Manager) {
+	t.Helper()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		GRPCToken:       "secret",
+	}
+	logger := slog.New(slog.DiscardHandler)
+	manager := jobs.NewManager(cfg, logger)
+	t.Cleanup(manager.Close)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer(cfg, manager, logger)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return builderv1.NewBuilderServiceClient(conn), manager
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := client.GetJob(ctx, &builderv1.GetJobRequest{Id: "missing"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected code without token: got=%v want=%v", status.Code(err), codes.Unauthenticated)
+	}
+
+	wrong := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer nope")
+	if _, err := client.GetJob(wrong, &builderv1.GetJobRequest{Id: "missing"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected code with wrong token: got=%v want=%v", status.Code(err), codes.Unauthenticated)
+	}
+}
+
+func TestServerCreatesAndReadsJobs(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+
+	created, err := client.CreateJob(ctx, &builderv1.CreateJobRequest{
+		RepoUrl:    "example/firmware",
+		Ref:        "main",
+		Device:     "tbeam",
+		BuildFlags: []string{"-DDEBUG"},
+	})
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if created.GetRepoUrl() != "https://github.com/example/firmware" || created.GetStatus() != "queued" {
+		t.Fatalf("unexpected job: repo=%q status=%q", created.GetRepoUrl(), created.GetStatus())
+	}
+	if created.QueuePosition == nil || created.GetQueuePosition() != 1 {
+		t.Fatalf("unexpected queue position: got=%v want=1", created.QueuePosition)
+	}
+
+	fetched, err := client.GetJob(ctx, &builderv1.GetJobRequest{Id: created.GetId()})
+	if err != nil || fetched.GetId() != created.GetId() {
+		t.Fatalf("get job: id=%q err=%v", fetched.GetId(), err)
+	}
+
+	if _, err := client.GetJob(ctx, &builderv1.GetJobRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected code for missing job: got=%v want=%v", status.Code(err), codes.NotFound)
+	}
+	if _, err := client.CreateJob(ctx, &builderv1.CreateJobRequest{RepoUrl: "example/firmware", Device: "bad device"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected code for invalid job: got=%v want=%v", status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestServerHidesPrivateJobs(t *testing.T) {
+	t.Parallel()
+
+	client, manager := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+
+	private, err := manager.CreateOwnedJob("https://github.com/example/firmware", "main", "tbeam", jobs.BuildOptions{},
+		jobs.JobOwner{ClientIP: "192.0.2.1", Private: true, AccessToken: "job-token"})
+	if err != nil {
+		t.Fatalf("create private job: %v", err)
+	}
+
+	if _, err := client.GetJob(ctx, &builderv1.GetJobRequest{Id: private.ID}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected code for a private job: got=%v want=%v", status.Code(err), codes.NotFound)
+	}
+	logs, err := client.StreamLogs(ctx, &builderv1.StreamLogsRequest{JobId: private.ID})
+	if err == nil {
+		_, err = logs.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected code for private logs: got=%v want=%v", status.Code(err), codes.NotFound)
+	}
+	download, err := client.DownloadArtifact(ctx, &builderv1.DownloadArtifactRequest{JobId: private.ID, ArtifactId: "1"})
+	if err == nil {
+		_, err = download.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected code for a private artifact: got=%v want=%v", status.Code(err), codes.NotFound)
+	}
+
+	withToken := metadata.AppendToOutgoingContext(ctx, jobTokenMetadata, "job-token")
+	if fetched, err := client.GetJob(withToken, &builderv1.GetJobRequest{Id: private.ID}); err != nil || fetched.GetId() != private.ID {
+		t.Fatalf("expected the job token to grant access: id=%q err=%v", fetched.GetId(), err)
+	}
+}
@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+type abuseReportResponse struct {
+	Clients []jobs.ClientAbuseReport `json:"clients"`
+}
+
+type abuseClearRequest struct {
+	Client string `json:"client"`
+}
+
+func (s *Server) handleAdminAbuse(w http.ResponseWriter, requestID string) {
+	s.writeSuccess(w, http.StatusOK, requestID, abuseReportResponse{Clients: s.manager.AbuseReport()})
+}
+
+// handleAdminClearAbuse lifts the flag of a client, for example one whose
+// failures came from a broken fork rather than from a bot.
+func (s *Server) handleAdminClearAbuse(w http.ResponseWriter, r *http.Request, requestID string) {
+	var req abuseClearRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	client := strings.TrimSpace(req.Client)
+	if client == "" {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", "client is required", nil)
+		return
+	}
+	if !s.manager.ClearAbuse(client) {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "client is not tracked", nil)
+		return
+	}
+	s.logger.InfoContext(r.Context(), "admin: abuse flag cleared", "flaggedClient", client)
+	s.handleAdminAbuse(w, requestID)
+}
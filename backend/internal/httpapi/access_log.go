@@ -0,0 +1,61 @@
+package httpapi
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// accessLogWriter records the status and size of a response for the access
+// log.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	written, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(written)
+	return written, err
+}
+
+// Flush keeps event streams working through the wrapper.
+func (w *accessLogWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logAccess writes the access log record of a finished request. Failed
+// requests are always logged, successful ones at APP_ACCESS_LOG_SAMPLE_RATE.
+// The query string is left out: it may hold job access tokens.
+func (s *Server) logAccess(r *http.Request, method string, path string, w *accessLogWriter, started time.Time) {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status < http.StatusBadRequest && rand.Float64() >= s.cfg.AccessLogSampleRate {
+		return
+	}
+	s.logger.InfoContext(r.Context(), "request",
+		"method", method,
+		"path", path,
+		"status", status,
+		"durationMs", time.Since(started).Milliseconds(),
+		"bytes", w.bytes,
+		"servedBy", s.cfg.NodeName,
+	)
+}
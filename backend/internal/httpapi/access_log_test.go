@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/logging"
+)
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	records := func(output *bytes.Buffer) []map[string]any {
+		var result []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("invalid log line %q: %v", line, err)
+			}
+			if record["msg"] == "request" {
+				result = append(result, record)
+			}
+		}
+		return result
+	}
+	serve := func(server *Server, target string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		request.RemoteAddr = "203.0.113.9:1234"
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	var sampled bytes.Buffer
+	server := NewServer(config.Config{AccessLog: true, NodeName: "builder-1"}, nil, logging.New(&sampled, logging.FormatJSON))
+	serve(server, "/api/livez")
+	missing := serve(server, "/api/v1/nothing-here?token=job-secret")
+	logged := records(&sampled)
+	if len(logged) != 1 {
+		t.Fatalf("expected only the failed request with a zero sample rate, got %v", logged)
+	}
+	record := logged[0]
+	if record["method"] != "GET" || record["path"] != "/api/v1/nothing-here" || record["status"] != float64(http.StatusNotFound) ||
+		record["bytes"] != float64(missing.Body.Len()) || record["servedBy"] != "builder-1" ||
+		record["requestId"] != missing.Header().Get("X-Request-ID") || record["client"] != "203.0.113.9" {
+		t.Fatalf("unexpected access log record: %v", record)
+	}
+	if strings.Contains(sampled.String(), "job-secret") {
+		t.Fatalf("expected the query string to stay out of the access log: %s", sampled.String())
+	}
+
+	var everything bytes.Buffer
+	server = NewServer(config.Config{AccessLog: true, AccessLogSampleRate: 1}, nil, logging.New(&everything, logging.FormatJSON))
+	serve(server, "/api/livez")
+	if logged := records(&everything); len(logged) != 1 || logged[0]["status"] != float64(http.StatusOK) {
+		t.Fatalf("expected successful requests to be logged, got %v", logged)
+	}
+}
@@ -0,0 +1,311 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/logging"
+)
+
+const (
+	cacheArchiveFirmware   = "firmware-cache"
+	cacheArchivePlatformIO = "platformio-cache"
+	maxCacheImportBytes    = 32 << 30
+)
+
+// handleAdminRoutes serves the /api/admin/* namespace. Every route, known or
+// not, requires admin credentials first, so the namespace reveals nothing to
+// anonymous clients.
+func (s *Server) handleAdminRoutes(w http.ResponseWriter, r *http.Request, requestID string) {
+	if !s.requireAdminAuth(w, r, requestID) {
+		return
+	}
+	s.recordAudit(r, requestID, audit.Event{
+		Action:  audit.ActionAdminRequest,
+		Actor:   "admin",
+		Details: map[string]string{"method": r.Method, "path": r.URL.Path},
+	})
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/cache/export":
+		s.handleAdminCacheExport(w, r, requestID)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/admin/cache/import":
+		s.handleAdminCacheImport(w, r, requestID)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/admin/cache/purge":
+		s.handleAdminCachePurge(w, r, requestID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/queue":
+		s.handleAdminQueue(w, requestID)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/admin/jobs/") && strings.HasSuffix(r.URL.Path, "/cancel"):
+		jobID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/admin/jobs/"), "/cancel")
+		s.handleAdminCancelJob(w, r, requestID, jobID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/moderation":
+		s.handleAdminModeration(w, requestID)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/admin/jobs/") && strings.HasSuffix(r.URL.Path, "/approve"):
+		jobID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/admin/jobs/"), "/approve")
+		s.handleAdminApproveJob(w, r, requestID, jobID)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/admin/jobs/") && strings.HasSuffix(r.URL.Path, "/reject"):
+		jobID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/admin/jobs/"), "/reject")
+		s.handleAdminRejectJob(w, r, requestID, jobID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/audit":
+		s.handleAdminAudit(w, r, requestID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/abuse":
+		s.handleAdminAbuse(w, requestID)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/admin/abuse/clear":
+		s.handleAdminClearAbuse(w, r, requestID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/config":
+		s.writeSuccess(w, http.StatusOK, requestID, s.cfg.Redacted())
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/ip-rules":
+		s.handleAdminIPRules(w, requestID)
+	case r.Method == http.MethodPut && r.URL.Path == "/api/admin/ip-rules":
+		s.handleAdminSetIPRules(w, r, requestID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/cluster/jobs":
+		s.handleAdminClusterJobs(w, r, requestID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/secrets":
+		s.handleAdminSecrets(w, requestID)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/api/admin/secrets/"):
+		s.handleAdminPutSecret(w, r, requestID, strings.TrimPrefix(r.URL.Path, "/api/admin/secrets/"))
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/admin/secrets/"):
+		s.handleAdminDeleteSecret(w, r, requestID, strings.TrimPrefix(r.URL.Path, "/api/admin/secrets/"))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/drain":
+		s.writeSuccess(w, http.StatusOK, requestID, s.manager.DrainStatus())
+	case r.Method == http.MethodPost && r.URL.Path == "/api/admin/drain":
+		s.handleAdminDrain(w, r, requestID)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/log-level":
+		s.writeSuccess(w, http.StatusOK, requestID, currentLogLevel())
+	case r.Method == http.MethodPut && r.URL.Path == "/api/admin/log-level":
+		s.handleAdminSetLogLevel(w, r, requestID)
+	case (r.Method == http.MethodGet || r.Method == http.MethodPost) && strings.HasPrefix(r.URL.Path, adminPprofPrefix):
+		s.handleAdminPprof(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/debug/vars":
+		s.handleAdminVars(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/debug/dump":
+		s.handleAdminDebugDump(w, r, requestID)
+	default:
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+	}
+}
+
+// requireAdminAuth accepts a client certificate issued by
+// APP_ADMIN_CLIENT_CA or the APP_ADMIN_TOKEN bearer token. The namespace
+// answers 404 when neither is configured.
+func (s *Server) requireAdminAuth(w http.ResponseWriter, r *http.Request, requestID string) bool {
+	if s.cfg.AdminToken == "" && s.cfg.AdminClientCAs == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return false
+	}
+	if !s.adminAuthorized(r) {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionAuthFailed, Details: map[string]string{"realm": "admin"}})
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		s.writeError(w, http.StatusUnauthorized, requestID, "UNAUTHORIZED", "invalid admin token", nil)
+		return false
+	}
+	return true
+}
+
+// adminAuthorized reports whether r carries the admin token or a verified
+// admin client certificate.
+func (s *Server) adminAuthorized(r *http.Request) bool {
+	if s.verifiedAdminCertificate(r) {
+		return true
+	}
+
+	token := ""
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return s.cfg.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) == 1
+}
+
+// verifiedAdminCertificate reports whether the TLS client presented a
+// certificate that chains to APP_ADMIN_CLIENT_CA. It only applies when the
+// backend terminates TLS itself.
+func (s *Server) verifiedAdminCertificate(r *http.Request) bool {
+	if s.cfg.AdminClientCAs == nil || r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err := r.TLS.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         s.cfg.AdminClientCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err == nil
+}
+
+// cacheArchiveSources returns the cache directories selected by the
+// comma-separated "include" query parameter (all caches by default).
+func (s *Server) cacheArchiveSources(r *http.Request) ([]jobs.CacheArchiveSource, error) {
+	available := map[string]string{
+		cacheArchiveFirmware:   s.cfg.FirmwareCachePath,
+		cacheArchivePlatformIO: s.cfg.PlatformIOCache,
+	}
+
+	include := strings.TrimSpace(r.URL.Query().Get("include"))
+	if include == "" {
+		include = cacheArchiveFirmware + "," + cacheArchivePlatformIO
+	}
+
+	sources := make([]jobs.CacheArchiveSource, 0, len(available))
+	for _, name := range strings.Split(include, ",") {
+		name = strings.TrimSpace(name)
+		root, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cache %q, expected %s or %s", name, cacheArchiveFirmware, cacheArchivePlatformIO)
+		}
+		sources = append(sources, jobs.CacheArchiveSource{Name: name, Root: root})
+	}
+	return sources, nil
+}
+
+func (s *Server) handleAdminCacheExport(w http.ResponseWriter, r *http.Request, requestID string) {
+	sources, err := s.cacheArchiveSources(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+
+	fileName := fmt.Sprintf("mfb-cache-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err := jobs.ExportCacheArchive(w, sources); err != nil {
+		s.logger.ErrorContext(r.Context(), "admin: export cache archive", "error", err)
+	}
+}
+
+func (s *Server) handleAdminCacheImport(w http.ResponseWriter, r *http.Request, requestID string) {
+	sources, err := s.cacheArchiveSources(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxCacheImportBytes)
+	defer body.Close()
+
+	result, err := jobs.ImportCacheArchive(body, sources)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "admin: import cache archive", "error", err)
+		s.writeError(w, http.StatusBadRequest, requestID, "CACHE_IMPORT_FAILED", err.Error(), result)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "admin: imported cache archive", "filesWritten", result.FilesWritten, "filesSkipped", result.FilesSkipped)
+	s.writeSuccess(w, http.StatusOK, requestID, result)
+}
+
+// handleAdminCachePurge empties the selected caches; builds started
+// afterwards repopulate them.
+func (s *Server) handleAdminCachePurge(w http.ResponseWriter, r *http.Request, requestID string) {
+	sources, err := s.cacheArchiveSources(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+
+	result, err := jobs.PurgeCache(sources)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "admin: purge cache", "error", err)
+		s.writeError(w, http.StatusInternalServerError, requestID, "CACHE_PURGE_FAILED", err.Error(), result)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "admin: purged caches", "filesRemoved", result.FilesRemoved, "bytesRemoved", result.BytesRemoved)
+	s.writeSuccess(w, http.StatusOK, requestID, result)
+}
+
+type adminJobResponse struct {
+	stateResponse
+	ClientIP string `json:"clientIp,omitempty"`
+}
+
+type adminQueueResponse struct {
+	Jobs []adminJobResponse `json:"jobs"`
+}
+
+// handleAdminQueue lists queued and running jobs in queue order, including
+// the client addresses the public job endpoints hide.
+func (s *Server) handleAdminQueue(w http.ResponseWriter, requestID string) {
+	response := adminQueueResponse{Jobs: make([]adminJobResponse, 0)}
+	for _, state := range s.manager.ListJobs() {
+		if state.Status != jobs.StatusQueued && state.Status != jobs.StatusRunning {
+			continue
+		}
+		state, err := s.manager.GetJob(state.ID)
+		if err != nil {
+			continue
+		}
+		response.Jobs = append(response.Jobs, adminJobResponse{stateResponse: s.presentState(state), ClientIP: state.ClientIP})
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+func (s *Server) handleAdminCancelJob(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	state, err := s.manager.CancelJob(jobID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+	s.recordAudit(r, requestID, audit.Event{Action: audit.ActionJobCancelled, Actor: "admin", JobID: jobID})
+	s.logger.InfoContext(r.Context(), "admin: cancelled job", "jobId", jobID)
+	s.writeSuccess(w, http.StatusOK, requestID, s.presentState(state))
+}
+
+type adminDrainRequest struct {
+	Draining bool `json:"draining"`
+}
+
+// handleAdminDrain switches drain mode: while draining, POST /api/jobs
+// answers 503 SERVICE_DRAINING and queued and running jobs still finish.
+func (s *Server) handleAdminDrain(w http.ResponseWriter, r *http.Request, requestID string) {
+	var req adminDrainRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+
+	s.manager.SetDraining(req.Draining)
+	s.writeSuccess(w, http.StatusOK, requestID, s.manager.DrainStatus())
+}
+
+// adminLogLevel is the minimum level of the backend log: debug, info, warn
+// or error.
+type adminLogLevel struct {
+	Level string `json:"level"`
+}
+
+func currentLogLevel() adminLogLevel {
+	return adminLogLevel{Level: strings.ToLower(logging.Level().String())}
+}
+
+// handleAdminSetLogLevel changes the log level until the next restart, e.g.
+// to turn on debug records while investigating a problem.
+func (s *Server) handleAdminSetLogLevel(w http.ResponseWriter, r *http.Request, requestID string) {
+	var req adminLogLevel
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", "level must be one of: debug, info, warn, error", nil)
+		return
+	}
+
+	logging.SetLevel(level)
+	s.logger.InfoContext(r.Context(), "admin: log level changed", "logLevel", level)
+	s.writeSuccess(w, http.StatusOK, requestID, currentLogLevel())
+}
@@ -0,0 +1,467 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/logging"
+)
+
+func TestAdminRoutesDisabledWithoutToken(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/cache/export", nil)
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 without admin token, got %d", recorder.Code)
+	}
+}
+
+func TestAdminCacheExportRequiresToken(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{
+		AdminToken:        "admin-secret",
+		FirmwareCachePath: t.TempDir(),
+		PlatformIOCache:   t.TempDir(),
+	}, nil, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/cache/export", nil)
+	request.Header.Set("Authorization", "Bearer wrong")
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for wrong token, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodGet, "/api/admin/cache/export?include=firmware-cache", nil)
+	request.Header.Set("Authorization", "Bearer admin-secret")
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/gzip" {
+		t.Fatalf("unexpected content type: %q", got)
+	}
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodGet, "/api/admin/cache/export?include=unknown", nil)
+	request.Header.Set("Authorization", "Bearer admin-secret")
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unknown cache, got %d", recorder.Code)
+	}
+}
+
+func TestAdminQueueCancelAndDrain(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		BuildRateLimit:  10,
+		AdminToken:      "admin-secret",
+		GRPCToken:       "grpc-secret",
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "192.0.2.7")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	admin := func(method string, path string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer admin-secret")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := admin(http.MethodGet, "/api/admin/queue", "")
+	var queue struct {
+		Data adminQueueResponse `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &queue); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("queue: status=%d err=%v", recorder.Code, err)
+	}
+	if len(queue.Data.Jobs) != 1 || queue.Data.Jobs[0].ID != state.ID || queue.Data.Jobs[0].ClientIP != "192.0.2.7" {
+		t.Fatalf("unexpected queue: %+v", queue.Data.Jobs)
+	}
+
+	if recorder := admin(http.MethodPost, "/api/admin/jobs/"+state.ID+"/cancel", ""); recorder.Code != http.StatusOK {
+		t.Fatalf("cancel: status=%d body=%s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := admin(http.MethodPost, "/api/admin/jobs/"+state.ID+"/cancel", ""); recorder.Code != http.StatusConflict {
+		t.Fatalf("cancelling a finished job must conflict, got %d", recorder.Code)
+	}
+
+	if recorder := admin(http.MethodPost, "/api/admin/drain", `{"draining":true}`); recorder.Code != http.StatusOK {
+		t.Fatalf("drain: status=%d", recorder.Code)
+	}
+	create := httptest.NewRecorder()
+	server.ServeHTTP(create, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(`{"repoUrl":"https://github.com/example/repo","ref":"main","device":"tbeam"}`)))
+	if create.Code != http.StatusServiceUnavailable {
+		t.Fatalf("draining server must refuse builds, got %d", create.Code)
+	}
+
+	recorder = admin(http.MethodGet, "/api/admin/config", "")
+	if recorder.Code != http.StatusOK || strings.Contains(recorder.Body.String(), "admin-secret") || strings.Contains(recorder.Body.String(), "grpc-secret") {
+		t.Fatalf("config must be served with secrets redacted: %s", recorder.Body.String())
+	}
+
+	if recorder := admin(http.MethodGet, "/api/admin/unknown", ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("unknown admin route: got %d", recorder.Code)
+	}
+	anonymous := httptest.NewRecorder()
+	server.ServeHTTP(anonymous, httptest.NewRequest(http.MethodGet, "/api/admin/unknown", nil))
+	if anonymous.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown admin routes must require auth first, got %d", anonymous.Code)
+	}
+}
+
+func TestAdminCachePurge(t *testing.T) {
+	t.Parallel()
+
+	firmwareCache := t.TempDir()
+	if err := os.WriteFile(filepath.Join(firmwareCache, "entry.bin"), []byte("firmware"), 0o644); err != nil {
+		t.Fatalf("write cache entry: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(firmwareCache, "firmware-cache-123"), 0o755); err != nil {
+		t.Fatalf("create in-progress entry: %v", err)
+	}
+	server := NewServer(config.Config{AdminToken: "admin-secret", FirmwareCachePath: firmwareCache, PlatformIOCache: t.TempDir()}, nil, slog.New(slog.DiscardHandler))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/admin/cache/purge?include=firmware-cache", nil)
+	request.Header.Set("Authorization", "Bearer admin-secret")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	var payload struct {
+		Data jobs.CachePurgeResult `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("purge: status=%d err=%v", recorder.Code, err)
+	}
+	if payload.Data.FilesRemoved != 1 || payload.Data.BytesRemoved != int64(len("firmware")) {
+		t.Fatalf("unexpected purge result: %+v", payload.Data)
+	}
+	if _, err := os.Stat(filepath.Join(firmwareCache, "firmware-cache-123")); err != nil {
+		t.Fatalf("in-progress entry must survive the purge: %v", err)
+	}
+}
+
+func TestAdminClientCertificateAuth(t *testing.T) {
+	t.Parallel()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "admin CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("parse CA: %v", err)
+	}
+
+	issue := func(usage x509.ExtKeyUsage) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      pkix.Name{CommonName: "operator"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("issue certificate: %v", err)
+		}
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("parse certificate: %v", err)
+		}
+		return certificate
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	server := NewServer(config.Config{AdminClientCAs: pool, FirmwareCachePath: t.TempDir(), PlatformIOCache: t.TempDir()}, nil, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name string
+		tls  *tls.ConnectionState
+		want int
+	}{
+		{name: "client certificate", tls: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{issue(x509.ExtKeyUsageClientAuth)}}, want: http.StatusOK},
+		{name: "server certificate", tls: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{issue(x509.ExtKeyUsageServerAuth)}}, want: http.StatusUnauthorized},
+		{name: "no certificate", want: http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/api/admin/cache/purge", nil)
+		request.TLS = tc.tls
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("%s: got status %d, want %d", tc.name, recorder.Code, tc.want)
+		}
+	}
+}
+
+func TestAdminAbuseReport(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:      filepath.Join(workDir, "jobs"),
+		BuildLogsPath:     filepath.Join(workDir, "build-logs"),
+		MaxLogLines:       200,
+		CleanupInterval:   time.Hour,
+		AdminToken:        "admin-secret",
+		AbuseMinFailures:  3,
+		AbuseFailureRatio: 0.8,
+		AbuseWindow:       time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer admin-secret")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	report := serve(http.MethodGet, "/api/admin/abuse", "")
+	var envelope struct {
+		Data abuseReportResponse `json:"data"`
+	}
+	if report.Code != http.StatusOK || json.Unmarshal(report.Body.Bytes(), &envelope) != nil {
+		t.Fatalf("abuse report: %d %s", report.Code, report.Body.String())
+	}
+	if envelope.Data.Clients == nil || len(envelope.Data.Clients) != 0 {
+		t.Fatalf("expected an empty client list, got %s", report.Body.String())
+	}
+
+	if unknown := serve(http.MethodPost, "/api/admin/abuse/clear", `{"client":"203.0.113.9"}`); unknown.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an untracked client, got %d", unknown.Code)
+	}
+	if missing := serve(http.MethodPost, "/api/admin/abuse/clear", `{}`); missing.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a client, got %d", missing.Code)
+	}
+}
+
+func TestAdminModeration(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:            filepath.Join(workDir, "jobs"),
+		BuildLogsPath:           filepath.Join(workDir, "build-logs"),
+		MaxLogLines:             200,
+		CleanupInterval:         time.Hour,
+		BuildRateLimit:          10,
+		AdminToken:              "admin-secret",
+		ModerateAnonymousBuilds: true,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.RemoteAddr = "192.0.2.7:1000"
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	build := `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam","public":true}`
+	var created struct {
+		Data stateResponse `json:"data"`
+	}
+	var ids []string
+	for range 2 {
+		recorder := serve(http.MethodPost, "/api/jobs", build, "")
+		if recorder.Code != http.StatusCreated || json.Unmarshal(recorder.Body.Bytes(), &created) != nil || created.Data.Status != jobs.StatusPending {
+			t.Fatalf("expected a pending job, got %d %s", recorder.Code, recorder.Body.String())
+		}
+		ids = append(ids, created.Data.ID)
+	}
+
+	recorder := serve(http.MethodGet, "/api/admin/moderation", "", "admin-secret")
+	var pending struct {
+		Data adminQueueResponse `json:"data"`
+	}
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &pending) != nil {
+		t.Fatalf("moderation: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if len(pending.Data.Jobs) != 2 || pending.Data.Jobs[0].ID != ids[0] || pending.Data.Jobs[0].ClientIP != "192.0.2.7" {
+		t.Fatalf("unexpected moderation queue: %+v", pending.Data.Jobs)
+	}
+
+	if recorder := serve(http.MethodPost, "/api/admin/jobs/"+ids[0]+"/approve", "", "admin-secret"); recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"status":"queued"`) {
+		t.Fatalf("approve: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve(http.MethodPost, "/api/admin/jobs/"+ids[0]+"/reject", "", "admin-secret"); recorder.Code != http.StatusConflict || !strings.Contains(recorder.Body.String(), "JOB_NOT_PENDING") {
+		t.Fatalf("rejecting a queued job must conflict, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve(http.MethodPost, "/api/admin/jobs/"+ids[1]+"/reject", "", "admin-secret"); recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"status":"cancelled"`) {
+		t.Fatalf("reject: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve(http.MethodPost, "/api/admin/jobs/missing/approve", "", "admin-secret"); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown job, got %d", recorder.Code)
+	}
+	if recorder := serve(http.MethodPost, "/api/admin/jobs/"+ids[1]+"/approve", "", ""); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("approving must require admin auth, got %d", recorder.Code)
+	}
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	var output bytes.Buffer
+	server := NewServer(config.Config{AdminToken: "admin-secret"}, nil, logging.New(&output, logging.FormatJSON))
+	t.Cleanup(func() { logging.SetLevel(slog.LevelInfo) })
+
+	serve := func(method string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/api/admin/log-level", strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer admin-secret")
+		request.RemoteAddr = "203.0.113.9:1234"
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if current := serve(http.MethodGet, ""); current.Code != http.StatusOK || !strings.Contains(current.Body.String(), `"level":"info"`) {
+		t.Fatalf("unexpected log level: %d %s", current.Code, current.Body.String())
+	}
+	if invalid := serve(http.MethodPut, `{"level":"verbose"}`); invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected an unknown level to be rejected, got %d", invalid.Code)
+	}
+	changed := serve(http.MethodPut, `{"level":"debug"}`)
+	if changed.Code != http.StatusOK || !strings.Contains(changed.Body.String(), `"level":"debug"`) || logging.Level() != slog.LevelDebug {
+		t.Fatalf("unexpected response: %d %s", changed.Code, changed.Body.String())
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("expected one log record, got %q", output.String())
+	}
+	if record["msg"] != "admin: log level changed" || record["requestId"] != changed.Header().Get("X-Request-ID") || record["client"] != "203.0.113.9" {
+		t.Fatalf("expected the request fields in the record, got %v", record)
+	}
+}
+
+func TestAdminDebug(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		BuildRateLimit:  10,
+		AdminToken:      "admin-secret",
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "192.0.2.7")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	_, _, unsubscribe, err := manager.SubscribeLogs(state.ID, 0)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer unsubscribe()
+
+	admin := func(path string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Authorization", "Bearer admin-secret")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := admin("/api/admin/debug/dump?stacks=1")
+	var dump struct {
+		Data debugDumpResponse `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &dump); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected dump: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if dump.Data.Goroutines == 0 || dump.Data.Memory.HeapAllocBytes == 0 || !strings.Contains(dump.Data.Stacks, "goroutine ") {
+		t.Fatalf("expected runtime details, got %+v", dump.Data)
+	}
+	if len(dump.Data.Jobs.Queue.Order) != 1 || dump.Data.Jobs.Queue.Order[0] != state.ID || dump.Data.Jobs.Subscribers != 1 {
+		t.Fatalf("expected the queued job and its subscriber, got %+v", dump.Data.Jobs)
+	}
+	if len(dump.Data.Jobs.Jobs) != 1 || dump.Data.Jobs.Jobs[0].ID != state.ID || dump.Data.Jobs.Jobs[0].FullSubscribers != 0 {
+		t.Fatalf("unexpected job diagnostics: %+v", dump.Data.Jobs.Jobs)
+	}
+
+	if vars := admin("/api/admin/debug/vars"); vars.Code != http.StatusOK || !strings.Contains(vars.Body.String(), `"memstats"`) {
+		t.Fatalf("unexpected expvar response: %d", vars.Code)
+	}
+	if index := admin("/api/admin/debug/pprof/"); index.Code != http.StatusOK || !strings.Contains(index.Body.String(), "goroutine?debug=1") {
+		t.Fatalf("unexpected pprof index: %d %s", index.Code, index.Body.String())
+	}
+	if heap := admin("/api/admin/debug/pprof/heap?debug=1"); heap.Code != http.StatusOK || !strings.Contains(heap.Body.String(), "heap profile") {
+		t.Fatalf("unexpected heap profile: %d", heap.Code)
+	}
+
+	anonymous := httptest.NewRecorder()
+	server.ServeHTTP(anonymous, httptest.NewRequest(http.MethodGet, "/api/admin/debug/pprof/heap", nil))
+	if anonymous.Code != http.StatusUnauthorized {
+		t.Fatalf("expected profiles to require the admin token, got %d", anonymous.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+)
+
+const maxAuditQueryLimit = 1000
+
+type auditEventsResponse struct {
+	Events []audit.Event `json:"events"`
+}
+
+// recordAudit adds the client address, request ID and, unless the event
+// names one, the signed-in user as actor before recording event.
+func (s *Server) recordAudit(r *http.Request, requestID string, event audit.Event) {
+	if s.audit == nil {
+		return
+	}
+	event.IP = s.clientIP(r)
+	event.RequestID = requestID
+	if event.Actor == "" {
+		if user := s.sessionUser(r); user != nil {
+			event.Actor = user.ID
+		}
+	}
+	s.audit.Record(event)
+}
+
+// handleAdminAudit serves GET /api/admin/audit. The log is read from the
+// file sink, so the route is missing when events only go to syslog.
+func (s *Server) handleAdminAudit(w http.ResponseWriter, r *http.Request, requestID string) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Action: strings.TrimSpace(query.Get("action")),
+		Actor:  strings.TrimSpace(query.Get("actor")),
+		IP:     strings.TrimSpace(query.Get("ip")),
+		JobID:  strings.TrimSpace(query.Get("jobId")),
+		Limit:  100,
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxAuditQueryLimit {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", "limit must be between 1 and 1000", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", name+" must be an RFC 3339 time", nil)
+			return
+		}
+		*target = value
+	}
+
+	events, err := s.audit.Query(filter)
+	if errors.Is(err, audit.ErrNoFileSink) {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "admin: query audit log", "error", err)
+		s.writeError(w, http.StatusInternalServerError, requestID, "AUDIT_LOG_ERROR", "failed to read audit log", nil)
+		return
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, auditEventsResponse{Events: events})
+}
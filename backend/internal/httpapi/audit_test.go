@@ -0,0 +1,92 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestAdminAuditLog(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		BuildRateLimit:  1,
+		AdminToken:      "admin-secret",
+		AuditSinks:      []string{"file"},
+		AuditLogPath:    filepath.Join(workDir, "audit.jsonl"),
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.RemoteAddr = "198.51.100.7:4321"
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	build := `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam"}`
+	created := serve(http.MethodPost, "/api/jobs", build, "")
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create job: %d %s", created.Code, created.Body.String())
+	}
+	if limited := serve(http.MethodPost, "/api/jobs", build, ""); limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the second build to be rate limited, got %d", limited.Code)
+	}
+	if denied := serve(http.MethodGet, "/api/admin/queue", "", "wrong"); denied.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a wrong admin token to be rejected, got %d", denied.Code)
+	}
+
+	query := func(target string) []audit.Event {
+		t.Helper()
+		recorder := serve(http.MethodGet, target, "", "admin-secret")
+		var envelope struct {
+			Data auditEventsResponse `json:"data"`
+		}
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &envelope) != nil {
+			t.Fatalf("GET %s: %d %s", target, recorder.Code, recorder.Body.String())
+		}
+		return envelope.Data.Events
+	}
+
+	events := query("/api/admin/audit")
+	// The query itself is recorded before it runs, so it is the newest event.
+	want := []string{audit.ActionAdminRequest, audit.ActionAuthFailed, audit.ActionRateLimited, audit.ActionJobCreated}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, action := range want {
+		if events[i].Action != action || events[i].IP != "198.51.100.7" || events[i].RequestID == "" {
+			t.Fatalf("event %d: expected %s from the client, got %+v", i, action, events[i])
+		}
+	}
+	if events[3].JobID == "" || events[3].Details["device"] != "tbeam" || events[3].Details["visibility"] != "private" {
+		t.Fatalf("unexpected job event: %+v", events[3])
+	}
+
+	if auth := query("/api/admin/audit?action=auth&limit=5"); len(auth) != 1 || auth[0].Details["realm"] != "admin" {
+		t.Fatalf("expected the admin auth failure, got %+v", auth)
+	}
+	if recorder := serve(http.MethodGet, "/api/admin/audit?since=yesterday", "", "admin-secret"); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected an invalid since to be rejected, got %d", recorder.Code)
+	}
+}
@@ -1,7 +1,9 @@
 package httpapi
 
 import (
+	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"math/big"
 	"net"
@@ -37,9 +39,21 @@ func (s *Server) newCaptcha(remoteAddr string) (captchaResponse, error) {
 		return captchaResponse{}, err
 	}
 
+	var captchaImage string
+	if s.cfg.CaptchaImage {
+		rendered, err := renderCaptchaImage(question)
+		if err != nil {
+			return captchaResponse{}, err
+		}
+		captchaImage = base64.StdEncoding.EncodeToString(rendered)
+		// The text question would give the answer away; only the easter
+		// egg, if any, is still sent as text.
+		question = ""
+	}
+
 	withEasterEgg, err := maybeAddCaptchaEasterEgg(question)
 	if err == nil {
-		question = withEasterEgg
+		question = strings.TrimSuffix(withEasterEgg, "\n")
 	}
 
 	challengeID := generateRequestID()
@@ -48,7 +62,6 @@ func (s *Server) newCaptcha(remoteAddr string) (captchaResponse, error) {
 	s.captchaMu.Lock()
 	defer s.captchaMu.Unlock()
 	s.cleanupCaptchasLocked(now)
-	s.cleanupCaptchaSessionsLocked(now)
 	s.captchas[challengeID] = captchaChallenge{
 		answer:    strconv.FormatInt(answerValue, 10),
 		host:      host,
@@ -58,6 +71,7 @@ func (s *Server) newCaptcha(remoteAddr string) (captchaResponse, error) {
 	return captchaResponse{
 		CaptchaID: challengeID,
 		Question:  question,
+		Image:     captchaImage,
 		ExpiresAt: expiresAt,
 	}, nil
 }
@@ -78,7 +92,6 @@ func (s *Server) validateCaptcha(remoteAddr string, captchaID string, captchaAns
 	s.captchaMu.Lock()
 	defer s.captchaMu.Unlock()
 	s.cleanupCaptchasLocked(now)
-	s.cleanupCaptchaSessionsLocked(now)
 
 	challenge, ok := s.captchas[challengeID]
 	if !ok {
@@ -108,28 +121,24 @@ func (s *Server) validateCaptcha(remoteAddr string, captchaID string, captchaAns
 	return nil
 }
 
-func (s *Server) createCaptchaSession(remoteAddr string) (string, error) {
-	host := normalizeRemoteHost(remoteAddr)
-	now := time.Now().UTC()
-
+func (s *Server) createCaptchaSession(ctx context.Context, remoteAddr string) (string, error) {
 	sessionToken := generateRequestID()
 	if sessionToken == "" {
 		return "", fmt.Errorf("captcha session generation failed")
 	}
 
-	s.captchaMu.Lock()
-	defer s.captchaMu.Unlock()
-	s.cleanupCaptchasLocked(now)
-	s.cleanupCaptchaSessionsLocked(now)
-	s.captchaSessions[sessionToken] = captchaSession{
-		host:      host,
-		expiresAt: now.Add(captchaSessionTTL),
+	session := captchaSession{
+		host:      normalizeRemoteHost(remoteAddr),
+		expiresAt: time.Now().UTC().Add(captchaSessionTTL),
+	}
+	if err := s.store.putCaptchaSession(ctx, sessionToken, session); err != nil {
+		s.logger.ErrorContext(ctx, "captcha session", "error", err)
+		return "", fmt.Errorf("captcha session could not be stored")
 	}
-
 	return sessionToken, nil
 }
 
-func (s *Server) validateCaptchaSession(remoteAddr string, sessionToken string) error {
+func (s *Server) validateCaptchaSession(ctx context.Context, remoteAddr string, sessionToken string) error {
 	token := strings.TrimSpace(sessionToken)
 	if token == "" {
 		return fmt.Errorf("captcha session is missing")
@@ -141,26 +150,25 @@ func (s *Server) validateCaptchaSession(remoteAddr string, sessionToken string)
 	host := normalizeRemoteHost(remoteAddr)
 	now := time.Now().UTC()
 
-	s.captchaMu.Lock()
-	defer s.captchaMu.Unlock()
-	s.cleanupCaptchasLocked(now)
-	s.cleanupCaptchaSessionsLocked(now)
-
-	session, ok := s.captchaSessions[token]
+	session, ok, err := s.store.getCaptchaSession(ctx, token, now)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "captcha session", "error", err)
+		return fmt.Errorf("captcha session could not be checked")
+	}
 	if !ok {
 		return fmt.Errorf("captcha session is invalid or expired")
 	}
 	if session.host != host {
-		delete(s.captchaSessions, token)
+		if err := s.store.deleteCaptchaSession(ctx, token); err != nil {
+			s.logger.ErrorContext(ctx, "captcha session", "error", err)
+		}
 		return fmt.Errorf("captcha session is invalid for this client")
 	}
-	if now.After(session.expiresAt) {
-		delete(s.captchaSessions, token)
-		return fmt.Errorf("captcha session is expired")
-	}
 
 	session.expiresAt = now.Add(captchaSessionTTL)
-	s.captchaSessions[token] = session
+	if err := s.store.putCaptchaSession(ctx, token, session); err != nil {
+		s.logger.ErrorContext(ctx, "captcha session", "error", err)
+	}
 	return nil
 }
 
@@ -172,14 +180,6 @@ func (s *Server) cleanupCaptchasLocked(now time.Time) {
 	}
 }
 
-func (s *Server) cleanupCaptchaSessionsLocked(now time.Time) {
-	for sessionToken, session := range s.captchaSessions {
-		if now.After(session.expiresAt) {
-			delete(s.captchaSessions, sessionToken)
-		}
-	}
-}
-
 func randomCaptchaNumber(min int64, max int64) (int64, error) {
 	if max < min {
 		return 0, fmt.Errorf("captcha random range is invalid")
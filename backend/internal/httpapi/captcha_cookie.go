@@ -0,0 +1,51 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	captchaSessionCookieName = "mfb_captcha"
+	// csrfHeader must accompany requests that rely on the captcha session
+	// cookie. Browsers only let cross-origin pages set custom headers after
+	// a CORS preflight, which handleCORS refuses for unknown origins.
+	csrfHeader = "X-MFB-CSRF"
+)
+
+// requestCaptchaSession returns the captcha session token of the request
+// body or, with APP_CAPTCHA_SESSION_COOKIE, of the session cookie. A cookie
+// sent without the CSRF header, or by a cross-site page, writes 403
+// CSRF_CHECK_FAILED and returns false.
+func (s *Server) requestCaptchaSession(w http.ResponseWriter, r *http.Request, requestID string, bodyToken string) (string, bool) {
+	bodyToken = strings.TrimSpace(bodyToken)
+	if bodyToken != "" || !s.cfg.CaptchaSessionCookie {
+		return bodyToken, true
+	}
+	cookie, err := r.Cookie(captchaSessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return "", true
+	}
+	if strings.TrimSpace(r.Header.Get(csrfHeader)) == "" || r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		s.writeError(w, http.StatusForbidden, requestID, "CSRF_CHECK_FAILED", "the captcha session cookie requires the "+csrfHeader+" header", nil)
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// setCaptchaSessionCookie hands token out as the captcha session cookie,
+// scoped to the API, unreadable by scripts and never sent cross-site.
+func (s *Server) setCaptchaSessionCookie(w http.ResponseWriter, r *http.Request, token string) {
+	if !s.cfg.CaptchaSessionCookie {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     captchaSessionCookieName,
+		Value:    token,
+		Path:     "/api/",
+		MaxAge:   int(captchaSessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https"),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
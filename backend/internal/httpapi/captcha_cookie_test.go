@@ -0,0 +1,81 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestCaptchaSessionCookie(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:         filepath.Join(workDir, "jobs"),
+		BuildLogsPath:        filepath.Join(workDir, "build-logs"),
+		MaxLogLines:          200,
+		CleanupInterval:      time.Hour,
+		BuildRateLimit:       10,
+		RequireCaptcha:       true,
+		CaptchaProvider:      "math",
+		CaptchaSessionCookie: true,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	challenge, err := server.newCaptcha("192.0.2.7:1000")
+	if err != nil {
+		t.Fatalf("newCaptcha failed: %v", err)
+	}
+	server.captchaMu.Lock()
+	answer := server.captchas[challenge.CaptchaID].answer
+	server.captchaMu.Unlock()
+
+	build := func(body string, cookie *http.Cookie, csrf bool) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body))
+		request.RemoteAddr = "192.0.2.7:1000"
+		request.Header.Set("X-Forwarded-Proto", "https")
+		if cookie != nil {
+			request.AddCookie(cookie)
+		}
+		if csrf {
+			request.Header.Set(csrfHeader, "1")
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	const job = `"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam"`
+	first := build(`{`+job+`,"captchaId":"`+challenge.CaptchaID+`","captchaAnswer":"`+answer+`"}`, nil, false)
+	if first.Code != http.StatusCreated || !strings.Contains(first.Body.String(), "captchaSessionToken") {
+		t.Fatalf("first build: %d %s", first.Code, first.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, candidate := range first.Result().Cookies() {
+		if candidate.Name == captchaSessionCookieName {
+			cookie = candidate
+		}
+	}
+	if cookie == nil || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode || cookie.Path != "/api/" {
+		t.Fatalf("expected a secure HttpOnly strict captcha cookie, got %+v", cookie)
+	}
+
+	if reused := build(`{`+job+`}`, cookie, true); reused.Code != http.StatusCreated {
+		t.Fatalf("expected the cookie to stand in for the captcha, got %d %s", reused.Code, reused.Body.String())
+	}
+	if forged := build(`{`+job+`}`, cookie, false); forged.Code != http.StatusForbidden || !strings.Contains(forged.Body.String(), "CSRF_CHECK_FAILED") {
+		t.Fatalf("expected the cookie without the CSRF header to be refused, got %d %s", forged.Code, forged.Body.String())
+	}
+	if missing := build(`{`+job+`}`, nil, true); missing.Code != http.StatusBadRequest || !strings.Contains(missing.Body.String(), "INVALID_CAPTCHA") {
+		t.Fatalf("expected a captcha to be required without a session, got %d %s", missing.Code, missing.Body.String())
+	}
+}
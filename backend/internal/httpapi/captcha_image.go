@@ -0,0 +1,149 @@
+package httpapi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	captchaGlyphWidth  = 5
+	captchaGlyphHeight = 7
+	captchaImageHeight = 64
+	captchaImagePad    = 12
+)
+
+// captchaGlyphs is a 5x7 bitmap font covering the characters of the
+// generated questions.
+var captchaGlyphs = map[rune][captchaGlyphHeight]string{
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"####.", "....#", "....#", ".###.", "....#", "....#", "####."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'+': {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'*': {".....", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "....."},
+	'/': {"....#", "....#", "...#.", "..#..", ".#...", "#....", "#...."},
+	'=': {".....", ".....", "#####", ".....", "#####", ".....", "....."},
+	'?': {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+	'(': {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+	')': {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+	's': {".....", ".....", ".####", "#....", ".###.", "....#", "####."},
+	'q': {".....", ".....", ".####", "#...#", ".####", "....#", "....#"},
+	'r': {".....", ".....", "#.##.", "##..#", "#....", "#....", "#...."},
+	't': {".#...", ".#...", "####.", ".#...", ".#...", ".#..#", "..##."},
+	' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+}
+
+// renderCaptchaImage draws question as a PNG with per-glyph scale and
+// offset jitter, a wave distortion, crossing lines and speckle noise, so
+// reading it takes OCR rather than copying a JSON field.
+func renderCaptchaImage(question string) ([]byte, error) {
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+
+	type placedGlyph struct {
+		bitmap  [captchaGlyphHeight]string
+		x, y    int
+		scale   int
+		shear   float64
+		ink     color.RGBA
+		advance int
+	}
+	glyphs := make([]placedGlyph, 0, len(question))
+	x := captchaImagePad
+	for _, char := range question {
+		bitmap, ok := captchaGlyphs[char]
+		if !ok {
+			return nil, fmt.Errorf("captcha image: unsupported character %q", char)
+		}
+		scale := 5 + rng.IntN(2)
+		glyph := placedGlyph{
+			bitmap: bitmap,
+			x:      x,
+			y:      (captchaImageHeight-captchaGlyphHeight*scale)/2 + rng.IntN(9) - 4,
+			scale:  scale,
+			shear:  rng.Float64()*0.5 - 0.25,
+			ink:    color.RGBA{uint8(rng.IntN(90)), uint8(rng.IntN(90)), uint8(40 + rng.IntN(100)), 255},
+		}
+		glyph.advance = captchaGlyphWidth*scale + 2 + rng.IntN(5)
+		if char == ' ' {
+			glyph.advance = 2*scale + rng.IntN(4)
+		}
+		x += glyph.advance
+		glyphs = append(glyphs, glyph)
+	}
+	width := x + captchaImagePad
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, captchaImageHeight))
+	for py := 0; py < captchaImageHeight; py++ {
+		for px := 0; px < width; px++ {
+			shade := uint8(225 + rng.IntN(30))
+			canvas.SetRGBA(px, py, color.RGBA{shade, shade, uint8(215 + rng.IntN(40)), 255})
+		}
+	}
+
+	amplitude := 2 + rng.Float64()*3
+	period := 30 + rng.Float64()*40
+	phase := rng.Float64() * 2 * math.Pi
+	for _, glyph := range glyphs {
+		for row, line := range glyph.bitmap {
+			for column, cell := range line {
+				if cell != '#' {
+					continue
+				}
+				for dy := 0; dy < glyph.scale; dy++ {
+					for dx := 0; dx < glyph.scale; dx++ {
+						py := glyph.y + row*glyph.scale + dy
+						px := glyph.x + column*glyph.scale + dx + int(glyph.shear*float64(py-captchaImageHeight/2))
+						py += int(amplitude * math.Sin(float64(px)/period*2*math.Pi+phase))
+						if image.Pt(px, py).In(canvas.Rect) {
+							canvas.SetRGBA(px, py, glyph.ink)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for line := 0; line < 4+rng.IntN(3); line++ {
+		drawCaptchaLine(canvas, rng, color.RGBA{uint8(rng.IntN(120)), uint8(rng.IntN(120)), uint8(rng.IntN(160)), 255})
+	}
+	for speck := 0; speck < width*captchaImageHeight/12; speck++ {
+		shade := uint8(rng.IntN(200))
+		canvas.SetRGBA(rng.IntN(width), rng.IntN(captchaImageHeight), color.RGBA{shade, shade, shade, 255})
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, canvas); err != nil {
+		return nil, fmt.Errorf("captcha image: %w", err)
+	}
+	return encoded.Bytes(), nil
+}
+
+// drawCaptchaLine strokes a two pixel wide line across the whole image.
+func drawCaptchaLine(canvas *image.RGBA, rng *rand.Rand, ink color.RGBA) {
+	bounds := canvas.Rect
+	x0, y0 := 0.0, float64(rng.IntN(bounds.Dy()))
+	x1, y1 := float64(bounds.Dx()-1), float64(rng.IntN(bounds.Dy()))
+	steps := bounds.Dx() * 2
+	for step := 0; step <= steps; step++ {
+		ratio := float64(step) / float64(steps)
+		px := int(x0 + (x1-x0)*ratio)
+		py := int(y0 + (y1-y0)*ratio)
+		for _, point := range []image.Point{{px, py}, {px, py + 1}} {
+			if point.In(bounds) {
+				canvas.SetRGBA(point.X, point.Y, ink)
+			}
+		}
+	}
+}
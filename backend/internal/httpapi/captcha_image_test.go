@@ -0,0 +1,69 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"log/slog"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestRenderCaptchaImage(t *testing.T) {
+	t.Parallel()
+
+	question := "(sqrt(144) - 3) * 4 = ?"
+	rendered, err := renderCaptchaImage(question)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(rendered))
+	if err != nil {
+		t.Fatalf("decode PNG: %v", err)
+	}
+	if bounds := decoded.Bounds(); bounds.Dy() != captchaImageHeight || bounds.Dx() < len(question)*captchaGlyphWidth*5 {
+		t.Fatalf("unexpected image size %v", bounds)
+	}
+
+	for range 50 {
+		generated, _, err := generateCaptchaQuestion()
+		if err != nil {
+			t.Fatalf("generate question: %v", err)
+		}
+		if _, err := renderCaptchaImage(generated); err != nil {
+			t.Fatalf("render %q: %v", generated, err)
+		}
+	}
+
+	if _, err := renderCaptchaImage("1 + x"); err == nil {
+		t.Fatalf("expected an error for a character without a glyph")
+	}
+}
+
+func TestCaptchaImageChallenge(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{CaptchaImage: true}, nil, slog.New(slog.DiscardHandler))
+	challenge, err := server.newCaptcha("127.0.0.1:10001")
+	if err != nil {
+		t.Fatalf("newCaptcha failed: %v", err)
+	}
+	if challenge.Question != "" && challenge.Question != captchaEasterEggMessage {
+		t.Fatalf("the question must not be sent as text: %q", challenge.Question)
+	}
+	rendered, err := base64.StdEncoding.DecodeString(challenge.Image)
+	if err != nil {
+		t.Fatalf("decode base64 image: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(rendered)); err != nil {
+		t.Fatalf("decode PNG: %v", err)
+	}
+
+	server.captchaMu.Lock()
+	answer := server.captchas[challenge.CaptchaID].answer
+	server.captchaMu.Unlock()
+	if err := server.validateCaptcha("127.0.0.1:10001", challenge.CaptchaID, answer); err != nil {
+		t.Fatalf("validateCaptcha failed: %v", err)
+	}
+}
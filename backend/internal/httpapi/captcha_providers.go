@@ -0,0 +1,156 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+const captchaVerifyTimeout = 10 * time.Second
+
+// maxCaptchaTokenLength bounds the widget response token forwarded to the
+// verification endpoint; real tokens are a few kilobytes at most.
+const maxCaptchaTokenLength = 8192
+
+// errCaptchaUnavailable wraps failures to reach the verification endpoint,
+// as opposed to answers the provider rejected.
+var errCaptchaUnavailable = errors.New("captcha verification is unavailable")
+
+// captchaProvider issues and verifies the challenges behind APP_REQUIRE_CAPTCHA.
+type captchaProvider interface {
+	name() string
+	// challenge returns what the client needs to solve a captcha: a
+	// question for the math captcha, a site key for widget providers.
+	challenge(remoteAddr string) (captchaResponse, error)
+	// verify checks an answer; captchaID is empty for widget providers,
+	// whose response token arrives as the answer.
+	verify(ctx context.Context, remoteAddr string, captchaID string, answer string) error
+}
+
+func newCaptchaProvider(cfg config.Config, s *Server) captchaProvider {
+	verifyURL := cfg.CaptchaVerifyURL
+	switch cfg.CaptchaProvider {
+	case "turnstile":
+		if verifyURL == "" {
+			verifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+		}
+	case "hcaptcha":
+		if verifyURL == "" {
+			verifyURL = "https://api.hcaptcha.com/siteverify"
+		}
+	case "recaptcha":
+		if verifyURL == "" {
+			verifyURL = "https://www.google.com/recaptcha/api/siteverify"
+		}
+	default:
+		return mathCaptcha{s: s}
+	}
+	return &siteVerifyCaptcha{
+		provider:  cfg.CaptchaProvider,
+		siteKey:   cfg.CaptchaSiteKey,
+		secret:    cfg.CaptchaSecretKey,
+		verifyURL: verifyURL,
+		minScore:  cfg.CaptchaMinScore,
+		client:    &http.Client{Timeout: captchaVerifyTimeout},
+	}
+}
+
+// mathCaptcha is the built-in arithmetic challenge, bound to the client host.
+type mathCaptcha struct {
+	s *Server
+}
+
+func (m mathCaptcha) name() string {
+	return "math"
+}
+
+func (m mathCaptcha) challenge(remoteAddr string) (captchaResponse, error) {
+	return m.s.newCaptcha(remoteAddr)
+}
+
+func (m mathCaptcha) verify(_ context.Context, remoteAddr string, captchaID string, answer string) error {
+	return m.s.validateCaptcha(remoteAddr, captchaID, answer)
+}
+
+// siteVerifyCaptcha verifies Cloudflare Turnstile, hCaptcha and reCAPTCHA
+// widget tokens. The three share the siteverify protocol: a form POST of
+// secret, response and remoteip answered with {"success": bool, ...}.
+type siteVerifyCaptcha struct {
+	provider  string
+	siteKey   string
+	secret    string
+	verifyURL string
+	minScore  float64
+	client    *http.Client
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+	// Score is only reported by reCAPTCHA v3 (and hCaptcha Enterprise).
+	Score *float64 `json:"score"`
+}
+
+func (c *siteVerifyCaptcha) name() string {
+	return c.provider
+}
+
+func (c *siteVerifyCaptcha) challenge(string) (captchaResponse, error) {
+	return captchaResponse{Provider: c.provider, SiteKey: c.siteKey}, nil
+}
+
+func (c *siteVerifyCaptcha) verify(ctx context.Context, remoteAddr string, _ string, answer string) error {
+	token := strings.TrimSpace(answer)
+	if token == "" {
+		return fmt.Errorf("captcha is required")
+	}
+	if len(token) > maxCaptchaTokenLength {
+		return fmt.Errorf("captcha is invalid")
+	}
+
+	form := url.Values{"secret": {c.secret}, "response": {token}}
+	if host := normalizeRemoteHost(remoteAddr); host != "" {
+		form.Set("remoteip", host)
+	}
+	if c.provider == "hcaptcha" {
+		// hCaptcha rejects tokens issued for another site key when given one.
+		form.Set("sitekey", c.siteKey)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errCaptchaUnavailable, err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errCaptchaUnavailable, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s siteverify returned %s", errCaptchaUnavailable, c.provider, response.Status)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(io.LimitReader(response.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("%w: decode %s siteverify response: %v", errCaptchaUnavailable, c.provider, err)
+	}
+	if !result.Success {
+		if len(result.ErrorCodes) > 0 {
+			return fmt.Errorf("captcha is invalid (%s)", strings.Join(result.ErrorCodes, ", "))
+		}
+		return fmt.Errorf("captcha is invalid")
+	}
+	if result.Score != nil && *result.Score < c.minScore {
+		return fmt.Errorf("captcha score is too low")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestSiteVerifyCaptcha(t *testing.T) {
+	t.Parallel()
+
+	verifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("secret") != "s3cret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.PostFormValue("remoteip") != "203.0.113.7" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": false, "error-codes": []string{"bad-remoteip"}})
+			return
+		}
+		switch r.PostFormValue("response") {
+		case "human":
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": true})
+		case "bot":
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "score": 0.1})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": false, "error-codes": []string{"invalid-input-response"}})
+		}
+	}))
+	defer verifier.Close()
+
+	cfg := config.Config{
+		RequireCaptcha:   true,
+		CaptchaProvider:  "recaptcha",
+		CaptchaSiteKey:   "site",
+		CaptchaSecretKey: "s3cret",
+		CaptchaVerifyURL: verifier.URL,
+		CaptchaMinScore:  0.5,
+	}
+	server := NewServer(cfg, nil, slog.New(slog.DiscardHandler))
+
+	challenge, err := server.captcha.challenge("203.0.113.7:1000")
+	if err != nil || challenge.Provider != "recaptcha" || challenge.SiteKey != "site" || challenge.Question != "" {
+		t.Fatalf("unexpected widget challenge: %+v, %v", challenge, err)
+	}
+
+	ctx := context.Background()
+	if err := server.captcha.verify(ctx, "203.0.113.7:1000", "", "human"); err != nil {
+		t.Fatalf("expected the token to verify: %v", err)
+	}
+	for token, want := range map[string]string{"": "required", "forged": "invalid-input-response", "bot": "score"} {
+		if err := server.captcha.verify(ctx, "203.0.113.7:1000", "", token); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("verify(%q) = %v, want an error mentioning %q", token, err, want)
+		}
+	}
+
+	cfg.CaptchaSecretKey = "wrong"
+	misconfigured := NewServer(cfg, nil, slog.New(slog.DiscardHandler))
+	if err := misconfigured.captcha.verify(ctx, "203.0.113.7:1000", "", "human"); !errors.Is(err, errCaptchaUnavailable) {
+		t.Fatalf("expected a provider failure to be reported as unavailable, got %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	misconfigured.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/repos/discover",
+		strings.NewReader(`{"repoUrl":"https://github.com/example/repo.git","captchaAnswer":"human"}`)))
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "CAPTCHA_UNAVAILABLE") {
+		t.Fatalf("expected 503 CAPTCHA_UNAVAILABLE, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -1,8 +1,8 @@
 package httpapi
 
 import (
-	"io"
-	"log"
+	"context"
+	"log/slog"
 	"testing"
 
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
@@ -11,7 +11,7 @@ import (
 func TestCaptchaLifecycle(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
 	challenge, err := server.newCaptcha("127.0.0.1:10001")
 	if err != nil {
@@ -40,7 +40,7 @@ func TestCaptchaLifecycle(t *testing.T) {
 func TestCaptchaRejectsWrongAnswer(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 	challenge, err := server.newCaptcha("127.0.0.1:20002")
 	if err != nil {
 		t.Fatalf("newCaptcha failed: %v", err)
@@ -54,7 +54,7 @@ func TestCaptchaRejectsWrongAnswer(t *testing.T) {
 func TestCaptchaConcurrentAccess(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
 	// Test multiple concurrent captcha creations
 	const numGoroutines = 10
@@ -78,7 +78,7 @@ func TestCaptchaConcurrentAccess(t *testing.T) {
 func TestCaptchaDifferentIPAddresses(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
 	ips := []string{"127.0.0.1:40001", "192.168.1.1:40002", "10.0.0.1:40003"}
 
@@ -105,7 +105,7 @@ func TestCaptchaDifferentIPAddresses(t *testing.T) {
 func TestCaptchaCleanup(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
 	// Create a captcha
 	challenge, err := server.newCaptcha("127.0.0.1:50001")
@@ -139,7 +139,7 @@ func TestCaptchaCleanup(t *testing.T) {
 func TestCaptchaInvalidID(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
 	// Test with non-existent captcha ID
 	err := server.validateCaptcha("127.0.0.1:60001", "non-existent-id", "any-answer")
@@ -151,22 +151,22 @@ func TestCaptchaInvalidID(t *testing.T) {
 func TestCaptchaSessionReuse(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
-	sessionToken, err := server.createCaptchaSession("127.0.0.1:70001")
+	sessionToken, err := server.createCaptchaSession(context.Background(), "127.0.0.1:70001")
 	if err != nil {
 		t.Fatalf("createCaptchaSession failed: %v", err)
 	}
 
-	if err := server.validateCaptchaSession("127.0.0.1:70001", sessionToken); err != nil {
+	if err := server.validateCaptchaSession(context.Background(), "127.0.0.1:70001", sessionToken); err != nil {
 		t.Fatalf("validateCaptchaSession failed: %v", err)
 	}
 
-	if err := server.validateCaptchaSession("127.0.0.1:70001", sessionToken); err != nil {
+	if err := server.validateCaptchaSession(context.Background(), "127.0.0.1:70001", sessionToken); err != nil {
 		t.Fatalf("captcha session should be reusable within session TTL: %v", err)
 	}
 
-	if err := server.validateCaptchaSession("127.0.0.2:70002", sessionToken); err == nil {
+	if err := server.validateCaptchaSession(context.Background(), "127.0.0.2:70002", sessionToken); err == nil {
 		t.Fatalf("captcha session must be bound to client host")
 	}
 }
@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+const maxClusterJobsLimit = 1000
+
+var jobStatuses = []jobs.Status{
+	jobs.StatusPending, jobs.StatusQueued, jobs.StatusRunning,
+	jobs.StatusSuccess, jobs.StatusFailed, jobs.StatusCancelled,
+}
+
+// handleAdminClusterJobs lists the jobs of every node sharing the job
+// registry (APP_JOB_REGISTRY), newest first, and answers 404 without it.
+func (s *Server) handleAdminClusterJobs(w http.ResponseWriter, r *http.Request, requestID string) {
+	if !s.cfg.JobRegistry {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	query := r.URL.Query()
+	filter := jobs.ClusterFilter{
+		Node:   strings.TrimSpace(query.Get("node")),
+		Status: jobs.Status(strings.TrimSpace(query.Get("status"))),
+		Limit:  100,
+	}
+	if filter.Status != "" && !slices.Contains(jobStatuses, filter.Status) {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", "status must be a job status", nil)
+		return
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxClusterJobsLimit {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", "limit must be between 1 and 1000", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	cluster, err := s.manager.ClusterJobs(r.Context(), filter)
+	if errors.Is(err, jobs.ErrRegistryDisabled) {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "admin: query job registry", "error", err)
+		s.writeError(w, http.StatusServiceUnavailable, requestID, "REGISTRY_UNAVAILABLE", "job registry is unavailable", nil)
+		return
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, cluster)
+}
+
+// clusterHealthResponse adds up the builds of every node that reported to
+// the job registry within the last heartbeats.
+type clusterHealthResponse struct {
+	Nodes            []jobs.NodeStatus `json:"nodes"`
+	Pending          int               `json:"pending"`
+	Queued           int               `json:"queued"`
+	Running          int               `json:"running"`
+	ConcurrentBuilds int               `json:"concurrentBuilds"`
+}
+
+// handleClusterHealthz serves the combined cluster view from the job
+// registry heartbeats, so one request covers every node without this node
+// contacting its peers.
+func (s *Server) handleClusterHealthz(w http.ResponseWriter, r *http.Request, requestID string) {
+	if !s.cfg.JobRegistry || s.manager == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	nodes, err := s.manager.ClusterNodes(r.Context())
+	if errors.Is(err, jobs.ErrRegistryDisabled) {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "cluster healthz", "error", err)
+		s.writeError(w, http.StatusServiceUnavailable, requestID, "REGISTRY_UNAVAILABLE", "job registry is unavailable", nil)
+		return
+	}
+
+	response := clusterHealthResponse{Nodes: nodes}
+	for _, node := range nodes {
+		response.Pending += node.Pending
+		response.Queued += node.Queued
+		response.Running += node.Running
+		response.ConcurrentBuilds += node.ConcurrentBuilds
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
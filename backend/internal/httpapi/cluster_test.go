@@ -0,0 +1,133 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestAdminClusterJobs(t *testing.T) {
+	t.Parallel()
+
+	redisServer := miniredis.RunT(t)
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		Retention:       time.Hour,
+		BuildRateLimit:  5,
+		AdminToken:      "admin-secret",
+		RedisURL:        "redis://" + redisServer.Addr() + "/0",
+		RedisKeyPrefix:  "test:",
+		JobRegistry:     true,
+		NodeName:        "builder-1",
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer admin-secret")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if created := serve(http.MethodPost, "/api/jobs", `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam"}`); created.Code != http.StatusCreated {
+		t.Fatalf("create job: %d %s", created.Code, created.Body.String())
+	}
+
+	var envelope struct {
+		Data jobs.ClusterJobs `json:"data"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(envelope.Data.Jobs) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the job never reached the registry")
+		}
+		time.Sleep(10 * time.Millisecond)
+		recorder := serve(http.MethodGet, "/api/admin/cluster/jobs?status=queued&limit=10", "")
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &envelope) != nil {
+			t.Fatalf("cluster jobs: %d %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if job := envelope.Data.Jobs[0]; job.Node != "builder-1" || job.Device != "tbeam" {
+		t.Fatalf("unexpected registry entry: %+v", job)
+	}
+	if len(envelope.Data.Nodes) != 1 || envelope.Data.Nodes[0].Status[jobs.StatusQueued] != 1 {
+		t.Fatalf("unexpected node summaries: %+v", envelope.Data.Nodes)
+	}
+
+	if invalid := serve(http.MethodGet, "/api/admin/cluster/jobs?status=done", ""); invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected an unknown status to be rejected, got %d", invalid.Code)
+	}
+	redisServer.Close()
+	if unavailable := serve(http.MethodGet, "/api/admin/cluster/jobs", ""); unavailable.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected REGISTRY_UNAVAILABLE without redis, got %d", unavailable.Code)
+	}
+}
+
+func TestClusterHealthz(t *testing.T) {
+	t.Parallel()
+
+	redisServer := miniredis.RunT(t)
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:     filepath.Join(workDir, "jobs"),
+		BuildLogsPath:    filepath.Join(workDir, "build-logs"),
+		MaxLogLines:      200,
+		CleanupInterval:  time.Hour,
+		Retention:        time.Hour,
+		ConcurrentBuilds: 2,
+		RedisURL:         "redis://" + redisServer.Addr() + "/0",
+		RedisKeyPrefix:   "test:",
+		JobRegistry:      true,
+		NodeName:         "builder-1",
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+	// A peer's heartbeat, as written by its registry.
+	redisServer.Set("test:registry:node:builder-2", `{"node":"builder-2","version":"dev","concurrentBuilds":3,"updatedAt":"2026-01-01T00:00:00Z","draining":true,"queued":4,"running":3}`)
+	redisServer.ZAdd("test:registry:nodes", float64(time.Now().UnixMilli()), "builder-2")
+
+	var envelope struct {
+		Data clusterHealthResponse `json:"data"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(envelope.Data.Nodes) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the local heartbeat never reached the registry: %+v", envelope.Data)
+		}
+		time.Sleep(10 * time.Millisecond)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/cluster/healthz", nil))
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &envelope) != nil {
+			t.Fatalf("cluster healthz: %d %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if response := envelope.Data; response.Nodes[0].Node != "builder-1" || !response.Nodes[1].Draining ||
+		response.Queued != 4 || response.Running != 3 || response.ConcurrentBuilds != 5 {
+		t.Fatalf("unexpected cluster health: %+v", response)
+	}
+
+	redisServer.Close()
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/cluster/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "REGISTRY_UNAVAILABLE") {
+		t.Fatalf("expected REGISTRY_UNAVAILABLE without redis, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
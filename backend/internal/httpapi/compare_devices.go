@@ -0,0 +1,103 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+type compareDevicesRequest struct {
+	RepoURL             string `json:"repoUrl"`
+	BaseRef             string `json:"baseRef"`
+	HeadRef             string `json:"headRef"`
+	CaptchaID           string `json:"captchaId,omitempty"`
+	CaptchaAnswer       string `json:"captchaAnswer,omitempty"`
+	CaptchaSessionToken string `json:"captchaSessionToken,omitempty"`
+}
+
+type compareDevicesResponse struct {
+	RepoURL             string                 `json:"repoUrl"`
+	BaseRef             string                 `json:"baseRef"`
+	HeadRef             string                 `json:"headRef"`
+	BaseCommit          string                 `json:"baseCommit,omitempty"`
+	HeadCommit          string                 `json:"headCommit,omitempty"`
+	Added               []discoveredDeviceView `json:"added"`
+	Removed             []discoveredDeviceView `json:"removed"`
+	Changed             []deviceDiffView       `json:"changed"`
+	Unchanged           int                    `json:"unchanged"`
+	CaptchaSessionToken string                 `json:"captchaSessionToken,omitempty"`
+}
+
+type deviceDiffView struct {
+	Name              string               `json:"name"`
+	Fields            []string             `json:"fields"`
+	BuildFlagsAdded   []string             `json:"buildFlagsAdded"`
+	BuildFlagsRemoved []string             `json:"buildFlagsRemoved"`
+	LibDepsAdded      []string             `json:"libDepsAdded"`
+	LibDepsRemoved    []string             `json:"libDepsRemoved"`
+	Before            discoveredDeviceView `json:"before"`
+	After             discoveredDeviceView `json:"after"`
+}
+
+// handleCompareDevices reports the devices added, removed and changed
+// between two refs of a repository, e.g. two release tags.
+func (s *Server) handleCompareDevices(w http.ResponseWriter, r *http.Request, requestID string) {
+	var req compareDevicesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	req.RepoURL = jobs.NormalizeRepoURL(req.RepoURL)
+
+	remoteIP := s.clientIP(r)
+	sessionToken, ok := s.requestCaptchaSession(w, r, requestID, req.CaptchaSessionToken)
+	if !ok {
+		return
+	}
+	captchaSessionToken, ok := s.checkCaptcha(w, r, requestID, remoteIP, sessionToken, req.CaptchaID, req.CaptchaAnswer)
+	if !ok {
+		return
+	}
+
+	comparison, err := s.manager.CompareDevices(r.Context(), req.RepoURL, req.BaseRef, req.HeadRef)
+	if errors.Is(err, jobs.ErrRepoNotAllowed) {
+		s.writeRepoNotAllowed(w, r, requestID, req.RepoURL, err)
+		return
+	}
+	if errors.Is(err, jobs.ErrDiscoveryBusy) {
+		s.writeDiscoveryBusy(w, requestID, err)
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, requestID, "DISCOVERY_FAILED", err.Error(), nil)
+		return
+	}
+
+	data := compareDevicesResponse{
+		RepoURL:             req.RepoURL,
+		BaseRef:             req.BaseRef,
+		HeadRef:             req.HeadRef,
+		BaseCommit:          comparison.BaseCommit,
+		HeadCommit:          comparison.HeadCommit,
+		Added:               toDiscoveredDeviceViews(comparison.Added),
+		Removed:             toDiscoveredDeviceViews(comparison.Removed),
+		Changed:             make([]deviceDiffView, 0, len(comparison.Changed)),
+		Unchanged:           comparison.Unchanged,
+		CaptchaSessionToken: captchaSessionToken,
+	}
+	for _, diff := range comparison.Changed {
+		views := toDiscoveredDeviceViews([]jobs.DiscoveredDevice{diff.Before, diff.After})
+		data.Changed = append(data.Changed, deviceDiffView{
+			Name:              diff.After.Name,
+			Fields:            diff.Fields,
+			BuildFlagsAdded:   diff.BuildFlagsAdded,
+			BuildFlagsRemoved: diff.BuildFlagsRemoved,
+			LibDepsAdded:      diff.LibDepsAdded,
+			LibDepsRemoved:    diff.LibDepsRemoved,
+			Before:            views[0],
+			After:             views[1],
+		})
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, data)
+}
@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"bytes"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+const adminPprofPrefix = "/api/admin/debug/pprof/"
+
+// handleAdminPprof serves net/http/pprof under the admin namespace, so
+// `go tool pprof` can be pointed at a production backend with the admin
+// token. The package also registers itself on http.DefaultServeMux, which
+// the backend never serves.
+func (s *Server) handleAdminPprof(w http.ResponseWriter, r *http.Request) {
+	switch name := strings.TrimPrefix(r.URL.Path, adminPprofPrefix); name {
+	case "":
+		// Index only lists profiles under its own /debug/pprof/ path; its
+		// links are relative, so they resolve under this prefix too.
+		index := r.Clone(r.Context())
+		index.URL.Path = "/debug/pprof/"
+		pprof.Index(w, index)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+// handleAdminVars serves the expvar variables: the command line and
+// runtime.MemStats.
+func (s *Server) handleAdminVars(w http.ResponseWriter, r *http.Request) {
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+type debugMemory struct {
+	HeapAllocBytes uint64     `json:"heapAllocBytes"`
+	HeapInuseBytes uint64     `json:"heapInuseBytes"`
+	HeapObjects    uint64     `json:"heapObjects"`
+	SysBytes       uint64     `json:"sysBytes"`
+	NumGC          uint32     `json:"numGc"`
+	LastGC         *time.Time `json:"lastGc,omitempty"`
+}
+
+// debugDumpResponse is the runtime state worth a first look when memory
+// grows: goroutines, the heap, and the log buffers, log stream subscribers
+// and queue of the job manager.
+type debugDumpResponse struct {
+	GoVersion  string           `json:"goVersion"`
+	Goroutines int              `json:"goroutines"`
+	Memory     debugMemory      `json:"memory"`
+	Jobs       jobs.Diagnostics `json:"jobs"`
+	// Stacks holds every goroutine's stack, as pprof's goroutine?debug=2,
+	// when requested with ?stacks=1.
+	Stacks string `json:"stacks,omitempty"`
+}
+
+func (s *Server) handleAdminDebugDump(w http.ResponseWriter, r *http.Request, requestID string) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	response := debugDumpResponse{
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: debugMemory{
+			HeapAllocBytes: stats.HeapAlloc,
+			HeapInuseBytes: stats.HeapInuse,
+			HeapObjects:    stats.HeapObjects,
+			SysBytes:       stats.Sys,
+			NumGC:          stats.NumGC,
+		},
+		Jobs: s.manager.Diagnostics(),
+	}
+	if stats.LastGC > 0 {
+		lastGC := time.Unix(0, int64(stats.LastGC)).UTC()
+		response.Memory.LastGC = &lastGC
+	}
+	if value := r.URL.Query().Get("stacks"); value == "1" || value == "true" {
+		var stacks bytes.Buffer
+		if err := rpprof.Lookup("goroutine").WriteTo(&stacks, 2); err != nil {
+			s.writeError(w, http.StatusInternalServerError, requestID, "INTERNAL_ERROR", "failed to dump goroutines", nil)
+			return
+		}
+		response.Stacks = stacks.String()
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
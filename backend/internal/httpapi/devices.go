@@ -0,0 +1,217 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+const (
+	defaultDeviceSearchLimit = 20
+	maxDeviceSearchLimit     = 100
+	maxDeviceSearchQuery     = 64
+)
+
+type deviceCatalogResponse struct {
+	RefreshedAt *time.Time               `json:"refreshedAt,omitempty"`
+	Repos       []deviceCatalogEntryView `json:"repos"`
+}
+
+type deviceCatalogEntryView struct {
+	RepoURL       string                          `json:"repoUrl"`
+	Ref           string                          `json:"ref"`
+	Commit        string                          `json:"commit,omitempty"`
+	Release       bool                            `json:"release"`
+	RefreshedAt   time.Time                       `json:"refreshedAt"`
+	Error         string                          `json:"error,omitempty"`
+	Devices       []string                        `json:"devices"`
+	DeviceInfo    []discoveredDeviceView          `json:"deviceInfo"`
+	Platforms     []platformCount                 `json:"platforms"`
+	DeviceOptions map[string]discoverBuildOptions `json:"deviceOptions,omitempty"`
+}
+
+// handleDeviceCatalog serves the background-discovered devices of the
+// featured repositories. Optional repoUrl, ref and platform query
+// parameters narrow the result, as do tag parameters (repeated or
+// comma-separated), which must all match.
+func (s *Server) handleDeviceCatalog(w http.ResponseWriter, r *http.Request, requestID string) {
+	query := r.URL.Query()
+	filter := jobs.DeviceFilter{
+		Platform: strings.ToLower(strings.TrimSpace(query.Get("platform"))),
+		Tags:     jobs.ParseDeviceTags(query["tag"]...),
+	}
+	if err := jobs.ValidateDeviceFilter(filter); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_FILTER", err.Error(), nil)
+		return
+	}
+	repoURL := jobs.NormalizeRepoURL(query.Get("repoUrl"))
+	ref := strings.TrimSpace(query.Get("ref"))
+
+	var (
+		entries     []jobs.CatalogEntry
+		refreshedAt time.Time
+	)
+	if s.manager != nil {
+		entries, refreshedAt = s.manager.DeviceCatalog()
+	}
+
+	response := deviceCatalogResponse{Repos: make([]deviceCatalogEntryView, 0, len(entries))}
+	if !refreshedAt.IsZero() {
+		response.RefreshedAt = &refreshedAt
+	}
+	for _, entry := range entries {
+		if repoURL != "" && entry.RepoURL != repoURL {
+			continue
+		}
+		if ref != "" && entry.Ref != ref {
+			continue
+		}
+		devices := jobs.FilterDevices(entry.Devices, filter)
+		response.Repos = append(response.Repos, deviceCatalogEntryView{
+			RepoURL:       entry.RepoURL,
+			Ref:           entry.Ref,
+			Commit:        entry.Commit,
+			Release:       entry.Release,
+			RefreshedAt:   entry.RefreshedAt,
+			Error:         entry.Error,
+			Devices:       discoveredDeviceNames(devices),
+			DeviceInfo:    s.withLastBuilds(toDiscoveredDeviceViews(devices), devices, entry.RepoURL, entry.Commit),
+			Platforms:     countDevicePlatforms(entry.Devices),
+			DeviceOptions: discoveredDeviceOptions(devices),
+		})
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+type deviceSearchResponse struct {
+	Query   string             `json:"query"`
+	Results []deviceSearchView `json:"results"`
+}
+
+type deviceSearchView struct {
+	discoveredDeviceView
+	Aliases   []string          `json:"aliases,omitempty"`
+	MatchedOn string            `json:"matchedOn"`
+	Score     int               `json:"score"`
+	Refs      []jobs.CatalogRef `json:"refs"`
+}
+
+// handleDeviceSearch serves type-ahead suggestions from the device catalog.
+// q is matched against env names, boards and variant directories; limit
+// caps the number of results.
+func (s *Server) handleDeviceSearch(w http.ResponseWriter, r *http.Request, requestID string) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > maxDeviceSearchQuery {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", "q must be 1-64 characters", nil)
+		return
+	}
+
+	limit := defaultDeviceSearchLimit
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", "limit must be a positive integer", nil)
+			return
+		}
+		limit = parsed
+		if limit > maxDeviceSearchLimit {
+			limit = maxDeviceSearchLimit
+		}
+	}
+
+	var entries []jobs.CatalogEntry
+	if s.manager != nil {
+		entries, _ = s.manager.DeviceCatalog()
+	}
+
+	matches := jobs.SearchCatalog(entries, query, limit)
+	response := deviceSearchResponse{Query: query, Results: make([]deviceSearchView, 0, len(matches))}
+	for _, match := range matches {
+		response.Results = append(response.Results, deviceSearchView{
+			discoveredDeviceView: toDiscoveredDeviceViews([]jobs.DiscoveredDevice{match.Device})[0],
+			Aliases:              match.Aliases,
+			MatchedOn:            match.MatchedOn,
+			Score:                match.Score,
+			Refs:                 match.Refs,
+		})
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+type deviceChangesResponse struct {
+	Changes []jobs.CatalogChange `json:"changes"`
+}
+
+// handleDeviceChanges lists devices that appeared in or disappeared from
+// the default branches of the featured repositories, newest first. An
+// optional repoUrl query parameter narrows the result.
+func (s *Server) handleDeviceChanges(w http.ResponseWriter, r *http.Request, requestID string) {
+	repoURL := jobs.NormalizeRepoURL(r.URL.Query().Get("repoUrl"))
+
+	var changes []jobs.CatalogChange
+	if s.manager != nil {
+		changes = s.manager.CatalogChanges()
+	}
+
+	response := deviceChangesResponse{Changes: make([]jobs.CatalogChange, 0, len(changes))}
+	for _, change := range changes {
+		if repoURL != "" && change.RepoURL != repoURL {
+			continue
+		}
+		response.Changes = append(response.Changes, change)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+// handleDeviceAvailability reports which recent release tags of a featured
+// repository contain a device. Optional repoUrl (default: the first
+// featured repository) and limit (number of tags) query parameters.
+func (s *Server) handleDeviceAvailability(w http.ResponseWriter, r *http.Request, requestID string, device string) {
+	if err := jobs.ValidateDeviceSelection(device); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_DEVICE", err.Error(), nil)
+		return
+	}
+
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", "limit must be a positive integer", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	if s.manager == nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "REPO_NOT_FEATURED", jobs.ErrRepoNotFeatured.Error(), nil)
+		return
+	}
+	availability, err := s.manager.DeviceAvailability(r.Context(), jobs.NormalizeRepoURL(r.URL.Query().Get("repoUrl")), device, limit)
+	switch {
+	case errors.Is(err, jobs.ErrRepoNotFeatured):
+		s.writeError(w, http.StatusBadRequest, requestID, "REPO_NOT_FEATURED", err.Error(), nil)
+		return
+	case errors.Is(err, jobs.ErrDeviceNotAllowed):
+		s.writeError(w, http.StatusForbidden, requestID, "DEVICE_NOT_ALLOWED", err.Error(), nil)
+		return
+	case errors.Is(err, jobs.ErrDiscoveryBusy):
+		s.writeDiscoveryBusy(w, requestID, err)
+		return
+	case err != nil:
+		s.writeError(w, http.StatusUnprocessableEntity, requestID, "DISCOVERY_FAILED", err.Error(), nil)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	s.writeSuccess(w, http.StatusOK, requestID, availability)
+}
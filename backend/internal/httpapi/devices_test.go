@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestHandleDeviceCatalog(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/devices", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got=%d want=%d", recorder.Code, http.StatusOK)
+	}
+	var body struct {
+		Data deviceCatalogResponse `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Data.Repos == nil || len(body.Data.Repos) != 0 || body.Data.RefreshedAt != nil {
+		t.Fatalf("expected an empty catalog, got %+v", body.Data)
+	}
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/devices?platform=avr", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status for unknown platform: got=%d want=%d", recorder.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleDeviceSearchValidatesQuery(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
+
+	for _, target := range []string{"/api/devices/search", "/api/devices/search?q=tbeam&limit=0"} {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("unexpected status for %s: got=%d want=%d", target, recorder.Code, http.StatusBadRequest)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/devices/search?q=tbeam", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got=%d want=%d", recorder.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,170 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type idempotencyOutcome int
+
+const (
+	idempotencyClaimed idempotencyOutcome = iota
+	idempotencyReplay
+	idempotencyInProgress
+	idempotencyMismatch
+)
+
+// idempotencyRecord remembers the job created for an Idempotency-Key. jobID
+// stays empty while the first request is still being handled.
+type idempotencyRecord struct {
+	fingerprint string
+	jobID       string
+	expiresAt   time.Time
+}
+
+// idempotencyStore maps client-scoped Idempotency-Key values to the jobs they
+// created, so a retried POST /api/jobs returns the original job instead of
+// queueing a duplicate build.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	window  time.Duration
+	records map[string]idempotencyRecord
+}
+
+func newIdempotencyStore(window time.Duration) *idempotencyStore {
+	if window <= 0 {
+		return nil
+	}
+	return &idempotencyStore{window: window, records: make(map[string]idempotencyRecord)}
+}
+
+// claim reserves key for a new request or reports what an earlier request
+// with the same key did.
+func (s *idempotencyStore) claim(key string, fingerprint string, now time.Time) (string, idempotencyOutcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for existing, record := range s.records {
+		if !now.Before(record.expiresAt) {
+			delete(s.records, existing)
+		}
+	}
+
+	record, exists := s.records[key]
+	switch {
+	case !exists:
+		s.records[key] = idempotencyRecord{fingerprint: fingerprint, expiresAt: now.Add(s.window)}
+		return "", idempotencyClaimed
+	case record.fingerprint != fingerprint:
+		return "", idempotencyMismatch
+	case record.jobID == "":
+		return "", idempotencyInProgress
+	}
+	return record.jobID, idempotencyReplay
+}
+
+// finish stores the job created for a claimed key, or releases the key when
+// the request failed so that the client can retry it.
+func (s *idempotencyStore) finish(key string, jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if jobID == "" {
+		delete(s.records, key)
+		return
+	}
+	if record, ok := s.records[key]; ok {
+		record.jobID = jobID
+		s.records[key] = record
+	}
+}
+
+func (s *idempotencyStore) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+}
+
+// claimIdempotencyKey handles the Idempotency-Key header of a job creation
+// request. It returns the claimed store key, or handled=true when the
+// response was already written: a replay of the original job, or an error.
+func (s *Server) claimIdempotencyKey(w http.ResponseWriter, r *http.Request, requestID string, ip string, req createJobRequest) (string, bool) {
+	header := r.Header.Get("Idempotency-Key")
+	if s.idempotency == nil || header == "" {
+		return "", false
+	}
+	if !validIdempotencyKey(header) {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be 1-255 printable ASCII characters", nil)
+		return "", true
+	}
+
+	key := ip + "\x00" + header
+	fingerprint := createJobFingerprint(req)
+	for {
+		jobID, outcome := s.idempotency.claim(key, fingerprint, time.Now().UTC())
+		switch outcome {
+		case idempotencyClaimed:
+			return key, false
+		case idempotencyMismatch:
+			s.writeError(w, http.StatusUnprocessableEntity, requestID, "IDEMPOTENCY_KEY_MISMATCH", "Idempotency-Key was already used for a different request", nil)
+			return "", true
+		case idempotencyInProgress:
+			s.writeError(w, http.StatusConflict, requestID, "IDEMPOTENCY_KEY_IN_PROGRESS", "a request with this Idempotency-Key is still being processed", nil)
+			return "", true
+		}
+
+		state, err := s.manager.GetJob(jobID)
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			// The job was cleaned up; treat the key as unused.
+			s.idempotency.forget(key)
+			continue
+		}
+		if err != nil {
+			s.handleJobError(w, requestID, err)
+			return "", true
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		response := s.presentState(state)
+		// The key is scoped to the client, which may have lost the first
+		// response and with it the token of the private job.
+		response.AccessToken = state.AccessToken
+		s.writeSuccess(w, http.StatusOK, requestID, response)
+		return "", true
+	}
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength || strings.TrimSpace(key) == "" {
+		return false
+	}
+	for index := 0; index < len(key); index++ {
+		if key[index] < 0x20 || key[index] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// createJobFingerprint hashes the build parameters of req; captcha and
+// proof-of-work fields are left out because a retry may carry a fresh
+// solution.
+func createJobFingerprint(req createJobRequest) string {
+	req.CaptchaID = ""
+	req.CaptchaAnswer = ""
+	req.CaptchaSessionToken = ""
+	req.PoWChallenge = ""
+	req.PoWNonce = ""
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,110 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestHandleCreateJobIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:      filepath.Join(workDir, "jobs"),
+		MaxLogLines:       200,
+		CleanupInterval:   time.Hour,
+		BuildRateLimit:    10,
+		IdempotencyWindow: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	post := func(key string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			request.Header.Set("Idempotency-Key", key)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+	jobID := func(recorder *httptest.ResponseRecorder) string {
+		var payload struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return payload.Data.ID
+	}
+
+	body := `{"repoUrl":"https://github.com/example/repo","ref":"main","device":"tbeam"}`
+	first := post("retry-1", body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+
+	replay := post("retry-1", body)
+	if replay.Code != http.StatusOK || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replayed 200, got %d: %s", replay.Code, replay.Body.String())
+	}
+	if jobID(replay) != jobID(first) {
+		t.Fatalf("replay returned job %q, want %q", jobID(replay), jobID(first))
+	}
+
+	if other := post("retry-2", body); other.Code != http.StatusCreated || jobID(other) == jobID(first) {
+		t.Fatalf("a new key must create a new job: status=%d", other.Code)
+	}
+
+	mismatch := post("retry-1", `{"repoUrl":"https://github.com/example/repo","ref":"main","device":"heltec-v3"}`)
+	if mismatch.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a reused key, got %d", mismatch.Code)
+	}
+
+	if invalid := post(strings.Repeat("k", 256), body); invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an oversized key, got %d", invalid.Code)
+	}
+}
+
+func TestIdempotencyStoreReleasesFailedClaims(t *testing.T) {
+	t.Parallel()
+
+	store := newIdempotencyStore(time.Minute)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, outcome := store.claim("key", "a", now); outcome != idempotencyClaimed {
+		t.Fatalf("first claim must succeed, got %v", outcome)
+	}
+	if _, outcome := store.claim("key", "a", now); outcome != idempotencyInProgress {
+		t.Fatalf("concurrent claim must report in progress, got %v", outcome)
+	}
+
+	store.finish("key", "")
+	if _, outcome := store.claim("key", "a", now); outcome != idempotencyClaimed {
+		t.Fatalf("failed request must release the key, got %v", outcome)
+	}
+	store.finish("key", "job-1")
+	if jobID, outcome := store.claim("key", "a", now.Add(30*time.Second)); outcome != idempotencyReplay || jobID != "job-1" {
+		t.Fatalf("unexpected replay: job=%q outcome=%v", jobID, outcome)
+	}
+	if _, outcome := store.claim("key", "a", now.Add(time.Minute)); outcome != idempotencyClaimed {
+		t.Fatalf("expired key must be claimable again, got %v", outcome)
+	}
+
+	if newIdempotencyStore(0) != nil {
+		t.Fatalf("zero window must disable the store")
+	}
+}
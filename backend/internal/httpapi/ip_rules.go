@@ -0,0 +1,154 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/netip"
+	"sync"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// ipRules holds the client address lists, which start from the
+// configuration and can be replaced through the admin API until restart.
+type ipRules struct {
+	mu        sync.RWMutex
+	allow     []netip.Prefix
+	deny      []netip.Prefix
+	anonymous []netip.Prefix
+}
+
+type ipRulesResponse struct {
+	Allow                   []string `json:"allow"`
+	Deny                    []string `json:"deny"`
+	Anonymous               []string `json:"anonymous"`
+	AnonymousBuildRateLimit int      `json:"anonymousBuildRateLimit"`
+}
+
+// ipRulesRequest replaces the lists that are present; omitted lists are
+// kept and an empty list clears one.
+type ipRulesRequest struct {
+	Allow     *[]string `json:"allow,omitempty"`
+	Deny      *[]string `json:"deny,omitempty"`
+	Anonymous *[]string `json:"anonymous,omitempty"`
+}
+
+func newIPRules(cfg config.Config) *ipRules {
+	return &ipRules{allow: cfg.IPAllow, deny: cfg.IPDeny, anonymous: cfg.AnonymousRanges}
+}
+
+func prefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether ip may use the API: not denied and, when an
+// allow list is set, on it. Unparsable addresses only pass without lists.
+func (rules *ipRules) allowed(ip string) bool {
+	rules.mu.RLock()
+	defer rules.mu.RUnlock()
+	if len(rules.allow) == 0 && len(rules.deny) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	if prefixesContain(rules.deny, addr) {
+		return false
+	}
+	return len(rules.allow) == 0 || prefixesContain(rules.allow, addr)
+}
+
+// isAnonymous reports whether ip belongs to a configured Tor or VPN range.
+func (rules *ipRules) isAnonymous(ip string) bool {
+	rules.mu.RLock()
+	defer rules.mu.RUnlock()
+	if len(rules.anonymous) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	return err == nil && prefixesContain(rules.anonymous, addr.Unmap())
+}
+
+// enforceIPRules answers 403 IP_DENIED for clients outside the lists.
+// Probes and requests with admin credentials are exempt, so a bad list can
+// neither fail health checks nor lock the operator out of fixing it.
+func (s *Server) enforceIPRules(w http.ResponseWriter, r *http.Request, requestID string) bool {
+	if r.URL.Path == "/api/livez" || r.URL.Path == "/api/readyz" {
+		return true
+	}
+	if s.ipRules.allowed(s.clientIP(r)) || s.adminAuthorized(r) {
+		return true
+	}
+	s.writeError(w, http.StatusForbidden, requestID, "IP_DENIED", "access from this address is not allowed", nil)
+	return false
+}
+
+func (s *Server) handleAdminIPRules(w http.ResponseWriter, requestID string) {
+	s.ipRules.mu.RLock()
+	defer s.ipRules.mu.RUnlock()
+	s.writeSuccess(w, http.StatusOK, requestID, ipRulesResponse{
+		Allow:                   prefixStrings(s.ipRules.allow),
+		Deny:                    prefixStrings(s.ipRules.deny),
+		Anonymous:               prefixStrings(s.ipRules.anonymous),
+		AnonymousBuildRateLimit: s.cfg.AnonymousBuildRateLimit,
+	})
+}
+
+func (s *Server) handleAdminSetIPRules(w http.ResponseWriter, r *http.Request, requestID string) {
+	var req ipRulesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+
+	lists := []struct {
+		name    string
+		entries *[]string
+		target  *[]netip.Prefix
+	}{
+		{"allow", req.Allow, &s.ipRules.allow},
+		{"deny", req.Deny, &s.ipRules.deny},
+		{"anonymous", req.Anonymous, &s.ipRules.anonymous},
+	}
+	parsed := make([][]netip.Prefix, len(lists))
+	for i, list := range lists {
+		if list.entries == nil {
+			continue
+		}
+		prefixes, err := config.ParsePrefixes(*list.entries)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", list.name+" "+err.Error(), nil)
+			return
+		}
+		parsed[i] = prefixes
+	}
+
+	s.ipRules.mu.Lock()
+	for i, list := range lists {
+		if list.entries != nil {
+			*list.target = parsed[i]
+		}
+	}
+	s.ipRules.mu.Unlock()
+
+	s.logger.InfoContext(r.Context(), "admin: ip rules updated")
+	s.handleAdminIPRules(w, requestID)
+}
+
+func prefixStrings(prefixes []netip.Prefix) []string {
+	values := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix.IsSingleIP() {
+			values = append(values, prefix.Addr().String())
+			continue
+		}
+		values = append(values, prefix.String())
+	}
+	return values
+}
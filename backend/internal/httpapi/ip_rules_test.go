@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestIPRules(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:            filepath.Join(workDir, "jobs"),
+		BuildLogsPath:           filepath.Join(workDir, "build-logs"),
+		MaxLogLines:             200,
+		CleanupInterval:         time.Hour,
+		BuildRateLimit:          5,
+		AdminToken:              "admin-secret",
+		IPDeny:                  []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")},
+		AnonymousRanges:         []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")},
+		AnonymousBuildRateLimit: 1,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string, remoteAddr string, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.RemoteAddr = remoteAddr
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	denied := serve(http.MethodGet, "/api/version", "", "203.0.113.5:1000", "")
+	if denied.Code != http.StatusForbidden || !strings.Contains(denied.Body.String(), "IP_DENIED") {
+		t.Fatalf("expected a denied client to get IP_DENIED, got %d %s", denied.Code, denied.Body.String())
+	}
+	if probe := serve(http.MethodGet, "/api/livez", "", "203.0.113.5:1000", ""); probe.Code != http.StatusOK {
+		t.Fatalf("expected probes to be exempt, got %d", probe.Code)
+	}
+	if admin := serve(http.MethodGet, "/api/admin/ip-rules", "", "203.0.113.5:1000", "admin-secret"); admin.Code != http.StatusOK {
+		t.Fatalf("expected the admin to be exempt, got %d %s", admin.Code, admin.Body.String())
+	}
+	if other := serve(http.MethodGet, "/api/version", "", "192.0.2.1:1000", ""); other.Code != http.StatusOK {
+		t.Fatalf("expected other clients to be served, got %d", other.Code)
+	}
+
+	build := `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam"}`
+	if created := serve(http.MethodPost, "/api/jobs", build, "198.51.100.9:1000", ""); created.Code != http.StatusCreated {
+		t.Fatalf("create job: %d %s", created.Code, created.Body.String())
+	}
+	limited := serve(http.MethodPost, "/api/jobs", build, "198.51.100.9:1000", "")
+	if limited.Code != http.StatusTooManyRequests || limited.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("expected the anonymous limit of 1, got %d limit=%q", limited.Code, limited.Header().Get("X-RateLimit-Limit"))
+	}
+	for range 2 {
+		if created := serve(http.MethodPost, "/api/jobs", build, "192.0.2.1:1000", ""); created.Code != http.StatusCreated {
+			t.Fatalf("expected the regular limit for other clients, got %d %s", created.Code, created.Body.String())
+		}
+	}
+
+	update := serve(http.MethodPut, "/api/admin/ip-rules", `{"allow":["192.0.2.0/24"],"deny":[]}`, "192.0.2.1:1000", "admin-secret")
+	var envelope struct {
+		Data ipRulesResponse `json:"data"`
+	}
+	if update.Code != http.StatusOK || json.Unmarshal(update.Body.Bytes(), &envelope) != nil {
+		t.Fatalf("update ip rules: %d %s", update.Code, update.Body.String())
+	}
+	rules := envelope.Data
+	if !slices.Equal(rules.Allow, []string{"192.0.2.0/24"}) || len(rules.Deny) != 0 || !slices.Equal(rules.Anonymous, []string{"198.51.100.0/24"}) {
+		t.Fatalf("unexpected ip rules after the update: %+v", rules)
+	}
+	if allowed := serve(http.MethodGet, "/api/version", "", "192.0.2.7:1000", ""); allowed.Code != http.StatusOK {
+		t.Fatalf("expected an allowed client to be served, got %d", allowed.Code)
+	}
+	for _, remoteAddr := range []string{"203.0.113.5:1000", "198.51.100.9:1000"} {
+		if outside := serve(http.MethodGet, "/api/version", "", remoteAddr, ""); outside.Code != http.StatusForbidden {
+			t.Fatalf("expected %s outside the allow list to be denied, got %d", remoteAddr, outside.Code)
+		}
+	}
+
+	if invalid := serve(http.MethodPut, "/api/admin/ip-rules", `{"deny":["not-an-ip"]}`, "192.0.2.1:1000", "admin-secret"); invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected an invalid entry to be rejected, got %d", invalid.Code)
+	}
+}
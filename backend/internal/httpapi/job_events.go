@@ -0,0 +1,136 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+// jobEventsPollInterval is how often the job events stream compares the job
+// state; job state lives in memory, so polling it is cheap.
+var jobEventsPollInterval = time.Second
+
+type jobStatusEvent struct {
+	Status      jobs.Status      `json:"status"`
+	StartedAt   *time.Time       `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time       `json:"finishedAt,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	FailureKind jobs.FailureKind `json:"failureKind,omitempty"`
+}
+
+type jobQueueEvent struct {
+	QueuePosition   *int `json:"queuePosition,omitempty"`
+	QueueETASeconds *int `json:"queueEtaSeconds,omitempty"`
+}
+
+type sseEvent struct {
+	name    string
+	payload any
+}
+
+// handleJobEventsStream streams job state changes: "state" with the full
+// state on connect, "status" on transitions, "queue" when the queue
+// position changes and "done" with the final state, after which the stream
+// ends.
+func (s *Server) handleJobEventsStream(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	state, err := s.manager.GetJob(jobID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, requestID, "STREAM_UNSUPPORTED", "streaming is not supported", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	current := s.presentState(state)
+	if isFinalStatus(current.Status) {
+		writeSSEJSON(w, "done", current)
+		flusher.Flush()
+		return
+	}
+	writeSSEJSON(w, "state", current)
+	flusher.Flush()
+
+	poll := time.NewTicker(jobEventsPollInterval)
+	defer poll.Stop()
+	ping := time.NewTicker(15 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			writeSSE(w, "ping", time.Now().UTC().Format(time.RFC3339))
+			flusher.Flush()
+		case <-poll.C:
+			state, err := s.manager.GetJob(jobID)
+			if err != nil {
+				writeSSEJSON(w, "error", map[string]string{"message": err.Error()})
+				flusher.Flush()
+				return
+			}
+			next := s.presentState(state)
+			for _, event := range jobStateEvents(current, next) {
+				writeSSEJSON(w, event.name, event.payload)
+			}
+			flusher.Flush()
+			if isFinalStatus(next.Status) {
+				return
+			}
+			current = next
+		}
+	}
+}
+
+// jobStateEvents returns the events describing the change from prev to next.
+func jobStateEvents(prev stateResponse, next stateResponse) []sseEvent {
+	var events []sseEvent
+	if next.Status != prev.Status {
+		events = append(events, sseEvent{name: "status", payload: jobStatusEvent{
+			Status:      next.Status,
+			StartedAt:   next.StartedAt,
+			FinishedAt:  next.FinishedAt,
+			Error:       next.Error,
+			FailureKind: next.FailureKind,
+		}})
+	}
+	if next.Status == jobs.StatusQueued && (!equalIntPtr(prev.QueuePosition, next.QueuePosition) || !equalIntPtr(prev.QueueETASeconds, next.QueueETASeconds)) {
+		events = append(events, sseEvent{name: "queue", payload: jobQueueEvent{
+			QueuePosition:   next.QueuePosition,
+			QueueETASeconds: next.QueueETASeconds,
+		}})
+	}
+	if isFinalStatus(next.Status) {
+		events = append(events, sseEvent{name: "done", payload: next})
+	}
+	return events
+}
+
+func isFinalStatus(status jobs.Status) bool {
+	return status == jobs.StatusSuccess || status == jobs.StatusFailed || status == jobs.StatusCancelled
+}
+
+func equalIntPtr(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func writeSSEJSON(w http.ResponseWriter, event string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	writeSSE(w, event, string(data))
+}
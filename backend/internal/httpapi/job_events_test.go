@@ -0,0 +1,81 @@
+package httpapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestJobStateEvents(t *testing.T) {
+	t.Parallel()
+
+	first, second := 2, 1
+	queued := stateResponse{Status: jobs.StatusQueued, QueuePosition: &first}
+	moved := stateResponse{Status: jobs.StatusQueued, QueuePosition: &second}
+	running := stateResponse{Status: jobs.StatusRunning}
+	failed := stateResponse{Status: jobs.StatusFailed, Error: "boom"}
+
+	names := func(events []sseEvent) string {
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.name)
+		}
+		return strings.Join(result, ",")
+	}
+
+	cases := []struct {
+		prev, next stateResponse
+		want       string
+	}{
+		{queued, queued, ""},
+		{queued, moved, "queue"},
+		{moved, running, "status"},
+		{running, running, ""},
+		{running, failed, "status,done"},
+	}
+	for _, tc := range cases {
+		if got := names(jobStateEvents(tc.prev, tc.next)); got != tc.want {
+			t.Fatalf("unexpected events for %s -> %s: got=%q want=%q", tc.prev.Status, tc.next.Status, got, tc.want)
+		}
+	}
+}
+
+func TestHandleJobEventsStreamSendsInitialState(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/jobs/"+state.ID+"/events/stream", nil).WithContext(ctx))
+
+	body := recorder.Body.String()
+	if recorder.Header().Get("Content-Type") != "text/event-stream" || !strings.HasPrefix(body, "event: state\n") {
+		t.Fatalf("unexpected stream: type=%q body=%q", recorder.Header().Get("Content-Type"), body)
+	}
+	if !strings.Contains(body, `"status":"queued"`) {
+		t.Fatalf("initial state must include the job status: %q", body)
+	}
+}
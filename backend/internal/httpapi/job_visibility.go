@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// jobTokenHeader carries the access token of a private job. Links that
+// cannot set headers, such as EventSource streams and downloads, pass it
+// as the token query parameter instead.
+const jobTokenHeader = "X-Job-Token"
+
+func jobAccessToken(r *http.Request) string {
+	if token := strings.TrimSpace(r.Header.Get(jobTokenHeader)); token != "" {
+		return token
+	}
+	return strings.TrimSpace(r.URL.Query().Get("token"))
+}
+
+// jobReadable reports whether the caller may read jobID: the job is public,
+// or the caller is its signed-in creator, presents its access token or is
+// an admin. Unknown jobs are reported as readable so that handlers answer
+// with their usual JOB_NOT_FOUND.
+func (s *Server) jobReadable(r *http.Request, jobID string) bool {
+	state, err := s.manager.GetJob(jobID)
+	if err != nil {
+		return true
+	}
+	userID := ""
+	if user := s.sessionUser(r); user != nil {
+		userID = user.ID
+	}
+	return state.VisibleTo(userID, jobAccessToken(r)) || s.adminAuthorized(r)
+}
+
+func jobVisibility(private bool) string {
+	if private {
+		return "private"
+	}
+	return "public"
+}
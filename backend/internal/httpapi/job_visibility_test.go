@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestJobVisibility(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:      filepath.Join(workDir, "jobs"),
+		BuildLogsPath:     filepath.Join(workDir, "build-logs"),
+		MaxLogLines:       200,
+		CleanupInterval:   time.Hour,
+		BuildRateLimit:    10,
+		AdminToken:        "admin-secret",
+		IdempotencyWindow: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string, header http.Header) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		for name, values := range header {
+			request.Header[name] = values
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+	create := func(body string) stateResponse {
+		t.Helper()
+		recorder := serve(http.MethodPost, "/api/jobs", body, nil)
+		var envelope struct {
+			Data stateResponse `json:"data"`
+		}
+		if recorder.Code != http.StatusCreated || json.Unmarshal(recorder.Body.Bytes(), &envelope) != nil {
+			t.Fatalf("create job: %d %s", recorder.Code, recorder.Body.String())
+		}
+		return envelope.Data
+	}
+
+	private := create(`{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam"}`)
+	if private.Visibility != "private" || private.AccessToken == "" {
+		t.Fatalf("expected a private job with an access token: %+v", private)
+	}
+	for _, path := range []string{"", "/logs", "/logs.txt", "/artifacts", "/sizediff?against=x"} {
+		if recorder := serve(http.MethodGet, "/api/jobs/"+private.ID+path, "", nil); recorder.Code != http.StatusNotFound {
+			t.Errorf("GET %s without a token: expected 404, got %d", path, recorder.Code)
+		}
+	}
+	if recorder := serve(http.MethodGet, "/api/jobs/"+private.ID, "", http.Header{jobTokenHeader: {"guess"}}); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected a wrong token to be rejected, got %d", recorder.Code)
+	}
+	if recorder := serve(http.MethodGet, "/api/jobs/"+private.ID, "", http.Header{jobTokenHeader: {private.AccessToken}}); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the token header to grant access, got %d", recorder.Code)
+	}
+	if recorder := serve(http.MethodGet, "/api/jobs/"+private.ID+"/logs?token="+url.QueryEscape(private.AccessToken), "", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the token query parameter to grant access, got %d", recorder.Code)
+	}
+	if recorder := serve(http.MethodGet, "/api/jobs/"+private.ID, "", http.Header{"Authorization": {"Bearer admin-secret"}}); recorder.Code != http.StatusOK {
+		t.Fatalf("expected admins to read private jobs, got %d", recorder.Code)
+	}
+
+	public := create(`{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam","public":true}`)
+	if public.Visibility != "public" || public.AccessToken != "" {
+		t.Fatalf("expected a public job without a token: %+v", public)
+	}
+	if recorder := serve(http.MethodGet, "/api/jobs/"+public.ID, "", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("expected anyone to read a public job, got %d", recorder.Code)
+	}
+	if recorder := serve(http.MethodGet, "/api/jobs/"+public.ID+"/sizediff?against="+private.ID, "", nil); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected a private job to be hidden as a size diff baseline, got %d", recorder.Code)
+	}
+
+	// Jobs cloned with the stored git credentials are private even when the
+	// creator did not ask for it; only admins read them.
+	trusted, err := manager.CreateOwnedJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{},
+		jobs.JobOwner{ClientIP: "192.0.2.1", Trusted: true})
+	if err != nil {
+		t.Fatalf("create trusted job: %v", err)
+	}
+	for _, path := range []string{"", "/logs", "/artifacts"} {
+		if recorder := serve(http.MethodGet, "/api/jobs/"+trusted.ID+path, "", nil); recorder.Code != http.StatusNotFound {
+			t.Errorf("GET %s of a trusted job without credentials: expected 404, got %d", path, recorder.Code)
+		}
+	}
+	if recorder := serve(http.MethodGet, "/api/jobs/"+trusted.ID, "", http.Header{"Authorization": {"Bearer admin-secret"}}); recorder.Code != http.StatusOK {
+		t.Fatalf("expected admins to read trusted jobs, got %d", recorder.Code)
+	}
+
+	replay := func() stateResponse {
+		recorder := serve(http.MethodPost, "/api/jobs", `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"rak4631"}`,
+			http.Header{"Idempotency-Key": {"retry-1"}})
+		var envelope struct {
+			Data stateResponse `json:"data"`
+		}
+		_ = json.Unmarshal(recorder.Body.Bytes(), &envelope)
+		return envelope.Data
+	}
+	first, second := replay(), replay()
+	if first.ID != second.ID || first.AccessToken == "" || second.AccessToken != first.AccessToken {
+		t.Fatalf("expected an idempotent replay to return the access token: %+v %+v", first, second)
+	}
+}
@@ -3,10 +3,9 @@ package httpapi
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 
@@ -171,7 +170,7 @@ func (s *Server) handleLauncherHubFirmwareDetail(w http.ResponseWriter, r *http.
 		return
 	}
 
-	cacheEntry, firmwarePath := s.findCacheEntry(device, version, source)
+	cacheEntry, firmwareName := s.findCacheEntry(device, version, source)
 	if cacheEntry == nil {
 		s.lhError(w, http.StatusNotFound, fmt.Sprintf(
 			"Version '%s' not found for device '%s' in source '%s'", version, device, source))
@@ -192,16 +191,15 @@ func (s *Server) handleLauncherHubFirmwareDetail(w http.ResponseWriter, r *http.
 	}
 
 	// Try to get file size.
-	if firmwarePath != "" {
-		if info, err := os.Stat(firmwarePath); err == nil {
-			vd.FileSize = info.Size()
+	for _, a := range cacheEntry.Artifacts {
+		if firmwareName != "" && a.Name == firmwareName {
+			vd.FileSize = a.Size
 		}
 	}
 
 	// Try to parse partition table from partitions.bin in the same cache entry.
-	partPath := s.findArtifactInCache(cacheEntry.Key, "partitions.bin")
-	if partPath != "" {
-		if pt, err := parsePartitionTable(partPath); err == nil {
+	if partData := s.readArtifactFromCache(cacheEntry.Key, "partitions.bin"); partData != nil {
+		if pt, err := parsePartitionTable(partData); err == nil {
 			vd.NoBoot = false
 			vd.AppOffset = pt.AppOffset
 			vd.AppSize = pt.AppSize
@@ -240,41 +238,34 @@ func (s *Server) handleLauncherHubDownload(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	cacheEntry, firmwarePath := s.findCacheEntry(device, version, source)
-	if cacheEntry == nil || firmwarePath == "" {
+	cacheEntry, firmwareName := s.findCacheEntry(device, version, source)
+	if cacheEntry == nil || firmwareName == "" {
 		s.lhError(w, http.StatusNotFound, fmt.Sprintf(
 			"Firmware not found for device '%s' version '%s'", device, version))
 		return
 	}
 
-	f, err := os.Open(firmwarePath)
+	f, _, err := jobs.OpenFirmwareCacheFile(s.cfg.FirmwareCachePath, cacheEntry.Key, firmwareName)
 	if err != nil {
 		s.lhError(w, http.StatusInternalServerError, "cannot read firmware file")
 		return
 	}
 	defer f.Close()
 
-	info, err := f.Stat()
-	if err != nil {
-		s.lhError(w, http.StatusInternalServerError, "cannot stat firmware file")
-		return
-	}
-
 	filename := fmt.Sprintf("firmware-%s-%s.bin", device, version)
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
 
 	if s.stats != nil {
 		s.stats.Record(stats.Event{
 			Type:      stats.EventDownload,
-			IP:        clientIP(r, s.cfg.TrustProxyHeaders),
+			IP:        s.clientIP(r),
 			UserAgent: r.UserAgent(),
 			Extra:     fmt.Sprintf("launcherhub:%s", fid),
 		})
 	}
 
-	http.ServeContent(w, r, filename, info.ModTime(), f)
+	http.ServeContent(w, r, filename, cacheEntry.CreatedAt, f)
 }
 
 // --- helpers ---
@@ -288,7 +279,7 @@ func (s *Server) lhError(w http.ResponseWriter, status int, detail string) {
 }
 
 // findCacheEntry locates a cache entry matching device+version+source
-// and returns the path to the best firmware binary for OTA.
+// and returns the name of the best firmware binary for OTA.
 func (s *Server) findCacheEntry(device string, version string, source string) (*jobs.FirmwareCacheEntry, string) {
 	cacheInfo := jobs.ScanFirmwareCache(s.cfg.FirmwareCachePath)
 
@@ -307,8 +298,8 @@ func (s *Server) findCacheEntry(device string, version string, source string) (*
 
 		// Find the best firmware binary: prefer firmware.bin (OTA-ready),
 		// then firmware-<device>-*.bin, then *.factory.bin as last resort.
-		firmwarePath := s.findBestFirmwareBinary(ce)
-		return &ce, firmwarePath
+		firmwareName := findBestFirmwareBinary(ce)
+		return &ce, firmwareName
 	}
 
 	return nil, ""
@@ -316,23 +307,22 @@ func (s *Server) findCacheEntry(device string, version string, source string) (*
 
 // findBestFirmwareBinary picks the best OTA binary from cache artifacts.
 // Preference: device-specific firmware.bin > generic firmware.bin > factory.bin
-func (s *Server) findBestFirmwareBinary(ce jobs.FirmwareCacheEntry) string {
+func findBestFirmwareBinary(ce jobs.FirmwareCacheEntry) string {
 	var genericFirmware, deviceFirmware, factoryFirmware string
 
 	for _, a := range ce.Artifacts {
 		lower := strings.ToLower(a.Name)
-		path := filepath.Join(s.cfg.FirmwareCachePath, ce.Key, "files", a.Name)
 
 		if lower == "firmware.bin" {
-			genericFirmware = path
+			genericFirmware = a.Name
 			continue
 		}
 		if strings.HasPrefix(lower, "firmware-") && strings.HasSuffix(lower, ".bin") && !strings.Contains(lower, "factory") {
-			deviceFirmware = path
+			deviceFirmware = a.Name
 			continue
 		}
 		if strings.HasSuffix(lower, ".factory.bin") {
-			factoryFirmware = path
+			factoryFirmware = a.Name
 			continue
 		}
 	}
@@ -346,13 +336,20 @@ func (s *Server) findBestFirmwareBinary(ce jobs.FirmwareCacheEntry) string {
 	return factoryFirmware
 }
 
-// findArtifactInCache returns the absolute path to a named artifact in a cache entry.
-func (s *Server) findArtifactInCache(cacheKey string, name string) string {
-	path := filepath.Join(s.cfg.FirmwareCachePath, cacheKey, "files", name)
-	if _, err := os.Stat(path); err == nil {
-		return path
+// readArtifactFromCache returns the decoded content of a named artifact in a
+// cache entry, or nil when it is missing or unreadable.
+func (s *Server) readArtifactFromCache(cacheKey string, name string) []byte {
+	f, _, err := jobs.OpenFirmwareCacheFile(s.cfg.FirmwareCachePath, cacheKey, name)
+	if err != nil {
+		return nil
 	}
-	return ""
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	return data
 }
 
 // parseFID parses "device|version[|source]" into components.
@@ -534,13 +531,8 @@ const (
 	partSubtypeFAT     = 0x81
 )
 
-// parsePartitionTable reads an ESP32 partitions.bin file and extracts layout info.
-func parsePartitionTable(path string) (partitionInfo, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return partitionInfo{}, err
-	}
-
+// parsePartitionTable parses ESP32 partitions.bin content and extracts layout info.
+func parsePartitionTable(data []byte) (partitionInfo, error) {
 	var info partitionInfo
 	fatCount := 0
 
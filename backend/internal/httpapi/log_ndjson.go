@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+// ndjsonPingInterval is how often the NDJSON log stream writes a heartbeat,
+// so that proxies and the stall timeout keep a quiet stream open.
+var ndjsonPingInterval = 15 * time.Second
+
+type ndjsonLogLine struct {
+	Seq  int       `json:"seq"`
+	TS   time.Time `json:"ts"`
+	Line string    `json:"line"`
+}
+
+type ndjsonPing struct {
+	Type string    `json:"type"`
+	TS   time.Time `json:"ts"`
+}
+
+// handleLogNDJSON streams the job log as newline-delimited JSON, one
+// {seq, ts, line} object per log line, for curl and scripts, with a
+// {"type": "ping"} heartbeat while the log is quiet. The response ends when
+// the job finishes; ?after=<seq> skips the lines already received.
+func (s *Server) handleLogNDJSON(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	afterID := 0
+	if value := strings.TrimSpace(r.URL.Query().Get("after")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", "after must be a non-negative integer", nil)
+			return
+		}
+		afterID = parsed
+	}
+
+	stream, snapshot, unsubscribe, err := s.manager.SubscribeLogs(jobID, afterID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+	defer unsubscribe()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, requestID, "STREAM_UNSUPPORTED", "streaming is not supported", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := newNDJSONEncoder(w)
+	write := func(event jobs.LogEvent) error {
+		return encoder.Encode(ndjsonLogLine{Seq: event.ID, TS: event.Time, Line: event.Line})
+	}
+
+	for _, event := range snapshot {
+		if err := write(event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ping := time.NewTicker(ndjsonPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			if err := encoder.Encode(ndjsonPing{Type: "ping", TS: time.Now().UTC()}); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, open := <-stream:
+			if !open {
+				return
+			}
+			if event.Progress != nil {
+				continue
+			}
+			if err := write(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func newNDJSONEncoder(w io.Writer) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder
+}
@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestHandleLogNDJSON(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/jobs/"+state.ID+"/logs/ndjson?after=x", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an invalid after, got %d", recorder.Code)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/jobs/"+state.ID+"/logs/ndjson", nil))
+		done <- recorder
+	}()
+
+	// The stream ends once the job finishes.
+	time.Sleep(50 * time.Millisecond)
+	if _, err := manager.CancelJob(state.ID); err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	select {
+	case recorder := <-done:
+		if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/x-ndjson" {
+			t.Fatalf("unexpected response: status=%d type=%q", recorder.Code, recorder.Header().Get("Content-Type"))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("stream did not end after the job finished")
+	}
+}
+
+// TestLogNDJSONPings is not parallel, since it shortens the ping interval.
+func TestLogNDJSONPings(t *testing.T) {
+	defer func(interval time.Duration) { ndjsonPingInterval = interval }(ndjsonPingInterval)
+	ndjsonPingInterval = 50 * time.Millisecond
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	listener := httptest.NewServer(NewServer(cfg, manager, slog.New(slog.DiscardHandler)))
+	defer listener.Close()
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	// ?after skips the queued job's log, so only pings are written.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, listener.URL+"/api/jobs/"+state.ID+"/logs/ndjson?after=1000", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	response, err := listener.Client().Do(request)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer response.Body.Close()
+
+	scanner := bufio.NewScanner(response.Body)
+	if !scanner.Scan() {
+		t.Fatalf("expected a heartbeat: %v", scanner.Err())
+	}
+	var ping ndjsonPing
+	if err := json.Unmarshal(scanner.Bytes(), &ping); err != nil || ping.Type != "ping" || ping.TS.IsZero() {
+		t.Fatalf("unexpected heartbeat %q: %v", scanner.Text(), err)
+	}
+}
+
+func TestNDJSONLogLineEncoding(t *testing.T) {
+	t.Parallel()
+
+	recorder := httptest.NewRecorder()
+	line := ndjsonLogLine{Seq: 3, TS: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Line: "<ok> \"done\""}
+	if err := newNDJSONEncoder(recorder).Encode(line); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	want := `{"seq":3,"ts":"2026-03-01T12:00:00Z","line":"<ok> \"done\""}` + "\n"
+	if recorder.Body.String() != want {
+		t.Fatalf("unexpected line %q, want %q", recorder.Body.String(), want)
+	}
+}
@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+const metricsNamespace = "meshtastic_builder_"
+
+// handleMetrics exposes counters in the Prometheus text format. It uses the
+// stats password because labels contain job IDs, which grant artifact
+// access.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request, requestID string) {
+	if !s.requireStatsAuth(w, r, requestID) {
+		return
+	}
+
+	var states []jobs.State
+	var disks []jobs.DiskUsage
+	var branchChanges uint64
+	flaggedClients := 0
+	if s.manager != nil {
+		states = s.manager.ListJobs()
+		disks = s.manager.DiskUsage()
+		branchChanges = s.manager.DefaultBranchChanges()
+		for _, client := range s.manager.AbuseReport() {
+			if client.Flagged {
+				flaggedClients++
+			}
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := &metricsWriter{w: buffer}
+	writeArtifactDownloadMetrics(writer, states)
+	writer.header("default_branch_changes_total", "counter", "Repository default branch changes that invalidated cached refs.")
+	writer.sample("default_branch_changes_total", float64(branchChanges))
+	writer.header("abuse_flagged_clients", "gauge", "Clients flagged for builds that keep failing with the same error.")
+	writer.sample("abuse_flagged_clients", float64(flaggedClients))
+	writeDiskMetrics(writer, disks, s.cfg.MinFreeDiskMB)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buffer.Bytes())
+}
+
+func writeArtifactDownloadMetrics(m *metricsWriter, states []jobs.State) {
+	m.header("artifact_downloads_total", "counter", "Completed artifact downloads per job artifact.")
+	for _, state := range states {
+		for _, artifact := range state.Artifacts {
+			m.sample("artifact_downloads_total", float64(artifact.Downloads),
+				"job_id", state.ID, "device", state.Device, "artifact", artifact.Name)
+		}
+	}
+
+	m.header("artifact_last_download_timestamp_seconds", "gauge", "Unix time of the latest download per job artifact.")
+	for _, state := range states {
+		for _, artifact := range state.Artifacts {
+			if artifact.LastDownloadAt == nil {
+				continue
+			}
+			m.sample("artifact_last_download_timestamp_seconds", float64(artifact.LastDownloadAt.Unix()),
+				"job_id", state.ID, "device", state.Device, "artifact", artifact.Name)
+		}
+	}
+}
+
+func writeDiskMetrics(m *metricsWriter, disks []jobs.DiskUsage, minFreeMB int) {
+	m.header("disk_free_bytes", "gauge", "Free space on the filesystem of each build directory.")
+	for _, disk := range disks {
+		if disk.Error == "" {
+			m.sample("disk_free_bytes", float64(disk.FreeBytes), "dir", disk.Name)
+		}
+	}
+	m.header("disk_total_bytes", "gauge", "Size of the filesystem of each build directory.")
+	for _, disk := range disks {
+		if disk.Error == "" {
+			m.sample("disk_total_bytes", float64(disk.TotalBytes), "dir", disk.Name)
+		}
+	}
+	m.header("disk_min_free_bytes", "gauge", "Free space below which new builds are refused (0 = no limit).")
+	m.sample("disk_min_free_bytes", float64(minFreeMB)*(1<<20))
+}
+
+// metricsWriter emits the Prometheus text exposition format. Labels are
+// passed as alternating name/value pairs.
+type metricsWriter struct {
+	w io.Writer
+}
+
+func (m *metricsWriter) header(name string, kind string, help string) {
+	fmt.Fprintf(m.w, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsNamespace, name, help, metricsNamespace, name, kind)
+}
+
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	builder := strings.Builder{}
+	builder.WriteString(metricsNamespace)
+	builder.WriteString(name)
+	if len(labels) > 0 {
+		builder.WriteByte('{')
+		for index := 0; index+1 < len(labels); index += 2 {
+			if index > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteString(labels[index])
+			builder.WriteString(`="`)
+			builder.WriteString(escapeMetricLabel(labels[index+1]))
+			builder.WriteByte('"')
+		}
+		builder.WriteByte('}')
+	}
+	builder.WriteByte(' ')
+	builder.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	builder.WriteByte('\n')
+	_, _ = io.WriteString(m.w, builder.String())
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeMetricLabel(value string) string {
+	return metricLabelEscaper.Replace(value)
+}
@@ -0,0 +1,121 @@
+package httpapi
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestWriteArtifactDownloadMetrics(t *testing.T) {
+	t.Parallel()
+
+	downloadedAt := time.Unix(1767225600, 0)
+	states := []jobs.State{{
+		ID:     "job1",
+		Device: "tbeam",
+		Artifacts: []jobs.Artifact{
+			{ID: "1", Name: "firmware.bin", Downloads: 3, LastDownloadAt: &downloadedAt},
+			{ID: "2", Name: `odd"name.elf`},
+		},
+	}}
+
+	buffer := &bytes.Buffer{}
+	writeArtifactDownloadMetrics(&metricsWriter{w: buffer}, states)
+	output := buffer.String()
+
+	expected := []string{
+		"# TYPE meshtastic_builder_artifact_downloads_total counter\n",
+		`meshtastic_builder_artifact_downloads_total{job_id="job1",device="tbeam",artifact="firmware.bin"} 3` + "\n",
+		`meshtastic_builder_artifact_downloads_total{job_id="job1",device="tbeam",artifact="odd\"name.elf"} 0` + "\n",
+		`meshtastic_builder_artifact_last_download_timestamp_seconds{job_id="job1",device="tbeam",artifact="firmware.bin"} 1767225600` + "\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Fatalf("metrics output misses %q:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, `last_download_timestamp_seconds{job_id="job1",device="tbeam",artifact="odd`) {
+		t.Fatalf("artifacts without downloads must not report a timestamp:\n%s", output)
+	}
+}
+
+func TestWriteDiskMetrics(t *testing.T) {
+	t.Parallel()
+
+	disks := []jobs.DiskUsage{
+		{Name: "workdir", FreeBytes: 2 << 30, TotalBytes: 8 << 30},
+		{Name: "firmware-cache", Error: "stat filesystem: permission denied"},
+	}
+
+	buffer := &bytes.Buffer{}
+	writeDiskMetrics(&metricsWriter{w: buffer}, disks, 1024)
+	output := buffer.String()
+
+	expected := []string{
+		`meshtastic_builder_disk_free_bytes{dir="workdir"} 2147483648` + "\n",
+		`meshtastic_builder_disk_total_bytes{dir="workdir"} 8589934592` + "\n",
+		"meshtastic_builder_disk_min_free_bytes 1073741824\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Fatalf("metrics output misses %q:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, "firmware-cache") {
+		t.Fatalf("directories that cannot be inspected must not report samples:\n%s", output)
+	}
+}
+
+func TestHandleMetricsRequiresAuth(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{StatsPassword: "secret"}, nil, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status without credentials: got=%d want=%d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status with credentials: got=%d want=%d", recorder.Code, http.StatusOK)
+	}
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type: %s", recorder.Header().Get("Content-Type"))
+	}
+}
+
+func TestCountsAsDownload(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method string
+		rng    string
+		want   bool
+	}{
+		{http.MethodGet, "", true},
+		{http.MethodGet, "bytes=0-", true},
+		{http.MethodGet, "bytes=1024-", false},
+		{http.MethodHead, "", false},
+	}
+	for _, tc := range cases {
+		request := httptest.NewRequest(tc.method, "/api/jobs/a/artifacts/1", nil)
+		if tc.rng != "" {
+			request.Header.Set("Range", tc.rng)
+		}
+		if got := countsAsDownload(request); got != tc.want {
+			t.Fatalf("countsAsDownload(%s, %q): got=%v want=%v", tc.method, tc.rng, got, tc.want)
+		}
+	}
+}
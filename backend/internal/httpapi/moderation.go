@@ -0,0 +1,39 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+)
+
+// handleAdminModeration lists the anonymous builds that
+// APP_MODERATE_ANONYMOUS_BUILDS holds until an operator approves them.
+func (s *Server) handleAdminModeration(w http.ResponseWriter, requestID string) {
+	response := adminQueueResponse{Jobs: make([]adminJobResponse, 0)}
+	for _, state := range s.manager.PendingJobs() {
+		response.Jobs = append(response.Jobs, adminJobResponse{stateResponse: s.presentState(state), ClientIP: state.ClientIP})
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+func (s *Server) handleAdminApproveJob(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	state, err := s.manager.ApproveJob(jobID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+	s.recordAudit(r, requestID, audit.Event{Action: audit.ActionJobApproved, Actor: "admin", JobID: jobID})
+	s.logger.InfoContext(r.Context(), "admin: approved job", "jobId", jobID)
+	s.writeSuccess(w, http.StatusOK, requestID, s.presentState(state))
+}
+
+func (s *Server) handleAdminRejectJob(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	state, err := s.manager.RejectJob(jobID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+	s.recordAudit(r, requestID, audit.Event{Action: audit.ActionJobRejected, Actor: "admin", JobID: jobID})
+	s.logger.InfoContext(r.Context(), "admin: rejected job", "jobId", jobID)
+	s.writeSuccess(w, http.StatusOK, requestID, s.presentState(state))
+}
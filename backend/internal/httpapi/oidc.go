@@ -0,0 +1,645 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+const (
+	sessionCookieName = "mfb_session"
+	// loginStateCookieName ties a sign-in to the browser that started it,
+	// so that a callback URL carrying someone else's code and state cannot
+	// sign the browser into their account.
+	loginStateCookieName = "mfb_login_state"
+	// oidcLoginTTL bounds how long the user may take at the provider.
+	oidcLoginTTL     = 10 * time.Minute
+	maxPendingLogins = 10000
+	// oidcLoginsPerMinute limits the sign-ins a client may start, so that
+	// no client can fill maxPendingLogins on its own.
+	oidcLoginsPerMinute = 10
+	oidcHTTPTimeout     = 15 * time.Second
+	maxOIDCBodyBytes    = 1 << 20
+
+	defaultUserJobsLimit = 50
+	maxUserJobsLimit     = 500
+)
+
+// githubOAuthEndpoints serve APP_OIDC_ISSUER=github. GitHub OAuth apps do
+// not issue ID tokens, so the identity is read from the user API instead.
+var githubOAuthEndpoints = oidcEndpoints{
+	Authorization: "https://github.com/login/oauth/authorize",
+	Token:         "https://github.com/login/oauth/access_token",
+	UserInfo:      "https://api.github.com/user",
+}
+
+// oidcEndpoints is the part of the provider metadata the login flow uses.
+type oidcEndpoints struct {
+	Issuer        string `json:"issuer"`
+	Authorization string `json:"authorization_endpoint"`
+	Token         string `json:"token_endpoint"`
+	UserInfo      string `json:"userinfo_endpoint"`
+}
+
+// authUser is a signed-in user. ID is "<provider>:<subject>" and is what
+// jobs and quotas are keyed by.
+type authUser struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+type authStatusResponse struct {
+	Enabled  bool      `json:"enabled"`
+	Provider string    `json:"provider,omitempty"`
+	User     *authUser `json:"user,omitempty"`
+}
+
+type userJobsResponse struct {
+	Jobs []jobs.UserJob `json:"jobs"`
+}
+
+// oidcLogin is a sign-in waiting for the provider to redirect back,
+// keyed by its state parameter.
+type oidcLogin struct {
+	nonce     string
+	verifier  string
+	redirect  string
+	expiresAt time.Time
+}
+
+type authSession struct {
+	user      authUser
+	expiresAt time.Time
+}
+
+// oidcAuth runs the authorization code flow (with PKCE) against the
+// configured provider and keeps the resulting sessions in memory.
+type oidcAuth struct {
+	cfg      config.Config
+	provider string
+	github   bool
+	client   *http.Client
+
+	mu        sync.Mutex
+	endpoints *oidcEndpoints
+	logins    map[string]oidcLogin
+	sessions  map[string]authSession
+}
+
+// newOIDCAuth returns nil when sign-in is not configured.
+func newOIDCAuth(cfg config.Config) *oidcAuth {
+	if cfg.OIDCIssuer == "" {
+		return nil
+	}
+	auth := &oidcAuth{
+		cfg:      cfg,
+		provider: cfg.OIDCIssuer,
+		client:   &http.Client{Timeout: oidcHTTPTimeout},
+		logins:   make(map[string]oidcLogin),
+		sessions: make(map[string]authSession),
+	}
+	if cfg.OIDCIssuer == "github" {
+		endpoints := githubOAuthEndpoints
+		auth.endpoints = &endpoints
+		auth.github = true
+	} else if parsed, err := url.Parse(cfg.OIDCIssuer); err == nil && parsed.Host != "" {
+		auth.provider = parsed.Host
+	}
+	return auth
+}
+
+// discover fetches the provider metadata once; failures are retried on the
+// next sign-in.
+func (a *oidcAuth) discover(ctx context.Context) (oidcEndpoints, error) {
+	a.mu.Lock()
+	cached := a.endpoints
+	a.mu.Unlock()
+	if cached != nil {
+		return *cached, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, a.cfg.OIDCIssuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return oidcEndpoints{}, err
+	}
+	var endpoints oidcEndpoints
+	if err := a.doJSON(request, &endpoints); err != nil {
+		return oidcEndpoints{}, fmt.Errorf("openid discovery: %w", err)
+	}
+	if endpoints.Issuer != a.cfg.OIDCIssuer {
+		return oidcEndpoints{}, fmt.Errorf("openid discovery: issuer %q does not match %q", endpoints.Issuer, a.cfg.OIDCIssuer)
+	}
+	if endpoints.Authorization == "" || endpoints.Token == "" {
+		return oidcEndpoints{}, errors.New("openid discovery: authorization or token endpoint is missing")
+	}
+
+	a.mu.Lock()
+	a.endpoints = &endpoints
+	a.mu.Unlock()
+	return endpoints, nil
+}
+
+func (a *oidcAuth) doJSON(request *http.Request, target any) error {
+	request.Header.Set("Accept", "application/json")
+	response, err := a.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxOIDCBodyBytes))
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", request.URL.Host, response.Status)
+	}
+	return json.Unmarshal(body, target)
+}
+
+func (a *oidcAuth) startLogin(state string, login oidcLogin) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cleanupLoginsLocked(login.expiresAt.Add(-oidcLoginTTL))
+	if len(a.logins) >= maxPendingLogins {
+		return false
+	}
+	a.logins[state] = login
+	return true
+}
+
+// takeLogin returns and forgets the pending sign-in of state, so that every
+// authorization response is accepted at most once.
+func (a *oidcAuth) takeLogin(state string, now time.Time) (oidcLogin, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	login, ok := a.logins[state]
+	delete(a.logins, state)
+	if !ok || state == "" || now.After(login.expiresAt) {
+		return oidcLogin{}, false
+	}
+	return login, true
+}
+
+func (a *oidcAuth) cleanupLoginsLocked(now time.Time) {
+	for state, login := range a.logins {
+		if now.After(login.expiresAt) {
+			delete(a.logins, state)
+		}
+	}
+}
+
+func (a *oidcAuth) createSession(user authUser, now time.Time) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cleanupSessionsLocked(now)
+	a.sessions[token] = authSession{user: user, expiresAt: now.Add(a.cfg.OIDCSessionTTL)}
+	return token, nil
+}
+
+func (a *oidcAuth) session(token string, now time.Time) (authUser, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	session, ok := a.sessions[token]
+	if !ok {
+		return authUser{}, false
+	}
+	if now.After(session.expiresAt) {
+		delete(a.sessions, token)
+		return authUser{}, false
+	}
+	return session.user, true
+}
+
+func (a *oidcAuth) endSession(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, token)
+}
+
+func (a *oidcAuth) cleanupSessionsLocked(now time.Time) {
+	for token, session := range a.sessions {
+		if now.After(session.expiresAt) {
+			delete(a.sessions, token)
+		}
+	}
+}
+
+// sessionCookie is scoped to the API and unreadable by scripts. maxAge < 0
+// deletes it.
+func (a *oidcAuth) sessionCookie(token string, maxAge int) *http.Cookie {
+	return a.cookie(sessionCookieName, token, maxAge)
+}
+
+// loginStateCookie holds the state of the sign-in in progress. Lax cookies
+// are sent with the provider's top-level redirect back to the callback.
+func (a *oidcAuth) loginStateCookie(state string, maxAge int) *http.Cookie {
+	return a.cookie(loginStateCookieName, state, maxAge)
+}
+
+func (a *oidcAuth) cookie(name string, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/api/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(a.cfg.OIDCRedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchange redeems the authorization code and identifies the user.
+func (a *oidcAuth) exchange(ctx context.Context, code string, login oidcLogin, now time.Time) (authUser, error) {
+	endpoints, err := a.discover(ctx)
+	if err != nil {
+		return authUser{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.cfg.OIDCRedirectURL},
+		"client_id":     {a.cfg.OIDCClientID},
+		"client_secret": {a.cfg.OIDCClientSecret},
+		"code_verifier": {login.verifier},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoints.Token, strings.NewReader(form.Encode()))
+	if err != nil {
+		return authUser{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token oidcTokenResponse
+	if err := a.doJSON(request, &token); err != nil {
+		return authUser{}, fmt.Errorf("token exchange: %w", err)
+	}
+	if token.Error != "" {
+		return authUser{}, fmt.Errorf("token exchange: %s %s", token.Error, token.ErrorDescription)
+	}
+
+	if a.github {
+		return a.githubUser(ctx, endpoints.UserInfo, token.AccessToken)
+	}
+	if token.IDToken == "" {
+		return authUser{}, errors.New("token exchange: provider returned no ID token")
+	}
+	claims, err := parseIDToken(token.IDToken)
+	if err != nil {
+		return authUser{}, err
+	}
+	if err := claims.validate(a.cfg.OIDCIssuer, a.cfg.OIDCClientID, login.nonce, now); err != nil {
+		return authUser{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	return authUser{ID: a.provider + ":" + claims.Subject, Provider: a.provider, Name: name, Email: claims.Email}, nil
+}
+
+func (a *oidcAuth) githubUser(ctx context.Context, endpoint string, accessToken string) (authUser, error) {
+	if accessToken == "" {
+		return authUser{}, errors.New("token exchange: provider returned no access token")
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return authUser{}, err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var profile struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := a.doJSON(request, &profile); err != nil {
+		return authUser{}, fmt.Errorf("github user: %w", err)
+	}
+	if profile.ID == 0 {
+		return authUser{}, errors.New("github user: response has no id")
+	}
+	name := profile.Name
+	if name == "" {
+		name = profile.Login
+	}
+	return authUser{ID: "github:" + strconv.FormatInt(profile.ID, 10), Provider: "github", Name: name, Email: profile.Email}, nil
+}
+
+// idTokenClaims are the ID token claims the login flow checks or uses.
+type idTokenClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	Expiry            int64    `json:"exp"`
+	Nonce             string   `json:"nonce"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Email             string   `json:"email"`
+}
+
+// audience is the aud claim, a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// parseIDToken decodes the claims without checking the signature: the token
+// was received directly from the token endpoint over TLS in exchange for
+// the client secret, which OpenID Connect Core (3.1.3.7) accepts in place
+// of signature validation.
+func parseIDToken(token string) (idTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return idTokenClaims{}, errors.New("ID token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return idTokenClaims{}, fmt.Errorf("decode ID token: %w", err)
+	}
+	var claims idTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return idTokenClaims{}, fmt.Errorf("decode ID token: %w", err)
+	}
+	return claims, nil
+}
+
+func (c idTokenClaims) validate(issuer string, clientID string, nonce string, now time.Time) error {
+	switch {
+	case c.Issuer != issuer:
+		return fmt.Errorf("ID token issuer %q does not match", c.Issuer)
+	case !slices.Contains(c.Audience, clientID):
+		return errors.New("ID token is not issued for this client")
+	case c.Subject == "":
+		return errors.New("ID token has no subject")
+	case !now.Before(time.Unix(c.Expiry, 0)):
+		return errors.New("ID token has expired")
+	case c.Nonce != nonce:
+		return errors.New("ID token nonce does not match")
+	}
+	return nil
+}
+
+// randomToken returns 32 random bytes, base64url encoded, for session
+// tokens and the state, nonce and PKCE verifier of a sign-in.
+func randomToken() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+func pkceChallenge(verifier string) string {
+	digest := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// sessionUser returns the signed-in user of the request, or nil.
+func (s *Server) sessionUser(r *http.Request) *authUser {
+	if s.auth == nil {
+		return nil
+	}
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	user, ok := s.auth.session(cookie.Value, time.Now().UTC())
+	if !ok {
+		return nil
+	}
+	return &user
+}
+
+// loginRedirect accepts a path on this host or a URL on an allowed origin,
+// so that sign-in cannot be used as an open redirect.
+func (s *Server) loginRedirect(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "/", true
+	}
+	if strings.ContainsAny(raw, "\\\r\n") {
+		return "", false
+	}
+	if strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		return raw, true
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", false
+	}
+	if _, ok := s.allowedOrigins[parsed.Scheme+"://"+parsed.Host]; !ok {
+		return "", false
+	}
+	return raw, true
+}
+
+// handleAuthLogin starts a sign-in by redirecting the browser to the
+// provider; ?redirect= is where the browser returns afterwards.
+func (s *Server) handleAuthLogin(w http.ResponseWriter, r *http.Request, requestID string) {
+	if s.auth == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	redirect, ok := s.loginRedirect(r.URL.Query().Get("redirect"))
+	if !ok {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REDIRECT", "redirect must be a path or a URL on an allowed origin", nil)
+		return
+	}
+
+	endpoints, err := s.auth.discover(r.Context())
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "oidc: start sign-in", "error", err)
+		s.writeError(w, http.StatusBadGateway, requestID, "AUTH_PROVIDER_UNAVAILABLE", "identity provider is unavailable", nil)
+		return
+	}
+
+	quota := s.allowBuildRequest(r.Context(), "login:"+s.clientIP(r), oidcLoginsPerMinute)
+	setRateLimitHeaders(w, quota, time.Now().UTC())
+	if !quota.Allowed {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionRateLimited, Details: map[string]string{"limit": "login-rate"}})
+		s.writeError(w, http.StatusTooManyRequests, requestID, "RATE_LIMITED", "too many sign-ins from this client", nil)
+		return
+	}
+
+	var state, nonce, verifier string
+	for _, target := range []*string{&state, &nonce, &verifier} {
+		if *target, err = randomToken(); err != nil {
+			s.writeError(w, http.StatusInternalServerError, requestID, "AUTH_FAILED", err.Error(), nil)
+			return
+		}
+	}
+	login := oidcLogin{nonce: nonce, verifier: verifier, redirect: redirect, expiresAt: time.Now().UTC().Add(oidcLoginTTL)}
+	if !s.auth.startLogin(state, login) {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionRateLimited, Details: map[string]string{"limit": "logins"}})
+		s.writeError(w, http.StatusServiceUnavailable, requestID, "TOO_MANY_LOGINS", "too many sign-ins in progress", nil)
+		return
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.cfg.OIDCClientID},
+		"redirect_uri":          {s.cfg.OIDCRedirectURL},
+		"scope":                 {strings.Join(s.cfg.OIDCScopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if !s.auth.github {
+		query.Set("nonce", nonce)
+	}
+	http.SetCookie(w, s.auth.loginStateCookie(state, int(oidcLoginTTL/time.Second)))
+	separator := "?"
+	if strings.Contains(endpoints.Authorization, "?") {
+		separator = "&"
+	}
+	http.Redirect(w, r, endpoints.Authorization+separator+query.Encode(), http.StatusFound)
+}
+
+// handleAuthCallback completes a sign-in: it redeems the code, starts a
+// session cookie and sends the browser back to where the sign-in started.
+func (s *Server) handleAuthCallback(w http.ResponseWriter, r *http.Request, requestID string) {
+	if s.auth == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+
+	now := time.Now().UTC()
+	query := r.URL.Query()
+	state := query.Get("state")
+	cookie, err := r.Cookie(loginStateCookieName)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionAuthFailed, Details: map[string]string{"realm": "oidc", "reason": "state does not match the browser"}})
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_AUTH_STATE", "sign-in was not started in this browser, please try again", nil)
+		return
+	}
+	http.SetCookie(w, s.auth.loginStateCookie("", -1))
+	login, ok := s.auth.takeLogin(state, now)
+	if !ok {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionAuthFailed, Details: map[string]string{"realm": "oidc", "reason": "unknown or expired state"}})
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_AUTH_STATE", "sign-in is unknown or has expired, please try again", nil)
+		return
+	}
+	if providerError := query.Get("error"); providerError != "" {
+		message := strings.TrimSpace(providerError + " " + query.Get("error_description"))
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionAuthFailed, Details: map[string]string{"realm": "oidc", "reason": message}})
+		s.writeError(w, http.StatusUnauthorized, requestID, "AUTH_FAILED", message, nil)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		s.writeError(w, http.StatusBadRequest, requestID, "AUTH_FAILED", "authorization code is missing", nil)
+		return
+	}
+
+	user, err := s.auth.exchange(r.Context(), code, login, now)
+	if err != nil {
+		s.logger.WarnContext(r.Context(), "oidc: sign-in failed", "error", err)
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionAuthFailed, Details: map[string]string{"realm": "oidc", "reason": err.Error()}})
+		s.writeError(w, http.StatusUnauthorized, requestID, "AUTH_FAILED", err.Error(), nil)
+		return
+	}
+	token, err := s.auth.createSession(user, now)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, requestID, "AUTH_FAILED", err.Error(), nil)
+		return
+	}
+
+	http.SetCookie(w, s.auth.sessionCookie(token, int(s.cfg.OIDCSessionTTL/time.Second)))
+	http.Redirect(w, r, login.redirect, http.StatusSeeOther)
+}
+
+func (s *Server) handleAuthMe(w http.ResponseWriter, r *http.Request, requestID string) {
+	response := authStatusResponse{Enabled: s.auth != nil, User: s.sessionUser(r)}
+	if s.auth != nil {
+		response.Provider = s.auth.provider
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+func (s *Server) handleAuthLogout(w http.ResponseWriter, r *http.Request, requestID string) {
+	if s.auth == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		s.auth.endSession(cookie.Value)
+	}
+	http.SetCookie(w, s.auth.sessionCookie("", -1))
+	s.writeSuccess(w, http.StatusOK, requestID, authStatusResponse{Enabled: true, Provider: s.auth.provider})
+}
+
+// handleUserJobs lists the jobs of the signed-in user, newest first,
+// including expired ones whose build logs are still kept.
+func (s *Server) handleUserJobs(w http.ResponseWriter, r *http.Request, requestID string) {
+	if s.auth == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	user := s.sessionUser(r)
+	if user == nil {
+		s.writeError(w, http.StatusUnauthorized, requestID, "UNAUTHENTICATED", "sign in to see your builds", nil)
+		return
+	}
+
+	limit := defaultUserJobsLimit
+	if value := strings.TrimSpace(r.URL.Query().Get("limit")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxUserJobsLimit {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_QUERY", fmt.Sprintf("limit must be between 1 and %d", maxUserJobsLimit), nil)
+			return
+		}
+		limit = parsed
+	}
+
+	history, err := s.manager.UserJobs(user.ID, limit)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, requestID, "INTERNAL_ERROR", err.Error(), nil)
+		return
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, userJobsResponse{Jobs: history})
+}
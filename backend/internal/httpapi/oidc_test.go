@@ -0,0 +1,252 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+// fakeIdentityProvider is an OpenID Connect provider that accepts the code
+// "good-code" for the last authorization request.
+func fakeIdentityProvider(t *testing.T, clientID string) *httptest.Server {
+	t.Helper()
+
+	var (
+		mu                       sync.Mutex
+		issuer, nonce, challenge string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcEndpoints{Issuer: issuer, Authorization: issuer + "/authorize", Token: issuer + "/token"})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		nonce = r.URL.Query().Get("nonce")
+		challenge = r.URL.Query().Get("code_challenge")
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.PostFormValue("code") != "good-code" || r.PostFormValue("client_secret") != "s3cret" ||
+			pkceChallenge(r.PostFormValue("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims, _ := json.Marshal(map[string]any{
+			"iss": issuer, "sub": "alice", "aud": []string{clientID}, "exp": time.Now().Add(time.Hour).Unix(),
+			"nonce": nonce, "name": "Alice", "email": "alice@example.com",
+		})
+		idToken := "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".c2ln"
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "token", "id_token": idToken})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	issuer = server.URL
+	return server
+}
+
+func TestOIDCLogin(t *testing.T) {
+	t.Parallel()
+
+	idp := fakeIdentityProvider(t, "builder")
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:     filepath.Join(workDir, "jobs"),
+		BuildLogsPath:    filepath.Join(workDir, "build-logs"),
+		MaxLogLines:      200,
+		CleanupInterval:  time.Hour,
+		BuildRateLimit:   5,
+		AllowedOrigins:   []string{"http://localhost:5173"},
+		OIDCIssuer:       idp.URL,
+		OIDCClientID:     "builder",
+		OIDCClientSecret: "s3cret",
+		OIDCRedirectURL:  "http://builder.example.com/api/auth/callback",
+		OIDCScopes:       []string{"openid", "profile"},
+		OIDCSessionTTL:   time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		if cookie != nil {
+			request.AddCookie(cookie)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+	decode := func(recorder *httptest.ResponseRecorder, target any) {
+		t.Helper()
+		if err := json.Unmarshal(recorder.Body.Bytes(), target); err != nil {
+			t.Fatalf("decode %d response: %v", recorder.Code, err)
+		}
+	}
+
+	login := serve(http.MethodGet, "/api/auth/login?redirect=/builds", "", nil)
+	if login.Code != http.StatusFound {
+		t.Fatalf("expected a redirect to the provider, got %d: %s", login.Code, login.Body.String())
+	}
+	authorize, err := url.Parse(login.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(authorize.String(), idp.URL+"/authorize?") {
+		t.Fatalf("unexpected authorization URL %q", login.Header().Get("Location"))
+	}
+	query := authorize.Query()
+	if query.Get("client_id") != "builder" || query.Get("scope") != "openid profile" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request: %v", query)
+	}
+	response, err := http.Get(authorize.String())
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	response.Body.Close()
+
+	var stateCookie *http.Cookie
+	for _, cookie := range login.Result().Cookies() {
+		if cookie.Name == loginStateCookieName {
+			stateCookie = cookie
+		}
+	}
+	state := query.Get("state")
+	if stateCookie == nil || !stateCookie.HttpOnly || stateCookie.Value != state {
+		t.Fatalf("expected an HttpOnly cookie with the state, got %+v", stateCookie)
+	}
+	// A callback URL forwarded to another browser must not sign it in.
+	if forged := serve(http.MethodGet, "/api/auth/callback?code=good-code&state="+url.QueryEscape(state), "", nil); forged.Code != http.StatusBadRequest {
+		t.Fatalf("expected a callback without the state cookie to be rejected, got %d", forged.Code)
+	}
+	callback := serve(http.MethodGet, "/api/auth/callback?code=good-code&state="+url.QueryEscape(state), "", stateCookie)
+	if callback.Code != http.StatusSeeOther || callback.Header().Get("Location") != "/builds" {
+		t.Fatalf("expected a redirect back, got %d %q: %s", callback.Code, callback.Header().Get("Location"), callback.Body.String())
+	}
+	var session *http.Cookie
+	for _, cookie := range callback.Result().Cookies() {
+		if cookie.Name == sessionCookieName {
+			session = cookie
+		}
+	}
+	if session == nil || !session.HttpOnly || session.Value == "" {
+		t.Fatalf("expected an HttpOnly session cookie, got %+v", session)
+	}
+
+	if replay := serve(http.MethodGet, "/api/auth/callback?code=good-code&state="+url.QueryEscape(state), "", stateCookie); replay.Code != http.StatusBadRequest {
+		t.Fatalf("expected a replayed state to be rejected, got %d", replay.Code)
+	}
+	limited := false
+	for attempt := 0; attempt < oidcLoginsPerMinute && !limited; attempt++ {
+		limited = serve(http.MethodGet, "/api/auth/login", "", nil).Code == http.StatusTooManyRequests
+	}
+	if !limited {
+		t.Fatalf("expected sign-ins to be rate limited per client")
+	}
+
+	var me struct {
+		Data authStatusResponse `json:"data"`
+	}
+	decode(serve(http.MethodGet, "/api/auth/me", "", session), &me)
+	wantID := strings.TrimPrefix(idp.URL, "http://") + ":alice"
+	if !me.Data.Enabled || me.Data.User == nil || me.Data.User.ID != wantID || me.Data.User.Name != "Alice" {
+		t.Fatalf("unexpected session user: %+v", me.Data)
+	}
+
+	created := serve(http.MethodPost, "/api/jobs", `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam"}`, session)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create job: %d %s", created.Code, created.Body.String())
+	}
+	var job struct {
+		Data stateResponse `json:"data"`
+	}
+	decode(created, &job)
+	if owned := serve(http.MethodGet, "/api/jobs/"+job.Data.ID, "", session); owned.Code != http.StatusOK {
+		t.Fatalf("expected the creator to read the private job, got %d", owned.Code)
+	}
+	if foreign := serve(http.MethodGet, "/api/jobs/"+job.Data.ID, "", nil); foreign.Code != http.StatusNotFound {
+		t.Fatalf("expected the private job to be hidden from others, got %d", foreign.Code)
+	}
+	if _, err := manager.CreateJob("https://github.com/example/repo.git", "main", "rak4631", jobs.BuildOptions{}, ""); err != nil {
+		t.Fatalf("create anonymous job: %v", err)
+	}
+
+	var history struct {
+		Data userJobsResponse `json:"data"`
+	}
+	decode(serve(http.MethodGet, "/api/me/jobs", "", session), &history)
+	if len(history.Data.Jobs) != 1 || history.Data.Jobs[0].Device != "tbeam" || !history.Data.Jobs[0].Retained {
+		t.Fatalf("unexpected history: %+v", history.Data.Jobs)
+	}
+	if anonymous := serve(http.MethodGet, "/api/me/jobs", "", nil); anonymous.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %d", anonymous.Code)
+	}
+
+	if logout := serve(http.MethodPost, "/api/auth/logout", "", session); logout.Code != http.StatusOK {
+		t.Fatalf("logout: %d", logout.Code)
+	}
+	me.Data = authStatusResponse{}
+	decode(serve(http.MethodGet, "/api/auth/me", "", session), &me)
+	if me.Data.User != nil {
+		t.Fatalf("expected the session to end, got %+v", me.Data.User)
+	}
+}
+
+func TestLoginRedirect(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{AllowedOrigins: []string{"http://localhost:5173"}}, nil, slog.New(slog.DiscardHandler))
+	for raw, want := range map[string]bool{
+		"":                              true,
+		"/builds?id=1":                  true,
+		"http://localhost:5173/builds":  true,
+		"//evil.example.com":            false,
+		"/\\evil.example.com":           false,
+		"https://evil.example.com/":     false,
+		"javascript:alert(1)":           false,
+		"http://localhost:5173.evil.io": false,
+	} {
+		if _, ok := server.loginRedirect(raw); ok != want {
+			t.Errorf("loginRedirect(%q) = %v, want %v", raw, ok, want)
+		}
+	}
+}
+
+func TestIDTokenValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	valid := idTokenClaims{Issuer: "https://idp.example.com", Subject: "alice", Audience: audience{"builder"}, Expiry: now.Add(time.Minute).Unix(), Nonce: "n"}
+	if err := valid.validate("https://idp.example.com", "builder", "n", now); err != nil {
+		t.Fatalf("expected valid claims: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*idTokenClaims){
+		"issuer":   func(c *idTokenClaims) { c.Issuer = "https://other.example.com" },
+		"audience": func(c *idTokenClaims) { c.Audience = audience{"other"} },
+		"subject":  func(c *idTokenClaims) { c.Subject = "" },
+		"expired":  func(c *idTokenClaims) { c.Expiry = now.Unix() },
+		"nonce":    func(c *idTokenClaims) { c.Nonce = "replayed" },
+	} {
+		claims := valid
+		mutate(&claims)
+		if err := claims.validate("https://idp.example.com", "builder", "n", now); err == nil {
+			t.Errorf("%s: expected validation to fail", name)
+		}
+	}
+
+	var single idTokenClaims
+	if err := json.Unmarshal([]byte(`{"aud":"builder"}`), &single); err != nil || len(single.Audience) != 1 {
+		t.Fatalf("expected a string audience to decode: %+v, %v", single.Audience, err)
+	}
+}
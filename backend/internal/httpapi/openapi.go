@@ -0,0 +1,481 @@
+package httpapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildinfo"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildlogs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
+)
+
+// apiOperation describes one route for the OpenAPI document. Request and
+// Response are zero values of the Go types the handler decodes and writes,
+// so schemas follow the handler types. Raw responses are written without the
+// data/meta envelope; ContentType marks non-JSON bodies.
+type apiOperation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Auth        string
+	Params      []apiParam
+	Request     any
+	Status      int
+	Response    any
+	Raw         bool
+	ContentType string
+}
+
+type apiParam struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+}
+
+// apiErrorCodes lists every error.code the API returns.
+var apiErrorCodes = []string{
+	"ARTIFACT_NOT_FOUND", "ARTIFACT_READ_FAILED", "AUDIT_LOG_ERROR", "AUTH_FAILED",
+	"AUTH_PROVIDER_UNAVAILABLE", "BUILD_LOGS_ERROR", "BUILD_LOG_NOT_FOUND", "CACHE_IMPORT_FAILED",
+	"CACHE_PURGE_FAILED", "CAPTCHA_GENERATION_FAILED", "CAPTCHA_SESSION_FAILED", "CAPTCHA_UNAVAILABLE",
+	"CHECKSUM_NOT_FOUND", "CSRF_CHECK_FAILED", "DEVICE_MISMATCH", "DEVICE_NOT_ALLOWED", "DISCOVERY_BUSY",
+	"DISCOVERY_FAILED", "ELF_NOT_FOUND", "IDEMPOTENCY_KEY_IN_PROGRESS", "IDEMPOTENCY_KEY_MISMATCH",
+	"INSUFFICIENT_STORAGE", "INTERNAL_ERROR", "INVALID_AUTH_STATE", "INVALID_CAPTCHA", "INVALID_DEVICE",
+	"INVALID_FILTER", "INVALID_IDEMPOTENCY_KEY", "INVALID_JOB", "INVALID_PROOF_OF_WORK", "INVALID_QUERY",
+	"INVALID_REDIRECT", "INVALID_REQUEST", "INVALID_SIGNATURE", "INVALID_WEBHOOK", "IP_DENIED",
+	"JOB_FINISHED", "JOB_NOT_FOUND", "JOB_NOT_PENDING", "NOT_FOUND", "NOT_READY", "ORIGIN_NOT_ALLOWED",
+	"PAYLOAD_TOO_LARGE", "QUOTA_EXCEEDED", "RATE_LIMITED", "REFS_DISCOVERY_FAILED",
+	"REGISTRY_UNAVAILABLE", "REPO_NOT_ALLOWED", "REPO_NOT_FEATURED", "SECRET_NOT_FOUND",
+	"SECRET_STORE_FAILED", "SERVICE_DRAINING", "SIZEDIFF_FAILED", "STATS_ERROR", "STREAM_UNSUPPORTED",
+	"TOO_MANY_LOGINS", "UNAUTHENTICATED", "UNAUTHORIZED", "UNSUPPORTED_API_VERSION",
+}
+
+// apiEnums lists the allowed values of string types used in responses.
+var apiEnums = map[reflect.Type][]string{
+	reflect.TypeOf(jobs.Status("")): {
+		string(jobs.StatusPending), string(jobs.StatusQueued), string(jobs.StatusRunning),
+		string(jobs.StatusSuccess), string(jobs.StatusFailed), string(jobs.StatusCancelled),
+	},
+	reflect.TypeOf(secrets.Kind("")): {
+		string(secrets.KindGitCredential), string(secrets.KindWebhookSecret), string(secrets.KindSigningKey),
+	},
+}
+
+var (
+	jobIDParam      = apiParam{Name: "jobId", In: "path", Required: true}
+	artifactIDParam = apiParam{Name: "artifactId", In: "path", Required: true}
+	repoURLQuery    = apiParam{Name: "repoUrl", In: "query", Description: "Repository URL or owner/name shorthand"}
+	limitQuery      = apiParam{Name: "limit", In: "query", Description: "Maximum number of results"}
+	jobTokenParam   = apiParam{Name: jobTokenHeader, In: "header", Description: "Access token of a private job, returned when it was created"}
+	secretNameParam = apiParam{Name: "name", In: "path", Required: true}
+	jobTokenQuery   = apiParam{Name: "token", In: "query", Description: "Same as " + jobTokenHeader + " for links and EventSource"}
+)
+
+var apiOperations = []apiOperation{
+	{Method: http.MethodGet, Path: "/api/healthz", Summary: "Service health, version and discovery load", Response: healthResponse{}},
+	{Method: http.MethodGet, Path: "/api/cluster/healthz", Summary: "Load, version and drain status of every node sharing the job registry",
+		Response: clusterHealthResponse{}},
+	{Method: http.MethodGet, Path: "/api/livez", Summary: "Liveness: the process serves requests", Response: livenessResponse{}},
+	{Method: http.MethodGet, Path: "/api/readyz", Summary: "Readiness: docker, git, writable workdir and queue capacity; 503 NOT_READY otherwise",
+		Response: jobs.Readiness{}},
+	{Method: http.MethodGet, Path: "/api/version", Summary: "Backend version, build details and capabilities", Response: versionResponse{}},
+	{Method: http.MethodGet, Path: "/api/openapi.json", Summary: "This OpenAPI document", Raw: true, Response: map[string]any{}},
+	{Method: http.MethodGet, Path: "/api/stats", Summary: "Usage statistics", Auth: "stats",
+		Params:   []apiParam{{Name: "recentLimit", In: "query"}, {Name: "topLimit", In: "query"}},
+		Response: statsFullResponse{}},
+	{Method: http.MethodGet, Path: "/api/metrics", Summary: "Prometheus metrics", Auth: "stats", Raw: true, ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/api/stats/service", Summary: "Public build totals from the job history",
+		Params: []apiParam{{Name: "days", In: "query", Description: "Window in days, 30 by default"}}, Response: jobs.ServiceStats{}},
+	{Method: http.MethodGet, Path: "/api/stats/build-logs", Summary: "Recent persisted build logs", Auth: "stats",
+		Params: []apiParam{limitQuery}, Response: struct {
+			Logs []buildlogs.BuildLogEntry `json:"logs"`
+		}{}},
+	{Method: http.MethodGet, Path: "/api/stats/build-logs/{jobId}", Summary: "One persisted build log", Auth: "stats",
+		Params: []apiParam{jobIDParam}, Response: buildlogs.BuildLog{}},
+	{Method: http.MethodGet, Path: "/api/stats/build-logs/{jobId}/logs.txt", Summary: "Full log file of a job, also from before a restart", Auth: "stats",
+		Params: []apiParam{jobIDParam}, Raw: true, ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/api/admin/cache/export", Summary: "Export firmware and PlatformIO caches as .tar.gz", Auth: "admin",
+		Params: []apiParam{{Name: "include", In: "query", Description: "Comma-separated sources"}}, Raw: true, ContentType: "application/gzip"},
+	{Method: http.MethodPost, Path: "/api/admin/cache/import", Summary: "Import a cache archive", Auth: "admin",
+		Params: []apiParam{{Name: "include", In: "query", Description: "Comma-separated sources"}}, Response: jobs.CacheImportResult{}},
+	{Method: http.MethodPost, Path: "/api/admin/cache/purge", Summary: "Delete the contents of the selected caches", Auth: "admin",
+		Params: []apiParam{{Name: "include", In: "query", Description: "Comma-separated sources"}}, Response: jobs.CachePurgeResult{}},
+	{Method: http.MethodGet, Path: "/api/admin/queue", Summary: "Queued and running jobs with client addresses", Auth: "admin", Response: adminQueueResponse{}},
+	{Method: http.MethodPost, Path: "/api/admin/jobs/{jobId}/cancel", Summary: "Cancel a pending, queued or running job", Auth: "admin",
+		Params: []apiParam{jobIDParam}, Response: stateResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/moderation", Summary: "Anonymous builds waiting for approval, oldest first", Auth: "admin",
+		Response: adminQueueResponse{}},
+	{Method: http.MethodPost, Path: "/api/admin/jobs/{jobId}/approve", Summary: "Queue a build waiting for approval", Auth: "admin",
+		Params: []apiParam{jobIDParam}, Response: stateResponse{}},
+	{Method: http.MethodPost, Path: "/api/admin/jobs/{jobId}/reject", Summary: "Cancel a build waiting for approval", Auth: "admin",
+		Params: []apiParam{jobIDParam}, Response: stateResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/audit", Summary: "Audit log events, newest first", Auth: "admin",
+		Params: []apiParam{
+			{Name: "action", In: "query", Description: "Action, or its prefix before the dot such as auth"},
+			{Name: "actor", In: "query"}, {Name: "ip", In: "query"}, {Name: "jobId", In: "query"},
+			{Name: "since", In: "query", Description: "RFC 3339 time"}, {Name: "until", In: "query", Description: "RFC 3339 time"}, limitQuery},
+		Response: auditEventsResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/abuse", Summary: "Clients with failing builds, flagged ones first", Auth: "admin", Response: abuseReportResponse{}},
+	{Method: http.MethodPost, Path: "/api/admin/abuse/clear", Summary: "Lift the abuse flag of a client", Auth: "admin",
+		Request: abuseClearRequest{}, Response: abuseReportResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/config", Summary: "Effective configuration with secrets redacted", Auth: "admin", Response: map[string]any{}},
+	{Method: http.MethodGet, Path: "/api/admin/ip-rules", Summary: "Client address allow, deny and anonymous lists", Auth: "admin", Response: ipRulesResponse{}},
+	{Method: http.MethodPut, Path: "/api/admin/ip-rules", Summary: "Replace client address lists until restart", Auth: "admin",
+		Request: ipRulesRequest{}, Response: ipRulesResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/cluster/jobs", Summary: "Jobs of every node sharing the job registry, newest first", Auth: "admin",
+		Params:   []apiParam{{Name: "node", In: "query"}, {Name: "status", In: "query"}, limitQuery},
+		Response: jobs.ClusterJobs{}},
+	{Method: http.MethodGet, Path: "/api/admin/secrets", Summary: "Stored credentials without their values", Auth: "admin", Response: secretsResponse{}},
+	{Method: http.MethodPut, Path: "/api/admin/secrets/{name}", Summary: "Create or rotate a stored credential", Auth: "admin",
+		Params: []apiParam{secretNameParam}, Request: putSecretRequest{}, Response: secrets.Entry{}},
+	{Method: http.MethodDelete, Path: "/api/admin/secrets/{name}", Summary: "Delete a stored credential", Auth: "admin",
+		Params: []apiParam{secretNameParam}, Response: secrets.Entry{}},
+	{Method: http.MethodGet, Path: "/api/admin/drain", Summary: "Drain mode and in-flight jobs", Auth: "admin", Response: jobs.DrainStatus{}},
+	{Method: http.MethodPost, Path: "/api/admin/drain", Summary: "Start or stop refusing new builds", Auth: "admin",
+		Request: adminDrainRequest{}, Response: jobs.DrainStatus{}},
+	{Method: http.MethodGet, Path: "/api/admin/log-level", Summary: "Minimum level of the backend log", Auth: "admin", Response: adminLogLevel{}},
+	{Method: http.MethodPut, Path: "/api/admin/log-level", Summary: "Change the log level until restart", Auth: "admin",
+		Request: adminLogLevel{}, Response: adminLogLevel{}},
+	{Method: http.MethodGet, Path: "/api/admin/debug/dump", Summary: "Goroutines, heap, job log buffers, log stream subscribers and queue", Auth: "admin",
+		Params: []apiParam{{Name: "stacks", In: "query", Description: "1 to include every goroutine's stack"}}, Response: debugDumpResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/debug/vars", Summary: "expvar variables", Auth: "admin", Raw: true, ContentType: "application/json"},
+	{Method: http.MethodGet, Path: "/api/admin/debug/pprof/{profile}", Summary: "net/http/pprof profiles; empty lists them", Auth: "admin",
+		Params: []apiParam{{Name: "profile", In: "path", Required: true}, {Name: "seconds", In: "query", Description: "Duration of profile and trace"},
+			{Name: "debug", In: "query", Description: "1 or 2 for text output"}}, Raw: true, ContentType: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/api/devices", Summary: "Device catalog of featured repositories",
+		Params:   []apiParam{repoURLQuery, {Name: "ref", In: "query"}, {Name: "platform", In: "query"}, {Name: "tags", In: "query"}},
+		Response: deviceCatalogResponse{}},
+	{Method: http.MethodGet, Path: "/api/devices/search", Summary: "Search the device catalog",
+		Params: []apiParam{{Name: "q", In: "query", Required: true}, limitQuery}, Response: deviceSearchResponse{}},
+	{Method: http.MethodGet, Path: "/api/devices/changes", Summary: "Device catalog change history",
+		Params: []apiParam{repoURLQuery}, Response: deviceChangesResponse{}},
+	{Method: http.MethodGet, Path: "/api/devices/{device}/availability", Summary: "Release tags that can build a device",
+		Params: []apiParam{{Name: "device", In: "path", Required: true}, repoURLQuery, limitQuery}, Response: jobs.DeviceAvailability{}},
+	{Method: http.MethodPost, Path: "/api/repos/discover", Summary: "Discover buildable devices", Request: discoverRequest{}, Response: discoverResponse{}},
+	{Method: http.MethodPost, Path: "/api/repos/refs", Summary: "Default branch and recent branches and tags", Request: repoRefsRequest{}, Response: repoRefsResponse{}},
+	{Method: http.MethodPost, Path: "/api/repos/compare-devices", Summary: "Compare devices between two refs", Request: compareDevicesRequest{}, Response: compareDevicesResponse{}},
+	{Method: http.MethodPost, Path: "/api/webhooks/git", Summary: "GitHub/GitLab push and tag webhook", Auth: "webhook", Status: http.StatusAccepted, Response: gitWebhookResponse{}},
+	{Method: http.MethodGet, Path: "/api/captcha", Summary: "New captcha challenge", Response: captchaResponse{}},
+	{Method: http.MethodGet, Path: "/api/pow", Summary: "New proof-of-work challenge, an alternative to the captcha for queueing a build",
+		Response: powResponse{}},
+	{Method: http.MethodPost, Path: "/api/jobs", Summary: "Queue a build",
+		Params:  []apiParam{{Name: "Idempotency-Key", In: "header", Description: "Retries with the same key return the original job with 200 and Idempotent-Replayed: true"}},
+		Request: createJobRequest{}, Status: http.StatusCreated, Response: stateResponse{}},
+	{Method: http.MethodGet, Path: "/api/auth/login", Summary: "Start an OpenID Connect sign-in; redirects to the identity provider",
+		Params: []apiParam{{Name: "redirect", In: "query", Description: "Path or allowed-origin URL to return to afterwards"}}, Status: http.StatusFound, Raw: true},
+	{Method: http.MethodGet, Path: "/api/auth/callback", Summary: "Sign-in redirect target; sets the session cookie and redirects back",
+		Params: []apiParam{{Name: "code", In: "query"}, {Name: "state", In: "query", Required: true}}, Status: http.StatusSeeOther, Raw: true},
+	{Method: http.MethodGet, Path: "/api/auth/me", Summary: "Whether sign-in is enabled and the signed-in user", Response: authStatusResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/logout", Summary: "End the session", Auth: "session", Response: authStatusResponse{}},
+	{Method: http.MethodGet, Path: "/api/me/jobs", Summary: "Build history of the signed-in user, newest first", Auth: "session",
+		Params: []apiParam{limitQuery}, Response: userJobsResponse{}},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}", Summary: "Job state", Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery}, Response: stateResponse{}},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/logs", Summary: "Job log lines", Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery}, Response: logsResponse{}},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/logs.txt", Summary: "Job log as a plain-text download",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery}, Raw: true, ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/logs/ndjson", Summary: "Live job log as newline-delimited JSON ({seq, ts, line} per line, {type: ping} heartbeats)",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery, {Name: "after", In: "query", Description: "Skip the lines up to this seq"}}, Raw: true, ContentType: "application/x-ndjson"},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/logs/stream", Summary: "Live job log as server-sent events (log, progress, ping)",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery,
+			{Name: "Last-Event-ID", In: "header", Description: "Resume after this log line ID instead of replaying the snapshot"},
+			{Name: "lastEventId", In: "query", Description: "Same as Last-Event-ID for clients that reopen the stream themselves"}},
+		Raw: true, ContentType: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/events/stream", Summary: "Job state changes as server-sent events (state, status, queue, done, ping)",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery}, Raw: true, ContentType: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/sizediff", Summary: "Firmware size difference against another job",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery, {Name: "against", In: "query", Required: true}, limitQuery}, Response: jobs.SizeDiff{}},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/artifacts", Summary: "Job artifacts", Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery}, Response: artifactsResponse{}},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/artifacts.zip", Summary: "All artifacts and manifest.json as a zip",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery}, Raw: true, ContentType: "application/zip"},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/artifacts/{artifactId}", Summary: "Download an artifact",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery, artifactIDParam, {Name: "inline", In: "query"}}, Raw: true, ContentType: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/api/jobs/{jobId}/artifacts/{artifactId}/sha256", Summary: "Artifact checksum in sha256sum format",
+		Params: []apiParam{jobIDParam, jobTokenParam, jobTokenQuery, artifactIDParam}, Raw: true, ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/api/launcherhub/firmwares", Summary: "Launcher hub firmware list or detail",
+		Params: []apiParam{{Name: "category", In: "query"}, {Name: "fid", In: "query"}, {Name: "page", In: "query"}}, Raw: true, Response: lhFirmwareListResponse{}},
+	{Method: http.MethodGet, Path: "/api/launcherhub/download", Summary: "Launcher hub firmware download",
+		Params: []apiParam{{Name: "fid", In: "query", Required: true}}, Raw: true, ContentType: "application/octet-stream"},
+}
+
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument []byte
+)
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request, requestID string) {
+	openAPIOnce.Do(func() {
+		document, err := json.Marshal(buildOpenAPISpec(buildinfo.Version, apiOperations))
+		if err != nil {
+			s.logger.ErrorContext(r.Context(), "openapi: build document", "error", err)
+			return
+		}
+		openAPIDocument = document
+	})
+	if openAPIDocument == nil {
+		s.writeError(w, http.StatusInternalServerError, requestID, "INTERNAL_ERROR", "openapi document unavailable", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openAPIDocument)
+}
+
+// buildOpenAPISpec renders an OpenAPI 3.1 document for operations.
+func buildOpenAPISpec(version string, operations []apiOperation) map[string]any {
+	if version == "" {
+		version = "dev"
+	}
+	schemas := &schemaRegistry{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	schemas.schemas["Meta"] = map[string]any{
+		"type":     "object",
+		"required": []string{"timestamp", "requestId"},
+		"properties": map[string]any{
+			"timestamp": map[string]any{"type": "string", "format": "date-time"},
+			"requestId": map[string]any{"type": "string"},
+		},
+	}
+	schemas.schemas["ErrorEnvelope"] = map[string]any{
+		"type":     "object",
+		"required": []string{"error", "meta"},
+		"properties": map[string]any{
+			"error": map[string]any{
+				"type":     "object",
+				"required": []string{"code", "message"},
+				"properties": map[string]any{
+					"code":    map[string]any{"type": "string", "enum": apiErrorCodes},
+					"message": map[string]any{"type": "string"},
+					"details": map[string]any{},
+				},
+			},
+			"meta": map[string]any{"$ref": "#/components/schemas/Meta"},
+		},
+	}
+
+	paths := map[string]any{}
+	for _, op := range operations {
+		path := apiVersionPrefix + strings.TrimPrefix(op.Path, "/api")
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(op.Method)] = schemas.operation(op)
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Meshtastic Firmware Builder API",
+			"version": version,
+		},
+		"paths":                 paths,
+		"x-unversioned-aliases": "Every /api/v1/ path is also served without the version segment for existing clients.",
+		"components": map[string]any{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]any{
+				"stats":   map[string]any{"type": "http", "scheme": "bearer", "description": "APP_STATS_PASSWORD"},
+				"admin":   map[string]any{"type": "http", "scheme": "bearer", "description": "APP_ADMIN_TOKEN, or a client certificate issued by APP_ADMIN_CLIENT_CA"},
+				"webhook": map[string]any{"type": "apiKey", "in": "header", "name": "X-Hub-Signature-256", "description": "HMAC of the body with APP_GIT_WEBHOOK_SECRET, or X-Gitlab-Token"},
+				"session": map[string]any{"type": "apiKey", "in": "cookie", "name": sessionCookieName, "description": "Session started through /api/auth/login"},
+			},
+		},
+	}
+}
+
+type schemaRegistry struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+func (r *schemaRegistry) operation(op apiOperation) map[string]any {
+	result := map[string]any{"summary": op.Summary}
+	if op.Auth != "" {
+		result["security"] = []map[string][]string{{op.Auth: {}}}
+	}
+
+	if len(op.Params) > 0 {
+		params := make([]map[string]any, 0, len(op.Params))
+		for _, param := range op.Params {
+			entry := map[string]any{"name": param.Name, "in": param.In, "schema": map[string]any{"type": "string"}}
+			if param.Required || param.In == "path" {
+				entry["required"] = true
+			}
+			if param.Description != "" {
+				entry["description"] = param.Description
+			}
+			params = append(params, entry)
+		}
+		result["parameters"] = params
+	}
+
+	if op.Request != nil {
+		result["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": r.schema(reflect.TypeOf(op.Request))}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	switch {
+	case op.ContentType != "":
+		success["content"] = map[string]any{op.ContentType: map[string]any{"schema": map[string]any{"type": "string"}}}
+	case op.Raw && op.Response == nil:
+		// A redirect without a body.
+	case op.Raw:
+		success["content"] = map[string]any{"application/json": map[string]any{"schema": r.schema(reflect.TypeOf(op.Response))}}
+	default:
+		success["content"] = map[string]any{"application/json": map[string]any{"schema": map[string]any{
+			"type":     "object",
+			"required": []string{"data", "meta"},
+			"properties": map[string]any{
+				"data": r.schema(reflect.TypeOf(op.Response)),
+				"meta": map[string]any{"$ref": "#/components/schemas/Meta"},
+			},
+		}}}
+	}
+
+	result["responses"] = map[string]any{
+		strconv.Itoa(status): success,
+		"default": map[string]any{
+			"description": "Error",
+			"content":     map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/ErrorEnvelope"}}},
+		},
+	}
+	return result
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schema returns the JSON schema of t the way encoding/json serializes it.
+// Named structs become components referenced by $ref.
+func (r *schemaRegistry) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType, t.Implements(jsonMarshalerType):
+		return map[string]any{}
+	case t.Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+	if values, ok := apiEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": r.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": r.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + r.register(t)}
+	}
+	return map[string]any{}
+}
+
+func (r *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+
+	name := exportedName(t.Name())
+	if _, taken := r.schemas[name]; taken {
+		name = exportedName(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+	}
+	r.names[t] = name
+	r.schemas[name] = map[string]any{}
+	r.schemas[name] = r.structSchema(t)
+	return name
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	r.collectFields(t, properties, &required)
+	sort.Strings(required)
+
+	result := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+	return result
+}
+
+func (r *schemaRegistry) collectFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.collectFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = r.schema(field.Type)
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
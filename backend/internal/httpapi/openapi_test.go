@@ -0,0 +1,125 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestHandleOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got=%d want=%d", recorder.Code, http.StatusOK)
+	}
+
+	var document struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+				Required   []string       `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	body := recorder.Body.Bytes()
+	if err := json.Unmarshal(body, &document); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	if document.OpenAPI != "3.1.0" {
+		t.Fatalf("unexpected openapi version: got=%q", document.OpenAPI)
+	}
+	if _, ok := document.Paths["/api/v1/jobs"]["post"]; !ok {
+		t.Fatalf("missing POST /api/v1/jobs")
+	}
+
+	request, ok := document.Components.Schemas["CreateJobRequest"]
+	if !ok {
+		t.Fatalf("missing CreateJobRequest schema")
+	}
+	for _, property := range []string{"repoUrl", "ref", "device", "submodules", "patch"} {
+		if _, ok := request.Properties[property]; !ok {
+			t.Fatalf("CreateJobRequest lacks %q", property)
+		}
+	}
+	if slices.Contains(request.Required, "patch") || !slices.Contains(request.Required, "repoUrl") {
+		t.Fatalf("unexpected required properties: %v", request.Required)
+	}
+
+	for _, match := range strings.Split(string(body), `"$ref":"#/components/schemas/`)[1:] {
+		name := match[:strings.Index(match, `"`)]
+		if _, ok := document.Components.Schemas[name]; !ok {
+			t.Fatalf("dangling schema reference %q", name)
+		}
+	}
+}
+
+// TestOpenAPICoversRoutes keeps the document in sync with the router and
+// the error codes written by handlers.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	t.Parallel()
+
+	documented := make(map[string]bool, len(apiOperations))
+	for _, op := range apiOperations {
+		documented[op.Path] = true
+	}
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("list sources: %v", err)
+	}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		source, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		file, err := parser.ParseFile(fset, name, source, 0)
+		if err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.BinaryExpr:
+				if literal, ok := node.Y.(*ast.BasicLit); ok && node.Op == token.EQL && literal.Kind == token.STRING {
+					value, _ := strconv.Unquote(literal.Value)
+					if strings.HasPrefix(value, "/api/") && !documented[value] {
+						t.Errorf("route %s is not documented", value)
+					}
+				}
+			case *ast.CallExpr:
+				selector, ok := node.Fun.(*ast.SelectorExpr)
+				if !ok || selector.Sel.Name != "writeError" || len(node.Args) < 4 {
+					return true
+				}
+				if literal, ok := node.Args[3].(*ast.BasicLit); ok && literal.Kind == token.STRING {
+					code, _ := strconv.Unquote(literal.Value)
+					if !slices.Contains(apiErrorCodes, code) {
+						t.Errorf("error code %s is not documented", code)
+					}
+				}
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const powTTL = 10 * time.Minute
+
+// maxPoWNonceLength bounds the submitted nonce; a decimal or hex counter
+// never needs more.
+const maxPoWNonceLength = 64
+
+type powChallenge struct {
+	host       string
+	difficulty int
+	expiresAt  time.Time
+}
+
+// powResponse is a hashcash-style challenge: find a nonce such that
+// SHA-256(challenge + ":" + nonce) starts with difficulty zero bits.
+type powResponse struct {
+	Required   bool      `json:"required"`
+	Challenge  string    `json:"challenge,omitempty"`
+	Algorithm  string    `json:"algorithm,omitempty"`
+	Difficulty int       `json:"difficulty,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt,omitempty"`
+}
+
+func (s *Server) handleNewProofOfWork(w http.ResponseWriter, r *http.Request, requestID string) {
+	if !s.cfg.PoWEnabled {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	if !s.cfg.RequireCaptcha {
+		s.writeSuccess(w, http.StatusOK, requestID, powResponse{Required: false})
+		return
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, s.newProofOfWork(s.clientIP(r)))
+}
+
+func (s *Server) newProofOfWork(remoteAddr string) powResponse {
+	now := time.Now().UTC()
+	challengeID := generateRequestID()
+	challenge := powChallenge{
+		host:       normalizeRemoteHost(remoteAddr),
+		difficulty: s.powDifficulty(),
+		expiresAt:  now.Add(powTTL),
+	}
+
+	s.captchaMu.Lock()
+	defer s.captchaMu.Unlock()
+	s.cleanupProofsOfWorkLocked(now)
+	s.powChallenges[challengeID] = challenge
+
+	return powResponse{
+		Required:   true,
+		Challenge:  challengeID,
+		Algorithm:  "sha256",
+		Difficulty: challenge.difficulty,
+		ExpiresAt:  challenge.expiresAt,
+	}
+}
+
+// powDifficulty adds one bit, doubling the expected work, each time the
+// queue grows by another multiple of the build concurrency.
+func (s *Server) powDifficulty() int {
+	difficulty := s.cfg.PoWDifficulty
+	if s.manager != nil {
+		queued, workers := s.manager.QueueLoad()
+		difficulty += bits.Len(uint(queued / workers))
+	}
+	return min(difficulty, s.cfg.PoWMaxDifficulty)
+}
+
+// validateProofOfWork consumes the challenge, so each solution queues one
+// build at most.
+func (s *Server) validateProofOfWork(remoteAddr string, challengeID string, nonce string) error {
+	challengeID = strings.TrimSpace(challengeID)
+	nonce = strings.TrimSpace(nonce)
+	if challengeID == "" || nonce == "" {
+		return fmt.Errorf("proof of work is required")
+	}
+	if len(challengeID) > 64 || len(nonce) > maxPoWNonceLength {
+		return fmt.Errorf("proof of work is invalid")
+	}
+
+	now := time.Now().UTC()
+	s.captchaMu.Lock()
+	s.cleanupProofsOfWorkLocked(now)
+	challenge, ok := s.powChallenges[challengeID]
+	delete(s.powChallenges, challengeID)
+	s.captchaMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("proof of work challenge is invalid or expired")
+	}
+	if challenge.host != normalizeRemoteHost(remoteAddr) {
+		return fmt.Errorf("proof of work challenge is invalid for this client")
+	}
+	if leadingZeroBits(sha256.Sum256([]byte(challengeID+":"+nonce))) < challenge.difficulty {
+		return fmt.Errorf("proof of work does not meet difficulty %d", challenge.difficulty)
+	}
+	return nil
+}
+
+func (s *Server) cleanupProofsOfWorkLocked(now time.Time) {
+	for challengeID, challenge := range s.powChallenges {
+		if now.After(challenge.expiresAt) {
+			delete(s.powChallenges, challengeID)
+		}
+	}
+}
+
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
@@ -0,0 +1,156 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func solveProofOfWork(t *testing.T, challenge string, difficulty int) string {
+	t.Helper()
+	for nonce := 0; ; nonce++ {
+		candidate := strconv.Itoa(nonce)
+		if leadingZeroBits(sha256.Sum256([]byte(challenge+":"+candidate))) >= difficulty {
+			return candidate
+		}
+	}
+}
+
+func TestProofOfWork(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{RequireCaptcha: true, PoWEnabled: true, PoWDifficulty: 8, PoWMaxDifficulty: 12}, nil, slog.New(slog.DiscardHandler))
+
+	challenge := server.newProofOfWork("127.0.0.1:1000")
+	if !challenge.Required || challenge.Algorithm != "sha256" || challenge.Difficulty != 8 {
+		t.Fatalf("unexpected challenge: %+v", challenge)
+	}
+	nonce := solveProofOfWork(t, challenge.Challenge, challenge.Difficulty)
+	if err := server.validateProofOfWork("127.0.0.2:1000", challenge.Challenge, nonce); err == nil {
+		t.Fatalf("expected a challenge issued to another client to be rejected")
+	}
+
+	challenge = server.newProofOfWork("127.0.0.1:1000")
+	nonce = solveProofOfWork(t, challenge.Challenge, challenge.Difficulty)
+	if err := server.validateProofOfWork("127.0.0.1:2000", challenge.Challenge, nonce); err != nil {
+		t.Fatalf("expected the solution to verify: %v", err)
+	}
+	if err := server.validateProofOfWork("127.0.0.1:2000", challenge.Challenge, nonce); err == nil {
+		t.Fatalf("expected a reused challenge to be rejected")
+	}
+
+	challenge = server.newProofOfWork("127.0.0.1:1000")
+	for nonce := 0; ; nonce++ {
+		candidate := strconv.Itoa(nonce)
+		if leadingZeroBits(sha256.Sum256([]byte(challenge.Challenge+":"+candidate))) < challenge.Difficulty {
+			if err := server.validateProofOfWork("127.0.0.1:1000", challenge.Challenge, candidate); err == nil {
+				t.Fatalf("expected an insufficient nonce to be rejected")
+			}
+			break
+		}
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	t.Parallel()
+
+	var sum [sha256.Size]byte
+	if got := leadingZeroBits(sum); got != 256 {
+		t.Fatalf("all-zero sum: got %d", got)
+	}
+	sum[2] = 0x10
+	if got := leadingZeroBits(sum); got != 19 {
+		t.Fatalf("expected 19 leading zero bits, got %d", got)
+	}
+}
+
+func TestCreateJobWithProofOfWork(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:     filepath.Join(workDir, "jobs"),
+		BuildLogsPath:    filepath.Join(workDir, "build-logs"),
+		MaxLogLines:      200,
+		CleanupInterval:  time.Hour,
+		BuildRateLimit:   5,
+		RequireCaptcha:   true,
+		PoWEnabled:       true,
+		PoWDifficulty:    6,
+		PoWMaxDifficulty: 10,
+		// Two failed builds of a client flag it.
+		ConcurrentBuilds:  1,
+		BuildTimeout:      time.Minute,
+		AbuseMinFailures:  2,
+		AbuseFailureRatio: 1,
+		AbuseWindow:       time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/pow", nil))
+	var envelope struct {
+		Data powResponse `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil || !envelope.Data.Required {
+		t.Fatalf("unexpected challenge response %d: %s", recorder.Code, recorder.Body.String())
+	}
+	challenge := envelope.Data
+
+	create := func(nonce string) *httptest.ResponseRecorder {
+		body := `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"tbeam",` +
+			`"powChallenge":"` + challenge.Challenge + `","powNonce":"` + nonce + `"}`
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body)))
+		return recorder
+	}
+	nonce := solveProofOfWork(t, challenge.Challenge, challenge.Difficulty)
+	if created := create(nonce); created.Code != http.StatusCreated || strings.Contains(created.Body.String(), "captchaSessionToken") {
+		t.Fatalf("expected the build to be queued without a captcha session: %d %s", created.Code, created.Body.String())
+	}
+	if replayed := create(nonce); replayed.Code != http.StatusBadRequest || !strings.Contains(replayed.Body.String(), "INVALID_PROOF_OF_WORK") {
+		t.Fatalf("expected a reused solution to be rejected: %d %s", replayed.Code, replayed.Body.String())
+	}
+
+	// Flagged clients must solve the captcha even with a valid solution.
+	for index := range 2 {
+		if _, err := manager.CreateJob("https://127.0.0.1:1/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "192.0.2.1"); err != nil {
+			t.Fatalf("create failing job %d: %v", index, err)
+		}
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for !manager.AbuseFlagged("192.0.2.1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the client to be flagged, report: %+v", manager.AbuseReport())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/pow", nil))
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode challenge: %v", err)
+	}
+	challenge = envelope.Data
+	if flagged := create(solveProofOfWork(t, challenge.Challenge, challenge.Difficulty)); flagged.Code != http.StatusBadRequest || !strings.Contains(flagged.Body.String(), "CAPTCHA") {
+		t.Fatalf("expected a flagged client to need the captcha: %d %s", flagged.Code, flagged.Body.String())
+	}
+
+	disabled := NewServer(config.Config{RequireCaptcha: true}, nil, slog.New(slog.DiscardHandler))
+	recorder = httptest.NewRecorder()
+	disabled.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/pow", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when proof of work is disabled, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	quotaDay  = 24 * time.Hour
+	quotaWeek = 7 * quotaDay
+)
+
+// quotaPeriod is the usage of one quota window of a client.
+type quotaPeriod struct {
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resetsAt"`
+}
+
+// quotaStatus reports the daily and weekly build quotas of a client; a
+// period is omitted when its quota is disabled.
+type quotaStatus struct {
+	Daily  *quotaPeriod `json:"daily,omitempty"`
+	Weekly *quotaPeriod `json:"weekly,omitempty"`
+}
+
+type quotaUsage struct {
+	dayStart  time.Time
+	dayUsed   int
+	weekStart time.Time
+	weekUsed  int
+}
+
+// quotaWindows returns the start of the current UTC day and week. The zero
+// time is midnight UTC on a Monday, so truncation aligns with both.
+func quotaWindows(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	return now.Truncate(quotaDay), now.Truncate(quotaWeek)
+}
+
+func (s *Server) quotasEnabled() bool {
+	return s.cfg.BuildQuotaDaily > 0 || s.cfg.BuildQuotaWeekly > 0
+}
+
+func (s *Server) presentQuota(usage quotaUsage) *quotaStatus {
+	status := &quotaStatus{}
+	if s.cfg.BuildQuotaDaily > 0 {
+		status.Daily = &quotaPeriod{
+			Limit:     s.cfg.BuildQuotaDaily,
+			Used:      usage.dayUsed,
+			Remaining: max(s.cfg.BuildQuotaDaily-usage.dayUsed, 0),
+			ResetsAt:  usage.dayStart.Add(quotaDay),
+		}
+	}
+	if s.cfg.BuildQuotaWeekly > 0 {
+		status.Weekly = &quotaPeriod{
+			Limit:     s.cfg.BuildQuotaWeekly,
+			Used:      usage.weekUsed,
+			Remaining: max(s.cfg.BuildQuotaWeekly-usage.weekUsed, 0),
+			ResetsAt:  usage.weekStart.Add(quotaWeek),
+		}
+	}
+	return status
+}
+
+// clientQuota returns the quota of key without using it, or nil when no
+// quota is configured or the store is unreachable.
+func (s *Server) clientQuota(ctx context.Context, key string) *quotaStatus {
+	if !s.quotasEnabled() {
+		return nil
+	}
+	dayStart, weekStart := quotaWindows(time.Now())
+	usage, err := s.store.getQuota(ctx, key, dayStart, weekStart)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "quota", "error", err)
+		return nil
+	}
+	return s.presentQuota(usage)
+}
+
+// reserveQuota counts one build against key. When a quota is exhausted
+// nothing is counted and ok is false. Like the rate limit, builds are let
+// through uncounted while the store is unreachable.
+func (s *Server) reserveQuota(ctx context.Context, key string) (status *quotaStatus, ok bool) {
+	if !s.quotasEnabled() {
+		return nil, true
+	}
+	dayStart, weekStart := quotaWindows(time.Now())
+	usage, ok, err := s.store.reserveQuota(ctx, key, s.cfg.BuildQuotaDaily, s.cfg.BuildQuotaWeekly, dayStart, weekStart)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "quota", "error", err)
+		return nil, true
+	}
+	return s.presentQuota(usage), ok
+}
+
+// releaseQuota returns a reserved build whose job was not created.
+func (s *Server) releaseQuota(ctx context.Context, key string) {
+	if !s.quotasEnabled() {
+		return
+	}
+	dayStart, weekStart := quotaWindows(time.Now())
+	if err := s.store.releaseQuota(ctx, key, dayStart, weekStart); err != nil {
+		s.logger.ErrorContext(ctx, "quota", "error", err)
+	}
+}
+
+// setQuotaHeaders reports the tighter of the two quotas, and on a refusal
+// Retry-After until the exhausted quota resets.
+func setQuotaHeaders(w http.ResponseWriter, status *quotaStatus, allowed bool, now time.Time) {
+	var tightest *quotaPeriod
+	for _, period := range []*quotaPeriod{status.Daily, status.Weekly} {
+		if period == nil {
+			continue
+		}
+		exhausted := period.Remaining == 0
+		switch {
+		case tightest == nil:
+			tightest = period
+		case !allowed && exhausted && (tightest.Remaining > 0 || period.ResetsAt.After(tightest.ResetsAt)):
+			// The build only becomes possible once every exhausted quota resets.
+			tightest = period
+		case allowed && period.Remaining < tightest.Remaining:
+			tightest = period
+		}
+	}
+	if tightest == nil {
+		return
+	}
+	resetSeconds := max(int(math.Ceil(tightest.ResetsAt.Sub(now).Seconds())), 0)
+	w.Header().Set("X-Quota-Limit", strconv.Itoa(tightest.Limit))
+	w.Header().Set("X-Quota-Remaining", strconv.Itoa(tightest.Remaining))
+	w.Header().Set("X-Quota-Reset", strconv.Itoa(resetSeconds))
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(max(resetSeconds, 1)))
+	}
+}
@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestQuotaWindows(t *testing.T) {
+	t.Parallel()
+
+	day, week := quotaWindows(time.Date(2026, 3, 5, 17, 30, 0, 0, time.FixedZone("UTC+3", 3*3600)))
+	if !day.Equal(time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected day start %v", day)
+	}
+	if !week.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) || week.Weekday() != time.Monday {
+		t.Fatalf("expected the week to start on Monday 2026-03-02, got %v", week)
+	}
+}
+
+func TestReserveQuota(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{BuildQuotaDaily: 2, BuildQuotaWeekly: 3}, nil, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+	for want := 1; want >= 0; want-- {
+		status, ok := server.reserveQuota(ctx, "203.0.113.7")
+		if !ok || status.Daily.Remaining != want || status.Weekly.Remaining != want+1 {
+			t.Fatalf("unexpected quota: ok=%v daily=%+v weekly=%+v", ok, status.Daily, status.Weekly)
+		}
+	}
+	if _, ok := server.reserveQuota(ctx, "203.0.113.7"); ok {
+		t.Fatalf("expected the daily quota to be exhausted")
+	}
+	if _, ok := server.reserveQuota(ctx, "user:alice"); !ok {
+		t.Fatalf("expected other clients to keep their quota")
+	}
+
+	server.releaseQuota(ctx, "203.0.113.7")
+	if status := server.clientQuota(ctx, "203.0.113.7"); status.Daily.Used != 1 || status.Weekly.Used != 1 {
+		t.Fatalf("expected the released build to be returned: %+v %+v", status.Daily, status.Weekly)
+	}
+
+	if disabled := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler)); disabled.clientQuota(ctx, "203.0.113.7") != nil {
+		t.Fatalf("expected no quota status without a configured quota")
+	}
+}
+
+func TestCreateJobQuota(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		BuildRateLimit:  10,
+		BuildQuotaDaily: 1,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	create := func(device string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		body := `{"repoUrl":"https://github.com/example/repo.git","ref":"main","device":"` + device + `"}`
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body)))
+		return recorder
+	}
+
+	if rejected := create("../escape"); rejected.Code != http.StatusBadRequest {
+		t.Fatalf("expected an invalid job, got %d: %s", rejected.Code, rejected.Body.String())
+	}
+	created := create("tbeam")
+	if created.Code != http.StatusCreated || created.Header().Get("X-Quota-Remaining") != "0" {
+		t.Fatalf("expected the failed request not to use the quota: %d %v %s", created.Code, created.Header(), created.Body.String())
+	}
+	var envelope struct {
+		Data stateResponse `json:"data"`
+	}
+	if err := json.Unmarshal(created.Body.Bytes(), &envelope); err != nil || envelope.Data.Quota == nil || envelope.Data.Quota.Daily.Used != 1 {
+		t.Fatalf("expected the quota in the job response: %s", created.Body.String())
+	}
+
+	exceeded := create("tbeam")
+	if exceeded.Code != http.StatusTooManyRequests || !strings.Contains(exceeded.Body.String(), "QUOTA_EXCEEDED") || exceeded.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 QUOTA_EXCEEDED with Retry-After, got %d %v: %s", exceeded.Code, exceeded.Header(), exceeded.Body.String())
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/healthz", nil))
+	var health struct {
+		Data healthResponse `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &health); err != nil || health.Data.Quota == nil || health.Data.Quota.Daily.Remaining != 0 {
+		t.Fatalf("expected the client's quota in healthz: %s", recorder.Body.String())
+	}
+	if !health.Data.Quota.Daily.ResetsAt.After(time.Now()) {
+		t.Fatalf("expected a future reset, got %v", health.Data.Quota.Daily.ResetsAt)
+	}
+}
@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
+)
+
+type secretsResponse struct {
+	Secrets []secrets.Entry `json:"secrets"`
+}
+
+// putSecretRequest creates or rotates a stored secret. Scope and username
+// only apply to git credentials.
+type putSecretRequest struct {
+	Kind     secrets.Kind `json:"kind"`
+	Scope    string       `json:"scope,omitempty"`
+	Username string       `json:"username,omitempty"`
+	Value    string       `json:"value"`
+}
+
+// handleAdminSecrets lists the stored secrets without their values. The
+// routes answer 404 when APP_SECRETS_KEY is not set.
+func (s *Server) handleAdminSecrets(w http.ResponseWriter, requestID string) {
+	store := s.manager.Secrets()
+	if store == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, secretsResponse{Secrets: store.List()})
+}
+
+func (s *Server) handleAdminPutSecret(w http.ResponseWriter, r *http.Request, requestID string, name string) {
+	store := s.manager.Secrets()
+	if store == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	var req putSecretRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+
+	entry, created, err := store.Put(name, req.Kind, req.Scope, req.Username, req.Value)
+	if err != nil {
+		var validation *secrets.ValidationError
+		if errors.As(err, &validation) {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+			return
+		}
+		s.logger.ErrorContext(r.Context(), "secrets: store", "name", name, "error", err)
+		s.writeError(w, http.StatusInternalServerError, requestID, "SECRET_STORE_FAILED", "failed to write the credential store", nil)
+		return
+	}
+
+	s.recordAudit(r, requestID, audit.Event{
+		Action:  audit.ActionSecretStored,
+		Actor:   "admin",
+		Details: map[string]string{"name": entry.Name, "kind": string(entry.Kind), "version": strconv.Itoa(entry.Version)},
+	})
+	s.logger.InfoContext(r.Context(), "admin: stored secret", "name", entry.Name, "kind", entry.Kind, "version", entry.Version)
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	s.writeSuccess(w, status, requestID, entry)
+}
+
+func (s *Server) handleAdminDeleteSecret(w http.ResponseWriter, r *http.Request, requestID string, name string) {
+	store := s.manager.Secrets()
+	if store == nil {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+	entry, err := store.Delete(name)
+	if errors.Is(err, secrets.ErrNotFound) {
+		s.writeError(w, http.StatusNotFound, requestID, "SECRET_NOT_FOUND", "secret not found", nil)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "secrets: delete", "name", name, "error", err)
+		s.writeError(w, http.StatusInternalServerError, requestID, "SECRET_STORE_FAILED", "failed to write the credential store", nil)
+		return
+	}
+
+	s.recordAudit(r, requestID, audit.Event{
+		Action:  audit.ActionSecretDeleted,
+		Actor:   "admin",
+		Details: map[string]string{"name": entry.Name, "kind": string(entry.Kind)},
+	})
+	s.logger.InfoContext(r.Context(), "admin: deleted secret", "name", entry.Name)
+	s.writeSuccess(w, http.StatusOK, requestID, entry)
+}
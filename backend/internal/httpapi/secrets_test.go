@@ -0,0 +1,121 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
+)
+
+func TestAdminSecrets(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		AdminToken:      "admin-secret",
+		SecretsKey:      base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		SecretsPath:     filepath.Join(workDir, "secrets.json"),
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(method string, target string, body string, header http.Header) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+	admin := http.Header{"Authorization": {"Bearer admin-secret"}}
+
+	if unauthorized := serve(http.MethodGet, "/api/admin/secrets", "", nil); unauthorized.Code != http.StatusUnauthorized {
+		t.Fatalf("expected admin auth, got %d", unauthorized.Code)
+	}
+	if webhook := serve(http.MethodPost, "/api/webhooks/git", "{}", http.Header{"X-Github-Event": {"ping"}}); webhook.Code != http.StatusNotFound {
+		t.Fatalf("expected webhooks to stay disabled without secrets, got %d", webhook.Code)
+	}
+
+	created := serve(http.MethodPut, "/api/admin/secrets/hook", `{"kind":"webhook-secret","value":"whsec_1"}`, admin)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create secret: %d %s", created.Code, created.Body.String())
+	}
+	rotated := serve(http.MethodPut, "/api/admin/secrets/hook", `{"kind":"webhook-secret","value":"whsec_2"}`, admin)
+	var entry struct {
+		Data secrets.Entry `json:"data"`
+	}
+	if rotated.Code != http.StatusOK || json.Unmarshal(rotated.Body.Bytes(), &entry) != nil || entry.Data.Version != 2 {
+		t.Fatalf("rotate secret: %d %s", rotated.Code, rotated.Body.String())
+	}
+	if invalid := serve(http.MethodPut, "/api/admin/secrets/github", `{"kind":"git-credential","value":"token"}`, admin); invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected a git credential without scope to be rejected, got %d", invalid.Code)
+	}
+
+	listed := serve(http.MethodGet, "/api/admin/secrets", "", admin)
+	if listed.Code != http.StatusOK || !strings.Contains(listed.Body.String(), `"name":"hook"`) || strings.Contains(listed.Body.String(), "whsec_") {
+		t.Fatalf("unexpected list: %d %s", listed.Code, listed.Body.String())
+	}
+
+	// The rotated secret authenticates deliveries without a restart.
+	body := `{"zen":"ok"}`
+	sign := func(secret string) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return http.Header{"X-Github-Event": {"ping"}, "X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))}}
+	}
+	if ping := serve(http.MethodPost, "/api/webhooks/git", body, sign("whsec_2")); ping.Code != http.StatusOK {
+		t.Fatalf("expected the stored secret to authenticate, got %d %s", ping.Code, ping.Body.String())
+	}
+	if stale := serve(http.MethodPost, "/api/webhooks/git", body, sign("whsec_1")); stale.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the rotated-out secret to fail, got %d", stale.Code)
+	}
+
+	if deleted := serve(http.MethodDelete, "/api/admin/secrets/hook", "", admin); deleted.Code != http.StatusOK {
+		t.Fatalf("delete secret: %d %s", deleted.Code, deleted.Body.String())
+	}
+	if missing := serve(http.MethodDelete, "/api/admin/secrets/hook", "", admin); missing.Code != http.StatusNotFound || !strings.Contains(missing.Body.String(), "SECRET_NOT_FOUND") {
+		t.Fatalf("expected SECRET_NOT_FOUND, got %d %s", missing.Code, missing.Body.String())
+	}
+}
+
+func TestAdminSecretsDisabledWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		AdminToken:      "admin-secret",
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/secrets", nil)
+	request.Header.Set("Authorization", "Bearer admin-secret")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without APP_SECRETS_KEY, got %d", recorder.Code)
+	}
+}
@@ -1,66 +1,114 @@
 package httpapi
 
 import (
+	"bufio"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"net/netip"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildinfo"
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/logging"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/notify"
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/stats"
 )
 
 type Server struct {
-	cfg             config.Config
-	manager         *jobs.Manager
-	logger          *log.Logger
-	allowedOrigins  map[string]struct{}
-	rateMu          sync.Mutex
-	buildRequests   map[string][]time.Time
-	captchaMu       sync.Mutex
-	captchas        map[string]captchaChallenge
-	captchaSessions map[string]captchaSession
-	stats           *stats.Collector
-}
-
-func NewServer(cfg config.Config, manager *jobs.Manager, logger *log.Logger) *Server {
+	cfg            config.Config
+	manager        *jobs.Manager
+	logger         *slog.Logger
+	allowedOrigins map[string]struct{}
+	store          clientStore
+	ipRules        *ipRules
+	captchaMu      sync.Mutex
+	captchas       map[string]captchaChallenge
+	powChallenges  map[string]powChallenge
+	captcha        captchaProvider
+	idempotency    *idempotencyStore
+	stats          *stats.Collector
+	audit          *audit.Log
+	auth           *oidcAuth
+}
+
+func NewServer(cfg config.Config, manager *jobs.Manager, logger *slog.Logger) *Server {
 	allowed := make(map[string]struct{}, len(cfg.AllowedOrigins))
 	for _, origin := range cfg.AllowedOrigins {
 		allowed[origin] = struct{}{}
 	}
 
-	return &Server{
-		cfg:             cfg,
-		manager:         manager,
-		logger:          logger,
-		allowedOrigins:  allowed,
-		buildRequests:   make(map[string][]time.Time),
-		captchas:        make(map[string]captchaChallenge),
-		captchaSessions: make(map[string]captchaSession),
-		stats:           stats.NewCollector(cfg.StatsFilePath, logger),
+	s := &Server{
+		cfg:            cfg,
+		manager:        manager,
+		logger:         logger,
+		allowedOrigins: allowed,
+		captchas:       make(map[string]captchaChallenge),
+		powChallenges:  make(map[string]powChallenge),
+		ipRules:        newIPRules(cfg),
+		idempotency:    newIdempotencyStore(cfg.IdempotencyWindow),
+		stats:          stats.NewCollector(cfg.StatsFilePath, logger),
+		audit: audit.New(audit.Options{
+			Sinks:         cfg.AuditSinks,
+			FilePath:      cfg.AuditLogPath,
+			SyslogAddress: cfg.AuditSyslogAddress,
+		}, logger),
+		auth: newOIDCAuth(cfg),
+	}
+	s.captcha = newCaptchaProvider(cfg, s)
+
+	store, err := newClientStore(cfg)
+	if err != nil {
+		// Config.Load validated the URL; only a hand-built config gets here.
+		logger.Error("client store unavailable; keeping rate limits, quotas and captcha sessions in memory", "error", err)
+		store = newMemoryStore()
 	}
+	s.store = store
+	return s
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	requestID := generateRequestID()
 	w.Header().Set("X-Request-ID", requestID)
+	// Records logged with the request context carry its ID and client.
+	r = r.WithContext(logging.With(r.Context(), slog.String("requestId", requestID), slog.String("client", s.clientIP(r))))
+	if s.cfg.AccessLog {
+		recorder := &accessLogWriter{ResponseWriter: w}
+		w = recorder
+		defer s.logAccess(r, r.Method, r.URL.Path, recorder, time.Now())
+	}
 
 	if !s.handleCORS(w, r, requestID) {
 		return
 	}
 
+	if !s.enforceIPRules(w, r, requestID) {
+		return
+	}
+
+	if !s.resolveAPIVersion(w, r, requestID) {
+		return
+	}
+
+	w, r, cancel := s.applyRouteTimeouts(w, r)
+	defer cancel()
+
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -71,16 +119,56 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Method == http.MethodGet && r.URL.Path == "/api/cluster/healthz" {
+		s.handleClusterHealthz(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/livez" {
+		s.writeSuccess(w, http.StatusOK, requestID, livenessResponse{Status: "ok"})
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/readyz" {
+		s.handleReadyz(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/version" {
+		s.handleVersion(w, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/openapi.json" {
+		s.handleOpenAPI(w, r, requestID)
+		return
+	}
+
 	if r.Method == http.MethodGet && r.URL.Path == "/api/stats" {
 		s.handleStats(w, r, requestID)
 		return
 	}
 
+	if r.Method == http.MethodGet && r.URL.Path == "/api/metrics" {
+		s.handleMetrics(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/stats/service" {
+		s.handleServiceStats(w, r, requestID)
+		return
+	}
+
 	if r.Method == http.MethodGet && r.URL.Path == "/api/stats/build-logs" {
 		s.handleBuildLogsList(w, r, requestID)
 		return
 	}
 
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/stats/build-logs/") && strings.HasSuffix(r.URL.Path, "/logs.txt") {
+		s.handleStoredLogDownload(w, r, requestID, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/stats/build-logs/"), "/logs.txt"))
+		return
+	}
+
 	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/stats/build-logs/") {
 		logID := strings.TrimPrefix(r.URL.Path, "/api/stats/build-logs/")
 		logID = strings.Trim(logID, "/")
@@ -90,11 +178,47 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if strings.HasPrefix(r.URL.Path, "/api/admin/") {
+		s.handleAdminRoutes(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/devices" {
+		s.handleDeviceCatalog(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/devices/search" {
+		s.handleDeviceSearch(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/devices/changes" {
+		s.handleDeviceChanges(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/devices/") && strings.HasSuffix(r.URL.Path, "/availability") {
+		device := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/devices/"), "/availability")
+		s.handleDeviceAvailability(w, r, requestID, device)
+		return
+	}
+
 	if r.Method == http.MethodPost && r.URL.Path == "/api/repos/discover" {
 		s.handleDiscover(w, r, requestID)
 		return
 	}
 
+	if r.Method == http.MethodPost && r.URL.Path == "/api/webhooks/git" {
+		s.handleGitWebhook(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodPost && r.URL.Path == "/api/repos/compare-devices" {
+		s.handleCompareDevices(w, r, requestID)
+		return
+	}
+
 	if r.Method == http.MethodPost && r.URL.Path == "/api/repos/refs" {
 		s.handleRepoRefs(w, r, requestID)
 		return
@@ -105,11 +229,41 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Method == http.MethodGet && r.URL.Path == "/api/pow" {
+		s.handleNewProofOfWork(w, r, requestID)
+		return
+	}
+
 	if r.Method == http.MethodPost && r.URL.Path == "/api/jobs" {
 		s.handleCreateJob(w, r, requestID)
 		return
 	}
 
+	if r.Method == http.MethodGet && r.URL.Path == "/api/auth/login" {
+		s.handleAuthLogin(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/auth/callback" {
+		s.handleAuthCallback(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/auth/me" {
+		s.handleAuthMe(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodPost && r.URL.Path == "/api/auth/logout" {
+		s.handleAuthLogout(w, r, requestID)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/me/jobs" {
+		s.handleUserJobs(w, r, requestID)
+		return
+	}
+
 	if r.Method == http.MethodGet && r.URL.Path == "/api/launcherhub/firmwares" {
 		s.handleLauncherHubFirmwares(w, r, requestID)
 		return
@@ -132,17 +286,60 @@ func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request, requestID
 	if s.cfg.StatsPassword != "" && s.stats != nil {
 		s.stats.Record(stats.Event{
 			Type:      stats.EventVisit,
-			IP:        clientIP(r, s.cfg.TrustProxyHeaders),
+			IP:        s.clientIP(r),
 			UserAgent: r.UserAgent(),
 		})
 	}
-	s.writeSuccess(w, http.StatusOK, requestID, healthResponse{
+	response := healthResponse{
 		Status:          "ok",
 		CaptchaRequired: s.cfg.RequireCaptcha,
 		StatsEnabled:    s.cfg.StatsPassword != "",
+		BuildRateLimit:  s.cfg.BuildRateLimit,
+		ProofOfWork:     s.cfg.RequireCaptcha && s.cfg.PoWEnabled,
 		Version:         strings.TrimSpace(buildinfo.Version),
 		Commit:          strings.TrimSpace(buildinfo.Commit),
-	})
+	}
+	if s.quotasEnabled() {
+		key, _ := s.buildClientKey(r, s.clientIP(r))
+		response.Quota = s.clientQuota(r.Context(), key)
+	}
+	if s.manager != nil {
+		load := s.manager.DiscoveryLoad()
+		response.Discovery = &load
+		response.Draining = s.manager.Draining()
+		response.Disk = s.manager.DiskUsage()
+		if health, ok := s.manager.DockerHealth(); ok {
+			response.Docker = &health
+		}
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+// handleReadyz answers 503 NOT_READY, with the failed checks in details,
+// when this node cannot build, so orchestrators stop routing traffic to it.
+// Unlike healthz it records no visit.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request, requestID string) {
+	w.Header().Set("Cache-Control", "no-store")
+	if s.manager == nil {
+		s.writeError(w, http.StatusServiceUnavailable, requestID, "NOT_READY", "build manager is not running", nil)
+		return
+	}
+	readiness := s.manager.Readiness(r.Context())
+	if s.cfg.RedisURL != "" {
+		// Without Redis captcha sessions fail and the build rate limit is
+		// not enforced, so the node should not take traffic.
+		check := jobs.ReadinessCheck{Name: "redis", OK: true}
+		if err := s.store.ping(r.Context()); err != nil {
+			check.OK, check.Error = false, err.Error()
+			readiness.Ready = false
+		}
+		readiness.Checks = append(readiness.Checks, check)
+	}
+	if !readiness.Ready {
+		s.writeError(w, http.StatusServiceUnavailable, requestID, "NOT_READY", "service is not ready to build", readiness)
+		return
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, readiness)
 }
 
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request, requestID string) {
@@ -176,7 +373,7 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request, requestID s
 
 	summary, err := s.stats.Summarize(opts)
 	if err != nil {
-		s.logger.Printf("stats: summarize: %v", err)
+		s.logger.ErrorContext(r.Context(), "stats: summarize", "error", err)
 		s.writeError(w, http.StatusInternalServerError, requestID, "STATS_ERROR", "internal error", nil)
 		return
 	}
@@ -188,6 +385,32 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request, requestID s
 	})
 }
 
+// handleServiceStats serves aggregate build statistics for public status
+// dashboards. Unlike /api/stats it needs no password, so it only exposes
+// totals derived from the build history, never client IPs or repositories.
+func (s *Server) handleServiceStats(w http.ResponseWriter, r *http.Request, requestID string) {
+	const maxDays = 365
+
+	days := 30
+	if v, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && v > 0 {
+		if v > maxDays {
+			days = maxDays
+		} else {
+			days = v
+		}
+	}
+
+	result, err := s.manager.ServiceStats(days)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "stats: service", "error", err)
+		s.writeError(w, http.StatusInternalServerError, requestID, "STATS_ERROR", "internal error", nil)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=30")
+	s.writeSuccess(w, http.StatusOK, requestID, result)
+}
+
 func (s *Server) requireStatsAuth(w http.ResponseWriter, r *http.Request, requestID string) bool {
 	if s.cfg.StatsPassword == "" {
 		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
@@ -203,6 +426,7 @@ func (s *Server) requireStatsAuth(w http.ResponseWriter, r *http.Request, reques
 	}
 
 	if password != s.cfg.StatsPassword {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionAuthFailed, Details: map[string]string{"realm": "stats"}})
 		w.Header().Set("WWW-Authenticate", `Bearer realm="stats"`)
 		s.writeError(w, http.StatusUnauthorized, requestID, "UNAUTHORIZED", "invalid password", nil)
 		return false
@@ -226,7 +450,7 @@ func (s *Server) handleBuildLogsList(w http.ResponseWriter, r *http.Request, req
 
 	entries, err := s.manager.BuildLogs().List(limit)
 	if err != nil {
-		s.logger.Printf("build-logs: list: %v", err)
+		s.logger.ErrorContext(r.Context(), "build-logs: list", "error", err)
 		s.writeError(w, http.StatusInternalServerError, requestID, "BUILD_LOGS_ERROR", "internal error", nil)
 		return
 	}
@@ -242,7 +466,7 @@ func (s *Server) handleBuildLogGet(w http.ResponseWriter, r *http.Request, reque
 
 	bl, err := s.manager.BuildLogs().Get(logID)
 	if err != nil {
-		s.logger.Printf("build-logs: get %s: %v", logID, err)
+		s.logger.ErrorContext(r.Context(), "build-logs: get", "jobId", logID, "error", err)
 		s.writeError(w, http.StatusInternalServerError, requestID, "BUILD_LOGS_ERROR", "internal error", nil)
 		return
 	}
@@ -261,45 +485,55 @@ func (s *Server) handleDiscover(w http.ResponseWriter, r *http.Request, requestI
 		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
 		return
 	}
+	req.RepoURL = jobs.NormalizeRepoURL(req.RepoURL)
 
-	remoteIP := clientIP(r, s.cfg.TrustProxyHeaders)
+	remoteIP := s.clientIP(r)
 
-	captchaSessionToken := ""
-	if s.cfg.RequireCaptcha {
-		captchaSessionToken = strings.TrimSpace(req.CaptchaSessionToken)
-		if captchaSessionToken != "" {
-			if err := s.validateCaptchaSession(remoteIP, captchaSessionToken); err != nil {
-				captchaSessionToken = ""
-			}
-		}
-
-		if captchaSessionToken == "" {
-			if err := s.validateCaptcha(remoteIP, req.CaptchaID, req.CaptchaAnswer); err != nil {
-				s.writeError(w, http.StatusBadRequest, requestID, "INVALID_CAPTCHA", err.Error(), nil)
-				return
-			}
+	sessionToken, ok := s.requestCaptchaSession(w, r, requestID, req.CaptchaSessionToken)
+	if !ok {
+		return
+	}
+	captchaSessionToken, ok := s.checkCaptcha(w, r, requestID, remoteIP, sessionToken, req.CaptchaID, req.CaptchaAnswer)
+	if !ok {
+		return
+	}
 
-			issuedSessionToken, err := s.createCaptchaSession(remoteIP)
-			if err != nil {
-				s.writeError(w, http.StatusInternalServerError, requestID, "CAPTCHA_SESSION_FAILED", err.Error(), nil)
-				return
-			}
-			captchaSessionToken = issuedSessionToken
-		}
+	filter := jobs.DeviceFilter{
+		Platform: strings.ToLower(strings.TrimSpace(req.Platform)),
+		Tags:     jobs.ParseDeviceTags(req.Tags...),
+	}
+	if err := jobs.ValidateDeviceFilter(filter); err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_FILTER", err.Error(), nil)
+		return
 	}
 
-	discoveredDevices, err := s.manager.Discover(r.Context(), req.RepoURL, req.Ref)
+	result, err := s.manager.Discover(r.Context(), req.RepoURL, req.Ref)
+	if errors.Is(err, jobs.ErrRepoNotAllowed) {
+		s.writeRepoNotAllowed(w, r, requestID, req.RepoURL, err)
+		return
+	}
+	if errors.Is(err, jobs.ErrDiscoveryBusy) {
+		s.writeDiscoveryBusy(w, requestID, err)
+		return
+	}
+	var noDevices *jobs.NoDevicesError
+	if errors.As(err, &noDevices) {
+		s.writeError(w, http.StatusUnprocessableEntity, requestID, "DISCOVERY_FAILED", err.Error(), map[string]any{"warnings": noDevices.Warnings})
+		return
+	}
 	if err != nil {
 		s.writeError(w, http.StatusUnprocessableEntity, requestID, "DISCOVERY_FAILED", err.Error(), nil)
 		return
 	}
+	platforms := countDevicePlatforms(result.Devices)
+	discoveredDevices := jobs.FilterDevices(result.Devices, filter)
 
 	if s.stats != nil {
 		s.stats.Record(stats.Event{
 			Type:      stats.EventDiscover,
 			IP:        remoteIP,
 			UserAgent: r.UserAgent(),
-			RepoURL:   req.RepoURL,
+			RepoURL:   jobs.RedactSecrets(req.RepoURL),
 			Ref:       req.Ref,
 		})
 	}
@@ -307,8 +541,12 @@ func (s *Server) handleDiscover(w http.ResponseWriter, r *http.Request, requestI
 	data := discoverResponse{
 		RepoURL:             req.RepoURL,
 		Ref:                 req.Ref,
+		Commit:              result.Commit,
 		Devices:             discoveredDeviceNames(discoveredDevices),
+		DeviceInfo:          s.withLastBuilds(toDiscoveredDeviceViews(discoveredDevices), discoveredDevices, req.RepoURL, result.Commit),
+		Platforms:           platforms,
 		DeviceOptions:       discoveredDeviceOptions(discoveredDevices),
+		Warnings:            result.Warnings,
 		CaptchaSessionToken: captchaSessionToken,
 	}
 	s.writeSuccess(w, http.StatusOK, requestID, data)
@@ -320,8 +558,17 @@ func (s *Server) handleRepoRefs(w http.ResponseWriter, r *http.Request, requestI
 		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
 		return
 	}
+	req.RepoURL = jobs.NormalizeRepoURL(req.RepoURL)
 
 	refs, err := s.manager.DiscoverRefs(r.Context(), req.RepoURL)
+	if errors.Is(err, jobs.ErrRepoNotAllowed) {
+		s.writeRepoNotAllowed(w, r, requestID, req.RepoURL, err)
+		return
+	}
+	if errors.Is(err, jobs.ErrDiscoveryBusy) {
+		s.writeDiscoveryBusy(w, requestID, err)
+		return
+	}
 	if err != nil {
 		s.writeError(w, http.StatusUnprocessableEntity, requestID, "REFS_DISCOVERY_FAILED", err.Error(), nil)
 		return
@@ -336,76 +583,227 @@ func (s *Server) handleRepoRefs(w http.ResponseWriter, r *http.Request, requestI
 	s.writeSuccess(w, http.StatusOK, requestID, data)
 }
 
+// discoveryBusyRetrySeconds is the Retry-After hint for DISCOVERY_BUSY.
+const discoveryBusyRetrySeconds = 10
+
+// writeDiscoveryBusy answers 503 with the current discovery load, so
+// clients can show how many clones are running and queued.
+func (s *Server) writeDiscoveryBusy(w http.ResponseWriter, requestID string, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(discoveryBusyRetrySeconds))
+	s.writeError(w, http.StatusServiceUnavailable, requestID, "DISCOVERY_BUSY", err.Error(), s.manager.DiscoveryLoad())
+}
+
+// writeRepoNotAllowed answers 403 for a repository outside
+// APP_ALLOWED_REPO_HOSTS and records the rejection in the audit log.
+func (s *Server) writeRepoNotAllowed(w http.ResponseWriter, r *http.Request, requestID string, repoURL string, err error) {
+	s.recordAudit(r, requestID, audit.Event{Action: audit.ActionRepoRejected, Details: map[string]string{"repo": jobs.RedactSecrets(repoURL)}})
+	s.writeError(w, http.StatusForbidden, requestID, "REPO_NOT_ALLOWED", err.Error(), nil)
+}
+
+// checkCaptcha enforces the captcha when it is required: a valid session
+// token is reused, otherwise the answer is checked and a new session token
+// issued. It returns the session token to hand back to the client ("" when
+// captcha is disabled), or writes the error response and returns false.
+// The token is also set as the captcha session cookie when enabled.
+func (s *Server) checkCaptcha(w http.ResponseWriter, r *http.Request, requestID string, ip string, sessionToken string, captchaID string, captchaAnswer string) (string, bool) {
+	if !s.cfg.RequireCaptcha {
+		return "", true
+	}
+	ctx := r.Context()
+
+	sessionToken = strings.TrimSpace(sessionToken)
+	if sessionToken != "" {
+		if err := s.validateCaptchaSession(ctx, ip, sessionToken); err == nil {
+			s.setCaptchaSessionCookie(w, r, sessionToken)
+			return sessionToken, true
+		}
+	}
+
+	if err := s.captcha.verify(ctx, ip, captchaID, captchaAnswer); err != nil {
+		if errors.Is(err, errCaptchaUnavailable) {
+			s.logger.ErrorContext(r.Context(), "captcha provider", "provider", s.captcha.name(), "error", err)
+			s.writeError(w, http.StatusServiceUnavailable, requestID, "CAPTCHA_UNAVAILABLE", "captcha verification is unavailable, try again later", nil)
+			return "", false
+		}
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_CAPTCHA", err.Error(), nil)
+		return "", false
+	}
+
+	issuedSessionToken, err := s.createCaptchaSession(ctx, ip)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, requestID, "CAPTCHA_SESSION_FAILED", err.Error(), nil)
+		return "", false
+	}
+	s.setCaptchaSessionCookie(w, r, issuedSessionToken)
+	return issuedSessionToken, true
+}
+
 func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request, requestID string) {
 	var req createJobRequest
 	if err := decodeJSON(r, &req); err != nil {
 		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
 		return
 	}
+	req.RepoURL = jobs.NormalizeRepoURL(req.RepoURL)
 
-	ip := clientIP(r, s.cfg.TrustProxyHeaders)
+	ip := s.clientIP(r)
 
-	captchaSessionToken := ""
-	if s.cfg.RequireCaptcha {
-		captchaSessionToken = strings.TrimSpace(req.CaptchaSessionToken)
-		if captchaSessionToken != "" {
-			if err := s.validateCaptchaSession(ip, captchaSessionToken); err != nil {
-				captchaSessionToken = ""
-			}
-		}
+	idempotencyKey, handled := s.claimIdempotencyKey(w, r, requestID, ip, req)
+	if handled {
+		return
+	}
+	createdJobID := ""
+	if idempotencyKey != "" {
+		defer func() { s.idempotency.finish(idempotencyKey, createdJobID) }()
+	}
 
-		if captchaSessionToken == "" {
-			if err := s.validateCaptcha(ip, req.CaptchaID, req.CaptchaAnswer); err != nil {
-				s.writeError(w, http.StatusBadRequest, requestID, "INVALID_CAPTCHA", err.Error(), nil)
-				return
-			}
+	rateKey, userID := s.buildClientKey(r, ip)
+	// Clients whose builds keep failing with the same error look like bots
+	// probing the builder: they solve a fresh captcha for every build.
+	abusive := s.manager != nil && s.manager.AbuseFlagged(rateKey)
+	sessionToken := ""
+	if !abusive {
+		var ok bool
+		if sessionToken, ok = s.requestCaptchaSession(w, r, requestID, req.CaptchaSessionToken); !ok {
+			return
+		}
+	}
 
-			issuedSessionToken, err := s.createCaptchaSession(ip)
-			if err != nil {
-				s.writeError(w, http.StatusInternalServerError, requestID, "CAPTCHA_SESSION_FAILED", err.Error(), nil)
-				return
-			}
-			captchaSessionToken = issuedSessionToken
+	// A solved proof-of-work challenge stands in for the captcha; it covers
+	// this one build and issues no captcha session. Work is cheap for a bot,
+	// so flagged clients still have to solve the captcha.
+	captchaSessionToken := ""
+	if !abusive && s.cfg.RequireCaptcha && s.cfg.PoWEnabled && req.PoWChallenge != "" {
+		if err := s.validateProofOfWork(ip, req.PoWChallenge, req.PoWNonce); err != nil {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_PROOF_OF_WORK", err.Error(), nil)
+			return
+		}
+	} else {
+		var ok bool
+		captchaSessionToken, ok = s.checkCaptcha(w, r, requestID, ip, sessionToken, req.CaptchaID, req.CaptchaAnswer)
+		if !ok {
+			return
 		}
 	}
 
-	if !s.allowBuildRequest(ip) {
+	rateLimit, limitName := s.cfg.BuildRateLimit, "rate"
+	if userID == "" && s.ipRules.isAnonymous(ip) {
+		// A Tor or VPN exit stands for many clients, each able to move on
+		// to another exit, so anonymous builds get a tighter limit.
+		rateLimit = s.cfg.AnonymousBuildRateLimit
+	}
+	if abusive {
+		rateLimit, limitName = min(rateLimit, s.cfg.AbuseBuildRateLimit), "abuse"
+	}
+	quota := s.allowBuildRequest(r.Context(), rateKey, rateLimit)
+	setRateLimitHeaders(w, quota, time.Now().UTC())
+	if !quota.Allowed {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionRateLimited, Details: map[string]string{"limit": limitName}})
 		s.writeError(w, http.StatusTooManyRequests, requestID, "RATE_LIMITED", "too many build requests from this client", nil)
 		return
 	}
 
-	state, err := s.manager.CreateJob(req.RepoURL, req.Ref, req.Device, jobs.BuildOptions{
-		BuildFlags: req.BuildFlags,
-		LibDeps:    req.LibDeps,
-	}, ip)
+	quotaState, allowed := s.reserveQuota(r.Context(), rateKey)
+	if quotaState != nil {
+		setQuotaHeaders(w, quotaState, allowed, time.Now().UTC())
+	}
+	if !allowed {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionRateLimited, Details: map[string]string{"limit": "quota"}})
+		s.writeError(w, http.StatusTooManyRequests, requestID, "QUOTA_EXCEEDED", "build quota of this client is used up", quotaState)
+		return
+	}
+	defer func() {
+		if createdJobID == "" {
+			s.releaseQuota(context.WithoutCancel(r.Context()), rateKey)
+		}
+	}()
+
+	// Jobs are private to their creator unless asked otherwise: logs and
+	// artifacts may reveal private forks.
+	owner := jobs.JobOwner{ClientIP: ip, UserID: userID, Private: !req.Public, Anonymous: userID == ""}
+	if owner.Private {
+		token, err := randomToken()
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, requestID, "INTERNAL_ERROR", "generate job access token", nil)
+			return
+		}
+		owner.AccessToken = token
+	}
+	if req.Notify != nil {
+		owner.Notify = *req.Notify
+	}
+
+	state, err := s.manager.CreateOwnedJob(req.RepoURL, req.Ref, req.Device, jobs.BuildOptions{
+		BuildFlags:       req.BuildFlags,
+		LibDeps:          req.LibDeps,
+		ArtifactPatterns: req.ArtifactPatterns,
+		Patch:            req.Patch,
+		Submodules:       req.Submodules,
+	}, owner)
+	if errors.Is(err, jobs.ErrRepoNotAllowed) {
+		s.writeRepoNotAllowed(w, r, requestID, req.RepoURL, err)
+		return
+	}
+	if errors.Is(err, jobs.ErrDeviceNotAllowed) {
+		s.writeError(w, http.StatusForbidden, requestID, "DEVICE_NOT_ALLOWED", err.Error(), nil)
+		return
+	}
+	if errors.Is(err, jobs.ErrDraining) {
+		s.writeError(w, http.StatusServiceUnavailable, requestID, "SERVICE_DRAINING", err.Error(), nil)
+		return
+	}
+	if errors.Is(err, jobs.ErrLowDiskSpace) {
+		s.logger.WarnContext(r.Context(), "jobs: build refused", "error", err)
+		s.writeError(w, http.StatusInsufficientStorage, requestID, "INSUFFICIENT_STORAGE", err.Error(), nil)
+		return
+	}
 	if err != nil {
 		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_JOB", err.Error(), nil)
 		return
 	}
+	createdJobID = state.ID
+	s.recordAudit(r, requestID, audit.Event{
+		Action:  audit.ActionJobCreated,
+		Actor:   userID,
+		JobID:   state.ID,
+		Details: map[string]string{"repo": state.RepoURL, "ref": state.Ref, "device": state.Device, "visibility": jobVisibility(state.Private)},
+	})
 
 	if s.stats != nil {
 		s.stats.Record(stats.Event{
 			Type:      stats.EventBuild,
 			IP:        ip,
 			UserAgent: r.UserAgent(),
-			RepoURL:   req.RepoURL,
+			RepoURL:   jobs.RedactSecrets(req.RepoURL),
 			Ref:       req.Ref,
 			Device:    req.Device,
 		})
 	}
 
 	response := s.presentState(state)
+	response.AccessToken = state.AccessToken
 	response.CaptchaSessionToken = captchaSessionToken
+	response.Quota = quotaState
 	s.writeSuccess(w, http.StatusCreated, requestID, response)
 }
 
+// buildClientKey identifies the client that builds are counted against.
+// Signed-in users are limited per account, so that users behind a shared
+// address do not exhaust each other's limit.
+func (s *Server) buildClientKey(r *http.Request, ip string) (key string, userID string) {
+	if user := s.sessionUser(r); user != nil {
+		return "user:" + user.ID, user.ID
+	}
+	return normalizeRemoteHost(ip), ""
+}
+
 func (s *Server) handleNewCaptcha(w http.ResponseWriter, r *http.Request, requestID string) {
 	if !s.cfg.RequireCaptcha {
 		s.writeSuccess(w, http.StatusOK, requestID, captchaResponse{CaptchaRequired: false})
 		return
 	}
 
-	challenge, err := s.newCaptcha(clientIP(r, s.cfg.TrustProxyHeaders))
+	challenge, err := s.captcha.challenge(s.clientIP(r))
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, requestID, "CAPTCHA_GENERATION_FAILED", err.Error(), nil)
 		return
@@ -426,8 +824,21 @@ func (s *Server) handleJobRoutes(w http.ResponseWriter, r *http.Request, request
 	parts := strings.Split(trimmed, "/")
 	jobID := parts[0]
 
+	// Private jobs are reported as missing rather than forbidden, so that
+	// their IDs cannot be probed.
+	if !s.jobReadable(r, jobID) {
+		s.handleJobError(w, requestID, jobs.ErrJobNotFound)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "sizediff" {
+		if against := strings.TrimSpace(r.URL.Query().Get("against")); against != "" && !s.jobReadable(r, against) {
+			s.handleJobError(w, requestID, jobs.ErrJobNotFound)
+			return
+		}
+	}
+
 	if len(parts) == 1 && r.Method == http.MethodGet {
-		s.handleGetJob(w, requestID, jobID)
+		s.handleGetJob(w, r, requestID, jobID)
 		return
 	}
 
@@ -436,16 +847,46 @@ func (s *Server) handleJobRoutes(w http.ResponseWriter, r *http.Request, request
 		return
 	}
 
+	if len(parts) == 2 && parts[1] == "logs.txt" && r.Method == http.MethodGet {
+		s.handleDownloadLogs(w, requestID, jobID)
+		return
+	}
+
+	if len(parts) == 3 && parts[1] == "logs" && parts[2] == "ndjson" && r.Method == http.MethodGet {
+		s.handleLogNDJSON(w, r, requestID, jobID)
+		return
+	}
+
 	if len(parts) == 3 && parts[1] == "logs" && parts[2] == "stream" && r.Method == http.MethodGet {
 		s.handleLogStream(w, r, requestID, jobID)
 		return
 	}
 
+	if len(parts) == 3 && parts[1] == "events" && parts[2] == "stream" && r.Method == http.MethodGet {
+		s.handleJobEventsStream(w, r, requestID, jobID)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "sizediff" && r.Method == http.MethodGet {
+		s.handleSizeDiff(w, r, requestID, jobID)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "artifacts.zip" && r.Method == http.MethodGet {
+		s.handleDownloadArtifactsZip(w, r, requestID, jobID)
+		return
+	}
+
 	if len(parts) == 2 && parts[1] == "artifacts" && r.Method == http.MethodGet {
 		s.handleGetArtifacts(w, requestID, jobID)
 		return
 	}
 
+	if len(parts) == 4 && parts[1] == "artifacts" && parts[3] == "sha256" && r.Method == http.MethodGet {
+		s.handleArtifactChecksum(w, requestID, jobID, parts[2])
+		return
+	}
+
 	if len(parts) == 3 && parts[1] == "artifacts" && r.Method == http.MethodGet {
 		s.handleDownloadArtifact(w, r, requestID, jobID, parts[2])
 		return
@@ -454,14 +895,47 @@ func (s *Server) handleJobRoutes(w http.ResponseWriter, r *http.Request, request
 	s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
 }
 
-func (s *Server) handleGetJob(w http.ResponseWriter, requestID string, jobID string) {
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
 	state, err := s.manager.GetJob(jobID)
 	if err != nil {
 		s.handleJobError(w, requestID, err)
 		return
 	}
 
-	s.writeSuccess(w, http.StatusOK, requestID, s.presentState(state))
+	response := s.presentState(state)
+	if etag, err := jobStateETag(response); err == nil {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	s.writeSuccess(w, http.StatusOK, requestID, response)
+}
+
+// jobStateETag is a weak validator over the job data; the envelope meta
+// changes on every response, so the body is not byte-identical.
+func jobStateETag(response stateResponse) (string, error) {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches implements the weak comparison of If-None-Match: the header
+// is a comma-separated list of entity tags or "*", and a W/ prefix on either
+// side is ignored.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Server) handleGetLogs(w http.ResponseWriter, requestID string, jobID string) {
@@ -474,8 +948,86 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, requestID string, jobID st
 	s.writeSuccess(w, http.StatusOK, requestID, logsResponse{Lines: logs})
 }
 
+// handleDownloadLogs serves the job log as a plain-text attachment, so it can
+// be attached to bug reports as-is. The full log is streamed from the job's
+// log file; without one the lines held in memory are sent.
+func (s *Server) handleDownloadLogs(w http.ResponseWriter, requestID string, jobID string) {
+	state, err := s.manager.GetJob(jobID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+	file, err := s.manager.OpenLog(jobID)
+	if err == nil {
+		defer file.Close()
+		s.serveLogFile(w, requestID, jobID, logsFileName(state), file)
+		return
+	}
+	if !errors.Is(err, jobs.ErrLogNotFound) {
+		s.logger.Warn("job log: opening the log file failed", "requestId", requestID, "jobId", jobID, "error", err)
+	}
+	logs, err := s.manager.GetLogs(jobID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", logsFileName(state)))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	buffered := bufio.NewWriter(w)
+	for _, line := range logs {
+		buffered.WriteString(line)
+		buffered.WriteByte('\n')
+	}
+	if err := buffered.Flush(); err != nil {
+		s.logger.Error("serve job logs", "requestId", requestID, "jobId", jobID, "error", err)
+	}
+}
+
+// handleStoredLogDownload serves the log file of any job whose workspace
+// still exists, including jobs from before a restart that the job routes no
+// longer know. It is limited to the stats password because it skips the
+// visibility check of private jobs.
+func (s *Server) handleStoredLogDownload(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	if !s.requireStatsAuth(w, r, requestID) {
+		return
+	}
+	file, err := s.manager.OpenStoredLog(jobID)
+	if errors.Is(err, jobs.ErrLogNotFound) {
+		s.writeError(w, http.StatusNotFound, requestID, "BUILD_LOG_NOT_FOUND", "build log not found", nil)
+		return
+	}
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "build-logs: open log file", "jobId", jobID, "error", err)
+		s.writeError(w, http.StatusInternalServerError, requestID, "BUILD_LOGS_ERROR", "internal error", nil)
+		return
+	}
+	defer file.Close()
+	s.serveLogFile(w, requestID, jobID, jobID+".log", file)
+}
+
+func (s *Server) serveLogFile(w http.ResponseWriter, requestID string, jobID string, name string, file io.Reader) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		s.logger.Error("serve job logs", "requestId", requestID, "jobId", jobID, "error", err)
+	}
+}
+
+func logsFileName(state jobs.State) string {
+	return strings.TrimSuffix(artifactsZipFileName(state), ".zip") + ".log"
+}
+
+// handleLogStream streams the job log as server-sent events. Log events carry
+// the line ID as event ID, so a reconnecting EventSource resumes after the
+// Last-Event-ID it received instead of replaying the whole snapshot.
 func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
-	stream, snapshot, unsubscribe, err := s.manager.SubscribeLogs(jobID)
+	stream, snapshot, unsubscribe, err := s.manager.SubscribeLogs(jobID, lastEventID(r))
 	if err != nil {
 		s.handleJobError(w, requestID, err)
 		return
@@ -492,8 +1044,8 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request, request
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
-	for _, line := range snapshot {
-		writeSSE(w, "log", line)
+	for _, event := range snapshot {
+		writeSSEWithID(w, event.ID, "log", event.Line)
 	}
 	flusher.Flush()
 
@@ -507,11 +1059,19 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request, request
 		case <-ticker.C:
 			writeSSE(w, "ping", time.Now().UTC().Format(time.RFC3339))
 			flusher.Flush()
-		case line, open := <-stream:
+		case event, open := <-stream:
 			if !open {
 				return
 			}
-			writeSSE(w, "log", line)
+			if event.Progress != nil {
+				payload, err := json.Marshal(event.Progress)
+				if err != nil {
+					continue
+				}
+				writeSSE(w, "progress", string(payload))
+			} else {
+				writeSSEWithID(w, event.ID, "log", event.Line)
+			}
 			flusher.Flush()
 		}
 	}
@@ -534,18 +1094,275 @@ func (s *Server) handleDownloadArtifact(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	// Answer revalidation before counting a download or decoding the file.
+	encoded := artifact.Encoding() != "" && acceptsEncoding(r, artifact.Encoding())
+	if etag := artifactETag(artifact, encoded); etag != "" && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		if artifact.Encoding() != "" {
+			w.Header().Set("Vary", "Accept-Encoding")
+		}
+		setArtifactDigestHeaders(w, artifact, encoded)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	if s.stats != nil {
 		s.stats.Record(stats.Event{
 			Type:      stats.EventDownload,
-			IP:        clientIP(r, s.cfg.TrustProxyHeaders),
+			IP:        s.clientIP(r),
 			UserAgent: r.UserAgent(),
 			Extra:     artifact.Name,
 		})
 	}
+	if countsAsDownload(r) {
+		if err := s.manager.RecordArtifactDownload(jobID, artifact.ID); err != nil {
+			s.logger.ErrorContext(r.Context(), "record download", "jobId", jobID, "artifactId", artifact.ID, "error", err)
+		}
+	}
 
 	fileName := filepath.Base(artifact.Name)
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
-	http.ServeFile(w, r, artifact.AbsolutePath())
+	contentType := artifactContentType(fileName)
+	disposition := "attachment"
+	if r.URL.Query().Get("inline") == "1" && isTextContentType(contentType) {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, fileName))
+	if artifact.Encoding() == "" {
+		setArtifactDigestHeaders(w, artifact, false)
+		http.ServeFile(w, r, artifact.AbsolutePath())
+		return
+	}
+
+	s.serveEncodedArtifact(w, r, requestID, artifact)
+}
+
+// countsAsDownload reports whether a request starts a new download rather
+// than resuming one, so Range requests are not counted twice.
+func countsAsDownload(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	rangeHeader := strings.TrimSpace(r.Header.Get("Range"))
+	return rangeHeader == "" || strings.HasPrefix(rangeHeader, "bytes=0-")
+}
+
+// setArtifactDigestHeaders advertises the artifact checksums. The Digest
+// header always describes the decoded file; the ETag is suffixed when the
+// response body is sent with a content encoding so caches keep both
+// representations apart.
+func setArtifactDigestHeaders(w http.ResponseWriter, artifact jobs.Artifact, encoded bool) {
+	etag := artifactETag(artifact, encoded)
+	if etag == "" {
+		return
+	}
+	w.Header().Set("ETag", etag)
+
+	digests := make([]string, 0, 2)
+	if value, err := hex.DecodeString(artifact.SHA256); err == nil {
+		digests = append(digests, "sha-256="+base64.StdEncoding.EncodeToString(value))
+	}
+	if value, err := hex.DecodeString(artifact.MD5); err == nil && len(value) > 0 {
+		digests = append(digests, "md5="+base64.StdEncoding.EncodeToString(value))
+	}
+	if len(digests) > 0 {
+		w.Header().Set("Digest", strings.Join(digests, ","))
+	}
+}
+
+// artifactETag returns the strong validator of an artifact representation,
+// or "" when its checksum is unknown.
+func artifactETag(artifact jobs.Artifact, encoded bool) string {
+	if artifact.SHA256 == "" {
+		return ""
+	}
+	etag := artifact.SHA256
+	if encoded {
+		etag += "-" + artifact.Encoding()
+	}
+	return strconv.Quote(etag)
+}
+
+// handleArtifactChecksum returns the artifact checksum in sha256sum format
+// so it can be piped straight into "sha256sum -c".
+func (s *Server) handleArtifactChecksum(w http.ResponseWriter, requestID string, jobID string, artifactID string) {
+	artifact, err := s.manager.GetArtifact(jobID, artifactID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+	if artifact.SHA256 == "" {
+		s.writeError(w, http.StatusNotFound, requestID, "CHECKSUM_NOT_FOUND", "checksum is not available for this artifact", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "%s  %s\n", artifact.SHA256, filepath.Base(artifact.Name))
+}
+
+func (s *Server) handleDownloadArtifactsZip(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	state, err := s.manager.GetJob(jobID)
+	if err != nil {
+		s.handleJobError(w, requestID, err)
+		return
+	}
+	if len(state.Artifacts) == 0 {
+		s.writeError(w, http.StatusNotFound, requestID, "ARTIFACT_NOT_FOUND", "job has no artifacts", nil)
+		return
+	}
+
+	if s.stats != nil {
+		s.stats.Record(stats.Event{
+			Type:      stats.EventDownload,
+			IP:        s.clientIP(r),
+			UserAgent: r.UserAgent(),
+			Extra:     "artifacts.zip",
+		})
+	}
+	artifactIDs := make([]string, 0, len(state.Artifacts))
+	for _, artifact := range state.Artifacts {
+		artifactIDs = append(artifactIDs, artifact.ID)
+	}
+	if err := s.manager.RecordArtifactDownload(jobID, artifactIDs...); err != nil {
+		s.logger.ErrorContext(r.Context(), "record archive download", "jobId", jobID, "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", artifactsZipFileName(state)))
+	w.WriteHeader(http.StatusOK)
+
+	if err := jobs.WriteArtifactsZip(w, state); err != nil {
+		s.logger.ErrorContext(r.Context(), "serve artifacts archive", "jobId", jobID, "error", err)
+	}
+}
+
+func artifactsZipFileName(state jobs.State) string {
+	suffix := state.ID
+	if len(state.Commit) >= 8 {
+		suffix = state.Commit[:8]
+	}
+	return fmt.Sprintf("%s-%s.zip", sanitizeFileNamePart(state.Device), suffix)
+}
+
+func sanitizeFileNamePart(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
+
+// serveEncodedArtifact serves a compressed cache artifact: clients that
+// accept the stored encoding receive the file as-is with Content-Encoding,
+// everyone else gets a transparently decompressed stream.
+func (s *Server) serveEncodedArtifact(w http.ResponseWriter, r *http.Request, requestID string, artifact jobs.Artifact) {
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	if acceptsEncoding(r, artifact.Encoding()) {
+		setArtifactDigestHeaders(w, artifact, true)
+		w.Header().Set("Content-Encoding", artifact.Encoding())
+		http.ServeFile(w, r, artifact.AbsolutePath())
+		return
+	}
+
+	setArtifactDigestHeaders(w, artifact, false)
+
+	// Decode on the fly behind a seekable reader so Range, If-Range and
+	// conditional requests behave the same as for raw files.
+	content, err := artifact.OpenSeeker()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, requestID, "ARTIFACT_READ_FAILED", "cannot read artifact", nil)
+		return
+	}
+	defer content.Close()
+
+	modified := time.Time{}
+	if info, err := os.Stat(artifact.AbsolutePath()); err == nil {
+		modified = info.ModTime()
+	}
+	http.ServeContent(w, r, filepath.Base(artifact.Name), modified, content)
+}
+
+// artifactContentTypes maps artifact extensions to MIME types; anything not
+// listed is served as application/octet-stream.
+var artifactContentTypes = map[string]string{
+	".json": "application/json",
+	".zip":  "application/zip",
+	".hex":  "text/plain; charset=utf-8",
+	".map":  "text/plain; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+	".log":  "text/plain; charset=utf-8",
+	".sh":   "text/plain; charset=utf-8",
+	".bat":  "text/plain; charset=utf-8",
+	".csv":  "text/csv; charset=utf-8",
+}
+
+func artifactContentType(name string) string {
+	if contentType, ok := artifactContentTypes[strings.ToLower(filepath.Ext(name))]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// isTextContentType reports whether an artifact may be displayed inline.
+func isTextContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") || contentType == "application/json"
+}
+
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+const (
+	defaultSizeDiffSymbols = 50
+	maxSizeDiffSymbols     = 1000
+)
+
+func (s *Server) handleSizeDiff(w http.ResponseWriter, r *http.Request, requestID string, jobID string) {
+	query := r.URL.Query()
+	againstID := strings.TrimSpace(query.Get("against"))
+	if againstID == "" {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", "query parameter against is required", nil)
+		return
+	}
+
+	limit := defaultSizeDiffSymbols
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > maxSizeDiffSymbols {
+			s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", fmt.Sprintf("limit must be between 1 and %d", maxSizeDiffSymbols), nil)
+			return
+		}
+		limit = value
+	}
+
+	diff, err := s.manager.SizeDiff(jobID, againstID, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrDeviceMismatch):
+			s.writeError(w, http.StatusBadRequest, requestID, "DEVICE_MISMATCH", err.Error(), nil)
+		case errors.Is(err, jobs.ErrELFNotFound):
+			s.writeError(w, http.StatusNotFound, requestID, "ELF_NOT_FOUND", err.Error(), nil)
+		case errors.Is(err, jobs.ErrJobNotFound):
+			s.handleJobError(w, requestID, err)
+		default:
+			s.writeError(w, http.StatusUnprocessableEntity, requestID, "SIZEDIFF_FAILED", err.Error(), nil)
+		}
+		return
+	}
+
+	s.writeSuccess(w, http.StatusOK, requestID, diff)
 }
 
 func (s *Server) handleJobError(w http.ResponseWriter, requestID string, err error) {
@@ -557,6 +1374,14 @@ func (s *Server) handleJobError(w http.ResponseWriter, requestID string, err err
 		s.writeError(w, http.StatusNotFound, requestID, "ARTIFACT_NOT_FOUND", err.Error(), nil)
 		return
 	}
+	if errors.Is(err, jobs.ErrJobFinished) {
+		s.writeError(w, http.StatusConflict, requestID, "JOB_FINISHED", err.Error(), nil)
+		return
+	}
+	if errors.Is(err, jobs.ErrJobNotPending) {
+		s.writeError(w, http.StatusConflict, requestID, "JOB_NOT_PENDING", err.Error(), nil)
+		return
+	}
 	s.writeError(w, http.StatusInternalServerError, requestID, "INTERNAL_ERROR", err.Error(), nil)
 }
 
@@ -573,7 +1398,10 @@ func (s *Server) handleCORS(w http.ResponseWriter, r *http.Request, requestID st
 
 	w.Header().Set("Access-Control-Allow-Origin", origin)
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, Last-Event-ID, X-Job-Token, "+csrfHeader)
+	w.Header().Set("Access-Control-Expose-Headers", "X-API-Version, Idempotent-Replayed, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
+	// Lets the frontend send the sign-in session cookie.
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
 	w.Header().Set("Access-Control-Max-Age", "600")
 	return true
 }
@@ -608,27 +1436,37 @@ func (s *Server) writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		s.logger.Printf("write response: %v", err)
+		s.logger.Warn("write response", "error", err)
 	}
 }
 
 func (s *Server) presentState(state jobs.State) stateResponse {
 	return stateResponse{
-		ID:              state.ID,
-		RepoURL:         state.RepoURL,
-		Ref:             state.Ref,
-		Device:          state.Device,
-		BuildFlags:      state.BuildFlags,
-		LibDeps:         state.LibDeps,
-		Status:          state.Status,
-		QueuePosition:   state.QueuePosition,
-		QueueETASeconds: state.QueueETASeconds,
-		CreatedAt:       state.CreatedAt,
-		StartedAt:       state.StartedAt,
-		FinishedAt:      state.FinishedAt,
-		Error:           state.Error,
-		LogLines:        state.LogLines,
-		Artifacts:       toArtifactViews(state.ID, state.Artifacts),
+		ID:               state.ID,
+		RepoURL:          state.RepoURL,
+		Ref:              state.Ref,
+		RequestedRef:     state.RequestedRef,
+		Device:           state.Device,
+		Commit:           state.Commit,
+		BuildFlags:       state.BuildFlags,
+		ArtifactPatterns: state.ArtifactPatterns,
+		PatchSHA256:      state.PatchSHA256,
+		Submodules:       state.Submodules,
+		LibDeps:          state.LibDeps,
+		Status:           state.Status,
+		Visibility:       jobVisibility(state.Private),
+		QueuePosition:    state.QueuePosition,
+		QueueETASeconds:  state.QueueETASeconds,
+		CreatedAt:        state.CreatedAt,
+		StartedAt:        state.StartedAt,
+		FinishedAt:       state.FinishedAt,
+		Error:            state.Error,
+		FailureKind:      state.FailureKind,
+		LogLines:         state.LogLines,
+		Artifacts:        toArtifactViews(state.ID, state.Artifacts),
+		Size:             state.Size,
+		Progress:         state.Progress,
+		Provenance:       state.Provenance,
 	}
 }
 
@@ -636,11 +1474,16 @@ func toArtifactViews(jobID string, artifacts []jobs.Artifact) []artifactView {
 	views := make([]artifactView, len(artifacts))
 	for index, artifact := range artifacts {
 		views[index] = artifactView{
-			ID:           artifact.ID,
-			Name:         artifact.Name,
-			RelativePath: artifact.RelativePath,
-			Size:         artifact.Size,
-			DownloadURL:  fmt.Sprintf("/api/jobs/%s/artifacts/%s", jobID, artifact.ID),
+			ID:             artifact.ID,
+			Name:           artifact.Name,
+			RelativePath:   artifact.RelativePath,
+			Size:           artifact.Size,
+			SHA256:         artifact.SHA256,
+			MD5:            artifact.MD5,
+			URL:            artifact.URL,
+			Downloads:      artifact.Downloads,
+			LastDownloadAt: artifact.LastDownloadAt,
+			DownloadURL:    fmt.Sprintf("/api/jobs/%s/artifacts/%s", jobID, artifact.ID),
 		}
 	}
 	return views
@@ -666,6 +1509,67 @@ func discoveredDeviceNames(devices []jobs.DiscoveredDevice) []string {
 	return names
 }
 
+func toDiscoveredDeviceViews(devices []jobs.DiscoveredDevice) []discoveredDeviceView {
+	views := make([]discoveredDeviceView, 0, len(devices))
+	for _, device := range devices {
+		views = append(views, discoveredDeviceView{
+			Name:         device.Name,
+			Group:        jobs.DevicePlatformGroup(device),
+			RelativePath: device.RelativePath,
+			Platform:     device.Platform,
+			Board:        device.Board,
+			MCU:          device.MCU,
+			HasDisplay:   device.Hints.Display,
+			HasGPS:       device.Hints.GPS,
+			Radios:       device.Hints.Radios,
+			Tags:         jobs.DeviceTags(device),
+		})
+	}
+	return views
+}
+
+// withLastBuilds annotates views, built from devices in the same order,
+// with the last successful build of each device.
+func (s *Server) withLastBuilds(views []discoveredDeviceView, devices []jobs.DiscoveredDevice, repoURL string, commit string) []discoveredDeviceView {
+	if s.manager == nil {
+		return views
+	}
+	builds := s.manager.LastSuccessfulBuilds(repoURL, commit)
+	if len(builds) == 0 {
+		return views
+	}
+	for index, device := range devices {
+		build, ok := jobs.LastSuccessfulBuild(builds, device)
+		if !ok {
+			continue
+		}
+		views[index].LastSuccessfulBuild = &lastBuildView{
+			JobID:       build.JobID,
+			Ref:         build.Ref,
+			Commit:      build.Commit,
+			FinishedAt:  build.FinishedAt,
+			Current:     build.Current,
+			DownloadURL: fmt.Sprintf("/api/jobs/%s/artifacts.zip", build.JobID),
+			Artifacts:   toArtifactViews(build.JobID, build.Artifacts),
+		}
+	}
+	return views
+}
+
+func countDevicePlatforms(devices []jobs.DiscoveredDevice) []platformCount {
+	counts := make(map[string]int, len(jobs.KnownPlatforms))
+	for _, device := range devices {
+		counts[jobs.DevicePlatformGroup(device)]++
+	}
+	result := make([]platformCount, 0, len(counts))
+	for _, platform := range jobs.KnownPlatforms {
+		if counts[platform] > 0 {
+			result = append(result, platformCount{Platform: platform, Count: counts[platform]})
+		}
+	}
+	return result
+}
+
 func discoveredDeviceOptions(devices []jobs.DiscoveredDevice) map[string]discoverBuildOptions {
 	if len(devices) == 0 {
 		return nil
@@ -703,6 +1607,27 @@ func writeSSE(w http.ResponseWriter, event string, data string) {
 	_, _ = fmt.Fprint(w, "\n")
 }
 
+func writeSSEWithID(w http.ResponseWriter, id int, event string, data string) {
+	_, _ = fmt.Fprintf(w, "id: %d\n", id)
+	writeSSE(w, event, data)
+}
+
+// lastEventID returns the ID of the last event a reconnecting client
+// received, from the Last-Event-ID header or, for clients that reopen the
+// stream themselves, the lastEventId query parameter. It is 0 when absent or
+// invalid, which replays the full snapshot.
+func lastEventID(r *http.Request) int {
+	value := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if value == "" {
+		value = strings.TrimSpace(r.URL.Query().Get("lastEventId"))
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
 func generateRequestID() string {
 	buffer := make([]byte, 8)
 	if _, err := rand.Read(buffer); err != nil {
@@ -723,8 +1648,15 @@ func parseValidIP(s string) string {
 	return ""
 }
 
-func clientIP(r *http.Request, trustProxy bool) string {
-	if trustProxy {
+// clientIP returns the address used for rate limiting, captcha binding and
+// statistics. With APP_TRUSTED_PROXIES set, forwarding headers are only
+// honored on connections from those proxies; otherwise
+// APP_TRUST_PROXY_HEADERS decides whether to believe them.
+func (s *Server) clientIP(r *http.Request) string {
+	if len(s.cfg.TrustedProxies) > 0 {
+		return trustedClientIP(r, s.cfg.TrustedProxies)
+	}
+	if s.cfg.TrustProxyHeaders {
 		if raw := strings.TrimSpace(r.Header.Get("X-Real-IP")); raw != "" {
 			if ip := parseValidIP(raw); ip != "" {
 				return ip
@@ -740,39 +1672,106 @@ func clientIP(r *http.Request, trustProxy bool) string {
 	return normalizeRemoteHost(r.RemoteAddr)
 }
 
-func (s *Server) allowBuildRequest(remoteAddr string) bool {
-	host := normalizeRemoteHost(remoteAddr)
-
-	now := time.Now().UTC()
-	threshold := now.Add(-1 * time.Minute)
+// trustedClientIP walks X-Forwarded-For from the nearest hop and returns the
+// first address that is not a trusted proxy, so clients cannot spoof their
+// address by prepending entries. X-Real-IP is used when a trusted proxy sent
+// no X-Forwarded-For.
+func trustedClientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote := normalizeRemoteHost(r.RemoteAddr)
+	if !isTrustedProxy(remote, trusted) {
+		return remote
+	}
 
-	s.rateMu.Lock()
-	defer s.rateMu.Unlock()
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	if len(hops) == 0 {
+		if ip := parseValidIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != "" {
+			return ip
+		}
+		return remote
+	}
 
-	items := s.buildRequests[host]
-	filtered := items[:0]
-	for _, stamp := range items {
-		if stamp.After(threshold) {
-			filtered = append(filtered, stamp)
+	for index := len(hops) - 1; index >= 0; index-- {
+		ip := parseValidIP(strings.TrimSpace(hops[index]))
+		if ip == "" {
+			break
 		}
+		if !isTrustedProxy(ip, trusted) {
+			return ip
+		}
+		remote = ip
 	}
+	return remote
+}
 
-	if len(filtered) >= s.cfg.BuildRateLimit {
-		s.buildRequests[host] = append([]time.Time(nil), filtered...)
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
 		return false
 	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
 
-	filtered = append(filtered, now)
-	s.buildRequests[host] = append([]time.Time(nil), filtered...)
-	return true
+// buildQuota is a client's position in the per-minute build rate limit.
+// Reset is when the oldest counted request leaves the window.
+type buildQuota struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// allowBuildRequest counts a build request against the per-minute limit of
+// key, a client host or a signed-in account. When the store is unreachable the request is let
+// through: an outage of Redis should not stop all builds.
+func (s *Server) allowBuildRequest(ctx context.Context, key string, limit int) buildQuota {
+	now := time.Now().UTC()
+	window, err := s.store.countRequest(ctx, key, limit, time.Minute, now)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "rate limit", "error", err)
+		return buildQuota{Allowed: true, Limit: limit, Remaining: limit, Reset: now}
+	}
+
+	quota := buildQuota{
+		Allowed:   window.allowed,
+		Limit:     limit,
+		Remaining: max(limit-window.count, 0),
+		Reset:     now,
+	}
+	if window.count > 0 {
+		quota.Reset = window.oldest.Add(time.Minute)
+	}
+	return quota
+}
+
+// setRateLimitHeaders reports quota in X-RateLimit-* headers, with the reset
+// in seconds from now, and adds Retry-After when the request was rejected.
+func setRateLimitHeaders(w http.ResponseWriter, quota buildQuota, now time.Time) {
+	resetSeconds := max(int(math.Ceil(quota.Reset.Sub(now).Seconds())), 0)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(quota.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(quota.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(resetSeconds))
+	if !quota.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(max(resetSeconds, 1)))
+	}
 }
 
 type discoverRequest struct {
-	RepoURL             string `json:"repoUrl"`
-	Ref                 string `json:"ref"`
-	CaptchaID           string `json:"captchaId,omitempty"`
-	CaptchaAnswer       string `json:"captchaAnswer,omitempty"`
-	CaptchaSessionToken string `json:"captchaSessionToken,omitempty"`
+	RepoURL             string   `json:"repoUrl"`
+	Ref                 string   `json:"ref"`
+	Platform            string   `json:"platform,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	CaptchaID           string   `json:"captchaId,omitempty"`
+	CaptchaAnswer       string   `json:"captchaAnswer,omitempty"`
+	CaptchaSessionToken string   `json:"captchaSessionToken,omitempty"`
 }
 
 type repoRefsRequest struct {
@@ -782,11 +1781,51 @@ type repoRefsRequest struct {
 type discoverResponse struct {
 	RepoURL             string                          `json:"repoUrl"`
 	Ref                 string                          `json:"ref,omitempty"`
+	Commit              string                          `json:"commit,omitempty"`
 	Devices             []string                        `json:"devices"`
+	DeviceInfo          []discoveredDeviceView          `json:"deviceInfo"`
+	Platforms           []platformCount                 `json:"platforms"`
 	DeviceOptions       map[string]discoverBuildOptions `json:"deviceOptions,omitempty"`
+	Warnings            []jobs.DiscoveryWarning         `json:"warnings"`
 	CaptchaSessionToken string                          `json:"captchaSessionToken,omitempty"`
 }
 
+// platformCount is the number of discovered devices per platform before
+// filtering, for building platform tabs.
+type platformCount struct {
+	Platform string `json:"platform"`
+	Count    int    `json:"count"`
+}
+
+type discoveredDeviceView struct {
+	Name         string `json:"name"`
+	Group        string `json:"group"`
+	RelativePath string `json:"relativePath,omitempty"`
+	Platform     string `json:"platform,omitempty"`
+	Board        string `json:"board,omitempty"`
+	MCU          string `json:"mcu,omitempty"`
+	HasDisplay   *bool  `json:"hasDisplay,omitempty"`
+	HasGPS       *bool  `json:"hasGps,omitempty"`
+
+	Radios []string `json:"radios,omitempty"`
+	Tags   []string `json:"tags"`
+
+	LastSuccessfulBuild *lastBuildView `json:"lastSuccessfulBuild,omitempty"`
+}
+
+// lastBuildView points at a retained successful build of a device with
+// default options; current is true when it was built from the discovered
+// commit.
+type lastBuildView struct {
+	JobID       string         `json:"jobId"`
+	Ref         string         `json:"ref,omitempty"`
+	Commit      string         `json:"commit,omitempty"`
+	FinishedAt  time.Time      `json:"finishedAt"`
+	Current     bool           `json:"current"`
+	DownloadURL string         `json:"downloadUrl"`
+	Artifacts   []artifactView `json:"artifacts"`
+}
+
 type discoverBuildOptions struct {
 	BuildFlags []string `json:"buildFlags,omitempty"`
 	LibDeps    []string `json:"libDeps,omitempty"`
@@ -800,29 +1839,50 @@ type repoRefsResponse struct {
 }
 
 type createJobRequest struct {
-	RepoURL             string   `json:"repoUrl"`
-	Ref                 string   `json:"ref"`
-	Device              string   `json:"device"`
-	BuildFlags          []string `json:"buildFlags,omitempty"`
-	LibDeps             []string `json:"libDeps,omitempty"`
-	CaptchaID           string   `json:"captchaId,omitempty"`
-	CaptchaAnswer       string   `json:"captchaAnswer,omitempty"`
-	CaptchaSessionToken string   `json:"captchaSessionToken,omitempty"`
+	RepoURL             string                   `json:"repoUrl"`
+	Ref                 string                   `json:"ref"`
+	Device              string                   `json:"device"`
+	BuildFlags          []string                 `json:"buildFlags,omitempty"`
+	LibDeps             []string                 `json:"libDeps,omitempty"`
+	ArtifactPatterns    []string                 `json:"artifactPatterns,omitempty"`
+	Patch               string                   `json:"patch,omitempty"`
+	Submodules          []jobs.SubmoduleOverride `json:"submodules,omitempty"`
+	CaptchaID           string                   `json:"captchaId,omitempty"`
+	CaptchaAnswer       string                   `json:"captchaAnswer,omitempty"`
+	CaptchaSessionToken string                   `json:"captchaSessionToken,omitempty"`
+	Public              bool                     `json:"public,omitempty"`
+	PoWChallenge        string                   `json:"powChallenge,omitempty"`
+	PoWNonce            string                   `json:"powNonce,omitempty"`
+	Notify              *notify.Target           `json:"notify,omitempty"`
 }
 
 type captchaResponse struct {
 	CaptchaRequired bool      `json:"captchaRequired"`
+	Provider        string    `json:"provider,omitempty"`
+	SiteKey         string    `json:"siteKey,omitempty"`
 	CaptchaID       string    `json:"captchaId,omitempty"`
 	Question        string    `json:"question,omitempty"`
+	Image           string    `json:"image,omitempty"`
 	ExpiresAt       time.Time `json:"expiresAt,omitempty"`
 }
 
 type healthResponse struct {
-	Status          string `json:"status"`
-	CaptchaRequired bool   `json:"captchaRequired"`
-	StatsEnabled    bool   `json:"statsEnabled"`
-	Version         string `json:"version,omitempty"`
-	Commit          string `json:"commit,omitempty"`
+	Status          string              `json:"status"`
+	CaptchaRequired bool                `json:"captchaRequired"`
+	StatsEnabled    bool                `json:"statsEnabled"`
+	Version         string              `json:"version,omitempty"`
+	Commit          string              `json:"commit,omitempty"`
+	Discovery       *jobs.DiscoveryLoad `json:"discovery,omitempty"`
+	BuildRateLimit  int                 `json:"buildRateLimitPerMinute"`
+	Draining        bool                `json:"draining,omitempty"`
+	ProofOfWork     bool                `json:"proofOfWork,omitempty"`
+	Quota           *quotaStatus        `json:"quota,omitempty"`
+	Disk            []jobs.DiskUsage    `json:"disk,omitempty"`
+	Docker          *jobs.DockerHealth  `json:"docker,omitempty"`
+}
+
+type livenessResponse struct {
+	Status string `json:"status"`
 }
 
 type logsResponse struct {
@@ -830,22 +1890,34 @@ type logsResponse struct {
 }
 
 type stateResponse struct {
-	ID                  string         `json:"id"`
-	RepoURL             string         `json:"repoUrl"`
-	Ref                 string         `json:"ref,omitempty"`
-	Device              string         `json:"device"`
-	BuildFlags          []string       `json:"buildFlags,omitempty"`
-	LibDeps             []string       `json:"libDeps,omitempty"`
-	Status              jobs.Status    `json:"status"`
-	CaptchaSessionToken string         `json:"captchaSessionToken,omitempty"`
-	QueuePosition       *int           `json:"queuePosition,omitempty"`
-	QueueETASeconds     *int           `json:"queueEtaSeconds,omitempty"`
-	CreatedAt           time.Time      `json:"createdAt"`
-	StartedAt           *time.Time     `json:"startedAt,omitempty"`
-	FinishedAt          *time.Time     `json:"finishedAt,omitempty"`
-	Error               string         `json:"error,omitempty"`
-	LogLines            int            `json:"logLines"`
-	Artifacts           []artifactView `json:"artifacts"`
+	ID                  string                   `json:"id"`
+	RepoURL             string                   `json:"repoUrl"`
+	Ref                 string                   `json:"ref,omitempty"`
+	RequestedRef        string                   `json:"requestedRef,omitempty"`
+	Device              string                   `json:"device"`
+	Commit              string                   `json:"commit,omitempty"`
+	BuildFlags          []string                 `json:"buildFlags,omitempty"`
+	LibDeps             []string                 `json:"libDeps,omitempty"`
+	ArtifactPatterns    []string                 `json:"artifactPatterns,omitempty"`
+	PatchSHA256         string                   `json:"patchSha256,omitempty"`
+	Submodules          []jobs.SubmoduleOverride `json:"submodules,omitempty"`
+	Status              jobs.Status              `json:"status"`
+	Visibility          string                   `json:"visibility"`
+	AccessToken         string                   `json:"accessToken,omitempty"`
+	CaptchaSessionToken string                   `json:"captchaSessionToken,omitempty"`
+	Quota               *quotaStatus             `json:"quota,omitempty"`
+	QueuePosition       *int                     `json:"queuePosition,omitempty"`
+	QueueETASeconds     *int                     `json:"queueEtaSeconds,omitempty"`
+	CreatedAt           time.Time                `json:"createdAt"`
+	StartedAt           *time.Time               `json:"startedAt,omitempty"`
+	FinishedAt          *time.Time               `json:"finishedAt,omitempty"`
+	Error               string                   `json:"error,omitempty"`
+	FailureKind         jobs.FailureKind         `json:"failureKind,omitempty"`
+	LogLines            int                      `json:"logLines"`
+	Artifacts           []artifactView           `json:"artifacts"`
+	Size                *jobs.SizeReport         `json:"size,omitempty"`
+	Progress            *jobs.Progress           `json:"progress,omitempty"`
+	Provenance          *jobs.Provenance         `json:"provenance,omitempty"`
 }
 
 type artifactsResponse struct {
@@ -853,11 +1925,16 @@ type artifactsResponse struct {
 }
 
 type artifactView struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	RelativePath string `json:"relativePath"`
-	Size         int64  `json:"size"`
-	DownloadURL  string `json:"downloadUrl"`
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	RelativePath   string     `json:"relativePath"`
+	Size           int64      `json:"size"`
+	SHA256         string     `json:"sha256,omitempty"`
+	MD5            string     `json:"md5,omitempty"`
+	URL            string     `json:"url,omitempty"`
+	Downloads      int64      `json:"downloads"`
+	LastDownloadAt *time.Time `json:"lastDownloadAt,omitempty"`
+	DownloadURL    string     `json:"downloadUrl"`
 }
 
 type repoRefView struct {
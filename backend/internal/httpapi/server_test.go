@@ -2,14 +2,20 @@ package httpapi
 
 import (
 	"encoding/json"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
 )
 
 func TestHandleHealthz(t *testing.T) {
@@ -18,7 +24,8 @@ func TestHandleHealthz(t *testing.T) {
 	server := NewServer(config.Config{
 		RequireCaptcha: false,
 		StatsPassword:  "secret",
-	}, nil, log.New(io.Discard, "", 0))
+		BuildRateLimit: 5,
+	}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/healthz", nil)
@@ -43,6 +50,9 @@ func TestHandleHealthz(t *testing.T) {
 	if !envelope.Data.StatsEnabled {
 		t.Fatalf("expected statsEnabled=true when stats password is set")
 	}
+	if envelope.Data.BuildRateLimit != 5 {
+		t.Fatalf("unexpected buildRateLimitPerMinute: %d", envelope.Data.BuildRateLimit)
+	}
 	if recorder.Header().Get("X-Request-ID") == "" {
 		t.Fatalf("expected X-Request-ID header")
 	}
@@ -51,7 +61,7 @@ func TestHandleHealthz(t *testing.T) {
 func TestHandleHealthzCaptchaRequired(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{RequireCaptcha: true}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{RequireCaptcha: true}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/healthz", nil)
@@ -73,7 +83,7 @@ func TestHandleCORSRejectsUnknownOrigin(t *testing.T) {
 
 	server := NewServer(config.Config{
 		AllowedOrigins: []string{"http://localhost:5173"},
-	}, nil, log.New(io.Discard, "", 0))
+	}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/healthz", nil)
@@ -91,7 +101,7 @@ func TestHandleCORSAllowsConfiguredOrigin(t *testing.T) {
 	const origin = "http://localhost:5173"
 	server := NewServer(config.Config{
 		AllowedOrigins: []string{origin},
-	}, nil, log.New(io.Discard, "", 0))
+	}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodOptions, "/api/healthz", nil)
@@ -109,7 +119,7 @@ func TestHandleCORSAllowsConfiguredOrigin(t *testing.T) {
 func TestHandleNewCaptchaDisabled(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{RequireCaptcha: false}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{RequireCaptcha: false}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/captcha", nil)
@@ -131,7 +141,7 @@ func TestHandleNewCaptchaDisabled(t *testing.T) {
 func TestHandleNewCaptchaEnabled(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{RequireCaptcha: true}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{RequireCaptcha: true}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/captcha", nil)
@@ -159,7 +169,7 @@ func TestHandleNewCaptchaEnabled(t *testing.T) {
 func TestHandleStatsRequiresAuth(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{StatsPassword: "secret"}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{StatsPassword: "secret"}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
@@ -173,7 +183,7 @@ func TestHandleStatsRequiresAuth(t *testing.T) {
 func TestHandleStatsHiddenWhenDisabled(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
@@ -185,10 +195,90 @@ func TestHandleStatsHiddenWhenDisabled(t *testing.T) {
 	}
 }
 
+func TestHandleServiceStatsIsPublic(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		StatsPassword:   "secret",
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/stats/service?days=1000", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var payload struct {
+		Data jobs.ServiceStats `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if payload.Data.Days != 365 {
+		t.Fatalf("days must be capped at 365, got %d", payload.Data.Days)
+	}
+}
+
+func TestHandleStoredLogDownload(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		StatsPassword:   "secret",
+	}
+	// A job from before a restart: only its workspace is left.
+	if err := os.MkdirAll(filepath.Join(cfg.JobsRootPath, "abc123"), 0o755); err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.JobsRootPath, "abc123", "build.log"), []byte("cloning\nbuilding\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	serve := func(path string, password string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if password != "" {
+			request.Header.Set("Authorization", "Bearer "+password)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/api/stats/build-logs/abc123/logs.txt", "secret")
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "cloning\nbuilding\n" || recorder.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("unexpected stored log: %d %q", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve("/api/stats/build-logs/abc123/logs.txt", ""); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the stats password to be required, got %d", recorder.Code)
+	}
+	if recorder := serve("/api/stats/build-logs/missing/logs.txt", "secret"); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a job without a log file, got %d", recorder.Code)
+	}
+	// The job routes only serve jobs the manager knows.
+	if recorder := serve("/api/jobs/abc123/logs.txt", ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected the job route to answer 404, got %d", recorder.Code)
+	}
+}
+
 func TestHandleUnknownRoute(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodGet, "/api/unknown", nil)
@@ -214,7 +304,7 @@ func TestHandleUnknownRoute(t *testing.T) {
 func TestHandleCreateJobValidation(t *testing.T) {
 	t.Parallel()
 
-	server := NewServer(config.Config{RequireCaptcha: false}, nil, log.New(io.Discard, "", 0))
+	server := NewServer(config.Config{RequireCaptcha: false}, nil, slog.New(slog.DiscardHandler))
 
 	recorder := httptest.NewRecorder()
 	request := httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(`{"repoUrl":"","ref":"","device":""}`))
@@ -225,3 +315,403 @@ func TestHandleCreateJobValidation(t *testing.T) {
 		t.Fatalf("expected validation error, got 200")
 	}
 }
+
+func TestHandleCreateJobRateLimitHeaders(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		BuildRateLimit:  1,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	post := func() *httptest.ResponseRecorder {
+		body := `{"repoUrl":"https://github.com/example/repo","ref":"main","device":"tbeam"}`
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body)))
+		return recorder
+	}
+
+	first := post()
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	if first.Header().Get("X-RateLimit-Limit") != "1" || first.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected quota headers: %v", first.Header())
+	}
+	if first.Header().Get("Retry-After") != "" {
+		t.Fatalf("accepted request must not carry Retry-After")
+	}
+
+	second := post()
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", second.Code)
+	}
+	retryAfter, err := strconv.Atoi(second.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Fatalf("unexpected Retry-After: %q", second.Header().Get("Retry-After"))
+	}
+	if second.Header().Get("X-RateLimit-Reset") != strconv.Itoa(retryAfter) {
+		t.Fatalf("reset and Retry-After must agree: %v", second.Header())
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"":                     false,
+		"gzip, deflate":        false,
+		"gzip, zstd":           true,
+		"ZSTD;q=0.5":           true,
+		"zstd;q=0, gzip":       false,
+		"br, zstd ; q=0.9, gz": true,
+	}
+	for header, want := range cases {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept-Encoding", header)
+		if got := acceptsEncoding(request, "zstd"); got != want {
+			t.Fatalf("Accept-Encoding %q: got=%v want=%v", header, got, want)
+		}
+	}
+}
+
+func TestSetArtifactDigestHeaders(t *testing.T) {
+	t.Parallel()
+
+	// Arbitrary hex digests; only the header formatting is under test.
+	artifact := jobs.Artifact{
+		Name:   "firmware.bin",
+		SHA256: "a5a1e2a1b5ad5d6d6a0eb6d6e1fb0e1a2b8c1a0c6a1b54c2d5c07d1c3e3f6b3c",
+		MD5:    "0c1d1c8e5d42a63b7c2b9c1f0f0f6f4e",
+	}
+
+	recorder := httptest.NewRecorder()
+	setArtifactDigestHeaders(recorder, artifact, false)
+	if got := recorder.Header().Get("ETag"); got != `"`+artifact.SHA256+`"` {
+		t.Fatalf("unexpected ETag: %q", got)
+	}
+	digest := recorder.Header().Get("Digest")
+	if !strings.HasPrefix(digest, "sha-256=") || !strings.Contains(digest, ",md5=") {
+		t.Fatalf("unexpected Digest header: %q", digest)
+	}
+
+	encoded := httptest.NewRecorder()
+	setArtifactDigestHeaders(encoded, artifact, true)
+	if got := encoded.Header().Get("ETag"); got == recorder.Header().Get("ETag") {
+		t.Fatalf("encoded representation must use a distinct ETag: %q", got)
+	}
+
+	empty := httptest.NewRecorder()
+	setArtifactDigestHeaders(empty, jobs.Artifact{Name: "firmware.bin"}, false)
+	if empty.Header().Get("ETag") != "" || empty.Header().Get("Digest") != "" {
+		t.Fatalf("headers must be omitted without checksums")
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	t.Parallel()
+
+	etag := artifactETag(jobs.Artifact{SHA256: "a5a1e2a1"}, false)
+	cases := map[string]bool{
+		"":                         false,
+		etag:                       true,
+		"W/" + etag:                true,
+		`"stale", ` + etag:         true,
+		`W/"stale",W/` + etag:      true,
+		"*":                        true,
+		`"stale"`:                  false,
+		`"a5a1e2a1-zstd"`:          false,
+		strings.Trim(etag, `"`):    false,
+		`"stale" , W/"a5a1e2a1"  `: true,
+	}
+	for header, want := range cases {
+		if got := etagMatches(header, etag); got != want {
+			t.Fatalf("If-None-Match %q: got=%v want=%v", header, got, want)
+		}
+	}
+}
+
+func TestArtifactContentType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		want   string
+		inline bool
+	}{
+		{name: "firmware.bin", want: "application/octet-stream"},
+		{name: "firmware.UF2", want: "application/octet-stream"},
+		{name: "firmware-tbeam-2.5.0-ota.zip", want: "application/zip"},
+		{name: "manifest.json", want: "application/json", inline: true},
+		{name: "firmware.map", want: "text/plain; charset=utf-8", inline: true},
+		{name: "firmware.hex", want: "text/plain; charset=utf-8", inline: true},
+	}
+
+	for _, tc := range tests {
+		got := artifactContentType(tc.name)
+		if got != tc.want {
+			t.Fatalf("content type for %s: got=%q want=%q", tc.name, got, tc.want)
+		}
+		if isTextContentType(got) != tc.inline {
+			t.Fatalf("inline for %s: got=%v want=%v", tc.name, !tc.inline, tc.inline)
+		}
+	}
+}
+
+func TestHandleDiscoverRejectsUnknownPlatform(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/repos/discover", strings.NewReader(`{"repoUrl":"https://github.com/meshtastic/firmware","ref":"master","platform":"avr"}`))
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: got=%d want=%d", recorder.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCountDevicePlatforms(t *testing.T) {
+	t.Parallel()
+
+	counts := countDevicePlatforms([]jobs.DiscoveredDevice{
+		{Name: "rak4631", Platform: jobs.PlatformNRF52},
+		{Name: "tbeam", Platform: jobs.PlatformESP32},
+		{Name: "heltec-v3", Platform: jobs.PlatformESP32},
+		{Name: "mystery"},
+	})
+	want := []platformCount{{jobs.PlatformESP32, 2}, {jobs.PlatformNRF52, 1}, {jobs.PlatformOther, 1}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("unexpected platform counts: got=%v want=%v", counts, want)
+	}
+}
+
+func TestDiscoveredDeviceOptions(t *testing.T) {
+	t.Parallel()
+
+	devices := []jobs.DiscoveredDevice{
+		{Name: "tbeam", BuildFlags: []string{"-D HAS_GPS=1"}, LibDeps: []string{"lewisxhe/XPowersLib@^0.2.1"}},
+		{Name: "rak4631"},
+	}
+	options := discoveredDeviceOptions(devices)
+	want := map[string]discoverBuildOptions{
+		"tbeam":   {BuildFlags: []string{"-D HAS_GPS=1"}, LibDeps: []string{"lewisxhe/XPowersLib@^0.2.1"}},
+		"rak4631": {},
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Fatalf("unexpected device options: got=%v want=%v", options, want)
+	}
+
+	options["tbeam"].BuildFlags[0] = "-D CHANGED"
+	if devices[0].BuildFlags[0] != "-D HAS_GPS=1" {
+		t.Fatalf("device options must not share slices with discovered devices")
+	}
+
+	if options := discoveredDeviceOptions(nil); options != nil {
+		t.Fatalf("expected nil options without devices, got %v", options)
+	}
+}
+
+func TestHandleGetJobETag(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/jobs/"+state.ID, nil))
+	etag := recorder.Header().Get("ETag")
+	if recorder.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("unexpected first response: status=%d etag=%q", recorder.Code, etag)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/jobs/"+state.ID, nil)
+	request.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 {
+		t.Fatalf("unexpected conditional response: status=%d body=%q", recorder.Code, recorder.Body.String())
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/api/jobs/"+state.ID, nil)
+	request.Header.Set("If-None-Match", `W/"stale"`)
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("stale validator must return the state: status=%d", recorder.Code)
+	}
+}
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("127.0.0.1/32")}
+	server := NewServer(config.Config{TrustProxyHeaders: true, TrustedProxies: trusted}, nil, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{name: "untrusted peer ignores headers", remoteAddr: "203.0.113.9:4000", forwarded: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.9"},
+		{name: "single trusted hop", remoteAddr: "127.0.0.1:4000", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed entries are skipped", remoteAddr: "127.0.0.1:4000", forwarded: []string{"1.2.3.4, 198.51.100.1, 10.1.2.3"}, want: "198.51.100.1"},
+		{name: "multiple header lines", remoteAddr: "127.0.0.1:4000", forwarded: []string{"198.51.100.7", "10.0.0.2"}, want: "198.51.100.7"},
+		{name: "all hops trusted", remoteAddr: "127.0.0.1:4000", forwarded: []string{"10.0.0.5, 10.0.0.2"}, want: "10.0.0.5"},
+		{name: "malformed hop stops the walk", remoteAddr: "127.0.0.1:4000", forwarded: []string{"198.51.100.1, garbage, 10.0.0.2"}, want: "10.0.0.2"},
+		{name: "real ip without forwarded for", remoteAddr: "127.0.0.1:4000", realIP: "198.51.100.3", want: "198.51.100.3"},
+	}
+
+	for _, tc := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/api/healthz", nil)
+		request.RemoteAddr = tc.remoteAddr
+		for _, value := range tc.forwarded {
+			request.Header.Add("X-Forwarded-For", value)
+		}
+		if tc.realIP != "" {
+			request.Header.Set("X-Real-IP", tc.realIP)
+		}
+		if got := server.clientIP(request); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHandleDownloadLogs(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/jobs/"+state.ID+"/logs.txt", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	if disposition := recorder.Header().Get("Content-Disposition"); disposition != `attachment; filename="tbeam-`+state.ID+`.log"` {
+		t.Fatalf("unexpected content disposition %q", disposition)
+	}
+	logs, err := manager.GetLogs(state.ID)
+	if err != nil {
+		t.Fatalf("get logs: %v", err)
+	}
+	want := ""
+	for _, line := range logs {
+		want += line + "\n"
+	}
+	if recorder.Body.String() != want {
+		t.Fatalf("unexpected body %q, want %q", recorder.Body.String(), want)
+	}
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/jobs/missing/logs.txt", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for unknown job, got %d", recorder.Code)
+	}
+}
+
+func TestLastEventID(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		header string
+		query  string
+		want   int
+	}{
+		{"", "", 0},
+		{"42", "", 42},
+		{" 42 ", "7", 42},
+		{"", "7", 7},
+		{"abc", "", 0},
+		{"-3", "", 0},
+	}
+	for _, tc := range cases {
+		target := "/api/jobs/abc/logs/stream"
+		if tc.query != "" {
+			target += "?lastEventId=" + tc.query
+		}
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		if tc.header != "" {
+			request.Header.Set("Last-Event-ID", tc.header)
+		}
+		if got := lastEventID(request); got != tc.want {
+			t.Fatalf("lastEventID(header=%q, query=%q) = %d, want %d", tc.header, tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestHandleLivezAndReadyz(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	server := NewServer(cfg, manager, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/livez", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected livez status 200, got %d", recorder.Code)
+	}
+
+	// A draining node is alive but not ready, whatever the host provides.
+	manager.SetDraining(true)
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected readyz status 503, got %d", recorder.Code)
+	}
+	var payload struct {
+		Error struct {
+			Code    string         `json:"code"`
+			Details jobs.Readiness `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if payload.Error.Code != "NOT_READY" || len(payload.Error.Details.Checks) != 5 {
+		t.Fatalf("unexpected readyz response: %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,187 @@
+package httpapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// clientStore holds the build rate limit windows, build quotas and captcha
+// sessions. The memory store keeps them in this process; the Redis store
+// shares them between the backends of a cluster and keeps them across
+// restarts.
+type clientStore interface {
+	name() string
+	// countRequest adds a request of key at now to its sliding window of
+	// length window, unless the window already holds limit requests.
+	countRequest(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (requestWindow, error)
+	// getQuota returns the builds of key in the quota day and week starting
+	// at dayStart and weekStart.
+	getQuota(ctx context.Context, key string, dayStart time.Time, weekStart time.Time) (quotaUsage, error)
+	// reserveQuota counts a build of key in both windows unless one of them
+	// already holds its limit (0 is unlimited); ok reports whether it did.
+	reserveQuota(ctx context.Context, key string, daily int, weekly int, dayStart time.Time, weekStart time.Time) (usage quotaUsage, ok bool, err error)
+	// releaseQuota takes back a build counted by reserveQuota.
+	releaseQuota(ctx context.Context, key string, dayStart time.Time, weekStart time.Time) error
+	putCaptchaSession(ctx context.Context, token string, session captchaSession) error
+	// getCaptchaSession reports ok=false for unknown and expired sessions.
+	getCaptchaSession(ctx context.Context, token string, now time.Time) (session captchaSession, ok bool, err error)
+	deleteCaptchaSession(ctx context.Context, token string) error
+	ping(ctx context.Context) error
+}
+
+// requestWindow is the state of a sliding window after a request was
+// counted or refused.
+type requestWindow struct {
+	allowed bool
+	count   int
+	oldest  time.Time
+}
+
+func newClientStore(cfg config.Config) (clientStore, error) {
+	if cfg.RedisURL == "" {
+		return newMemoryStore(), nil
+	}
+	return newRedisStore(cfg.RedisURL, cfg.RedisKeyPrefix)
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+	quotas   map[string]quotaUsage
+	sessions map[string]captchaSession
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		requests: make(map[string][]time.Time),
+		quotas:   make(map[string]quotaUsage),
+		sessions: make(map[string]captchaSession),
+	}
+}
+
+func (m *memoryStore) name() string {
+	return "memory"
+}
+
+func (m *memoryStore) countRequest(_ context.Context, key string, limit int, window time.Duration, now time.Time) (requestWindow, error) {
+	threshold := now.Add(-window)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := m.requests[key]
+	filtered := items[:0]
+	for _, stamp := range items {
+		if stamp.After(threshold) {
+			filtered = append(filtered, stamp)
+		}
+	}
+
+	result := requestWindow{}
+	if len(filtered) < limit {
+		filtered = append(filtered, now)
+		result.allowed = true
+	}
+	m.requests[key] = append([]time.Time(nil), filtered...)
+
+	result.count = len(filtered)
+	if len(filtered) > 0 {
+		result.oldest = filtered[0]
+	}
+	return result, nil
+}
+
+func (m *memoryStore) getQuota(_ context.Context, key string, dayStart time.Time, weekStart time.Time) (quotaUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.currentQuotaLocked(key, dayStart, weekStart), nil
+}
+
+func (m *memoryStore) reserveQuota(_ context.Context, key string, daily int, weekly int, dayStart time.Time, weekStart time.Time) (quotaUsage, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cleanupQuotasLocked(weekStart)
+
+	usage := m.currentQuotaLocked(key, dayStart, weekStart)
+	if (daily > 0 && usage.dayUsed >= daily) || (weekly > 0 && usage.weekUsed >= weekly) {
+		return usage, false, nil
+	}
+	usage.dayUsed++
+	usage.weekUsed++
+	m.quotas[key] = usage
+	return usage, true, nil
+}
+
+func (m *memoryStore) releaseQuota(_ context.Context, key string, dayStart time.Time, weekStart time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := m.currentQuotaLocked(key, dayStart, weekStart)
+	usage.dayUsed = max(usage.dayUsed-1, 0)
+	usage.weekUsed = max(usage.weekUsed-1, 0)
+	m.quotas[key] = usage
+	return nil
+}
+
+// currentQuotaLocked returns the usage of key, rolled over to the given
+// windows.
+func (m *memoryStore) currentQuotaLocked(key string, dayStart time.Time, weekStart time.Time) quotaUsage {
+	usage := m.quotas[key]
+	if !usage.dayStart.Equal(dayStart) {
+		usage.dayStart, usage.dayUsed = dayStart, 0
+	}
+	if !usage.weekStart.Equal(weekStart) {
+		usage.weekStart, usage.weekUsed = weekStart, 0
+	}
+	return usage
+}
+
+// cleanupQuotasLocked drops clients whose usage is from a past week.
+func (m *memoryStore) cleanupQuotasLocked(weekStart time.Time) {
+	for key, usage := range m.quotas {
+		if usage.weekStart.Before(weekStart) {
+			delete(m.quotas, key)
+		}
+	}
+}
+
+func (m *memoryStore) putCaptchaSession(_ context.Context, token string, session captchaSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cleanupSessionsLocked(time.Now().UTC())
+	m.sessions[token] = session
+	return nil
+}
+
+func (m *memoryStore) getCaptchaSession(_ context.Context, token string, now time.Time) (captchaSession, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[token]
+	if !ok || now.After(session.expiresAt) {
+		delete(m.sessions, token)
+		return captchaSession{}, false, nil
+	}
+	return session, true, nil
+}
+
+func (m *memoryStore) deleteCaptchaSession(_ context.Context, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, token)
+	return nil
+}
+
+func (m *memoryStore) ping(context.Context) error {
+	return nil
+}
+
+func (m *memoryStore) cleanupSessionsLocked(now time.Time) {
+	for token, session := range m.sessions {
+		if now.After(session.expiresAt) {
+			delete(m.sessions, token)
+		}
+	}
+}
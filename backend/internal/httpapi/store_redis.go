@@ -0,0 +1,207 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript counts a request in a sorted set scored by time in
+// milliseconds, atomically across backends. It returns whether the request
+// was allowed, the requests in the window and the oldest one's score.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+if count < tonumber(ARGV[3]) then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call("PEXPIRE", KEYS[1], window)
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return {allowed, count, oldest[2] or "0"}
+`)
+
+// reserveQuotaScript counts a build in the day and week counters unless one
+// of them is at its limit (0 is unlimited), atomically across backends. The
+// counters expire when their window ends. It returns whether the build was
+// counted and both counts.
+var reserveQuotaScript = redis.NewScript(`
+local day = tonumber(redis.call("GET", KEYS[1]) or "0")
+local week = tonumber(redis.call("GET", KEYS[2]) or "0")
+local daily = tonumber(ARGV[1])
+local weekly = tonumber(ARGV[2])
+if (daily > 0 and day >= daily) or (weekly > 0 and week >= weekly) then
+	return {0, day, week}
+end
+day = redis.call("INCR", KEYS[1])
+redis.call("PEXPIREAT", KEYS[1], ARGV[3])
+week = redis.call("INCR", KEYS[2])
+redis.call("PEXPIREAT", KEYS[2], ARGV[4])
+return {1, day, week}
+`)
+
+// releaseQuotaScript decrements the quota counters that are above zero.
+var releaseQuotaScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if tonumber(redis.call("GET", key) or "0") > 0 then
+		redis.call("DECR", key)
+	end
+end
+return 0
+`)
+
+// redisStore keeps rate limit windows, build quotas and captcha sessions in
+// Redis under prefix, so every backend pointed at the same Redis shares
+// them.
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// redisCaptchaSession is the stored form of a captchaSession; Redis expires
+// the key itself.
+type redisCaptchaSession struct {
+	Host      string    `json:"host"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newRedisStore(rawURL string, prefix string) (*redisStore, error) {
+	options, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse APP_REDIS_URL: %w", err)
+	}
+	return &redisStore{client: redis.NewClient(options), prefix: prefix}, nil
+}
+
+func (r *redisStore) name() string {
+	return "redis"
+}
+
+func (r *redisStore) countRequest(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (requestWindow, error) {
+	values, err := slidingWindowScript.Run(ctx, r.client, []string{r.prefix + "rate:" + key},
+		now.UnixMilli(), window.Milliseconds(), limit, strconv.FormatInt(now.UnixNano(), 36)+generateRequestID()).Slice()
+	if err != nil {
+		return requestWindow{}, fmt.Errorf("redis rate limit: %w", err)
+	}
+	if len(values) != 3 {
+		return requestWindow{}, fmt.Errorf("redis rate limit: unexpected reply %v", values)
+	}
+	allowed, _ := values[0].(int64)
+	count, _ := values[1].(int64)
+	oldestText, _ := values[2].(string)
+	oldest, err := strconv.ParseFloat(oldestText, 64)
+	if err != nil {
+		return requestWindow{}, fmt.Errorf("redis rate limit: unexpected score %q", oldestText)
+	}
+
+	result := requestWindow{allowed: allowed == 1, count: int(count)}
+	if count > 0 {
+		result.oldest = time.UnixMilli(int64(oldest)).UTC()
+	}
+	return result, nil
+}
+
+func (r *redisStore) getQuota(ctx context.Context, key string, dayStart time.Time, weekStart time.Time) (quotaUsage, error) {
+	values, err := r.client.MGet(ctx, r.quotaKeys(key, dayStart, weekStart)...).Result()
+	if err != nil {
+		return quotaUsage{}, fmt.Errorf("redis quota: %w", err)
+	}
+	usage := quotaUsage{dayStart: dayStart, weekStart: weekStart}
+	for i, value := range values {
+		text, _ := value.(string)
+		count, _ := strconv.Atoi(text)
+		if i == 0 {
+			usage.dayUsed = count
+		} else {
+			usage.weekUsed = count
+		}
+	}
+	return usage, nil
+}
+
+func (r *redisStore) reserveQuota(ctx context.Context, key string, daily int, weekly int, dayStart time.Time, weekStart time.Time) (quotaUsage, bool, error) {
+	values, err := reserveQuotaScript.Run(ctx, r.client, r.quotaKeys(key, dayStart, weekStart),
+		daily, weekly, dayStart.Add(quotaDay).UnixMilli(), weekStart.Add(quotaWeek).UnixMilli()).Int64Slice()
+	if err != nil {
+		return quotaUsage{}, false, fmt.Errorf("redis quota: %w", err)
+	}
+	if len(values) != 3 {
+		return quotaUsage{}, false, fmt.Errorf("redis quota: unexpected reply %v", values)
+	}
+	usage := quotaUsage{dayStart: dayStart, dayUsed: int(values[1]), weekStart: weekStart, weekUsed: int(values[2])}
+	return usage, values[0] == 1, nil
+}
+
+func (r *redisStore) releaseQuota(ctx context.Context, key string, dayStart time.Time, weekStart time.Time) error {
+	if err := releaseQuotaScript.Run(ctx, r.client, r.quotaKeys(key, dayStart, weekStart)).Err(); err != nil {
+		return fmt.Errorf("redis quota: %w", err)
+	}
+	return nil
+}
+
+// quotaKeys are the day and week counters of key; each window gets its own
+// keys, so a new window starts from zero.
+func (r *redisStore) quotaKeys(key string, dayStart time.Time, weekStart time.Time) []string {
+	return []string{
+		r.prefix + "quota:day:" + strconv.FormatInt(dayStart.Unix(), 10) + ":" + key,
+		r.prefix + "quota:week:" + strconv.FormatInt(weekStart.Unix(), 10) + ":" + key,
+	}
+}
+
+func (r *redisStore) putCaptchaSession(ctx context.Context, token string, session captchaSession) error {
+	payload, err := json.Marshal(redisCaptchaSession{Host: session.host, ExpiresAt: session.expiresAt})
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(session.expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := r.client.Set(ctx, r.captchaSessionKey(token), payload, ttl).Err(); err != nil {
+		return fmt.Errorf("redis captcha session: %w", err)
+	}
+	return nil
+}
+
+func (r *redisStore) getCaptchaSession(ctx context.Context, token string, now time.Time) (captchaSession, bool, error) {
+	payload, err := r.client.Get(ctx, r.captchaSessionKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return captchaSession{}, false, nil
+	}
+	if err != nil {
+		return captchaSession{}, false, fmt.Errorf("redis captcha session: %w", err)
+	}
+
+	var stored redisCaptchaSession
+	if err := json.Unmarshal(payload, &stored); err != nil {
+		return captchaSession{}, false, fmt.Errorf("redis captcha session: %w", err)
+	}
+	if now.After(stored.ExpiresAt) {
+		return captchaSession{}, false, nil
+	}
+	return captchaSession{host: stored.Host, expiresAt: stored.ExpiresAt}, true, nil
+}
+
+func (r *redisStore) deleteCaptchaSession(ctx context.Context, token string) error {
+	if err := r.client.Del(ctx, r.captchaSessionKey(token)).Err(); err != nil {
+		return fmt.Errorf("redis captcha session: %w", err)
+	}
+	return nil
+}
+
+func (r *redisStore) ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *redisStore) captchaSessionKey(token string) string {
+	return r.prefix + "captcha-session:" + token
+}
@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestRedisStoreSharedBetweenServers(t *testing.T) {
+	t.Parallel()
+
+	redisServer := miniredis.RunT(t)
+	cfg := config.Config{
+		BuildRateLimit: 2,
+		RedisURL:       "redis://" + redisServer.Addr() + "/0",
+		RedisKeyPrefix: "test:",
+	}
+	first := NewServer(cfg, nil, slog.New(slog.DiscardHandler))
+	second := NewServer(cfg, nil, slog.New(slog.DiscardHandler))
+	if first.store.name() != "redis" {
+		t.Fatalf("expected the redis store, got %s", first.store.name())
+	}
+	ctx := context.Background()
+
+	if quota := first.allowBuildRequest(ctx, "203.0.113.9", cfg.BuildRateLimit); !quota.Allowed || quota.Remaining != 1 {
+		t.Fatalf("unexpected first quota: %+v", quota)
+	}
+	if quota := second.allowBuildRequest(ctx, "203.0.113.9", cfg.BuildRateLimit); !quota.Allowed || quota.Remaining != 0 {
+		t.Fatalf("expected the second backend to see the first request: %+v", quota)
+	}
+	quota := first.allowBuildRequest(ctx, "203.0.113.9", cfg.BuildRateLimit)
+	if quota.Allowed || quota.Reset.Before(time.Now().Add(50*time.Second)) {
+		t.Fatalf("expected the shared limit to refuse a third request: %+v", quota)
+	}
+	if quota := second.allowBuildRequest(ctx, "198.51.100.1", cfg.BuildRateLimit); !quota.Allowed {
+		t.Fatalf("expected other clients to have their own window: %+v", quota)
+	}
+	if ttl := redisServer.TTL("test:rate:203.0.113.9"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected the window key to expire within a minute, got %v", ttl)
+	}
+
+	token, err := first.createCaptchaSession(ctx, "203.0.113.9:1000")
+	if err != nil {
+		t.Fatalf("createCaptchaSession failed: %v", err)
+	}
+	if err := second.validateCaptchaSession(ctx, "203.0.113.9:2000", token); err != nil {
+		t.Fatalf("expected the session to be valid on another backend: %v", err)
+	}
+	if err := second.validateCaptchaSession(ctx, "198.51.100.1:2000", token); err == nil {
+		t.Fatalf("expected the session to stay bound to its client")
+	}
+	if err := first.validateCaptchaSession(ctx, "203.0.113.9:1000", token); err == nil {
+		t.Fatalf("expected a session used from another client to be revoked")
+	}
+
+	token, err = first.createCaptchaSession(ctx, "203.0.113.9:1000")
+	if err != nil {
+		t.Fatalf("createCaptchaSession failed: %v", err)
+	}
+	redisServer.FastForward(captchaSessionTTL + time.Second)
+	if err := first.validateCaptchaSession(ctx, "203.0.113.9:1000", token); err == nil {
+		t.Fatalf("expected the session to expire in redis")
+	}
+}
+
+func TestRedisStoreSharesQuotas(t *testing.T) {
+	t.Parallel()
+
+	redisServer := miniredis.RunT(t)
+	cfg := config.Config{
+		BuildQuotaDaily:  1,
+		BuildQuotaWeekly: 2,
+		RedisURL:         "redis://" + redisServer.Addr() + "/0",
+		RedisKeyPrefix:   "test:",
+	}
+	first := NewServer(cfg, nil, slog.New(slog.DiscardHandler))
+	second := NewServer(cfg, nil, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	if status, ok := first.reserveQuota(ctx, "user:alice"); !ok || status.Daily.Remaining != 0 || status.Weekly.Remaining != 1 {
+		t.Fatalf("unexpected first quota: ok=%v %+v", ok, status)
+	}
+	if status, ok := second.reserveQuota(ctx, "user:alice"); ok || status.Daily.Used != 1 || status.Weekly.Used != 1 {
+		t.Fatalf("expected the second backend to see the used daily quota: ok=%v %+v %+v", ok, status.Daily, status.Weekly)
+	}
+	second.releaseQuota(ctx, "user:alice")
+	if status := first.clientQuota(ctx, "user:alice"); status.Daily.Used != 0 || status.Weekly.Used != 0 {
+		t.Fatalf("expected the released build to be returned everywhere: %+v %+v", status.Daily, status.Weekly)
+	}
+	second.releaseQuota(ctx, "user:alice")
+	if status := first.clientQuota(ctx, "user:alice"); status.Daily.Used != 0 {
+		t.Fatalf("expected usage not to go below zero: %+v", status.Daily)
+	}
+
+	first.reserveQuota(ctx, "user:alice")
+	dayStart, weekStart := quotaWindows(time.Now())
+	dayKey := "test:quota:day:" + strconv.FormatInt(dayStart.Unix(), 10) + ":user:alice"
+	weekKey := "test:quota:week:" + strconv.FormatInt(weekStart.Unix(), 10) + ":user:alice"
+	if ttl := redisServer.TTL(dayKey); ttl <= 0 || ttl > quotaDay {
+		t.Fatalf("expected the day counter to expire with its day, got %v", ttl)
+	}
+	if ttl := redisServer.TTL(weekKey); ttl <= 0 || ttl > quotaWeek {
+		t.Fatalf("expected the week counter to expire with its week, got %v", ttl)
+	}
+}
+
+func TestRedisStoreUnavailable(t *testing.T) {
+	t.Parallel()
+
+	redisServer := miniredis.RunT(t)
+	server := NewServer(config.Config{BuildRateLimit: 1, BuildQuotaDaily: 1, RedisURL: "redis://" + redisServer.Addr() + "/0?max_retries=-1"}, nil, slog.New(slog.DiscardHandler))
+	redisServer.Close()
+
+	ctx := context.Background()
+	if quota := server.allowBuildRequest(ctx, "203.0.113.9", 1); !quota.Allowed {
+		t.Fatalf("expected builds to be allowed while redis is down: %+v", quota)
+	}
+	if _, ok := server.reserveQuota(ctx, "203.0.113.9"); !ok {
+		t.Fatalf("expected builds to be let through the quota while redis is down")
+	}
+	if _, err := server.createCaptchaSession(ctx, "203.0.113.9:1000"); err == nil {
+		t.Fatalf("expected sessions to fail while redis is down")
+	}
+}
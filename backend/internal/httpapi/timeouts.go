@@ -0,0 +1,139 @@
+package httpapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type routeClass int
+
+const (
+	// routeAPI is a JSON request answered from memory or local disk.
+	routeAPI routeClass = iota
+	// routeSlowAPI is a JSON request that clones or queries a remote.
+	routeSlowAPI
+	// routeStream is a long-lived SSE or NDJSON stream.
+	routeStream
+	// routeTransfer is a file download or upload.
+	routeTransfer
+)
+
+// classifyRoute picks the timeout class of a request; r.URL.Path must
+// already be resolved to the unversioned form.
+func classifyRoute(r *http.Request) routeClass {
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/api/jobs/") && (strings.HasSuffix(path, "/stream") || strings.HasSuffix(path, "/logs/ndjson")):
+		return routeStream
+	case strings.HasPrefix(path, "/api/jobs/") && (strings.Contains(path, "/artifacts/") || strings.HasSuffix(path, "/artifacts.zip") || strings.HasSuffix(path, "/logs.txt")),
+		strings.HasPrefix(path, "/api/stats/build-logs/") && strings.HasSuffix(path, "/logs.txt"),
+		path == "/api/launcherhub/download",
+		path == "/api/admin/cache/export",
+		path == "/api/admin/cache/import":
+		return routeTransfer
+	case strings.HasPrefix(path, "/api/admin/debug/pprof/"):
+		// CPU profiles and traces take ?seconds=N to record.
+		return routeSlowAPI
+	case r.Method == http.MethodPost && (path == "/api/repos/discover" || path == "/api/repos/refs" ||
+		path == "/api/repos/compare-devices" || path == "/api/jobs" || path == "/api/webhooks/git"):
+		return routeSlowAPI
+	}
+	return routeAPI
+}
+
+// applyRouteTimeouts sets the deadlines of the request's class. JSON
+// requests get a total read/write deadline and a context that aborts the
+// handler when it passes. Streams and transfers have no total limit; instead
+// every read and write must make progress within HTTPStallTimeout, and a
+// stalled client cancels the request context so that the handler stops.
+func (s *Server) applyRouteTimeouts(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, context.CancelFunc) {
+	controller := http.NewResponseController(w)
+
+	switch class := classifyRoute(r); class {
+	case routeStream, routeTransfer:
+		hasBody := r.Body != nil && r.Body != http.NoBody
+		if !hasBody {
+			// Once the body is read, net/http keeps reading the connection in
+			// the background and cancels the request when that read fails, so
+			// any read deadline, including the server's ReadTimeout, would end
+			// the response however fast the client reads it. Stalls show up
+			// as failing writes instead.
+			_ = controller.SetReadDeadline(time.Time{})
+		}
+		if s.cfg.HTTPStallTimeout <= 0 {
+			return w, r, func() {}
+		}
+		ctx, cancel := context.WithCancel(r.Context())
+		r = r.WithContext(ctx)
+		if hasBody {
+			r.Body = &stallReader{ReadCloser: r.Body, controller: controller, stall: s.cfg.HTTPStallTimeout, cancel: cancel}
+		}
+		return &stallWriter{ResponseWriter: w, controller: controller, stall: s.cfg.HTTPStallTimeout, cancel: cancel}, r, cancel
+	default:
+		timeout := s.cfg.HTTPAPITimeout
+		if class == routeSlowAPI {
+			timeout = s.cfg.HTTPSlowAPITimeout
+		}
+		if timeout <= 0 {
+			return w, r, func() {}
+		}
+		deadline := time.Now().Add(timeout)
+		_ = controller.SetReadDeadline(deadline)
+		_ = controller.SetWriteDeadline(deadline)
+		ctx, cancel := context.WithDeadline(r.Context(), deadline)
+		return w, r.WithContext(ctx), cancel
+	}
+}
+
+// stallWriter extends the write deadline before every write, so a response
+// may take as long as the client keeps reading it.
+type stallWriter struct {
+	http.ResponseWriter
+	controller *http.ResponseController
+	stall      time.Duration
+	cancel     context.CancelFunc
+}
+
+func (w *stallWriter) Write(data []byte) (int, error) {
+	_ = w.controller.SetWriteDeadline(time.Now().Add(w.stall))
+	written, err := w.ResponseWriter.Write(data)
+	if err != nil {
+		w.cancel()
+	}
+	return written, err
+}
+
+func (w *stallWriter) Flush() {
+	_ = w.controller.SetWriteDeadline(time.Now().Add(w.stall))
+	if err := w.controller.Flush(); err != nil {
+		w.cancel()
+	}
+}
+
+func (w *stallWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// stallReader extends the read deadline before every read of the body and
+// clears it at the end of the body, for the same reason as bodiless streams.
+type stallReader struct {
+	io.ReadCloser
+	controller *http.ResponseController
+	stall      time.Duration
+	cancel     context.CancelFunc
+}
+
+func (r *stallReader) Read(data []byte) (int, error) {
+	_ = r.controller.SetReadDeadline(time.Now().Add(r.stall))
+	read, err := r.ReadCloser.Read(data)
+	switch {
+	case err == io.EOF:
+		_ = r.controller.SetReadDeadline(time.Time{})
+	case err != nil:
+		r.cancel()
+	}
+	return read, err
+}
@@ -0,0 +1,174 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+)
+
+func TestClassifyRoute(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method string
+		path   string
+		want   routeClass
+	}{
+		{http.MethodGet, "/api/healthz", routeAPI},
+		{http.MethodGet, "/api/jobs/abc", routeAPI},
+		{http.MethodGet, "/api/jobs/abc/artifacts", routeAPI},
+		{http.MethodPost, "/api/repos/discover", routeSlowAPI},
+		{http.MethodPost, "/api/jobs", routeSlowAPI},
+		{http.MethodGet, "/api/admin/debug/pprof/profile", routeSlowAPI},
+		{http.MethodGet, "/api/jobs/abc/logs/stream", routeStream},
+		{http.MethodGet, "/api/jobs/abc/events/stream", routeStream},
+		{http.MethodGet, "/api/jobs/abc/logs/ndjson", routeStream},
+		{http.MethodGet, "/api/jobs/abc/artifacts/def", routeTransfer},
+		{http.MethodGet, "/api/jobs/abc/artifacts.zip", routeTransfer},
+		{http.MethodGet, "/api/jobs/abc/logs.txt", routeTransfer},
+		{http.MethodPost, "/api/admin/cache/import", routeTransfer},
+		{http.MethodGet, "/api/stats/build-logs/abc/logs.txt", routeTransfer},
+	}
+	for _, tc := range cases {
+		if got := classifyRoute(httptest.NewRequest(tc.method, tc.path, nil)); got != tc.want {
+			t.Fatalf("classifyRoute(%s %s) = %d, want %d", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestApplyRouteTimeoutsLimitsJSONRequests(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{
+		HTTPAPITimeout:     30 * time.Second,
+		HTTPSlowAPITimeout: 10 * time.Minute,
+		HTTPStallTimeout:   time.Minute,
+	}, nil, slog.New(slog.DiscardHandler))
+
+	_, request, cancel := server.applyRouteTimeouts(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/healthz", nil))
+	deadline, ok := request.Context().Deadline()
+	cancel()
+	if !ok || time.Until(deadline) > 30*time.Second {
+		t.Fatalf("expected a JSON API deadline within 30s, got %v (set=%t)", deadline, ok)
+	}
+
+	_, request, cancel = server.applyRouteTimeouts(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/repos/discover", nil))
+	deadline, ok = request.Context().Deadline()
+	cancel()
+	if !ok || time.Until(deadline) < 9*time.Minute {
+		t.Fatalf("expected the slow API deadline, got %v (set=%t)", deadline, ok)
+	}
+}
+
+func TestStreamOutlivesStallTimeout(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	cfg := config.Config{
+		JobsRootPath:       filepath.Join(workDir, "jobs"),
+		MaxLogLines:        200,
+		CleanupInterval:    time.Hour,
+		HTTPAPITimeout:     300 * time.Millisecond,
+		HTTPSlowAPITimeout: 300 * time.Millisecond,
+		HTTPStallTimeout:   300 * time.Millisecond,
+	}
+	manager := jobs.NewManager(cfg, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	listener := httptest.NewUnstartedServer(NewServer(cfg, manager, slog.New(slog.DiscardHandler)))
+	// As in production, the server's own timeouts equal the slow API one.
+	listener.Config.ReadTimeout = cfg.HTTPSlowAPITimeout
+	listener.Config.WriteTimeout = cfg.HTTPSlowAPITimeout
+	listener.Start()
+	defer listener.Close()
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", jobs.BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, listener.URL+"/api/jobs/"+state.ID+"/events/stream", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	response, err := listener.Client().Do(request)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer response.Body.Close()
+
+	events := make(chan string, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+				events <- name
+			}
+		}
+	}()
+	if name := <-events; name != "state" {
+		t.Fatalf("expected the initial state, got %q", name)
+	}
+
+	// Nothing is written for several stall timeouts; the client is still
+	// reading, so the stream must stay open.
+	time.Sleep(4 * cfg.HTTPStallTimeout)
+	if _, err := manager.CancelJob(state.ID); err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	var received []string
+	for name := range events {
+		received = append(received, name)
+	}
+	if len(received) == 0 || received[len(received)-1] != "done" {
+		t.Fatalf("expected the stream to last until the job ended, got events %q", received)
+	}
+}
+
+func TestStalledDownloadCancelsRequest(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{HTTPStallTimeout: 200 * time.Millisecond}, nil, slog.New(slog.DiscardHandler))
+	stopped := make(chan error, 1)
+	listener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w, r, cancel := server.applyRouteTimeouts(w, r)
+		defer cancel()
+		chunk := make([]byte, 64*1024)
+		for {
+			if _, err := w.Write(chunk); err != nil {
+				<-r.Context().Done()
+				stopped <- err
+				return
+			}
+		}
+	}))
+	defer listener.Close()
+
+	response, err := listener.Client().Get(listener.URL + "/api/jobs/abc/artifacts.zip")
+	if err != nil {
+		t.Fatalf("start download: %v", err)
+	}
+	defer response.Body.Close()
+
+	// The client never reads the body, so the writes stall.
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("expected the write deadline to end the download, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("a stalled client must cancel the request")
+	}
+}
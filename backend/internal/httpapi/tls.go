@@ -0,0 +1,152 @@
+package httpapi
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// certReloadInterval bounds how often the certificate files are checked for
+// changes, e.g. after a certbot renewal.
+const certReloadInterval = time.Minute
+
+// NewTLSConfig returns the TLS configuration for serving HTTPS and HTTP/2
+// directly, or nil when TLS is terminated elsewhere. The returned handler
+// serves the plain HTTP redirect port: ACME http-01 challenges when autocert
+// is used, and redirects to HTTPS for everything else.
+func NewTLSConfig(cfg config.Config, logger *slog.Logger) (*tls.Config, http.Handler, error) {
+	redirect := httpsRedirectHandler(cfg.Port)
+
+	var tlsConfig *tls.Config
+	switch {
+	case cfg.TLSCertFile != "":
+		reloader, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig = &tls.Config{GetCertificate: reloader.getCertificate}
+	case len(cfg.TLSAutocertDomains) > 0:
+		if err := os.MkdirAll(cfg.TLSAutocertCacheDir, 0o700); err != nil {
+			return nil, nil, fmt.Errorf("create autocert cache: %w", err)
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+			Email:      cfg.TLSAutocertEmail,
+		}
+		tlsConfig = manager.TLSConfig()
+		redirect = manager.HTTPHandler(redirect)
+	default:
+		return nil, nil, nil
+	}
+
+	tlsConfig.MinVersion = tls.VersionTLS12
+	for _, proto := range []string{"h2", "http/1.1"} {
+		if !slices.Contains(tlsConfig.NextProtos, proto) {
+			tlsConfig.NextProtos = append(tlsConfig.NextProtos, proto)
+		}
+	}
+	if cfg.AdminClientCAs != nil {
+		// Client certificates are optional; requireAdminAuth checks them
+		// for /api/admin/* only.
+		tlsConfig.ClientCAs = cfg.AdminClientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, redirect, nil
+}
+
+func httpsRedirectHandler(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		host = strings.Trim(host, "[]")
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// certReloader serves a certificate from disk and reloads it when the
+// files change, so renewed certificates apply without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile string, keyFile string, logger *slog.Logger) (*certReloader, error) {
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := reloader.load(time.Now()); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.checkedAt) >= certReloadInterval {
+		if err := c.load(now); err != nil {
+			c.logger.Error("tls: keep current certificate", "error", err)
+		}
+	}
+	return c.cert, nil
+}
+
+// load re-reads the key pair when either file is newer than the loaded one.
+func (c *certReloader) load(now time.Time) error {
+	c.checkedAt = now
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	if c.cert != nil && !modTime.After(c.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS key pair: %w", err)
+	}
+	c.cert = &cert
+	c.modTime = modTime
+	return nil
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
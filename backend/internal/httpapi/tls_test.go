@@ -0,0 +1,164 @@
+package httpapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// writeSelfSignedPair writes a self-signed certificate for localhost and its
+// key as PEM files and returns the certificate.
+func writeSelfSignedPair(t *testing.T, certPath string, keyPath string, commonName string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return certificate
+}
+
+func TestNewTLSConfigServesHTTP2(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certificate := writeSelfSignedPair(t, certPath, keyPath, "first")
+
+	cfg := config.Config{Port: 8443, TLSCertFile: certPath, TLSKeyFile: keyPath}
+	tlsConfig, _, err := NewTLSConfig(cfg, slog.New(slog.DiscardHandler))
+	if err != nil || tlsConfig == nil {
+		t.Fatalf("NewTLSConfig: config=%v err=%v", tlsConfig, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &http.Server{Handler: NewServer(cfg, nil, slog.New(slog.DiscardHandler)), TLSConfig: tlsConfig}
+	go server.ServeTLS(listener, "", "")
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "localhost"},
+		ForceAttemptHTTP2: true,
+	}}
+	response, err := client.Get("https://" + listener.Addr().String() + "/api/livez")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.ProtoMajor != 2 {
+		t.Fatalf("unexpected response: status=%d proto=%s", response.StatusCode, response.Proto)
+	}
+}
+
+func TestCertReloaderPicksUpRenewedCertificate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeSelfSignedPair(t, certPath, keyPath, "first")
+
+	reloader, err := newCertReloader(certPath, keyPath, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+
+	writeSelfSignedPair(t, certPath, keyPath, "renewed")
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{certPath, keyPath} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatalf("touch %s: %v", path, err)
+		}
+	}
+
+	commonName := func() string {
+		certificate, err := reloader.getCertificate(nil)
+		if err != nil {
+			t.Fatalf("getCertificate: %v", err)
+		}
+		parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			t.Fatalf("parse certificate: %v", err)
+		}
+		return parsed.Subject.CommonName
+	}
+	if got := commonName(); got != "first" {
+		t.Fatalf("files must not be re-read within the reload interval, got %q", got)
+	}
+
+	reloader.mu.Lock()
+	reloader.checkedAt = time.Now().Add(-certReloadInterval)
+	reloader.mu.Unlock()
+	if got := commonName(); got != "renewed" {
+		t.Fatalf("expected the renewed certificate, got %q", got)
+	}
+}
+
+func TestHTTPSRedirectHandler(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		port int
+		host string
+		want string
+	}{
+		{443, "builder.example.com", "https://builder.example.com/api/jobs?x=1"},
+		{443, "builder.example.com:80", "https://builder.example.com/api/jobs?x=1"},
+		{8443, "builder.example.com:8080", "https://builder.example.com:8443/api/jobs?x=1"},
+		{443, "[::1]:80", "https://[::1]/api/jobs?x=1"},
+	}
+	for _, tc := range cases {
+		request := httptest.NewRequest(http.MethodGet, "/api/jobs?x=1", nil)
+		request.Host = tc.host
+		recorder := httptest.NewRecorder()
+		httpsRedirectHandler(tc.port).ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusMovedPermanently || recorder.Header().Get("Location") != tc.want {
+			t.Fatalf("redirect of %s to port %d: status=%d location=%q, want %q", tc.host, tc.port, recorder.Code, recorder.Header().Get("Location"), tc.want)
+		}
+	}
+}
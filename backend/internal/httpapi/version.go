@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildinfo"
+)
+
+// baseCapabilities are API features every backend of this version serves.
+var baseCapabilities = []string{
+	"artifacts-zip",
+	"etag",
+	"job-events-stream",
+	"log-stream",
+	"openapi",
+	"patches",
+	"rate-limit-headers",
+	"service-stats",
+	"sizediff",
+	"submodule-overrides",
+	"symbolic-refs",
+}
+
+type versionResponse struct {
+	Version      string   `json:"version"`
+	Commit       string   `json:"commit,omitempty"`
+	BuildTime    string   `json:"buildTime,omitempty"`
+	GoVersion    string   `json:"goVersion"`
+	BuilderImage string   `json:"builderImage"`
+	APIVersions  []string `json:"apiVersions"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// handleVersion describes this backend so that frontends and cluster peers
+// can feature-detect: capabilities lists the always-available features plus
+// those enabled by configuration.
+func (s *Server) handleVersion(w http.ResponseWriter, requestID string) {
+	s.writeSuccess(w, http.StatusOK, requestID, versionResponse{
+		Version:      strings.TrimSpace(buildinfo.Version),
+		Commit:       strings.TrimSpace(buildinfo.Commit),
+		BuildTime:    strings.TrimSpace(buildinfo.BuildTime),
+		GoVersion:    runtime.Version(),
+		BuilderImage: s.cfg.BuilderImage,
+		APIVersions:  []string{apiVersion},
+		Capabilities: s.capabilities(),
+	})
+}
+
+func (s *Server) capabilities() []string {
+	capabilities := append([]string(nil), baseCapabilities...)
+	optional := []struct {
+		name    string
+		enabled bool
+	}{
+		{"admin", s.cfg.AdminToken != "" || s.cfg.AdminClientCAs != nil},
+		{"artifact-github-releases", s.cfg.ArtifactGitHubRepo != ""},
+		{"artifact-s3", s.cfg.ArtifactS3Bucket != ""},
+		{"audit-log", s.audit != nil},
+		{"build-moderation", s.cfg.ModerateAnonymousBuilds},
+		{"captcha", s.cfg.RequireCaptcha},
+		{"captcha-session-cookie", s.cfg.RequireCaptcha && s.cfg.CaptchaSessionCookie},
+		{"device-catalog", len(s.cfg.FeaturedRepos) > 0},
+		{"credential-store", s.manager != nil && s.manager.Secrets() != nil},
+		{"git-webhooks", len(s.webhookSecrets()) > 0},
+		{"grpc", s.cfg.GRPCPort > 0},
+		{"idempotency-key", s.cfg.IdempotencyWindow > 0},
+		{"job-registry", s.cfg.JobRegistry},
+		{"job-notifications", s.cfg.NotifyPerJob},
+		{"oidc-login", s.auth != nil},
+		{"proof-of-work", s.cfg.RequireCaptcha && s.cfg.PoWEnabled},
+		{"stats", s.cfg.StatsPassword != ""},
+		{"tag-signatures", s.cfg.TagSignatureMode != ""},
+	}
+	for _, capability := range optional {
+		if capability.enabled {
+			capabilities = append(capabilities, capability.name)
+		}
+	}
+	sort.Strings(capabilities)
+	return capabilities
+}
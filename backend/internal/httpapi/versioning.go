@@ -0,0 +1,36 @@
+package httpapi
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// apiVersion is the current API version. Routes are served under
+// /api/v1/; the unversioned /api/ paths stay as aliases of the current
+// version so existing frontends keep working.
+const (
+	apiVersion       = "1"
+	apiVersionPrefix = "/api/v" + apiVersion
+)
+
+var versionedPathPattern = regexp.MustCompile(`^/api/v[0-9]+(/|$)`)
+
+// resolveAPIVersion maps a versioned path onto the internal unversioned
+// route. Versions this server does not implement get 404 so clients can
+// detect an older backend and fall back.
+func (s *Server) resolveAPIVersion(w http.ResponseWriter, r *http.Request, requestID string) bool {
+	w.Header().Set("X-API-Version", apiVersion)
+
+	path := r.URL.Path
+	if path == apiVersionPrefix || strings.HasPrefix(path, apiVersionPrefix+"/") {
+		r.URL.Path = "/api" + strings.TrimPrefix(path, apiVersionPrefix)
+		r.URL.RawPath = ""
+		return true
+	}
+	if versionedPathPattern.MatchString(path) {
+		s.writeError(w, http.StatusNotFound, requestID, "UNSUPPORTED_API_VERSION", "API version is not supported", map[string]any{"supported": []string{apiVersion}})
+		return false
+	}
+	return true
+}
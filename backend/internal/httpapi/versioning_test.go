@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
+
+	for _, path := range []string{"/api/v1/healthz", "/api/healthz"} {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status for %s: got=%d want=%d", path, recorder.Code, http.StatusOK)
+		}
+		if got := recorder.Header().Get("X-API-Version"); got != apiVersion {
+			t.Fatalf("unexpected X-API-Version for %s: got=%q want=%q", path, got, apiVersion)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v2/healthz", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status for unsupported version: got=%d want=%d", recorder.Code, http.StatusNotFound)
+	}
+	var envelope struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil || envelope.Error.Code != "UNSUPPORTED_API_VERSION" {
+		t.Fatalf("unexpected error code: got=%q err=%v", envelope.Error.Code, err)
+	}
+}
+
+func TestHandleVersion(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{BuilderImage: "builder:test", GRPCPort: 9090, IdempotencyWindow: time.Hour}, nil, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+
+	var payload struct {
+		Data versionResponse `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if payload.Data.BuilderImage != "builder:test" || payload.Data.GoVersion == "" || !reflect.DeepEqual(payload.Data.APIVersions, []string{apiVersion}) {
+		t.Fatalf("unexpected version response: %+v", payload.Data)
+	}
+	capabilities := strings.Join(payload.Data.Capabilities, ",")
+	for _, want := range []string{"grpc", "idempotency-key", "openapi"} {
+		if !strings.Contains(capabilities, want) {
+			t.Fatalf("missing capability %q in %v", want, payload.Data.Capabilities)
+		}
+	}
+	if strings.Contains(capabilities, "admin") || strings.Contains(capabilities, "captcha") {
+		t.Fatalf("disabled features must not be advertised: %v", payload.Data.Capabilities)
+	}
+	if !sort.StringsAreSorted(payload.Data.Capabilities) {
+		t.Fatalf("capabilities must be sorted: %v", payload.Data.Capabilities)
+	}
+}
@@ -0,0 +1,277 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/audit"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/jobs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
+)
+
+// maxWebhookBodyBytes bounds webhook payloads; push events of large
+// merges can be several megabytes.
+const maxWebhookBodyBytes = 8 << 20
+
+var errWebhookSignature = errors.New("webhook signature is invalid")
+
+// gitWebhookEvent is a push or release reported by GitHub or GitLab,
+// reduced to what a build needs. Ignored explains why no build is queued.
+type gitWebhookEvent struct {
+	Provider string
+	Name     string
+	RepoURL  string
+	Ref      string
+	Ignored  string
+}
+
+type githubWebhookPayload struct {
+	Ref     string `json:"ref"`
+	After   string `json:"after"`
+	Deleted bool   `json:"deleted"`
+	Action  string `json:"action"`
+	Release struct {
+		TagName string `json:"tag_name"`
+	} `json:"release"`
+	Repository struct {
+		CloneURL      string `json:"clone_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+}
+
+type gitlabWebhookPayload struct {
+	Ref         string `json:"ref"`
+	CheckoutSHA string `json:"checkout_sha"`
+	Action      string `json:"action"`
+	Tag         string `json:"tag"`
+	Project     struct {
+		GitHTTPURL    string `json:"git_http_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"project"`
+}
+
+// parseGitWebhook authenticates a GitHub (X-Hub-Signature-256) or GitLab
+// (X-Gitlab-Token) delivery against any of secrets and extracts the ref to
+// build: the pushed commit for pushes to the default branch, the tag for
+// published releases.
+func parseGitWebhook(header http.Header, body []byte, webhookSecrets []string) (gitWebhookEvent, error) {
+	if name := header.Get("X-GitHub-Event"); name != "" {
+		for _, secret := range webhookSecrets {
+			expected := hmac.New(sha256.New, []byte(secret))
+			expected.Write(body)
+			signature := "sha256=" + hex.EncodeToString(expected.Sum(nil))
+			if subtle.ConstantTimeCompare([]byte(header.Get("X-Hub-Signature-256")), []byte(signature)) == 1 {
+				return parseGitHubWebhook(name, body)
+			}
+		}
+		return gitWebhookEvent{}, errWebhookSignature
+	}
+	if name := header.Get("X-Gitlab-Event"); name != "" {
+		for _, secret := range webhookSecrets {
+			if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) == 1 {
+				return parseGitLabWebhook(name, body)
+			}
+		}
+		return gitWebhookEvent{}, errWebhookSignature
+	}
+	return gitWebhookEvent{}, errors.New("missing X-GitHub-Event or X-Gitlab-Event header")
+}
+
+func parseGitHubWebhook(name string, body []byte) (gitWebhookEvent, error) {
+	event := gitWebhookEvent{Provider: "github", Name: name}
+	if name == "ping" {
+		event.Ignored = "ping"
+		return event, nil
+	}
+	if name != "push" && name != "release" {
+		event.Ignored = "unsupported event"
+		return event, nil
+	}
+
+	var payload githubWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return gitWebhookEvent{}, err
+	}
+	event.RepoURL = jobs.NormalizeRepoURL(payload.Repository.CloneURL)
+
+	if name == "release" {
+		if payload.Action != "published" {
+			event.Ignored = "release action " + payload.Action
+			return event, nil
+		}
+		event.Ref = payload.Release.TagName
+		return event, nil
+	}
+
+	switch {
+	case payload.Deleted:
+		event.Ignored = "branch deleted"
+	case payload.Ref != "refs/heads/"+payload.Repository.DefaultBranch:
+		event.Ignored = "not the default branch"
+	default:
+		event.Ref = payload.After
+	}
+	return event, nil
+}
+
+func parseGitLabWebhook(name string, body []byte) (gitWebhookEvent, error) {
+	event := gitWebhookEvent{Provider: "gitlab", Name: name}
+	if name != "Push Hook" && name != "Release Hook" {
+		event.Ignored = "unsupported event"
+		return event, nil
+	}
+
+	var payload gitlabWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return gitWebhookEvent{}, err
+	}
+	event.RepoURL = jobs.NormalizeRepoURL(payload.Project.GitHTTPURL)
+
+	if name == "Release Hook" {
+		if payload.Action != "create" {
+			event.Ignored = "release action " + payload.Action
+			return event, nil
+		}
+		event.Ref = payload.Tag
+		return event, nil
+	}
+
+	switch {
+	case payload.CheckoutSHA == "":
+		event.Ignored = "branch deleted"
+	case payload.Ref != "refs/heads/"+payload.Project.DefaultBranch:
+		event.Ignored = "not the default branch"
+	default:
+		event.Ref = payload.CheckoutSHA
+	}
+	return event, nil
+}
+
+type gitWebhookResponse struct {
+	Provider string           `json:"provider"`
+	Event    string           `json:"event"`
+	RepoURL  string           `json:"repoUrl,omitempty"`
+	Ref      string           `json:"ref,omitempty"`
+	Ignored  string           `json:"ignored,omitempty"`
+	Jobs     []webhookJobView `json:"jobs"`
+}
+
+type webhookJobView struct {
+	Device string `json:"device"`
+	JobID  string `json:"jobId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleGitWebhook enqueues the configured builds for pushes to the
+// default branch and published releases. Deliveries that do not lead to a
+// build are acknowledged with the reason, so providers do not retry them.
+func (s *Server) handleGitWebhook(w http.ResponseWriter, r *http.Request, requestID string) {
+	webhookSecrets := s.webhookSecrets()
+	if len(webhookSecrets) == 0 {
+		s.writeError(w, http.StatusNotFound, requestID, "NOT_FOUND", "route not found", nil)
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes+1))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	if len(body) > maxWebhookBodyBytes {
+		s.writeError(w, http.StatusRequestEntityTooLarge, requestID, "PAYLOAD_TOO_LARGE", "webhook payload is too large", nil)
+		return
+	}
+
+	event, err := parseGitWebhook(r.Header, body, webhookSecrets)
+	if errors.Is(err, errWebhookSignature) {
+		s.recordAudit(r, requestID, audit.Event{Action: audit.ActionAuthFailed, Details: map[string]string{"realm": "webhook"}})
+		s.writeError(w, http.StatusUnauthorized, requestID, "INVALID_SIGNATURE", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, requestID, "INVALID_WEBHOOK", err.Error(), nil)
+		return
+	}
+
+	response := gitWebhookResponse{
+		Provider: event.Provider,
+		Event:    event.Name,
+		RepoURL:  event.RepoURL,
+		Ref:      event.Ref,
+		Ignored:  event.Ignored,
+		Jobs:     []webhookJobView{},
+	}
+	devices := []string{}
+	if event.Ignored == "" {
+		devices = s.manager.WebhookDevices(event.RepoURL)
+		if len(devices) == 0 {
+			response.Ignored = "no builds configured for repository"
+		}
+	}
+
+	ip := s.clientIP(r)
+	for _, device := range devices {
+		view := webhookJobView{Device: device}
+		// Webhook builds may clone with the stored git credentials, so they
+		// are private; the token is never handed out and only admins read
+		// them.
+		token, err := randomToken()
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, requestID, "INTERNAL_ERROR", "generate job access token", nil)
+			return
+		}
+		state, err := s.manager.CreateOwnedJob(event.RepoURL, event.Ref, device, jobs.BuildOptions{},
+			jobs.JobOwner{ClientIP: ip, Trusted: true, Private: true, AccessToken: token})
+		if err != nil {
+			view.Error = err.Error()
+		} else {
+			view.JobID = state.ID
+			s.recordAudit(r, requestID, audit.Event{
+				Action:  audit.ActionJobCreated,
+				Actor:   "webhook",
+				JobID:   state.ID,
+				Details: map[string]string{"repo": state.RepoURL, "ref": state.Ref, "device": state.Device, "visibility": jobVisibility(state.Private)},
+			})
+		}
+		response.Jobs = append(response.Jobs, view)
+	}
+	if len(devices) > 0 {
+		s.logger.InfoContext(r.Context(), "webhook: queued builds", "provider", event.Provider, "event", event.Name,
+			"repo", event.RepoURL, "ref", strings.TrimSpace(event.Ref), "builds", len(devices))
+	}
+
+	status := http.StatusOK
+	if len(response.Jobs) > 0 {
+		status = http.StatusAccepted
+	}
+	s.writeSuccess(w, status, requestID, response)
+}
+
+// webhookSecrets returns APP_GIT_WEBHOOK_SECRET and the webhook secrets in
+// the credential store; any of them authenticates a delivery, so a secret
+// can be rotated at the provider before the old one is deleted.
+func (s *Server) webhookSecrets() []string {
+	if s.manager == nil {
+		return nil
+	}
+	var values []string
+	if s.cfg.GitWebhookSecret != "" {
+		values = append(values, s.cfg.GitWebhookSecret)
+	}
+	stored, err := s.manager.Secrets().Secrets(secrets.KindWebhookSecret)
+	if err != nil {
+		s.logger.Error("secrets: read webhook secrets", "error", err)
+	}
+	for _, secret := range stored {
+		values = append(values, secret.Value)
+	}
+	return values
+}
@@ -0,0 +1,89 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestGitWebhookDisabledWithoutSecret(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(config.Config{}, nil, slog.New(slog.DiscardHandler))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/webhooks/git", strings.NewReader("{}"))
+	request.Header.Set("X-GitHub-Event", "ping")
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status without webhook secret: got=%d want=%d", recorder.Code, http.StatusNotFound)
+	}
+}
+
+func TestParseGitWebhookGitHub(t *testing.T) {
+	t.Parallel()
+
+	sign := func(body string) http.Header {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		header := http.Header{}
+		header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		return header
+	}
+
+	push := `{"ref":"refs/heads/master","after":"abc123","repository":{"clone_url":"https://github.com/meshtastic/firmware.git","default_branch":"master"}}`
+	header := sign(push)
+	header.Set("X-GitHub-Event", "push")
+	event, err := parseGitWebhook(header, []byte(push), []string{"secret"})
+	if err != nil {
+		t.Fatalf("parse push failed: %v", err)
+	}
+	if event.Ignored != "" || event.Ref != "abc123" || event.RepoURL != "https://github.com/meshtastic/firmware" {
+		t.Fatalf("unexpected push event: %+v", event)
+	}
+
+	if _, err := parseGitWebhook(header, []byte(push+" "), []string{"secret"}); !errors.Is(err, errWebhookSignature) {
+		t.Fatalf("expected signature error for tampered body: got=%v", err)
+	}
+
+	branch := strings.Replace(push, "refs/heads/master", "refs/heads/feature", 1)
+	header = sign(branch)
+	header.Set("X-GitHub-Event", "push")
+	if event, err = parseGitWebhook(header, []byte(branch), []string{"secret"}); err != nil || event.Ignored == "" {
+		t.Fatalf("expected non-default branch push to be ignored: event=%+v err=%v", event, err)
+	}
+
+	release := `{"action":"published","release":{"tag_name":"v2.6.0"},"repository":{"clone_url":"https://github.com/meshtastic/firmware.git"}}`
+	header = sign(release)
+	header.Set("X-GitHub-Event", "release")
+	if event, err = parseGitWebhook(header, []byte(release), []string{"secret"}); err != nil || event.Ref != "v2.6.0" {
+		t.Fatalf("unexpected release event: event=%+v err=%v", event, err)
+	}
+}
+
+func TestParseGitWebhookGitLab(t *testing.T) {
+	t.Parallel()
+
+	push := `{"ref":"refs/heads/main","checkout_sha":"def456","project":{"git_http_url":"https://gitlab.com/acme/firmware.git","default_branch":"main"}}`
+	header := http.Header{}
+	header.Set("X-Gitlab-Event", "Push Hook")
+	header.Set("X-Gitlab-Token", "wrong")
+	if _, err := parseGitWebhook(header, []byte(push), []string{"secret"}); !errors.Is(err, errWebhookSignature) {
+		t.Fatalf("expected token error: got=%v", err)
+	}
+
+	header.Set("X-Gitlab-Token", "secret")
+	event, err := parseGitWebhook(header, []byte(push), []string{"secret"})
+	if err != nil || event.Ref != "def456" || event.RepoURL != "https://gitlab.com/acme/firmware" {
+		t.Fatalf("unexpected gitlab push event: event=%+v err=%v", event, err)
+	}
+}
@@ -0,0 +1,237 @@
+package jobs
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+const maxErrorSignatureLength = 200
+
+// errorSignatureNumbers matches the parts of a build error that differ
+// between otherwise identical failures: job IDs, hashes, ports and counts.
+var errorSignatureNumbers = regexp.MustCompile(`[0-9a-f]*[0-9][0-9a-f]*`)
+
+// ClientAbuseReport summarizes the builds of one client within the abuse
+// window. RepeatedError is the most frequent failure with numbers masked.
+type ClientAbuseReport struct {
+	Client        string     `json:"client"`
+	Builds        int        `json:"builds"`
+	Failures      int        `json:"failures"`
+	RepeatedError string     `json:"repeatedError,omitempty"`
+	Repeats       int        `json:"repeats"`
+	Flagged       bool       `json:"flagged"`
+	FlaggedAt     *time.Time `json:"flaggedAt,omitempty"`
+	FlaggedUntil  *time.Time `json:"flaggedUntil,omitempty"`
+}
+
+type buildOutcome struct {
+	at        time.Time
+	signature string // "" for a successful build
+}
+
+type clientOutcomes struct {
+	outcomes     []buildOutcome
+	flaggedAt    time.Time
+	flaggedUntil time.Time
+}
+
+// abuseTracker watches how the builds of each client end. A client whose
+// builds keep failing with the same error, as when a bot fuzzes refs and
+// build flags, is flagged so the API can slow it down.
+type abuseTracker struct {
+	mu          sync.Mutex
+	minFailures int
+	ratio       float64
+	window      time.Duration
+	clients     map[string]*clientOutcomes
+}
+
+func newAbuseTracker(cfg config.Config) *abuseTracker {
+	if cfg.AbuseMinFailures <= 0 {
+		return nil
+	}
+	return &abuseTracker{
+		minFailures: cfg.AbuseMinFailures,
+		ratio:       cfg.AbuseFailureRatio,
+		window:      cfg.AbuseWindow,
+		clients:     make(map[string]*clientOutcomes),
+	}
+}
+
+// errorSignature reduces a build error to the part that repeats between
+// identical failures.
+func errorSignature(message string) string {
+	message, _, _ = strings.Cut(strings.TrimSpace(message), "\n")
+	message = errorSignatureNumbers.ReplaceAllString(strings.ToLower(message), "#")
+	if len(message) > maxErrorSignatureLength {
+		message = message[:maxErrorSignatureLength]
+	}
+	return message
+}
+
+// record adds a finished build of client; failure is the build error, or
+// "" for a successful build. It reports whether the client became flagged.
+func (t *abuseTracker) record(client string, now time.Time, failure string) bool {
+	if t == nil || client == "" {
+		return false
+	}
+	outcome := buildOutcome{at: now}
+	if failure != "" {
+		outcome.signature = errorSignature(failure)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cleanupLocked(now)
+	entry := t.clients[client]
+	if entry == nil {
+		entry = &clientOutcomes{}
+		t.clients[client] = entry
+	}
+	entry.outcomes = append(entry.outcomes, outcome)
+	if failure == "" {
+		return false
+	}
+
+	report := t.reportLocked(client, entry, now)
+	if report.Repeats < t.minFailures || float64(report.Failures) < t.ratio*float64(report.Builds) {
+		return false
+	}
+	wasFlagged := report.Flagged
+	if !wasFlagged {
+		entry.flaggedAt = now
+	}
+	entry.flaggedUntil = now.Add(t.window)
+	return !wasFlagged
+}
+
+func (t *abuseTracker) flagged(client string, now time.Time) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.clients[client]
+	return entry != nil && now.Before(entry.flaggedUntil)
+}
+
+// clear lifts the flag of client and forgets its builds.
+func (t *abuseTracker) clear(client string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.clients[client]; !ok {
+		return false
+	}
+	delete(t.clients, client)
+	return true
+}
+
+// report lists flagged clients first, then the others with failed builds,
+// most failures first.
+func (t *abuseTracker) report(now time.Time) []ClientAbuseReport {
+	reports := make([]ClientAbuseReport, 0)
+	if t == nil {
+		return reports
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cleanupLocked(now)
+	for client, entry := range t.clients {
+		if report := t.reportLocked(client, entry, now); report.Flagged || report.Failures > 0 {
+			reports = append(reports, report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Flagged != reports[j].Flagged {
+			return reports[i].Flagged
+		}
+		if reports[i].Failures != reports[j].Failures {
+			return reports[i].Failures > reports[j].Failures
+		}
+		return reports[i].Client < reports[j].Client
+	})
+	return reports
+}
+
+func (t *abuseTracker) reportLocked(client string, entry *clientOutcomes, now time.Time) ClientAbuseReport {
+	report := ClientAbuseReport{Client: client, Builds: len(entry.outcomes)}
+	counts := make(map[string]int)
+	for _, outcome := range entry.outcomes {
+		if outcome.signature == "" {
+			continue
+		}
+		report.Failures++
+		counts[outcome.signature]++
+		count := counts[outcome.signature]
+		if count > report.Repeats || (count == report.Repeats && outcome.signature < report.RepeatedError) {
+			report.RepeatedError, report.Repeats = outcome.signature, count
+		}
+	}
+	if now.Before(entry.flaggedUntil) {
+		flaggedAt, flaggedUntil := entry.flaggedAt, entry.flaggedUntil
+		report.Flagged, report.FlaggedAt, report.FlaggedUntil = true, &flaggedAt, &flaggedUntil
+	}
+	return report
+}
+
+// cleanupLocked drops builds older than the window and clients with
+// neither builds nor an active flag.
+func (t *abuseTracker) cleanupLocked(now time.Time) {
+	threshold := now.Add(-t.window)
+	for client, entry := range t.clients {
+		kept := entry.outcomes[:0]
+		for _, outcome := range entry.outcomes {
+			if outcome.at.After(threshold) {
+				kept = append(kept, outcome)
+			}
+		}
+		entry.outcomes = kept
+		if len(kept) == 0 && !now.Before(entry.flaggedUntil) {
+			delete(t.clients, client)
+		}
+	}
+}
+
+// abuseClientKey identifies the creator of a job the same way the API
+// counts build requests: per signed-in account, otherwise per address.
+func abuseClientKey(state State) string {
+	if state.UserID != "" {
+		return "user:" + state.UserID
+	}
+	return state.ClientIP
+}
+
+// recordBuildOutcome counts a finished build towards abuse detection;
+// failure is "" for a successful build.
+func (m *Manager) recordBuildOutcome(job *Job, failure string) {
+	state := job.snapshot()
+	client := abuseClientKey(state)
+	if m.abuse.record(client, m.now(), failure) {
+		m.logger.Warn("abuse: client flagged, builds keep failing", "client", client, "failure", errorSignature(failure))
+	}
+}
+
+// AbuseFlagged reports whether client, a build rate limit key, is flagged
+// for repeatedly failing builds.
+func (m *Manager) AbuseFlagged(client string) bool {
+	return m.abuse.flagged(client, m.now())
+}
+
+// AbuseReport lists the clients with failed builds within the abuse window.
+func (m *Manager) AbuseReport() []ClientAbuseReport {
+	return m.abuse.report(m.now())
+}
+
+// ClearAbuse lifts the flag of client. It returns false when the client
+// is not tracked.
+func (m *Manager) ClearAbuse(client string) bool {
+	return m.abuse.clear(client)
+}
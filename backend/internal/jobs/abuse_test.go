@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestErrorSignatureMasksNumbers(t *testing.T) {
+	t.Parallel()
+
+	first := errorSignature("resolve ref: git ls-remote exited 128 for job 3f9a1c0d\ndetails")
+	second := errorSignature("Resolve ref: git ls-remote exited 128 for job 77b2e4aa")
+	if first != second || first != "resolve ref: git ls-remote exited # for job #" {
+		t.Fatalf("expected identical signatures, got %q and %q", first, second)
+	}
+}
+
+func TestAbuseTrackerFlagsRepeatedFailures(t *testing.T) {
+	t.Parallel()
+
+	tracker := newAbuseTracker(config.Config{AbuseMinFailures: 3, AbuseFailureRatio: 0.75, AbuseWindow: time.Hour})
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.record("203.0.113.9", now, "")
+	for i := range 2 {
+		if tracker.record("203.0.113.9", now.Add(time.Duration(i+1)*time.Minute), "ref not found: fuzz-"+string(rune('0'+i))) {
+			t.Fatalf("flagged after %d failures", i+1)
+		}
+	}
+	// A different error does not count towards the repeats.
+	if tracker.record("203.0.113.9", now.Add(3*time.Minute), "docker build exited") {
+		t.Fatalf("flagged for a different error")
+	}
+	if !tracker.record("203.0.113.9", now.Add(4*time.Minute), "ref not found: fuzz-7") {
+		t.Fatalf("expected the third identical failure to flag the client")
+	}
+	if tracker.record("203.0.113.9", now.Add(5*time.Minute), "ref not found: fuzz-8") {
+		t.Fatalf("an already flagged client must not be reported as newly flagged")
+	}
+	if !tracker.flagged("203.0.113.9", now.Add(5*time.Minute)) || tracker.flagged("198.51.100.1", now) {
+		t.Fatalf("unexpected flags")
+	}
+
+	// A client whose builds mostly succeed is not flagged.
+	for i := range 4 {
+		tracker.record("user:alice", now.Add(time.Duration(i)*time.Minute), "")
+		tracker.record("user:alice", now.Add(time.Duration(i)*time.Minute), "ref not found: v1")
+	}
+	if tracker.flagged("user:alice", now.Add(5*time.Minute)) {
+		t.Fatalf("expected a client with successful builds to stay unflagged")
+	}
+
+	reports := tracker.report(now.Add(6 * time.Minute))
+	if len(reports) != 2 || reports[0].Client != "203.0.113.9" || !reports[0].Flagged || reports[1].Flagged {
+		t.Fatalf("unexpected report: %+v", reports)
+	}
+	if got := reports[0]; got.Builds != 6 || got.Failures != 5 || got.Repeats != 4 || got.RepeatedError != "ref not found: fuzz-#" {
+		t.Fatalf("unexpected client report: %+v", got)
+	}
+	if !reports[0].FlaggedUntil.Equal(now.Add(5*time.Minute + time.Hour)) {
+		t.Fatalf("expected the flag to last a window after the last failure, got %v", reports[0].FlaggedUntil)
+	}
+
+	// The flag and the builds expire together after the window.
+	if tracker.flagged("203.0.113.9", now.Add(2*time.Hour)) {
+		t.Fatalf("expected the flag to expire")
+	}
+	if reports := tracker.report(now.Add(2 * time.Hour)); len(reports) != 0 {
+		t.Fatalf("expected expired clients to be dropped, got %+v", reports)
+	}
+
+	tracker.record("user:bob", now, "boom")
+	if !tracker.clear("user:bob") || tracker.clear("user:bob") {
+		t.Fatalf("expected clear to forget a tracked client once")
+	}
+}
+
+func TestAbuseTrackerDisabled(t *testing.T) {
+	t.Parallel()
+
+	tracker := newAbuseTracker(config.Config{})
+	if tracker.record("203.0.113.9", time.Now(), "boom") || tracker.flagged("203.0.113.9", time.Now()) {
+		t.Fatalf("a disabled tracker must not flag clients")
+	}
+	if reports := tracker.report(time.Now()); reports == nil || len(reports) != 0 {
+		t.Fatalf("expected an empty report, got %+v", reports)
+	}
+}
+
+func TestManagerFlagsClientsWithFailingBuilds(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	mgr := NewManager(config.Config{
+		JobsRootPath:      filepath.Join(workDir, "jobs"),
+		BuildLogsPath:     filepath.Join(workDir, "build-logs"),
+		MaxLogLines:       200,
+		CleanupInterval:   time.Hour,
+		AbuseMinFailures:  2,
+		AbuseFailureRatio: 1,
+		AbuseWindow:       time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	t.Cleanup(mgr.Close)
+
+	cancelled := newJob("job-0", "https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, workDir, time.Now(), "203.0.113.9")
+	cancelled.cancelRequested = true
+	mgr.failJob(cancelled, errors.New("context canceled"))
+	for _, id := range []string{"job-1", "job-2"} {
+		job := newJob(id, "https://github.com/example/repo.git", "fuzz-"+id, "tbeam", BuildOptions{}, workDir, time.Now(), "203.0.113.9")
+		if mgr.AbuseFlagged("203.0.113.9") {
+			t.Fatalf("flagged before %s failed", id)
+		}
+		mgr.failJob(job, errors.New("resolve ref fuzz-"+id+": not found"))
+	}
+	if !mgr.AbuseFlagged("203.0.113.9") {
+		t.Fatalf("expected the client to be flagged, report: %+v", mgr.AbuseReport())
+	}
+	if reports := mgr.AbuseReport(); len(reports) != 1 || reports[0].Builds != 2 {
+		t.Fatalf("cancelled builds must not count, got %+v", reports)
+	}
+	if !mgr.ClearAbuse("203.0.113.9") || mgr.AbuseFlagged("203.0.113.9") {
+		t.Fatalf("expected clear to lift the flag")
+	}
+}
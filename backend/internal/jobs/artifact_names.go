@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"regexp"
+	"strings"
+)
+
+// templateExtensionPattern matches a literal extension at the end of a name
+// template; it is replaced by each artifact's own extension.
+var templateExtensionPattern = regexp.MustCompile(`(\.[A-Za-z0-9]+)+$`)
+
+// artifactNameValues are the placeholder values for one job.
+type artifactNameValues struct {
+	Device    string
+	Ref       string
+	Commit    string
+	Version   string
+	BuildType string
+	JobID     string
+}
+
+// artifactBuildType is "custom" for builds with build flag or library
+// overrides and "stock" otherwise.
+func artifactBuildType(options BuildOptions) string {
+	if options.IsEmpty() && options.Patch == "" && len(options.Submodules) == 0 {
+		return "stock"
+	}
+	return "custom"
+}
+
+// applyArtifactNameTemplate sets the download name of every artifact the
+// template applies to. The file on disk and RelativePath are unchanged.
+//
+// Without a {name} placeholder only the main firmware files (firmware.bin,
+// firmware.factory.bin, firmware.elf, ...) are renamed, because every other
+// output would end up with the same name. Each artifact keeps its own
+// extension, and a rename that would collide with another artifact is
+// skipped.
+func applyArtifactNameTemplate(artifacts []Artifact, template string, values artifactNameValues) {
+	if template == "" || len(artifacts) == 0 {
+		return
+	}
+	perFile := strings.Contains(template, "{name}")
+	stemTemplate := templateExtensionPattern.ReplaceAllString(template, "")
+
+	shortHash := values.Commit
+	if len(shortHash) > 8 {
+		shortHash = shortHash[:8]
+	}
+	common := []string{
+		"{device}", sanitizeArtifactNamePart(values.Device),
+		"{ref}", sanitizeArtifactNamePart(values.Ref),
+		"{commit}", sanitizeArtifactNamePart(values.Commit),
+		"{shortCommit}", sanitizeArtifactNamePart(shortHash),
+		"{version}", sanitizeArtifactNamePart(values.Version),
+		"{buildType}", sanitizeArtifactNamePart(values.BuildType),
+		"{jobId}", sanitizeArtifactNamePart(values.JobID),
+	}
+
+	used := make(map[string]bool, len(artifacts))
+	for _, artifact := range artifacts {
+		used[strings.ToLower(artifact.Name)] = true
+	}
+
+	for index := range artifacts {
+		stem, extension := splitArtifactName(artifacts[index].Name)
+		if !perFile && !strings.EqualFold(stem, "firmware") {
+			continue
+		}
+
+		replacer := strings.NewReplacer(append(common, "{name}", stem)...)
+		renamed := replacer.Replace(stemTemplate) + extension
+		if renamed == "" || strings.EqualFold(renamed, artifacts[index].Name) || used[strings.ToLower(renamed)] {
+			continue
+		}
+		used[strings.ToLower(renamed)] = true
+		artifacts[index].Name = renamed
+	}
+}
+
+// splitArtifactName splits a file name at its first dot, so multi-part
+// extensions such as ".factory.bin" stay together.
+func splitArtifactName(name string) (string, string) {
+	if index := strings.Index(name, "."); index > 0 {
+		return name[:index], name[index:]
+	}
+	return name, ""
+}
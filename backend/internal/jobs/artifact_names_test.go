@@ -0,0 +1,65 @@
+package jobs
+
+import "testing"
+
+func TestApplyArtifactNameTemplate(t *testing.T) {
+	t.Parallel()
+
+	values := artifactNameValues{
+		Device:    "tbeam",
+		Ref:       "feature/gps",
+		Commit:    "1a2b3c4d5e6f7a8b",
+		Version:   "2.5.0",
+		BuildType: "stock",
+		JobID:     "job-1",
+	}
+
+	artifacts := []Artifact{
+		{Name: "bootloader.bin", RelativePath: "bootloader.bin"},
+		{Name: "firmware.bin", RelativePath: "firmware.bin"},
+		{Name: "firmware.factory.bin", RelativePath: "firmware.factory.bin"},
+		{Name: "firmware.elf", RelativePath: "firmware.elf"},
+	}
+	applyArtifactNameTemplate(artifacts, "firmware-{device}-{shortCommit}-{buildType}.bin", values)
+
+	want := []string{
+		"bootloader.bin",
+		"firmware-tbeam-1a2b3c4d-stock.bin",
+		"firmware-tbeam-1a2b3c4d-stock.factory.bin",
+		"firmware-tbeam-1a2b3c4d-stock.elf",
+	}
+	for index, artifact := range artifacts {
+		if artifact.Name != want[index] {
+			t.Fatalf("unexpected name for %s: got=%s want=%s", artifact.RelativePath, artifact.Name, want[index])
+		}
+	}
+	if artifacts[1].RelativePath != "firmware.bin" {
+		t.Fatalf("relative path must not change: got=%s", artifacts[1].RelativePath)
+	}
+
+	perFile := []Artifact{
+		{Name: "bootloader.bin", RelativePath: "bootloader.bin"},
+		{Name: "firmware.bin", RelativePath: "firmware.bin"},
+	}
+	applyArtifactNameTemplate(perFile, "{name}-{ref}", values)
+	if perFile[0].Name != "bootloader-feature_gps.bin" || perFile[1].Name != "firmware-feature_gps.bin" {
+		t.Fatalf("unexpected per-file names: got=%s,%s", perFile[0].Name, perFile[1].Name)
+	}
+}
+
+func TestApplyArtifactNameTemplateSkipsCollisions(t *testing.T) {
+	t.Parallel()
+
+	artifacts := []Artifact{
+		{Name: "firmware.bin", RelativePath: "firmware.bin"},
+		{Name: "firmware.bin", RelativePath: "nested/firmware.bin"},
+	}
+	applyArtifactNameTemplate(artifacts, "fw-{device}.bin", artifactNameValues{Device: "rak4631"})
+
+	if artifacts[0].Name != "fw-rak4631.bin" {
+		t.Fatalf("unexpected first name: got=%s want=fw-rak4631.bin", artifacts[0].Name)
+	}
+	if artifacts[1].Name != "firmware.bin" {
+		t.Fatalf("colliding artifact must keep its name: got=%s", artifacts[1].Name)
+	}
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// preservedArtifactsDirName is the workspace subdirectory finished build
+// outputs are moved to, so the repository checkout can be deleted early.
+const preservedArtifactsDirName = "artifacts"
+
+// artifactUploader copies finished artifacts to external storage. Upload
+// returns public download URLs keyed by artifact ID where available.
+type artifactUploader interface {
+	Name() string
+	Upload(ctx context.Context, state State, artifacts []Artifact) (map[string]string, error)
+}
+
+// preserveArtifacts moves artifacts that live inside the repository
+// checkout into <workspace>/artifacts. Artifacts served from the firmware
+// cache are left untouched.
+func preserveArtifacts(workspace string, artifacts []Artifact) error {
+	repoPath := filepath.Join(workspace, "repo") + string(filepath.Separator)
+	destinationRoot := filepath.Join(workspace, preservedArtifactsDirName)
+
+	for index := range artifacts {
+		source := artifacts[index].absPath
+		if !strings.HasPrefix(source, repoPath) {
+			continue
+		}
+
+		destination, err := cacheArchiveDestination(destinationRoot, artifacts[index].RelativePath)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(source, destination); err != nil {
+			if err := copyFile(source, destination); err != nil {
+				return fmt.Errorf("preserve artifact %q: %w", artifacts[index].RelativePath, err)
+			}
+		}
+		artifacts[index].absPath = destination
+	}
+	return nil
+}
+
+// externalizeArtifacts runs all configured uploaders and records the
+// returned download URLs on the artifacts. Upload failures are logged to the
+// job and never fail the build.
+func (m *Manager) externalizeArtifacts(ctx context.Context, job *Job, artifacts []Artifact) {
+	if len(m.uploaders) == 0 || len(artifacts) == 0 {
+		return
+	}
+
+	state := job.snapshot()
+	for _, uploader := range m.uploaders {
+		urls, err := uploader.Upload(ctx, state, artifacts)
+		if err != nil {
+			job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("%s upload failed: %v", uploader.Name(), err))
+			continue
+		}
+		for index := range artifacts {
+			if url, ok := urls[artifacts[index].ID]; ok && artifacts[index].URL == "" {
+				artifacts[index].URL = url
+			}
+		}
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("uploaded %d artifacts to %s", len(artifacts), uploader.Name()))
+	}
+}
+
+// pruneWorkspace deletes the repository checkout of a finished job while
+// keeping preserved artifacts.
+func (m *Manager) pruneWorkspace(job *Job) {
+	if !job.markWorkspacePruned() {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(job.Workspace, "repo")); err != nil {
+		m.logger.Warn("prune workspace", "jobId", job.ID, "path", job.Workspace, "error", err)
+	}
+}
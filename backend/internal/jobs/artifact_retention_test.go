@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestPreserveArtifactsAndPruneWorkspace(t *testing.T) {
+	t.Parallel()
+
+	workspace := t.TempDir()
+	buildDir := filepath.Join(workspace, "repo", ".pio", "build", "tbeam")
+	if err := os.MkdirAll(buildDir, 0o755); err != nil {
+		t.Fatalf("create build dir: %v", err)
+	}
+	sourcePath := filepath.Join(buildDir, "firmware.bin")
+	if err := os.WriteFile(sourcePath, []byte("firmware"), 0o644); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+	cachedPath := filepath.Join(t.TempDir(), "cached.bin")
+	if err := os.WriteFile(cachedPath, []byte("cached"), 0o644); err != nil {
+		t.Fatalf("write cached artifact: %v", err)
+	}
+
+	artifacts := []Artifact{
+		{ID: "1", Name: "firmware.bin", RelativePath: "firmware.bin", absPath: sourcePath},
+		{ID: "2", Name: "cached.bin", RelativePath: "cached.bin", absPath: cachedPath},
+	}
+	if err := preserveArtifacts(workspace, artifacts); err != nil {
+		t.Fatalf("preserveArtifacts failed: %v", err)
+	}
+
+	wantPath := filepath.Join(workspace, preservedArtifactsDirName, "firmware.bin")
+	if artifacts[0].AbsolutePath() != wantPath {
+		t.Fatalf("unexpected preserved path: got=%s want=%s", artifacts[0].AbsolutePath(), wantPath)
+	}
+	if artifacts[1].AbsolutePath() != cachedPath {
+		t.Fatalf("artifacts outside the checkout must not move: %s", artifacts[1].AbsolutePath())
+	}
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	mgr := &Manager{
+		cfg:    config.Config{Retention: 24 * time.Hour, WorkspaceRetention: 10 * time.Minute},
+		logger: slog.New(slog.DiscardHandler),
+		jobs:   make(map[string]*Job),
+		now:    func() time.Time { return now },
+	}
+	job := newJob("job-1", "https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, workspace, now, "")
+	job.markSuccess(now, artifacts)
+	mgr.jobs[job.ID] = job
+
+	mgr.cleanupExpiredJobs()
+	if _, err := os.Stat(filepath.Join(workspace, "repo")); err != nil {
+		t.Fatalf("checkout must survive until workspace retention passes: %v", err)
+	}
+
+	now = now.Add(15 * time.Minute)
+	mgr.cleanupExpiredJobs()
+	if _, err := os.Stat(filepath.Join(workspace, "repo")); !os.IsNotExist(err) {
+		t.Fatalf("checkout should be pruned, stat err=%v", err)
+	}
+	if _, err := mgr.GetArtifact(job.ID, "1"); err != nil {
+		t.Fatalf("job must be kept for the full retention window: %v", err)
+	}
+	if _, err := os.Stat(wantPath); err != nil {
+		t.Fatalf("preserved artifact must survive pruning: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// OpenSeeker returns the decoded artifact content as a seekable stream so it
+// can be served with http.ServeContent (Range requests, resumed downloads).
+// Raw files are returned directly; compressed files are decoded on demand
+// and a backwards seek restarts decoding from the beginning.
+func (a Artifact) OpenSeeker() (io.ReadSeekCloser, error) {
+	if a.encoding == "" {
+		return os.Open(a.absPath)
+	}
+	return &decodingSeeker{artifact: a, size: a.Size}, nil
+}
+
+type decodingSeeker struct {
+	artifact Artifact
+	size     int64
+	// offset is the logical position reported to callers; position is how
+	// far the current decoder has actually been read.
+	offset   int64
+	position int64
+	reader   io.ReadCloser
+}
+
+func (s *decodingSeeker) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = s.offset + offset
+	case io.SeekEnd:
+		target = s.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if target < 0 {
+		return 0, errors.New("negative position")
+	}
+	s.offset = target
+	return target, nil
+}
+
+func (s *decodingSeeker) Read(p []byte) (int, error) {
+	if s.offset >= s.size {
+		return 0, io.EOF
+	}
+	if err := s.sync(); err != nil {
+		return 0, err
+	}
+
+	n, err := s.reader.Read(p)
+	s.offset += int64(n)
+	s.position += int64(n)
+	return n, err
+}
+
+// sync positions the decoder at offset, reopening it for backward seeks.
+func (s *decodingSeeker) sync() error {
+	if s.reader != nil && s.position > s.offset {
+		s.reader.Close()
+		s.reader = nil
+	}
+	if s.reader == nil {
+		reader, err := s.artifact.Open()
+		if err != nil {
+			return err
+		}
+		s.reader = reader
+		s.position = 0
+	}
+	if skip := s.offset - s.position; skip > 0 {
+		skipped, err := io.CopyN(io.Discard, s.reader, skip)
+		s.position += skipped
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *decodingSeeker) Close() error {
+	if s.reader == nil {
+		return nil
+	}
+	err := s.reader.Close()
+	s.reader = nil
+	return err
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArtifactOpenSeekerOnCompressedArtifact(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 10000)
+	sourcePath := filepath.Join(t.TempDir(), "firmware.elf")
+	if err := os.WriteFile(sourcePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	compressedPath := sourcePath + zstdFileSuffix
+	if _, err := compressFileZstd(sourcePath, compressedPath); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	artifact := Artifact{Name: "firmware.elf", Size: int64(len(content)), absPath: compressedPath, encoding: FirmwareCacheCompressionZstd}
+	seeker, err := artifact.OpenSeeker()
+	if err != nil {
+		t.Fatalf("OpenSeeker failed: %v", err)
+	}
+	defer seeker.Close()
+
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil || end != int64(len(content)) {
+		t.Fatalf("seek end: got=%d err=%v want=%d", end, err, len(content))
+	}
+
+	for _, offset := range []int64{50000, 1234, 99990} {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			t.Fatalf("seek %d: %v", offset, err)
+		}
+		buf := make([]byte, 10)
+		if _, err := io.ReadFull(seeker, buf); err != nil {
+			t.Fatalf("read at %d: %v", offset, err)
+		}
+		if want := content[offset : offset+10]; string(buf) != want {
+			t.Fatalf("read at %d: got=%q want=%q", offset, buf, want)
+		}
+	}
+
+	if n, err := seeker.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF at end, got n=%d err=%v", n, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -18,7 +19,46 @@ var firmwareExtensions = []string{
 	".elf", // Executable and Linkable Format
 }
 
-func collectArtifacts(repoPath string, device string) ([]Artifact, error) {
+// artifactFilter selects which build outputs become artifacts.
+type artifactFilter struct {
+	// extensions are matched as case-insensitive file name suffixes, so
+	// multi-part extensions such as ".elf.sym" work; empty means
+	// firmwareExtensions.
+	extensions []string
+	// patterns are path.Match globs tested against the path relative to the
+	// build directory and against the file name.
+	patterns []string
+}
+
+func (f artifactFilter) matches(relPath string) bool {
+	lower := strings.ToLower(relPath)
+	if strings.HasSuffix(lower, otaPackageSuffix) {
+		return true
+	}
+
+	extensions := f.extensions
+	if len(extensions) == 0 {
+		extensions = firmwareExtensions
+	}
+	for _, extension := range extensions {
+		if strings.HasSuffix(lower, strings.ToLower(extension)) {
+			return true
+		}
+	}
+
+	for _, pattern := range f.patterns {
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, path.Base(relPath)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// collectArtifacts gathers firmware files from the PlatformIO build output.
+func collectArtifacts(repoPath string, device string, filter artifactFilter) ([]Artifact, error) {
 	buildRoot := filepath.Join(repoPath, ".pio", "build", device)
 	info, err := os.Stat(buildRoot)
 	if err != nil {
@@ -40,16 +80,11 @@ func collectArtifacts(repoPath string, device string) ([]Artifact, error) {
 			return nil
 		}
 
-		// Filter: only collect firmware files (.bin, .hex, .uf2, .elf)
-		ext := strings.ToLower(filepath.Ext(path))
-		isFirmware := false
-		for _, fwExt := range firmwareExtensions {
-			if ext == fwExt {
-				isFirmware = true
-				break
-			}
+		relPath, err := filepath.Rel(buildRoot, path)
+		if err != nil {
+			return err
 		}
-		if !isFirmware {
+		if !filter.matches(filepath.ToSlash(relPath)) {
 			return nil
 		}
 
@@ -58,11 +93,6 @@ func collectArtifacts(repoPath string, device string) ([]Artifact, error) {
 			return err
 		}
 
-		relPath, err := filepath.Rel(buildRoot, path)
-		if err != nil {
-			return err
-		}
-
 		artifacts = append(artifacts, Artifact{
 			Name:         filepath.Base(path),
 			RelativePath: filepath.ToSlash(relPath),
@@ -84,6 +114,9 @@ func collectArtifacts(repoPath string, device string) ([]Artifact, error) {
 	})
 
 	assignArtifactIDs(artifacts)
+	if err := computeArtifactChecksums(artifacts); err != nil {
+		return nil, err
+	}
 
 	return artifacts, nil
 }
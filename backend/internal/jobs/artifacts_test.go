@@ -49,7 +49,7 @@ func TestCollectArtifacts(t *testing.T) {
 		}
 	}
 
-	artifacts, err := collectArtifacts(root, device)
+	artifacts, err := collectArtifacts(root, device, artifactFilter{})
 	if err != nil {
 		t.Fatalf("collectArtifacts failed: %v", err)
 	}
@@ -69,6 +69,9 @@ func TestCollectArtifacts(t *testing.T) {
 		if artifact.AbsolutePath() == "" {
 			t.Fatalf("artifact absolute path must not be empty")
 		}
+		if len(artifact.SHA256) != 64 || len(artifact.MD5) != 32 {
+			t.Fatalf("artifact checksums must be populated: sha256=%q md5=%q", artifact.SHA256, artifact.MD5)
+		}
 
 		// Verify extension is firmware type
 		ext := filepath.Ext(artifact.Name)
@@ -99,3 +102,36 @@ func TestCollectArtifacts(t *testing.T) {
 		}
 	}
 }
+
+func TestCollectArtifactsWithFilter(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	device := "tbeam"
+	buildRoot := filepath.Join(root, ".pio", "build", device)
+	if err := os.MkdirAll(filepath.Join(buildRoot, "data"), 0o755); err != nil {
+		t.Fatalf("create build dir: %v", err)
+	}
+	for _, name := range []string{"firmware.bin", "firmware.elf.sym", "firmware.map", "data/littlefs.bin", "data/notes.txt", "output.o"} {
+		if err := os.WriteFile(filepath.Join(buildRoot, filepath.FromSlash(name)), []byte(name), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	artifacts, err := collectArtifacts(root, device, artifactFilter{
+		extensions: []string{".elf.sym"},
+		patterns:   []string{"*.map", "data/littlefs*"},
+	})
+	if err != nil {
+		t.Fatalf("collectArtifacts failed: %v", err)
+	}
+
+	got := make([]string, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		got = append(got, artifact.RelativePath)
+	}
+	want := "data/littlefs.bin,firmware.elf.sym,firmware.map"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected artifacts: got=%v want=%s", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// ArtifactsManifestName is the file name of the manifest stored at the end
+// of an artifacts archive.
+const ArtifactsManifestName = "manifest.json"
+
+// ArtifactsManifest describes the build that produced an artifacts archive.
+type ArtifactsManifest struct {
+	JobID      string                  `json:"jobId"`
+	RepoURL    string                  `json:"repoUrl"`
+	Ref        string                  `json:"ref,omitempty"`
+	Commit     string                  `json:"commit,omitempty"`
+	Device     string                  `json:"device"`
+	BuildFlags []string                `json:"buildFlags,omitempty"`
+	LibDeps    []string                `json:"libDeps,omitempty"`
+	FinishedAt *time.Time              `json:"finishedAt,omitempty"`
+	Artifacts  []ArtifactManifestEntry `json:"artifacts"`
+}
+
+// ArtifactManifestEntry lists one archived file and its checksum.
+type ArtifactManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// WriteArtifactsZip streams all artifacts of a finished job into a zip
+// archive followed by a manifest.json. Checksums are computed while the
+// files are written, so the archive is produced in a single pass.
+func WriteArtifactsZip(w io.Writer, state State) error {
+	zw := zip.NewWriter(w)
+
+	manifest := ArtifactsManifest{
+		JobID:      state.ID,
+		RepoURL:    state.RepoURL,
+		Ref:        state.Ref,
+		Commit:     state.Commit,
+		Device:     state.Device,
+		BuildFlags: state.BuildFlags,
+		LibDeps:    state.LibDeps,
+		FinishedAt: state.FinishedAt,
+		Artifacts:  make([]ArtifactManifestEntry, 0, len(state.Artifacts)),
+	}
+
+	modified := time.Now().UTC()
+	if state.FinishedAt != nil {
+		modified = state.FinishedAt.UTC()
+	}
+
+	for _, artifact := range state.Artifacts {
+		entry, err := writeArtifactZipEntry(zw, artifact, modified)
+		if err != nil {
+			return err
+		}
+		manifest.Artifacts = append(manifest.Artifacts, entry)
+	}
+
+	manifestWriter, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     ArtifactsManifestName,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("create manifest entry: %w", err)
+	}
+	encoder := json.NewEncoder(manifestWriter)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finish artifacts archive: %w", err)
+	}
+	return nil
+}
+
+func writeArtifactZipEntry(zw *zip.Writer, artifact Artifact, modified time.Time) (ArtifactManifestEntry, error) {
+	// Use the download name so renamed artifacts keep their names inside
+	// the archive.
+	name := artifact.RelativePath
+	if artifact.Name != "" {
+		name = path.Join(path.Dir(artifact.RelativePath), artifact.Name)
+	}
+
+	reader, err := artifact.Open()
+	if err != nil {
+		return ArtifactManifestEntry{}, fmt.Errorf("open artifact %s: %w", name, err)
+	}
+	defer reader.Close()
+
+	entryWriter, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return ArtifactManifestEntry{}, fmt.Errorf("create archive entry %s: %w", name, err)
+	}
+
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(entryWriter, hasher), reader)
+	if err != nil {
+		return ArtifactManifestEntry{}, fmt.Errorf("write archive entry %s: %w", name, err)
+	}
+
+	return ArtifactManifestEntry{
+		Path:   name,
+		Size:   written,
+		SHA256: hex.EncodeToString(hasher.Sum(nil)),
+	}, nil
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteArtifactsZip(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"firmware.bin":   "firmware",
+		"bootloader.bin": "bootloader",
+	}
+	artifacts := make([]Artifact, 0, len(files))
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		artifacts = append(artifacts, Artifact{Name: name, RelativePath: name, Size: int64(len(content)), absPath: path})
+	}
+
+	state := State{
+		ID:         "job-1",
+		RepoURL:    "https://github.com/meshtastic/firmware.git",
+		Device:     "tbeam",
+		Commit:     "0123456789abcdef0123456789abcdef01234567",
+		BuildFlags: []string{"-DFOO=1"},
+		Artifacts:  artifacts,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteArtifactsZip(&buf, state); err != nil {
+		t.Fatalf("WriteArtifactsZip failed: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+
+	contents := make(map[string][]byte, len(reader.File))
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", file.Name, err)
+		}
+		contents[file.Name] = data
+	}
+
+	for name, content := range files {
+		if string(contents[name]) != content {
+			t.Fatalf("unexpected content for %s: got=%q want=%q", name, contents[name], content)
+		}
+	}
+
+	var manifest ArtifactsManifest
+	if err := json.Unmarshal(contents[ArtifactsManifestName], &manifest); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if manifest.Commit != state.Commit || manifest.Device != "tbeam" || len(manifest.BuildFlags) != 1 {
+		t.Fatalf("unexpected manifest metadata: %+v", manifest)
+	}
+	if len(manifest.Artifacts) != len(files) {
+		t.Fatalf("unexpected manifest artifacts: got=%d want=%d", len(manifest.Artifacts), len(files))
+	}
+	for _, entry := range manifest.Artifacts {
+		sum := sha256.Sum256([]byte(files[entry.Path]))
+		if entry.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Fatalf("checksum mismatch for %s: got=%s", entry.Path, entry.SHA256)
+		}
+	}
+}
@@ -19,6 +19,10 @@ const (
 	firmwareCacheManifestVersion = 1
 	firmwareCacheFilesDirName    = "files"
 	firmwareCacheManifestName    = "manifest.json"
+
+	// FirmwareCacheCompressionZstd stores cached artifact files zstd-compressed.
+	FirmwareCacheCompressionZstd = "zstd"
+	zstdFileSuffix               = ".zst"
 )
 
 type firmwareCacheKeyInput struct {
@@ -28,6 +32,11 @@ type firmwareCacheKeyInput struct {
 	EnvName    string   `json:"envName"`
 	BuildFlags []string `json:"buildFlags,omitempty"`
 	LibDeps    []string `json:"libDeps,omitempty"`
+	// ArtifactPatterns change which files are cached, not the build, but a
+	// cache entry without the extra files must not satisfy such a job.
+	ArtifactPatterns []string            `json:"artifactPatterns,omitempty"`
+	PatchSHA256      string              `json:"patchSha256,omitempty"`
+	Submodules       []SubmoduleOverride `json:"submodules,omitempty"`
 }
 
 type firmwareCacheManifest struct {
@@ -36,6 +45,7 @@ type firmwareCacheManifest struct {
 	RepoURL   string                  `json:"repoUrl,omitempty"`
 	Ref       string                  `json:"ref,omitempty"`
 	Device    string                  `json:"device,omitempty"`
+	Size      *SizeReport             `json:"size,omitempty"`
 	Artifacts []firmwareCacheArtifact `json:"artifacts"`
 }
 
@@ -43,6 +53,10 @@ type firmwareCacheArtifact struct {
 	Name         string `json:"name"`
 	RelativePath string `json:"relativePath"`
 	Size         int64  `json:"size"`
+	Encoding     string `json:"encoding,omitempty"`
+	StoredSize   int64  `json:"storedSize,omitempty"`
+	SHA256       string `json:"sha256,omitempty"`
+	MD5          string `json:"md5,omitempty"`
 }
 
 func buildFirmwareCacheKey(repoURL string, commit string, envName string, options BuildOptions) (string, error) {
@@ -53,6 +67,10 @@ func buildFirmwareCacheKey(repoURL string, commit string, envName string, option
 		EnvName:    strings.TrimSpace(envName),
 		BuildFlags: append([]string(nil), options.BuildFlags...),
 		LibDeps:    append([]string(nil), options.LibDeps...),
+
+		ArtifactPatterns: append([]string(nil), options.ArtifactPatterns...),
+		PatchSHA256:      patchDigest(options.Patch),
+		Submodules:       cloneSubmoduleOverrides(options.Submodules),
 	}
 
 	if input.RepoURL == "" {
@@ -107,6 +125,14 @@ func loadArtifactsFromFirmwareCache(cacheRootPath string, cacheKey string) ([]Ar
 			return nil, false, err
 		}
 
+		switch item.Encoding {
+		case "":
+		case FirmwareCacheCompressionZstd:
+			path += zstdFileSuffix
+		default:
+			return nil, false, fmt.Errorf("cached artifact %q has unsupported encoding %q", item.RelativePath, item.Encoding)
+		}
+
 		info, err := os.Stat(path)
 		if err != nil {
 			return nil, false, fmt.Errorf("read cached artifact %q: %w", item.RelativePath, err)
@@ -114,32 +140,96 @@ func loadArtifactsFromFirmwareCache(cacheRootPath string, cacheKey string) ([]Ar
 		if info.IsDir() {
 			return nil, false, fmt.Errorf("cached artifact %q is a directory", item.RelativePath)
 		}
-		if item.Size > 0 && info.Size() != item.Size {
+
+		size := info.Size()
+		if item.Encoding != "" {
+			if item.StoredSize > 0 && info.Size() != item.StoredSize {
+				return nil, false, fmt.Errorf("cached artifact %q size mismatch", item.RelativePath)
+			}
+			size = item.Size
+		} else if item.Size > 0 && info.Size() != item.Size {
 			return nil, false, fmt.Errorf("cached artifact %q size mismatch", item.RelativePath)
 		}
 
 		name := strings.TrimSpace(item.Name)
 		if name == "" {
-			name = filepath.Base(path)
+			name = filepath.Base(filepath.FromSlash(item.RelativePath))
 		}
 
 		artifacts = append(artifacts, Artifact{
 			Name:         name,
 			RelativePath: item.RelativePath,
-			Size:         info.Size(),
+			Size:         size,
+			SHA256:       item.SHA256,
+			MD5:          item.MD5,
 			absPath:      path,
+			encoding:     item.Encoding,
 		})
 	}
 
 	assignArtifactIDs(artifacts)
+	// Entries written before checksums were recorded are hashed once and the
+	// result saved, so later loads and cache downloads skip the rehash.
+	missing := false
+	for _, artifact := range artifacts {
+		if artifact.SHA256 == "" || artifact.MD5 == "" {
+			missing = true
+			break
+		}
+	}
+	if missing {
+		if err := computeArtifactChecksums(artifacts); err != nil {
+			return nil, false, err
+		}
+		for index := range manifest.Artifacts {
+			manifest.Artifacts[index].SHA256 = artifacts[index].SHA256
+			manifest.Artifacts[index].MD5 = artifacts[index].MD5
+		}
+		// Best effort: a read-only cache still serves the entry and the
+		// next load tries again.
+		_ = writeFirmwareCacheManifest(cacheDir, manifest)
+	}
 	return artifacts, true, nil
 }
 
+// writeFirmwareCacheManifest replaces the manifest of an existing entry
+// atomically, so concurrent loads never read a partial file.
+func writeFirmwareCacheManifest(cacheDir string, manifest firmwareCacheManifest) error {
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("encode cache manifest: %w", err)
+	}
+
+	temp, err := os.CreateTemp(cacheDir, ".manifest-*")
+	if err != nil {
+		return fmt.Errorf("create cache manifest: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(content); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("write cache manifest: %w", err)
+	}
+	if err := temp.Chmod(0o644); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("write cache manifest: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("write cache manifest: %w", err)
+	}
+	if err := os.Rename(temp.Name(), filepath.Join(cacheDir, firmwareCacheManifestName)); err != nil {
+		return fmt.Errorf("replace cache manifest: %w", err)
+	}
+	return nil
+}
+
 // FirmwareCacheMeta holds optional metadata stored alongside cached artifacts.
 type FirmwareCacheMeta struct {
 	RepoURL string
 	Ref     string
 	Device  string
+	// Compression selects how artifact files are written; "" stores them raw.
+	Compression string
+	Size        *SizeReport
 }
 
 func storeArtifactsInFirmwareCache(cacheRootPath string, cacheKey string, artifacts []Artifact, meta ...FirmwareCacheMeta) error {
@@ -174,10 +264,16 @@ func storeArtifactsInFirmwareCache(cacheRootPath string, cacheKey string, artifa
 		CreatedAt: time.Now().UTC(),
 		Artifacts: make([]firmwareCacheArtifact, 0, len(artifacts)),
 	}
+	compression := ""
 	if len(meta) > 0 {
 		manifest.RepoURL = meta[0].RepoURL
 		manifest.Ref = meta[0].Ref
 		manifest.Device = meta[0].Device
+		manifest.Size = meta[0].Size
+		compression = meta[0].Compression
+	}
+	if compression != "" && compression != FirmwareCacheCompressionZstd {
+		return fmt.Errorf("unsupported firmware cache compression %q", compression)
 	}
 
 	for _, artifact := range artifacts {
@@ -190,20 +286,37 @@ func storeArtifactsInFirmwareCache(cacheRootPath string, cacheKey string, artifa
 		if err != nil {
 			return err
 		}
-		if err := copyFile(sourcePath, destinationPath); err != nil {
-			return fmt.Errorf("store cached artifact %q: %w", artifact.RelativePath, err)
-		}
-
-		info, err := os.Stat(destinationPath)
-		if err != nil {
-			return fmt.Errorf("read cached artifact %q: %w", artifact.RelativePath, err)
-		}
 
-		manifest.Artifacts = append(manifest.Artifacts, firmwareCacheArtifact{
+		entry := firmwareCacheArtifact{
 			Name:         artifact.Name,
 			RelativePath: artifact.RelativePath,
-			Size:         info.Size(),
-		})
+			SHA256:       artifact.SHA256,
+			MD5:          artifact.MD5,
+		}
+		if compression == FirmwareCacheCompressionZstd {
+			size, err := compressFileZstd(sourcePath, destinationPath+zstdFileSuffix)
+			if err != nil {
+				return fmt.Errorf("store cached artifact %q: %w", artifact.RelativePath, err)
+			}
+			info, err := os.Stat(destinationPath + zstdFileSuffix)
+			if err != nil {
+				return fmt.Errorf("read cached artifact %q: %w", artifact.RelativePath, err)
+			}
+			entry.Size = size
+			entry.Encoding = FirmwareCacheCompressionZstd
+			entry.StoredSize = info.Size()
+		} else {
+			if err := copyFile(sourcePath, destinationPath); err != nil {
+				return fmt.Errorf("store cached artifact %q: %w", artifact.RelativePath, err)
+			}
+			info, err := os.Stat(destinationPath)
+			if err != nil {
+				return fmt.Errorf("read cached artifact %q: %w", artifact.RelativePath, err)
+			}
+			entry.Size = info.Size()
+		}
+
+		manifest.Artifacts = append(manifest.Artifacts, entry)
 	}
 
 	sort.Slice(manifest.Artifacts, func(i int, j int) bool {
@@ -294,8 +407,9 @@ func shortCommit(commit string) string {
 
 // FirmwareCacheArtifactInfo describes a single artifact inside a cache entry.
 type FirmwareCacheArtifactInfo struct {
-	Name string `json:"name"`
-	Size int64  `json:"size"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Encoding string `json:"encoding,omitempty"`
 }
 
 // FirmwareCacheEntry describes a single firmware cache entry.
@@ -356,8 +470,9 @@ func ScanFirmwareCache(cacheRootPath string) FirmwareCacheInfo {
 		artifacts := make([]FirmwareCacheArtifactInfo, 0, len(manifest.Artifacts))
 		for _, a := range manifest.Artifacts {
 			artifacts = append(artifacts, FirmwareCacheArtifactInfo{
-				Name: a.Name,
-				Size: a.Size,
+				Name:     a.Name,
+				Size:     a.Size,
+				Encoding: a.Encoding,
 			})
 			entrySize += a.Size
 		}
@@ -0,0 +1,293 @@
+package jobs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CacheArchiveSource maps a top-level directory inside a cache archive to a
+// directory on disk.
+type CacheArchiveSource struct {
+	Name string
+	Root string
+}
+
+// CacheImportResult summarizes an archive import.
+type CacheImportResult struct {
+	FilesWritten int   `json:"filesWritten"`
+	FilesSkipped int   `json:"filesSkipped"`
+	BytesWritten int64 `json:"bytesWritten"`
+}
+
+// ExportCacheArchive writes a gzip-compressed tarball of all sources to w.
+// In-progress firmware cache entries (temporary directories) are skipped.
+func ExportCacheArchive(w io.Writer, sources []CacheArchiveSource) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, source := range sources {
+		if err := writeCacheArchiveSource(tw, source); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("finish cache archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("finish cache archive compression: %w", err)
+	}
+	return nil
+}
+
+func writeCacheArchiveSource(tw *tar.Writer, source CacheArchiveSource) error {
+	root := strings.TrimSpace(source.Root)
+	if root == "" {
+		return nil
+	}
+	if _, err := os.Stat(root); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read %s cache: %w", source.Name, err)
+	}
+
+	return filepath.WalkDir(root, func(current string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		rel, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "firmware-cache-") {
+			return filepath.SkipDir
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(current)
+			if err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(source.Name, filepath.ToSlash(rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		header.Uname, header.Gname = "", ""
+
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("write archive header %s: %w", header.Name, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(current)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := io.Copy(tw, file); err != nil {
+			return fmt.Errorf("write archive entry %s: %w", header.Name, err)
+		}
+		return nil
+	})
+}
+
+// ImportCacheArchive extracts a tarball produced by ExportCacheArchive into
+// the matching source roots. Existing files are never overwritten, entries
+// for unknown sources are ignored and paths escaping a root are rejected.
+// Every write goes through an os.Root, so symlinks, whether in the archive
+// or already on disk, cannot redirect a write outside the root; symlinks
+// from the archive that resolve outside it once all are in place, such as
+// a chain through "p/q -> ..", are removed and fail the import.
+func ImportCacheArchive(r io.Reader, sources []CacheArchiveSource) (result CacheImportResult, err error) {
+	roots := make(map[string]string, len(sources))
+	for _, source := range sources {
+		if strings.TrimSpace(source.Root) != "" {
+			roots[source.Name] = source.Root
+		}
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return CacheImportResult{}, fmt.Errorf("open cache archive: %w", err)
+	}
+	defer gz.Close()
+
+	opened := make(map[string]*os.Root)
+	var links []cacheArchiveLink
+	defer func() {
+		err = errors.Join(err, verifyCacheArchiveLinks(opened, links))
+		for _, root := range opened {
+			root.Close()
+		}
+	}()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, fmt.Errorf("read cache archive: %w", err)
+		}
+
+		sourceName, rel, _ := strings.Cut(strings.TrimPrefix(path.Clean("/"+header.Name), "/"), "/")
+		rootPath, ok := roots[sourceName]
+		if !ok || rel == "" {
+			continue
+		}
+		destination, err := cacheArchivePath(rel)
+		if err != nil {
+			return result, err
+		}
+		root, ok := opened[sourceName]
+		if !ok {
+			if err := os.MkdirAll(rootPath, 0o755); err != nil {
+				return result, fmt.Errorf("create %s cache: %w", sourceName, err)
+			}
+			if root, err = os.OpenRoot(rootPath); err != nil {
+				return result, fmt.Errorf("open %s cache: %w", sourceName, err)
+			}
+			opened[sourceName] = root
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := root.MkdirAll(destination, 0o755); err != nil {
+				return result, fmt.Errorf("create %s: %w", header.Name, err)
+			}
+		case tar.TypeReg:
+			written, skipped, err := extractCacheArchiveFile(root, tr, destination, header.FileInfo().Mode().Perm())
+			if err != nil {
+				return result, fmt.Errorf("extract %s: %w", header.Name, err)
+			}
+			if skipped {
+				result.FilesSkipped++
+				continue
+			}
+			result.FilesWritten++
+			result.BytesWritten += written
+		case tar.TypeSymlink:
+			target := header.Linkname
+			if filepath.IsAbs(target) {
+				return result, fmt.Errorf("archive entry %s links outside the cache", header.Name)
+			}
+			if _, err := cacheArchivePath(path.Join(path.Dir(rel), target)); err != nil {
+				return result, fmt.Errorf("archive entry %s links outside the cache", header.Name)
+			}
+			if _, err := root.Lstat(destination); err == nil {
+				result.FilesSkipped++
+				continue
+			}
+			if err := root.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+				return result, err
+			}
+			if err := root.Symlink(target, destination); err != nil {
+				return result, fmt.Errorf("extract %s: %w", header.Name, err)
+			}
+			links = append(links, cacheArchiveLink{source: sourceName, name: destination})
+			result.FilesWritten++
+		}
+	}
+}
+
+type cacheArchiveLink struct {
+	source string
+	name   string
+}
+
+// verifyCacheArchiveLinks removes the imported symlinks that resolve outside
+// their root. Links are checked after the whole archive is extracted, since
+// a later link can change where an earlier one leads.
+func verifyCacheArchiveLinks(roots map[string]*os.Root, links []cacheArchiveLink) error {
+	var err error
+	for _, link := range links {
+		root := roots[link.source]
+		rootPath, rootErr := filepath.EvalSymlinks(root.Name())
+		if rootErr != nil {
+			return rootErr
+		}
+		resolved, resolveErr := filepath.EvalSymlinks(filepath.Join(root.Name(), link.name))
+		if errors.Is(resolveErr, fs.ErrNotExist) {
+			continue
+		}
+		if resolveErr == nil {
+			if rel, relErr := filepath.Rel(rootPath, resolved); relErr == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+		}
+		_ = root.Remove(link.name)
+		err = errors.Join(err, fmt.Errorf("archive entry %s links outside the cache", path.Join(link.source, filepath.ToSlash(link.name))))
+	}
+	return err
+}
+
+func cacheArchiveDestination(root string, rel string) (string, error) {
+	cleaned, err := cacheArchivePath(rel)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, cleaned), nil
+}
+
+// cacheArchivePath returns rel as a clean path inside its root.
+func cacheArchivePath(rel string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(rel))
+	if cleaned == "." || cleaned == ".." || filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q escapes cache directory", rel)
+	}
+	return cleaned, nil
+}
+
+func extractCacheArchiveFile(root *os.Root, r io.Reader, destination string, mode os.FileMode) (int64, bool, error) {
+	if _, err := root.Lstat(destination); err == nil {
+		return 0, true, nil
+	}
+	if err := root.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return 0, false, err
+	}
+	if mode == 0 {
+		mode = 0o644
+	}
+
+	file, err := root.OpenFile(destination, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return 0, false, err
+	}
+	written, copyErr := io.Copy(file, r)
+	closeErr := file.Close()
+	if copyErr != nil {
+		_ = root.Remove(destination)
+		return 0, false, copyErr
+	}
+	return written, false, closeErr
+}
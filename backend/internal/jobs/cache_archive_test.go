@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheArchiveRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sourceRoot := t.TempDir()
+	firmwareRoot := filepath.Join(sourceRoot, "firmware")
+	pioRoot := filepath.Join(sourceRoot, "pio")
+	files := map[string]string{
+		filepath.Join(firmwareRoot, "abc", "manifest.json"):           "{}",
+		filepath.Join(firmwareRoot, "abc", "files", "firmware.bin"):   "fw",
+		filepath.Join(firmwareRoot, "firmware-cache-123", "partial"):  "tmp",
+		filepath.Join(pioRoot, "packages", "toolchain", "bin", "gcc"): "gcc",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	var archive bytes.Buffer
+	if err := ExportCacheArchive(&archive, []CacheArchiveSource{
+		{Name: "firmware-cache", Root: firmwareRoot},
+		{Name: "platformio-cache", Root: pioRoot},
+	}); err != nil {
+		t.Fatalf("ExportCacheArchive failed: %v", err)
+	}
+
+	targetRoot := t.TempDir()
+	targetFirmware := filepath.Join(targetRoot, "firmware")
+	targetPIO := filepath.Join(targetRoot, "pio")
+	if err := os.MkdirAll(filepath.Join(targetFirmware, "abc"), 0o755); err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(targetFirmware, "abc", "manifest.json"), []byte("existing"), 0o644); err != nil {
+		t.Fatalf("write existing manifest: %v", err)
+	}
+
+	result, err := ImportCacheArchive(bytes.NewReader(archive.Bytes()), []CacheArchiveSource{
+		{Name: "firmware-cache", Root: targetFirmware},
+		{Name: "platformio-cache", Root: targetPIO},
+	})
+	if err != nil {
+		t.Fatalf("ImportCacheArchive failed: %v", err)
+	}
+	if result.FilesWritten != 2 || result.FilesSkipped != 1 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(targetFirmware, "abc", "manifest.json")); err != nil || string(content) != "existing" {
+		t.Fatalf("existing files must not be overwritten: %q %v", content, err)
+	}
+	if content, err := os.ReadFile(filepath.Join(targetPIO, "packages", "toolchain", "bin", "gcc")); err != nil || string(content) != "gcc" {
+		t.Fatalf("platformio cache file not imported: %q %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(targetFirmware, "firmware-cache-123")); !os.IsNotExist(err) {
+		t.Fatalf("temporary cache directories must not be exported")
+	}
+}
+
+func TestImportCacheArchiveRejectsTraversal(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	content := []byte("evil")
+	if err := tw.WriteHeader(&tar.Header{Name: "firmware-cache/../../evil", Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("write content: %v", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "firmware-cache/link", Linkname: "../../outside", Typeflag: tar.TypeSymlink}); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+
+	root := t.TempDir()
+	target := filepath.Join(root, "firmware")
+	_, err := ImportCacheArchive(bytes.NewReader(archive.Bytes()), []CacheArchiveSource{{Name: "firmware-cache", Root: target}})
+	if err == nil {
+		t.Fatalf("expected symlink escaping the cache to be rejected")
+	}
+	if _, statErr := os.Stat(filepath.Join(root, "evil")); !os.IsNotExist(statErr) {
+		t.Fatalf("path traversal entry must not be written outside the cache")
+	}
+}
+
+func TestImportCacheArchiveRejectsSymlinkChains(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	// Each target stays inside the cache on its own, but "s" resolves to
+	// a sibling of the cache through "a/b/c", which leads back to "a".
+	for _, header := range []*tar.Header{
+		{Name: "firmware-cache/a/b/", Mode: 0o755, Typeflag: tar.TypeDir},
+		{Name: "firmware-cache/a/b/c", Linkname: "..", Typeflag: tar.TypeSymlink},
+		{Name: "firmware-cache/s", Linkname: "a/b/c/../../outside", Typeflag: tar.TypeSymlink},
+	} {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+	}
+	content := []byte("evil")
+	if err := tw.WriteHeader(&tar.Header{Name: "firmware-cache/s/x", Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("write content: %v", err)
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+
+	root := t.TempDir()
+	target := filepath.Join(root, "firmware")
+	if err := os.Mkdir(filepath.Join(root, "outside"), 0o755); err != nil {
+		t.Fatalf("create outside directory: %v", err)
+	}
+	if _, err := ImportCacheArchive(bytes.NewReader(archive.Bytes()), []CacheArchiveSource{{Name: "firmware-cache", Root: target}}); err == nil {
+		t.Fatalf("expected the symlink chain to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(root, "outside", "x")); !os.IsNotExist(err) {
+		t.Fatalf("the file behind the symlink chain must not be written outside the cache")
+	}
+	if _, err := os.Lstat(filepath.Join(target, "s")); !os.IsNotExist(err) {
+		t.Fatalf("the escaping symlink must be removed, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(target, "a", "b", "c")); err != nil {
+		t.Fatalf("links that stay inside the cache must be kept: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressFileZstd writes a zstd-compressed copy of sourcePath and returns
+// the uncompressed size.
+func compressFileZstd(sourcePath string, destinationPath string) (int64, error) {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	if err := os.MkdirAll(filepath.Dir(destinationPath), 0o755); err != nil {
+		return 0, err
+	}
+
+	destination, err := os.OpenFile(destinationPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer destination.Close()
+
+	encoder, err := zstd.NewWriter(destination, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(encoder, source)
+	if err != nil {
+		_ = encoder.Close()
+		return 0, err
+	}
+	if err := encoder.Close(); err != nil {
+		return 0, err
+	}
+
+	return written, destination.Sync()
+}
+
+// openEncodedFile opens path and transparently decodes it according to
+// encoding.
+func openEncodedFile(path string, encoding string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch encoding {
+	case "":
+		return file, nil
+	case FirmwareCacheCompressionZstd:
+		decoder, err := zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("open zstd stream: %w", err)
+		}
+		return &zstdReadCloser{decoder: decoder, file: file}, nil
+	default:
+		file.Close()
+		return nil, fmt.Errorf("unsupported artifact encoding %q", encoding)
+	}
+}
+
+type zstdReadCloser struct {
+	decoder *zstd.Decoder
+	file    *os.File
+}
+
+func (r *zstdReadCloser) Read(p []byte) (int, error) {
+	return r.decoder.Read(p)
+}
+
+func (r *zstdReadCloser) Close() error {
+	r.decoder.Close()
+	return r.file.Close()
+}
+
+// OpenFirmwareCacheFile opens a named artifact from a firmware cache entry,
+// decompressing it when the entry was stored compressed. It returns the
+// decoded size alongside the seekable reader.
+func OpenFirmwareCacheFile(cacheRootPath string, cacheKey string, name string) (io.ReadSeekCloser, int64, error) {
+	artifacts, hit, err := loadArtifactsFromFirmwareCache(cacheRootPath, cacheKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !hit {
+		return nil, 0, os.ErrNotExist
+	}
+
+	for _, artifact := range artifacts {
+		if artifact.Name != name && artifact.RelativePath != name {
+			continue
+		}
+		reader, err := artifact.OpenSeeker()
+		if err != nil {
+			return nil, 0, err
+		}
+		return reader, artifact.Size, nil
+	}
+	return nil, 0, os.ErrNotExist
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreAndLoadCompressedFirmwareCache(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	key := "1123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	content := []byte(strings.Repeat("firmware-data-", 4096))
+	sourcePath := filepath.Join(root, "source", "firmware.elf")
+	if err := os.MkdirAll(filepath.Dir(sourcePath), 0o755); err != nil {
+		t.Fatalf("create source dir: %v", err)
+	}
+	if err := os.WriteFile(sourcePath, content, 0o644); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+
+	artifacts := []Artifact{{Name: "firmware.elf", RelativePath: "firmware.elf", absPath: sourcePath}}
+	if err := storeArtifactsInFirmwareCache(root, key, artifacts, FirmwareCacheMeta{Compression: FirmwareCacheCompressionZstd}); err != nil {
+		t.Fatalf("storeArtifactsInFirmwareCache failed: %v", err)
+	}
+
+	loaded, hit, err := loadArtifactsFromFirmwareCache(root, key)
+	if err != nil || !hit {
+		t.Fatalf("expected cache hit, got hit=%v err=%v", hit, err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("unexpected loaded artifacts count: %d", len(loaded))
+	}
+
+	artifact := loaded[0]
+	if artifact.Encoding() != FirmwareCacheCompressionZstd {
+		t.Fatalf("expected zstd encoding, got %q", artifact.Encoding())
+	}
+	if artifact.Size != int64(len(content)) {
+		t.Fatalf("size must report decoded length: got=%d want=%d", artifact.Size, len(content))
+	}
+	if !strings.HasSuffix(artifact.AbsolutePath(), zstdFileSuffix) {
+		t.Fatalf("compressed artifact path should end with %s: %s", zstdFileSuffix, artifact.AbsolutePath())
+	}
+
+	info, err := os.Stat(artifact.AbsolutePath())
+	if err != nil {
+		t.Fatalf("stat compressed artifact: %v", err)
+	}
+	if info.Size() >= int64(len(content)) {
+		t.Fatalf("compressed artifact is not smaller: %d >= %d", info.Size(), len(content))
+	}
+
+	reader, err := artifact.Open()
+	if err != nil {
+		t.Fatalf("open artifact: %v", err)
+	}
+	defer reader.Close()
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read artifact: %v", err)
+	}
+	if !bytes.Equal(decoded, content) {
+		t.Fatalf("decoded artifact content mismatch")
+	}
+
+	cacheInfo := ScanFirmwareCache(root)
+	if cacheInfo.EntryCount != 1 || cacheInfo.Entries[0].Artifacts[0].Encoding != FirmwareCacheCompressionZstd {
+		t.Fatalf("scan should report the stored encoding: %+v", cacheInfo)
+	}
+}
@@ -2,7 +2,7 @@ package jobs
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -16,16 +16,16 @@ import (
 // existing cache manifests by matching them against build logs.
 // It matches by comparing artifact file names and creation timestamps.
 // This is a one-time best-effort migration; unmatched entries are left as-is.
-func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlogs.Store, logger *log.Logger) {
+func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlogs.Store, logger *slog.Logger) {
 	root := strings.TrimSpace(cacheRootPath)
 	if root == "" {
-		logger.Printf("cache migration: skipped, no cache root path configured")
+		logger.Info("cache migration: skipped, no cache root path configured")
 		return
 	}
 
 	dirEntries, err := os.ReadDir(root)
 	if err != nil {
-		logger.Printf("cache migration: cannot read cache dir %s: %v", root, err)
+		logger.Warn("cache migration: cannot read cache dir", "path", root, "error", err)
 		return
 	}
 
@@ -46,13 +46,13 @@ func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlog
 		manifestPath := filepath.Join(root, de.Name(), firmwareCacheManifestName)
 		content, err := os.ReadFile(manifestPath)
 		if err != nil {
-			logger.Printf("cache migration: cannot read manifest %s: %v", de.Name()[:12], err)
+			logger.Warn("cache migration: cannot read manifest", "entry", de.Name()[:12], "error", err)
 			continue
 		}
 
 		var manifest firmwareCacheManifest
 		if err := json.Unmarshal(content, &manifest); err != nil {
-			logger.Printf("cache migration: cannot parse manifest %s: %v", de.Name()[:12], err)
+			logger.Warn("cache migration: cannot parse manifest", "entry", de.Name()[:12], "error", err)
 			continue
 		}
 
@@ -69,7 +69,7 @@ func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlog
 		})
 	}
 
-	logger.Printf("cache migration: found %d entries needing migration, %d already have metadata", len(pending), alreadyOk)
+	logger.Info("cache migration: scanned entries", "pending", len(pending), "withMetadata", alreadyOk)
 
 	if len(pending) == 0 {
 		return
@@ -78,15 +78,15 @@ func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlog
 	// Load all build logs (successful ones).
 	allLogs, err := buildLogsStore.List(0)
 	if err != nil {
-		logger.Printf("cache migration: cannot load build logs: %v", err)
+		logger.Warn("cache migration: cannot load build logs", "error", err)
 		return
 	}
 	if len(allLogs) == 0 {
-		logger.Printf("cache migration: no build logs found, cannot match")
+		logger.Info("cache migration: no build logs found, cannot match")
 		return
 	}
 
-	logger.Printf("cache migration: loaded %d build log entries", len(allLogs))
+	logger.Info("cache migration: loaded build logs", "count", len(allLogs))
 
 	// Build an index of successful logs.
 	type logInfo struct {
@@ -112,7 +112,7 @@ func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlog
 		})
 	}
 
-	logger.Printf("cache migration: %d successful build logs available for matching", len(logIndex))
+	logger.Info("cache migration: successful build logs available for matching", "count", len(logIndex))
 
 	migrated := 0
 	unmatched := 0
@@ -126,12 +126,12 @@ func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlog
 			artifactNames = append(artifactNames, a.Name)
 		}
 
-		logger.Printf("cache migration: processing %s (created %s, %d artifacts, extracted device=%q, files: %s)",
-			pe.dirName[:12],
-			pe.manifest.CreatedAt.Format("2006-01-02 15:04:05"),
-			len(pe.manifest.Artifacts),
-			deviceFromArtifacts,
-			strings.Join(artifactNames, ", "),
+		logger.Debug("cache migration: processing entry",
+			"entry", pe.dirName[:12],
+			"createdAt", pe.manifest.CreatedAt,
+			"artifacts", len(pe.manifest.Artifacts),
+			"device", deviceFromArtifacts,
+			"files", strings.Join(artifactNames, ", "),
 		)
 
 		var bestMatch *logInfo
@@ -163,18 +163,18 @@ func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlog
 		}
 
 		if bestMatch == nil {
-			logger.Printf("cache migration: %s — no matching build log found", pe.dirName[:12])
+			logger.Info("cache migration: no matching build log found", "entry", pe.dirName[:12])
 			unmatched++
 			continue
 		}
 
-		logger.Printf("cache migration: %s — matched to job %s (device=%s, repo=%s, ref=%s, time diff=%s)",
-			pe.dirName[:12],
-			bestMatch.jobID[:12],
-			bestMatch.device,
-			bestMatch.repoURL,
-			bestMatch.ref,
-			bestTimeDiff,
+		logger.Info("cache migration: matched entry to build log",
+			"entry", pe.dirName[:12],
+			"jobId", bestMatch.jobID,
+			"device", bestMatch.device,
+			"repo", bestMatch.repoURL,
+			"ref", bestMatch.ref,
+			"timeDiff", bestTimeDiff,
 		)
 
 		pe.manifest.RepoURL = bestMatch.repoURL
@@ -183,17 +183,17 @@ func MigrateFirmwareCacheMetadata(cacheRootPath string, buildLogsStore *buildlog
 
 		data, err := json.Marshal(pe.manifest)
 		if err != nil {
-			logger.Printf("cache migration: %s — marshal error: %v", pe.dirName[:12], err)
+			logger.Warn("cache migration: cannot encode manifest", "entry", pe.dirName[:12], "error", err)
 			continue
 		}
 		if err := os.WriteFile(pe.manifestPath, data, 0o644); err != nil {
-			logger.Printf("cache migration: %s — write error: %v", pe.dirName[:12], err)
+			logger.Warn("cache migration: cannot write manifest", "entry", pe.dirName[:12], "error", err)
 			continue
 		}
 		migrated++
 	}
 
-	logger.Printf("cache migration: done — migrated %d, unmatched %d, total pending %d", migrated, unmatched, len(pending))
+	logger.Info("cache migration: done", "migrated", migrated, "unmatched", unmatched, "pending", len(pending))
 }
 
 // extractDeviceFromArtifacts tries to extract a device name from firmware
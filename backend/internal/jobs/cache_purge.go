@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CachePurgeResult summarizes a cache purge.
+type CachePurgeResult struct {
+	FilesRemoved int   `json:"filesRemoved"`
+	BytesRemoved int64 `json:"bytesRemoved"`
+}
+
+// PurgeCache deletes the contents of every source root, keeping the roots
+// themselves. In-progress firmware cache entries (temporary directories) are
+// left alone so that running builds can still publish their results.
+func PurgeCache(sources []CacheArchiveSource) (CachePurgeResult, error) {
+	var result CachePurgeResult
+	for _, source := range sources {
+		root := strings.TrimSpace(source.Root)
+		if root == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return result, fmt.Errorf("read %s cache: %w", source.Name, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "firmware-cache-") {
+				continue
+			}
+			path := filepath.Join(root, entry.Name())
+			_ = filepath.WalkDir(path, func(_ string, item fs.DirEntry, walkErr error) error {
+				if walkErr != nil || item.IsDir() {
+					return nil
+				}
+				if info, err := item.Info(); err == nil {
+					result.FilesRemoved++
+					result.BytesRemoved += info.Size()
+				}
+				return nil
+			})
+			if err := os.RemoveAll(path); err != nil {
+				return result, fmt.Errorf("purge %s cache: %w", source.Name, err)
+			}
+		}
+	}
+	return result, nil
+}
@@ -1,9 +1,12 @@
 package jobs
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -104,6 +107,57 @@ func TestStoreAndLoadArtifactsFromFirmwareCache(t *testing.T) {
 	}
 }
 
+// Entries stored before checksums were recorded are hashed on the first load
+// and the checksums written back to the manifest.
+func TestLoadArtifactsFromFirmwareCacheSavesLegacyChecksums(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	key := "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	sourcePath := filepath.Join(t.TempDir(), "firmware.bin")
+	if err := os.WriteFile(sourcePath, []byte("firmware-data"), 0o644); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+	if err := storeArtifactsInFirmwareCache(root, key, []Artifact{{Name: "firmware.bin", RelativePath: "firmware.bin", absPath: sourcePath}}); err != nil {
+		t.Fatalf("storeArtifactsInFirmwareCache failed: %v", err)
+	}
+
+	readManifest := func() firmwareCacheManifest {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(root, key, firmwareCacheManifestName))
+		if err != nil {
+			t.Fatalf("read manifest: %v", err)
+		}
+		var manifest firmwareCacheManifest
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			t.Fatalf("decode manifest: %v", err)
+		}
+		return manifest
+	}
+	if manifest := readManifest(); manifest.Artifacts[0].SHA256 != "" {
+		t.Fatalf("expected a manifest without checksums, got %+v", manifest.Artifacts[0])
+	}
+
+	loaded, hit, err := loadArtifactsFromFirmwareCache(root, key)
+	if err != nil || !hit {
+		t.Fatalf("expected a cache hit, got hit=%v err=%v", hit, err)
+	}
+	saved := readManifest().Artifacts[0]
+	if saved.SHA256 == "" || saved.SHA256 != loaded[0].SHA256 || saved.MD5 != loaded[0].MD5 {
+		t.Fatalf("checksums not written back: saved=%+v loaded=%+v", saved, loaded[0])
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, key))
+	if err != nil {
+		t.Fatalf("read cache entry: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != firmwareCacheManifestName && entry.Name() != firmwareCacheFilesDirName {
+			t.Fatalf("temporary manifest left behind: %s", entry.Name())
+		}
+	}
+}
+
 func TestLoadArtifactsFromFirmwareCacheMiss(t *testing.T) {
 	t.Parallel()
 
@@ -121,3 +175,48 @@ func TestLoadArtifactsFromFirmwareCacheMiss(t *testing.T) {
 		t.Fatalf("expected no artifacts on miss: %v", loaded)
 	}
 }
+
+// Nodes sharing one cache directory may finish the same build at once; the
+// first entry wins and nobody sees a half-written one.
+func TestStoreArtifactsInSharedFirmwareCache(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	key := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	sourcePath := filepath.Join(t.TempDir(), "firmware.bin")
+	if err := os.WriteFile(sourcePath, []byte("firmware-data"), 0o644); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+	artifacts := []Artifact{{Name: "firmware.bin", RelativePath: "firmware.bin", absPath: sourcePath}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- storeArtifactsInFirmwareCache(root, key, artifacts)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent store failed: %v", err)
+		}
+	}
+
+	loaded, hit, err := loadArtifactsFromFirmwareCache(root, key)
+	if err != nil || !hit || len(loaded) != 1 {
+		t.Fatalf("expected one cache entry, got %v hit=%v err=%v", loaded, hit, err)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read cache root: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "firmware-cache-") {
+			t.Fatalf("temporary cache directory left behind: %s", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package jobs
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// catalogRefreshTimeout bounds one refresh of every featured repository.
+const catalogRefreshTimeout = 30 * time.Minute
+
+// releaseTagPattern matches release tags such as "v2.5.20.4c97351".
+var releaseTagPattern = regexp.MustCompile(`^v?\d+\.\d+`)
+
+// CatalogEntry is the device list of one featured repository ref.
+type CatalogEntry struct {
+	RepoURL     string             `json:"repoUrl"`
+	Ref         string             `json:"ref"`
+	Commit      string             `json:"commit,omitempty"`
+	Release     bool               `json:"release"`
+	RefreshedAt time.Time          `json:"refreshedAt"`
+	Error       string             `json:"error,omitempty"`
+	Devices     []DiscoveredDevice `json:"devices"`
+}
+
+func (e CatalogEntry) clone() CatalogEntry {
+	e.Devices = cloneDiscoveredDevices(e.Devices)
+	return e
+}
+
+// deviceCatalog holds the latest discovery results for the featured
+// repositories, so clients can show a device picker without waiting for a
+// clone.
+type deviceCatalog struct {
+	mu          sync.RWMutex
+	entries     []CatalogEntry
+	refreshedAt time.Time
+	changes     []CatalogChange
+}
+
+func (c *deviceCatalog) snapshot() ([]CatalogEntry, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries := make([]CatalogEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry.clone())
+	}
+	return entries, c.refreshedAt
+}
+
+func (c *deviceCatalog) find(repoURL string, ref string) (CatalogEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, entry := range c.entries {
+		if entry.RepoURL == repoURL && entry.Ref == ref {
+			return entry, true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+func (c *deviceCatalog) replace(entries []CatalogEntry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = entries
+	c.refreshedAt = now
+}
+
+// recordChanges prepends changes to the history, keeping the newest
+// maxCatalogChanges.
+func (c *deviceCatalog) recordChanges(changes []CatalogChange) {
+	if len(changes) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	history := make([]CatalogChange, 0, len(changes)+len(c.changes))
+	for index := len(changes) - 1; index >= 0; index-- {
+		history = append(history, changes[index])
+	}
+	history = append(history, c.changes...)
+	if len(history) > maxCatalogChanges {
+		history = history[:maxCatalogChanges]
+	}
+	c.changes = history
+}
+
+func (c *deviceCatalog) changeHistory() []CatalogChange {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	changes := make([]CatalogChange, 0, len(c.changes))
+	for _, change := range c.changes {
+		changes = append(changes, change.clone())
+	}
+	return changes
+}
+
+// catalogRefs returns the refs listed for a featured repository: its
+// default branch followed by the newest release tags.
+func catalogRefs(refs RepoRefs, releaseTags int) []string {
+	result := make([]string, 0, releaseTags+1)
+	if refs.DefaultBranch != "" {
+		result = append(result, refs.DefaultBranch)
+	}
+	tags := 0
+	for _, tag := range refs.RecentTags {
+		if tags >= releaseTags {
+			break
+		}
+		if releaseTagPattern.MatchString(tag.Name) {
+			result = append(result, tag.Name)
+			tags++
+		}
+	}
+	return result
+}
+
+// DeviceCatalog returns the catalog entries and the time of the last
+// refresh. The time is zero until the first refresh finished.
+func (m *Manager) DeviceCatalog() ([]CatalogEntry, time.Time) {
+	if m.catalog == nil {
+		return []CatalogEntry{}, time.Time{}
+	}
+	return m.catalog.snapshot()
+}
+
+func (m *Manager) catalogLoop() {
+	defer m.wg.Done()
+	m.refreshCatalog()
+
+	ticker := time.NewTicker(m.cfg.CatalogRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.refreshCatalog()
+		}
+	}
+}
+
+// refreshCatalog rediscovers every featured repository. A ref that fails
+// keeps its previous device list and reports the error. Devices added to or
+// removed from a default branch are recorded and announced.
+func (m *Manager) refreshCatalog() {
+	ctx, cancel := context.WithTimeout(m.ctx, catalogRefreshTimeout)
+	defer cancel()
+
+	entries := make([]CatalogEntry, 0, len(m.cfg.FeaturedRepos)*(m.cfg.CatalogReleaseTags+1))
+	for _, repoURL := range m.cfg.FeaturedRepos {
+		refs, err := discoverRefs(ctx, m.cfg.DiscoveryRootPath, repoURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			m.logger.Warn("catalog: list refs", "repo", repoURL, "error", err)
+			entries = append(entries, m.staleCatalogEntries(repoURL, err)...)
+			continue
+		}
+
+		for index, ref := range catalogRefs(refs, m.cfg.CatalogReleaseTags) {
+			entry := CatalogEntry{
+				RepoURL:     repoURL,
+				Ref:         ref,
+				Release:     index > 0 || refs.DefaultBranch == "",
+				RefreshedAt: m.now(),
+			}
+			result, err := m.discover(ctx, repoURL, ref)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				m.logger.Warn("catalog: discover devices", "repo", repoURL, "ref", ref, "error", err)
+				if previous, ok := m.catalog.find(repoURL, ref); ok {
+					entry = previous.clone()
+				}
+				entry.Error = err.Error()
+			} else {
+				entry.Commit = result.commit
+				entry.Devices = result.devices
+			}
+			if entry.Devices == nil {
+				entry.Devices = []DiscoveredDevice{}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	changes := m.detectCatalogChanges(entries)
+	m.catalog.replace(entries, m.now())
+	m.catalog.recordChanges(changes)
+	m.announceCatalogChanges(ctx, changes)
+}
+
+func (m *Manager) staleCatalogEntries(repoURL string, err error) []CatalogEntry {
+	previous, _ := m.catalog.snapshot()
+	entries := make([]CatalogEntry, 0, len(previous))
+	for _, entry := range previous {
+		if entry.RepoURL != repoURL {
+			continue
+		}
+		entry.Error = err.Error()
+		entries = append(entries, entry)
+	}
+	return entries
+}
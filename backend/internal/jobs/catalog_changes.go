@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// maxCatalogChanges bounds the change history kept in memory.
+const maxCatalogChanges = 50
+
+// CatalogChangeEvent is the event name sent to the catalog webhook.
+const CatalogChangeEvent = "catalog.devices_changed"
+
+// CatalogChange records devices that appeared in or disappeared from the
+// default branch of a featured repository between two catalog refreshes.
+type CatalogChange struct {
+	RepoURL        string    `json:"repoUrl"`
+	Ref            string    `json:"ref"`
+	PreviousCommit string    `json:"previousCommit"`
+	Commit         string    `json:"commit"`
+	DetectedAt     time.Time `json:"detectedAt"`
+	Added          []string  `json:"added"`
+	Removed        []string  `json:"removed"`
+}
+
+func (c CatalogChange) clone() CatalogChange {
+	c.Added = append([]string(nil), c.Added...)
+	c.Removed = append([]string(nil), c.Removed...)
+	return c
+}
+
+// diffCatalogDevices returns the device names only present in current and
+// only present in previous, sorted.
+func diffCatalogDevices(previous []DiscoveredDevice, current []DiscoveredDevice) ([]string, []string) {
+	before := make(map[string]bool, len(previous))
+	for _, device := range previous {
+		before[device.Name] = true
+	}
+	after := make(map[string]bool, len(current))
+	added := make([]string, 0, 4)
+	for _, device := range current {
+		after[device.Name] = true
+		if !before[device.Name] {
+			added = append(added, device.Name)
+		}
+	}
+	removed := make([]string, 0, 4)
+	for name := range before {
+		if !after[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// detectCatalogChanges compares the refreshed default-branch entries with
+// the current catalog. Release tags never change, and refs that failed or
+// were not listed before are not compared.
+func (m *Manager) detectCatalogChanges(entries []CatalogEntry) []CatalogChange {
+	changes := make([]CatalogChange, 0, 2)
+	for _, entry := range entries {
+		if entry.Release || entry.Error != "" {
+			continue
+		}
+		previous, ok := m.catalog.find(entry.RepoURL, entry.Ref)
+		if !ok || previous.Commit == "" || previous.Commit == entry.Commit {
+			continue
+		}
+		added, removed := diffCatalogDevices(previous.Devices, entry.Devices)
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		changes = append(changes, CatalogChange{
+			RepoURL:        entry.RepoURL,
+			Ref:            entry.Ref,
+			PreviousCommit: previous.Commit,
+			Commit:         entry.Commit,
+			DetectedAt:     m.now(),
+			Added:          added,
+			Removed:        removed,
+		})
+	}
+	return changes
+}
+
+// CatalogChanges returns the recorded device changes, newest first.
+func (m *Manager) CatalogChanges() []CatalogChange {
+	if m.catalog == nil {
+		return []CatalogChange{}
+	}
+	return m.catalog.changeHistory()
+}
+
+// announceCatalogChanges logs each change and posts it to the configured
+// webhook. Webhook failures are logged and not retried.
+func (m *Manager) announceCatalogChanges(ctx context.Context, changes []CatalogChange) {
+	for _, change := range changes {
+		m.logger.Info("catalog: devices changed", "repo", change.RepoURL, "ref", change.Ref,
+			"from", shortCommit(change.PreviousCommit), "to", shortCommit(change.Commit),
+			"added", strings.Join(change.Added, ","), "removed", strings.Join(change.Removed, ","))
+		if m.catalogWebhook == nil {
+			continue
+		}
+		if err := m.catalogWebhook.send(ctx, change); err != nil {
+			m.logger.Warn("catalog: webhook failed", "repo", change.RepoURL, "ref", change.Ref, "error", err)
+		}
+	}
+}
+
+// catalogWebhook posts catalog changes as JSON to a URL.
+type catalogWebhook struct {
+	url    string
+	client *http.Client
+}
+
+type catalogWebhookPayload struct {
+	Event  string        `json:"event"`
+	Change CatalogChange `json:"change"`
+}
+
+func newCatalogWebhook(cfg config.Config) *catalogWebhook {
+	if cfg.CatalogWebhookURL == "" {
+		return nil
+	}
+	return &catalogWebhook{
+		url:    cfg.CatalogWebhookURL,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (h *catalogWebhook) send(ctx context.Context, change CatalogChange) error {
+	payload, err := json.Marshal(catalogWebhookPayload{Event: CatalogChangeEvent, Change: change})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("webhook responded %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
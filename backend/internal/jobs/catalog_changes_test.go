@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestCatalogChangesAreDetectedAndAnnounced(t *testing.T) {
+	t.Parallel()
+
+	payloads := make(chan catalogWebhookPayload, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload catalogWebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode webhook payload: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer webhook.Close()
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	mgr := &Manager{
+		logger:         slog.New(slog.DiscardHandler),
+		catalog:        &deviceCatalog{},
+		catalogWebhook: newCatalogWebhook(config.Config{CatalogWebhookURL: webhook.URL}),
+		now:            func() time.Time { return now },
+	}
+	repoURL := "https://github.com/meshtastic/firmware"
+	mgr.catalog.replace([]CatalogEntry{
+		{RepoURL: repoURL, Ref: "master", Commit: "aaa", Devices: []DiscoveredDevice{{Name: "tbeam"}, {Name: "rak4631"}}},
+		{RepoURL: repoURL, Ref: "v2.6.0", Commit: "bbb", Release: true, Devices: []DiscoveredDevice{{Name: "tbeam"}}},
+	}, now)
+
+	entries := []CatalogEntry{
+		{RepoURL: repoURL, Ref: "master", Commit: "ccc", Devices: []DiscoveredDevice{{Name: "tbeam"}, {Name: "heltec-v4"}, {Name: "t-deck"}}},
+		{RepoURL: repoURL, Ref: "v2.6.0", Commit: "ddd", Release: true, Devices: []DiscoveredDevice{}},
+	}
+	changes := mgr.detectCatalogChanges(entries)
+	if len(changes) != 1 {
+		t.Fatalf("expected one change for the default branch, got %+v", changes)
+	}
+	change := changes[0]
+	if change.PreviousCommit != "aaa" || change.Commit != "ccc" || !change.DetectedAt.Equal(now) {
+		t.Fatalf("unexpected change metadata: %+v", change)
+	}
+	if !reflect.DeepEqual(change.Added, []string{"heltec-v4", "t-deck"}) || !reflect.DeepEqual(change.Removed, []string{"rak4631"}) {
+		t.Fatalf("unexpected device diff: added=%v removed=%v", change.Added, change.Removed)
+	}
+
+	mgr.catalog.replace(entries, now)
+	mgr.catalog.recordChanges(changes)
+	mgr.announceCatalogChanges(context.Background(), changes)
+
+	payload := <-payloads
+	if payload.Event != CatalogChangeEvent || !reflect.DeepEqual(payload.Change.Added, change.Added) {
+		t.Fatalf("unexpected webhook payload: %+v", payload)
+	}
+	if history := mgr.CatalogChanges(); len(history) != 1 || history[0].Commit != "ccc" {
+		t.Fatalf("unexpected change history: %+v", history)
+	}
+
+	// The same commit again, and a failed refresh, are not changes.
+	if again := mgr.detectCatalogChanges(entries); len(again) != 0 {
+		t.Fatalf("expected no change for an unchanged commit, got %+v", again)
+	}
+	failed := []CatalogEntry{{RepoURL: repoURL, Ref: "master", Commit: "eee", Error: "clone failed"}}
+	if again := mgr.detectCatalogChanges(failed); len(again) != 0 {
+		t.Fatalf("expected no change for a failed refresh, got %+v", again)
+	}
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestCatalogRefs(t *testing.T) {
+	t.Parallel()
+
+	refs := RepoRefs{
+		DefaultBranch: "master",
+		RecentTags: []RepoRef{
+			{Name: "v2.6.1.abc1234"},
+			{Name: "nightly"},
+			{Name: "v2.6.0.def5678"},
+			{Name: "2.5.20"},
+		},
+	}
+
+	got := catalogRefs(refs, 2)
+	want := []string{"master", "v2.6.1.abc1234", "v2.6.0.def5678"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected catalog refs: got=%v want=%v", got, want)
+	}
+
+	if got := catalogRefs(refs, 0); !reflect.DeepEqual(got, []string{"master"}) {
+		t.Fatalf("unexpected catalog refs without tags: got=%v", got)
+	}
+}
+
+func TestRefreshCatalog(t *testing.T) {
+	t.Parallel()
+
+	upstream := initTestRepository(t, map[string]string{
+		"platformio.ini":                "[platformio]\n",
+		"variants/tbeam/platformio.ini": "[env:tbeam]\nplatform = espressif32\nboard = ttgo-t-beam\n",
+	})
+	cmd := exec.Command("git", "-C", upstream, "tag", "v2.0.0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("git tag unavailable: %v (%s)", err, output)
+	}
+	repoURL := "file://" + filepath.ToSlash(upstream)
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	mgr := &Manager{
+		cfg: config.Config{
+			DiscoveryRootPath:  t.TempDir(),
+			FeaturedRepos:      []string{repoURL},
+			CatalogReleaseTags: 1,
+		},
+		logger:    slog.New(slog.DiscardHandler),
+		discovery: newDiscoveryCache(4),
+		catalog:   &deviceCatalog{},
+		now:       func() time.Time { return now },
+	}
+	mgr.ctx, mgr.cancel = context.WithCancel(context.Background())
+	defer mgr.cancel()
+
+	mgr.refreshCatalog()
+
+	entries, refreshedAt := mgr.DeviceCatalog()
+	if !refreshedAt.Equal(now) {
+		t.Fatalf("unexpected refresh time: got=%s want=%s", refreshedAt, now)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected default branch and one tag, got %+v", entries)
+	}
+	if entries[0].Ref != "main" || entries[0].Release || entries[1].Ref != "v2.0.0" || !entries[1].Release {
+		t.Fatalf("unexpected catalog refs: %+v", entries)
+	}
+	for _, entry := range entries {
+		if entry.Error != "" || len(entry.Commit) != 40 {
+			t.Fatalf("unexpected entry state: %+v", entry)
+		}
+		if len(entry.Devices) != 1 || entry.Devices[0].Name != "tbeam" || entry.Devices[0].Platform != PlatformESP32 {
+			t.Fatalf("unexpected catalog devices: %+v", entry.Devices)
+		}
+	}
+}
+
+func TestDiscoverCoalescesConcurrentCalls(t *testing.T) {
+	t.Parallel()
+
+	upstream := initTestRepository(t, map[string]string{
+		"variants/tbeam/platformio.ini": "[env:tbeam]\nboard = ttgo-t-beam\n",
+	})
+	repoURL := "file://" + filepath.ToSlash(upstream)
+
+	mgr := &Manager{
+		cfg:       config.Config{DiscoveryRootPath: t.TempDir()},
+		logger:    slog.New(slog.DiscardHandler),
+		discovery: newDiscoveryCache(4),
+		now:       func() time.Time { return time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC) },
+	}
+	mgr.ctx, mgr.cancel = context.WithCancel(context.Background())
+	defer mgr.cancel()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := mgr.discover(cancelled, repoURL, "main"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled for an abandoned caller, got %v", err)
+	}
+
+	const callers = 4
+	commits := make(chan string, callers)
+	errs := make(chan error, callers)
+	for index := 0; index < callers; index++ {
+		go func() {
+			result, err := mgr.discover(context.Background(), repoURL, "main")
+			if err == nil && (len(result.devices) != 1 || result.devices[0].Name != "tbeam") {
+				err = fmt.Errorf("unexpected devices: %+v", result.devices)
+			}
+			errs <- err
+			commits <- result.commit
+		}()
+	}
+	first := ""
+	for index := 0; index < callers; index++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("discover: %v", err)
+		}
+		commit := <-commits
+		if first == "" {
+			first = commit
+		}
+		if len(commit) != 40 || commit != first {
+			t.Fatalf("unexpected commit: got=%s first=%s", commit, first)
+		}
+	}
+	mgr.wg.Wait()
+}
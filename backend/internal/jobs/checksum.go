@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// computeChecksums fills in SHA256 and MD5 from the decoded artifact content.
+func (a *Artifact) computeChecksums() error {
+	reader, err := a.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	sha := sha256.New()
+	sum := md5.New()
+	if _, err := io.Copy(io.MultiWriter(sha, sum), reader); err != nil {
+		return err
+	}
+
+	a.SHA256 = hex.EncodeToString(sha.Sum(nil))
+	a.MD5 = hex.EncodeToString(sum.Sum(nil))
+	return nil
+}
+
+func computeArtifactChecksums(artifacts []Artifact) error {
+	for index := range artifacts {
+		if artifacts[index].SHA256 != "" && artifacts[index].MD5 != "" {
+			continue
+		}
+		if err := artifacts[index].computeChecksums(); err != nil {
+			return fmt.Errorf("checksum artifact %q: %w", artifacts[index].RelativePath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
+)
+
+// defaultGitCredentialUser is sent with tokens stored without a username;
+// GitHub, GitLab and Gitea accept any name alongside an access token.
+const defaultGitCredentialUser = "x-access-token"
+
+// gitCredentials is the store whose git credentials are offered to https
+// remotes. It is nil when the credential store is disabled.
+var gitCredentials atomic.Pointer[secrets.Store]
+
+func configureGitCredentials(store *secrets.Store) {
+	gitCredentials.Store(store)
+}
+
+type gitCredentialsKey struct{}
+
+// withGitCredentials marks ctx as belonging to a trusted job, whose git
+// commands may use the stored credentials. Anything else, including device
+// discovery and ref listing, runs anonymously: a broad scope such as
+// github.com would otherwise let any client build, and download the sources
+// of, the operator's private repositories.
+func withGitCredentials(ctx context.Context) context.Context {
+	return context.WithValue(ctx, gitCredentialsKey{}, true)
+}
+
+func gitCredentialsAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(gitCredentialsKey{}).(bool)
+	return allowed
+}
+
+// applyGitCredentials adds the stored git credentials to the environment of
+// cmd when ctx allows them. They are read on every command, so a rotation
+// applies to the next clone or fetch without a restart.
+func applyGitCredentials(ctx context.Context, cmd *exec.Cmd) {
+	if !gitCredentialsAllowed(ctx) {
+		return
+	}
+	store := gitCredentials.Load()
+	if store == nil {
+		return
+	}
+	credentials, err := store.Secrets(secrets.KindGitCredential)
+	if err != nil {
+		return
+	}
+	if env := gitCredentialEnv(credentials); len(env) > 0 {
+		appendCommandEnv(cmd, env...)
+	}
+}
+
+// gitCredentialEnv passes each credential to git as an Authorization header
+// for the https URLs under its scope, through GIT_CONFIG_* variables so the
+// token never appears in arguments or remote URLs. Broader scopes come first
+// and a narrower scope clears the headers before adding its own, so an owner
+// credential replaces the host credential for that owner's repositories.
+func gitCredentialEnv(credentials []secrets.Secret) []string {
+	sorted := make([]secrets.Secret, len(credentials))
+	copy(sorted, credentials)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i].Scope, "/") < strings.Count(sorted[j].Scope, "/")
+	})
+
+	var entries [][2]string
+	for _, credential := range sorted {
+		key := "http.https://" + credential.Scope + "/.extraHeader"
+		username := credential.Username
+		if username == "" {
+			username = defaultGitCredentialUser
+		}
+		token := base64.StdEncoding.EncodeToString([]byte(username + ":" + credential.Value))
+		if strings.Contains(credential.Scope, "/") {
+			entries = append(entries, [2]string{key, ""})
+		}
+		entries = append(entries, [2]string{key, "Authorization: Basic " + token})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	env := []string{"GIT_CONFIG_COUNT=" + strconv.Itoa(len(entries))}
+	for index, entry := range entries {
+		suffix := strconv.Itoa(index)
+		env = append(env, "GIT_CONFIG_KEY_"+suffix+"="+entry[0], "GIT_CONFIG_VALUE_"+suffix+"="+entry[1])
+	}
+	return env
+}
+
+// appendCommandEnv adds entries to the environment cmd inherits.
+func appendCommandEnv(cmd *exec.Cmd, entries ...string) {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, entries...)
+}
+
+// Secrets returns the credential store, or nil when it is disabled.
+func (m *Manager) Secrets() *secrets.Store {
+	return m.secrets
+}
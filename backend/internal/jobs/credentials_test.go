@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
+)
+
+func TestGitCredentialEnv(t *testing.T) {
+	t.Parallel()
+
+	env := gitCredentialEnv([]secrets.Secret{
+		{Entry: secrets.Entry{Name: "example-org", Scope: "github.com/example", Username: "bot"}, Value: "org-token"},
+		{Entry: secrets.Entry{Name: "github", Scope: "github.com"}, Value: "host-token"},
+	})
+	basic := func(credentials string) string {
+		return "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	want := []string{
+		"GIT_CONFIG_COUNT=3",
+		"GIT_CONFIG_KEY_0=http.https://github.com/.extraHeader", "GIT_CONFIG_VALUE_0=" + basic("x-access-token:host-token"),
+		"GIT_CONFIG_KEY_1=http.https://github.com/example/.extraHeader", "GIT_CONFIG_VALUE_1=",
+		"GIT_CONFIG_KEY_2=http.https://github.com/example/.extraHeader", "GIT_CONFIG_VALUE_2=" + basic("bot:org-token"),
+	}
+	if !slices.Equal(env, want) {
+		t.Fatalf("unexpected env:\n%s", strings.Join(env, "\n"))
+	}
+	if env := gitCredentialEnv(nil); env != nil {
+		t.Fatalf("expected no env without credentials, got %v", env)
+	}
+}
+
+// TestGitCredentialsOnlyForTrustedJobs is not parallel: the credential
+// store is process-wide and every NewManager replaces it.
+func TestGitCredentialsOnlyForTrustedJobs(t *testing.T) {
+	workDir := t.TempDir()
+	mgr := NewManager(config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+		SecretsKey:      base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		SecretsPath:     filepath.Join(workDir, "secrets.json"),
+	}, slog.New(slog.DiscardHandler))
+	t.Cleanup(mgr.Close)
+	if _, _, err := mgr.Secrets().Put("github", secrets.KindGitCredential, "github.com", "", "host-token"); err != nil {
+		t.Fatalf("store git credential: %v", err)
+	}
+
+	anonymous := exec.Command("git", "ls-remote", "https://github.com/example/private.git")
+	applyGitCredentials(context.Background(), anonymous)
+	if anonymous.Env != nil {
+		t.Fatalf("expected no credentials outside trusted jobs, got %v", anonymous.Env)
+	}
+	trusted := exec.Command("git", "ls-remote", "https://github.com/example/private.git")
+	applyGitCredentials(withGitCredentials(context.Background()), trusted)
+	if !slices.Contains(trusted.Env, "GIT_CONFIG_KEY_0=http.https://github.com/.extraHeader") {
+		t.Fatalf("expected the credential for trusted jobs, got %v", trusted.Env)
+	}
+
+	if mirrorKey(context.Background(), "https://github.com/example/private.git") == mirrorKey(withGitCredentials(context.Background()), "https://github.com/example/private.git") {
+		t.Fatalf("mirrors fetched with credentials must not be shared with anonymous jobs")
+	}
+}
+
+func TestTrustedSigningKeysIncludeStoredKeys(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	signersPath := filepath.Join(workDir, "allowed_signers")
+	if err := os.WriteFile(signersPath, []byte("ops@example.com ssh-ed25519 AAAAops\n"), 0o600); err != nil {
+		t.Fatalf("write allowed signers: %v", err)
+	}
+	mgr := NewManager(config.Config{
+		JobsRootPath:         filepath.Join(workDir, "jobs"),
+		BuildLogsPath:        filepath.Join(workDir, "build-logs"),
+		MaxLogLines:          200,
+		CleanupInterval:      time.Hour,
+		TagSSHAllowedSigners: signersPath,
+		SecretsKey:           base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		SecretsPath:          filepath.Join(workDir, "secrets.json"),
+	}, slog.New(slog.DiscardHandler))
+	t.Cleanup(mgr.Close)
+
+	if _, _, err := mgr.Secrets().Put("release", secrets.KindSigningKey, "", "", "dev@example.com ssh-ed25519 AAAAdev"); err != nil {
+		t.Fatalf("store ssh key: %v", err)
+	}
+	armored := "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmQINBF\n-----END PGP PUBLIC KEY BLOCK-----\n"
+	if _, _, err := mgr.Secrets().Put("maintainer", secrets.KindSigningKey, "", "", armored); err != nil {
+		t.Fatalf("store gpg key: %v", err)
+	}
+
+	gpgKeyrings, allowedSigners, cleanup, err := mgr.trustedSigningKeys(workDir)
+	if err != nil {
+		t.Fatalf("trusted signing keys: %v", err)
+	}
+	if len(gpgKeyrings) != 1 {
+		t.Fatalf("expected the stored gpg key, got %v", gpgKeyrings)
+	}
+	if content, err := os.ReadFile(gpgKeyrings[0]); err != nil || string(content) != armored {
+		t.Fatalf("unexpected keyring %q: %v", content, err)
+	}
+	content, err := os.ReadFile(allowedSigners)
+	if err != nil || !strings.Contains(string(content), "ops@example.com") || !strings.Contains(string(content), "dev@example.com") {
+		t.Fatalf("expected configured and stored signers, got %q: %v", content, err)
+	}
+	cleanup()
+	if _, err := os.Stat(allowedSigners); !os.IsNotExist(err) {
+		t.Fatalf("expected cleanup to remove the temporary keys, got %v", err)
+	}
+}
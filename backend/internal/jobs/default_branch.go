@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"strings"
+	"sync"
+)
+
+// defaultBranchTracker remembers the default branch last reported by each
+// repository so a rename (for example master to main) can be detected.
+type defaultBranchTracker struct {
+	mu       sync.Mutex
+	branches map[string]string
+	changes  uint64
+}
+
+func newDefaultBranchTracker() *defaultBranchTracker {
+	return &defaultBranchTracker{branches: make(map[string]string)}
+}
+
+// observe records branch as the default branch of repoURL and returns the
+// previously recorded branch when it differs.
+func (t *defaultBranchTracker) observe(repoURL string, branch string) (string, bool) {
+	key := repoKey(repoURL)
+	branch = strings.TrimSpace(branch)
+	if t == nil || key == "" || branch == "" {
+		return "", false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	previous, known := t.branches[key]
+	t.branches[key] = branch
+	if !known || previous == branch {
+		return "", false
+	}
+	t.changes++
+	return previous, true
+}
+
+func (t *defaultBranchTracker) changeCount() uint64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.changes
+}
+
+// DefaultBranchChanges reports how many default branch changes have been
+// detected since startup.
+func (m *Manager) DefaultBranchChanges() uint64 {
+	return m.defaultBranches.changeCount()
+}
+
+// observeDefaultBranch drops data cached for repoURL when its default branch
+// differs from the one seen before. Discovery results and the git mirror
+// would otherwise keep following the old branch for empty refs.
+func (m *Manager) observeDefaultBranch(repoURL string, branch string) {
+	previous, changed := m.defaultBranches.observe(repoURL, branch)
+	if !changed {
+		return
+	}
+
+	dropped := m.discovery.removeRepo(repoURL)
+	m.mirrors.invalidate(repoURL)
+	m.logger.Info("refs: default branch changed; dropped cached discovery results and the git mirror",
+		"repo", repoURL, "previous", previous, "branch", branch, "dropped", dropped)
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDefaultBranchTrackerReportsChanges(t *testing.T) {
+	t.Parallel()
+
+	tracker := newDefaultBranchTracker()
+	if _, changed := tracker.observe("https://github.com/example/firmware", "master"); changed {
+		t.Fatalf("first observation must not count as a change")
+	}
+	if _, changed := tracker.observe("https://github.com/Example/firmware.git", "master"); changed {
+		t.Fatalf("same branch must not count as a change")
+	}
+	if _, changed := tracker.observe("https://github.com/example/firmware", ""); changed {
+		t.Fatalf("unknown branch must be ignored")
+	}
+	previous, changed := tracker.observe("https://github.com/example/firmware", "main")
+	if !changed || previous != "master" {
+		t.Fatalf("unexpected change: got=%q,%v want=master,true", previous, changed)
+	}
+	if got := tracker.changeCount(); got != 1 {
+		t.Fatalf("unexpected change count: got=%d want=1", got)
+	}
+}
+
+func TestListRefsInvalidatesCachesOnDefaultBranchChange(t *testing.T) {
+	t.Parallel()
+
+	upstream := initTestRepository(t, map[string]string{"README.md": "hello\n"})
+	repoURL := "file://" + filepath.ToSlash(upstream)
+	workDir := t.TempDir()
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	mgr := &Manager{
+		logger:          slog.New(slog.DiscardHandler),
+		discovery:       newDiscoveryCache(8),
+		defaultBranches: newDefaultBranchTracker(),
+		now:             func() time.Time { return now },
+	}
+	mgr.cfg.DiscoveryRootPath = filepath.Join(workDir, "discovery")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	mgr.mirrors = newMirrorCache(ctx, &mgr.wg, filepath.Join(workDir, "mirrors"), 1, time.Hour, mgr.logger, mgr.now)
+
+	if err := mgr.cloneRepositoryWithMirror(ctx, repoURL, "", filepath.Join(workDir, "clone"), nil); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	mgr.wg.Wait()
+	mirrorPath := filepath.Join(workDir, "mirrors", mirrorDirName(repoURL))
+	if !isBareRepository(mirrorPath) {
+		t.Fatalf("expected mirror at %s", mirrorPath)
+	}
+	commit := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	mgr.discovery.put(repoURL, commit, []DiscoveredDevice{{Name: "tbeam"}}, nil, now)
+
+	refs, err := mgr.listRefs(ctx, repoURL)
+	if err != nil {
+		t.Fatalf("list refs: %v", err)
+	}
+	if refs.DefaultBranch != "main" {
+		t.Fatalf("unexpected default branch: got=%q want=main", refs.DefaultBranch)
+	}
+	if _, _, ok := mgr.discovery.get(repoURL, commit); !ok {
+		t.Fatalf("first observation must keep cached discovery")
+	}
+
+	if output, err := exec.Command("git", "-C", upstream, "branch", "-m", "main", "trunk").CombinedOutput(); err != nil {
+		t.Fatalf("rename branch: %v (%s)", err, output)
+	}
+
+	refs, err = mgr.listRefs(ctx, repoURL)
+	if err != nil {
+		t.Fatalf("list refs after rename: %v", err)
+	}
+	if refs.DefaultBranch != "trunk" {
+		t.Fatalf("unexpected default branch: got=%q want=trunk", refs.DefaultBranch)
+	}
+	if got := mgr.DefaultBranchChanges(); got != 1 {
+		t.Fatalf("unexpected change count: got=%d want=1", got)
+	}
+	if _, _, ok := mgr.discovery.get(repoURL, commit); ok {
+		t.Fatalf("cached discovery must be dropped after a default branch change")
+	}
+	if isBareRepository(mirrorPath) {
+		t.Fatalf("mirror must be removed after a default branch change")
+	}
+}
@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultAvailabilityTags = 10
+	maxAvailabilityTags     = 20
+)
+
+// ErrRepoNotFeatured is returned for availability scans of repositories
+// outside APP_FEATURED_REPOS, which are the only ones scanned without a
+// captcha.
+var ErrRepoNotFeatured = errors.New("repository is not a featured repository")
+
+// TagAvailability tells whether a release tag contains a device. Error is
+// set when the tag could not be discovered.
+type TagAvailability struct {
+	Tag       string `json:"tag"`
+	Commit    string `json:"commit,omitempty"`
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DeviceAvailability lists the recent release tags of a repository, newest
+// first, and which of them contain a device. FirstAvailable is the oldest
+// scanned tag that contains it.
+type DeviceAvailability struct {
+	RepoURL        string            `json:"repoUrl"`
+	Device         string            `json:"device"`
+	FirstAvailable string            `json:"firstAvailable,omitempty"`
+	Tags           []TagAvailability `json:"tags"`
+}
+
+// DeviceAvailability discovers the newest limit release tags of repoURL,
+// the first featured repository when empty, and reports which contain
+// device (an env name or variant path).
+func (m *Manager) DeviceAvailability(ctx context.Context, repoURL string, device string, limit int) (DeviceAvailability, error) {
+	if err := ValidateDeviceSelection(device); err != nil {
+		return DeviceAvailability{}, err
+	}
+	repoURL = strings.TrimSpace(repoURL)
+	if repoURL == "" && len(m.cfg.FeaturedRepos) > 0 {
+		repoURL = m.cfg.FeaturedRepos[0]
+	}
+	if !m.isFeaturedRepo(repoURL) {
+		return DeviceAvailability{}, ErrRepoNotFeatured
+	}
+	if !m.deviceAllowed(repoURL, device) {
+		return DeviceAvailability{}, fmt.Errorf("%w: %s", ErrDeviceNotAllowed, device)
+	}
+	if limit < 1 {
+		limit = defaultAvailabilityTags
+	}
+	if limit > maxAvailabilityTags {
+		limit = maxAvailabilityTags
+	}
+
+	refs, err := m.listRefs(ctx, repoURL)
+	if err != nil {
+		return DeviceAvailability{}, err
+	}
+
+	availability := DeviceAvailability{RepoURL: repoURL, Device: device, Tags: make([]TagAvailability, 0, limit)}
+	for _, tag := range refs.RecentTags {
+		if len(availability.Tags) >= limit {
+			break
+		}
+		if !releaseTagPattern.MatchString(tag.Name) {
+			continue
+		}
+
+		entry := TagAvailability{Tag: tag.Name, Commit: tag.Commit}
+		result, err := m.discover(ctx, repoURL, tag.Name)
+		switch {
+		case ctx.Err() != nil:
+			return DeviceAvailability{}, ctx.Err()
+		case errors.Is(err, ErrDiscoveryBusy):
+			return DeviceAvailability{}, err
+		case err != nil:
+			entry.Error = err.Error()
+		default:
+			if result.commit != "" {
+				entry.Commit = result.commit
+			}
+			entry.Available = containsDevice(result.devices, device)
+		}
+		if entry.Available {
+			availability.FirstAvailable = entry.Tag
+		}
+		availability.Tags = append(availability.Tags, entry)
+	}
+	return availability, nil
+}
+
+func (m *Manager) isFeaturedRepo(repoURL string) bool {
+	for _, featured := range m.cfg.FeaturedRepos {
+		if featured == repoURL {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDevice(devices []DiscoveredDevice, device string) bool {
+	for _, candidate := range devices {
+		if candidate.Name == device || (candidate.RelativePath != "" && candidate.RelativePath == device) {
+			return true
+		}
+	}
+	return false
+}
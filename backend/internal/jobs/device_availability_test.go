@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestDeviceAvailability(t *testing.T) {
+	t.Parallel()
+
+	upstream := initTestRepository(t, map[string]string{
+		"variants/tbeam/platformio.ini": "[env:tbeam]\nboard = ttgo-t-beam\n",
+	})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", upstream, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %v unavailable: %v (%s)", args, err, output)
+		}
+	}
+	git("tag", "v2.0.0")
+	if err := os.MkdirAll(filepath.Join(upstream, "variants", "t-deck"), 0o755); err != nil {
+		t.Fatalf("create variant: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(upstream, "variants", "t-deck", "platformio.ini"), []byte("[env:t-deck]\nboard = t-deck\n"), 0o644); err != nil {
+		t.Fatalf("write variant: %v", err)
+	}
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "add t-deck")
+	git("tag", "v2.1.0")
+	git("tag", "nightly")
+	repoURL := "file://" + filepath.ToSlash(upstream)
+
+	mgr := &Manager{
+		cfg: config.Config{
+			DiscoveryRootPath: t.TempDir(),
+			FeaturedRepos:     []string{repoURL},
+		},
+		logger:    slog.New(slog.DiscardHandler),
+		discovery: newDiscoveryCache(4),
+		now:       func() time.Time { return time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC) },
+	}
+	mgr.ctx, mgr.cancel = context.WithCancel(context.Background())
+	defer mgr.cancel()
+
+	got, err := mgr.DeviceAvailability(context.Background(), "", "t-deck", 0)
+	if err != nil {
+		t.Fatalf("DeviceAvailability failed: %v", err)
+	}
+	if got.RepoURL != repoURL || got.FirstAvailable != "v2.1.0" || len(got.Tags) != 2 {
+		t.Fatalf("unexpected availability: %+v", got)
+	}
+	available := map[string]bool{}
+	for _, tag := range got.Tags {
+		if tag.Error != "" || len(tag.Commit) != 40 {
+			t.Fatalf("unexpected tag state: %+v", tag)
+		}
+		available[tag.Tag] = tag.Available
+	}
+	if !available["v2.1.0"] || available["v2.0.0"] {
+		t.Fatalf("unexpected tag availability: %+v", got.Tags)
+	}
+
+	if _, err := mgr.DeviceAvailability(context.Background(), "https://github.com/other/firmware", "t-deck", 0); !errors.Is(err, ErrRepoNotFeatured) {
+		t.Fatalf("expected ErrRepoNotFeatured, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DeviceComparison lists how the devices of a repository differ between a
+// base and a head ref.
+type DeviceComparison struct {
+	BaseCommit string
+	HeadCommit string
+	Added      []DiscoveredDevice
+	Removed    []DiscoveredDevice
+	Changed    []DeviceDiff
+	Unchanged  int
+}
+
+// DeviceDiff describes a device present in both refs whose configuration
+// changed. Fields names the changed metadata ("relativePath", "platform",
+// "board", "mcu"); flags and dependencies are compared one by one.
+type DeviceDiff struct {
+	Before            DiscoveredDevice
+	After             DiscoveredDevice
+	Fields            []string
+	BuildFlagsAdded   []string
+	BuildFlagsRemoved []string
+	LibDepsAdded      []string
+	LibDepsRemoved    []string
+}
+
+// CompareDevices discovers repoURL at baseRef and headRef and compares the
+// device lists.
+func (m *Manager) CompareDevices(ctx context.Context, repoURL string, baseRef string, headRef string) (DeviceComparison, error) {
+	if err := ValidateRepoURL(repoURL); err != nil {
+		return DeviceComparison{}, err
+	}
+	if err := m.checkRepoAllowed(repoURL); err != nil {
+		return DeviceComparison{}, err
+	}
+	for _, ref := range []string{baseRef, headRef} {
+		if strings.TrimSpace(ref) == "" {
+			return DeviceComparison{}, errors.New("baseRef and headRef are required")
+		}
+		if err := ValidateRef(ref); err != nil {
+			return DeviceComparison{}, err
+		}
+	}
+
+	base, err := m.discover(ctx, repoURL, baseRef)
+	if err != nil {
+		return DeviceComparison{}, fmt.Errorf("discover %s: %w", baseRef, err)
+	}
+	head, err := m.discover(ctx, repoURL, headRef)
+	if err != nil {
+		return DeviceComparison{}, fmt.Errorf("discover %s: %w", headRef, err)
+	}
+
+	comparison := compareDevices(base.devices, head.devices)
+	comparison.BaseCommit = base.commit
+	comparison.HeadCommit = head.commit
+	return comparison, nil
+}
+
+// compareDevices matches devices by env name. Both lists are sorted by
+// name, and so are the results.
+func compareDevices(base []DiscoveredDevice, head []DiscoveredDevice) DeviceComparison {
+	comparison := DeviceComparison{
+		Added:   make([]DiscoveredDevice, 0, 4),
+		Removed: make([]DiscoveredDevice, 0, 4),
+		Changed: make([]DeviceDiff, 0, 4),
+	}
+
+	before := make(map[string]DiscoveredDevice, len(base))
+	for _, device := range base {
+		before[device.Name] = device
+	}
+	after := make(map[string]bool, len(head))
+	for _, device := range head {
+		after[device.Name] = true
+		previous, ok := before[device.Name]
+		if !ok {
+			comparison.Added = append(comparison.Added, device)
+			continue
+		}
+		diff, changed := diffDevice(previous, device)
+		if !changed {
+			comparison.Unchanged++
+			continue
+		}
+		comparison.Changed = append(comparison.Changed, diff)
+	}
+	for _, device := range base {
+		if !after[device.Name] {
+			comparison.Removed = append(comparison.Removed, device)
+		}
+	}
+	return comparison
+}
+
+func diffDevice(before DiscoveredDevice, after DiscoveredDevice) (DeviceDiff, bool) {
+	diff := DeviceDiff{Before: before, After: after, Fields: make([]string, 0, 4)}
+	fields := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{"relativePath", before.RelativePath, after.RelativePath},
+		{"platform", before.Platform, after.Platform},
+		{"board", before.Board, after.Board},
+		{"mcu", before.MCU, after.MCU},
+	}
+	for _, field := range fields {
+		if field.before != field.after {
+			diff.Fields = append(diff.Fields, field.name)
+		}
+	}
+
+	diff.BuildFlagsAdded, diff.BuildFlagsRemoved = diffValues(splitBuildFlags(before.BuildFlags), splitBuildFlags(after.BuildFlags))
+	diff.LibDepsAdded, diff.LibDepsRemoved = diffValues(before.LibDeps, after.LibDeps)
+
+	changed := len(diff.Fields) > 0 ||
+		len(diff.BuildFlagsAdded) > 0 || len(diff.BuildFlagsRemoved) > 0 ||
+		len(diff.LibDepsAdded) > 0 || len(diff.LibDepsRemoved) > 0
+	return diff, changed
+}
+
+// diffValues returns the values only in after and only in before, in their
+// original order. Reordering alone is not a change.
+func diffValues(before []string, after []string) ([]string, []string) {
+	inBefore := make(map[string]bool, len(before))
+	for _, value := range before {
+		inBefore[value] = true
+	}
+	inAfter := make(map[string]bool, len(after))
+	for _, value := range after {
+		inAfter[value] = true
+	}
+
+	added := make([]string, 0, 2)
+	for _, value := range after {
+		if !inBefore[value] {
+			added = append(added, value)
+			inBefore[value] = true
+		}
+	}
+	removed := make([]string, 0, 2)
+	for _, value := range before {
+		if !inAfter[value] {
+			removed = append(removed, value)
+			inAfter[value] = true
+		}
+	}
+	return added, removed
+}
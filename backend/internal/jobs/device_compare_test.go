@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareDevices(t *testing.T) {
+	t.Parallel()
+
+	base := []DiscoveredDevice{
+		{Name: "heltec-v3", RelativePath: "esp32s3/heltec_v3", Board: "heltec_wifi_lora_32_V3", BuildFlags: []string{"-DHELTEC_V3 -DUSE_SX1262"}},
+		{Name: "rak4631", RelativePath: "rak4631", Board: "wiscore_rak4631", LibDeps: []string{"lib-a", "lib-b"}},
+		{Name: "tbeam", RelativePath: "tbeam", Board: "ttgo-t-beam", BuildFlags: []string{"-DTBEAM"}},
+	}
+	head := []DiscoveredDevice{
+		{Name: "heltec-v3", RelativePath: "esp32s3/heltec_v3", Board: "heltec_wifi_lora_32_V3", BuildFlags: []string{"-DUSE_SX1262", "-DHELTEC_V3"}},
+		{Name: "rak4631", RelativePath: "nrf52840/rak4631", Board: "wiscore_rak4631", LibDeps: []string{"lib-b", "lib-c"}},
+		{Name: "t-deck", RelativePath: "esp32s3/t-deck", Board: "t-deck"},
+	}
+
+	got := compareDevices(base, head)
+	if len(got.Added) != 1 || got.Added[0].Name != "t-deck" {
+		t.Fatalf("unexpected added devices: %+v", got.Added)
+	}
+	if len(got.Removed) != 1 || got.Removed[0].Name != "tbeam" {
+		t.Fatalf("unexpected removed devices: %+v", got.Removed)
+	}
+	if got.Unchanged != 1 {
+		t.Fatalf("reordered build flags must not count as a change: unchanged=%d changed=%+v", got.Unchanged, got.Changed)
+	}
+	if len(got.Changed) != 1 {
+		t.Fatalf("unexpected changed devices: %+v", got.Changed)
+	}
+
+	diff := got.Changed[0]
+	if diff.After.Name != "rak4631" || !reflect.DeepEqual(diff.Fields, []string{"relativePath"}) {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if !reflect.DeepEqual(diff.LibDepsAdded, []string{"lib-c"}) || !reflect.DeepEqual(diff.LibDepsRemoved, []string{"lib-a"}) {
+		t.Fatalf("unexpected lib_deps diff: added=%v removed=%v", diff.LibDepsAdded, diff.LibDepsRemoved)
+	}
+	if len(diff.BuildFlagsAdded) != 0 || len(diff.BuildFlagsRemoved) != 0 {
+		t.Fatalf("unexpected build_flags diff: added=%v removed=%v", diff.BuildFlagsAdded, diff.BuildFlagsRemoved)
+	}
+}
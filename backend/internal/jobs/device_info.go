@@ -0,0 +1,334 @@
+package jobs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Platform identifiers reported for discovered devices.
+const (
+	PlatformESP32  = "esp32"
+	PlatformNRF52  = "nrf52"
+	PlatformRP2040 = "rp2040"
+	PlatformSTM32  = "stm32"
+	PlatformNative = "native"
+)
+
+// PlatformOther groups devices whose platform could not be detected.
+const PlatformOther = "other"
+
+// KnownPlatforms lists the values accepted by DeviceFilter.Platform.
+var KnownPlatforms = []string{PlatformESP32, PlatformNRF52, PlatformRP2040, PlatformSTM32, PlatformNative, PlatformOther}
+
+// maxFilterTags bounds the tags of one DeviceFilter.
+const maxFilterTags = 8
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9:_-]{0,31}$`)
+
+// DeviceFilter narrows a discovered device list. Empty fields match all
+// devices; a device must carry every tag in Tags (see DeviceTags).
+type DeviceFilter struct {
+	Platform string
+	Tags     []string
+}
+
+// ValidateDeviceFilter rejects unknown filter values.
+func ValidateDeviceFilter(filter DeviceFilter) error {
+	if len(filter.Tags) > maxFilterTags {
+		return fmt.Errorf("at most %d tags can be combined", maxFilterTags)
+	}
+	for _, tag := range filter.Tags {
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("tag %q is invalid", tag)
+		}
+	}
+	if filter.Platform == "" {
+		return nil
+	}
+	for _, platform := range KnownPlatforms {
+		if filter.Platform == platform {
+			return nil
+		}
+	}
+	return fmt.Errorf("platform must be one of: %s", strings.Join(KnownPlatforms, ", "))
+}
+
+// ParseDeviceTags splits comma-separated tag lists, lowercased and without
+// empty entries.
+func ParseDeviceTags(values ...string) []string {
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// DevicePlatformGroup returns the platform a device is grouped under.
+func DevicePlatformGroup(device DiscoveredDevice) string {
+	if device.Platform == "" {
+		return PlatformOther
+	}
+	return device.Platform
+}
+
+// FilterDevices returns the devices matching filter.
+func FilterDevices(devices []DiscoveredDevice, filter DeviceFilter) []DiscoveredDevice {
+	filtered := make([]DiscoveredDevice, 0, len(devices))
+	for _, device := range devices {
+		if filter.Platform != "" && DevicePlatformGroup(device) != filter.Platform {
+			continue
+		}
+		if len(filter.Tags) > 0 && !hasAllTags(DeviceTags(device), filter.Tags) {
+			continue
+		}
+		filtered = append(filtered, device)
+	}
+	return filtered
+}
+
+// DeviceTags returns the filterable attributes of a device, sorted:
+// "platform:<group>", "mcu:<mcu>", "radio:<chip>", and "gps"/"no-gps",
+// "display"/"no-display" when the build flags say so.
+func DeviceTags(device DiscoveredDevice) []string {
+	tags := make([]string, 0, 6)
+	tags = append(tags, "platform:"+DevicePlatformGroup(device))
+	if device.MCU != "" {
+		tags = append(tags, "mcu:"+device.MCU)
+	}
+	for _, radio := range device.Hints.Radios {
+		tags = append(tags, "radio:"+radio)
+	}
+	if device.Hints.GPS != nil {
+		tags = append(tags, boolTag("gps", *device.Hints.GPS))
+	}
+	if device.Hints.Display != nil {
+		tags = append(tags, boolTag("display", *device.Hints.Display))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func boolTag(name string, value bool) string {
+	if value {
+		return name
+	}
+	return "no-" + name
+}
+
+func hasAllTags(tags []string, required []string) bool {
+	for _, want := range required {
+		found := false
+		for _, tag := range tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// DeviceHints are hardware features guessed from build flags. A nil value
+// means the flags do not say either way. Radios lists the LoRa chips
+// ("sx1262", "sx1276", ...) named by flags or the env and board names.
+type DeviceHints struct {
+	Display *bool
+	GPS     *bool
+	Radios  []string
+}
+
+func (h DeviceHints) clone() DeviceHints {
+	return DeviceHints{Display: cloneBool(h.Display), GPS: cloneBool(h.GPS), Radios: append([]string(nil), h.Radios...)}
+}
+
+func cloneBool(value *bool) *bool {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+// platformIOPackages maps substrings of PlatformIO platform package names
+// (or package URLs) to platforms.
+var platformIOPackages = []struct {
+	token    string
+	platform string
+}{
+	{"espressif32", PlatformESP32},
+	{"nordicnrf52", PlatformNRF52},
+	{"raspberrypi", PlatformRP2040},
+	{"ststm32", PlatformSTM32},
+	{"native", PlatformNative},
+	{"linux_", PlatformNative},
+	{"portduino", PlatformNative},
+}
+
+// mcuTokens are searched, in order, in board_build.mcu, the extends value
+// (e.g. "esp32s3_base") and the variant path (e.g. "esp32s3/heltec_v3").
+var mcuTokens = []struct {
+	token    string
+	mcu      string
+	platform string
+}{
+	{"esp32s3", "esp32s3", PlatformESP32},
+	{"esp32-s3", "esp32s3", PlatformESP32},
+	{"esp32s2", "esp32s2", PlatformESP32},
+	{"esp32c3", "esp32c3", PlatformESP32},
+	{"esp32c6", "esp32c6", PlatformESP32},
+	{"esp32", "esp32", PlatformESP32},
+	{"nrf52840", "nrf52840", PlatformNRF52},
+	{"nrf52832", "nrf52832", PlatformNRF52},
+	{"nrf52", "", PlatformNRF52},
+	{"rp2350", "rp2350", PlatformRP2040},
+	{"rp2040", "rp2040", PlatformRP2040},
+	{"stm32wl", "stm32wl", PlatformSTM32},
+	{"stm32", "", PlatformSTM32},
+	{"portduino", "", PlatformNative},
+	{"native", "", PlatformNative},
+}
+
+// detectDevicePlatform derives the platform family and MCU of an env from
+// its platform, board_build.mcu and extends options, falling back to the
+// variant directory layout.
+func detectDevicePlatform(settings map[string]string, relativePath string) (string, string) {
+	platform := ""
+	packageName := strings.ToLower(settings["platform"])
+	for _, candidate := range platformIOPackages {
+		if strings.Contains(packageName, candidate.token) {
+			platform = candidate.platform
+			break
+		}
+	}
+
+	mcu := ""
+	sources := []string{settings["board_build.mcu"], settings["extends"], relativePath}
+	for _, source := range sources {
+		lowered := strings.ToLower(source)
+		if lowered == "" {
+			continue
+		}
+		for _, candidate := range mcuTokens {
+			if !strings.Contains(lowered, candidate.token) {
+				continue
+			}
+			if mcu == "" {
+				mcu = candidate.mcu
+			}
+			if platform == "" {
+				platform = candidate.platform
+			}
+			break
+		}
+		if mcu != "" && platform != "" {
+			break
+		}
+	}
+	return platform, mcu
+}
+
+var (
+	displayFlagPattern   = regexp.MustCompile(`^-D\s*(HAS_SCREEN\s*=\s*1|USE_SSD1306|USE_SH1106|USE_SH1107|USE_ST7567|USE_ST7789|ST7789_CS|ST7735_CS|ILI9341_DRIVER|USE_EINK|HAS_TFT|TFT_CS)\b`)
+	noDisplayFlagPattern = regexp.MustCompile(`^-D\s*(HAS_SCREEN\s*=\s*0|MESHTASTIC_EXCLUDE_SCREEN)\b`)
+	gpsFlagPattern       = regexp.MustCompile(`^-D\s*(GPS_RX_PIN|GPS_TX_PIN|HAS_GPS\s*=\s*1)\b`)
+	noGPSFlagPattern     = regexp.MustCompile(`^-D\s*(HAS_GPS\s*=\s*0|MESHTASTIC_EXCLUDE_GPS)\b`)
+	radioFlagPattern     = regexp.MustCompile(`^-D\s*USE_(SX1262|SX1268|SX1276|SX1278|SX1280|RF95|LR1110|LR1120|LR1121|LLCC68)\b`)
+	radioNamePattern     = regexp.MustCompile(`(sx1262|sx1268|sx1276|sx1278|sx1280|rfm95|lr1110|lr1120|lr1121|llcc68)`)
+)
+
+// radioAliases maps module names to the LoRa chip they carry.
+var radioAliases = map[string]string{
+	"rf95":  "sx1276",
+	"rfm95": "sx1276",
+}
+
+// detectDeviceRadios collects the LoRa chips enabled by USE_<CHIP> defines
+// or named in the env and board names (e.g. "tlora-v2-1-1_6-sx1280").
+func detectDeviceRadios(buildFlags []string, names ...string) []string {
+	found := make(map[string]bool, 2)
+	for _, flag := range splitBuildFlags(buildFlags) {
+		if match := radioFlagPattern.FindStringSubmatch(flag); len(match) == 2 {
+			found[strings.ToLower(match[1])] = true
+		}
+	}
+	for _, name := range names {
+		for _, match := range radioNamePattern.FindAllString(strings.ToLower(name), -1) {
+			found[match] = true
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	radios := make([]string, 0, len(found))
+	seen := make(map[string]bool, len(found))
+	for radio := range found {
+		if alias, ok := radioAliases[radio]; ok {
+			radio = alias
+		}
+		if !seen[radio] {
+			seen[radio] = true
+			radios = append(radios, radio)
+		}
+	}
+	sort.Strings(radios)
+	return radios
+}
+
+// detectDeviceHints looks for display and GPS related defines. Explicit
+// "off" defines win over drivers that happen to be enabled.
+func detectDeviceHints(buildFlags []string) DeviceHints {
+	hints := DeviceHints{}
+	var display, noDisplay, gps, noGPS bool
+	for _, flag := range splitBuildFlags(buildFlags) {
+		switch {
+		case noDisplayFlagPattern.MatchString(flag):
+			noDisplay = true
+		case displayFlagPattern.MatchString(flag):
+			display = true
+		}
+		switch {
+		case noGPSFlagPattern.MatchString(flag):
+			noGPS = true
+		case gpsFlagPattern.MatchString(flag):
+			gps = true
+		}
+	}
+
+	if noDisplay || display {
+		value := !noDisplay
+		hints.Display = &value
+	}
+	if noGPS || gps {
+		value := !noGPS
+		hints.GPS = &value
+	}
+	return hints
+}
+
+// splitBuildFlags splits build_flags lines into single flags, keeping a
+// "-D NAME" pair together.
+func splitBuildFlags(lines []string) []string {
+	flags := make([]string, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		for index := 0; index < len(fields); index++ {
+			field := fields[index]
+			if (field == "-D" || field == "-U") && index+1 < len(fields) {
+				field += fields[index+1]
+				index++
+			}
+			flags = append(flags, field)
+		}
+	}
+	return flags
+}
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectDevicePlatform(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		settings     map[string]string
+		relativePath string
+		platform     string
+		mcu          string
+	}{
+		{"extends", map[string]string{"extends": "esp32s3_base"}, "heltec_v3", PlatformESP32, "esp32s3"},
+		{"platform package", map[string]string{"platform": "platformio/nordicnrf52@^10.5.0"}, "rak4631", PlatformNRF52, ""},
+		{"board mcu", map[string]string{"platform": "nordicnrf52", "board_build.mcu": "nRF52840"}, "t-echo", PlatformNRF52, "nrf52840"},
+		{"variant path", map[string]string{}, "rp2040/rpipico", PlatformRP2040, "rp2040"},
+		{"native", map[string]string{"extends": "portduino_base"}, "portduino", PlatformNative, ""},
+		{"unknown", map[string]string{}, "custom_board", "", ""},
+	}
+	for _, tc := range cases {
+		platform, mcu := detectDevicePlatform(tc.settings, tc.relativePath)
+		if platform != tc.platform || mcu != tc.mcu {
+			t.Fatalf("%s: got=%s/%s want=%s/%s", tc.name, platform, mcu, tc.platform, tc.mcu)
+		}
+	}
+}
+
+func TestDetectDeviceHints(t *testing.T) {
+	t.Parallel()
+
+	hints := detectDeviceHints([]string{"${esp32_base.build_flags} -D USE_SSD1306 -DGPS_RX_PIN=34"})
+	if hints.Display == nil || !*hints.Display || hints.GPS == nil || !*hints.GPS {
+		t.Fatalf("expected display and GPS: %+v", hints)
+	}
+
+	hints = detectDeviceHints([]string{"-DUSE_SSD1306", "-DHAS_SCREEN=0", "-DMESHTASTIC_EXCLUDE_GPS=1"})
+	if hints.Display == nil || *hints.Display || hints.GPS == nil || *hints.GPS {
+		t.Fatalf("explicit opt-outs must win: %+v", hints)
+	}
+
+	hints = detectDeviceHints([]string{"-Ivariants/tbeam"})
+	if hints.Display != nil || hints.GPS != nil {
+		t.Fatalf("unrelated flags must leave hints unknown: %+v", hints)
+	}
+}
+
+func TestDetectDeviceRadios(t *testing.T) {
+	t.Parallel()
+
+	got := detectDeviceRadios([]string{"-DUSE_SX1262 -D USE_RF95", "-DUSE_SX1262_EXTRA=1"}, "tlora-v2-1-1_6-sx1280", "")
+	want := []string{"sx1262", "sx1276", "sx1280"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected radios: got=%v want=%v", got, want)
+	}
+	if got := detectDeviceRadios([]string{"-DHAS_GPS=1"}, "tbeam", "ttgo-t-beam"); got != nil {
+		t.Fatalf("expected no radios, got %v", got)
+	}
+}
+
+func TestFilterDevicesByTags(t *testing.T) {
+	t.Parallel()
+
+	yes, no := true, false
+	devices := []DiscoveredDevice{
+		{Name: "heltec-v3", Platform: PlatformESP32, MCU: "esp32s3", Hints: DeviceHints{Display: &yes, Radios: []string{"sx1262"}}},
+		{Name: "tbeam", Platform: PlatformESP32, MCU: "esp32", Hints: DeviceHints{GPS: &yes, Display: &no, Radios: []string{"sx1276"}}},
+		{Name: "rak4631", Platform: PlatformNRF52, MCU: "nrf52840", Hints: DeviceHints{Radios: []string{"sx1262"}}},
+	}
+
+	if tags := DeviceTags(devices[1]); !reflect.DeepEqual(tags, []string{"gps", "mcu:esp32", "no-display", "platform:esp32", "radio:sx1276"}) {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+
+	filter := DeviceFilter{Tags: ParseDeviceTags("radio:SX1262", " platform:esp32 ")}
+	if err := ValidateDeviceFilter(filter); err != nil {
+		t.Fatalf("ValidateDeviceFilter failed: %v", err)
+	}
+	got := FilterDevices(devices, filter)
+	if len(got) != 1 || got[0].Name != "heltec-v3" {
+		t.Fatalf("unexpected filtered devices: %+v", got)
+	}
+
+	if err := ValidateDeviceFilter(DeviceFilter{Tags: []string{"radio sx1262"}}); err == nil {
+		t.Fatalf("expected error for invalid tag")
+	}
+}
+
+func TestListVariantDevicesIncludesMetadata(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	devicePath := filepath.Join(root, "variants", "esp32s3", "heltec_v3")
+	if err := os.MkdirAll(devicePath, 0o755); err != nil {
+		t.Fatalf("create device dir: %v", err)
+	}
+	content := `[env:heltec-v3]
+extends = esp32s3_base
+board = heltec_wifi_lora_32_V3
+build_flags = ${esp32s3_base.build_flags} -D HELTEC_V3 -DUSE_SSD1306
+`
+	if err := os.WriteFile(filepath.Join(devicePath, "platformio.ini"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write platformio.ini: %v", err)
+	}
+
+	devices, err := listVariantDevices(root)
+	if err != nil {
+		t.Fatalf("listVariantDevices failed: %v", err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("unexpected devices count: got=%d want=1", len(devices))
+	}
+	device := devices[0]
+	if device.RelativePath != "esp32s3/heltec_v3" || device.Board != "heltec_wifi_lora_32_V3" {
+		t.Fatalf("unexpected path/board: %s %s", device.RelativePath, device.Board)
+	}
+	if device.Platform != PlatformESP32 || device.MCU != "esp32s3" {
+		t.Fatalf("unexpected platform/mcu: %s/%s", device.Platform, device.MCU)
+	}
+	if device.Hints.Display == nil || !*device.Hints.Display {
+		t.Fatalf("expected display hint: %+v", device.Hints)
+	}
+}
+
+func TestFilterDevices(t *testing.T) {
+	t.Parallel()
+
+	devices := []DiscoveredDevice{
+		{Name: "tbeam", Platform: PlatformESP32},
+		{Name: "rak4631", Platform: PlatformNRF52},
+		{Name: "mystery"},
+	}
+
+	filtered := FilterDevices(devices, DeviceFilter{Platform: PlatformNRF52})
+	if len(filtered) != 1 || filtered[0].Name != "rak4631" {
+		t.Fatalf("unexpected nrf52 devices: %+v", filtered)
+	}
+	filtered = FilterDevices(devices, DeviceFilter{Platform: PlatformOther})
+	if len(filtered) != 1 || filtered[0].Name != "mystery" {
+		t.Fatalf("unexpected other devices: %+v", filtered)
+	}
+	if len(FilterDevices(devices, DeviceFilter{})) != 3 {
+		t.Fatalf("empty filter must keep all devices")
+	}
+	if err := ValidateDeviceFilter(DeviceFilter{Platform: "avr"}); err == nil {
+		t.Fatalf("expected error for unknown platform")
+	}
+}
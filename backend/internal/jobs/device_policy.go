@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"path"
+	"strings"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// repoKey reduces a repository URL to "host/owner/name" for matching device
+// rules, so https, ssh and scp-like URLs of one repository compare equal.
+func repoKey(repoURL string) string {
+	key := strings.ToLower(strings.TrimSpace(repoURL))
+	if _, rest, ok := strings.Cut(key, "://"); ok {
+		key = rest
+	} else if scpLikeRepoPattern.MatchString(key) {
+		key = strings.Replace(key, ":", "/", 1)
+	}
+	if at := strings.Index(key, "@"); at >= 0 && at < strings.Index(key+"/", "/") {
+		key = key[at+1:]
+	}
+	return trimRepoSuffix(key)
+}
+
+// deviceAllowedByRules reports whether device may be discovered and built
+// from repoURL. Deny rules win; when any allow rule applies to the
+// repository, the device must match one of them. A device selection given
+// as a variant path ("esp32/tbeam") is also matched by its last element.
+func deviceAllowedByRules(allow []config.DeviceRule, deny []config.DeviceRule, repoURL string, device string) bool {
+	if len(allow) == 0 && len(deny) == 0 {
+		return true
+	}
+	key := repoKey(repoURL)
+	names := []string{device}
+	if base := path.Base(device); base != device {
+		names = append(names, base)
+	}
+
+	for _, rule := range deny {
+		if ruleAppliesToRepo(rule, key) && ruleMatchesDevice(rule, names) {
+			return false
+		}
+	}
+
+	restricted := false
+	for _, rule := range allow {
+		if !ruleAppliesToRepo(rule, key) {
+			continue
+		}
+		restricted = true
+		if ruleMatchesDevice(rule, names) {
+			return true
+		}
+	}
+	return !restricted
+}
+
+func ruleAppliesToRepo(rule config.DeviceRule, key string) bool {
+	if rule.Repo == "" {
+		return true
+	}
+	matched, err := path.Match(repoKey(rule.Repo), key)
+	return err == nil && matched
+}
+
+func ruleMatchesDevice(rule config.DeviceRule, names []string) bool {
+	for _, name := range names {
+		if matched, err := path.Match(rule.Pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) deviceAllowed(repoURL string, device string) bool {
+	return deviceAllowedByRules(m.cfg.DeviceAllow, m.cfg.DeviceDeny, repoURL, device)
+}
+
+// allowedDevices drops the devices the configured rules block for repoURL.
+func (m *Manager) allowedDevices(repoURL string, devices []DiscoveredDevice) []DiscoveredDevice {
+	if len(m.cfg.DeviceAllow) == 0 && len(m.cfg.DeviceDeny) == 0 {
+		return devices
+	}
+	allowed := make([]DiscoveredDevice, 0, len(devices))
+	for _, device := range devices {
+		if m.deviceAllowed(repoURL, device.Name) {
+			allowed = append(allowed, device)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// DeviceMatch is one device found by SearchCatalog. A device listed under
+// several catalog refs is reported once, with every ref it appears in.
+type DeviceMatch struct {
+	Device    DiscoveredDevice
+	Aliases   []string
+	MatchedOn string
+	Score     int
+	Refs      []CatalogRef
+}
+
+// CatalogRef identifies a repository ref of the catalog.
+type CatalogRef struct {
+	RepoURL string `json:"repoUrl"`
+	Ref     string `json:"ref"`
+}
+
+// deviceAliases are alternative names a user may type for a device: its
+// PlatformIO board and the variant directory name.
+func deviceAliases(device DiscoveredDevice) []string {
+	aliases := make([]string, 0, 2)
+	candidates := []string{device.Board}
+	if device.RelativePath != "" {
+		candidates = append(candidates, path.Base(device.RelativePath))
+	}
+	for _, candidate := range candidates {
+		if candidate == "" || candidate == "." || strings.EqualFold(candidate, device.Name) {
+			continue
+		}
+		duplicate := false
+		for _, alias := range aliases {
+			if strings.EqualFold(alias, candidate) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			aliases = append(aliases, candidate)
+		}
+	}
+	return aliases
+}
+
+// SearchCatalog fuzzy-matches query against device names and aliases of the
+// catalog entries and returns at most limit matches, best first.
+func SearchCatalog(entries []CatalogEntry, query string, limit int) []DeviceMatch {
+	needle := normalizeSearchTerm(query)
+	if needle == "" || limit < 1 {
+		return []DeviceMatch{}
+	}
+
+	byName := make(map[string]*DeviceMatch)
+	order := make([]string, 0, 32)
+	for _, entry := range entries {
+		for _, device := range entry.Devices {
+			ref := CatalogRef{RepoURL: entry.RepoURL, Ref: entry.Ref}
+			if match, ok := byName[device.Name]; ok {
+				match.Refs = append(match.Refs, ref)
+				continue
+			}
+
+			aliases := deviceAliases(device)
+			score := searchScore(needle, device.Name)
+			matchedOn := device.Name
+			for _, alias := range aliases {
+				// Alias hits rank just below name hits of the same kind.
+				if aliasScore := searchScore(needle, alias) - 5; aliasScore > score {
+					score = aliasScore
+					matchedOn = alias
+				}
+			}
+			if score <= 0 {
+				continue
+			}
+
+			byName[device.Name] = &DeviceMatch{
+				Device:    device,
+				Aliases:   aliases,
+				MatchedOn: matchedOn,
+				Score:     score,
+				Refs:      []CatalogRef{ref},
+			}
+			order = append(order, device.Name)
+		}
+	}
+
+	matches := make([]DeviceMatch, 0, len(order))
+	for _, name := range order {
+		matches = append(matches, *byName[name])
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Device.Name < matches[j].Device.Name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// searchScore rates how well needle matches candidate: exact, prefix,
+// substring, then in-order subsequence ("tb3" matches "t-beam-s3"). Shorter
+// candidates win among equal kinds of match. Zero means no match.
+func searchScore(needle string, candidate string) int {
+	haystack := normalizeSearchTerm(candidate)
+	if haystack == "" {
+		return 0
+	}
+	lengthPenalty := len(haystack) - len(needle)
+	if lengthPenalty > 19 {
+		lengthPenalty = 19
+	}
+
+	switch {
+	case haystack == needle:
+		return 100
+	case strings.HasPrefix(haystack, needle):
+		return 80 - lengthPenalty
+	case strings.Contains(haystack, needle):
+		return 60 - lengthPenalty
+	case isSubsequence(needle, haystack):
+		return 40 - lengthPenalty
+	}
+	return 0
+}
+
+func isSubsequence(needle string, haystack string) bool {
+	index := 0
+	for position := 0; position < len(haystack) && index < len(needle); position++ {
+		if haystack[position] == needle[index] {
+			index++
+		}
+	}
+	return index == len(needle)
+}
+
+// normalizeSearchTerm lowercases value and drops separators, so "T-Beam",
+// "t_beam" and "tbeam" compare equal.
+func normalizeSearchTerm(value string) string {
+	builder := strings.Builder{}
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
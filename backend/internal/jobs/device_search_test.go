@@ -0,0 +1,58 @@
+package jobs
+
+import "testing"
+
+func TestSearchCatalog(t *testing.T) {
+	t.Parallel()
+
+	entries := []CatalogEntry{
+		{
+			RepoURL: "https://github.com/meshtastic/firmware",
+			Ref:     "master",
+			Devices: []DiscoveredDevice{
+				{Name: "tbeam", Board: "ttgo-t-beam", RelativePath: "variants/esp32/tbeam"},
+				{Name: "tbeam-s3-core", Board: "tbeam-s3-core", RelativePath: "variants/esp32s3/tbeam-s3-core"},
+				{Name: "heltec-v3", Board: "heltec_wifi_lora_32_V3", RelativePath: "variants/esp32s3/heltec_v3"},
+				{Name: "rak4631", Board: "wiscore_rak4631", RelativePath: "variants/nrf52840/rak4631"},
+			},
+		},
+		{
+			RepoURL: "https://github.com/meshtastic/firmware",
+			Ref:     "v2.6.0.abc1234",
+			Devices: []DiscoveredDevice{{Name: "tbeam", Board: "ttgo-t-beam"}},
+		},
+	}
+
+	matches := SearchCatalog(entries, "T-Beam", 10)
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", matches)
+	}
+	if matches[0].Device.Name != "tbeam" || matches[0].Score != 100 || len(matches[0].Refs) != 2 {
+		t.Fatalf("unexpected best match: %+v", matches[0])
+	}
+	if matches[1].Device.Name != "tbeam-s3-core" {
+		t.Fatalf("unexpected second match: %+v", matches[1])
+	}
+
+	matches = SearchCatalog(entries, "heltec_v3", 10)
+	if len(matches) != 1 || matches[0].Device.Name != "heltec-v3" {
+		t.Fatalf("unexpected heltec matches: %+v", matches)
+	}
+
+	matches = SearchCatalog(entries, "wiscore", 10)
+	if len(matches) != 1 || matches[0].MatchedOn != "wiscore_rak4631" {
+		t.Fatalf("expected alias match on board, got %+v", matches)
+	}
+
+	matches = SearchCatalog(entries, "tbs3", 10)
+	if len(matches) != 1 || matches[0].Device.Name != "tbeam-s3-core" {
+		t.Fatalf("expected fuzzy subsequence match, got %+v", matches)
+	}
+
+	if matches := SearchCatalog(entries, "tbeam", 1); len(matches) != 1 {
+		t.Fatalf("limit not applied: %+v", matches)
+	}
+	if matches := SearchCatalog(entries, "--", 10); len(matches) != 0 {
+		t.Fatalf("expected no matches for separators only, got %+v", matches)
+	}
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import "sort"
+
+// Diagnostics is a point-in-time view of the memory the manager holds for
+// jobs: retained log lines and log stream subscribers per job, and the
+// build queue.
+type Diagnostics struct {
+	Queue       QueueDiagnostics `json:"queue"`
+	Jobs        []JobDiagnostics `json:"jobs"`
+	LogLines    int              `json:"logLines"`
+	LogBytes    int              `json:"logBytes"`
+	Subscribers int              `json:"subscribers"`
+}
+
+// QueueDiagnostics describes the build queue. Buffered counts the jobs
+// handed to the workers but not yet picked up.
+type QueueDiagnostics struct {
+	Order    []string `json:"order"`
+	Buffered int      `json:"buffered"`
+	Capacity int      `json:"capacity"`
+}
+
+// JobDiagnostics describes one job in memory. A subscriber whose channel is
+// full no longer receives log lines; one that stays full is a stream whose
+// client stopped reading without disconnecting.
+type JobDiagnostics struct {
+	ID              string `json:"id"`
+	Status          Status `json:"status"`
+	LogLines        int    `json:"logLines"`
+	LogBytes        int    `json:"logBytes"`
+	Subscribers     int    `json:"subscribers"`
+	FullSubscribers int    `json:"fullSubscribers"`
+}
+
+// Diagnostics returns the jobs held in memory, largest log buffers first.
+func (m *Manager) Diagnostics() Diagnostics {
+	m.mu.RLock()
+	all := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		all = append(all, job)
+	}
+	result := Diagnostics{
+		Queue: QueueDiagnostics{
+			Order:    append([]string{}, m.queueOrder...),
+			Buffered: len(m.queue),
+			Capacity: cap(m.queue),
+		},
+		Jobs: make([]JobDiagnostics, 0, len(all)),
+	}
+	m.mu.RUnlock()
+
+	for _, job := range all {
+		item := job.diagnostics()
+		result.Jobs = append(result.Jobs, item)
+		result.LogLines += item.LogLines
+		result.LogBytes += item.LogBytes
+		result.Subscribers += item.Subscribers
+	}
+	sort.Slice(result.Jobs, func(i, j int) bool {
+		if result.Jobs[i].LogBytes != result.Jobs[j].LogBytes {
+			return result.Jobs[i].LogBytes > result.Jobs[j].LogBytes
+		}
+		return result.Jobs[i].ID < result.Jobs[j].ID
+	})
+	return result
+}
+
+func (j *Job) diagnostics() JobDiagnostics {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := JobDiagnostics{ID: j.ID, Status: j.Status, LogLines: len(j.logLines), Subscribers: len(j.subscribers)}
+	for _, line := range j.logLines {
+		result.LogBytes += len(line.text)
+	}
+	for stream := range j.subscribers {
+		if len(stream) == cap(stream) {
+			result.FullSubscribers++
+		}
+	}
+	return result
+}
@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -16,12 +17,18 @@ var (
 	sectionPattern    = regexp.MustCompile(`^\[\s*([^\]]+?)\s*\]\s*(?:[;#].*)?$`)
 )
 
-const commonEnvSectionKey = "__common_env__"
-
 type DiscoveredDevice struct {
 	Name       string
 	BuildFlags []string
 	LibDeps    []string
+
+	// RelativePath is the variant directory below variants/; it is empty
+	// for envs declared in the root platformio.ini.
+	RelativePath string
+	Platform     string
+	Board        string
+	MCU          string
+	Hints        DeviceHints
 }
 
 type variantProject struct {
@@ -31,29 +38,38 @@ type variantProject struct {
 	EnvName      string
 	EnvNames     []string
 	EnvOptions   map[string]BuildOptions
+	EnvSettings  map[string]map[string]string
 }
 
-func discoverDevices(ctx context.Context, discoveryRoot string, repoURL string, ref string) ([]DiscoveredDevice, error) {
+type cloneFunc func(ctx context.Context, repoURL string, ref string, destination string, onLine func(string)) error
+
+func discoverDevices(ctx context.Context, discoveryRoot string, repoURL string, ref string, clone cloneFunc) (discoveryResult, error) {
 	tempDir, err := os.MkdirTemp(discoveryRoot, "discover-*")
 	if err != nil {
-		return nil, fmt.Errorf("create discovery workspace: %w", err)
+		return discoveryResult{}, fmt.Errorf("create discovery workspace: %w", err)
 	}
 	defer os.RemoveAll(tempDir)
 
 	repoPath := filepath.Join(tempDir, "repo")
-	if err := cloneRepository(ctx, repoURL, ref, repoPath, nil); err != nil {
-		return nil, err
+	if err := clone(ctx, repoURL, ref, repoPath, nil); err != nil {
+		return discoveryResult{}, err
 	}
 
-	devices, err := listVariantDevices(repoPath)
+	warnings := newDiscoveryWarnings()
+	devices, err := scanVariantDevices(repoPath, warnings)
 	if err != nil {
-		return nil, err
+		return discoveryResult{}, err
 	}
 	if len(devices) == 0 {
-		return nil, fmt.Errorf("no final devices found in variants directory")
+		return discoveryResult{}, &NoDevicesError{Warnings: warnings.list()}
 	}
 
-	return devices, nil
+	commit, err := resolveRepositoryCommit(ctx, repoPath)
+	if err != nil {
+		commit = ""
+	}
+
+	return discoveryResult{devices: devices, commit: commit, warnings: warnings.list()}, nil
 }
 
 func listVariantDirectories(repoPath string) ([]string, error) {
@@ -70,14 +86,20 @@ func listVariantDirectories(repoPath string) ([]string, error) {
 }
 
 func listVariantDevices(repoPath string) ([]DiscoveredDevice, error) {
+	return scanVariantDevices(repoPath, nil)
+}
+
+// scanVariantDevices lists the devices of a checkout and reports skipped
+// or partially resolved configuration to warnings.
+func scanVariantDevices(repoPath string, warnings *discoveryWarnings) ([]DiscoveredDevice, error) {
 	variantsDir := filepath.Join(repoPath, "variants")
-	entries, err := collectVariantProjects(variantsDir)
+	entries, err := collectVariantProjects(variantsDir, warnings)
 	if err != nil {
 		return nil, err
 	}
 
 	devices := make([]DiscoveredDevice, 0, len(entries)*2)
-	seen := make(map[string]struct{}, len(entries)*2)
+	seen := make(map[string]string, len(entries)*2)
 	for _, entry := range entries {
 		for _, envName := range entry.EnvNames {
 			target := strings.TrimSpace(envName)
@@ -87,16 +109,26 @@ func listVariantDevices(repoPath string) ([]DiscoveredDevice, error) {
 			if err := ValidateDeviceSelection(target); err != nil {
 				continue
 			}
-			if _, exists := seen[target]; exists {
+			if first, exists := seen[target]; exists {
+				warnings.addf(WarningDuplicateEnv, variantConfigFile(entry.RelativePath), 0, target, "env %q is skipped, it is already defined in %s", target, first)
 				continue
 			}
-			seen[target] = struct{}{}
+			seen[target] = variantConfigFile(entry.RelativePath)
 
 			options := entry.EnvOptions[target]
+			settings := entry.EnvSettings[target]
+			platform, mcu := detectDevicePlatform(settings, entry.RelativePath)
+			hints := detectDeviceHints(options.BuildFlags)
+			hints.Radios = detectDeviceRadios(options.BuildFlags, target, settings["board"])
 			devices = append(devices, DiscoveredDevice{
-				Name:       target,
-				BuildFlags: append([]string(nil), options.BuildFlags...),
-				LibDeps:    append([]string(nil), options.LibDeps...),
+				Name:         target,
+				BuildFlags:   append([]string(nil), options.BuildFlags...),
+				LibDeps:      append([]string(nil), options.LibDeps...),
+				RelativePath: entry.RelativePath,
+				Platform:     platform,
+				Board:        settings["board"],
+				MCU:          mcu,
+				Hints:        hints,
 			})
 		}
 	}
@@ -114,7 +146,7 @@ func findVariantProject(repoPath string, selection string) (variantProject, erro
 	}
 
 	variantsDir := filepath.Join(repoPath, "variants")
-	entries, err := collectVariantProjects(variantsDir)
+	entries, err := collectVariantProjects(variantsDir, nil)
 	if err != nil {
 		return variantProject{}, err
 	}
@@ -138,6 +170,9 @@ func findVariantProject(repoPath string, selection string) (variantProject, erro
 			}
 		}
 	}
+	if len(envMatches) > 1 {
+		envMatches = preferVariantProjects(envMatches)
+	}
 	if len(envMatches) == 1 {
 		return envMatches[0], nil
 	}
@@ -170,6 +205,21 @@ func findVariantProject(repoPath string, selection string) (variantProject, erro
 	return resolveEntryEnvironment(nameMatches[0])
 }
 
+// preferVariantProjects drops root platformio.ini matches when a variant
+// defines the same env, matching the device list of listVariantDevices.
+func preferVariantProjects(matches []variantProject) []variantProject {
+	variants := make([]variantProject, 0, len(matches))
+	for _, match := range matches {
+		if match.RelativePath != "" {
+			variants = append(variants, match)
+		}
+	}
+	if len(variants) == 0 {
+		return matches
+	}
+	return variants
+}
+
 func resolveEntryEnvironment(entry variantProject) (variantProject, error) {
 	if len(entry.EnvNames) == 0 {
 		return variantProject{}, fmt.Errorf("device %q has no [env:*] targets in platformio.ini", entry.RelativePath)
@@ -190,10 +240,15 @@ func resolveEntryEnvironment(entry variantProject) (variantProject, error) {
 	return variantProject{}, fmt.Errorf("device %q has multiple build targets, choose one of: %s", entry.RelativePath, strings.Join(entry.EnvNames, ", "))
 }
 
-func collectVariantProjects(variantsDir string) ([]variantProject, error) {
+func collectVariantProjects(variantsDir string, warnings *discoveryWarnings) ([]variantProject, error) {
 	entries := make([]variantProject, 0, 128)
+	repoPath := filepath.Dir(variantsDir)
+	project, rootEnvNames, err := loadProjectPlatformIOConfig(repoPath, warnings)
+	if err != nil {
+		return nil, err
+	}
 
-	err := filepath.WalkDir(variantsDir, func(path string, entry fs.DirEntry, walkErr error) error {
+	err = filepath.WalkDir(variantsDir, func(path string, entry fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
@@ -227,14 +282,16 @@ func collectVariantProjects(variantsDir string) ([]variantProject, error) {
 		}
 		relPath = filepath.ToSlash(relPath)
 		if err := ValidateDeviceSelection(relPath); err != nil {
+			warnings.addf(WarningInvalidVariantPath, variantConfigFile(relPath), 0, "", "variant directory is skipped: %v", err)
 			return nil
 		}
 
-		envNames, envOptions, err := extractPlatformIOEnvConfig(path)
+		config, err := readVariantEnvConfig(path, project, variantConfigFile(relPath))
 		if err != nil {
 			return err
 		}
-		if len(envNames) == 0 {
+		if len(config.EnvNames) == 0 {
+			warnings.addf(WarningNoEnvs, variantConfigFile(relPath), 0, "", "no valid [env:NAME] section")
 			return nil
 		}
 
@@ -242,12 +299,13 @@ func collectVariantProjects(variantsDir string) ([]variantProject, error) {
 			Name:         name,
 			RelativePath: relPath,
 			AbsolutePath: path,
-			EnvNames:     envNames,
-			EnvOptions:   envOptions,
+			EnvNames:     config.EnvNames,
+			EnvOptions:   config.Options,
+			EnvSettings:  config.Settings,
 		})
 		return filepath.SkipDir
 	})
-	if err != nil {
+	if err != nil && !(errors.Is(err, fs.ErrNotExist) && len(rootEnvNames) > 0) {
 		return nil, fmt.Errorf("read variants directory: %w", err)
 	}
 
@@ -255,9 +313,30 @@ func collectVariantProjects(variantsDir string) ([]variantProject, error) {
 		return entries[i].RelativePath < entries[j].RelativePath
 	})
 
+	// Envs declared directly in the root platformio.ini come last, so a
+	// variant env of the same name wins.
+	if len(rootEnvNames) > 0 {
+		config := resolveEnvConfig(project, rootEnvNames)
+		entries = append(entries, variantProject{
+			AbsolutePath: repoPath,
+			EnvNames:     config.EnvNames,
+			EnvOptions:   config.Options,
+			EnvSettings:  config.Settings,
+		})
+	}
+
 	return entries, nil
 }
 
+// variantConfigFile returns the repository-relative platformio.ini path of
+// a variant; the root file for an empty relative path.
+func variantConfigFile(relativePath string) string {
+	if relativePath == "" {
+		return "platformio.ini"
+	}
+	return "variants/" + relativePath + "/platformio.ini"
+}
+
 func hasPlatformIOIni(devicePath string) (bool, error) {
 	configPath := filepath.Join(devicePath, "platformio.ini")
 	info, err := os.Stat(configPath)
@@ -279,113 +358,76 @@ func extractPlatformIOEnvNames(devicePath string) ([]string, error) {
 }
 
 func extractPlatformIOEnvConfig(devicePath string) ([]string, map[string]BuildOptions, error) {
-	configPath := filepath.Join(devicePath, "platformio.ini")
-	content, err := os.ReadFile(configPath)
+	config, err := readPlatformIOEnvConfig(devicePath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("read platformio.ini: %w", err)
+		return nil, nil, err
 	}
+	return config.EnvNames, config.Options, nil
+}
 
-	envNames := make([]string, 0, 4)
-	seen := make(map[string]struct{}, 4)
-	allOptions := make(map[string]BuildOptions, 4)
-
-	currentEnv := ""
-	currentOption := ""
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			currentOption = ""
-			continue
-		}
-		if strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#") {
-			continue
-		}
-
-		sectionMatch := sectionPattern.FindStringSubmatch(trimmed)
-		if len(sectionMatch) == 2 {
-			sectionName := strings.TrimSpace(sectionMatch[1])
-			currentOption = ""
-
-			if strings.EqualFold(sectionName, "env") {
-				currentEnv = commonEnvSectionKey
-				if _, exists := allOptions[currentEnv]; !exists {
-					allOptions[currentEnv] = BuildOptions{}
-				}
-				continue
-			}
-
-			envMatch := envSectionPattern.FindStringSubmatch(trimmed)
-			if len(envMatch) != 2 {
-				currentEnv = ""
-				continue
-			}
+// platformIOEnvConfig is the effective configuration of the [env:*]
+// sections of one platformio.ini.
+type platformIOEnvConfig struct {
+	EnvNames []string
+	Options  map[string]BuildOptions
+	// Settings holds the scalar options listed in envSettingKeys per env,
+	// resolved like Options.
+	Settings map[string]map[string]string
+}
 
-			envName := strings.TrimSpace(envMatch[1])
-			if envName == "" {
-				currentEnv = ""
-				continue
-			}
-			if err := ValidateDevice(envName); err != nil {
-				currentEnv = ""
-				continue
-			}
+// envSettingKeys are the scalar options kept for device metadata.
+var envSettingKeys = map[string]bool{
+	"board":           true,
+	"platform":        true,
+	"board_build.mcu": true,
+	"extends":         true,
+}
 
-			currentEnv = envName
-			if _, exists := allOptions[currentEnv]; !exists {
-				allOptions[currentEnv] = BuildOptions{}
-			}
-			if _, exists := seen[envName]; !exists {
-				seen[envName] = struct{}{}
-				envNames = append(envNames, envName)
-			}
-			continue
-		}
+func readPlatformIOEnvConfig(devicePath string) (platformIOEnvConfig, error) {
+	return readVariantEnvConfig(devicePath, nil, "platformio.ini")
+}
 
-		if currentEnv == "" {
-			currentOption = ""
-			continue
-		}
+// readVariantEnvConfig parses a variant's platformio.ini and resolves the
+// effective options of its envs, following extends and ${...} references
+// into project, the repository's root config. source names the file in
+// warnings.
+func readVariantEnvConfig(devicePath string, project *platformIOConfig, source string) (platformIOEnvConfig, error) {
+	configPath := filepath.Join(devicePath, "platformio.ini")
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return platformIOEnvConfig{}, fmt.Errorf("read platformio.ini: %w", err)
+	}
 
-		isContinuation := (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && currentOption != ""
-		if isContinuation {
-			value := parseOptionValue(trimmed)
-			if value != "" {
-				appendBuildOptionValue(allOptions, currentEnv, currentOption, value)
-			}
-			continue
-		}
+	config := newPlatformIOConfig(project)
+	config.source = source
+	envNames := config.parse(string(content))
+	return resolveEnvConfig(config, envNames), nil
+}
 
-		key, value, ok := splitIniOption(trimmed)
-		if !ok {
-			currentOption = ""
-			continue
+// resolveEnvConfig computes the effective options and settings of envNames.
+func resolveEnvConfig(config *platformIOConfig, envNames []string) platformIOEnvConfig {
+	resolvedOptions := make(map[string]BuildOptions, len(envNames))
+	resolvedSettings := make(map[string]map[string]string, len(envNames))
+	for _, envName := range envNames {
+		resolvedOptions[envName] = BuildOptions{
+			BuildFlags: config.envList(envName, "build_flags"),
+			LibDeps:    config.envList(envName, "lib_deps"),
 		}
 
-		normalizedKey := strings.ToLower(key)
-		switch normalizedKey {
-		case "build_flags", "lib_deps":
-			currentOption = normalizedKey
-			parsedValue := parseOptionValue(value)
-			if parsedValue != "" {
-				appendBuildOptionValue(allOptions, currentEnv, currentOption, parsedValue)
+		settings := make(map[string]string, len(envSettingKeys))
+		for key := range envSettingKeys {
+			if value := strings.TrimSpace(config.envValue(envName, key)); value != "" {
+				settings[key] = value
 			}
-		default:
-			currentOption = ""
 		}
+		resolvedSettings[envName] = settings
 	}
 
-	resolvedOptions := make(map[string]BuildOptions, len(envNames))
-	commonOptions := allOptions[commonEnvSectionKey]
-	for _, envName := range envNames {
-		envOptions := allOptions[envName]
-		resolvedOptions[envName] = BuildOptions{
-			BuildFlags: append(append([]string(nil), commonOptions.BuildFlags...), envOptions.BuildFlags...),
-			LibDeps:    append(append([]string(nil), commonOptions.LibDeps...), envOptions.LibDeps...),
-		}
+	return platformIOEnvConfig{
+		EnvNames: envNames,
+		Options:  resolvedOptions,
+		Settings: resolvedSettings,
 	}
-
-	return envNames, resolvedOptions, nil
 }
 
 func splitIniOption(line string) (string, string, bool) {
@@ -427,14 +469,3 @@ func parseOptionValue(value string) string {
 
 	return trimmed
 }
-
-func appendBuildOptionValue(options map[string]BuildOptions, envName string, option string, value string) {
-	entry := options[envName]
-	switch option {
-	case "build_flags":
-		entry.BuildFlags = append(entry.BuildFlags, value)
-	case "lib_deps":
-		entry.LibDeps = append(entry.LibDeps, value)
-	}
-	options[envName] = entry
-}
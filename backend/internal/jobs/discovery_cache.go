@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxDiscoveryCacheEntries = 256
+
+// discoveryCache keeps discovered device lists keyed by repository URL and
+// resolved commit. Entries for a commit never change, so they are only
+// evicted when the cache grows beyond its capacity.
+type discoveryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]discoveryCacheEntry
+}
+
+type discoveryCacheEntry struct {
+	devices  []DiscoveredDevice
+	warnings []DiscoveryWarning
+	storedAt time.Time
+}
+
+func newDiscoveryCache(capacity int) *discoveryCache {
+	if capacity < 1 {
+		capacity = maxDiscoveryCacheEntries
+	}
+	return &discoveryCache{
+		capacity: capacity,
+		entries:  make(map[string]discoveryCacheEntry, capacity),
+	}
+}
+
+func (c *discoveryCache) get(repoURL string, commit string) ([]DiscoveredDevice, []DiscoveryWarning, bool) {
+	if c == nil {
+		return nil, nil, false
+	}
+	key := discoveryCacheKey(repoURL, commit)
+	if key == "" {
+		return nil, nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	return cloneDiscoveredDevices(entry.devices), cloneDiscoveryWarnings(entry.warnings), true
+}
+
+func (c *discoveryCache) put(repoURL string, commit string, devices []DiscoveredDevice, warnings []DiscoveryWarning, now time.Time) {
+	if c == nil || len(devices) == 0 {
+		return
+	}
+	key := discoveryCacheKey(repoURL, commit)
+	if key == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.capacity {
+		c.evictOldestLocked()
+	}
+	c.entries[key] = discoveryCacheEntry{
+		devices:  cloneDiscoveredDevices(devices),
+		warnings: cloneDiscoveryWarnings(warnings),
+		storedAt: now,
+	}
+}
+
+// removeRepo drops every cached result of repoURL and returns how many
+// entries were removed.
+func (c *discoveryCache) removeRepo(repoURL string) int {
+	repo := strings.TrimSpace(repoURL)
+	if c == nil || repo == "" {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key := range c.entries {
+		if strings.HasPrefix(key, repo+"@") {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *discoveryCache) evictOldestLocked() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey = key
+			oldest = entry.storedAt
+		}
+	}
+	if oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+func discoveryCacheKey(repoURL string, commit string) string {
+	repo := strings.TrimSpace(repoURL)
+	hash := strings.ToLower(strings.TrimSpace(commit))
+	if repo == "" || !isValidCommitHash(hash) {
+		return ""
+	}
+	return repo + "@" + hash
+}
+
+func cloneDiscoveredDevices(devices []DiscoveredDevice) []DiscoveredDevice {
+	cloned := make([]DiscoveredDevice, len(devices))
+	for index, device := range devices {
+		cloned[index] = device
+		cloned[index].BuildFlags = append([]string(nil), device.BuildFlags...)
+		cloned[index].LibDeps = append([]string(nil), device.LibDeps...)
+		cloned[index].Hints = device.Hints.clone()
+	}
+	return cloned
+}
+
+// resolveRemoteCommit maps a ref to a commit hash without cloning. Full
+// commit hashes are returned as-is; branches and tags are resolved with
+// ls-remote, preferring the peeled commit of annotated tags.
+func resolveRemoteCommit(ctx context.Context, repoURL string, ref string) (string, error) {
+	value := strings.TrimSpace(ref)
+	lowered := strings.ToLower(value)
+	if len(lowered) == 40 && isValidCommitHash(lowered) {
+		return lowered, nil
+	}
+
+	patterns := []string{"HEAD"}
+	if value != "" {
+		patterns = []string{value, value + "^{}"}
+	}
+
+	output, err := runGitCapture(ctx, append([]string{"ls-remote", repoURL}, patterns...)...)
+	if err != nil {
+		return "", err
+	}
+	return parseLsRemoteCommit(output, value), nil
+}
+
+func parseLsRemoteCommit(output string, ref string) string {
+	candidates := []string{"HEAD"}
+	if ref != "" {
+		candidates = []string{
+			"refs/tags/" + ref + "^{}",
+			"refs/heads/" + ref,
+			"refs/tags/" + ref,
+			ref,
+		}
+	}
+
+	commits := make(map[string]string, 4)
+	for _, rawLine := range strings.Split(output, "\n") {
+		parts := strings.Split(strings.TrimSpace(rawLine), "\t")
+		if len(parts) != 2 {
+			continue
+		}
+		commit := strings.ToLower(strings.TrimSpace(parts[0]))
+		if !isValidCommitHash(commit) {
+			continue
+		}
+		commits[strings.TrimSpace(parts[1])] = commit
+	}
+
+	for _, candidate := range candidates {
+		if commit, ok := commits[candidate]; ok {
+			return commit
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiscoveryCacheStoresByCommit(t *testing.T) {
+	t.Parallel()
+
+	cache := newDiscoveryCache(2)
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	commitA := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	commitB := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	commitC := "cccccccccccccccccccccccccccccccccccccccc"
+	repoURL := "https://github.com/example/firmware.git"
+
+	cache.put(repoURL, commitA, []DiscoveredDevice{{Name: "tbeam", BuildFlags: []string{"-DA"}}}, nil, now)
+	cache.put(repoURL, commitB, []DiscoveredDevice{{Name: "t-echo"}}, nil, now.Add(time.Minute))
+
+	got, _, ok := cache.get(repoURL, commitA)
+	if !ok || len(got) != 1 || got[0].Name != "tbeam" {
+		t.Fatalf("expected cached devices for commit A, got=%v ok=%v", got, ok)
+	}
+
+	got[0].BuildFlags[0] = "-DMUTATED"
+	again, _, _ := cache.get(repoURL, commitA)
+	if again[0].BuildFlags[0] != "-DA" {
+		t.Fatalf("cached entry must not be mutated through returned slices")
+	}
+
+	if _, _, ok := cache.get("https://github.com/other/firmware.git", commitA); ok {
+		t.Fatalf("cache must be keyed by repository URL")
+	}
+	if _, _, ok := cache.get(repoURL, ""); ok {
+		t.Fatalf("empty commit must never hit the cache")
+	}
+
+	cache.put(repoURL, commitC, []DiscoveredDevice{{Name: "rak4631"}}, nil, now.Add(2*time.Minute))
+	if _, _, ok := cache.get(repoURL, commitA); ok {
+		t.Fatalf("oldest entry should be evicted when capacity is exceeded")
+	}
+	if _, _, ok := cache.get(repoURL, commitC); !ok {
+		t.Fatalf("newest entry should be present")
+	}
+}
+
+func TestParseLsRemoteCommit(t *testing.T) {
+	t.Parallel()
+
+	output := "1111111111111111111111111111111111111111\tHEAD\n" +
+		"2222222222222222222222222222222222222222\trefs/heads/main\n" +
+		"3333333333333333333333333333333333333333\trefs/tags/v2.5.0\n" +
+		"4444444444444444444444444444444444444444\trefs/tags/v2.5.0^{}\n"
+
+	cases := map[string]string{
+		"":       "1111111111111111111111111111111111111111",
+		"main":   "2222222222222222222222222222222222222222",
+		"v2.5.0": "4444444444444444444444444444444444444444",
+		"other":  "",
+	}
+	for ref, want := range cases {
+		if got := parseLsRemoteCommit(output, ref); got != want {
+			t.Fatalf("ref %q: got=%q want=%q", ref, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrDiscoveryBusy is returned when every discovery slot is taken and the
+// wait queue is full.
+var ErrDiscoveryBusy = errors.New("discovery is busy, try again shortly")
+
+// DiscoveryLoad describes the discovery clones in progress.
+type DiscoveryLoad struct {
+	Active int `json:"active"`
+	Queued int `json:"queued"`
+	Limit  int `json:"limit"`
+}
+
+// discoveryLimiter bounds simultaneous discovery clones independently of
+// the build workers. Up to maxQueued callers wait for a free slot; further
+// callers fail with ErrDiscoveryBusy.
+type discoveryLimiter struct {
+	slots     chan struct{}
+	maxQueued int
+
+	mu     sync.Mutex
+	queued int
+}
+
+func newDiscoveryLimiter(limit int, maxQueued int) *discoveryLimiter {
+	if limit < 1 {
+		return nil
+	}
+	return &discoveryLimiter{slots: make(chan struct{}, limit), maxQueued: maxQueued}
+}
+
+// acquire waits for a slot and returns the function releasing it. A nil
+// limiter does not limit anything.
+func (l *discoveryLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	l.mu.Lock()
+	if l.queued >= l.maxQueued {
+		l.mu.Unlock()
+		return nil, ErrDiscoveryBusy
+	}
+	l.queued++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+	}()
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *discoveryLimiter) load() DiscoveryLoad {
+	if l == nil {
+		return DiscoveryLoad{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return DiscoveryLoad{Active: len(l.slots), Queued: l.queued, Limit: cap(l.slots)}
+}
+
+// DiscoveryLoad reports the discovery clones running and waiting.
+func (m *Manager) DiscoveryLoad() DiscoveryLoad {
+	return m.discoveryLimit.load()
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDiscoveryLimiterQueuesAndRejects(t *testing.T) {
+	t.Parallel()
+
+	limiter := newDiscoveryLimiter(1, 1)
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire first slot: %v", err)
+	}
+
+	acquired := make(chan func(), 1)
+	go func() {
+		queuedRelease, err := limiter.acquire(context.Background())
+		if err != nil {
+			t.Errorf("queued acquire: %v", err)
+			acquired <- func() {}
+			return
+		}
+		acquired <- queuedRelease
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for limiter.load().Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("second caller was not queued: %+v", limiter.load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := limiter.acquire(context.Background()); !errors.Is(err, ErrDiscoveryBusy) {
+		t.Fatalf("expected ErrDiscoveryBusy with a full queue, got %v", err)
+	}
+	if load := limiter.load(); load != (DiscoveryLoad{Active: 1, Queued: 1, Limit: 1}) {
+		t.Fatalf("unexpected load: %+v", load)
+	}
+
+	release()
+	(<-acquired)()
+	if load := limiter.load(); load != (DiscoveryLoad{Limit: 1}) {
+		t.Fatalf("unexpected load after release: %+v", load)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hold, _ := limiter.acquire(context.Background())
+	cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	hold()
+
+	var unlimited *discoveryLimiter
+	if release, err := unlimited.acquire(context.Background()); err != nil {
+		t.Fatalf("nil limiter must not limit: %v", err)
+	} else {
+		release()
+	}
+}
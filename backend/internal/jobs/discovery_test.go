@@ -330,3 +330,126 @@ lib_deps =
 		t.Fatalf("unexpected lib deps: %v", devices[0].LibDeps)
 	}
 }
+
+func TestListVariantDevicesIncludesRootEnvs(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"platformio.ini": `[env]
+platform = espressif32
+build_flags = -DFORK=1
+
+[env:fork-board]
+board = esp32dev
+
+[env:tbeam]
+board = ttgo-t-beam
+`,
+		"variants/esp32/tbeam/platformio.ini": "[env:tbeam]\nboard = ttgo-t-beam\n",
+	})
+
+	devices, err := listVariantDevices(root)
+	if err != nil {
+		t.Fatalf("listVariantDevices failed: %v", err)
+	}
+	names := make([]string, 0, len(devices))
+	for _, device := range devices {
+		names = append(names, device.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"fork-board", "tbeam"}) {
+		t.Fatalf("unexpected devices: %v", names)
+	}
+	if devices[0].RelativePath != "" || devices[0].Platform != PlatformESP32 || !reflect.DeepEqual(devices[0].BuildFlags, []string{"-DFORK=1"}) {
+		t.Fatalf("unexpected root env device: %+v", devices[0])
+	}
+	if devices[1].RelativePath != "esp32/tbeam" {
+		t.Fatalf("variant env must win over the root env: %+v", devices[1])
+	}
+
+	project, err := findVariantProject(root, "fork-board")
+	if err != nil {
+		t.Fatalf("findVariantProject failed: %v", err)
+	}
+	if project.AbsolutePath != root || project.EnvName != "fork-board" {
+		t.Fatalf("unexpected root project: %+v", project)
+	}
+	project, err = findVariantProject(root, "tbeam")
+	if err != nil || project.RelativePath != "esp32/tbeam" {
+		t.Fatalf("expected the variant project for tbeam, got %+v err=%v", project, err)
+	}
+}
+
+func TestListVariantDevicesWithoutVariantsDirectory(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"platformio.ini": "[env:custom]\nboard = pico\n"})
+
+	devices, err := listVariantDevices(root)
+	if err != nil {
+		t.Fatalf("listVariantDevices failed: %v", err)
+	}
+	if len(devices) != 1 || devices[0].Name != "custom" {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+
+	if _, err := listVariantDevices(t.TempDir()); err == nil {
+		t.Fatalf("expected error without variants directory and root envs")
+	}
+}
+
+func TestScanVariantDevicesReportsWarnings(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"platformio.ini": "[platformio]\ndefault_envs = tbeam\n",
+		"variants/tbeam/platformio.ini": `[env:tbeam]
+extends = esp32_base
+build_flags = ${esp32_base.build_flags} -DTBEAM ${platformio.build_dir}
+
+[env:bad name]
+board = x
+
+[env:broken
+board = leaked
+`,
+		"variants/copy/platformio.ini":  "[env:tbeam]\nboard = other\n",
+		"variants/empty/platformio.ini": "[common]\nboard = none\nnot an option\n",
+		"variants/b@d/platformio.ini":   "[env:weird]\n",
+	})
+
+	warnings := newDiscoveryWarnings()
+	devices, err := scanVariantDevices(root, warnings)
+	if err != nil {
+		t.Fatalf("scanVariantDevices failed: %v", err)
+	}
+	if len(devices) != 1 || devices[0].Name != "tbeam" || devices[0].RelativePath != "copy" {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+
+	got := make(map[string]DiscoveryWarning)
+	for _, warning := range warnings.list() {
+		got[warning.Code] = warning
+	}
+	want := map[string]DiscoveryWarning{
+		WarningInvalidVariantPath:  {File: "variants/b@d/platformio.ini"},
+		WarningNoEnvs:              {File: "variants/empty/platformio.ini"},
+		WarningMalformedOption:     {File: "variants/empty/platformio.ini", Line: 3},
+		WarningInvalidEnvName:      {File: "variants/tbeam/platformio.ini", Line: 5, Env: "bad name"},
+		WarningMalformedSection:    {File: "variants/tbeam/platformio.ini", Line: 8},
+		WarningUnknownExtends:      {Env: "tbeam"},
+		WarningUnresolvedReference: {Env: "tbeam"},
+		WarningDuplicateEnv:        {File: "variants/tbeam/platformio.ini", Env: "tbeam"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected warnings: got=%+v", warnings.list())
+	}
+	for code, expected := range want {
+		warning, ok := got[code]
+		if !ok || warning.File != expected.File || warning.Line != expected.Line || warning.Env != expected.Env || warning.Message == "" {
+			t.Fatalf("unexpected %s warning: got=%+v want=%+v", code, warning, expected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// maxDiscoveryWarnings bounds the warnings reported for one discovery, so
+// a broken fork cannot produce an unbounded response.
+const maxDiscoveryWarnings = 200
+
+// Discovery warning codes.
+const (
+	WarningMalformedSection    = "MALFORMED_SECTION"
+	WarningMalformedOption     = "MALFORMED_OPTION"
+	WarningInvalidEnvName      = "INVALID_ENV_NAME"
+	WarningUnresolvedReference = "UNRESOLVED_REFERENCE"
+	WarningUnknownExtends      = "UNKNOWN_EXTENDS"
+	WarningDuplicateEnv        = "DUPLICATE_ENV"
+	WarningInvalidVariantPath  = "INVALID_VARIANT_PATH"
+	WarningNoEnvs              = "NO_ENVS"
+	WarningUnreadableConfig    = "UNREADABLE_CONFIG"
+)
+
+// DiscoveryWarning explains why part of a repository's PlatformIO
+// configuration was skipped or could not be resolved during discovery.
+// File is relative to the repository root; Line is 1-based and zero when
+// the warning is not about a single line.
+type DiscoveryWarning struct {
+	Code    string `json:"code"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Env     string `json:"env,omitempty"`
+	Message string `json:"message"`
+}
+
+// NoDevicesError is returned when discovery finds no buildable env. The
+// warnings usually tell why.
+type NoDevicesError struct {
+	Warnings []DiscoveryWarning
+}
+
+func (e *NoDevicesError) Error() string {
+	return "no final devices found in variants directory or root platformio.ini"
+}
+
+// discoveryWarnings collects warnings without duplicates. A nil collector
+// discards everything, for callers that do not report warnings.
+type discoveryWarnings struct {
+	items []DiscoveryWarning
+	seen  map[string]bool
+}
+
+func newDiscoveryWarnings() *discoveryWarnings {
+	return &discoveryWarnings{items: make([]DiscoveryWarning, 0, 8), seen: make(map[string]bool, 8)}
+}
+
+func (w *discoveryWarnings) add(warning DiscoveryWarning) {
+	if w == nil || len(w.items) >= maxDiscoveryWarnings {
+		return
+	}
+	key := warning.Code + "\x00" + warning.File + "\x00" + strconv.Itoa(warning.Line) + "\x00" + warning.Env + "\x00" + warning.Message
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
+	w.items = append(w.items, warning)
+}
+
+func (w *discoveryWarnings) addf(code string, file string, line int, env string, format string, args ...interface{}) {
+	w.add(DiscoveryWarning{Code: code, File: file, Line: line, Env: env, Message: fmt.Sprintf(format, args...)})
+}
+
+func (w *discoveryWarnings) list() []DiscoveryWarning {
+	if w == nil {
+		return []DiscoveryWarning{}
+	}
+	return append([]DiscoveryWarning{}, w.items...)
+}
+
+func cloneDiscoveryWarnings(warnings []DiscoveryWarning) []DiscoveryWarning {
+	return append([]DiscoveryWarning{}, warnings...)
+}
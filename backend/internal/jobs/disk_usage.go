@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const bytesPerMB = 1 << 20
+
+// ErrLowDiskSpace is returned by CreateJob while a build directory has less
+// free space than APP_MIN_FREE_DISK_MB.
+var ErrLowDiskSpace = errors.New("not enough free disk space to start a build")
+
+// DiskUsage is the space left on the filesystem of one build directory.
+// Low is set when new builds are refused because of it.
+type DiskUsage struct {
+	Name       string `json:"name"`
+	Path       string `json:"-"`
+	FreeBytes  uint64 `json:"freeBytes"`
+	TotalBytes uint64 `json:"totalBytes"`
+	Low        bool   `json:"low,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// diskSpace is the free and total size of a filesystem in bytes.
+type diskSpace struct {
+	free  uint64
+	total uint64
+}
+
+// DiskUsage reports the free space of the work directory, the PlatformIO
+// cache and the firmware cache. Directories that are not configured are
+// skipped.
+func (m *Manager) DiskUsage() []DiskUsage {
+	dirs := []struct {
+		name string
+		path string
+	}{
+		{"workdir", m.cfg.WorkDir},
+		{"platformio-cache", m.cfg.PlatformIOCache},
+		{"firmware-cache", m.cfg.FirmwareCachePath},
+	}
+	minFree := uint64(max(m.cfg.MinFreeDiskMB, 0)) * bytesPerMB
+	usage := make([]DiskUsage, 0, len(dirs))
+	for _, dir := range dirs {
+		if strings.TrimSpace(dir.path) == "" {
+			continue
+		}
+		entry := DiskUsage{Name: dir.name, Path: dir.path}
+		space, err := m.diskStat(dir.path)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.FreeBytes = space.free
+			entry.TotalBytes = space.total
+			entry.Low = space.free < minFree
+		}
+		usage = append(usage, entry)
+	}
+	return usage
+}
+
+// checkDiskSpace fails with ErrLowDiskSpace when a build directory is below
+// the free space threshold. Directories that cannot be inspected do not
+// block builds; they show up in DiskUsage instead.
+func (m *Manager) checkDiskSpace() error {
+	if m.cfg.MinFreeDiskMB <= 0 {
+		return nil
+	}
+	for _, usage := range m.DiskUsage() {
+		if usage.Low {
+			return fmt.Errorf("%w: %s has %d MB free, at least %d MB are required",
+				ErrLowDiskSpace, usage.Name, usage.FreeBytes/bytesPerMB, m.cfg.MinFreeDiskMB)
+		}
+	}
+	return nil
+}
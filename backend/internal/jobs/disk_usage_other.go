@@ -0,0 +1,9 @@
+//go:build !unix
+
+package jobs
+
+import "errors"
+
+func statDisk(string) (diskSpace, error) {
+	return diskSpace{}, errors.New("disk usage is not supported on this platform")
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestManagerRefusesBuildsOnLowDiskSpace(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	manager := NewManager(config.Config{
+		WorkDir:           workDir,
+		JobsRootPath:      filepath.Join(workDir, "jobs"),
+		BuildLogsPath:     filepath.Join(workDir, "build-logs"),
+		PlatformIOCache:   filepath.Join(workDir, "platformio-cache"),
+		FirmwareCachePath: filepath.Join(workDir, "firmware-cache"),
+		MinFreeDiskMB:     512,
+		MaxLogLines:       200,
+		CleanupInterval:   time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+	manager.dockerCheck = func(context.Context) error { return nil }
+
+	free := map[string]uint64{
+		workDir: 4 << 30,
+		filepath.Join(workDir, "platformio-cache"): 100 << 20,
+	}
+	manager.diskStat = func(path string) (diskSpace, error) {
+		space, ok := free[path]
+		if !ok {
+			return diskSpace{}, errors.New("no such filesystem")
+		}
+		return diskSpace{free: space, total: 8 << 30}, nil
+	}
+
+	usage := manager.DiskUsage()
+	if len(usage) != 3 || usage[0].Low || !usage[1].Low || usage[1].Name != "platformio-cache" || usage[2].Error == "" || usage[2].Low {
+		t.Fatalf("unexpected disk usage: %+v", usage)
+	}
+
+	_, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "203.0.113.9")
+	if !errors.Is(err, ErrLowDiskSpace) {
+		t.Fatalf("expected ErrLowDiskSpace, got %v", err)
+	}
+	if want := "platformio-cache has 100 MB free, at least 512 MB are required"; err.Error() != ErrLowDiskSpace.Error()+": "+want {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+	for _, check := range manager.Readiness(context.Background()).Checks {
+		if check.Name == "disk" && check.OK {
+			t.Fatalf("expected the disk readiness check to fail")
+		}
+	}
+
+	// A directory that cannot be inspected does not block builds.
+	free[filepath.Join(workDir, "platformio-cache")] = 1 << 30
+	if _, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "203.0.113.9"); err != nil {
+		t.Fatalf("create job with enough space: %v", err)
+	}
+}
+
+func TestStatDisk(t *testing.T) {
+	t.Parallel()
+
+	space, err := statDisk(t.TempDir())
+	if err != nil {
+		t.Skipf("disk usage unavailable: %v", err)
+	}
+	if space.total == 0 || space.free > space.total {
+		t.Fatalf("unexpected disk space: %+v", space)
+	}
+}
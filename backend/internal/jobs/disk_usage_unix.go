@@ -0,0 +1,17 @@
+//go:build unix
+
+package jobs
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func statDisk(path string) (diskSpace, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return diskSpace{}, fmt.Errorf("stat filesystem of %s: %w", path, err)
+	}
+	blockSize := uint64(stat.Bsize)
+	return diskSpace{free: uint64(stat.Bavail) * blockSize, total: uint64(stat.Blocks) * blockSize}, nil
+}
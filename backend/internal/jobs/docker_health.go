@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dockerHealthTimeout = 10 * time.Second
+
+// ErrInfrastructureUnavailable fails builds that cannot run because the
+// docker daemon or the builder image is unavailable on this node.
+var ErrInfrastructureUnavailable = errors.New("infrastructure unavailable")
+
+// DockerHealth is the outcome of the latest periodic docker check: whether
+// the daemon answers and whether it has the builder image.
+type DockerHealth struct {
+	OK        bool      `json:"ok"`
+	Daemon    bool      `json:"daemon"`
+	Image     bool      `json:"image"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+type dockerHealthState struct {
+	mu     sync.RWMutex
+	result DockerHealth
+}
+
+// DockerHealth returns the latest docker check and false when periodic
+// checks are disabled or have not completed yet.
+func (m *Manager) DockerHealth() (DockerHealth, bool) {
+	if m.cfg.DockerHealthInterval <= 0 {
+		return DockerHealth{}, false
+	}
+	m.dockerHealth.mu.RLock()
+	defer m.dockerHealth.mu.RUnlock()
+	result := m.dockerHealth.result
+	return result, !result.CheckedAt.IsZero()
+}
+
+func (m *Manager) dockerHealthLoop() {
+	defer m.wg.Done()
+	m.refreshDockerHealth(m.ctx)
+
+	ticker := time.NewTicker(m.cfg.DockerHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.refreshDockerHealth(m.ctx)
+		}
+	}
+}
+
+// refreshDockerHealth checks the daemon and the builder image and, while
+// either is unavailable, fails every queued job at once instead of letting
+// each one time out in turn.
+func (m *Manager) refreshDockerHealth(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, dockerHealthTimeout)
+	defer cancel()
+
+	health := DockerHealth{CheckedAt: m.now()}
+	if err := m.dockerCheck(ctx); err != nil {
+		health.Error = err.Error()
+	} else if err := m.imageCheck(ctx, m.cfg.BuilderImage); err != nil {
+		health.Daemon = true
+		health.Error = err.Error()
+	} else {
+		health.Daemon, health.Image, health.OK = true, true, true
+	}
+	if m.ctx.Err() != nil {
+		return
+	}
+
+	m.dockerHealth.mu.Lock()
+	previous := m.dockerHealth.result
+	m.dockerHealth.result = health
+	m.dockerHealth.mu.Unlock()
+
+	switch {
+	case !health.OK && (previous.OK || previous.CheckedAt.IsZero()):
+		m.logger.Warn("docker: builds unavailable", "error", health.Error)
+	case health.OK && !previous.OK && !previous.CheckedAt.IsZero():
+		m.logger.Info("docker: builds available again")
+	}
+	if !health.OK {
+		m.failQueuedJobs(fmt.Errorf("%w: %s", ErrInfrastructureUnavailable, health.Error))
+	}
+}
+
+// infrastructureError returns the reason builds cannot run while the latest
+// docker check failed.
+func (m *Manager) infrastructureError() error {
+	health, ok := m.DockerHealth()
+	if !ok || health.OK {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInfrastructureUnavailable, health.Error)
+}
+
+// failQueuedJobs fails every queued job with err. These failures are not
+// the client's doing, so they do not count towards abuse detection.
+func (m *Manager) failQueuedJobs(err error) {
+	m.mu.RLock()
+	queued := make([]*Job, 0, len(m.queueOrder))
+	for _, jobID := range m.queueOrder {
+		if job, ok := m.jobs[jobID]; ok {
+			queued = append(queued, job)
+		}
+	}
+	m.mu.RUnlock()
+
+	failed := 0
+	for _, job := range queued {
+		if !job.failQueued(m.now(), err.Error(), FailureInfra) {
+			continue
+		}
+		m.removeQueuedJob(job.ID)
+		job.appendLog(m.cfg.MaxLogLines, "ERROR: "+err.Error())
+		m.saveBuildLog(job)
+		failed++
+	}
+	if failed > 0 {
+		m.logger.Warn("docker: failed queued jobs", "count", failed, "error", err)
+	}
+}
+
+// dockerReady is the docker readiness check: the latest periodic result
+// when those checks run, otherwise a direct check of the daemon.
+func (m *Manager) dockerReady(ctx context.Context) error {
+	health, ok := m.DockerHealth()
+	if !ok {
+		return m.dockerCheck(ctx)
+	}
+	if !health.OK {
+		return errors.New(health.Error)
+	}
+	return nil
+}
+
+func builderImagePresent(ctx context.Context, image string) error {
+	output, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", image).CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			message = err.Error()
+		}
+		return fmt.Errorf("builder image %s unavailable: %s", image, message)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestDockerHealthFailsQueuedJobs(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	manager := NewManager(config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		BuilderImage:    "meshtastic-pio-builder:latest",
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+
+	// Enabled after NewManager so no background loop races the stubs.
+	manager.cfg.DockerHealthInterval = time.Hour
+	if _, ok := manager.DockerHealth(); ok {
+		t.Fatalf("expected no docker health before the first check")
+	}
+	daemonErr := errors.New("docker daemon unreachable: connection refused")
+	imageErr := errors.New("builder image meshtastic-pio-builder:latest unavailable: no such image")
+	manager.dockerCheck = func(context.Context) error { return daemonErr }
+	manager.imageCheck = func(context.Context, string) error { return imageErr }
+
+	first, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "203.0.113.9")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	second, err := manager.CreateJob("https://github.com/example/repo.git", "main", "rak4631", BuildOptions{}, "203.0.113.9")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	manager.refreshDockerHealth(context.Background())
+	health, ok := manager.DockerHealth()
+	if !ok || health.OK || health.Daemon || health.Error != daemonErr.Error() {
+		t.Fatalf("unexpected docker health with the daemon down: %+v", health)
+	}
+	for _, jobID := range []string{first.ID, second.ID} {
+		state, err := manager.GetJob(jobID)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if state.Status != StatusFailed || !strings.HasPrefix(state.Error, ErrInfrastructureUnavailable.Error()+": docker daemon unreachable") {
+			t.Fatalf("expected the queued job to fail fast, got %s %q", state.Status, state.Error)
+		}
+	}
+	if queued, _ := manager.QueueLoad(); queued != 0 {
+		t.Fatalf("expected an empty queue, got %d jobs", queued)
+	}
+	if report := manager.AbuseReport(); len(report) != 0 {
+		t.Fatalf("infrastructure failures must not count as abuse: %+v", report)
+	}
+	for _, check := range manager.Readiness(context.Background()).Checks {
+		if check.Name == "docker" && (check.OK || check.Error != daemonErr.Error()) {
+			t.Fatalf("expected readiness to report the docker health: %+v", check)
+		}
+	}
+
+	// Jobs queued between two checks fail when a worker picks them.
+	late, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "203.0.113.9")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	job, err := manager.getJob(late.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	manager.executeJob(job)
+	if state := job.snapshot(); state.Status != StatusFailed || !strings.Contains(state.Error, "docker daemon unreachable") {
+		t.Fatalf("expected the worker to fail the job without building, got %s %q", state.Status, state.Error)
+	}
+
+	manager.dockerCheck = func(context.Context) error { return nil }
+	manager.refreshDockerHealth(context.Background())
+	if health, _ := manager.DockerHealth(); health.OK || !health.Daemon || health.Image || health.Error != imageErr.Error() {
+		t.Fatalf("unexpected docker health without the builder image: %+v", health)
+	}
+
+	manager.imageCheck = func(context.Context, string) error { return nil }
+	manager.refreshDockerHealth(context.Background())
+	if health, _ := manager.DockerHealth(); !health.OK || health.Error != "" {
+		t.Fatalf("unexpected docker health: %+v", health)
+	}
+	third, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "203.0.113.9")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if state, _ := manager.GetJob(third.ID); state.Status != StatusQueued {
+		t.Fatalf("expected jobs to queue again, got %s", state.Status)
+	}
+}
@@ -0,0 +1,207 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergedImageName is the file name of the single flash image produced for
+// ESP32 builds. It is written at offset 0x0.
+const MergedImageName = "firmware.merged.bin"
+
+const (
+	esp32ImageMagic          = 0xE9
+	esp32PartitionTableAddr  = 0x8000
+	esp32DefaultAppAddr      = 0x10000
+	esp32PartitionEntrySize  = 32
+	esp32PartitionTypeApp    = 0x00
+	esp32PartitionSubFactory = 0x00
+	esp32PartitionSubOTA0    = 0x10
+)
+
+// esp32BootloaderOffsets maps the chip id stored in the bootloader image
+// header to the flash address the ROM loads the bootloader from.
+var esp32BootloaderOffsets = map[uint16]int64{
+	0x0000: 0x1000, // ESP32
+	0x0002: 0x1000, // ESP32-S2
+	0x0005: 0x0,    // ESP32-C3
+	0x0009: 0x0,    // ESP32-S3
+	0x000C: 0x0,    // ESP32-C2
+	0x000D: 0x0,    // ESP32-C6
+	0x0010: 0x0,    // ESP32-H2
+	0x0012: 0x2000, // ESP32-P4
+	0x0017: 0x2000, // ESP32-C5
+}
+
+type flashSegment struct {
+	offset int64
+	path   string
+	size   int64
+}
+
+// writeMergedESP32Image combines bootloader.bin, partitions.bin and
+// firmware.bin from an ESP32 build directory into MergedImageName, the
+// same layout "esptool.py merge_bin" produces. Gaps are padded with 0xFF.
+// It reports false when the build is not an ESP32 build or already ships a
+// factory image.
+func writeMergedESP32Image(buildRoot string) (bool, error) {
+	entries, err := os.ReadDir(buildRoot)
+	if err != nil {
+		return false, fmt.Errorf("read build output directory: %w", err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(strings.ToLower(entry.Name()), ".factory.bin") {
+			return false, nil
+		}
+	}
+
+	bootloaderPath := filepath.Join(buildRoot, "bootloader.bin")
+	partitionsPath := filepath.Join(buildRoot, "partitions.bin")
+	appPath := filepath.Join(buildRoot, "firmware.bin")
+	for _, path := range []string{bootloaderPath, partitionsPath, appPath} {
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+
+	bootloaderOffset, err := esp32BootloaderOffset(bootloaderPath)
+	if err != nil {
+		return false, err
+	}
+	partitions, err := os.ReadFile(partitionsPath)
+	if err != nil {
+		return false, fmt.Errorf("read partition table: %w", err)
+	}
+
+	segments := make([]flashSegment, 0, 3)
+	for _, segment := range []flashSegment{
+		{offset: bootloaderOffset, path: bootloaderPath},
+		{offset: esp32PartitionTableAddr, path: partitionsPath},
+		{offset: esp32AppOffset(partitions), path: appPath},
+	} {
+		info, err := os.Stat(segment.path)
+		if err != nil {
+			return false, err
+		}
+		segment.size = info.Size()
+		if len(segments) > 0 {
+			previous := segments[len(segments)-1]
+			if previous.offset+previous.size > segment.offset {
+				return false, fmt.Errorf("%s overlaps %s at 0x%x", filepath.Base(segment.path), filepath.Base(previous.path), segment.offset)
+			}
+		}
+		segments = append(segments, segment)
+	}
+
+	if err := writeFlashSegments(filepath.Join(buildRoot, MergedImageName), segments); err != nil {
+		return false, fmt.Errorf("write merged image: %w", err)
+	}
+	return true, nil
+}
+
+func esp32BootloaderOffset(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, fmt.Errorf("read bootloader header: %w", err)
+	}
+	if header[0] != esp32ImageMagic {
+		return 0, errors.New("bootloader.bin is not an ESP image")
+	}
+
+	chipID := binary.LittleEndian.Uint16(header[12:14])
+	offset, ok := esp32BootloaderOffsets[chipID]
+	if !ok {
+		return 0, fmt.Errorf("unknown ESP chip id 0x%04x in bootloader header", chipID)
+	}
+	return offset, nil
+}
+
+// esp32AppOffset returns the address of the first factory or ota_0 app
+// partition, falling back to the PlatformIO default.
+func esp32AppOffset(table []byte) int64 {
+	for offset := 0; offset+esp32PartitionEntrySize <= len(table); offset += esp32PartitionEntrySize {
+		entry := table[offset : offset+esp32PartitionEntrySize]
+		if entry[0] != 0xAA || entry[1] != 0x50 {
+			continue
+		}
+		if entry[2] == esp32PartitionTypeApp && (entry[3] == esp32PartitionSubFactory || entry[3] == esp32PartitionSubOTA0) {
+			return int64(binary.LittleEndian.Uint32(entry[4:8]))
+		}
+	}
+	return esp32DefaultAppAddr
+}
+
+func writeFlashSegments(destination string, segments []flashSegment) error {
+	tempPath := destination + ".tmp"
+	file, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempPath)
+
+	writer := bufio.NewWriter(file)
+	position := int64(0)
+	for _, segment := range segments {
+		if err := writePadding(writer, segment.offset-position); err != nil {
+			file.Close()
+			return err
+		}
+		source, err := os.Open(segment.path)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		written, err := io.Copy(writer, source)
+		source.Close()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		position = segment.offset + written
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, destination)
+}
+
+func writePadding(w io.Writer, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	chunk := make([]byte, 4096)
+	for index := range chunk {
+		chunk[index] = 0xFF
+	}
+	for size > 0 {
+		n := int64(len(chunk))
+		if size < n {
+			n = size
+		}
+		if _, err := w.Write(chunk[:n]); err != nil {
+			return err
+		}
+		size -= n
+	}
+	return nil
+}
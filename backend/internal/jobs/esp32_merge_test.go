@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteMergedESP32Image(t *testing.T) {
+	t.Parallel()
+
+	buildRoot := t.TempDir()
+
+	bootloader := make([]byte, 32)
+	bootloader[0] = esp32ImageMagic
+	binary.LittleEndian.PutUint16(bootloader[12:14], 0x0000) // ESP32, loaded at 0x1000
+
+	partitions := make([]byte, 64)
+	for index := range partitions {
+		partitions[index] = 0xFF
+	}
+	entry := partitions[:esp32PartitionEntrySize]
+	entry[0], entry[1] = 0xAA, 0x50
+	entry[2], entry[3] = esp32PartitionTypeApp, esp32PartitionSubOTA0
+	binary.LittleEndian.PutUint32(entry[4:8], 0x20000)
+	binary.LittleEndian.PutUint32(entry[8:12], 0x100000)
+
+	app := []byte("application-image")
+
+	files := map[string][]byte{
+		"bootloader.bin": bootloader,
+		"partitions.bin": partitions,
+		"firmware.bin":   app,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(buildRoot, name), content, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	merged, err := writeMergedESP32Image(buildRoot)
+	if err != nil || !merged {
+		t.Fatalf("expected merged image, got merged=%v err=%v", merged, err)
+	}
+
+	image, err := os.ReadFile(filepath.Join(buildRoot, MergedImageName))
+	if err != nil {
+		t.Fatalf("read merged image: %v", err)
+	}
+	if len(image) != 0x20000+len(app) {
+		t.Fatalf("unexpected merged image size: got=%d want=%d", len(image), 0x20000+len(app))
+	}
+	if image[0] != 0xFF || !bytes.Equal(image[0x1000:0x1000+len(bootloader)], bootloader) {
+		t.Fatalf("bootloader must be placed at 0x1000")
+	}
+	if !bytes.Equal(image[esp32PartitionTableAddr:esp32PartitionTableAddr+len(partitions)], partitions) {
+		t.Fatalf("partition table must be placed at 0x8000")
+	}
+	if !bytes.Equal(image[0x20000:], app) {
+		t.Fatalf("application must be placed at the ota_0 offset")
+	}
+}
+
+func TestWriteMergedESP32ImageSkipsNonESP32Builds(t *testing.T) {
+	t.Parallel()
+
+	buildRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(buildRoot, "firmware.uf2"), []byte("uf2"), 0o644); err != nil {
+		t.Fatalf("write firmware: %v", err)
+	}
+
+	merged, err := writeMergedESP32Image(buildRoot)
+	if err != nil || merged {
+		t.Fatalf("expected no merged image, got merged=%v err=%v", merged, err)
+	}
+	if _, err := os.Stat(filepath.Join(buildRoot, MergedImageName)); !os.IsNotExist(err) {
+		t.Fatalf("merged image must not be written: %v", err)
+	}
+}
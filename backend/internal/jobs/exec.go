@@ -33,6 +33,7 @@ func runCommandStreaming(ctx context.Context, cmd *exec.Cmd, onLine func(string)
 		defer wg.Done()
 		scanner := bufio.NewScanner(reader)
 		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		scanner.Split(scanOutputLines)
 		for scanner.Scan() {
 			if onLine != nil {
 				onLine(scanner.Text())
@@ -71,3 +72,26 @@ func runCommandStreaming(ctx context.Context, cmd *exec.Cmd, onLine func(string)
 
 	return nil
 }
+
+// scanOutputLines splits command output on "\n" and on bare "\r", which
+// progress meters (git --progress) use to redraw a line in place.
+func scanOutputLines(data []byte, atEOF bool) (int, []byte, error) {
+	for i, b := range data {
+		switch b {
+		case '\n':
+			return i + 1, data[:i], nil
+		case '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			if i+1 == len(data) && !atEOF {
+				return 0, nil, nil
+			}
+			return i + 1, data[:i], nil
+		}
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -29,3 +29,26 @@ func TestRunCommandStreamingSlowConsumer(t *testing.T) {
 		t.Fatalf("unexpected line count: got=%d want>=1000", got)
 	}
 }
+
+func TestRunCommandStreamingSplitsCarriageReturns(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", "-c", `printf 'Receiving objects:  10%% (1/10)\rReceiving objects: 100%% (10/10), done.\r\nnext\n' 1>&2`)
+
+	var lines []string
+	if err := runCommandStreaming(context.Background(), cmd, func(line string) {
+		lines = append(lines, line)
+	}); err != nil {
+		t.Fatalf("runCommandStreaming returned error: %v", err)
+	}
+
+	want := []string{"Receiving objects:  10% (1/10)", "Receiving objects: 100% (10/10), done.", "next"}
+	if len(lines) != len(want) {
+		t.Fatalf("unexpected lines: got=%q want=%q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("unexpected line %d: got=%q want=%q", i, lines[i], want[i])
+		}
+	}
+}
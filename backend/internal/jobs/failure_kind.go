@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// FailureKind is the machine-readable cause of a failed job, derived from
+// the step that failed, how it exited and its log. Failures that fit no
+// kind, such as a device missing from the repository, have none.
+type FailureKind string
+
+const (
+	FailureClone      FailureKind = "clone_error"
+	FailureSubmodule  FailureKind = "submodule_error"
+	FailureDependency FailureKind = "dependency_error"
+	FailureCompile    FailureKind = "compile_error"
+	FailureTimeout    FailureKind = "timeout"
+	FailureOOM        FailureKind = "oom"
+	FailureInfra      FailureKind = "infra"
+)
+
+// FailureKinds lists every kind, in the order statistics report them.
+var FailureKinds = []FailureKind{
+	FailureClone, FailureSubmodule, FailureDependency, FailureCompile, FailureTimeout, FailureOOM, FailureInfra,
+}
+
+// failureLogTail is how many of the last log lines the build failure
+// patterns are matched against; PlatformIO stops right after the error.
+const failureLogTail = 200
+
+// Exit codes of docker run: 125 when docker itself failed, 126 and 127 when
+// the builder image cannot run its command, 137 when the build was killed,
+// which without a cancellation is the kernel's OOM killer.
+const (
+	dockerExitDaemonError = 125
+	dockerExitCannotRun   = 126
+	dockerExitNotFound    = 127
+	dockerExitKilled      = 137
+)
+
+var (
+	oomPatterns = []string{
+		"out of memory",
+		"cannot allocate memory",
+		"virtual memory exhausted",
+		"killed signal terminated program",
+		"oomkilled",
+	}
+	infraPatterns = []string{
+		"cannot connect to the docker daemon",
+		"error response from daemon",
+		"unable to find image",
+		"no space left on device",
+	}
+	dependencyPatterns = []string{
+		"unknownpackageerror",
+		"could not find the package",
+		"could not install package",
+		"packageexception",
+		"missingpackagemanifesterror",
+		"httpclienterror",
+		"no matching distribution found",
+	}
+)
+
+// failureError attaches the kind of failure to the error of a build step.
+type failureError struct {
+	kind FailureKind
+	err  error
+}
+
+func (e *failureError) Error() string { return e.err.Error() }
+
+func (e *failureError) Unwrap() error { return e.err }
+
+func withFailureKind(kind FailureKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &failureError{kind: kind, err: err}
+}
+
+// stepFailure marks err as a failure of a build step of kind, unless a
+// more specific kind is already attached or the build ran out of time.
+func stepFailure(ctx context.Context, kind FailureKind, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return withFailureKind(FailureTimeout, err)
+	}
+	if failureKindOf(err) != "" {
+		return err
+	}
+	return withFailureKind(kind, err)
+}
+
+func failureKindOf(err error) FailureKind {
+	var failure *failureError
+	switch {
+	case errors.As(err, &failure):
+		return failure.kind
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.Is(err, ErrInfrastructureUnavailable):
+		return FailureInfra
+	}
+	return ""
+}
+
+// classifyBuildFailure tells why the build container failed from its exit
+// code and the tail of the job log. A failure without a known pattern is a
+// compile error: PlatformIO ran and reported one.
+func classifyBuildFailure(err error, lines []string) FailureKind {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case dockerExitKilled:
+			return FailureOOM
+		case dockerExitDaemonError, dockerExitCannotRun, dockerExitNotFound:
+			return FailureInfra
+		}
+	} else if err != nil {
+		// docker could not be started at all.
+		return FailureInfra
+	}
+
+	tail := strings.ToLower(strings.Join(lines[max(len(lines)-failureLogTail, 0):], "\n"))
+	switch {
+	case containsAny(tail, oomPatterns):
+		return FailureOOM
+	case containsAny(tail, infraPatterns):
+		return FailureInfra
+	case containsAny(tail, dependencyPatterns):
+		return FailureDependency
+	}
+	return FailureCompile
+}
+
+func containsAny(text string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(text, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func exitError(t *testing.T, code int) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	if err == nil {
+		t.Fatalf("expected exit status %d", code)
+	}
+	return fmt.Errorf("run build container: command failed: %w", err)
+}
+
+func TestClassifyBuildFailure(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		err   error
+		lines []string
+		want  FailureKind
+	}{
+		{"compile", exitError(t, 1), []string{"src/main.cpp:12:5: error: 'foo' was not declared in this scope", "*** [.pio/build/tbeam/src/main.cpp.o] Error 1"}, FailureCompile},
+		{"no pattern", exitError(t, 1), []string{"Building in release mode"}, FailureCompile},
+		{"killed", exitError(t, 137), []string{"Compiling .pio/build/tbeam/src/mesh/Router.cpp.o"}, FailureOOM},
+		{"compiler oom", exitError(t, 1), []string{"xtensa-esp32-elf-g++: fatal error: Killed signal terminated program cc1plus"}, FailureOOM},
+		{"docker daemon", exitError(t, 125), []string{"docker: Error response from daemon: pull access denied."}, FailureInfra},
+		{"disk full", exitError(t, 1), []string{"OSError: [Errno 28] No space left on device"}, FailureInfra},
+		{"package", exitError(t, 1), []string{"UnknownPackageError: Could not find the package with 'meshtastic/NonExisting' requirements for your system 'linux_x86_64'"}, FailureDependency},
+		{"docker missing", errors.New(`start command: exec: "docker": executable file not found in $PATH`), nil, FailureInfra},
+	}
+	for _, tc := range cases {
+		if got := classifyBuildFailure(tc.err, tc.lines); got != tc.want {
+			t.Errorf("%s: classifyBuildFailure = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestStepFailure(t *testing.T) {
+	t.Parallel()
+
+	cloneErr := errors.New("clone repository: exit status 128")
+	if kind := failureKindOf(stepFailure(context.Background(), FailureClone, cloneErr)); kind != FailureClone {
+		t.Fatalf("expected a clone error, got %q", kind)
+	}
+	submoduleErr := withFailureKind(FailureSubmodule, errors.New("update submodules: exit status 1"))
+	wrapped := stepFailure(context.Background(), FailureClone, fmt.Errorf("clone from mirror: %w", submoduleErr))
+	if kind := failureKindOf(wrapped); kind != FailureSubmodule {
+		t.Fatalf("expected the submodule kind to be kept, got %q", kind)
+	}
+	if wrapped.Error() != "clone from mirror: update submodules: exit status 1" {
+		t.Fatalf("the kind must not change the message, got %q", wrapped.Error())
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Unix(0, 0))
+	defer cancel()
+	if kind := failureKindOf(stepFailure(expired, FailureClone, cloneErr)); kind != FailureTimeout {
+		t.Fatalf("expected a timeout, got %q", kind)
+	}
+	if kind := failureKindOf(fmt.Errorf("%w: docker daemon unreachable", ErrInfrastructureUnavailable)); kind != FailureInfra {
+		t.Fatalf("expected infra, got %q", kind)
+	}
+	if kind := failureKindOf(errors.New(`device "tbeam" has no platformio.ini in variants`)); kind != "" {
+		t.Fatalf("expected no kind, got %q", kind)
+	}
+}
@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	flashScriptShell = "flash.sh"
+	flashScriptBatch = "flash.bat"
+)
+
+// flashPlan is the single command needed to flash a build over serial.
+type flashPlan struct {
+	// tool is "esptool" or "nrfutil".
+	tool  string
+	image string
+}
+
+// planFlash picks the flashing command from the artifact names: a full
+// ESP32 image written at 0x0, or an nRF52 DFU package. Other platforms are
+// flashed by copying the UF2 file and get no script.
+func planFlash(artifacts []Artifact) (flashPlan, bool) {
+	for _, preferred := range []string{".factory.bin", MergedImageName} {
+		for _, artifact := range artifacts {
+			if strings.HasSuffix(strings.ToLower(artifact.RelativePath), preferred) {
+				return flashPlan{tool: "esptool", image: artifact.Name}, true
+			}
+		}
+	}
+	for _, artifact := range artifacts {
+		if strings.HasSuffix(strings.ToLower(artifact.RelativePath), otaPackageSuffix) {
+			return flashPlan{tool: "nrfutil", image: artifact.Name}, true
+		}
+	}
+	return flashPlan{}, false
+}
+
+// writeFlashScripts writes flash.sh and flash.bat for the artifacts into
+// dir and returns them as additional artifacts. The scripts expect to sit
+// next to the downloaded firmware files and take the serial port as their
+// only argument.
+func writeFlashScripts(dir string, device string, artifacts []Artifact) ([]Artifact, error) {
+	plan, ok := planFlash(artifacts)
+	if !ok {
+		return nil, nil
+	}
+	if strings.ContainsAny(plan.image, "\"%!^&|<>'\\/\r\n") {
+		return nil, fmt.Errorf("file name %q cannot be used in a flash script", plan.image)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Name == flashScriptShell || artifact.Name == flashScriptBatch {
+			return nil, nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	scripts := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+	}{
+		{flashScriptShell, flashShellScript(device, plan), 0o755},
+		{flashScriptBatch, strings.ReplaceAll(flashBatchScript(device, plan), "\n", "\r\n"), 0o644},
+	}
+
+	result := make([]Artifact, 0, len(scripts))
+	nextID := len(artifacts) + 1
+	for _, script := range scripts {
+		path := filepath.Join(dir, script.name)
+		if err := os.WriteFile(path, []byte(script.content), script.mode); err != nil {
+			return nil, fmt.Errorf("write %s: %w", script.name, err)
+		}
+		artifact := Artifact{
+			ID:           strconv.Itoa(nextID),
+			Name:         script.name,
+			RelativePath: script.name,
+			Size:         int64(len(script.content)),
+			absPath:      path,
+		}
+		if err := artifact.computeChecksums(); err != nil {
+			return nil, err
+		}
+		result = append(result, artifact)
+		nextID++
+	}
+	return result, nil
+}
+
+func flashShellScript(device string, plan flashPlan) string {
+	builder := strings.Builder{}
+	builder.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&builder, "# Flash %s firmware over USB serial.\n", device)
+	builder.WriteString("# Keep this script in the same folder as the downloaded firmware files.\n")
+	builder.WriteString("set -e\n")
+	builder.WriteString("cd \"$(dirname \"$0\")\"\n")
+	builder.WriteString("PORT=\"$1\"\n\n")
+
+	switch plan.tool {
+	case "esptool":
+		builder.WriteString("# Requires esptool: pip install esptool\n")
+		builder.WriteString("if [ -n \"$PORT\" ]; then\n")
+		fmt.Fprintf(&builder, "  python3 -m esptool --port \"$PORT\" --baud 921600 write_flash 0x0 '%s'\n", plan.image)
+		builder.WriteString("else\n")
+		fmt.Fprintf(&builder, "  python3 -m esptool --baud 921600 write_flash 0x0 '%s'\n", plan.image)
+		builder.WriteString("fi\n")
+	case "nrfutil":
+		builder.WriteString("# Requires adafruit-nrfutil: pip install adafruit-nrfutil\n")
+		builder.WriteString("if [ -z \"$PORT\" ]; then\n")
+		builder.WriteString("  echo \"usage: $0 <serial port>, e.g. $0 /dev/ttyACM0\" >&2\n")
+		builder.WriteString("  exit 1\n")
+		builder.WriteString("fi\n")
+		fmt.Fprintf(&builder, "adafruit-nrfutil --verbose dfu serial --package '%s' -p \"$PORT\" -b 115200 --singlebank --touch 1200\n", plan.image)
+	}
+	return builder.String()
+}
+
+func flashBatchScript(device string, plan flashPlan) string {
+	builder := strings.Builder{}
+	builder.WriteString("@echo off\n")
+	fmt.Fprintf(&builder, "rem Flash %s firmware over USB serial.\n", device)
+	builder.WriteString("rem Keep this script in the same folder as the downloaded firmware files.\n")
+	builder.WriteString("setlocal\n")
+	builder.WriteString("cd /d \"%~dp0\"\n")
+	builder.WriteString("set \"PORT=%~1\"\n\n")
+
+	switch plan.tool {
+	case "esptool":
+		builder.WriteString("rem Requires esptool: pip install esptool\n")
+		builder.WriteString("if \"%PORT%\"==\"\" (\n")
+		fmt.Fprintf(&builder, "  python -m esptool --baud 921600 write_flash 0x0 \"%s\"\n", plan.image)
+		builder.WriteString(") else (\n")
+		fmt.Fprintf(&builder, "  python -m esptool --port %%PORT%% --baud 921600 write_flash 0x0 \"%s\"\n", plan.image)
+		builder.WriteString(")\n")
+	case "nrfutil":
+		builder.WriteString("rem Requires adafruit-nrfutil: pip install adafruit-nrfutil\n")
+		builder.WriteString("if \"%PORT%\"==\"\" (\n")
+		builder.WriteString("  echo usage: %~nx0 ^<serial port^>, e.g. %~nx0 COM3\n")
+		builder.WriteString("  exit /b 1\n")
+		builder.WriteString(")\n")
+		fmt.Fprintf(&builder, "adafruit-nrfutil --verbose dfu serial --package \"%s\" -p %%PORT%% -b 115200 --singlebank --touch 1200\n", plan.image)
+	}
+	builder.WriteString("if errorlevel 1 pause\n")
+	return builder.String()
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteFlashScriptsESP32(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	artifacts := []Artifact{
+		{ID: "1", Name: "firmware-tbeam-1a2b3c4d-stock.bin", RelativePath: "firmware.bin"},
+		{ID: "2", Name: "firmware-tbeam-1a2b3c4d-stock.factory.bin", RelativePath: "firmware.factory.bin"},
+	}
+
+	scripts, err := writeFlashScripts(dir, "tbeam", artifacts)
+	if err != nil {
+		t.Fatalf("writeFlashScripts failed: %v", err)
+	}
+	if len(scripts) != 2 || scripts[0].ID != "3" || scripts[1].ID != "4" {
+		t.Fatalf("unexpected script artifacts: %+v", scripts)
+	}
+
+	shell, err := os.ReadFile(scripts[0].AbsolutePath())
+	if err != nil {
+		t.Fatalf("read flash.sh: %v", err)
+	}
+	if !strings.Contains(string(shell), "write_flash 0x0 'firmware-tbeam-1a2b3c4d-stock.factory.bin'") {
+		t.Fatalf("flash.sh must write the factory image at 0x0:\n%s", shell)
+	}
+
+	batch, err := os.ReadFile(scripts[1].AbsolutePath())
+	if err != nil {
+		t.Fatalf("read flash.bat: %v", err)
+	}
+	if !strings.Contains(string(batch), "\r\n") || !strings.Contains(string(batch), "--port %PORT%") {
+		t.Fatalf("flash.bat must use CRLF and pass the port:\n%s", batch)
+	}
+	if scripts[1].SHA256 == "" || scripts[1].Size != int64(len(batch)) {
+		t.Fatalf("script artifact metadata must match the file: %+v", scripts[1])
+	}
+}
+
+func TestWriteFlashScriptsNRF52(t *testing.T) {
+	t.Parallel()
+
+	artifacts := []Artifact{
+		{ID: "1", Name: "firmware.uf2", RelativePath: "firmware.uf2"},
+		{ID: "2", Name: "firmware-rak4631-2.5.0-ota.zip", RelativePath: "firmware-rak4631-2.5.0-ota.zip"},
+	}
+	scripts, err := writeFlashScripts(t.TempDir(), "rak4631", artifacts)
+	if err != nil {
+		t.Fatalf("writeFlashScripts failed: %v", err)
+	}
+	shell, err := os.ReadFile(scripts[0].AbsolutePath())
+	if err != nil {
+		t.Fatalf("read flash.sh: %v", err)
+	}
+	if !strings.Contains(string(shell), "adafruit-nrfutil --verbose dfu serial --package 'firmware-rak4631-2.5.0-ota.zip'") {
+		t.Fatalf("flash.sh must use the DFU package:\n%s", shell)
+	}
+}
+
+func TestWriteFlashScriptsSkipsUnsupportedPlatforms(t *testing.T) {
+	t.Parallel()
+
+	scripts, err := writeFlashScripts(t.TempDir(), "pico", []Artifact{{ID: "1", Name: "firmware.uf2", RelativePath: "firmware.uf2"}})
+	if err != nil || len(scripts) != 0 {
+		t.Fatalf("expected no scripts for UF2-only builds: scripts=%v err=%v", scripts, err)
+	}
+}
@@ -7,16 +7,63 @@ import (
 	"strings"
 )
 
-func cloneRepository(ctx context.Context, repoURL string, ref string, destination string, onLine func(string)) error {
-	cloneArgs := []string{"clone", "--depth", "1", "--single-branch", repoURL, destination}
+// cloneStrategy selects how much history a build clone fetches (see
+// config.Config.CloneStrategy). The zero value is a depth-1 clone.
+type cloneStrategy struct {
+	mode  string
+	since string
+}
+
+// historyArgs are the clone and fetch arguments limiting history.
+func (s cloneStrategy) historyArgs() []string {
+	switch s.mode {
+	case "full":
+		return nil
+	case "treeless":
+		return []string{"--filter=tree:0"}
+	case "shallow-since":
+		return []string{"--shallow-since=" + s.since}
+	default:
+		return []string{"--depth", "1"}
+	}
+}
+
+// fetchAttempts lists the history arguments to try when fetching a ref. A
+// ref older than the shallow-since date cannot be fetched that way, so it
+// falls back to depth 1.
+func (s cloneStrategy) fetchAttempts() [][]string {
+	attempts := [][]string{s.historyArgs()}
+	if s.mode == "shallow-since" {
+		attempts = append(attempts, []string{"--depth", "1"})
+	}
+	return attempts
+}
+
+func cloneRepository(ctx context.Context, repoURL string, ref string, destination string, strategy cloneStrategy, onLine func(string)) error {
+	return cloneRepositoryFrom(ctx, repoURL, repoURL, ref, destination, strategy, onLine)
+}
+
+// cloneRepositoryFrom clones sourceURL (which may be a local mirror of
+// originURL) and points the origin remote at originURL before submodules are
+// initialized, so relative submodule URLs resolve against the real upstream.
+func cloneRepositoryFrom(ctx context.Context, sourceURL string, originURL string, ref string, destination string, strategy cloneStrategy, onLine func(string)) error {
+	cloneArgs := append([]string{"clone", "--progress"}, strategy.historyArgs()...)
+	cloneArgs = append(cloneArgs, "--single-branch", sourceURL, destination)
 	if err := runGit(ctx, onLine, cloneArgs...); err != nil {
 		return fmt.Errorf("clone repository: %w", err)
 	}
 
 	ref = strings.TrimSpace(ref)
 	if ref != "" {
-		fetchArgs := []string{"-C", destination, "fetch", "--depth", "1", "origin", ref}
-		if err := runGit(ctx, onLine, fetchArgs...); err == nil {
+		fetched := false
+		for _, historyArgs := range strategy.fetchAttempts() {
+			fetchArgs := append([]string{"-C", destination, "fetch", "--progress"}, historyArgs...)
+			if err := runGit(ctx, onLine, append(fetchArgs, "origin", ref)...); err == nil {
+				fetched = true
+				break
+			}
+		}
+		if fetched {
 			if err := runGit(ctx, onLine, "-C", destination, "checkout", "--force", "FETCH_HEAD"); err != nil {
 				return fmt.Errorf("checkout fetched ref: %w", err)
 			}
@@ -27,6 +74,12 @@ func cloneRepository(ctx context.Context, repoURL string, ref string, destinatio
 		}
 	}
 
+	if sourceURL != originURL {
+		if err := runGit(ctx, onLine, "-C", destination, "remote", "set-url", "origin", originURL); err != nil {
+			return fmt.Errorf("set origin url: %w", err)
+		}
+	}
+
 	optimizedSubmoduleArgs := []string{
 		"-C", destination,
 		"-c", "submodule.fetchJobs=8",
@@ -36,24 +89,58 @@ func cloneRepository(ctx context.Context, repoURL string, ref string, destinatio
 		"--depth", "1",
 		"--jobs", "8",
 		"--recommend-shallow",
+		"--progress",
 	}
 	if err := runGit(ctx, onLine, optimizedSubmoduleArgs...); err != nil {
 		if onLine != nil {
 			onLine("submodule optimized mode failed, retrying with compatibility flags")
 		}
 		if fallbackErr := runGit(ctx, onLine, "-C", destination, "submodule", "update", "--init", "--recursive", "--depth", "1"); fallbackErr != nil {
-			return fmt.Errorf("update submodules: %w", fallbackErr)
+			return withFailureKind(FailureSubmodule, fmt.Errorf("update submodules: %w", fallbackErr))
 		}
 	}
 
 	return nil
 }
 
+// sparseCloneRepository checks out only paths (plus top-level files) of ref
+// without submodules, fetching file contents on demand. It is used when the
+// working tree is only read, never built.
+func sparseCloneRepository(ctx context.Context, sourceURL string, ref string, destination string, paths []string, onLine func(string)) error {
+	cloneArgs := []string{"clone", "--depth", "1", "--single-branch", "--filter=blob:none", "--sparse", sourceURL, destination}
+	if err := runGit(ctx, onLine, cloneArgs...); err != nil {
+		return fmt.Errorf("sparse clone repository: %w", err)
+	}
+
+	sparseArgs := append([]string{"-C", destination, "sparse-checkout", "set", "--cone"}, paths...)
+	if err := runGit(ctx, onLine, sparseArgs...); err != nil {
+		return fmt.Errorf("set sparse checkout: %w", err)
+	}
+
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+	fetchArgs := []string{"-C", destination, "fetch", "--depth", "1", "--filter=blob:none", "origin", ref}
+	if err := runGit(ctx, onLine, fetchArgs...); err != nil {
+		return fmt.Errorf("fetch ref: %w", err)
+	}
+	if err := runGit(ctx, onLine, "-C", destination, "checkout", "--force", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("checkout fetched ref: %w", err)
+	}
+	return nil
+}
+
 func runGit(ctx context.Context, onLine func(string), args ...string) error {
 	cmd := exec.CommandContext(ctx, "git", args...)
 	if onLine != nil {
 		onLine("$ git " + strings.Join(args, " "))
 	}
+	release, err := prepareGitCommand(ctx, cmd, onLine, args)
+	if err != nil {
+		return err
+	}
+	defer release()
 	if err := runCommandStreaming(ctx, cmd, onLine); err != nil {
 		return err
 	}
@@ -0,0 +1,272 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// gitNetwork holds the process-wide limits applied to git commands that talk
+// to a remote. It is nil when neither limit is configured.
+var gitNetwork atomic.Pointer[gitNetworkLimits]
+
+type gitNetworkLimits struct {
+	slots    chan struct{}
+	proxyURL string
+}
+
+// configureGitNetwork installs the git network limits from cfg. Bandwidth
+// throttling routes http(s) remotes through a local proxy; it is skipped when
+// an outbound proxy is already configured in the environment, since the
+// local proxy would bypass it.
+func configureGitNetwork(cfg config.Config, logger *slog.Logger) {
+	limits := &gitNetworkLimits{}
+	if cfg.GitNetworkConcurrency > 0 {
+		limits.slots = make(chan struct{}, cfg.GitNetworkConcurrency)
+	}
+	if cfg.GitBandwidthLimit > 0 {
+		if environmentProxyConfigured() {
+			logger.Warn("git network: bandwidth limit ignored because an outbound proxy is configured")
+		} else if proxyURL, err := startThrottleProxy(newByteRateLimiter(cfg.GitBandwidthLimit)); err != nil {
+			logger.Error("git network: start bandwidth limiter", "error", err)
+		} else {
+			limits.proxyURL = proxyURL
+		}
+	}
+
+	if limits.slots == nil && limits.proxyURL == "" {
+		gitNetwork.Store(nil)
+		return
+	}
+	gitNetwork.Store(limits)
+}
+
+// prepareGitCommand applies the stored git credentials and the network
+// limits to cmd when args describe a network operation. The returned function releases the concurrency slot.
+func prepareGitCommand(ctx context.Context, cmd *exec.Cmd, onLine func(string), args []string) (func(), error) {
+	if !isGitNetworkCommand(args) {
+		return func() {}, nil
+	}
+	applyGitCredentials(ctx, cmd)
+	limits := gitNetwork.Load()
+	if limits == nil {
+		return func() {}, nil
+	}
+
+	if limits.proxyURL != "" {
+		appendCommandEnv(cmd,
+			"http_proxy="+limits.proxyURL,
+			"https_proxy="+limits.proxyURL,
+			"HTTPS_PROXY="+limits.proxyURL,
+		)
+	}
+	if limits.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case limits.slots <- struct{}{}:
+	default:
+		if onLine != nil {
+			onLine("Waiting for a free git network slot...")
+		}
+		select {
+		case limits.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func() { <-limits.slots }, nil
+}
+
+// isGitNetworkCommand reports whether git args run a subcommand that
+// contacts a remote. Global options before the subcommand are skipped.
+func isGitNetworkCommand(args []string) bool {
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+		switch {
+		case arg == "-C" || arg == "-c":
+			index++
+			continue
+		case strings.HasPrefix(arg, "-"):
+			continue
+		}
+
+		switch arg {
+		case "clone", "fetch", "ls-remote", "pull":
+			return true
+		case "submodule":
+			for _, rest := range args[index+1:] {
+				if rest == "update" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func environmentProxyConfigured() bool {
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY", "all_proxy"} {
+		if strings.TrimSpace(os.Getenv(key)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// byteRateLimiter is a token bucket shared by every proxied connection, so
+// the limit applies to the combined transfer rate.
+type byteRateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newByteRateLimiter(bytesPerSecond int64) *byteRateLimiter {
+	rate := float64(bytesPerSecond)
+	return &byteRateLimiter{rate: rate, burst: rate, tokens: rate, last: time.Now()}
+}
+
+// wait takes n bytes from the bucket, sleeping until the debt is repaid.
+func (l *byteRateLimiter) wait(n int) {
+	if n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	debt := l.tokens
+	l.mu.Unlock()
+
+	if debt < 0 {
+		time.Sleep(time.Duration(-debt / l.rate * float64(time.Second)))
+	}
+}
+
+type throttledReader struct {
+	reader  io.Reader
+	limiter *byteRateLimiter
+}
+
+func (r throttledReader) Read(p []byte) (int, error) {
+	if len(p) > 32*1024 {
+		p = p[:32*1024]
+	}
+	n, err := r.reader.Read(p)
+	r.limiter.wait(n)
+	return n, err
+}
+
+// startThrottleProxy serves an HTTP proxy on the loopback interface that
+// relays traffic through limiter and returns its URL.
+func startThrottleProxy(limiter *byteRateLimiter) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	server := &http.Server{
+		Handler:           &throttleProxy{limiter: limiter, transport: &http.Transport{Proxy: nil}},
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() { _ = server.Serve(listener) }()
+	return "http://" + listener.Addr().String(), nil
+}
+
+type throttleProxy struct {
+	limiter   *byteRateLimiter
+	transport http.RoundTripper
+}
+
+func (p *throttleProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if r.URL.Scheme != "http" || r.URL.Host == "" {
+		http.Error(w, "proxy only accepts absolute http URLs", http.StatusBadRequest)
+		return
+	}
+
+	outbound := r.Clone(r.Context())
+	outbound.RequestURI = ""
+	outbound.Body = io.NopCloser(throttledReader{reader: r.Body, limiter: p.limiter})
+	outbound.Header.Del("Proxy-Connection")
+	outbound.Header.Del("Proxy-Authorization")
+
+	response, err := p.transport.RoundTrip(outbound)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer response.Body.Close()
+
+	for key, values := range response.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(response.StatusCode)
+	_, _ = io.Copy(w, throttledReader{reader: response.Body, limiter: p.limiter})
+}
+
+func (p *throttleProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := net.DialTimeout("tcp", r.Host, 30*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "connection hijacking unsupported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := fmt.Fprint(client, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	relay := func(dst net.Conn, src io.Reader) {
+		_, _ = io.Copy(dst, throttledReader{reader: src, limiter: p.limiter})
+		if conn, ok := dst.(*net.TCPConn); ok {
+			_ = conn.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go relay(upstream, buffered)
+	go relay(client, upstream)
+	<-done
+	<-done
+	client.Close()
+	upstream.Close()
+}
@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsGitNetworkCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"clone", "--depth", "1", "https://example.com/repo.git", "dest"}, true},
+		{[]string{"-C", "/tmp/repo", "fetch", "--depth", "1", "origin", "main"}, true},
+		{[]string{"-c", "protocol.version=2", "ls-remote", "--symref", "https://example.com/repo.git", "HEAD"}, true},
+		{[]string{"-C", "/tmp/repo", "submodule", "update", "--init", "--recursive"}, true},
+		{[]string{"-C", "/tmp/repo", "submodule", "sync", "--recursive"}, false},
+		{[]string{"-C", "/tmp/fetch", "checkout", "--detach", "FETCH_HEAD"}, false},
+		{[]string{"-C", "/tmp/repo", "apply", "--whitespace=nowarn", "job.patch"}, false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if got := isGitNetworkCommand(tc.args); got != tc.want {
+			t.Fatalf("unexpected result for %v: got=%v want=%v", tc.args, got, tc.want)
+		}
+	}
+}
+
+// TestPrepareGitCommandLimitsConcurrency replaces the process-wide limits,
+// so it must not run in parallel with tests that invoke git.
+func TestPrepareGitCommandLimitsConcurrency(t *testing.T) {
+	limits := &gitNetworkLimits{slots: make(chan struct{}, 1)}
+	gitNetwork.Store(limits)
+	defer gitNetwork.Store(nil)
+
+	args := []string{"ls-remote", "https://example.com/repo.git"}
+	release, err := prepareGitCommand(context.Background(), exec.Command("git"), nil, args)
+	if err != nil {
+		t.Fatalf("acquire first slot: %v", err)
+	}
+
+	if local, err := prepareGitCommand(context.Background(), exec.Command("git"), nil, []string{"rev-parse", "HEAD"}); err != nil {
+		t.Fatalf("local commands must not wait: %v", err)
+	} else {
+		local()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var lines []string
+	if _, err := prepareGitCommand(ctx, exec.Command("git"), func(line string) { lines = append(lines, line) }, args); err == nil {
+		t.Fatalf("second network command must wait for a free slot")
+	}
+	if len(lines) != 1 || !strings.Contains(lines[0], "Waiting") {
+		t.Fatalf("expected a waiting notice, got %v", lines)
+	}
+
+	release()
+	again, err := prepareGitCommand(context.Background(), exec.Command("git"), nil, args)
+	if err != nil {
+		t.Fatalf("acquire released slot: %v", err)
+	}
+	again()
+}
+
+func TestThrottleProxyLimitsTransferRate(t *testing.T) {
+	t.Parallel()
+
+	payload := strings.Repeat("x", 64*1024)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, payload)
+	}))
+	defer upstream.Close()
+
+	proxyURL, err := startThrottleProxy(newByteRateLimiter(64 * 1024))
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatalf("parse proxy url: %v", err)
+	}
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(parsed)}, Timeout: 10 * time.Second}
+
+	started := time.Now()
+	for attempt := 0; attempt < 2; attempt++ {
+		response, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatalf("get through proxy: %v", err)
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil || string(body) != payload {
+			t.Fatalf("unexpected body: len=%d err=%v", len(body), err)
+		}
+	}
+	// The first 64 KiB fit in the burst; the second must wait about a second.
+	if elapsed := time.Since(started); elapsed < 700*time.Millisecond {
+		t.Fatalf("transfer was not throttled: elapsed=%v", elapsed)
+	}
+}
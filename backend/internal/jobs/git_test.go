@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCloneStrategiesCheckOutOlderTag(t *testing.T) {
+	t.Parallel()
+
+	// v1 is tagged on a commit from 2020, older than the shallow-since
+	// date below, so that strategy has to fall back to depth 1.
+	source := t.TempDir()
+	steps := []struct {
+		date string
+		args []string
+	}{
+		{args: []string{"init", "--quiet", "--initial-branch=main"}},
+		{args: []string{"add", "-A"}},
+		{date: "2020-01-01T00:00:00Z", args: []string{"commit", "--quiet", "--allow-empty", "-m", "first"}},
+		{args: []string{"tag", "v1"}},
+		{date: "2024-01-01T00:00:00Z", args: []string{"commit", "--quiet", "--allow-empty", "-m", "second"}},
+	}
+	for i, step := range steps {
+		if i == 1 {
+			if err := os.WriteFile(filepath.Join(source, "version.txt"), []byte("1\n"), 0o644); err != nil {
+				t.Fatalf("write version: %v", err)
+			}
+		}
+		cmd := exec.Command("git", append([]string{"-C", source, "-c", "user.name=test", "-c", "user.email=test@example.com"}, step.args...)...)
+		if step.date != "" {
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+step.date, "GIT_COMMITTER_DATE="+step.date)
+		}
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %s unavailable: %v (%s)", strings.Join(step.args, " "), err, output)
+		}
+	}
+
+	strategies := []cloneStrategy{
+		{},
+		{mode: "shallow-since", since: "2023-01-01"},
+		{mode: "treeless"},
+		{mode: "full"},
+	}
+	for _, strategy := range strategies {
+		repoPath := filepath.Join(t.TempDir(), "repo")
+		if err := cloneRepository(context.Background(), "file://"+filepath.ToSlash(source), "v1", repoPath, strategy, nil); err != nil {
+			t.Fatalf("clone with %q strategy: %v", strategy.mode, err)
+		}
+		content, err := os.ReadFile(filepath.Join(repoPath, "version.txt"))
+		if err != nil || string(content) != "1\n" {
+			t.Fatalf("unexpected checkout with %q strategy: content=%q err=%v", strategy.mode, content, err)
+		}
+
+		_, shallowErr := os.Stat(filepath.Join(repoPath, ".git", "shallow"))
+		wantShallow := strategy.mode == "" || strategy.mode == "shallow-since"
+		if (shallowErr == nil) != wantShallow {
+			t.Fatalf("unexpected shallow state with %q strategy: shallow=%v", strategy.mode, shallowErr == nil)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// githubReleaseUploader publishes the artifacts of a job as assets of a
+// new GitHub Release tagged build-<jobID>.
+type githubReleaseUploader struct {
+	apiURL     string
+	repo       string
+	token      string
+	prerelease bool
+	client     *http.Client
+}
+
+type githubRelease struct {
+	HTMLURL   string `json:"html_url"`
+	UploadURL string `json:"upload_url"`
+}
+
+type githubReleaseAsset struct {
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+func newGitHubReleaseUploader(cfg config.Config) *githubReleaseUploader {
+	if cfg.ArtifactGitHubRepo == "" {
+		return nil
+	}
+	return &githubReleaseUploader{
+		apiURL:     cfg.ArtifactGitHubAPIURL,
+		repo:       cfg.ArtifactGitHubRepo,
+		token:      cfg.ArtifactGitHubToken,
+		prerelease: cfg.ArtifactGitHubPrerelease,
+		client:     &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+func (u *githubReleaseUploader) Name() string {
+	return "github-release"
+}
+
+// Upload creates the release and attaches every artifact. The returned URLs
+// are the public browser download links of the assets.
+func (u *githubReleaseUploader) Upload(ctx context.Context, state State, artifacts []Artifact) (map[string]string, error) {
+	release, err := u.createRelease(ctx, state, artifacts)
+	if err != nil {
+		return nil, err
+	}
+	uploadURL, _, _ := strings.Cut(release.UploadURL, "{")
+	if uploadURL == "" {
+		return nil, fmt.Errorf("release response has no upload URL")
+	}
+
+	urls := make(map[string]string, len(artifacts))
+	for index, artifact := range artifacts {
+		assetURL, err := u.uploadAsset(ctx, uploadURL, githubAssetName(artifacts, index), artifact)
+		if err != nil {
+			return urls, fmt.Errorf("upload %s: %w", artifact.RelativePath, err)
+		}
+		urls[artifact.ID] = assetURL
+	}
+	return urls, nil
+}
+
+func (u *githubReleaseUploader) createRelease(ctx context.Context, state State, artifacts []Artifact) (githubRelease, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"tag_name":   "build-" + state.ID,
+		"name":       githubReleaseTitle(state),
+		"body":       githubReleaseBody(state, artifacts),
+		"prerelease": u.prerelease,
+	})
+	if err != nil {
+		return githubRelease{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, u.apiURL+"/repos/"+u.repo+"/releases", bytes.NewReader(payload))
+	if err != nil {
+		return githubRelease{}, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	var release githubRelease
+	if err := u.do(request, &release); err != nil {
+		return githubRelease{}, fmt.Errorf("create release: %w", err)
+	}
+	return release, nil
+}
+
+func (u *githubReleaseUploader) uploadAsset(ctx context.Context, uploadURL string, name string, artifact Artifact) (string, error) {
+	reader, err := artifact.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL+"?name="+url.QueryEscape(name), reader)
+	if err != nil {
+		return "", err
+	}
+	request.ContentLength = artifact.Size
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	var asset githubReleaseAsset
+	if err := u.do(request, &asset); err != nil {
+		return "", err
+	}
+	return asset.BrowserDownloadURL, nil
+}
+
+func (u *githubReleaseUploader) do(request *http.Request, target interface{}) error {
+	request.Header.Set("Authorization", "Bearer "+u.token)
+	request.Header.Set("Accept", "application/vnd.github+json")
+	request.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	response, err := u.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("GitHub responded %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+// githubAssetName returns the artifact name, falling back to the relative
+// path when another artifact has the same name, because asset names must be
+// unique within a release.
+func githubAssetName(artifacts []Artifact, index int) string {
+	name := artifacts[index].Name
+	for other := range artifacts {
+		if other != index && artifacts[other].Name == name {
+			return strings.ReplaceAll(artifacts[index].RelativePath, "/", "_")
+		}
+	}
+	return name
+}
+
+func githubReleaseTitle(state State) string {
+	title := state.Device
+	if state.Ref != "" {
+		title += " @ " + state.Ref
+	}
+	if len(state.Commit) >= 8 {
+		title += " (" + state.Commit[:8] + ")"
+	}
+	return title
+}
+
+func githubReleaseBody(state State, artifacts []Artifact) string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "Firmware build `%s` for `%s`.\n\n", state.ID, state.Device)
+	fmt.Fprintf(&builder, "- Repository: %s\n", state.RepoURL)
+	if state.Ref != "" {
+		fmt.Fprintf(&builder, "- Ref: `%s`\n", state.Ref)
+	}
+	if state.Commit != "" {
+		fmt.Fprintf(&builder, "- Commit: `%s`\n", state.Commit)
+	}
+	for _, flag := range state.BuildFlags {
+		fmt.Fprintf(&builder, "- Build flag: `%s`\n", flag)
+	}
+	for _, dep := range state.LibDeps {
+		fmt.Fprintf(&builder, "- Library: `%s`\n", dep)
+	}
+
+	builder.WriteString("\n| File | SHA-256 |\n| --- | --- |\n")
+	for index, artifact := range artifacts {
+		fmt.Fprintf(&builder, "| %s | `%s` |\n", githubAssetName(artifacts, index), artifact.SHA256)
+	}
+	return builder.String()
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestGitHubReleaseUploaderUpload(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var release map[string]interface{}
+	assets := make(map[string]string)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/firmware/releases":
+			_ = json.NewDecoder(r.Body).Decode(&release)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"html_url":"https://github.com/acme/firmware/releases/tag/build-job1","upload_url":"`+server.URL+`/uploads/1/assets{?name,label}"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/uploads/1/assets":
+			body, _ := io.ReadAll(r.Body)
+			name := r.URL.Query().Get("name")
+			assets[name] = string(body)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"browser_download_url":"https://github.com/acme/firmware/releases/download/build-job1/`+name+`"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	artifacts := make([]Artifact, 0, 2)
+	for index, relPath := range []string{"firmware.bin", "nested/firmware.bin"} {
+		path := filepath.Join(dir, strings.ReplaceAll(relPath, "/", "_"))
+		if err := os.WriteFile(path, []byte(relPath), 0o644); err != nil {
+			t.Fatalf("write artifact: %v", err)
+		}
+		artifacts = append(artifacts, Artifact{
+			ID:           string(rune('1' + index)),
+			Name:         "firmware.bin",
+			RelativePath: relPath,
+			Size:         int64(len(relPath)),
+			absPath:      path,
+		})
+	}
+
+	uploader := newGitHubReleaseUploader(config.Config{
+		ArtifactGitHubRepo:       "acme/firmware",
+		ArtifactGitHubToken:      "token",
+		ArtifactGitHubAPIURL:     server.URL,
+		ArtifactGitHubPrerelease: true,
+	})
+	state := State{ID: "job1", RepoURL: "https://github.com/meshtastic/firmware", Ref: "main", Device: "tbeam", Commit: "1a2b3c4d5e6f"}
+	urls, err := uploader.Upload(context.Background(), state, artifacts)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if release["tag_name"] != "build-job1" || release["prerelease"] != true {
+		t.Fatalf("unexpected release request: %v", release)
+	}
+	if release["name"] != "tbeam @ main (1a2b3c4d)" {
+		t.Fatalf("unexpected release name: got=%v", release["name"])
+	}
+	if assets["firmware.bin"] != "firmware.bin" || assets["nested_firmware.bin"] != "nested/firmware.bin" {
+		t.Fatalf("duplicate names must fall back to relative paths: %v", assets)
+	}
+	if urls["2"] != "https://github.com/acme/firmware/releases/download/build-job1/nested_firmware.bin" {
+		t.Fatalf("unexpected asset URL: got=%s", urls["2"])
+	}
+}
+
+func TestNewGitHubReleaseUploaderDisabled(t *testing.T) {
+	t.Parallel()
+
+	if uploader := newGitHubReleaseUploader(config.Config{}); uploader != nil {
+		t.Fatalf("uploader must be disabled without a repository")
+	}
+}
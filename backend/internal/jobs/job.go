@@ -1,14 +1,19 @@
 package jobs
 
 import (
+	"context"
+	"io"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/notify"
 )
 
 type Status string
 
 const (
+	StatusPending   Status = "pending"
 	StatusQueued    Status = "queued"
 	StatusRunning   Status = "running"
 	StatusSuccess   Status = "success"
@@ -19,8 +24,16 @@ const (
 type BuildOptions struct {
 	BuildFlags []string
 	LibDeps    []string
+	// ArtifactPatterns are extra glob patterns for build outputs to publish
+	// as artifacts; they do not change the build itself.
+	ArtifactPatterns []string
+	// Patch is a unified diff applied with git apply after checkout.
+	Patch string
+	// Submodules re-point or pin submodules after checkout.
+	Submodules []SubmoduleOverride
 }
 
+// IsEmpty reports whether the options require no platformio.ini overrides.
 func (o BuildOptions) IsEmpty() bool {
 	return len(o.BuildFlags) == 0 && len(o.LibDeps) == 0
 }
@@ -33,8 +46,11 @@ func (o BuildOptions) clone() BuildOptions {
 	copy(deps, o.LibDeps)
 
 	return BuildOptions{
-		BuildFlags: flags,
-		LibDeps:    deps,
+		BuildFlags:       flags,
+		LibDeps:          deps,
+		ArtifactPatterns: append([]string(nil), o.ArtifactPatterns...),
+		Patch:            o.Patch,
+		Submodules:       cloneSubmoduleOverrides(o.Submodules),
 	}
 }
 
@@ -43,72 +59,127 @@ type Artifact struct {
 	Name         string `json:"name"`
 	RelativePath string `json:"relativePath"`
 	Size         int64  `json:"size"`
-
-	absPath string
+	SHA256       string `json:"sha256,omitempty"`
+	MD5          string `json:"md5,omitempty"`
+	// URL is an external download location (object storage, releases).
+	URL string `json:"url,omitempty"`
+	// Downloads counts completed downloads through this server.
+	Downloads      int64      `json:"downloads"`
+	LastDownloadAt *time.Time `json:"lastDownloadAt,omitempty"`
+
+	absPath  string
+	encoding string
 }
 
 func (a Artifact) AbsolutePath() string {
 	return a.absPath
 }
 
+// Encoding reports how the file at AbsolutePath is stored: "" for raw
+// files or "zstd" for compressed firmware cache entries.
+func (a Artifact) Encoding() string {
+	return a.encoding
+}
+
+// Open returns the decoded artifact content regardless of storage encoding.
+func (a Artifact) Open() (io.ReadCloser, error) {
+	return openEncodedFile(a.absPath, a.encoding)
+}
+
 type State struct {
-	ID              string      `json:"id"`
-	RepoURL         string      `json:"repoUrl"`
-	Ref             string      `json:"ref,omitempty"`
-	Device          string      `json:"device"`
-	BuildFlags      []string    `json:"buildFlags,omitempty"`
-	LibDeps         []string    `json:"libDeps,omitempty"`
-	ClientIP        string      `json:"-"`
-	Status          Status      `json:"status"`
-	QueuePosition   *int        `json:"queuePosition,omitempty"`
-	QueueETASeconds *int        `json:"queueEtaSeconds,omitempty"`
-	CreatedAt       time.Time   `json:"createdAt"`
-	StartedAt       *time.Time  `json:"startedAt,omitempty"`
-	FinishedAt      *time.Time  `json:"finishedAt,omitempty"`
-	Error           string      `json:"error,omitempty"`
-	Artifacts       []Artifact  `json:"artifacts"`
-	LogLines        int         `json:"logLines"`
-	Logs            []string    `json:"-"`
-	Internal        interface{} `json:"-"`
+	ID               string              `json:"id"`
+	RepoURL          string              `json:"repoUrl"`
+	Ref              string              `json:"ref,omitempty"`
+	RequestedRef     string              `json:"requestedRef,omitempty"`
+	Device           string              `json:"device"`
+	Commit           string              `json:"commit,omitempty"`
+	BuildFlags       []string            `json:"buildFlags,omitempty"`
+	LibDeps          []string            `json:"libDeps,omitempty"`
+	ArtifactPatterns []string            `json:"artifactPatterns,omitempty"`
+	PatchSHA256      string              `json:"patchSha256,omitempty"`
+	Submodules       []SubmoduleOverride `json:"submodules,omitempty"`
+	ClientIP         string              `json:"-"`
+	UserID           string              `json:"-"`
+	Private          bool                `json:"-"`
+	AccessToken      string              `json:"-"`
+	Status           Status              `json:"status"`
+	QueuePosition    *int                `json:"queuePosition,omitempty"`
+	QueueETASeconds  *int                `json:"queueEtaSeconds,omitempty"`
+	CreatedAt        time.Time           `json:"createdAt"`
+	StartedAt        *time.Time          `json:"startedAt,omitempty"`
+	FinishedAt       *time.Time          `json:"finishedAt,omitempty"`
+	Error            string              `json:"error,omitempty"`
+	FailureKind      FailureKind         `json:"failureKind,omitempty"`
+	Artifacts        []Artifact          `json:"artifacts"`
+	Size             *SizeReport         `json:"size,omitempty"`
+	Progress         *Progress           `json:"progress,omitempty"`
+	Provenance       *Provenance         `json:"provenance,omitempty"`
+	LogLines         int                 `json:"logLines"`
+	Logs             []string            `json:"-"`
+	Internal         interface{}         `json:"-"`
 }
 
 type Job struct {
-	mu          sync.RWMutex
-	ID          string
-	RepoURL     string
-	Ref         string
-	Device      string
-	BuildFlags  []string
-	LibDeps     []string
-	ClientIP    string
-	Status      Status
-	CreatedAt   time.Time
-	StartedAt   *time.Time
-	FinishedAt  *time.Time
-	Error       string
-	Artifacts   []Artifact
-	Workspace   string
-	logLines    []string
-	subscribers map[chan string]struct{}
+	mu               sync.RWMutex
+	ID               string
+	RepoURL          string
+	Ref              string
+	RequestedRef     string
+	Device           string
+	Commit           string
+	BuildFlags       []string
+	LibDeps          []string
+	ArtifactPatterns []string
+	Patch            string
+	Submodules       []SubmoduleOverride
+	ClientIP         string
+	UserID           string
+	Private          bool
+	AccessToken      string
+	Status           Status
+	CreatedAt        time.Time
+	StartedAt        *time.Time
+	FinishedAt       *time.Time
+	Error            string
+	FailureKind      FailureKind
+	Artifacts        []Artifact
+	Size             *SizeReport
+	Progress         *Progress
+	Provenance       *Provenance
+	Workspace        string
+	pruned           bool
+	platform         string
+	cacheHit         bool
+	notify           notify.Target // the job's own recipients; holds webhook URLs, so never in the state
+	trusted          bool          // clones with the stored git credentials
+	cancel           context.CancelFunc
+	cancelRequested  bool
+	logLines         []logLine
+	logOffset        int // lines trimmed from logLines; logLines[i] has ID logOffset+i+1
+	subscribers      map[chan LogEvent]struct{}
+	logFile          *logFile // nil when log files are disabled
 }
 
 func newJob(id string, repoURL string, ref string, device string, options BuildOptions, workspace string, now time.Time, clientIP string) *Job {
 	cloned := options.clone()
 
 	return &Job{
-		ID:          id,
-		RepoURL:     repoURL,
-		Ref:         ref,
-		Device:      device,
-		BuildFlags:  cloned.BuildFlags,
-		LibDeps:     cloned.LibDeps,
-		ClientIP:    clientIP,
-		Status:      StatusQueued,
-		CreatedAt:   now,
-		Workspace:   workspace,
-		logLines:    make([]string, 0, 256),
-		Artifacts:   make([]Artifact, 0),
-		subscribers: make(map[chan string]struct{}),
+		ID:               id,
+		RepoURL:          repoURL,
+		Ref:              ref,
+		Device:           device,
+		BuildFlags:       cloned.BuildFlags,
+		LibDeps:          cloned.LibDeps,
+		ArtifactPatterns: cloned.ArtifactPatterns,
+		Patch:            cloned.Patch,
+		Submodules:       cloned.Submodules,
+		ClientIP:         clientIP,
+		Status:           StatusQueued,
+		CreatedAt:        now,
+		Workspace:        workspace,
+		logLines:         make([]logLine, 0, 256),
+		Artifacts:        make([]Artifact, 0),
+		subscribers:      make(map[chan LogEvent]struct{}),
 	}
 }
 
@@ -120,20 +191,32 @@ func (j *Job) snapshot() State {
 	copy(artifacts, j.Artifacts)
 
 	return State{
-		ID:         j.ID,
-		RepoURL:    j.RepoURL,
-		Ref:        j.Ref,
-		Device:     j.Device,
-		BuildFlags: append([]string(nil), j.BuildFlags...),
-		LibDeps:    append([]string(nil), j.LibDeps...),
-		ClientIP:   j.ClientIP,
-		Status:     j.Status,
-		CreatedAt:  j.CreatedAt,
-		StartedAt:  copyTime(j.StartedAt),
-		FinishedAt: copyTime(j.FinishedAt),
-		Error:      j.Error,
-		Artifacts:  artifacts,
-		LogLines:   len(j.logLines),
+		ID:               j.ID,
+		RepoURL:          RedactSecrets(j.RepoURL),
+		Ref:              j.Ref,
+		RequestedRef:     j.RequestedRef,
+		Device:           j.Device,
+		Commit:           j.Commit,
+		BuildFlags:       append([]string(nil), j.BuildFlags...),
+		LibDeps:          append([]string(nil), j.LibDeps...),
+		ArtifactPatterns: append([]string(nil), j.ArtifactPatterns...),
+		PatchSHA256:      patchDigest(j.Patch),
+		Submodules:       cloneSubmoduleOverrides(j.Submodules),
+		ClientIP:         j.ClientIP,
+		UserID:           j.UserID,
+		Private:          j.Private,
+		AccessToken:      j.AccessToken,
+		Status:           j.Status,
+		CreatedAt:        j.CreatedAt,
+		StartedAt:        copyTime(j.StartedAt),
+		FinishedAt:       copyTime(j.FinishedAt),
+		Error:            j.Error,
+		FailureKind:      j.FailureKind,
+		Artifacts:        artifacts,
+		Size:             j.Size.clone(),
+		Progress:         j.Progress.clone(),
+		Provenance:       j.Provenance.clone(),
+		LogLines:         len(j.logLines),
 	}
 }
 
@@ -141,18 +224,42 @@ func (j *Job) getLogs() []string {
 	j.mu.RLock()
 	defer j.mu.RUnlock()
 	logs := make([]string, len(j.logLines))
-	copy(logs, j.logLines)
+	for index, line := range j.logLines {
+		logs[index] = line.text
+	}
 	return logs
 }
 
-func (j *Job) subscribe() (<-chan string, []string, func()) {
+type logLine struct {
+	text string
+	at   time.Time
+}
+
+// LogEvent is delivered to log stream subscribers: a log line, or a
+// progress update when Progress is set. Log lines carry an ID that increases
+// by one per line of the job, starting at 1, and the time they were logged;
+// progress updates have neither.
+type LogEvent struct {
+	ID       int
+	Time     time.Time
+	Line     string
+	Progress *Progress
+}
+
+// subscribe returns the retained log lines with an ID above afterID and a
+// stream of the events that follow them.
+func (j *Job) subscribe(afterID int) (<-chan LogEvent, []LogEvent, func()) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
-	snapshot := make([]string, len(j.logLines))
-	copy(snapshot, j.logLines)
+	start := max(afterID-j.logOffset, 0)
+	snapshot := make([]LogEvent, 0, max(len(j.logLines)-start, 0))
+	for index := start; index < len(j.logLines); index++ {
+		line := j.logLines[index]
+		snapshot = append(snapshot, LogEvent{ID: j.logOffset + index + 1, Time: line.at, Line: line.text})
+	}
 
-	stream := make(chan string, 256)
+	stream := make(chan LogEvent, 256)
 	if !isFinal(j.Status) {
 		j.subscribers[stream] = struct{}{}
 	} else {
@@ -173,7 +280,7 @@ func (j *Job) subscribe() (<-chan string, []string, func()) {
 }
 
 func (j *Job) appendLog(maxLines int, line string) {
-	clean := strings.TrimRight(line, "\r\n")
+	clean := RedactSecrets(strings.TrimRight(line, "\r\n"))
 	if clean == "" {
 		return
 	}
@@ -181,35 +288,179 @@ func (j *Job) appendLog(maxLines int, line string) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
-	j.logLines = append(j.logLines, clean)
+	now := time.Now().UTC()
+	j.logLines = append(j.logLines, logLine{text: clean, at: now})
+	j.logFile.write(clean)
+	id := j.logOffset + len(j.logLines)
 	if len(j.logLines) > maxLines {
-		j.logLines = append([]string(nil), j.logLines[len(j.logLines)-maxLines:]...)
+		j.logOffset += len(j.logLines) - maxLines
+		j.logLines = append([]logLine(nil), j.logLines[len(j.logLines)-maxLines:]...)
 	}
 
+	j.broadcastLocked(LogEvent{ID: id, Time: now, Line: clean})
+}
+
+func (j *Job) broadcastLocked(event LogEvent) {
 	for stream := range j.subscribers {
 		select {
-		case stream <- clean:
+		case stream <- event:
 		default:
 		}
 	}
 }
 
-func (j *Job) markRunning(now time.Time) {
+func (j *Job) setCommit(commit string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Commit = commit
+}
+
+// setBuildFacts records the detected platform and whether the artifacts
+// came from the firmware cache; both are only persisted with the build log.
+func (j *Job) setBuildFacts(platform string, cacheHit bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.platform = platform
+	j.cacheHit = cacheHit
+}
+
+func (j *Job) buildFacts() (string, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.platform, j.cacheHit
+}
+
+// closeLogFile releases the log file of a finished job; a later line, such
+// as a cleanup message, reopens it.
+func (j *Job) closeLogFile() {
 	j.mu.Lock()
 	defer j.mu.Unlock()
+	j.logFile.close()
+}
+
+func (j *Job) subscriberCount() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return len(j.subscribers)
+}
+
+func (j *Job) setSizeReport(report *SizeReport) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Size = report.clone()
+}
+
+// markRunning moves a queued job to running. It reports false when the job
+// left the queue otherwise, e.g. it was cancelled before a worker picked it.
+func (j *Job) markRunning(now time.Time) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Status != StatusQueued {
+		return false
+	}
 	started := now
 	j.StartedAt = &started
 	j.Status = StatusRunning
+	return true
+}
+
+// markApproved moves a pending job to the queue. It reports false when the
+// job is no longer pending.
+func (j *Job) markApproved() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Status != StatusPending {
+		return false
+	}
+	j.Status = StatusQueued
+	return true
 }
 
-func (j *Job) markFailed(now time.Time, reason string) {
+// markRejected cancels a pending job with reason. It reports false when the
+// job is no longer pending.
+func (j *Job) markRejected(now time.Time, reason string) bool {
 	j.mu.Lock()
 	defer j.mu.Unlock()
+	if j.Status != StatusPending {
+		return false
+	}
 	finished := now
-	j.Status = StatusFailed
+	j.Status = StatusCancelled
 	j.FinishedAt = &finished
 	j.Error = reason
 	j.closeSubscribersLocked()
+	return true
+}
+
+// failQueued fails a queued job with reason before a worker picks it. It
+// reports false when the job is no longer queued.
+func (j *Job) failQueued(now time.Time, reason string, kind FailureKind) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Status != StatusQueued {
+		return false
+	}
+	finished := now
+	j.Status = StatusFailed
+	j.FinishedAt = &finished
+	j.Error = RedactSecrets(reason)
+	j.FailureKind = kind
+	j.closeSubscribersLocked()
+	return true
+}
+
+// setCancel installs the function that aborts the running build. A cancel
+// requested before the build context existed takes effect immediately.
+func (j *Job) setCancel(cancel context.CancelFunc) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cancel = cancel
+	if j.cancelRequested {
+		cancel()
+	}
+}
+
+// requestCancel cancels a pending or queued job right away and aborts a
+// running one. It returns the status the job had and false when the job
+// already finished.
+func (j *Job) requestCancel(now time.Time, reason string) (Status, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := j.Status
+	switch status {
+	case StatusPending, StatusQueued:
+		finished := now
+		j.Status = StatusCancelled
+		j.FinishedAt = &finished
+		j.Error = reason
+		j.closeSubscribersLocked()
+	case StatusRunning:
+		j.cancelRequested = true
+		if j.cancel != nil {
+			j.cancel()
+		}
+	default:
+		return status, false
+	}
+	return status, true
+}
+
+func (j *Job) cancelWasRequested() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.cancelRequested
+}
+
+func (j *Job) markFailed(now time.Time, reason string, kind FailureKind) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	finished := now
+	j.Status = StatusFailed
+	j.FinishedAt = &finished
+	j.Error = RedactSecrets(reason)
+	j.FailureKind = kind
+	j.closeSubscribersLocked()
 }
 
 func (j *Job) markCancelled(now time.Time, reason string) {
@@ -218,7 +469,7 @@ func (j *Job) markCancelled(now time.Time, reason string) {
 	finished := now
 	j.Status = StatusCancelled
 	j.FinishedAt = &finished
-	j.Error = reason
+	j.Error = RedactSecrets(reason)
 	j.closeSubscribersLocked()
 }
 
@@ -242,6 +493,29 @@ func (j *Job) isExpired(now time.Time, ttl time.Duration) bool {
 	return now.Sub(*j.FinishedAt) >= ttl
 }
 
+// workspaceExpired reports whether a finished job's checkout has outlived
+// ttl and has not been pruned yet.
+func (j *Job) workspaceExpired(now time.Time, ttl time.Duration) bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.FinishedAt == nil || j.pruned {
+		return false
+	}
+	return now.Sub(*j.FinishedAt) >= ttl
+}
+
+// markWorkspacePruned records that the checkout was removed and reports
+// whether this call was the first to do so.
+func (j *Job) markWorkspacePruned() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.pruned {
+		return false
+	}
+	j.pruned = true
+	return true
+}
+
 func (j *Job) artifactByID(artifactID string) (Artifact, bool) {
 	j.mu.RLock()
 	defer j.mu.RUnlock()
@@ -253,6 +527,26 @@ func (j *Job) artifactByID(artifactID string) (Artifact, bool) {
 	return Artifact{}, false
 }
 
+// recordDownload counts a download of each listed artifact and reports
+// whether any of them exists.
+func (j *Job) recordDownload(now time.Time, artifactIDs []string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	found := false
+	for index := range j.Artifacts {
+		for _, artifactID := range artifactIDs {
+			if j.Artifacts[index].ID != artifactID {
+				continue
+			}
+			downloadedAt := now
+			j.Artifacts[index].Downloads++
+			j.Artifacts[index].LastDownloadAt = &downloadedAt
+			found = true
+		}
+	}
+	return found
+}
+
 func (j *Job) closeSubscribersLocked() {
 	for stream := range j.subscribers {
 		close(stream)
@@ -0,0 +1,62 @@
+package jobs
+
+import "fmt"
+
+// DrainStatus reports whether new builds are refused and how much work is
+// still in flight.
+type DrainStatus struct {
+	Draining bool `json:"draining"`
+	Pending  int  `json:"pending"`
+	Queued   int  `json:"queued"`
+	Running  int  `json:"running"`
+}
+
+// CancelJob cancels a pending or queued job or aborts a running build. Finished jobs
+// return ErrJobFinished.
+func (m *Manager) CancelJob(jobID string) (State, error) {
+	job, err := m.getJob(jobID)
+	if err != nil {
+		return State{}, err
+	}
+
+	status, ok := job.requestCancel(m.now(), "build cancelled")
+	if !ok {
+		return State{}, fmt.Errorf("%w: %s", ErrJobFinished, status)
+	}
+	switch status {
+	case StatusPending:
+		m.saveBuildLog(job)
+	case StatusQueued:
+		m.removeQueuedJob(jobID)
+		m.saveBuildLog(job)
+	}
+	m.logger.Info("job cancelled", "jobId", jobID, "status", status)
+	return job.snapshot(), nil
+}
+
+// SetDraining makes CreateJob refuse new builds with ErrDraining while
+// queued and running jobs finish, e.g. before a planned restart.
+func (m *Manager) SetDraining(draining bool) {
+	if m.draining.Swap(draining) != draining {
+		m.logger.Info("drain mode changed", "draining", draining)
+	}
+}
+
+func (m *Manager) Draining() bool {
+	return m.draining.Load()
+}
+
+func (m *Manager) DrainStatus() DrainStatus {
+	status := DrainStatus{Draining: m.draining.Load()}
+	for _, state := range m.ListJobs() {
+		switch state.Status {
+		case StatusPending:
+			status.Pending++
+		case StatusQueued:
+			status.Queued++
+		case StatusRunning:
+			status.Running++
+		}
+	}
+	return status
+}
@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func newQueueOnlyManager(t *testing.T) *Manager {
+	t.Helper()
+	workDir := t.TempDir()
+	mgr := NewManager(config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	t.Cleanup(mgr.Close)
+	return mgr
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	t.Parallel()
+
+	mgr := newQueueOnlyManager(t)
+	first, err := mgr.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	second, err := mgr.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	state, err := mgr.CancelJob(first.ID)
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if state.Status != StatusCancelled || state.FinishedAt == nil {
+		t.Fatalf("unexpected state after cancel: %+v", state)
+	}
+	if _, err := mgr.CancelJob(first.ID); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("second cancel must report ErrJobFinished, got %v", err)
+	}
+
+	remaining, err := mgr.GetJob(second.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if remaining.QueuePosition == nil || *remaining.QueuePosition != 1 {
+		t.Fatalf("cancelled job must leave the queue: %+v", remaining.QueuePosition)
+	}
+
+	job, err := mgr.getJob(first.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if job.markRunning(time.Now()) {
+		t.Fatalf("a worker must not start a cancelled job")
+	}
+	if bl, err := mgr.buildLogs.Get(first.ID); err != nil || bl == nil || bl.Status != string(StatusCancelled) {
+		t.Fatalf("cancelled job must be persisted: %+v, %v", bl, err)
+	}
+}
+
+func TestCancelRunningJobAbortsBuild(t *testing.T) {
+	t.Parallel()
+
+	mgr := newQueueOnlyManager(t)
+	created, err := mgr.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	job, err := mgr.getJob(created.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	job.markRunning(time.Now())
+
+	if _, err := mgr.CancelJob(created.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job.setCancel(cancel)
+	if ctx.Err() == nil {
+		t.Fatalf("cancel requested before the build context existed must apply")
+	}
+
+	mgr.failJob(job, ctx.Err())
+	if state := job.snapshot(); state.Status != StatusCancelled {
+		t.Fatalf("aborted build must end cancelled, got %s", state.Status)
+	}
+}
+
+func TestDrainingRefusesNewJobs(t *testing.T) {
+	t.Parallel()
+
+	mgr := newQueueOnlyManager(t)
+	if _, err := mgr.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, ""); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	mgr.SetDraining(true)
+	if _, err := mgr.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, ""); !errors.Is(err, ErrDraining) {
+		t.Fatalf("expected ErrDraining, got %v", err)
+	}
+	if status := mgr.DrainStatus(); !status.Draining || status.Queued != 1 || status.Running != 0 {
+		t.Fatalf("unexpected drain status: %+v", status)
+	}
+
+	mgr.SetDraining(false)
+	if _, err := mgr.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, ""); err != nil {
+		t.Fatalf("create job after drain: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"slices"
 	"testing"
 	"time"
 )
@@ -34,3 +35,70 @@ func TestJobStateTransitions(t *testing.T) {
 		t.Fatalf("expected one artifact")
 	}
 }
+
+func TestJobRecordDownload(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 2, 24, 10, 0, 0, 0, time.UTC)
+	job := newJob("abc", "https://example.com/repo.git", "main", "tbeam", BuildOptions{}, "/tmp/workspace", now, "")
+	job.markSuccess(now, []Artifact{{ID: "1", Name: "firmware.bin"}, {ID: "2", Name: "firmware.elf"}})
+
+	if job.recordDownload(now, []string{"9"}) {
+		t.Fatalf("unknown artifact must not be recorded")
+	}
+	job.recordDownload(now.Add(time.Minute), []string{"1"})
+	job.recordDownload(now.Add(2*time.Minute), []string{"1", "2"})
+
+	state := job.snapshot()
+	if state.Artifacts[0].Downloads != 2 || state.Artifacts[1].Downloads != 1 {
+		t.Fatalf("unexpected download counts: got=%d,%d want=2,1", state.Artifacts[0].Downloads, state.Artifacts[1].Downloads)
+	}
+	if last := state.Artifacts[0].LastDownloadAt; last == nil || !last.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("unexpected last download time: got=%v want=%v", last, now.Add(2*time.Minute))
+	}
+}
+
+func TestJobSubscribeResumesAfterLogID(t *testing.T) {
+	t.Parallel()
+
+	job := newJob("abc", "https://example.com/repo.git", "main", "tbeam", BuildOptions{}, "/tmp/workspace", time.Now(), "")
+	for _, line := range []string{"one", "two", "three", "four", "five"} {
+		job.appendLog(3, line)
+	}
+
+	ids := func(events []LogEvent) []int {
+		result := make([]int, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.ID)
+		}
+		return result
+	}
+
+	cases := []struct {
+		afterID int
+		want    []int
+	}{
+		{0, []int{3, 4, 5}},
+		{1, []int{3, 4, 5}},
+		{3, []int{4, 5}},
+		{5, []int{}},
+		{9, []int{}},
+	}
+	for _, tc := range cases {
+		_, snapshot, unsubscribe := job.subscribe(tc.afterID)
+		unsubscribe()
+		if got := ids(snapshot); !slices.Equal(got, tc.want) {
+			t.Fatalf("unexpected snapshot after %d: got=%v want=%v", tc.afterID, got, tc.want)
+		}
+	}
+
+	stream, snapshot, unsubscribe := job.subscribe(4)
+	defer unsubscribe()
+	if len(snapshot) != 1 || snapshot[0].Line != "five" {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	job.appendLog(3, "six")
+	if event := <-stream; event.ID != 6 || event.Line != "six" || event.Time.IsZero() {
+		t.Fatalf("unexpected live event: %+v", event)
+	}
+}
@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"strings"
+	"time"
+)
+
+// LastBuild is a finished, successful build of a device that is still
+// retained, so its artifacts can be downloaded without queueing a new job.
+type LastBuild struct {
+	JobID      string
+	Ref        string
+	Commit     string
+	FinishedAt time.Time
+	Artifacts  []Artifact
+	// Current reports whether the build is of the commit the caller asked
+	// about.
+	Current bool
+}
+
+// LastSuccessfulBuilds returns, keyed by the device the job was created
+// for, the newest successful public build of repoURL with default build
+// options. A build of commit is preferred over newer builds of other
+// commits.
+func (m *Manager) LastSuccessfulBuilds(repoURL string, commit string) map[string]LastBuild {
+	m.mu.RLock()
+	candidates := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		candidates = append(candidates, job)
+	}
+	m.mu.RUnlock()
+
+	commit = strings.ToLower(strings.TrimSpace(commit))
+	builds := make(map[string]LastBuild)
+	for _, job := range candidates {
+		state := job.snapshot()
+		if state.Status != StatusSuccess || state.RepoURL != repoURL || state.FinishedAt == nil || len(state.Artifacts) == 0 || state.Private {
+			continue
+		}
+		if len(state.BuildFlags) > 0 || len(state.LibDeps) > 0 || state.PatchSHA256 != "" || len(state.Submodules) > 0 {
+			continue
+		}
+
+		build := LastBuild{
+			JobID:      state.ID,
+			Ref:        state.Ref,
+			Commit:     state.Commit,
+			FinishedAt: *state.FinishedAt,
+			Artifacts:  state.Artifacts,
+			Current:    commit != "" && strings.EqualFold(state.Commit, commit),
+		}
+		if previous, ok := builds[state.Device]; ok && !build.preferredOver(previous) {
+			continue
+		}
+		builds[state.Device] = build
+	}
+	return builds
+}
+
+// LastSuccessfulBuild returns the build of device from builds, which may
+// be keyed by the env name or by the variant path the job was created with.
+func LastSuccessfulBuild(builds map[string]LastBuild, device DiscoveredDevice) (LastBuild, bool) {
+	build, ok := builds[device.Name]
+	if device.RelativePath == "" {
+		return build, ok
+	}
+	if byPath, found := builds[device.RelativePath]; found && (!ok || byPath.preferredOver(build)) {
+		return byPath, true
+	}
+	return build, ok
+}
+
+// preferredOver reports whether b should be shown instead of other: builds
+// of the current commit first, then the newest.
+func (b LastBuild) preferredOver(other LastBuild) bool {
+	if b.Current != other.Current {
+		return b.Current
+	}
+	return b.FinishedAt.After(other.FinishedAt)
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastSuccessfulBuilds(t *testing.T) {
+	t.Parallel()
+
+	const repoURL = "https://github.com/example/repo.git"
+	commitA := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	commitB := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	finished := func(id string, device string, commit string, options BuildOptions, status Status, finishedAt time.Time) *Job {
+		job := newJob(id, repoURL, "main", device, options, "/tmp/"+id, now, "")
+		job.Status = status
+		job.Commit = commit
+		job.FinishedAt = &finishedAt
+		job.Artifacts = []Artifact{{ID: "firmware", Name: "firmware.bin"}}
+		return job
+	}
+
+	mgr := &Manager{jobs: map[string]*Job{
+		"old-a":     finished("old-a", "tbeam", commitA, BuildOptions{}, StatusSuccess, now.Add(time.Minute)),
+		"new-b":     finished("new-b", "tbeam", commitB, BuildOptions{}, StatusSuccess, now.Add(time.Hour)),
+		"custom":    finished("custom", "tbeam", commitA, BuildOptions{BuildFlags: []string{"-DX"}}, StatusSuccess, now.Add(2*time.Hour)),
+		"failed":    finished("failed", "rak4631", commitA, BuildOptions{}, StatusFailed, now.Add(time.Hour)),
+		"by-path":   finished("by-path", "nrf52/t-echo", commitA, BuildOptions{}, StatusSuccess, now.Add(time.Hour)),
+		"by-name":   finished("by-name", "t-echo", commitB, BuildOptions{}, StatusSuccess, now.Add(2*time.Hour)),
+		"elsewhere": finished("elsewhere", "heltec-v3", commitA, BuildOptions{}, StatusSuccess, now),
+		"private":   finished("private", "rak4631", commitA, BuildOptions{}, StatusSuccess, now.Add(3*time.Hour)),
+	}}
+	mgr.jobs["elsewhere"].RepoURL = "https://github.com/other/repo.git"
+	mgr.jobs["private"].Private = true
+
+	builds := mgr.LastSuccessfulBuilds(repoURL, commitA)
+
+	tbeam, ok := LastSuccessfulBuild(builds, DiscoveredDevice{Name: "tbeam", RelativePath: "esp32/tbeam"})
+	if !ok || tbeam.JobID != "old-a" || !tbeam.Current {
+		t.Fatalf("expected the build of the current commit to win: got=%+v", tbeam)
+	}
+	if _, ok := LastSuccessfulBuild(builds, DiscoveredDevice{Name: "rak4631"}); ok {
+		t.Fatalf("failed and private builds must not be reported")
+	}
+	if _, ok := LastSuccessfulBuild(builds, DiscoveredDevice{Name: "heltec-v3"}); ok {
+		t.Fatalf("builds of other repositories must not be reported")
+	}
+	echo, ok := LastSuccessfulBuild(builds, DiscoveredDevice{Name: "t-echo", RelativePath: "nrf52/t-echo"})
+	if !ok || echo.JobID != "by-path" {
+		t.Fatalf("expected the current-commit build selected by path: got=%+v", echo)
+	}
+
+	latest := mgr.LastSuccessfulBuilds(repoURL, "")
+	if build := latest["tbeam"]; build.JobID != "new-b" || build.Current {
+		t.Fatalf("expected the newest build without a commit: got=%+v", build)
+	}
+}
@@ -0,0 +1,293 @@
+package jobs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	logFileName = "build.log"
+	// maxLogFileLine bounds the lines read back; build output lines are far
+	// shorter.
+	maxLogFileLine = 1 << 20
+)
+
+// ErrLogNotFound is returned when a job has no log file on disk.
+var ErrLogNotFound = errors.New("log file not found")
+
+// logFile appends the lines of one job to build.log in its workspace, so
+// the full log outlives the in-memory MaxLogLines ring and restarts. Once
+// the file exceeds maxBytes it is compressed to build.log.1.gz, older
+// segments move up by one and those beyond maxFiles are deleted. The job
+// mutex serializes every call.
+type logFile struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+	logger   *slog.Logger
+	file     *os.File
+	size     int64
+	failed   bool
+}
+
+func (m *Manager) newLogFile(jobID string, workspace string) *logFile {
+	if !m.cfg.JobLogFiles {
+		return nil
+	}
+	return &logFile{
+		path:     filepath.Join(workspace, logFileName),
+		maxBytes: int64(m.cfg.JobLogRotateMB) * bytesPerMB,
+		maxFiles: m.cfg.JobLogMaxFiles,
+		logger:   m.logger.With("jobId", jobID),
+	}
+}
+
+// write appends line. After an error the job keeps its in-memory log only.
+func (f *logFile) write(line string) {
+	if f == nil || f.failed {
+		return
+	}
+	if err := f.writeLine(line); err != nil {
+		f.failed = true
+		f.close()
+		f.logger.Warn("job log: writing the log file failed, keeping the log in memory only", "path", f.path, "error", err)
+	}
+}
+
+func (f *logFile) writeLine(line string) error {
+	if f.file == nil {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		f.file, f.size = file, info.Size()
+	}
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(line))+1 > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return fmt.Errorf("rotate: %w", err)
+		}
+		return f.writeLine(line)
+	}
+	written, err := f.file.WriteString(line + "\n")
+	f.size += int64(written)
+	return err
+}
+
+// rotate compresses the current file into the first segment. The next write
+// opens a new file.
+func (f *logFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file, f.size = nil, 0
+
+	if f.maxFiles == 0 {
+		return os.Remove(f.path)
+	}
+	segments, err := logSegments(f.path)
+	if err != nil {
+		return err
+	}
+	for index := len(segments) - 1; index >= 0; index-- {
+		segment := segments[index]
+		if segment.number >= f.maxFiles {
+			if err := os.Remove(segment.path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(segment.path, segmentPath(f.path, segment.number+1)); err != nil {
+			return err
+		}
+	}
+	if err := compressFile(f.path, segmentPath(f.path, 1)); err != nil {
+		return err
+	}
+	return os.Remove(f.path)
+}
+
+func (f *logFile) close() {
+	if f == nil || f.file == nil {
+		return
+	}
+	if err := f.file.Close(); err != nil {
+		f.logger.Warn("job log: closing the log file failed", "path", f.path, "error", err)
+	}
+	f.file = nil
+}
+
+type logSegment struct {
+	number int
+	path   string
+}
+
+// logSegments returns the compressed segments of the log at path, newest
+// (build.log.1.gz) first.
+func logSegments(path string) ([]logSegment, error) {
+	matches, err := filepath.Glob(path + ".*.gz")
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]logSegment, 0, len(matches))
+	for _, match := range matches {
+		number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(match, path+"."), ".gz"))
+		if err != nil || number < 1 {
+			continue
+		}
+		segments = append(segments, logSegment{number: number, path: match})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].number < segments[j].number })
+	return segments, nil
+}
+
+func segmentPath(path string, number int) string {
+	return path + "." + strconv.Itoa(number) + ".gz"
+}
+
+func compressFile(source string, target string) (err error) {
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	temporary := target + ".tmp"
+	output, err := os.Create(temporary)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			output.Close()
+			os.Remove(temporary)
+		}
+	}()
+
+	writer := gzip.NewWriter(output)
+	if _, err = io.Copy(writer, input); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	if err = output.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary, target)
+}
+
+// openLogFile returns the log at path, oldest segment first. Segments are
+// opened up front, so a rotation while reading does not skip or repeat
+// lines.
+func openLogFile(path string) (io.ReadCloser, error) {
+	segments, err := logSegments(path)
+	if err != nil {
+		return nil, err
+	}
+	reader := &logReader{}
+	for index := len(segments) - 1; index >= 0; index-- {
+		file, err := os.Open(segments[index].path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		reader.files = append(reader.files, file)
+		decompressed, err := gzip.NewReader(file)
+		if err != nil {
+			reader.Close()
+			return nil, fmt.Errorf("read %s: %w", filepath.Base(segments[index].path), err)
+		}
+		reader.readers = append(reader.readers, decompressed)
+	}
+	file, err := os.Open(path)
+	switch {
+	case err == nil:
+		reader.files = append(reader.files, file)
+		reader.readers = append(reader.readers, file)
+	case !errors.Is(err, os.ErrNotExist):
+		reader.Close()
+		return nil, err
+	}
+	if len(reader.files) == 0 {
+		return nil, ErrLogNotFound
+	}
+	reader.Reader = io.MultiReader(reader.readers...)
+	return reader, nil
+}
+
+type logReader struct {
+	io.Reader
+	readers []io.Reader
+	files   []*os.File
+}
+
+func (r *logReader) Close() error {
+	var err error
+	for _, file := range r.files {
+		err = errors.Join(err, file.Close())
+	}
+	return err
+}
+
+// OpenLog returns the full log of a job from its log file, including the
+// lines no longer held in memory. It fails with ErrLogNotFound when log
+// files are disabled or nothing was written yet.
+func (m *Manager) OpenLog(jobID string) (io.ReadCloser, error) {
+	job, err := m.getJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.mu.RLock()
+	defer job.mu.RUnlock()
+	if job.logFile == nil || job.logFile.failed {
+		return nil, ErrLogNotFound
+	}
+	return openLogFile(job.logFile.path)
+}
+
+func (m *Manager) readLogFile(jobID string) ([]string, error) {
+	file, err := m.OpenLog(jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := make([]string, 0, 256)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogFileLine)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// OpenStoredLog returns the log file of any job whose workspace still
+// exists, including jobs from before a restart. Callers must restrict it to
+// operators: it does not check the job's visibility.
+func (m *Manager) OpenStoredLog(jobID string) (io.ReadCloser, error) {
+	clean := filepath.Base(jobID)
+	if clean != jobID || clean == "." || clean == ".." {
+		return nil, ErrLogNotFound
+	}
+	return openLogFile(filepath.Join(m.cfg.JobsRootPath, clean, logFileName))
+}
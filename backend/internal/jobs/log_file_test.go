@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func readLog(t *testing.T, reader io.ReadCloser) []string {
+	t.Helper()
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestLogFileRotation(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "workspace", logFileName)
+	file := &logFile{path: path, maxBytes: 64, maxFiles: 2, logger: slog.New(slog.DiscardHandler)}
+	for index := 1; index <= 30; index++ {
+		// Eight bytes per line, so every file holds eight lines.
+		file.write(fmt.Sprintf("line %02d", index))
+	}
+	file.close()
+	if file.failed {
+		t.Fatalf("unexpected write failure")
+	}
+
+	for name, exists := range map[string]bool{"build.log": true, "build.log.1.gz": true, "build.log.2.gz": true, "build.log.3.gz": false} {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), name)); (err == nil) != exists {
+			t.Fatalf("%s: exists=%t, want %t", name, err == nil, exists)
+		}
+	}
+
+	reader, err := openLogFile(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	lines := readLog(t, reader)
+	// Lines 1-8 went to the segment that rotated out.
+	if len(lines) != 22 || lines[0] != "line 09" || lines[21] != "line 30" {
+		t.Fatalf("unexpected log: %q", lines)
+	}
+
+	if _, err := openLogFile(filepath.Join(t.TempDir(), logFileName)); !errors.Is(err, ErrLogNotFound) {
+		t.Fatalf("expected ErrLogNotFound without a log file, got %v", err)
+	}
+}
+
+func TestManagerServesFullLogFromFile(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	manager := NewManager(config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     100,
+		JobLogFiles:     true,
+		CleanupInterval: time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "203.0.113.9")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	job, err := manager.getJob(state.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	for index := 1; index <= 150; index++ {
+		job.appendLog(manager.cfg.MaxLogLines, fmt.Sprintf("line %d", index))
+	}
+	if memory := job.getLogs(); len(memory) != 100 {
+		t.Fatalf("expected the memory ring to hold 100 lines, got %d", len(memory))
+	}
+
+	lines, err := manager.GetLogs(state.ID)
+	if err != nil {
+		t.Fatalf("get logs: %v", err)
+	}
+	if len(lines) != 150 || lines[0] != "line 1" || lines[149] != "line 150" {
+		t.Fatalf("expected the full log from the file, got %d lines", len(lines))
+	}
+
+	job.markCancelled(manager.now(), "build cancelled")
+	manager.saveBuildLog(job)
+	stored, err := manager.OpenStoredLog(state.ID)
+	if err != nil {
+		t.Fatalf("open stored log: %v", err)
+	}
+	if lines := readLog(t, stored); len(lines) != 150 {
+		t.Fatalf("expected the stored log to hold 150 lines, got %d", len(lines))
+	}
+	for _, jobID := range []string{"../build-logs", "missing", "."} {
+		if _, err := manager.OpenStoredLog(jobID); !errors.Is(err, ErrLogNotFound) {
+			t.Fatalf("OpenStoredLog(%q) = %v, want ErrLogNotFound", jobID, err)
+		}
+	}
+}
@@ -6,25 +6,58 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/sync/singleflight"
+
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildlogs"
 	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/notify"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
 )
 
 var (
 	ErrJobNotFound      = errors.New("job not found")
 	ErrArtifactNotFound = errors.New("artifact not found")
+	ErrDeviceMismatch   = errors.New("jobs were built for different devices")
+	ErrELFNotFound      = errors.New("job has no ELF artifact")
+	ErrDeviceNotAllowed = errors.New("device is not available for this repository")
+	ErrJobFinished      = errors.New("job has already finished")
+	ErrDraining         = errors.New("service is draining and does not accept new builds")
+	ErrJobNotPending    = errors.New("job is not waiting for approval")
 )
 
 type Manager struct {
 	cfg       config.Config
-	logger    *log.Logger
+	logger    *slog.Logger
 	buildLogs *buildlogs.Store
+	discovery *discoveryCache
+	uploaders []artifactUploader
+	mirrors   *mirrorCache
+	catalog   *deviceCatalog
+
+	discoveryCalls  singleflight.Group
+	discoveryLimit  *discoveryLimiter
+	catalogWebhook  *catalogWebhook
+	defaultBranches *defaultBranchTracker
+	abuse           *abuseTracker
+	secrets         *secrets.Store
+	registry        *jobRegistry
+	notifier        *notify.Notifier
+	serviceStats    serviceStatsCache
+	readiness       readinessCache
+	dockerCheck     func(context.Context) error
+	imageCheck      func(context.Context, string) error
+	dockerHealth    dockerHealthState
+	diskStat        func(string) (diskSpace, error)
+	draining        atomic.Bool
 
 	mu         sync.RWMutex
 	jobs       map[string]*Job
@@ -37,12 +70,14 @@ type Manager struct {
 	now    func() time.Time
 }
 
-func NewManager(cfg config.Config, logger *log.Logger) *Manager {
+func NewManager(cfg config.Config, logger *slog.Logger) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 	mgr := &Manager{
 		cfg:        cfg,
 		logger:     logger,
 		buildLogs:  buildlogs.NewStore(cfg.BuildLogsPath),
+		discovery:  newDiscoveryCache(maxDiscoveryCacheEntries),
+		catalog:    &deviceCatalog{},
 		jobs:       make(map[string]*Job),
 		queueOrder: make([]string, 0, 128),
 		queue:      make(chan *Job, 128),
@@ -50,6 +85,53 @@ func NewManager(cfg config.Config, logger *log.Logger) *Manager {
 		cancel:     cancel,
 		now:        func() time.Time { return time.Now().UTC() },
 	}
+	mgr.dockerCheck = dockerDaemonReachable
+	mgr.imageCheck = builderImagePresent
+	mgr.diskStat = statDisk
+
+	mgr.discoveryLimit = newDiscoveryLimiter(cfg.DiscoveryConcurrency, cfg.DiscoveryQueueSize)
+	mgr.catalogWebhook = newCatalogWebhook(cfg)
+	mgr.defaultBranches = newDefaultBranchTracker()
+	mgr.abuse = newAbuseTracker(cfg)
+	configureGitNetwork(cfg, logger)
+	if cfg.SecretsKey != "" {
+		// A store that fails to open stays disabled rather than empty, so
+		// the file is never overwritten with a store that lost its entries.
+		if store, err := secrets.Open(cfg.SecretsPath, cfg.SecretsKey); err != nil {
+			logger.Error("secrets: credential store disabled", "error", err)
+		} else {
+			mgr.secrets = store
+		}
+	}
+	configureGitCredentials(mgr.secrets)
+	if registry, err := newJobRegistry(cfg, logger); err != nil {
+		logger.Error("job registry disabled", "error", err)
+	} else if registry != nil {
+		mgr.registry = registry
+		mgr.wg.Add(1)
+		go func() {
+			defer mgr.wg.Done()
+			registry.run(mgr.ctx, mgr.nodeStatus)
+		}()
+	}
+	if cfg.GitMirrorEnabled {
+		mgr.mirrors = newMirrorCache(mgr.ctx, &mgr.wg, cfg.GitMirrorPath, cfg.GitMirrorMinUses, cfg.GitMirrorRefresh, logger, mgr.now)
+	}
+	if uploader := newS3Uploader(cfg); uploader != nil {
+		mgr.uploaders = append(mgr.uploaders, uploader)
+	}
+	if uploader := newGitHubReleaseUploader(cfg); uploader != nil {
+		mgr.uploaders = append(mgr.uploaders, uploader)
+	}
+
+	if notifier := notify.New(notifyOptions(cfg), logger); notifier != nil {
+		mgr.notifier = notifier
+		mgr.wg.Add(1)
+		go func() {
+			defer mgr.wg.Done()
+			notifier.Run(mgr.ctx)
+		}()
+	}
 
 	MigrateFirmwareCacheMetadata(cfg.FirmwareCachePath, mgr.buildLogs, logger)
 
@@ -61,6 +143,16 @@ func NewManager(cfg config.Config, logger *log.Logger) *Manager {
 	mgr.wg.Add(1)
 	go mgr.cleanupLoop()
 
+	if cfg.DockerHealthInterval > 0 {
+		mgr.wg.Add(1)
+		go mgr.dockerHealthLoop()
+	}
+
+	if len(cfg.FeaturedRepos) > 0 && cfg.CatalogRefresh > 0 {
+		mgr.wg.Add(1)
+		go mgr.catalogLoop()
+	}
+
 	return mgr
 }
 
@@ -69,66 +161,216 @@ func (m *Manager) Close() {
 	m.wg.Wait()
 }
 
-func (m *Manager) Discover(ctx context.Context, repoURL string, ref string) ([]DiscoveredDevice, error) {
+// DiscoveryResult is the outcome of Discover: the devices, the commit they
+// were read from (empty when it could not be resolved) and warnings about
+// configuration that was skipped or could not be resolved.
+type DiscoveryResult struct {
+	Devices  []DiscoveredDevice
+	Commit   string
+	Warnings []DiscoveryWarning
+}
+
+func (m *Manager) Discover(ctx context.Context, repoURL string, ref string) (DiscoveryResult, error) {
 	if err := ValidateRepoURL(repoURL); err != nil {
-		return nil, err
+		return DiscoveryResult{}, err
+	}
+	if err := m.checkRepoAllowed(repoURL); err != nil {
+		return DiscoveryResult{}, err
 	}
 	if err := ValidateRef(ref); err != nil {
-		return nil, err
+		return DiscoveryResult{}, err
 	}
 
-	devices, err := discoverDevices(ctx, m.cfg.DiscoveryRootPath, repoURL, ref)
+	result, err := m.discover(ctx, repoURL, ref)
 	if err != nil {
-		return nil, err
+		return DiscoveryResult{}, err
+	}
+	return DiscoveryResult{Devices: result.devices, Commit: result.commit, Warnings: result.warnings}, nil
+}
+
+// discoveryTimeout bounds a shared discovery clone. It is not tied to any
+// single request, because other callers may be waiting for the result.
+const discoveryTimeout = 10 * time.Minute
+
+type discoveryResult struct {
+	devices  []DiscoveredDevice
+	commit   string
+	warnings []DiscoveryWarning
+}
+
+// discover returns the devices of repoURL at ref and the commit they were
+// read from, using the discovery cache when the ref still points at a
+// cached commit. Concurrent calls for the same repository and ref share one
+// clone; a caller that gives up does not cancel it for the others.
+func (m *Manager) discover(ctx context.Context, repoURL string, ref string) (discoveryResult, error) {
+	resultChan := m.discoveryCalls.DoChan(repoURL+"\x00"+ref, func() (interface{}, error) {
+		m.wg.Add(1)
+		defer m.wg.Done()
+		parent := m.ctx
+		if parent == nil {
+			parent = context.Background()
+		}
+		callCtx, cancel := context.WithTimeout(parent, discoveryTimeout)
+		defer cancel()
+		return m.discoverUncoalesced(callCtx, repoURL, ref)
+	})
+
+	select {
+	case <-ctx.Done():
+		return discoveryResult{}, ctx.Err()
+	case call := <-resultChan:
+		if call.Err != nil {
+			return discoveryResult{}, call.Err
+		}
+		result := call.Val.(discoveryResult)
+		return discoveryResult{
+			devices:  m.allowedDevices(repoURL, cloneDiscoveredDevices(result.devices)),
+			commit:   result.commit,
+			warnings: cloneDiscoveryWarnings(result.warnings),
+		}, nil
+	}
+}
+
+func (m *Manager) discoverUncoalesced(ctx context.Context, repoURL string, ref string) (discoveryResult, error) {
+	commit, err := resolveRemoteCommit(ctx, repoURL, ref)
+	if err != nil {
+		m.logger.Warn("discovery: resolve ref", "repo", repoURL, "ref", ref, "error", err)
+	}
+	if devices, warnings, ok := m.discovery.get(repoURL, commit); ok {
+		return discoveryResult{devices: devices, commit: commit, warnings: warnings}, nil
+	}
+
+	release, err := m.discoveryLimit.acquire(ctx)
+	if err != nil {
+		return discoveryResult{}, err
 	}
-	return devices, nil
+	defer release()
+
+	result, err := discoverDevices(ctx, m.cfg.DiscoveryRootPath, repoURL, ref, m.cloneForDiscovery)
+	if err != nil {
+		return discoveryResult{}, err
+	}
+	m.discovery.put(repoURL, result.commit, result.devices, result.warnings, m.now())
+	return result, nil
 }
 
 func (m *Manager) DiscoverRefs(ctx context.Context, repoURL string) (RepoRefs, error) {
 	if err := ValidateRepoURL(repoURL); err != nil {
 		return RepoRefs{}, err
 	}
+	if err := m.checkRepoAllowed(repoURL); err != nil {
+		return RepoRefs{}, err
+	}
+	return m.listRefs(ctx, repoURL)
+}
+
+// listRefs lists the refs of repoURL within the discovery concurrency
+// limit.
+func (m *Manager) listRefs(ctx context.Context, repoURL string) (RepoRefs, error) {
+	release, err := m.discoveryLimit.acquire(ctx)
+	if err != nil {
+		return RepoRefs{}, err
+	}
+	defer release()
 
 	refs, err := discoverRefs(ctx, m.cfg.DiscoveryRootPath, repoURL)
 	if err != nil {
 		return RepoRefs{}, err
 	}
+	m.observeDefaultBranch(repoURL, refs.headBranch)
 	return refs, nil
 }
 
 func (m *Manager) CreateJob(repoURL string, ref string, device string, options BuildOptions, clientIP string) (State, error) {
+	return m.CreateOwnedJob(repoURL, ref, device, options, JobOwner{ClientIP: clientIP})
+}
+
+// CreateOwnedJob is CreateJob with the creator and visibility of the job.
+func (m *Manager) CreateOwnedJob(repoURL string, ref string, device string, options BuildOptions, owner JobOwner) (State, error) {
+	if m.draining.Load() {
+		return State{}, ErrDraining
+	}
+	if err := m.checkDiskSpace(); err != nil {
+		return State{}, err
+	}
 	if err := ValidateRepoURL(repoURL); err != nil {
 		return State{}, err
 	}
+	if err := m.checkRepoAllowed(repoURL); err != nil {
+		return State{}, err
+	}
 	if err := ValidateRef(ref); err != nil {
 		return State{}, err
 	}
 	if err := ValidateDeviceSelection(device); err != nil {
 		return State{}, err
 	}
+	if !m.deviceAllowed(repoURL, device) {
+		return State{}, fmt.Errorf("%w: %s", ErrDeviceNotAllowed, device)
+	}
 
 	normalizedOptions, err := NormalizeBuildOptions(options)
 	if err != nil {
 		return State{}, err
 	}
+	if err := m.notifier.Check(owner.Notify); err != nil {
+		return State{}, err
+	}
+	for _, override := range normalizedOptions.Submodules {
+		if override.URL == "" {
+			continue
+		}
+		if err := m.checkRepoAllowed(override.URL); err != nil {
+			return State{}, err
+		}
+	}
 
 	jobID, err := generateJobID()
 	if err != nil {
 		return State{}, err
 	}
 
+	requestedRef := ""
+	if IsSymbolicRef(ref) {
+		resolved, err := m.resolveSymbolicRef(repoURL, ref)
+		if err != nil {
+			return State{}, err
+		}
+		requestedRef = strings.ToLower(strings.TrimSpace(ref))
+		ref = resolved
+	}
+
 	workspace := filepath.Join(m.cfg.JobsRootPath, jobID)
-	job := newJob(jobID, repoURL, ref, device, normalizedOptions, workspace, m.now(), clientIP)
+	job := newJob(jobID, repoURL, ref, device, normalizedOptions, workspace, m.now(), owner.ClientIP)
+	job.RequestedRef = requestedRef
+	job.UserID = owner.UserID
+	// Builds cloned with the stored credentials may be of private
+	// repositories; never publish them.
+	job.Private = owner.Private || owner.Trusted
+	job.AccessToken = owner.AccessToken
+	job.notify = owner.Notify
+	job.trusted = owner.Trusted
+	job.logFile = m.newLogFile(jobID, workspace)
+
+	if owner.Anonymous && m.needsApproval(repoURL, normalizedOptions) {
+		job.Status = StatusPending
+		job.appendLog(m.cfg.MaxLogLines, "build is waiting for operator approval")
+		m.mu.Lock()
+		m.jobs[jobID] = job
+		m.mu.Unlock()
+		m.logger.Info("job is waiting for approval", "jobId", jobID)
+		m.publishJob(job)
+		return job.snapshot(), nil
+	}
 
 	m.mu.Lock()
 	m.jobs[jobID] = job
-	m.queueOrder = append(m.queueOrder, jobID)
 	m.mu.Unlock()
-
-	select {
-	case m.queue <- job:
-	case <-m.ctx.Done():
-		return State{}, errors.New("service is shutting down")
+	// Published before the job is queued, so a worker's "running" update
+	// cannot be overtaken by this one.
+	m.publishJob(job)
+	if err := m.enqueue(job); err != nil {
+		return State{}, err
 	}
 
 	state := job.snapshot()
@@ -146,20 +388,32 @@ func (m *Manager) GetJob(jobID string) (State, error) {
 	return state, nil
 }
 
+// GetLogs returns the full log of a job from its log file, or the lines
+// held in memory when there is none.
 func (m *Manager) GetLogs(jobID string) ([]string, error) {
 	job, err := m.getJob(jobID)
 	if err != nil {
 		return nil, err
 	}
-	return job.getLogs(), nil
+	lines, err := m.readLogFile(jobID)
+	if err != nil {
+		if !errors.Is(err, ErrLogNotFound) {
+			m.logger.Warn("job log: reading the log file failed", "jobId", jobID, "error", err)
+		}
+		return job.getLogs(), nil
+	}
+	return lines, nil
 }
 
-func (m *Manager) SubscribeLogs(jobID string) (<-chan string, []string, func(), error) {
+// SubscribeLogs returns the retained log lines of a job with an ID above
+// afterID, so that a reconnecting client resumes where it left off, and a
+// stream of the following events.
+func (m *Manager) SubscribeLogs(jobID string, afterID int) (<-chan LogEvent, []LogEvent, func(), error) {
 	job, err := m.getJob(jobID)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	stream, snapshot, unsubscribe := job.subscribe()
+	stream, snapshot, unsubscribe := job.subscribe(afterID)
 	return stream, snapshot, unsubscribe, nil
 }
 
@@ -180,14 +434,50 @@ func (m *Manager) GetArtifact(jobID string, artifactID string) (Artifact, error)
 	return artifact, nil
 }
 
+// RecordArtifactDownload increments the download counters of the given
+// artifacts of a job.
+func (m *Manager) RecordArtifactDownload(jobID string, artifactIDs ...string) error {
+	job, err := m.getJob(jobID)
+	if err != nil {
+		return err
+	}
+	if !job.recordDownload(m.now(), artifactIDs) {
+		return ErrArtifactNotFound
+	}
+	return nil
+}
+
+// ListJobs returns a snapshot of every job still held in memory, oldest
+// first.
+func (m *Manager) ListJobs() []State {
+	m.mu.RLock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	m.mu.RUnlock()
+
+	states := make([]State, 0, len(jobs))
+	for _, job := range jobs {
+		states = append(states, job.snapshot())
+	}
+	sort.Slice(states, func(i int, j int) bool {
+		if !states[i].CreatedAt.Equal(states[j].CreatedAt) {
+			return states[i].CreatedAt.Before(states[j].CreatedAt)
+		}
+		return states[i].ID < states[j].ID
+	})
+	return states
+}
+
 func (m *Manager) workerLoop(workerID int) {
 	defer m.wg.Done()
 
-	m.logger.Printf("worker-%d started", workerID)
+	m.logger.Debug("worker started", "worker", workerID)
 	for {
 		select {
 		case <-m.ctx.Done():
-			m.logger.Printf("worker-%d stopped", workerID)
+			m.logger.Debug("worker stopped", "worker", workerID)
 			return
 		case job := <-m.queue:
 			if job == nil {
@@ -199,33 +489,72 @@ func (m *Manager) workerLoop(workerID int) {
 }
 
 func (m *Manager) executeJob(job *Job) {
-	job.markRunning(m.now())
+	started := job.markRunning(m.now())
 	m.removeQueuedJob(job.ID)
+	if !started {
+		return
+	}
+	m.publishJob(job)
+	if err := m.infrastructureError(); err != nil {
+		job.appendLog(m.cfg.MaxLogLines, "ERROR: "+err.Error())
+		job.markFailed(m.now(), err.Error(), FailureInfra)
+		m.saveBuildLog(job)
+		return
+	}
 	job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("build started for device %s", job.Device))
 
 	if err := os.MkdirAll(job.Workspace, 0o755); err != nil {
-		m.failJob(job, fmt.Errorf("create workspace: %w", err))
+		m.failJob(job, withFailureKind(FailureInfra, fmt.Errorf("create workspace: %w", err)))
 		return
 	}
 
 	repoPath := filepath.Join(job.Workspace, "repo")
 	ctx, cancel := context.WithTimeout(m.ctx, m.cfg.BuildTimeout)
 	defer cancel()
+	job.setCancel(cancel)
+	if job.trusted {
+		ctx = withGitCredentials(ctx)
+	}
+	if m.cfg.WorkspaceRetention == 0 {
+		defer m.pruneWorkspace(job)
+	}
 
 	onLog := func(line string) {
 		job.appendLog(m.cfg.MaxLogLines, line)
 	}
 
-	if err := cloneRepository(ctx, job.RepoURL, job.Ref, repoPath, onLog); err != nil {
+	cloneLog := progressLogger(job, "clone", onLog)
+	err := m.cloneRepositoryWithMirror(ctx, job.RepoURL, job.Ref, repoPath, cloneLog)
+	if err != nil {
+		err = stepFailure(ctx, FailureClone, err)
+	} else if err = applySubmoduleOverrides(ctx, repoPath, job.Submodules, cloneLog); err != nil {
+		err = stepFailure(ctx, FailureSubmodule, err)
+	}
+	job.finishProgress("clone")
+	if err != nil {
 		m.failJob(job, err)
 		return
 	}
 
 	commitHash, err := resolveRepositoryCommit(ctx, repoPath)
 	if err != nil {
-		m.failJob(job, err)
+		m.failJob(job, stepFailure(ctx, FailureClone, err))
 		return
 	}
+	job.setCommit(commitHash)
+	if m.cfg.TagSignatureMode != "" && job.Ref != "" {
+		if err := m.verifyJobTag(ctx, job, repoPath, commitHash); err != nil {
+			m.failJob(job, err)
+			return
+		}
+	}
+	if job.Patch != "" {
+		if err := applyPatch(ctx, repoPath, job.Patch, onLog); err != nil {
+			m.failJob(job, err)
+			return
+		}
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("applied patch %s on top of commit %s", patchDigest(job.Patch)[:12], shortCommit(commitHash)))
+	}
 	firmwareVersion, err := resolveRepositoryVersion(ctx, repoPath)
 	if err != nil {
 		firmwareVersion = shortCommit(commitHash)
@@ -245,10 +574,26 @@ func (m *Manager) executeJob(job *Job) {
 		m.failJob(job, fmt.Errorf("invalid environment name %q for device %q", project.EnvName, job.Device))
 		return
 	}
+	if !m.deviceAllowed(job.RepoURL, project.EnvName) {
+		m.failJob(job, fmt.Errorf("%w: %s", ErrDeviceNotAllowed, project.EnvName))
+		return
+	}
+
+	platform, _ := detectDevicePlatform(project.EnvSettings[project.EnvName], project.RelativePath)
+	job.setBuildFacts(platform, false)
 
 	buildEnvName := project.EnvName
 	projectConfigPath := ""
-	buildOptions := BuildOptions{BuildFlags: job.BuildFlags, LibDeps: job.LibDeps}
+	buildOptions := BuildOptions{BuildFlags: job.BuildFlags, LibDeps: job.LibDeps, ArtifactPatterns: job.ArtifactPatterns, Patch: job.Patch, Submodules: job.Submodules}
+
+	nameValues := artifactNameValues{
+		Device:    job.Device,
+		Ref:       job.Ref,
+		Commit:    commitHash,
+		Version:   firmwareVersion,
+		BuildType: artifactBuildType(buildOptions),
+		JobID:     job.ID,
+	}
 
 	cacheKey, err := buildFirmwareCacheKey(job.RepoURL, commitHash, project.EnvName, buildOptions)
 	if err != nil {
@@ -261,8 +606,14 @@ func (m *Manager) executeJob(job *Job) {
 		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("cache read failed for %s: %v", shortCommit(commitHash), cacheErr))
 	} else if cacheHit {
 		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("cache hit for commit %s, reusing %d artifacts", shortCommit(commitHash), len(cachedArtifacts)))
+		job.setSizeReport(loadFirmwareCacheSizeReport(m.cfg.FirmwareCachePath, cacheKey))
+		job.setBuildFacts(platform, true)
+		applyArtifactNameTemplate(cachedArtifacts, m.cfg.ArtifactNameTemplate, nameValues)
+		cachedArtifacts = m.addFlashScripts(job, cachedArtifacts)
+		m.externalizeArtifacts(ctx, job, cachedArtifacts)
 		job.markSuccess(m.now(), cachedArtifacts)
 		m.saveBuildLog(job)
+		m.recordBuildOutcome(job, "")
 		return
 	} else {
 		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("cache miss for commit %s, running build", shortCommit(commitHash)))
@@ -277,9 +628,15 @@ func (m *Manager) executeJob(job *Job) {
 		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("applied custom build options: build_flags=%d, lib_deps=%d", len(buildOptions.BuildFlags), len(buildOptions.LibDeps)))
 	}
 
-	if err := runBuildInContainer(ctx, m.cfg, repoPath, buildEnvName, projectConfigPath, onLog); err != nil {
+	sizeParser := &sizeReportParser{}
+	onBuildLog := func(line string) {
+		onLog(line)
+		sizeParser.observe(line)
+	}
+
+	if err := runBuildInContainer(ctx, m.cfg, repoPath, buildEnvName, projectConfigPath, onBuildLog); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			m.failJob(job, fmt.Errorf("build timeout reached after %s", m.cfg.BuildTimeout))
+			m.failJob(job, withFailureKind(FailureTimeout, fmt.Errorf("build timeout reached after %s", m.cfg.BuildTimeout)))
 			return
 		}
 		if errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled) {
@@ -287,55 +644,133 @@ func (m *Manager) executeJob(job *Job) {
 			m.saveBuildLog(job)
 			return
 		}
-		m.failJob(job, err)
+		m.failJob(job, withFailureKind(classifyBuildFailure(err, job.getLogs()), err))
 		return
 	}
 
-	artifacts, err := collectArtifacts(repoPath, buildEnvName)
+	sizeReport := sizeParser.result()
+	job.setSizeReport(sizeReport)
+
+	m.packageBuildOutputs(job, filepath.Join(repoPath, ".pio", "build", buildEnvName), firmwareVersion)
+
+	artifacts, err := collectArtifacts(repoPath, buildEnvName, m.artifactFilter(job))
 	if err != nil {
 		m.failJob(job, err)
 		return
 	}
 
 	if err := storeArtifactsInFirmwareCache(m.cfg.FirmwareCachePath, cacheKey, artifacts, FirmwareCacheMeta{
-		RepoURL: job.RepoURL,
-		Ref:     job.Ref,
-		Device:  job.Device,
+		RepoURL:     RedactSecrets(job.RepoURL),
+		Ref:         job.Ref,
+		Device:      job.Device,
+		Compression: m.cfg.FirmwareCacheCompression,
+		Size:        sizeReport,
 	}); err != nil {
 		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("cache write failed for %s: %v", shortCommit(commitHash), err))
 	} else {
 		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("stored build artifacts in cache for commit %s", shortCommit(commitHash)))
 	}
 
+	// Names are applied after the cache write so cached entries keep the
+	// PlatformIO names and can be renamed per job on reuse.
+	applyArtifactNameTemplate(artifacts, m.cfg.ArtifactNameTemplate, nameValues)
+	artifacts = m.addFlashScripts(job, artifacts)
+
+	if err := preserveArtifacts(job.Workspace, artifacts); err != nil {
+		m.failJob(job, withFailureKind(FailureInfra, err))
+		return
+	}
+	m.externalizeArtifacts(ctx, job, artifacts)
+
 	job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("build completed, artifacts: %d", len(artifacts)))
 	job.markSuccess(m.now(), artifacts)
 	m.saveBuildLog(job)
+	m.recordBuildOutcome(job, "")
+}
+
+func (m *Manager) artifactFilter(job *Job) artifactFilter {
+	extensions := append([]string(nil), m.cfg.ArtifactExtensions...)
+	if len(extensions) == 0 {
+		extensions = append(extensions, firmwareExtensions...)
+	}
+	if m.cfg.ArtifactIncludeMap {
+		extensions = append(extensions, ".map")
+	}
+	return artifactFilter{extensions: extensions, patterns: job.ArtifactPatterns}
+}
+
+// addFlashScripts appends flash.sh and flash.bat for the final artifact
+// names. They are written to the preserved artifacts directory rather than
+// the firmware cache because they depend on per-job names.
+func (m *Manager) addFlashScripts(job *Job, artifacts []Artifact) []Artifact {
+	scripts, err := writeFlashScripts(filepath.Join(job.Workspace, preservedArtifactsDirName), job.Device, artifacts)
+	if err != nil {
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("flash script generation failed: %v", err))
+		return artifacts
+	}
+	if len(scripts) > 0 {
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("added %s and %s", flashScriptShell, flashScriptBatch))
+	}
+	return append(artifacts, scripts...)
+}
+
+// packageBuildOutputs derives additional flashable files from the raw
+// PlatformIO output. Failures are logged and never fail the build.
+func (m *Manager) packageBuildOutputs(job *Job, buildRoot string, firmwareVersion string) {
+	if name, err := writeOTAPackage(buildRoot, job.Device, firmwareVersion); err != nil {
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("OTA package skipped: %v", err))
+	} else if name != "" {
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("created OTA package %s", name))
+	}
+
+	if merged, err := writeMergedESP32Image(buildRoot); err != nil {
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("merged image skipped: %v", err))
+	} else if merged {
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("created %s for flashing at offset 0x0", MergedImageName))
+	}
 }
 
 func (m *Manager) failJob(job *Job, err error) {
+	if job.cancelWasRequested() {
+		job.markCancelled(m.now(), "build cancelled")
+		m.saveBuildLog(job)
+		return
+	}
+	kind := failureKindOf(err)
 	job.appendLog(m.cfg.MaxLogLines, "ERROR: "+err.Error())
-	job.markFailed(m.now(), err.Error())
+	job.markFailed(m.now(), err.Error(), kind)
 	m.saveBuildLog(job)
+	// Infrastructure failures are not the client's doing.
+	if kind != FailureInfra {
+		m.recordBuildOutcome(job, err.Error())
+	}
 }
 
 func (m *Manager) saveBuildLog(job *Job) {
 	state := job.snapshot()
+	platform, cacheHit := job.buildFacts()
 	bl := buildlogs.BuildLog{
-		JobID:      state.ID,
-		RepoURL:    state.RepoURL,
-		Ref:        state.Ref,
-		Device:     state.Device,
-		ClientIP:   state.ClientIP,
-		Status:     string(state.Status),
-		CreatedAt:  state.CreatedAt,
-		StartedAt:  state.StartedAt,
-		FinishedAt: state.FinishedAt,
-		Error:      state.Error,
-		Lines:      job.getLogs(),
+		JobID:       state.ID,
+		RepoURL:     state.RepoURL,
+		Ref:         state.Ref,
+		Device:      state.Device,
+		ClientIP:    state.ClientIP,
+		UserID:      state.UserID,
+		Status:      string(state.Status),
+		CreatedAt:   state.CreatedAt,
+		StartedAt:   state.StartedAt,
+		FinishedAt:  state.FinishedAt,
+		Error:       state.Error,
+		FailureKind: string(state.FailureKind),
+		Platform:    platform,
+		CacheHit:    cacheHit,
+		Lines:       job.getLogs(),
 	}
 	if err := m.buildLogs.Save(bl); err != nil {
-		m.logger.Printf("save build log %s: %v", state.ID, err)
+		m.logger.Error("save build log", "jobId", state.ID, "error", err)
 	}
+	job.closeLogFile()
+	m.publishJob(job)
 }
 
 func (m *Manager) cleanupLoop() {
@@ -356,11 +791,15 @@ func (m *Manager) cleanupLoop() {
 func (m *Manager) cleanupExpiredJobs() {
 	now := m.now()
 	removePaths := make([]string, 0)
+	pruneJobs := make([]*Job, 0)
 	removed := 0
 
 	m.mu.Lock()
 	for jobID, job := range m.jobs {
 		if !job.isExpired(now, m.cfg.Retention) {
+			if m.cfg.WorkspaceRetention > 0 && job.workspaceExpired(now, m.cfg.WorkspaceRetention) {
+				pruneJobs = append(pruneJobs, job)
+			}
 			continue
 		}
 		delete(m.jobs, jobID)
@@ -372,12 +811,19 @@ func (m *Manager) cleanupExpiredJobs() {
 
 	for _, path := range removePaths {
 		if err := os.RemoveAll(path); err != nil {
-			m.logger.Printf("cleanup workspace %s: %v", path, err)
+			m.logger.Warn("cleanup workspace", "path", path, "error", err)
 		}
 	}
+	for _, job := range pruneJobs {
+		m.pruneWorkspace(job)
+	}
 
 	if removed > 0 {
-		m.logger.Printf("cleanup removed %d expired jobs", removed)
+		m.logger.Info("cleanup removed expired jobs", "count", removed)
+	}
+
+	if mirrors := m.mirrors.removeUnused(m.cfg.Retention); mirrors > 0 {
+		m.logger.Info("cleanup removed unused git mirrors", "count", mirrors)
 	}
 }
 
@@ -463,6 +909,28 @@ func (m *Manager) attachQueueMetadata(jobID string, state *State) {
 	}
 }
 
+// QueueLoad returns the number of queued jobs and the number of builds
+// that run concurrently.
+func (m *Manager) QueueLoad() (queued int, workers int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.queueOrder), max(m.cfg.ConcurrentBuilds, 1)
+}
+
+// enqueue hands job to the workers.
+func (m *Manager) enqueue(job *Job) error {
+	m.mu.Lock()
+	m.queueOrder = append(m.queueOrder, job.ID)
+	m.mu.Unlock()
+
+	select {
+	case m.queue <- job:
+		return nil
+	case <-m.ctx.Done():
+		return errors.New("service is shutting down")
+	}
+}
+
 func (m *Manager) removeQueuedJob(jobID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
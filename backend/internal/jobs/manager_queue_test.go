@@ -1,8 +1,7 @@
 package jobs
 
 import (
-	"io"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"testing"
 	"time"
@@ -19,7 +18,7 @@ func TestQueuePositionForQueuedJobs(t *testing.T) {
 		JobsRootPath:     filepath.Join(workDir, "jobs"),
 		MaxLogLines:      200,
 		CleanupInterval:  time.Hour,
-	}, log.New(io.Discard, "", 0))
+	}, slog.New(slog.DiscardHandler))
 	defer mgr.Close()
 
 	first, err := mgr.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "")
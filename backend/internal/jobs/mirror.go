@@ -0,0 +1,314 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mirrorCache maintains bare mirror clones of repositories that are cloned
+// repeatedly. Jobs clone from the local mirror instead of the network, and
+// mirrors are refreshed lazily once they are older than refreshAfter.
+// Mirrors are created and refreshed in the background, on ctx and tracked by
+// wg, so no job waits for a full-history clone or a fetch.
+type mirrorCache struct {
+	root         string
+	minUses      int
+	refreshAfter time.Duration
+	logger       *slog.Logger
+	now          func() time.Time
+	ctx          context.Context
+	wg           *sync.WaitGroup
+
+	mu      sync.Mutex
+	mirrors map[string]*mirrorState
+}
+
+type mirrorState struct {
+	mu        sync.Mutex
+	path      string
+	uses      int
+	fetchedAt time.Time
+	lastUsed  time.Time
+	// updating is set while a background clone or fetch owns the mirror.
+	updating bool
+}
+
+func newMirrorCache(ctx context.Context, wg *sync.WaitGroup, root string, minUses int, refreshAfter time.Duration, logger *slog.Logger, now func() time.Time) *mirrorCache {
+	if strings.TrimSpace(root) == "" {
+		return nil
+	}
+	if minUses < 1 {
+		minUses = 1
+	}
+	return &mirrorCache{
+		root:         root,
+		minUses:      minUses,
+		refreshAfter: refreshAfter,
+		logger:       logger,
+		now:          now,
+		ctx:          ctx,
+		wg:           wg,
+		mirrors:      make(map[string]*mirrorState),
+	}
+}
+
+// acquire records a use of repoURL and returns the path of an up-to-date
+// mirror, or "" when the caller should clone directly: the repository is
+// not mirrored yet, or its mirror is being created or refreshed. Reaching
+// the use threshold or the refresh age starts that work in the background.
+func (c *mirrorCache) acquire(ctx context.Context, repoURL string) string {
+	if c == nil {
+		return ""
+	}
+
+	state := c.state(mirrorKey(ctx, repoURL))
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	now := c.now()
+	state.uses++
+	state.lastUsed = now
+	if state.updating {
+		return ""
+	}
+
+	if !isBareRepository(state.path) {
+		if state.uses >= c.minUses {
+			c.update(ctx, repoURL, state, true)
+		}
+		return ""
+	}
+	if state.fetchedAt.IsZero() || now.Sub(state.fetchedAt) >= c.refreshAfter {
+		c.update(ctx, repoURL, state, false)
+		return ""
+	}
+	return state.path
+}
+
+// requestRefresh schedules a fetch of an existing mirror, used when a
+// requested ref is missing from a mirror that has not reached its refresh
+// age yet. The caller clones directly in the meantime.
+func (c *mirrorCache) requestRefresh(ctx context.Context, repoURL string) {
+	if c == nil {
+		return
+	}
+
+	state := c.state(mirrorKey(ctx, repoURL))
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.updating && isBareRepository(state.path) {
+		c.update(ctx, repoURL, state, false)
+	}
+}
+
+// update clones (create) or fetches the mirror of state in the background.
+// It runs on the cache context rather than the job's, so a cancelled job
+// does not abort it; only the job's permission to use the stored git
+// credentials carries over. state.mu must be held.
+func (c *mirrorCache) update(ctx context.Context, repoURL string, state *mirrorState, create bool) {
+	if c.ctx.Err() != nil {
+		return
+	}
+
+	updateCtx := c.ctx
+	if gitCredentialsAllowed(ctx) {
+		updateCtx = withGitCredentials(updateCtx)
+	}
+	state.updating = true
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		var err error
+		if create {
+			err = c.create(updateCtx, repoURL, state.path)
+		} else {
+			err = c.refresh(updateCtx, state.path)
+		}
+
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		state.updating = false
+		if err != nil {
+			c.logger.Warn("git mirror: update", "repo", repoURL, "create", create, "error", err)
+			return
+		}
+		state.fetchedAt = c.now()
+	}()
+}
+
+// invalidate deletes the mirrors of repoURL so the next use recreates them.
+// A mirror fetch does not move HEAD, so this is how a changed default
+// branch reaches clones of an empty ref.
+func (c *mirrorCache) invalidate(repoURL string) {
+	if c == nil {
+		return
+	}
+
+	for _, key := range []string{mirrorKey(context.Background(), repoURL), mirrorKey(withGitCredentials(context.Background()), repoURL)} {
+		state := c.state(key)
+		state.mu.Lock()
+		if err := os.RemoveAll(state.path); err != nil {
+			c.logger.Warn("git mirror: remove", "repo", repoURL, "error", err)
+		}
+		state.fetchedAt = time.Time{}
+		state.mu.Unlock()
+	}
+}
+
+// mirrorKey keeps the mirrors fetched with the stored git credentials apart
+// from the anonymous ones, so that jobs without credentials never clone
+// from a mirror of a private repository.
+func mirrorKey(ctx context.Context, repoURL string) string {
+	key := strings.TrimSpace(repoURL)
+	if gitCredentialsAllowed(ctx) {
+		key = "credentials " + key
+	}
+	return key
+}
+
+func (c *mirrorCache) state(key string) *mirrorState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.mirrors[key]
+	if !ok {
+		state = &mirrorState{path: filepath.Join(c.root, mirrorDirName(key))}
+		c.mirrors[key] = state
+	}
+	return state
+}
+
+func (c *mirrorCache) create(ctx context.Context, repoURL string, path string) error {
+	if err := os.MkdirAll(c.root, 0o755); err != nil {
+		return fmt.Errorf("create mirror root: %w", err)
+	}
+
+	tempPath, err := os.MkdirTemp(c.root, "mirror-*")
+	if err != nil {
+		return fmt.Errorf("create temporary mirror directory: %w", err)
+	}
+	defer os.RemoveAll(tempPath)
+
+	if err := runGit(ctx, nil, "clone", "--mirror", "--quiet", repoURL, tempPath); err != nil {
+		return fmt.Errorf("clone mirror: %w", err)
+	}
+	_ = os.RemoveAll(path)
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("activate mirror: %w", err)
+	}
+	return nil
+}
+
+func (c *mirrorCache) refresh(ctx context.Context, path string) error {
+	if err := runGit(ctx, nil, "-C", path, "fetch", "--prune", "--quiet", "origin"); err != nil {
+		return fmt.Errorf("update mirror: %w", err)
+	}
+	return nil
+}
+
+// removeUnused deletes mirrors that have not been used within maxIdle.
+func (c *mirrorCache) removeUnused(maxIdle time.Duration) int {
+	if c == nil {
+		return 0
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	stale := make(map[string]*mirrorState)
+	for key, state := range c.mirrors {
+		if state.mu.TryLock() {
+			if !state.updating && now.Sub(state.lastUsed) >= maxIdle {
+				stale[key] = state
+				delete(c.mirrors, key)
+			}
+			state.mu.Unlock()
+		}
+	}
+	c.mu.Unlock()
+
+	removed := 0
+	for key, state := range stale {
+		if err := os.RemoveAll(state.path); err != nil {
+			c.logger.Warn("git mirror: remove", "repo", key, "error", err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+func mirrorDirName(repoURL string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(repoURL)))
+	return hex.EncodeToString(sum[:])[:24] + ".git"
+}
+
+func isBareRepository(path string) bool {
+	info, err := os.Stat(filepath.Join(path, "HEAD"))
+	return err == nil && !info.IsDir()
+}
+
+// cloneStrategy is the configured history limit for build clones.
+func (m *Manager) cloneStrategy() cloneStrategy {
+	return cloneStrategy{mode: m.cfg.CloneStrategy, since: m.cfg.CloneShallowSince}
+}
+
+// cloneRepositoryWithMirror clones repoURL using a local mirror when one is
+// available, falling back to a direct network clone otherwise.
+func (m *Manager) cloneRepositoryWithMirror(ctx context.Context, repoURL string, ref string, destination string, onLine func(string)) error {
+	mirrorPath := m.mirrors.acquire(ctx, repoURL)
+	if mirrorPath == "" {
+		return cloneRepository(ctx, repoURL, ref, destination, m.cloneStrategy(), onLine)
+	}
+
+	source := "file://" + filepath.ToSlash(mirrorPath)
+	err := cloneRepositoryFrom(ctx, source, repoURL, ref, destination, m.cloneStrategy(), onLine)
+	if err == nil {
+		return nil
+	}
+
+	if onLine != nil {
+		onLine("clone from local mirror failed, cloning from the network")
+	}
+	_ = os.RemoveAll(destination)
+	m.mirrors.requestRefresh(ctx, repoURL)
+	return cloneRepository(ctx, repoURL, ref, destination, m.cloneStrategy(), onLine)
+}
+
+// discoverySparsePaths are the directories discovery reads from a checkout.
+var discoverySparsePaths = []string{"variants"}
+
+// cloneForDiscovery makes a sparse, submodule-free checkout of the files
+// device discovery needs, falling back to a regular clone when the server
+// or local git does not support partial clones.
+func (m *Manager) cloneForDiscovery(ctx context.Context, repoURL string, ref string, destination string, onLine func(string)) error {
+	source := repoURL
+	if mirrorPath := m.mirrors.acquire(ctx, repoURL); mirrorPath != "" {
+		source = "file://" + filepath.ToSlash(mirrorPath)
+	}
+
+	err := sparseCloneRepository(ctx, source, ref, destination, discoverySparsePaths, onLine)
+	if err == nil {
+		// Shared base sections (e.g. arch/esp32/esp32.ini) are pulled in
+		// through extra_configs of the root platformio.ini.
+		if directories := extraConfigDirectories(destination); len(directories) > 0 {
+			addArgs := append([]string{"-C", destination, "sparse-checkout", "add"}, directories...)
+			if addErr := runGit(ctx, onLine, addArgs...); addErr != nil {
+				m.logger.Warn("discovery: add extra_configs directories to sparse checkout", "repo", repoURL, "error", addErr)
+			}
+		}
+		return nil
+	}
+	m.logger.Warn("discovery: sparse clone failed, using full clone", "repo", repoURL, "error", err)
+	_ = os.RemoveAll(destination)
+	return m.cloneRepositoryWithMirror(ctx, repoURL, ref, destination, onLine)
+}
@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func initTestRepository(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	commands := [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "initial"},
+	}
+	for _, args := range commands {
+		cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %s unavailable: %v (%s)", strings.Join(args, " "), err, output)
+		}
+	}
+	return root
+}
+
+func TestCloneRepositoryWithMirror(t *testing.T) {
+	t.Parallel()
+
+	upstream := initTestRepository(t, map[string]string{"README.md": "hello\n"})
+	repoURL := "file://" + filepath.ToSlash(upstream)
+	workDir := t.TempDir()
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	mgr := &Manager{
+		logger: slog.New(slog.DiscardHandler),
+		now:    func() time.Time { return now },
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	mgr.mirrors = newMirrorCache(ctx, &mgr.wg, filepath.Join(workDir, "mirrors"), 2, time.Hour, mgr.logger, mgr.now)
+
+	if err := mgr.cloneRepositoryWithMirror(ctx, repoURL, "", filepath.Join(workDir, "first"), nil); err != nil {
+		t.Fatalf("first clone: %v", err)
+	}
+	mirrorPath := filepath.Join(workDir, "mirrors", mirrorDirName(repoURL))
+	if isBareRepository(mirrorPath) {
+		t.Fatalf("mirror must not be created before reaching the minimum use count")
+	}
+
+	// Reaching the threshold starts the mirror in the background; the job
+	// that triggered it clones directly.
+	if err := mgr.cloneRepositoryWithMirror(ctx, repoURL, "main", filepath.Join(workDir, "second"), nil); err != nil {
+		t.Fatalf("second clone: %v", err)
+	}
+	mgr.wg.Wait()
+	if !isBareRepository(mirrorPath) {
+		t.Fatalf("expected mirror at %s after repeated use", mirrorPath)
+	}
+	if got := mgr.mirrors.acquire(ctx, repoURL); got != mirrorPath {
+		t.Fatalf("expected the fresh mirror to be used, got %q", got)
+	}
+
+	thirdPath := filepath.Join(workDir, "third")
+	if err := mgr.cloneRepositoryWithMirror(ctx, repoURL, "main", thirdPath, nil); err != nil {
+		t.Fatalf("third clone: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(thirdPath, "README.md")); err != nil {
+		t.Fatalf("cloned working tree is incomplete: %v", err)
+	}
+
+	origin, err := runGitCapture(ctx, "-C", thirdPath, "remote", "get-url", "origin")
+	if err != nil {
+		t.Fatalf("read origin: %v", err)
+	}
+	if strings.TrimSpace(origin) != repoURL {
+		t.Fatalf("origin must point at upstream: got=%q want=%q", strings.TrimSpace(origin), repoURL)
+	}
+
+	// A stale mirror is refreshed in the background and skipped meanwhile.
+	now = now.Add(2 * time.Hour)
+	if got := mgr.mirrors.acquire(ctx, repoURL); got != "" {
+		t.Fatalf("a mirror being refreshed must not be used, got %q", got)
+	}
+	mgr.wg.Wait()
+	if got := mgr.mirrors.acquire(ctx, repoURL); got != mirrorPath {
+		t.Fatalf("expected the refreshed mirror to be used, got %q", got)
+	}
+
+	now = now.Add(48 * time.Hour)
+	if removed := mgr.mirrors.removeUnused(24 * time.Hour); removed != 1 {
+		t.Fatalf("expected one unused mirror to be removed, got %d", removed)
+	}
+	if isBareRepository(mirrorPath) {
+		t.Fatalf("unused mirror should be deleted")
+	}
+}
+
+func TestSparseCloneRepository(t *testing.T) {
+	t.Parallel()
+
+	upstream := initTestRepository(t, map[string]string{
+		"platformio.ini":                  "[platformio]\n",
+		"src/main.cpp":                    "int main() {}\n",
+		"variants/tbeam/platformio.ini":   "[env:tbeam]\n",
+		"variants/rak4631/platformio.ini": "[env:rak4631]\n",
+	})
+	destination := filepath.Join(t.TempDir(), "repo")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := sparseCloneRepository(ctx, "file://"+filepath.ToSlash(upstream), "main", destination, discoverySparsePaths, nil); err != nil {
+		t.Fatalf("sparse clone: %v", err)
+	}
+
+	for _, name := range []string{"platformio.ini", "variants/tbeam/platformio.ini", "variants/rak4631/platformio.ini"} {
+		if _, err := os.Stat(filepath.Join(destination, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("expected %s in sparse checkout: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(destination, "src", "main.cpp")); !os.IsNotExist(err) {
+		t.Fatalf("src/ must not be checked out, stat err=%v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import "fmt"
+
+// needsApproval reports whether an anonymous build of repoURL with options
+// has to wait for an operator. Featured repositories and
+// APP_MODERATION_ALLOWED_REPOS are trusted, but a patch or a submodule
+// fetched from elsewhere runs code of the client's choosing, so such builds
+// wait unless every submodule URL is trusted too and there is no patch.
+func (m *Manager) needsApproval(repoURL string, options BuildOptions) bool {
+	if !m.cfg.ModerateAnonymousBuilds {
+		return false
+	}
+	if options.Patch != "" || !m.moderationExempt(repoURL) {
+		return true
+	}
+	for _, override := range options.Submodules {
+		if override.URL != "" && !m.moderationExempt(override.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) moderationExempt(repoURL string) bool {
+	key := repoKey(repoURL)
+	for _, featured := range m.cfg.FeaturedRepos {
+		if repoKey(featured) == key {
+			return true
+		}
+	}
+	return len(m.cfg.ModerationAllowedRepos) > 0 && repoHostAllowed(m.cfg.ModerationAllowedRepos, repoURL)
+}
+
+// PendingJobs lists the jobs waiting for approval, oldest first.
+func (m *Manager) PendingJobs() []State {
+	pending := make([]State, 0)
+	for _, state := range m.ListJobs() {
+		if state.Status == StatusPending {
+			pending = append(pending, state)
+		}
+	}
+	return pending
+}
+
+// ApproveJob queues a pending job. Jobs that are not pending return
+// ErrJobNotPending.
+func (m *Manager) ApproveJob(jobID string) (State, error) {
+	job, err := m.getJob(jobID)
+	if err != nil {
+		return State{}, err
+	}
+	if !job.markApproved() {
+		return State{}, fmt.Errorf("%w: %s", ErrJobNotPending, job.snapshot().Status)
+	}
+	job.appendLog(m.cfg.MaxLogLines, "build approved by an operator")
+	m.publishJob(job)
+	if err := m.enqueue(job); err != nil {
+		return State{}, err
+	}
+	m.logger.Info("job approved", "jobId", jobID)
+
+	state := job.snapshot()
+	m.attachQueueMetadata(jobID, &state)
+	return state, nil
+}
+
+// RejectJob cancels a pending job. Jobs that are not pending return
+// ErrJobNotPending.
+func (m *Manager) RejectJob(jobID string) (State, error) {
+	job, err := m.getJob(jobID)
+	if err != nil {
+		return State{}, err
+	}
+	if !job.markRejected(m.now(), "build rejected by an operator") {
+		return State{}, fmt.Errorf("%w: %s", ErrJobNotPending, job.snapshot().Status)
+	}
+	m.saveBuildLog(job)
+	m.logger.Info("job rejected", "jobId", jobID)
+	return job.snapshot(), nil
+}
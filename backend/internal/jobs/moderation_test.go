@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestModerationHoldsAnonymousBuilds(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	mgr := NewManager(config.Config{
+		JobsRootPath:            filepath.Join(workDir, "jobs"),
+		BuildLogsPath:           filepath.Join(workDir, "build-logs"),
+		MaxLogLines:             200,
+		CleanupInterval:         time.Hour,
+		FeaturedRepos:           []string{"https://github.com/meshtastic/firmware"},
+		ModerateAnonymousBuilds: true,
+		ModerationAllowedRepos:  []string{"gitlab.com/trusted"},
+	}, slog.New(slog.DiscardHandler))
+	t.Cleanup(mgr.Close)
+
+	anonymous := JobOwner{ClientIP: "203.0.113.9", Anonymous: true}
+	for _, repoURL := range []string{"https://github.com/meshtastic/firmware.git", "https://gitlab.com/trusted/fork"} {
+		state, err := mgr.CreateOwnedJob(repoURL, "main", "tbeam", BuildOptions{}, anonymous)
+		if err != nil || state.Status != StatusQueued {
+			t.Fatalf("expected %s to be queued without approval, got %s %v", repoURL, state.Status, err)
+		}
+	}
+	signedIn, err := mgr.CreateOwnedJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, JobOwner{UserID: "alice"})
+	if err != nil || signedIn.Status != StatusQueued {
+		t.Fatalf("expected signed-in builds to be queued, got %s %v", signedIn.Status, err)
+	}
+
+	first, err := mgr.CreateOwnedJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, anonymous)
+	if err != nil || first.Status != StatusPending || first.QueuePosition != nil {
+		t.Fatalf("expected a pending job, got %+v %v", first, err)
+	}
+	second, err := mgr.CreateOwnedJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, anonymous)
+	if err != nil {
+		t.Fatalf("create second job: %v", err)
+	}
+	if pending := mgr.PendingJobs(); len(pending) != 2 || pending[0].ID != first.ID {
+		t.Fatalf("unexpected pending jobs: %+v", pending)
+	}
+	if drain := mgr.DrainStatus(); drain.Pending != 2 || drain.Queued != 3 {
+		t.Fatalf("unexpected drain status: %+v", drain)
+	}
+
+	approved, err := mgr.ApproveJob(first.ID)
+	if err != nil || approved.Status != StatusQueued || approved.QueuePosition == nil || *approved.QueuePosition != 4 {
+		t.Fatalf("expected the approved job at the end of the queue, got %+v %v", approved, err)
+	}
+	if _, err := mgr.ApproveJob(first.ID); !errors.Is(err, ErrJobNotPending) {
+		t.Fatalf("expected ErrJobNotPending for an approved job, got %v", err)
+	}
+	if _, err := mgr.RejectJob(first.ID); !errors.Is(err, ErrJobNotPending) {
+		t.Fatalf("expected ErrJobNotPending when rejecting a queued job, got %v", err)
+	}
+
+	rejected, err := mgr.RejectJob(second.ID)
+	if err != nil || rejected.Status != StatusCancelled || rejected.Error != "build rejected by an operator" {
+		t.Fatalf("unexpected rejected job: %+v %v", rejected, err)
+	}
+	if _, err := mgr.BuildLogs().Get(second.ID); err != nil {
+		t.Fatalf("expected the rejected build log to be saved: %v", err)
+	}
+	if _, err := mgr.ApproveJob("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestNeedsApproval(t *testing.T) {
+	t.Parallel()
+
+	mgr := &Manager{cfg: config.Config{ModerationAllowedRepos: []string{"github.com"}}}
+	if mgr.needsApproval("https://github.com/example/repo.git", BuildOptions{}) || mgr.needsApproval("https://example.com/repo.git", BuildOptions{}) {
+		t.Fatalf("builds must not need approval while moderation is off")
+	}
+	mgr.cfg.ModerateAnonymousBuilds = true
+	if mgr.needsApproval("https://github.com/example/repo.git", BuildOptions{}) || !mgr.needsApproval("https://example.com/repo.git", BuildOptions{}) {
+		t.Fatalf("expected only repositories outside the list to need approval")
+	}
+
+	cases := []struct {
+		name    string
+		options BuildOptions
+		want    bool
+	}{
+		{"patch", BuildOptions{Patch: "diff --git a/x b/x\n"}, true},
+		{"pinned submodule", BuildOptions{Submodules: []SubmoduleOverride{{Path: "lib/x", Commit: "0123456789abcdef0123456789abcdef01234567"}}}, false},
+		{"trusted submodule URL", BuildOptions{Submodules: []SubmoduleOverride{{Path: "lib/x", URL: "https://github.com/example/x.git"}}}, false},
+		{"foreign submodule URL", BuildOptions{Submodules: []SubmoduleOverride{{Path: "lib/x", URL: "https://example.com/x.git"}}}, true},
+	}
+	for _, tc := range cases {
+		if got := mgr.needsApproval("https://github.com/example/repo.git", tc.options); got != tc.want {
+			t.Fatalf("%s: needsApproval = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/notify"
+)
+
+func notifyOptions(cfg config.Config) notify.Options {
+	return notify.Options{
+		On:                cfg.NotifyOn,
+		PerJob:            cfg.NotifyPerJob,
+		SlackWebhookURL:   cfg.NotifySlackWebhookURL,
+		DiscordWebhookURL: cfg.NotifyDiscordWebhookURL,
+		TelegramBotToken:  cfg.NotifyTelegramBotToken,
+		TelegramChatID:    cfg.NotifyTelegramChatID,
+		SMTPAddr:          cfg.NotifySMTPAddr,
+		SMTPUsername:      cfg.NotifySMTPUsername,
+		SMTPPassword:      cfg.NotifySMTPPassword,
+		SMTPFrom:          cfg.NotifySMTPFrom,
+		EmailTo:           cfg.NotifyEmailTo,
+	}
+}
+
+// notifyJob sends the outcome of a finished job to the deployment channels
+// and to the job's own recipients. Other transitions are not notified.
+func (m *Manager) notifyJob(job *Job) {
+	if m.notifier == nil {
+		return
+	}
+	state := job.snapshot()
+	var outcome string
+	switch state.Status {
+	case StatusSuccess:
+		outcome = notify.OnSuccess
+	case StatusFailed:
+		outcome = notify.OnFailure
+	case StatusCancelled:
+		outcome = notify.OnCancelled
+	default:
+		return
+	}
+	event := notify.Event{
+		JobID:   state.ID,
+		Node:    m.cfg.NodeName,
+		RepoURL: RedactSecrets(state.RepoURL),
+		Ref:     state.Ref,
+		Device:  state.Device,
+		Outcome: outcome,
+		Error:   state.Error,
+	}
+	if state.StartedAt != nil && state.FinishedAt != nil {
+		event.Duration = state.FinishedAt.Sub(*state.StartedAt)
+	}
+	m.notifier.Notify(event, job.notify)
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// otaPackageSuffix marks nRF52 DFU packages that are collected as artifacts
+// alongside the regular firmware files.
+const otaPackageSuffix = "-ota.zip"
+
+// writeOTAPackage copies the platform's OTA payload into the build
+// directory under the file name the Meshtastic flasher and apps expect:
+//
+//	ESP32: firmware-<device>-<version>.bin (app image only)
+//	nRF52: firmware-<device>-<version>-ota.zip (Adafruit DFU package)
+//
+// It returns the created file name, or "" when the build has no OTA payload
+// or the firmware's own build scripts already produced one.
+func writeOTAPackage(buildRoot string, device string, version string) (string, error) {
+	entries, err := os.ReadDir(buildRoot)
+	if err != nil {
+		return "", fmt.Errorf("read build output directory: %w", err)
+	}
+
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		present[name] = true
+		if strings.HasSuffix(name, otaPackageSuffix) {
+			return "", nil
+		}
+		if strings.HasPrefix(name, "firmware-") && strings.HasSuffix(name, ".bin") && !strings.HasSuffix(name, ".factory.bin") {
+			return "", nil
+		}
+	}
+
+	baseName := fmt.Sprintf("firmware-%s-%s", sanitizeArtifactNamePart(device), sanitizeArtifactNamePart(version))
+
+	var source, target string
+	switch {
+	case present["bootloader.bin"] && present["partitions.bin"] && present["firmware.bin"]:
+		source, target = "firmware.bin", baseName+".bin"
+	case present["firmware.zip"]:
+		source, target = "firmware.zip", baseName+otaPackageSuffix
+	default:
+		return "", nil
+	}
+
+	if err := copyFile(filepath.Join(buildRoot, source), filepath.Join(buildRoot, target)); err != nil {
+		return "", fmt.Errorf("write OTA package: %w", err)
+	}
+	return target, nil
+}
+
+func sanitizeArtifactNamePart(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteOTAPackage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{name: "esp32", files: []string{"bootloader.bin", "partitions.bin", "firmware.bin"}, expected: "firmware-tbeam-2.5.0_dirty.bin"},
+		{name: "nrf52", files: []string{"firmware.hex", "firmware.zip"}, expected: "firmware-tbeam-2.5.0_dirty-ota.zip"},
+		{name: "rp2040", files: []string{"firmware.uf2", "firmware.elf"}, expected: ""},
+		{name: "already packaged", files: []string{"bootloader.bin", "partitions.bin", "firmware.bin", "firmware-tbeam-2.5.0.bin"}, expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buildRoot := t.TempDir()
+			for _, name := range tc.files {
+				if err := os.WriteFile(filepath.Join(buildRoot, name), []byte(name), 0o644); err != nil {
+					t.Fatalf("write %s: %v", name, err)
+				}
+			}
+
+			created, err := writeOTAPackage(buildRoot, "tbeam", "2.5.0+dirty")
+			if err != nil {
+				t.Fatalf("writeOTAPackage failed: %v", err)
+			}
+			if created != tc.expected {
+				t.Fatalf("unexpected OTA package: got=%q want=%q", created, tc.expected)
+			}
+			if created == "" {
+				return
+			}
+			if _, err := os.Stat(filepath.Join(buildRoot, created)); err != nil {
+				t.Fatalf("OTA package missing: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPatchBytes bounds the unified diff a job may apply on top of its ref.
+const maxPatchBytes = 256 << 10
+
+// normalizePatch validates a unified diff submitted with a job. Only text
+// patches are accepted; git apply itself rejects paths outside the
+// repository and inside .git.
+func normalizePatch(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+	if len(raw) > maxPatchBytes {
+		return "", fmt.Errorf("patch exceeds %d bytes", maxPatchBytes)
+	}
+	if !utf8.ValidString(raw) || strings.ContainsRune(raw, 0) {
+		return "", errors.New("patch must be a text unified diff")
+	}
+	if strings.Contains(raw, "\nGIT binary patch") {
+		return "", errors.New("patch must not contain binary diffs")
+	}
+	if !strings.HasPrefix(raw, "--- ") && !strings.Contains(raw, "\n--- ") ||
+		!strings.Contains(raw, "\n+++ ") {
+		return "", errors.New("patch must be a unified diff with ---/+++ file headers")
+	}
+	if !strings.HasSuffix(raw, "\n") {
+		raw += "\n"
+	}
+	return raw, nil
+}
+
+// patchDigest identifies a patch in job state and firmware cache keys.
+func patchDigest(patch string) string {
+	if patch == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(patch))
+	return hex.EncodeToString(sum[:])
+}
+
+// applyPatch writes patch next to the checkout and applies it with git
+// apply, which changes nothing unless every hunk applies.
+func applyPatch(ctx context.Context, repoPath string, patch string, onLine func(string)) error {
+	patchPath := filepath.Join(filepath.Dir(repoPath), "job.patch")
+	if err := os.WriteFile(patchPath, []byte(patch), 0o644); err != nil {
+		return fmt.Errorf("write patch: %w", err)
+	}
+	if err := runGit(ctx, onLine, "-C", repoPath, "apply", "--whitespace=nowarn", "--verbose", patchPath); err != nil {
+		return fmt.Errorf("apply patch: %w", err)
+	}
+	return nil
+}
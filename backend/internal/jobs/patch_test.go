@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPatch = `--- a/src/config.h
++++ b/src/config.h
+@@ -1 +1 @@
+-#define LORA_POWER 20
++#define LORA_POWER 22
+`
+
+func TestNormalizePatch(t *testing.T) {
+	t.Parallel()
+
+	if patch, err := normalizePatch("  \n"); err != nil || patch != "" {
+		t.Fatalf("expected blank patch to be ignored: patch=%q err=%v", patch, err)
+	}
+	if patch, err := normalizePatch(strings.TrimSuffix(testPatch, "\n")); err != nil || patch != testPatch {
+		t.Fatalf("unexpected normalized patch: patch=%q err=%v", patch, err)
+	}
+
+	invalid := []string{
+		"just some text",
+		"--- a/file\n+++ b/file\n\x00",
+		"diff --git a/fw.bin b/fw.bin\n--- a/fw.bin\n+++ b/fw.bin\nGIT binary patch\nliteral 1\n",
+		testPatch + strings.Repeat("+", maxPatchBytes),
+	}
+	for _, raw := range invalid {
+		if _, err := normalizePatch(raw); err == nil {
+			t.Fatalf("expected error for patch %.40q", raw)
+		}
+	}
+}
+
+func TestPatchChangesFirmwareCacheKey(t *testing.T) {
+	t.Parallel()
+
+	plain, err := buildFirmwareCacheKey("https://github.com/meshtastic/firmware", "abc123", "tbeam", BuildOptions{})
+	if err != nil {
+		t.Fatalf("build cache key: %v", err)
+	}
+	patched, err := buildFirmwareCacheKey("https://github.com/meshtastic/firmware", "abc123", "tbeam", BuildOptions{Patch: testPatch})
+	if err != nil {
+		t.Fatalf("build cache key: %v", err)
+	}
+	if plain == patched {
+		t.Fatalf("expected patch to change the firmware cache key")
+	}
+	if got := artifactBuildType(BuildOptions{Patch: testPatch}); got != "custom" {
+		t.Fatalf("unexpected build type for patched build: got=%s want=custom", got)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	t.Parallel()
+
+	source := initTestRepository(t, map[string]string{"src/config.h": "#define LORA_POWER 20\n"})
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := cloneRepository(context.Background(), source, "", repoPath, cloneStrategy{}, nil); err != nil {
+		t.Fatalf("clone repository: %v", err)
+	}
+
+	if err := applyPatch(context.Background(), repoPath, testPatch, nil); err != nil {
+		t.Fatalf("apply patch: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(repoPath, "src", "config.h"))
+	if err != nil {
+		t.Fatalf("read patched file: %v", err)
+	}
+	if string(content) != "#define LORA_POWER 22\n" {
+		t.Fatalf("unexpected patched content: got=%q", content)
+	}
+
+	if err := applyPatch(context.Background(), repoPath, testPatch, nil); err == nil {
+		t.Fatalf("expected error when the patch no longer applies")
+	}
+}
@@ -0,0 +1,405 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const maxInterpolationDepth = 16
+
+// interpolationPattern matches ${section.option}; the section name cannot
+// contain dots, so ${env.board_build.mcu} reads board_build.mcu of [env].
+var interpolationPattern = regexp.MustCompile(`\$\{([^.{}]+)\.([^{}]+)\}`)
+
+// platformIOConfig holds the sections of one or more platformio.ini files,
+// keyed by the name written in brackets ("env", "env:tbeam", "esp32_base").
+// Values of multi-line options are joined with "\n". Files parsed later
+// override earlier values, and options missing here are looked up in base.
+// Problems are reported to warnings, attributed to source, the
+// repository-relative path of the file being parsed.
+type platformIOConfig struct {
+	sections map[string]map[string]string
+	base     *platformIOConfig
+	source   string
+	warnings *discoveryWarnings
+}
+
+func newPlatformIOConfig(base *platformIOConfig) *platformIOConfig {
+	config := &platformIOConfig{sections: make(map[string]map[string]string, 16), base: base}
+	if base != nil {
+		config.warnings = base.warnings
+	}
+	return config
+}
+
+// loadProjectPlatformIOConfig parses the repository's root platformio.ini
+// and every file matched by its [platformio] extra_configs patterns, and
+// returns the envs defined in the root file itself. A repository without a
+// root platformio.ini yields an empty config.
+func loadProjectPlatformIOConfig(repoPath string, warnings *discoveryWarnings) (*platformIOConfig, []string, error) {
+	config := newPlatformIOConfig(nil)
+	config.warnings = warnings
+	content, err := os.ReadFile(filepath.Join(repoPath, "platformio.ini"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil, nil
+		}
+		return nil, nil, fmt.Errorf("read platformio.ini: %w", err)
+	}
+	config.source = "platformio.ini"
+	rootEnvNames := config.parse(string(content))
+
+	for _, pattern := range extraConfigPatterns(config) {
+		matches, err := filepath.Glob(filepath.Join(repoPath, filepath.FromSlash(pattern)))
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			relative, _ := filepath.Rel(repoPath, match)
+			config.source = filepath.ToSlash(relative)
+			extra, err := os.ReadFile(match)
+			if err != nil {
+				warnings.addf(WarningUnreadableConfig, config.source, 0, "", "extra_configs file cannot be read: %v", err)
+				continue
+			}
+			config.parse(string(extra))
+		}
+	}
+	return config, rootEnvNames, nil
+}
+
+// extraConfigPatterns returns the relative extra_configs entries; absolute
+// paths and paths leaving the repository are ignored.
+func extraConfigPatterns(config *platformIOConfig) []string {
+	raw, _ := config.get("platformio", "extra_configs")
+	patterns := make([]string, 0, 4)
+	for _, line := range strings.Split(raw, "\n") {
+		for _, item := range strings.Split(line, ",") {
+			pattern := path.Clean(filepath.ToSlash(strings.TrimSpace(item)))
+			if pattern == "." || path.IsAbs(pattern) || pattern == ".." || strings.HasPrefix(pattern, "../") {
+				continue
+			}
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// extraConfigDirectories lists the top-level directories the extra_configs
+// patterns of the root platformio.ini read from, so a sparse checkout can
+// include them.
+func extraConfigDirectories(repoPath string) []string {
+	content, err := os.ReadFile(filepath.Join(repoPath, "platformio.ini"))
+	if err != nil {
+		return nil
+	}
+	config := newPlatformIOConfig(nil)
+	config.parse(string(content))
+
+	directories := make([]string, 0, 4)
+	seen := make(map[string]bool, 4)
+	for _, pattern := range extraConfigPatterns(config) {
+		directory, _, nested := strings.Cut(pattern, "/")
+		if !nested || strings.ContainsAny(directory, "*?[") || seen[directory] {
+			continue
+		}
+		seen[directory] = true
+		directories = append(directories, directory)
+	}
+	return directories
+}
+
+// parse adds the sections of content and returns the names of the valid
+// [env:NAME] sections it defines, in order.
+func (c *platformIOConfig) parse(content string) []string {
+	envNames := make([]string, 0, 4)
+	seen := make(map[string]struct{}, 4)
+
+	currentSection := ""
+	currentOption := ""
+	for index, line := range strings.Split(content, "\n") {
+		lineNumber := index + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			currentOption = ""
+			continue
+		}
+		if strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if sectionMatch := sectionPattern.FindStringSubmatch(trimmed); len(sectionMatch) == 2 {
+			currentSection = strings.TrimSpace(sectionMatch[1])
+			currentOption = ""
+			if envMatch := envSectionPattern.FindStringSubmatch(trimmed); len(envMatch) == 2 {
+				envName := strings.TrimSpace(envMatch[1])
+				currentSection = "env:" + envName
+				if err := ValidateDevice(envName); err != nil {
+					c.warnings.addf(WarningInvalidEnvName, c.source, lineNumber, envName, "env %q is skipped: %v", envName, err)
+				} else if _, exists := seen[envName]; !exists {
+					seen[envName] = struct{}{}
+					envNames = append(envNames, envName)
+				}
+			}
+			if c.sections[currentSection] == nil {
+				c.sections[currentSection] = make(map[string]string, 8)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			// Options up to the next valid section header are ignored, so
+			// they do not leak into the previous section.
+			c.warnings.addf(WarningMalformedSection, c.source, lineNumber, "", "malformed section header %q", trimmed)
+			currentSection = ""
+			currentOption = ""
+			continue
+		}
+
+		if currentSection == "" {
+			currentOption = ""
+			continue
+		}
+
+		isContinuation := (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && currentOption != ""
+		if isContinuation {
+			if value := parseOptionValue(trimmed); value != "" {
+				existing := c.sections[currentSection][currentOption]
+				if existing != "" {
+					existing += "\n"
+				}
+				c.sections[currentSection][currentOption] = existing + value
+			}
+			continue
+		}
+
+		key, value, ok := splitIniOption(trimmed)
+		if !ok {
+			c.warnings.addf(WarningMalformedOption, c.source, lineNumber, envSectionName(currentSection), "line in [%s] is not an option: %q", currentSection, trimmed)
+			currentOption = ""
+			continue
+		}
+		currentOption = strings.ToLower(key)
+		c.sections[currentSection][currentOption] = parseOptionValue(value)
+	}
+	return envNames
+}
+
+func (c *platformIOConfig) hasSection(name string) bool {
+	for config := c; config != nil; config = config.base {
+		if _, ok := config.sections[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the option as written in the section itself.
+func (c *platformIOConfig) get(section string, key string) (string, bool) {
+	for config := c; config != nil; config = config.base {
+		if value, ok := config.sections[section][key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// lookup returns the raw option value the way PlatformIO resolves it: the
+// section itself, then the sections it extends, then the common [env]
+// section for [env:*] sections.
+func (c *platformIOConfig) lookup(section string, key string, visited map[string]bool) (string, bool) {
+	return c.resolve(section, key, visited, true)
+}
+
+// resolve implements lookup; without common the [env] section is skipped,
+// leaving the value an env sets itself or through extends.
+func (c *platformIOConfig) resolve(section string, key string, visited map[string]bool, common bool) (string, bool) {
+	if visited[section] {
+		return "", false
+	}
+	visited[section] = true
+
+	if value, ok := c.get(section, key); ok {
+		return value, true
+	}
+	extends, _ := c.get(section, "extends")
+	for _, parent := range strings.Split(extends, ",") {
+		parent = strings.TrimSpace(parent)
+		if parent == "" {
+			continue
+		}
+		if !c.hasSection(parent) && !strings.HasPrefix(parent, "env:") && c.hasSection("env:"+parent) {
+			parent = "env:" + parent
+		}
+		if !c.hasSection(parent) {
+			c.warnings.addf(WarningUnknownExtends, "", 0, envSectionName(section), "[%s] extends unknown section [%s]", section, parent)
+			continue
+		}
+		if value, ok := c.resolve(parent, key, visited, common); ok {
+			return value, true
+		}
+	}
+	if common && strings.HasPrefix(section, "env:") {
+		return c.resolve("env", key, visited, common)
+	}
+	return "", false
+}
+
+// envValue returns the effective, interpolated value of an option for
+// [env:envName].
+func (c *platformIOConfig) envValue(envName string, key string) string {
+	section := "env:" + envName
+	raw, ok := c.lookup(section, key, map[string]bool{})
+	if !ok {
+		return ""
+	}
+	return c.expand(raw, section, 0)
+}
+
+// envList returns a list option (build_flags, lib_deps) of [env:envName]:
+// the entries of the common [env] section followed by the env's own, set
+// directly or through extends, without repeats. Lines with ${...}
+// references are expanded and split into single items, build flags on
+// whitespace as PlatformIO does, so "${env.build_flags} -DTBEAM=1" yields
+// each flag separately.
+func (c *platformIOConfig) envList(envName string, key string) []string {
+	section := "env:" + envName
+	items := make([]string, 0, 8)
+	seen := make(map[string]bool, 8)
+	add := func(raw string) {
+		for _, line := range optionLines(raw) {
+			values := []string{line}
+			if strings.Contains(line, "${") {
+				values = optionLines(c.expand(line, section, 0))
+				if key == "build_flags" {
+					values = splitFlagWords(values)
+				}
+			}
+			for _, value := range values {
+				if !seen[value] {
+					seen[value] = true
+					items = append(items, value)
+				}
+			}
+		}
+	}
+
+	if raw, ok := c.resolve("env", key, map[string]bool{}, false); ok {
+		add(raw)
+	}
+	if raw, ok := c.resolve(section, key, map[string]bool{}, false); ok {
+		add(raw)
+	}
+	return items
+}
+
+// flagsWithArgument take their argument as the next word; splitFlagWords
+// keeps the pair together, as written.
+var flagsWithArgument = map[string]bool{"-D": true, "-U": true, "-I": true, "-L": true, "-l": true, "-include": true, "-isystem": true}
+
+// splitFlagWords splits build flag lines on whitespace outside quotes,
+// keeping the quotes, and "-D NAME" style pairs together.
+func splitFlagWords(lines []string) []string {
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		fields := make([]string, 0, 4)
+		var current strings.Builder
+		quote := rune(0)
+		for _, r := range line {
+			switch {
+			case quote != 0:
+				if r == quote {
+					quote = 0
+				}
+				current.WriteRune(r)
+			case r == '"' || r == '\'':
+				quote = r
+				current.WriteRune(r)
+			case r == ' ' || r == '\t':
+				if current.Len() > 0 {
+					fields = append(fields, current.String())
+					current.Reset()
+				}
+			default:
+				current.WriteRune(r)
+			}
+		}
+		if current.Len() > 0 {
+			fields = append(fields, current.String())
+		}
+
+		for index := 0; index < len(fields); index++ {
+			word := fields[index]
+			if flagsWithArgument[word] && index+1 < len(fields) {
+				word += " " + fields[index+1]
+				index++
+			}
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// expand replaces ${section.option} references. ${this.option} refers to
+// the env being resolved, ${this.__env__} to its name, and ${sysenv.NAME}
+// expands to nothing because the build environment is not known here.
+// Unknown references, and references nested deeper than
+// maxInterpolationDepth (usually a cycle), expand to nothing as well.
+func (c *platformIOConfig) expand(value string, envSection string, depth int) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	if depth >= maxInterpolationDepth {
+		c.warnings.addf(WarningUnresolvedReference, "", 0, envSectionName(envSection), "references nested deeper than %d levels, probably a cycle: %s", maxInterpolationDepth, strings.Join(interpolationPattern.FindAllString(value, -1), " "))
+		return interpolationPattern.ReplaceAllString(value, "")
+	}
+	return interpolationPattern.ReplaceAllStringFunc(value, func(match string) string {
+		parts := interpolationPattern.FindStringSubmatch(match)
+		section := strings.TrimSpace(parts[1])
+		key := strings.ToLower(strings.TrimSpace(parts[2]))
+		switch section {
+		case "sysenv":
+			return ""
+		case "this":
+			if key == "__env__" {
+				return strings.TrimPrefix(envSection, "env:")
+			}
+			section = envSection
+		}
+		resolved, ok := c.lookup(section, key, map[string]bool{})
+		if !ok {
+			// [platformio] options such as build_dir have built-in defaults
+			// that are not known here.
+			if section != "platformio" {
+				c.warnings.addf(WarningUnresolvedReference, "", 0, envSectionName(envSection), "%s is not defined and expands to nothing", match)
+			}
+			return ""
+		}
+		return c.expand(resolved, envSection, depth+1)
+	})
+}
+
+// envSectionName returns NAME for an [env:NAME] section and "" otherwise.
+func envSectionName(section string) string {
+	if name, ok := strings.CutPrefix(section, "env:"); ok {
+		return name
+	}
+	return ""
+}
+
+// optionLines splits a multi-line option value into its non-empty lines.
+func optionLines(value string) []string {
+	lines := make([]string, 0, 4)
+	for _, line := range strings.Split(value, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+func TestListVariantDevicesResolvesExtendsAcrossExtraConfigs(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"platformio.ini": `[platformio]
+extra_configs =
+  arch/*/*.ini
+  variants/*/*/platformio.ini
+
+[env]
+build_flags = -DGLOBAL=1
+lib_deps = SPI
+`,
+		"arch/esp32/esp32s3.ini": `[esp32_base]
+platform = platformio/espressif32@6.9.0
+build_flags =
+  ${env.build_flags}
+  -DESP32_BASE
+
+[esp32s3_base]
+extends = esp32_base
+board_build.mcu = esp32s3
+build_flags =
+  ${esp32_base.build_flags}
+  -DBOARD_HAS_PSRAM
+`,
+		"variants/esp32s3/heltec_v3/platformio.ini": `[env:heltec-v3]
+extends = esp32s3_base
+board = heltec_wifi_lora_32_V3
+build_flags =
+  ${esp32s3_base.build_flags}
+  -D PRIVATE_HW
+  -I variants/${this.__env__}
+
+[env:heltec-v3-gps]
+extends = env:heltec-v3
+build_flags =
+  ${env:heltec-v3.build_flags}
+  -D HAS_GPS=1
+`,
+	})
+
+	devices, err := listVariantDevices(root)
+	if err != nil {
+		t.Fatalf("listVariantDevices failed: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+
+	base := devices[0]
+	wantFlags := []string{"-DGLOBAL=1", "-DESP32_BASE", "-DBOARD_HAS_PSRAM", "-D PRIVATE_HW", "-I variants/heltec-v3"}
+	if base.Name != "heltec-v3" || !reflect.DeepEqual(base.BuildFlags, wantFlags) {
+		t.Fatalf("unexpected heltec-v3 flags: got=%v want=%v", base.BuildFlags, wantFlags)
+	}
+	if !reflect.DeepEqual(base.LibDeps, []string{"SPI"}) {
+		t.Fatalf("unexpected heltec-v3 lib deps: %v", base.LibDeps)
+	}
+	if base.Platform != PlatformESP32 || base.MCU != "esp32s3" || base.Board != "heltec_wifi_lora_32_V3" {
+		t.Fatalf("unexpected heltec-v3 metadata: %+v", base)
+	}
+
+	gps := devices[1]
+	wantFlags = []string{"-DGLOBAL=1", "-DESP32_BASE", "-DBOARD_HAS_PSRAM", "-D PRIVATE_HW", "-I variants/heltec-v3-gps", "-D HAS_GPS=1"}
+	if gps.Name != "heltec-v3-gps" || !reflect.DeepEqual(gps.BuildFlags, wantFlags) {
+		t.Fatalf("unexpected heltec-v3-gps flags: got=%v want=%v", gps.BuildFlags, wantFlags)
+	}
+	if gps.Board != "heltec_wifi_lora_32_V3" || gps.Hints.GPS == nil || !*gps.Hints.GPS {
+		t.Fatalf("unexpected heltec-v3-gps metadata: %+v", gps)
+	}
+}
+
+func TestExtractPlatformIOEnvConfigInterpolation(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"platformio.ini": `[env]
+build_flags = -DGLOBAL=1
+  -Wall
+lib_deps = SPI
+
+[radio]
+build_flags = -DRADIO=1 '-DNAME="a b"' -D LORA
+lib_deps =
+  sandeepmistry/LoRa @ ^0.8.0
+
+[env:tbeam]
+build_flags = ${env.build_flags} -DTBEAM=1
+lib_deps =
+  ${env.lib_deps}
+  bblanchon/ArduinoJson @ ^7
+
+[env:heltec]
+extends = radio
+build_flags =
+  ${radio.build_flags}
+  -DHELTEC=1
+`,
+	})
+
+	_, envOptions, err := extractPlatformIOEnvConfig(root)
+	if err != nil {
+		t.Fatalf("extractPlatformIOEnvConfig failed: %v", err)
+	}
+
+	tbeam := envOptions["tbeam"]
+	if !reflect.DeepEqual(tbeam.BuildFlags, []string{"-DGLOBAL=1", "-Wall", "-DTBEAM=1"}) {
+		t.Fatalf("interpolated flags must be split into single flags: %q", tbeam.BuildFlags)
+	}
+	if !reflect.DeepEqual(tbeam.LibDeps, []string{"SPI", "bblanchon/ArduinoJson @ ^7"}) {
+		t.Fatalf("common lib deps must not repeat: %q", tbeam.LibDeps)
+	}
+
+	heltec := envOptions["heltec"]
+	wantFlags := []string{"-DGLOBAL=1", "-Wall", "-DRADIO=1", `'-DNAME="a b"'`, "-D LORA", "-DHELTEC=1"}
+	if !reflect.DeepEqual(heltec.BuildFlags, wantFlags) {
+		t.Fatalf("unexpected heltec flags: got=%q want=%q", heltec.BuildFlags, wantFlags)
+	}
+	if !reflect.DeepEqual(heltec.LibDeps, []string{"SPI", "sandeepmistry/LoRa @ ^0.8.0"}) {
+		t.Fatalf("extended lib deps must follow the common ones: %q", heltec.LibDeps)
+	}
+}
+
+func TestSplitFlagWords(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]string{
+		"-DA=1 -DB=2":             {"-DA=1", "-DB=2"},
+		"  -Wall\t-Os ":           {"-Wall", "-Os"},
+		`-DNAME="x y" -DZ`:        {`-DNAME="x y"`, "-DZ"},
+		"-D HAS_GPS=1 -I include": {"-D HAS_GPS=1", "-I include"},
+		"-D":                      {"-D"},
+	}
+	for line, want := range cases {
+		if got := splitFlagWords([]string{line}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("splitFlagWords(%q): got=%q want=%q", line, got, want)
+		}
+	}
+}
+
+func TestPlatformIOConfigLookupStopsOnCycles(t *testing.T) {
+	t.Parallel()
+
+	config := newPlatformIOConfig(nil)
+	config.parse(`[env:a]
+extends = env:b
+build_flags = ${this.missing} -DA ${sysenv.HOME}
+
+[env:b]
+extends = env:a
+lib_deps = ${env:b.lib_deps}
+`)
+
+	if got := config.envValue("a", "build_flags"); got != " -DA " {
+		t.Fatalf("unexpected build flags: %q", got)
+	}
+	if got := config.envValue("b", "lib_deps"); got != "" {
+		t.Fatalf("self-referencing option must expand to nothing, got %q", got)
+	}
+	if _, ok := config.lookup("env:b", "board", map[string]bool{}); ok {
+		t.Fatalf("lookup through an extends cycle must fail")
+	}
+}
+
+func TestExtraConfigDirectories(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"platformio.ini": "[platformio]\nextra_configs = arch/*/*.ini, variants/*/*/platformio.ini\n  extra.ini\n  ../outside/*.ini\n  */common.ini\n",
+	})
+
+	got := extraConfigDirectories(root)
+	if !reflect.DeepEqual(got, []string{"arch", "variants"}) {
+		t.Fatalf("unexpected extra_configs directories: %v", got)
+	}
+}
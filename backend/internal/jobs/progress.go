@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gitProgressPattern matches git's transfer progress lines, e.g.
+// "Receiving objects:  42% (1234/2938), 1.20 MiB | 2.40 MiB/s" or
+// "remote: Compressing objects: 100% (12/12), done.".
+var gitProgressPattern = regexp.MustCompile(`^(?:remote: )?([A-Z][a-z]+(?: [a-z]+)*):\s+(\d{1,3})% \((\d+)/(\d+)\)(.*)$`)
+
+// Progress reports how far a long-running step of a job has come. Phase is
+// the job step ("clone"), Stage the git stage within it ("Receiving
+// objects", "Resolving deltas", ...).
+type Progress struct {
+	Phase   string `json:"phase"`
+	Stage   string `json:"stage"`
+	Percent int    `json:"percent"`
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+	// Done is set on the final event of a phase; the job state then no
+	// longer carries progress.
+	Done bool `json:"done,omitempty"`
+}
+
+// parseGitProgress parses a git progress line. Completed stages (", done.")
+// are reported as well so they can still be logged.
+func parseGitProgress(line string) (Progress, bool) {
+	match := gitProgressPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return Progress{}, false
+	}
+	percent, _ := strconv.Atoi(match[2])
+	current, _ := strconv.ParseInt(match[3], 10, 64)
+	total, _ := strconv.ParseInt(match[4], 10, 64)
+	if percent > 100 {
+		percent = 100
+	}
+	return Progress{
+		Stage:   match[1],
+		Percent: percent,
+		Current: current,
+		Total:   total,
+	}, true
+}
+
+// progressLogger wraps onLine for a git step of job: progress lines update
+// the job's progress instead of flooding the log, and only the final line of
+// each stage is kept in the log.
+func progressLogger(job *Job, phase string, onLine func(string)) func(string) {
+	return func(line string) {
+		progress, ok := parseGitProgress(line)
+		if !ok {
+			onLine(line)
+			return
+		}
+		progress.Phase = phase
+		job.setProgress(&progress)
+		if strings.HasSuffix(strings.TrimSpace(line), "done.") {
+			onLine(line)
+		}
+	}
+}
+
+func (j *Job) setProgress(progress *Progress) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if progress == nil {
+		j.Progress = nil
+		return
+	}
+	current := *progress
+	if current.Done {
+		j.Progress = nil
+	} else {
+		j.Progress = &current
+	}
+	j.broadcastLocked(LogEvent{Progress: &current})
+}
+
+// finishProgress ends the current phase and tells stream subscribers so.
+func (j *Job) finishProgress(phase string) {
+	j.setProgress(&Progress{Phase: phase, Stage: "done", Percent: 100, Done: true})
+}
+
+func (p *Progress) clone() *Progress {
+	if p == nil {
+		return nil
+	}
+	current := *p
+	return &current
+}
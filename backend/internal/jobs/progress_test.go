@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGitProgress(t *testing.T) {
+	t.Parallel()
+
+	progress, ok := parseGitProgress("Receiving objects:  42% (1234/2938), 1.20 MiB | 2.40 MiB/s")
+	if !ok || progress.Stage != "Receiving objects" || progress.Percent != 42 || progress.Current != 1234 || progress.Total != 2938 {
+		t.Fatalf("unexpected progress: ok=%v progress=%+v", ok, progress)
+	}
+	if progress, ok = parseGitProgress("remote: Compressing objects: 100% (12/12), done."); !ok || progress.Stage != "Compressing objects" {
+		t.Fatalf("unexpected remote progress: ok=%v progress=%+v", ok, progress)
+	}
+	for _, line := range []string{"Cloning into 'repo'...", "$ git clone --progress", "Processing tbeam (board: tbeam)"} {
+		if _, ok := parseGitProgress(line); ok {
+			t.Fatalf("expected %q not to be parsed as progress", line)
+		}
+	}
+}
+
+func TestProgressLoggerUpdatesJob(t *testing.T) {
+	t.Parallel()
+
+	job := newJob("job", "https://github.com/meshtastic/firmware", "main", "tbeam", BuildOptions{}, t.TempDir(), time.Now(), "")
+	stream, _, unsubscribe := job.subscribe(0)
+	defer unsubscribe()
+
+	onLine := progressLogger(job, "clone", func(line string) { job.appendLog(100, line) })
+	onLine("Cloning into 'repo'...")
+	onLine("Receiving objects:  50% (5/10)")
+	onLine("Receiving objects: 100% (10/10), done.")
+
+	state := job.snapshot()
+	if state.Progress == nil || state.Progress.Phase != "clone" || state.Progress.Percent != 100 {
+		t.Fatalf("unexpected job progress: %+v", state.Progress)
+	}
+	if len(job.getLogs()) != 2 {
+		t.Fatalf("unexpected log lines: got=%q", job.getLogs())
+	}
+
+	job.finishProgress("clone")
+	if job.snapshot().Progress != nil {
+		t.Fatalf("expected progress to be cleared after the phase finished")
+	}
+
+	var progressEvents int
+	for len(stream) > 0 {
+		if event := <-stream; event.Progress != nil {
+			progressEvents++
+		}
+	}
+	if progressEvents != 3 {
+		t.Fatalf("unexpected progress events: got=%d want=3", progressEvents)
+	}
+}
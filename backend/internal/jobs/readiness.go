@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// readinessTTL bounds how often the checks run; orchestrators probe
+	// every few seconds and each probe would otherwise spawn processes.
+	readinessTTL     = 5 * time.Second
+	readinessTimeout = 3 * time.Second
+)
+
+// ReadinessCheck is the outcome of one readiness check.
+type ReadinessCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Readiness reports whether this node can actually build: the docker daemon
+// answers, git is installed, the jobs directory is writable and the queue
+// accepts jobs with enough free disk space.
+type Readiness struct {
+	Ready     bool             `json:"ready"`
+	Checks    []ReadinessCheck `json:"checks"`
+	CheckedAt time.Time        `json:"checkedAt"`
+}
+
+type readinessCache struct {
+	mu     sync.Mutex
+	result Readiness
+}
+
+// Readiness runs the readiness checks, reusing a result younger than
+// readinessTTL.
+func (m *Manager) Readiness(ctx context.Context) Readiness {
+	m.readiness.mu.Lock()
+	defer m.readiness.mu.Unlock()
+
+	now := m.now()
+	cached := m.readiness.result
+	if !cached.CheckedAt.IsZero() && now.Sub(cached.CheckedAt) < readinessTTL {
+		return cloneReadiness(cached)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := []struct {
+		name  string
+		check func(context.Context) error
+	}{
+		{"docker", m.dockerReady},
+		{"git", gitAvailable},
+		{"workdir", func(context.Context) error { return writableDir(m.cfg.JobsRootPath) }},
+		{"queue", func(context.Context) error { return m.queueAccepting() }},
+		{"disk", func(context.Context) error { return m.checkDiskSpace() }},
+	}
+
+	result := Readiness{Ready: true, Checks: make([]ReadinessCheck, 0, len(checks)), CheckedAt: now}
+	for _, item := range checks {
+		check := ReadinessCheck{Name: item.name, OK: true}
+		if err := item.check(ctx); err != nil {
+			check.OK = false
+			check.Error = err.Error()
+			result.Ready = false
+		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	m.readiness.result = result
+	return cloneReadiness(result)
+}
+
+func cloneReadiness(readiness Readiness) Readiness {
+	readiness.Checks = append([]ReadinessCheck(nil), readiness.Checks...)
+	return readiness
+}
+
+// queueAccepting fails while draining or when the queue is full, in which
+// case CreateJob would block.
+func (m *Manager) queueAccepting() error {
+	if m.draining.Load() {
+		return ErrDraining
+	}
+	if len(m.queue) >= cap(m.queue) {
+		return fmt.Errorf("build queue is full (%d jobs)", cap(m.queue))
+	}
+	return nil
+}
+
+func dockerDaemonReachable(ctx context.Context) error {
+	output, err := exec.CommandContext(ctx, "docker", "version", "--format", "{{.Server.Version}}").CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			message = err.Error()
+		}
+		return fmt.Errorf("docker daemon unreachable: %s", message)
+	}
+	return nil
+}
+
+func gitAvailable(context.Context) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("git executable not found")
+	}
+	return nil
+}
+
+func writableDir(path string) error {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	file, err := os.CreateTemp(path, ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", path, err)
+	}
+	name := file.Name()
+	file.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestManagerReadiness(t *testing.T) {
+	workDir := t.TempDir()
+	manager := NewManager(config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time { return now }
+	dockerErr := errors.New("docker daemon unreachable: connection refused")
+	manager.dockerCheck = func(context.Context) error { return dockerErr }
+
+	checks := func(readiness Readiness) map[string]bool {
+		result := make(map[string]bool)
+		for _, check := range readiness.Checks {
+			result[check.Name] = check.OK
+		}
+		return result
+	}
+
+	readiness := manager.Readiness(context.Background())
+	got := checks(readiness)
+	if readiness.Ready || got["docker"] || !got["workdir"] || !got["queue"] {
+		t.Fatalf("unexpected readiness with docker down: %+v", readiness)
+	}
+
+	// The result is reused within the TTL.
+	manager.dockerCheck = func(context.Context) error { return nil }
+	if manager.Readiness(context.Background()).Ready {
+		t.Fatalf("expected the cached result within the TTL")
+	}
+
+	now = now.Add(readinessTTL)
+	readiness = manager.Readiness(context.Background())
+	if got := checks(readiness); !got["docker"] || got["git"] != readiness.Ready {
+		t.Fatalf("unexpected readiness after the TTL: %+v", readiness)
+	}
+
+	manager.SetDraining(true)
+	now = now.Add(readinessTTL)
+	readiness = manager.Readiness(context.Background())
+	if readiness.Ready || checks(readiness)["queue"] {
+		t.Fatalf("a draining node must not be ready: %+v", readiness)
+	}
+}
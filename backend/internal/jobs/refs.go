@@ -27,6 +27,10 @@ type RepoRefs struct {
 	DefaultBranch  string    `json:"defaultBranch,omitempty"`
 	RecentBranches []RepoRef `json:"recentBranches"`
 	RecentTags     []RepoRef `json:"recentTags"`
+
+	// headBranch is the branch HEAD points at on the remote, before any
+	// fallback is applied to DefaultBranch.
+	headBranch string
 }
 
 func discoverRefs(ctx context.Context, discoveryRoot string, repoURL string) (RepoRefs, error) {
@@ -41,6 +45,7 @@ func discoverRefs(ctx context.Context, discoveryRoot string, repoURL string) (Re
 		return RepoRefs{}, fmt.Errorf("read repository refs: %w", err)
 	}
 	result.DefaultBranch = parseDefaultBranch(defaultBranchOutput)
+	result.headBranch = result.DefaultBranch
 
 	branchesOutput, err := runGitCapture(ctx, "ls-remote", "--heads", repoURL)
 	if err != nil {
@@ -241,12 +246,17 @@ func limitRepoRefs(refs []RepoRef, limit int) []RepoRef {
 
 func runGitCapture(ctx context.Context, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
+	release, err := prepareGitCommand(ctx, cmd, nil, args)
+	if err != nil {
+		return "", err
+	}
+	defer release()
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		if ctx.Err() != nil {
 			return "", ctx.Err()
 		}
-		message := strings.TrimSpace(string(output))
+		message := RedactSecrets(strings.TrimSpace(string(output)))
 		if message == "" {
 			return "", fmt.Errorf("git command failed: %w", err)
 		}
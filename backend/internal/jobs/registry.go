@@ -0,0 +1,397 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildinfo"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+const (
+	registryQueueSize    = 256
+	registryBatchSize    = 500
+	registryWriteTimeout = 5 * time.Second
+	// registryHeartbeat is how often a node refreshes its status; a node
+	// missing three heartbeats drops out of the cluster view.
+	registryHeartbeat = 15 * time.Second
+	registryNodeTTL   = 3 * registryHeartbeat
+)
+
+// ErrRegistryDisabled is returned by the cluster queries when
+// APP_JOB_REGISTRY is off.
+var ErrRegistryDisabled = errors.New("job registry is disabled")
+
+// RegistryEntry is the metadata of a job as shared with the other nodes of
+// a cluster. Logs and artifacts are only served by Node.
+type RegistryEntry struct {
+	JobID       string      `json:"jobId"`
+	Node        string      `json:"node"`
+	RepoURL     string      `json:"repoUrl"`
+	Ref         string      `json:"ref,omitempty"`
+	Device      string      `json:"device"`
+	Status      Status      `json:"status"`
+	UserID      string      `json:"userId,omitempty"`
+	Private     bool        `json:"private,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	StartedAt   *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time  `json:"finishedAt,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	FailureKind FailureKind `json:"failureKind,omitempty"`
+}
+
+// NodeSummary counts the registered jobs of one node by status.
+type NodeSummary struct {
+	Node   string         `json:"node"`
+	Jobs   int            `json:"jobs"`
+	Status map[Status]int `json:"status"`
+}
+
+// NodeStatus is the load a node last reported to the registry.
+type NodeStatus struct {
+	Node             string    `json:"node"`
+	Version          string    `json:"version"`
+	Commit           string    `json:"commit,omitempty"`
+	ConcurrentBuilds int       `json:"concurrentBuilds"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+	DrainStatus
+}
+
+// ClusterJobs is a page of the registry, newest first, with the job counts
+// of every node.
+type ClusterJobs struct {
+	Jobs  []RegistryEntry `json:"jobs"`
+	Nodes []NodeSummary   `json:"nodes"`
+}
+
+// ClusterFilter selects registry entries; empty fields match everything.
+type ClusterFilter struct {
+	Node   string
+	Status Status
+	Limit  int
+}
+
+// jobRegistry shares job metadata through Redis: each job is a key that
+// expires after the retention window, indexed by creation time in one
+// sorted set for the cluster and one per user. Writes are queued and sent
+// by a single goroutine, so status updates arrive in order and a slow Redis
+// never delays a build.
+type jobRegistry struct {
+	client  *redis.Client
+	prefix  string
+	node    string
+	ttl     time.Duration
+	updates chan RegistryEntry
+	logger  *slog.Logger
+}
+
+func newJobRegistry(cfg config.Config, logger *slog.Logger) (*jobRegistry, error) {
+	if !cfg.JobRegistry {
+		return nil, nil
+	}
+	options, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse APP_REDIS_URL: %w", err)
+	}
+	return &jobRegistry{
+		client:  redis.NewClient(options),
+		prefix:  cfg.RedisKeyPrefix + "registry:",
+		node:    cfg.NodeName,
+		ttl:     cfg.Retention,
+		updates: make(chan RegistryEntry, registryQueueSize),
+		logger:  logger,
+	}, nil
+}
+
+func (r *jobRegistry) entry(state State) RegistryEntry {
+	return RegistryEntry{
+		JobID:       state.ID,
+		Node:        r.node,
+		RepoURL:     state.RepoURL,
+		Ref:         state.Ref,
+		Device:      state.Device,
+		Status:      state.Status,
+		UserID:      state.UserID,
+		Private:     state.Private,
+		CreatedAt:   state.CreatedAt,
+		StartedAt:   state.StartedAt,
+		FinishedAt:  state.FinishedAt,
+		Error:       state.Error,
+		FailureKind: state.FailureKind,
+	}
+}
+
+// publish queues the current state of job. Updates are dropped with a log
+// line when the queue is full.
+func (r *jobRegistry) publish(job *Job) {
+	if r == nil {
+		return
+	}
+	select {
+	case r.updates <- r.entry(job.snapshot()):
+	default:
+		r.logger.Warn("job registry: queue full, dropped update", "jobId", job.ID)
+	}
+}
+
+// run writes queued updates and the heartbeats built by status until ctx
+// ends, then flushes what is left so the final states of a shutdown are not
+// lost and removes the node from the cluster view.
+func (r *jobRegistry) run(ctx context.Context, status func() NodeStatus) {
+	defer r.client.Close()
+	ticker := time.NewTicker(registryHeartbeat)
+	defer ticker.Stop()
+	r.writeHeartbeat(context.Background(), status())
+	for {
+		select {
+		case entry := <-r.updates:
+			r.write(context.Background(), entry)
+		case <-ticker.C:
+			r.writeHeartbeat(context.Background(), status())
+		case <-ctx.Done():
+			deadline, cancel := context.WithTimeout(context.Background(), registryWriteTimeout)
+			defer cancel()
+			for {
+				select {
+				case entry := <-r.updates:
+					r.write(deadline, entry)
+				default:
+					pipe := r.client.TxPipeline()
+					pipe.Del(deadline, r.nodeKey(r.node))
+					pipe.ZRem(deadline, r.prefix+"nodes", r.node)
+					if _, err := pipe.Exec(deadline); err != nil {
+						r.logger.Error("job registry: remove node", "node", r.node, "error", err)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *jobRegistry) write(ctx context.Context, entry RegistryEntry) {
+	ctx, cancel := context.WithTimeout(ctx, registryWriteTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		r.logger.Error("job registry: encode job", "jobId", entry.JobID, "error", err)
+		return
+	}
+	member := redis.Z{Score: float64(entry.CreatedAt.UnixMicro()), Member: entry.JobID}
+	expired := fmt.Sprintf("(%d", time.Now().Add(-r.ttl).UnixMicro())
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, r.jobKey(entry.JobID), payload, r.ttl)
+	indexes := []string{r.prefix + "jobs"}
+	if entry.UserID != "" {
+		indexes = append(indexes, r.userKey(entry.UserID))
+	}
+	for _, index := range indexes {
+		pipe.ZAdd(ctx, index, member)
+		pipe.ZRemRangeByScore(ctx, index, "-inf", expired)
+		pipe.Expire(ctx, index, r.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("job registry: publish job", "jobId", entry.JobID, "error", err)
+	}
+}
+
+func (r *jobRegistry) writeHeartbeat(ctx context.Context, status NodeStatus) {
+	ctx, cancel := context.WithTimeout(ctx, registryWriteTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(status)
+	if err != nil {
+		r.logger.Error("job registry: encode node", "node", r.node, "error", err)
+		return
+	}
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, r.nodeKey(r.node), payload, registryNodeTTL)
+	pipe.ZAdd(ctx, r.prefix+"nodes", redis.Z{Score: float64(status.UpdatedAt.UnixMilli()), Member: r.node})
+	pipe.ZRemRangeByScore(ctx, r.prefix+"nodes", "-inf", fmt.Sprintf("(%d", status.UpdatedAt.Add(-registryNodeTTL).UnixMilli()))
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("job registry: heartbeat", "node", r.node, "error", err)
+	}
+}
+
+// nodes returns the status of every node with a live heartbeat, by name.
+func (r *jobRegistry) nodes(ctx context.Context) ([]NodeStatus, error) {
+	names, err := r.client.ZRange(ctx, r.prefix+"nodes", 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("job registry: %w", err)
+	}
+	statuses := make([]NodeStatus, 0, len(names))
+	if len(names) == 0 {
+		return statuses, nil
+	}
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = r.nodeKey(name)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("job registry: %w", err)
+	}
+	for _, value := range values {
+		payload, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var status NodeStatus
+		if err := json.Unmarshal([]byte(payload), &status); err == nil {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Node < statuses[j].Node })
+	return statuses, nil
+}
+
+// query walks index newest first and returns the entries accepted by
+// filter, up to limit (all when limit <= 0). visit sees every live entry.
+func (r *jobRegistry) query(ctx context.Context, index string, filter ClusterFilter, visit func(RegistryEntry)) ([]RegistryEntry, error) {
+	result := make([]RegistryEntry, 0)
+	for start := int64(0); ; start += registryBatchSize {
+		ids, err := r.client.ZRevRange(ctx, index, start, start+registryBatchSize-1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("job registry: %w", err)
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = r.jobKey(id)
+		}
+		values, err := r.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("job registry: %w", err)
+		}
+
+		for _, value := range values {
+			payload, ok := value.(string)
+			if !ok {
+				continue
+			}
+			var entry RegistryEntry
+			if err := json.Unmarshal([]byte(payload), &entry); err != nil {
+				continue
+			}
+			if visit != nil {
+				visit(entry)
+			}
+			if (filter.Node != "" && entry.Node != filter.Node) || (filter.Status != "" && entry.Status != filter.Status) {
+				continue
+			}
+			if filter.Limit <= 0 || len(result) < filter.Limit {
+				result = append(result, entry)
+			}
+		}
+		if visit == nil && filter.Limit > 0 && len(result) >= filter.Limit {
+			return result, nil
+		}
+		if len(ids) < registryBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func (r *jobRegistry) jobKey(jobID string) string {
+	return r.prefix + "job:" + jobID
+}
+
+func (r *jobRegistry) userKey(userID string) string {
+	return r.prefix + "user:" + userID
+}
+
+func (r *jobRegistry) nodeKey(node string) string {
+	return r.prefix + "node:" + node
+}
+
+// publishJob announces a state transition of job: the registry shares it
+// with the cluster and a finished job is notified.
+func (m *Manager) publishJob(job *Job) {
+	m.registry.publish(job)
+	m.notifyJob(job)
+}
+
+// nodeStatus is the heartbeat of this node.
+func (m *Manager) nodeStatus() NodeStatus {
+	return NodeStatus{
+		Node:             m.cfg.NodeName,
+		Version:          strings.TrimSpace(buildinfo.Version),
+		Commit:           strings.TrimSpace(buildinfo.Commit),
+		ConcurrentBuilds: m.cfg.ConcurrentBuilds,
+		UpdatedAt:        m.now(),
+		DrainStatus:      m.DrainStatus(),
+	}
+}
+
+// ClusterNodes returns the last reported status of every node sharing the
+// registry, by name.
+func (m *Manager) ClusterNodes(ctx context.Context) ([]NodeStatus, error) {
+	if m.registry == nil {
+		return nil, ErrRegistryDisabled
+	}
+	return m.registry.nodes(ctx)
+}
+
+// ClusterJobs lists the registered jobs of every node sharing the registry,
+// newest first, with per-node counts over the whole retention window.
+func (m *Manager) ClusterJobs(ctx context.Context, filter ClusterFilter) (ClusterJobs, error) {
+	if m.registry == nil {
+		return ClusterJobs{}, ErrRegistryDisabled
+	}
+	summaries := make(map[string]*NodeSummary)
+	entries, err := m.registry.query(ctx, m.registry.prefix+"jobs", filter, func(entry RegistryEntry) {
+		summary := summaries[entry.Node]
+		if summary == nil {
+			summary = &NodeSummary{Node: entry.Node, Status: make(map[Status]int)}
+			summaries[entry.Node] = summary
+		}
+		summary.Jobs++
+		summary.Status[entry.Status]++
+	})
+	if err != nil {
+		return ClusterJobs{}, err
+	}
+
+	result := ClusterJobs{Jobs: entries, Nodes: make([]NodeSummary, 0, len(summaries))}
+	for _, summary := range summaries {
+		result.Nodes = append(result.Nodes, *summary)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].Node < result.Nodes[j].Node })
+	return result, nil
+}
+
+// remoteUserJobs returns the registered jobs of userID built by other
+// nodes, newest first.
+func (m *Manager) remoteUserJobs(userID string, limit int) ([]RegistryEntry, error) {
+	if m.registry == nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(m.ctx, registryWriteTimeout)
+	defer cancel()
+	entries, err := m.registry.query(ctx, m.registry.userKey(userID), ClusterFilter{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	remote := make([]RegistryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Node != m.registry.node {
+			remote = append(remote, entry)
+		}
+		if limit > 0 && len(remote) >= limit {
+			break
+		}
+	}
+	return remote, nil
+}
@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestJobRegistrySharedBetweenNodes(t *testing.T) {
+	t.Parallel()
+
+	redisServer := miniredis.RunT(t)
+	newNode := func(name string) *Manager {
+		workDir := t.TempDir()
+		mgr := NewManager(config.Config{
+			JobsRootPath:    filepath.Join(workDir, "jobs"),
+			BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+			MaxLogLines:     200,
+			CleanupInterval: time.Hour,
+			Retention:       time.Hour,
+			RedisURL:        "redis://" + redisServer.Addr() + "/0",
+			RedisKeyPrefix:  "test:",
+			JobRegistry:     true,
+			NodeName:        name,
+		}, slog.New(slog.DiscardHandler))
+		t.Cleanup(mgr.Close)
+		return mgr
+	}
+	first, second := newNode("builder-1"), newNode("builder-2")
+	ctx := context.Background()
+
+	owner := JobOwner{ClientIP: "203.0.113.9", UserID: "alice"}
+	cancelled, err := first.CreateOwnedJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, owner)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if _, err := first.CancelJob(cancelled.ID); err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	queued, err := second.CreateOwnedJob("https://github.com/example/repo.git", "main", "rak4631", BuildOptions{}, JobOwner{ClientIP: "198.51.100.1"})
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	var cluster ClusterJobs
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cluster, err = second.ClusterJobs(ctx, ClusterFilter{})
+		if err != nil {
+			t.Fatalf("cluster jobs: %v", err)
+		}
+		if len(cluster.Jobs) == 2 && cluster.Jobs[0].Status == StatusQueued && cluster.Jobs[1].Status == StatusCancelled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("registry did not converge: %+v", cluster)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cluster.Jobs[0].JobID != queued.ID || cluster.Jobs[0].Node != "builder-2" || cluster.Jobs[1].Node != "builder-1" {
+		t.Fatalf("unexpected cluster jobs: %+v", cluster.Jobs)
+	}
+	if len(cluster.Nodes) != 2 || cluster.Nodes[0].Node != "builder-1" || cluster.Nodes[0].Status[StatusCancelled] != 1 {
+		t.Fatalf("unexpected node summaries: %+v", cluster.Nodes)
+	}
+
+	filtered, err := first.ClusterJobs(ctx, ClusterFilter{Node: "builder-2", Limit: 10})
+	if err != nil || len(filtered.Jobs) != 1 || filtered.Jobs[0].JobID != queued.ID || len(filtered.Nodes) != 2 {
+		t.Fatalf("unexpected filtered jobs: %+v err=%v", filtered, err)
+	}
+
+	history, err := second.UserJobs("alice", 10)
+	if err != nil || len(history) != 1 || history[0].JobID != cancelled.ID || history[0].Node != "builder-1" || history[0].Retained {
+		t.Fatalf("expected alice's job from the other node, got %+v err=%v", history, err)
+	}
+	if ttl := redisServer.TTL("test:registry:job:" + cancelled.ID); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("expected registry entries to expire with the retention, got %v", ttl)
+	}
+}
+
+func TestJobRegistryNodeHeartbeats(t *testing.T) {
+	t.Parallel()
+
+	redisServer := miniredis.RunT(t)
+	newNode := func(name string) *Manager {
+		workDir := t.TempDir()
+		return NewManager(config.Config{
+			JobsRootPath:    filepath.Join(workDir, "jobs"),
+			BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+			MaxLogLines:     200,
+			CleanupInterval: time.Hour,
+			Retention:       time.Hour,
+			RedisURL:        "redis://" + redisServer.Addr() + "/0",
+			RedisKeyPrefix:  "test:",
+			JobRegistry:     true,
+			NodeName:        name,
+		}, slog.New(slog.DiscardHandler))
+	}
+	first, second := newNode("builder-1"), newNode("builder-2")
+	t.Cleanup(first.Close)
+	if _, err := second.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "203.0.113.9"); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	second.SetDraining(true)
+	ctx := context.Background()
+
+	var nodes []NodeStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		// Refresh the heartbeats the way the ticker does; the one written
+		// when a registry starts may still be racing with this test.
+		first.registry.writeHeartbeat(ctx, first.nodeStatus())
+		second.registry.writeHeartbeat(ctx, second.nodeStatus())
+		var err error
+		nodes, err = first.ClusterNodes(ctx)
+		if err != nil {
+			t.Fatalf("cluster nodes: %v", err)
+		}
+		if len(nodes) == 2 && nodes[1].Draining && nodes[1].Queued == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected node statuses: %+v", nodes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if nodes[0].Node != "builder-1" || nodes[1].Node != "builder-2" || nodes[0].Queued != 0 || nodes[0].Draining {
+		t.Fatalf("unexpected node statuses: %+v", nodes)
+	}
+	if ttl := redisServer.TTL("test:registry:node:builder-1"); ttl <= 0 || ttl > registryNodeTTL {
+		t.Fatalf("expected heartbeats to expire, got %v", ttl)
+	}
+
+	second.Close()
+	if nodes, err := first.ClusterNodes(ctx); err != nil || len(nodes) != 1 || nodes[0].Node != "builder-1" {
+		t.Fatalf("expected a stopped node to leave the cluster view, got %+v err=%v", nodes, err)
+	}
+}
+
+func TestJobRegistryDisabled(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	mgr := NewManager(config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	t.Cleanup(mgr.Close)
+
+	if _, err := mgr.ClusterJobs(context.Background(), ClusterFilter{}); err != ErrRegistryDisabled {
+		t.Fatalf("expected ErrRegistryDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrRepoNotAllowed is returned for repositories outside
+// APP_ALLOWED_REPO_HOSTS.
+var ErrRepoNotAllowed = errors.New("repository host is not allowed")
+
+// checkRepoAllowed rejects repoURL unless it matches an allowed host entry.
+// Entries are "host" or "host/owner" globs matched against the leading
+// elements of the repository key, so "github.com" does not admit
+// "github.com.evil.net" or "github.com:8443".
+func (m *Manager) checkRepoAllowed(repoURL string) error {
+	if repoHostAllowed(m.cfg.AllowedRepoHosts, repoURL) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrRepoNotAllowed, repoURL)
+}
+
+func repoHostAllowed(allowed []string, repoURL string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	parts := strings.Split(repoKey(repoURL), "/")
+	for _, entry := range allowed {
+		size := strings.Count(entry, "/") + 1
+		if len(parts) <= size {
+			continue
+		}
+		if matched, err := path.Match(entry, strings.Join(parts[:size], "/")); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestRepoHostAllowed(t *testing.T) {
+	t.Parallel()
+
+	allowed := []string{"github.com", "gitlab.com/meshtastic", "*.example.org"}
+	tests := []struct {
+		repoURL string
+		want    bool
+	}{
+		{repoURL: "https://github.com/meshtastic/firmware.git", want: true},
+		{repoURL: "git@github.com:acme/firmware.git", want: true},
+		{repoURL: "https://gitlab.com/meshtastic/firmware", want: true},
+		{repoURL: "https://gitlab.com/acme/firmware", want: false},
+		{repoURL: "https://git.example.org/acme/firmware", want: true},
+		{repoURL: "https://github.com.evil.net/acme/firmware", want: false},
+		{repoURL: "https://github.com:8443/acme/firmware", want: false},
+		{repoURL: "http://169.254.169.254/latest/meta-data", want: false},
+		{repoURL: "https://github.com", want: false},
+	}
+	for _, tt := range tests {
+		if got := repoHostAllowed(allowed, tt.repoURL); got != tt.want {
+			t.Fatalf("unexpected result for %s: got=%v want=%v", tt.repoURL, got, tt.want)
+		}
+	}
+
+	if !repoHostAllowed(nil, "http://10.0.0.1/repo.git") {
+		t.Fatalf("expected any repository to be allowed without an allowlist")
+	}
+}
+
+func TestCreateJobRejectsDisallowedRepo(t *testing.T) {
+	t.Parallel()
+
+	manager := &Manager{cfg: config.Config{AllowedRepoHosts: []string{"github.com"}}}
+	_, err := manager.CreateJob("https://internal.local/acme/firmware.git", "main", "tbeam", BuildOptions{}, "127.0.0.1")
+	if !errors.Is(err, ErrRepoNotAllowed) {
+		t.Fatalf("unexpected error: got=%v want=%v", err, ErrRepoNotAllowed)
+	}
+}
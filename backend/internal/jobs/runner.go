@@ -36,15 +36,15 @@ func runBuildInContainer(ctx context.Context, cfg config.Config, repoPath string
 	repoMount := fmt.Sprintf("%s:/workspace/repo", hostRepoPath)
 	cacheMount := fmt.Sprintf("%s:%s", hostCachePath, containerPlatformIOPath)
 
-	args := []string{
-		"run",
-		"--rm",
+	args := []string{"run", "--rm"}
+	args = append(args, containerHardeningArgs(cfg, containerPlatformIOPath)...)
+	args = append(args,
 		"-e", "CI=true",
 		"-e", "PLATFORMIO_NO_ANSI=true",
-		"-e", "PLATFORMIO_RUN_JOBS=" + strconv.Itoa(cfg.PlatformIOJobs),
-		"-e", "PLATFORMIO_BUILD_CACHE_DIR=" + containerBuildCachePath,
-		"-e", "CCACHE_DIR=" + containerCCachePath,
-		"-e", "CCACHE_BASEDIR=" + containerProjectPath,
+		"-e", "PLATFORMIO_RUN_JOBS="+strconv.Itoa(cfg.PlatformIOJobs),
+		"-e", "PLATFORMIO_BUILD_CACHE_DIR="+containerBuildCachePath,
+		"-e", "CCACHE_DIR="+containerCCachePath,
+		"-e", "CCACHE_BASEDIR="+containerProjectPath,
 		"-e", "CCACHE_COMPILERCHECK=content",
 		"-e", "CCACHE_NOHASHDIR=true",
 		"-e", "CCACHE_SLOPPINESS=time_macros",
@@ -55,7 +55,7 @@ func runBuildInContainer(ctx context.Context, cfg config.Config, repoPath string
 		cfg.BuilderImage,
 		"run",
 		"-d", containerProjectPath,
-	}
+	)
 
 	if strings.TrimSpace(projectConfigPath) != "" {
 		containerConfigPath, err := resolveContainerProjectConfigPath(projectConfigPath, containerProjectPath)
@@ -82,6 +82,36 @@ func runBuildInContainer(ctx context.Context, cfg config.Config, repoPath string
 	return nil
 }
 
+// containerHardeningArgs returns the docker run options that contain an
+// untrusted build, as configured by the APP_BUILD_* hardening settings.
+func containerHardeningArgs(cfg config.Config, containerPlatformIOPath string) []string {
+	var args []string
+	if cfg.BuildReadOnlyRootFS {
+		// PlatformIO and the compilers write temporary files and run
+		// helper scripts from /tmp.
+		args = append(args, "--read-only", "--tmpfs", "/tmp:rw,exec,nosuid,nodev")
+	}
+	if cfg.BuildCapDropAll {
+		args = append(args, "--cap-drop", "ALL", "--security-opt", "no-new-privileges")
+	}
+	if cfg.BuildSeccompProfile != "" {
+		args = append(args, "--security-opt", "seccomp="+cfg.BuildSeccompProfile)
+	}
+	if cfg.BuildUser != "" {
+		// The user has no home in the image: keep PlatformIO on the cache
+		// mount and give everything else a writable home.
+		args = append(args,
+			"--user", cfg.BuildUser,
+			"-e", "HOME=/tmp",
+			"-e", "PLATFORMIO_CORE_DIR="+containerPlatformIOPath,
+		)
+	}
+	if cfg.BuildPidsLimit > 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(cfg.BuildPidsLimit))
+	}
+	return args
+}
+
 func resolveContainerProjectConfigPath(projectConfigPath string, containerProjectPath string) (string, error) {
 	value := strings.TrimSpace(projectConfigPath)
 	if value == "" {
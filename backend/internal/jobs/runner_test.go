@@ -1,6 +1,12 @@
 package jobs
 
-import "testing"
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
 
 func TestResolveDockerHostPath(t *testing.T) {
 	t.Parallel()
@@ -67,3 +73,29 @@ func TestResolveContainerProjectConfigPath(t *testing.T) {
 		}
 	})
 }
+
+func TestContainerHardeningArgs(t *testing.T) {
+	t.Parallel()
+
+	if args := containerHardeningArgs(config.Config{}, "/root/.platformio"); len(args) != 0 {
+		t.Fatalf("expected no options by default, got %v", args)
+	}
+
+	args := containerHardeningArgs(config.Config{
+		BuildReadOnlyRootFS: true,
+		BuildCapDropAll:     true,
+		BuildSeccompProfile: "/etc/mfb/seccomp.json",
+		BuildUser:           "1000:1000",
+		BuildPidsLimit:      512,
+	}, "/root/.platformio")
+	want := []string{
+		"--read-only", "--tmpfs", "/tmp:rw,exec,nosuid,nodev",
+		"--cap-drop", "ALL", "--security-opt", "no-new-privileges",
+		"--security-opt", "seccomp=/etc/mfb/seccomp.json",
+		"--user", "1000:1000", "-e", "HOME=/tmp", "-e", "PLATFORMIO_CORE_DIR=/root/.platformio",
+		"--pids-limit", "512",
+	}
+	if !slices.Equal(args, want) {
+		t.Fatalf("unexpected options:\n got: %s\nwant: %s", strings.Join(args, " "), strings.Join(want, " "))
+	}
+}
@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+// s3Uploader stores artifacts in an S3-compatible bucket using path-style
+// requests signed with AWS Signature Version 4.
+type s3Uploader struct {
+	endpoint  string
+	bucket    string
+	region    string
+	prefix    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func newS3Uploader(cfg config.Config) *s3Uploader {
+	if cfg.ArtifactS3Bucket == "" {
+		return nil
+	}
+	return &s3Uploader{
+		endpoint:  cfg.ArtifactS3Endpoint,
+		bucket:    cfg.ArtifactS3Bucket,
+		region:    cfg.ArtifactS3Region,
+		prefix:    cfg.ArtifactS3Prefix,
+		accessKey: cfg.ArtifactS3AccessKey,
+		secretKey: cfg.ArtifactS3SecretKey,
+		publicURL: cfg.ArtifactS3PublicURL,
+		client:    &http.Client{Timeout: 10 * time.Minute},
+		now:       time.Now,
+	}
+}
+
+func (u *s3Uploader) Name() string {
+	return "s3"
+}
+
+// Upload puts every artifact under <prefix><jobID>/<relativePath>. The
+// returned URLs are only populated when a public base URL is configured.
+func (u *s3Uploader) Upload(ctx context.Context, state State, artifacts []Artifact) (map[string]string, error) {
+	urls := make(map[string]string, len(artifacts))
+	for _, artifact := range artifacts {
+		key := u.prefix + state.ID + "/" + artifact.RelativePath
+		if err := u.putArtifact(ctx, key, artifact); err != nil {
+			return urls, fmt.Errorf("upload %s: %w", artifact.RelativePath, err)
+		}
+		if u.publicURL != "" {
+			urls[artifact.ID] = u.publicURL + "/" + escapeS3Key(key)
+		}
+	}
+	return urls, nil
+}
+
+func (u *s3Uploader) putArtifact(ctx context.Context, key string, artifact Artifact) error {
+	reader, err := artifact.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	target := u.endpoint + "/" + u.bucket + "/" + escapeS3Key(key)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, target, reader)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = artifact.Size
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	payloadHash := artifact.SHA256
+	if payloadHash == "" {
+		payloadHash = "UNSIGNED-PAYLOAD"
+	}
+	u.sign(request, payloadHash)
+
+	response, err := u.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("object storage responded %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds SigV4 headers for a request whose body hash is payloadHash.
+func (u *s3Uploader) sign(request *http.Request, payloadHash string) {
+	now := u.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if u.accessKey == "" {
+		return
+	}
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := shortDate + "/" + u.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+u.secretKey), shortDate)
+	key = hmacSHA256(key, u.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+u.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// escapeS3Key percent-encodes an object key the way SigV4 canonical URIs
+// expect: everything except unreserved characters and "/".
+func escapeS3Key(key string) string {
+	builder := strings.Builder{}
+	for index := 0; index < len(key); index++ {
+		char := key[index]
+		switch {
+		case char >= 'A' && char <= 'Z', char >= 'a' && char <= 'z', char >= '0' && char <= '9',
+			char == '-', char == '_', char == '.', char == '~', char == '/':
+			builder.WriteByte(char)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", char)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestS3UploaderUpload(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	received := make(map[string]string)
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.URL.EscapedPath()] = string(body)
+		authorization = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "firmware.bin")
+	if err := os.WriteFile(path, []byte("firmware"), 0o644); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+	artifact := Artifact{ID: "1", Name: "firmware.bin", RelativePath: "nested/firmware v1.bin", Size: 8, absPath: path}
+	if err := artifact.computeChecksums(); err != nil {
+		t.Fatalf("checksum: %v", err)
+	}
+
+	uploader := newS3Uploader(config.Config{
+		ArtifactS3Endpoint:  server.URL,
+		ArtifactS3Bucket:    "firmware",
+		ArtifactS3Region:    "us-east-1",
+		ArtifactS3Prefix:    "builds/",
+		ArtifactS3AccessKey: "AKIDEXAMPLE",
+		ArtifactS3SecretKey: "secret",
+		ArtifactS3PublicURL: "https://cdn.example.com",
+	})
+	uploader.now = func() time.Time { return time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC) }
+
+	urls, err := uploader.Upload(context.Background(), State{ID: "job-1"}, []Artifact{artifact})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	wantPath := "/firmware/builds/job-1/nested/firmware%20v1.bin"
+	if received[wantPath] != "firmware" {
+		t.Fatalf("object not uploaded to %s: %v", wantPath, received)
+	}
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260301/us-east-1/s3/aws4_request") {
+		t.Fatalf("unexpected Authorization header: %q", authorization)
+	}
+	if urls["1"] != "https://cdn.example.com/builds/job-1/nested/firmware%20v1.bin" {
+		t.Fatalf("unexpected public URL: %q", urls["1"])
+	}
+}
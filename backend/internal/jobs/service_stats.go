@@ -0,0 +1,200 @@
+package jobs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildlogs"
+)
+
+// serviceStatsTTL bounds how often the persisted build history is re-read;
+// the service statistics are public, so they must stay cheap to serve.
+const serviceStatsTTL = 30 * time.Second
+
+// ServiceStats summarizes the persisted build history for public status
+// dashboards. It carries no client or repository details.
+type ServiceStats struct {
+	Days              int                  `json:"days"`
+	TotalBuilds       int                  `json:"totalBuilds"`
+	DailyBuilds       []DailyBuildStats    `json:"dailyBuilds"`
+	Platforms         []PlatformBuildStats `json:"platforms"`
+	Failures          []FailureKindStats   `json:"failures"`
+	CacheHits         int                  `json:"cacheHits"`
+	CacheHitRate      float64              `json:"cacheHitRate"`
+	ActiveSubscribers int                  `json:"activeSubscribers"`
+	GeneratedAt       time.Time            `json:"generatedAt"`
+}
+
+// DailyBuildStats counts finished builds per status for one UTC day.
+type DailyBuildStats struct {
+	Date      string `json:"date"`
+	Success   int    `json:"success"`
+	Failed    int    `json:"failed"`
+	Cancelled int    `json:"cancelled"`
+}
+
+// PlatformBuildStats reports the builds of one platform. The average
+// duration only covers successful builds that were not served from the
+// firmware cache.
+type PlatformBuildStats struct {
+	Platform               string  `json:"platform"`
+	Builds                 int     `json:"builds"`
+	AverageDurationSeconds float64 `json:"averageDurationSeconds"`
+}
+
+// FailureKindStats counts the failed builds of one kind. Failures without a
+// kind, including those recorded before failures were classified, are not
+// counted.
+type FailureKindStats struct {
+	Kind   FailureKind `json:"kind"`
+	Builds int         `json:"builds"`
+}
+
+type serviceStatsCache struct {
+	mu         sync.Mutex
+	days       int
+	computedAt time.Time
+	stats      ServiceStats
+}
+
+// ServiceStats returns build totals of the last days days from the persisted
+// build logs together with the number of clients following live job logs.
+func (m *Manager) ServiceStats(days int) (ServiceStats, error) {
+	now := m.now()
+
+	m.serviceStats.mu.Lock()
+	defer m.serviceStats.mu.Unlock()
+
+	cache := &m.serviceStats
+	if cache.days != days || cache.computedAt.IsZero() || now.Sub(cache.computedAt) >= serviceStatsTTL {
+		entries, err := m.buildLogs.List(0)
+		if err != nil {
+			return ServiceStats{}, err
+		}
+		cache.stats = summarizeBuildHistory(entries, days, now)
+		cache.days = days
+		cache.computedAt = now
+	}
+
+	result := cache.stats
+	result.DailyBuilds = append([]DailyBuildStats(nil), cache.stats.DailyBuilds...)
+	result.Platforms = append([]PlatformBuildStats(nil), cache.stats.Platforms...)
+	result.Failures = append([]FailureKindStats(nil), cache.stats.Failures...)
+	result.ActiveSubscribers = m.activeSubscribers()
+	return result, nil
+}
+
+func (m *Manager) activeSubscribers() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	total := 0
+	for _, job := range m.jobs {
+		total += job.subscriberCount()
+	}
+	return total
+}
+
+// summarizeBuildHistory aggregates the build logs finished within the last
+// days days. Builds from before platforms were recorded count as
+// PlatformOther.
+func summarizeBuildHistory(entries []buildlogs.BuildLogEntry, days int, now time.Time) ServiceStats {
+	cutoff := now.UTC().AddDate(0, 0, -days).Truncate(24 * time.Hour)
+	result := ServiceStats{Days: days, GeneratedAt: now}
+
+	type platformTotals struct {
+		builds   int
+		timed    int
+		duration time.Duration
+	}
+	daily := make(map[string]*DailyBuildStats)
+	platforms := make(map[string]*platformTotals)
+	failures := make(map[FailureKind]int)
+	successes := 0
+
+	for _, entry := range entries {
+		finished := entry.CreatedAt
+		if entry.FinishedAt != nil {
+			finished = *entry.FinishedAt
+		}
+		if finished.Before(cutoff) {
+			continue
+		}
+
+		status := Status(entry.Status)
+		if status != StatusSuccess && status != StatusFailed && status != StatusCancelled {
+			continue
+		}
+
+		date := finished.UTC().Format("2006-01-02")
+		day, ok := daily[date]
+		if !ok {
+			day = &DailyBuildStats{Date: date}
+			daily[date] = day
+		}
+		switch status {
+		case StatusSuccess:
+			day.Success++
+			successes++
+		case StatusFailed:
+			day.Failed++
+			if entry.FailureKind != "" {
+				failures[FailureKind(entry.FailureKind)]++
+			}
+		case StatusCancelled:
+			day.Cancelled++
+		}
+		result.TotalBuilds++
+		if entry.CacheHit {
+			result.CacheHits++
+		}
+
+		platform := entry.Platform
+		if platform == "" {
+			platform = PlatformOther
+		}
+		totals, ok := platforms[platform]
+		if !ok {
+			totals = &platformTotals{}
+			platforms[platform] = totals
+		}
+		totals.builds++
+		if status == StatusSuccess && !entry.CacheHit && entry.StartedAt != nil && entry.FinishedAt != nil {
+			totals.timed++
+			totals.duration += entry.FinishedAt.Sub(*entry.StartedAt)
+		}
+	}
+
+	result.DailyBuilds = make([]DailyBuildStats, 0, len(daily))
+	for _, day := range daily {
+		result.DailyBuilds = append(result.DailyBuilds, *day)
+	}
+	sort.Slice(result.DailyBuilds, func(i, j int) bool {
+		return result.DailyBuilds[i].Date < result.DailyBuilds[j].Date
+	})
+
+	result.Platforms = make([]PlatformBuildStats, 0, len(platforms))
+	for name, totals := range platforms {
+		item := PlatformBuildStats{Platform: name, Builds: totals.builds}
+		if totals.timed > 0 {
+			item.AverageDurationSeconds = totals.duration.Seconds() / float64(totals.timed)
+		}
+		result.Platforms = append(result.Platforms, item)
+	}
+	sort.Slice(result.Platforms, func(i, j int) bool {
+		return result.Platforms[i].Platform < result.Platforms[j].Platform
+	})
+
+	result.Failures = make([]FailureKindStats, 0, len(failures))
+	for _, kind := range FailureKinds {
+		if failures[kind] > 0 {
+			result.Failures = append(result.Failures, FailureKindStats{Kind: kind, Builds: failures[kind]})
+		}
+	}
+
+	if successes > 0 {
+		result.CacheHitRate = float64(result.CacheHits) / float64(successes)
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildlogs"
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestSummarizeBuildHistory(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(day int, hour int) *time.Time {
+		value := time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC)
+		return &value
+	}
+	entries := []buildlogs.BuildLogEntry{
+		{Status: "success", Platform: PlatformESP32, StartedAt: at(9, 10), FinishedAt: at(9, 11)},
+		{Status: "success", Platform: PlatformESP32, StartedAt: at(9, 12), FinishedAt: at(9, 15)},
+		{Status: "success", Platform: PlatformESP32, CacheHit: true, StartedAt: at(10, 9), FinishedAt: at(10, 9)},
+		{Status: "failed", Platform: PlatformNRF52, FailureKind: string(FailureCompile), StartedAt: at(10, 8), FinishedAt: at(10, 9)},
+		{Status: "cancelled", CreatedAt: *at(10, 7)},
+		{Status: "success", Platform: PlatformESP32, StartedAt: at(1, 1), FinishedAt: at(1, 2)},
+		{Status: "running", CreatedAt: *at(10, 11)},
+	}
+
+	stats := summarizeBuildHistory(entries, 7, now)
+	if stats.TotalBuilds != 5 || stats.CacheHits != 1 {
+		t.Fatalf("unexpected totals: builds=%d hits=%d", stats.TotalBuilds, stats.CacheHits)
+	}
+	if stats.CacheHitRate != 1.0/3 {
+		t.Fatalf("unexpected cache hit rate: %v", stats.CacheHitRate)
+	}
+
+	wantDaily := []DailyBuildStats{
+		{Date: "2026-03-09", Success: 2},
+		{Date: "2026-03-10", Success: 1, Failed: 1, Cancelled: 1},
+	}
+	if len(stats.DailyBuilds) != len(wantDaily) {
+		t.Fatalf("unexpected daily builds: %+v", stats.DailyBuilds)
+	}
+	for index, want := range wantDaily {
+		if stats.DailyBuilds[index] != want {
+			t.Fatalf("unexpected day %d: got=%+v want=%+v", index, stats.DailyBuilds[index], want)
+		}
+	}
+
+	wantPlatforms := []PlatformBuildStats{
+		{Platform: PlatformESP32, Builds: 3, AverageDurationSeconds: 7200},
+		{Platform: PlatformNRF52, Builds: 1},
+		{Platform: PlatformOther, Builds: 1},
+	}
+	if len(stats.Platforms) != len(wantPlatforms) {
+		t.Fatalf("unexpected platforms: %+v", stats.Platforms)
+	}
+	for index, want := range wantPlatforms {
+		if stats.Platforms[index] != want {
+			t.Fatalf("unexpected platform %d: got=%+v want=%+v", index, stats.Platforms[index], want)
+		}
+	}
+
+	if len(stats.Failures) != 1 || stats.Failures[0] != (FailureKindStats{Kind: FailureCompile, Builds: 1}) {
+		t.Fatalf("unexpected failures: %+v", stats.Failures)
+	}
+}
+
+func TestServiceStatsCountsSubscribers(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	manager := NewManager(config.Config{
+		JobsRootPath:    filepath.Join(workDir, "jobs"),
+		BuildLogsPath:   filepath.Join(workDir, "build-logs"),
+		MaxLogLines:     200,
+		CleanupInterval: time.Hour,
+	}, slog.New(slog.DiscardHandler))
+	defer manager.Close()
+
+	state, err := manager.CreateJob("https://github.com/example/repo.git", "main", "tbeam", BuildOptions{}, "")
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	_, _, unsubscribe, err := manager.SubscribeLogs(state.ID, 0)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	stats, err := manager.ServiceStats(30)
+	if err != nil {
+		t.Fatalf("service stats: %v", err)
+	}
+	if stats.ActiveSubscribers != 1 || stats.TotalBuilds != 0 || stats.Days != 30 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	unsubscribe()
+	stats, err = manager.ServiceStats(30)
+	if err != nil {
+		t.Fatalf("service stats: %v", err)
+	}
+	if stats.ActiveSubscribers != 0 {
+		t.Fatalf("subscriber count must drop after unsubscribe: %d", stats.ActiveSubscribers)
+	}
+}
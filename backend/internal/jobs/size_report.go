@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// MemoryUsage is a single line of the PlatformIO size summary.
+type MemoryUsage struct {
+	Used    int64   `json:"used"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// SizeReport holds the flash and RAM usage printed by PlatformIO after
+// linking.
+type SizeReport struct {
+	RAM   *MemoryUsage `json:"ram,omitempty"`
+	Flash *MemoryUsage `json:"flash,omitempty"`
+}
+
+func (r *SizeReport) clone() *SizeReport {
+	if r == nil {
+		return nil
+	}
+	cloned := &SizeReport{}
+	if r.RAM != nil {
+		ram := *r.RAM
+		cloned.RAM = &ram
+	}
+	if r.Flash != nil {
+		flash := *r.Flash
+		cloned.Flash = &flash
+	}
+	return cloned
+}
+
+// sizeLinePattern matches PlatformIO's "Checking size" output, e.g.
+//
+//	RAM:   [==        ]  15.2% (used 49876 bytes from 327680 bytes)
+//	Flash: [========= ]  85.1% (used 1784313 bytes from 2097152 bytes)
+var sizeLinePattern = regexp.MustCompile(`^\s*(RAM|Flash):\s*\[[^\]]*\]\s*([0-9.]+)%\s*\(used\s+(\d+)\s+bytes\s+from\s+(\d+)\s+bytes\)`)
+
+// sizeReportParser collects size lines from streamed build output.
+type sizeReportParser struct {
+	mu     sync.Mutex
+	report SizeReport
+}
+
+func (p *sizeReportParser) observe(line string) {
+	match := sizeLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+
+	percent, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return
+	}
+	used, err := strconv.ParseInt(match[3], 10, 64)
+	if err != nil {
+		return
+	}
+	total, err := strconv.ParseInt(match[4], 10, 64)
+	if err != nil {
+		return
+	}
+
+	usage := &MemoryUsage{Used: used, Total: total, Percent: percent}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if match[1] == "RAM" {
+		p.report.RAM = usage
+	} else {
+		p.report.Flash = usage
+	}
+}
+
+// result returns the collected report, or nil when no size lines were seen.
+func (p *sizeReportParser) result() *SizeReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.report.RAM == nil && p.report.Flash == nil {
+		return nil
+	}
+	return p.report.clone()
+}
+
+// loadFirmwareCacheSizeReport returns the size report stored with a
+// firmware cache entry, if any.
+func loadFirmwareCacheSizeReport(cacheRootPath string, cacheKey string) *SizeReport {
+	cacheDir, err := firmwareCacheDirPath(cacheRootPath, cacheKey)
+	if err != nil {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(cacheDir, firmwareCacheManifestName))
+	if err != nil {
+		return nil
+	}
+
+	var manifest firmwareCacheManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil
+	}
+	return manifest.Size
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import "testing"
+
+func TestSizeReportParser(t *testing.T) {
+	t.Parallel()
+
+	parser := &sizeReportParser{}
+	if parser.result() != nil {
+		t.Fatalf("empty parser must not report sizes")
+	}
+
+	lines := []string{
+		"Linking .pio/build/tbeam/firmware.elf",
+		"Checking size .pio/build/tbeam/firmware.elf",
+		"RAM:   [==        ]  15.2% (used 49876 bytes from 327680 bytes)",
+		"Flash: [========= ]  85.1% (used 1784313 bytes from 2097152 bytes)",
+	}
+	for _, line := range lines {
+		parser.observe(line)
+	}
+
+	report := parser.result()
+	if report == nil || report.RAM == nil || report.Flash == nil {
+		t.Fatalf("expected RAM and flash usage, got %+v", report)
+	}
+	if report.RAM.Used != 49876 || report.RAM.Total != 327680 || report.RAM.Percent != 15.2 {
+		t.Fatalf("unexpected RAM usage: %+v", *report.RAM)
+	}
+	if report.Flash.Used != 1784313 || report.Flash.Total != 2097152 || report.Flash.Percent != 85.1 {
+		t.Fatalf("unexpected flash usage: %+v", *report.Flash)
+	}
+}
@@ -0,0 +1,205 @@
+package jobs
+
+import (
+	"bytes"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SizeDiff compares the linked firmware of two builds.
+type SizeDiff struct {
+	JobID      string          `json:"jobId"`
+	AgainstID  string          `json:"againstJobId"`
+	Device     string          `json:"device"`
+	FlashDelta *int64          `json:"flashDelta,omitempty"`
+	RAMDelta   *int64          `json:"ramDelta,omitempty"`
+	ImageDelta int64           `json:"imageDelta"`
+	Sections   []SizeDiffEntry `json:"sections"`
+	Symbols    []SizeDiffEntry `json:"symbols"`
+}
+
+// SizeDiffEntry is the size of one section or symbol in both builds.
+// Before refers to the "against" job.
+type SizeDiffEntry struct {
+	Name   string `json:"name"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+	Delta  int64  `json:"delta"`
+}
+
+type elfSizes struct {
+	sections map[string]int64
+	// imageSections are allocated sections with file content, i.e. the
+	// bytes that end up in the flash image.
+	imageSections map[string]bool
+	symbols       map[string]int64
+}
+
+// SizeDiff compares the ELF artifacts of jobID and againstID. Symbols are
+// sorted by absolute change and truncated to symbolLimit entries.
+func (m *Manager) SizeDiff(jobID string, againstID string, symbolLimit int) (SizeDiff, error) {
+	current, err := m.GetJob(jobID)
+	if err != nil {
+		return SizeDiff{}, err
+	}
+	against, err := m.GetJob(againstID)
+	if err != nil {
+		return SizeDiff{}, err
+	}
+	if current.Device != against.Device {
+		return SizeDiff{}, ErrDeviceMismatch
+	}
+
+	after, err := readArtifactELFSizes(current.Artifacts)
+	if err != nil {
+		return SizeDiff{}, err
+	}
+	before, err := readArtifactELFSizes(against.Artifacts)
+	if err != nil {
+		return SizeDiff{}, err
+	}
+
+	diff := SizeDiff{
+		JobID:     current.ID,
+		AgainstID: against.ID,
+		Device:    current.Device,
+		Sections:  diffSizeMaps(before.sections, after.sections),
+		Symbols:   diffSizeMaps(before.symbols, after.symbols),
+	}
+	for _, entry := range diff.Sections {
+		if before.imageSections[entry.Name] || after.imageSections[entry.Name] {
+			diff.ImageDelta += entry.Delta
+		}
+	}
+	if symbolLimit > 0 && len(diff.Symbols) > symbolLimit {
+		diff.Symbols = diff.Symbols[:symbolLimit]
+	}
+
+	if current.Size != nil && against.Size != nil {
+		if current.Size.Flash != nil && against.Size.Flash != nil {
+			delta := current.Size.Flash.Used - against.Size.Flash.Used
+			diff.FlashDelta = &delta
+		}
+		if current.Size.RAM != nil && against.Size.RAM != nil {
+			delta := current.Size.RAM.Used - against.Size.RAM.Used
+			diff.RAMDelta = &delta
+		}
+	}
+	return diff, nil
+}
+
+func findELFArtifact(artifacts []Artifact) (Artifact, bool) {
+	var found Artifact
+	ok := false
+	for _, artifact := range artifacts {
+		if !strings.EqualFold(filepath.Ext(artifact.Name), ".elf") {
+			continue
+		}
+		if strings.EqualFold(artifact.Name, "firmware.elf") {
+			return artifact, true
+		}
+		if !ok {
+			found, ok = artifact, true
+		}
+	}
+	return found, ok
+}
+
+func readArtifactELFSizes(artifacts []Artifact) (elfSizes, error) {
+	artifact, ok := findELFArtifact(artifacts)
+	if !ok {
+		return elfSizes{}, ErrELFNotFound
+	}
+
+	reader, err := artifact.Open()
+	if err != nil {
+		return elfSizes{}, fmt.Errorf("open %s: %w", artifact.Name, err)
+	}
+	defer reader.Close()
+
+	var readerAt io.ReaderAt
+	if file, ok := reader.(io.ReaderAt); ok {
+		readerAt = file
+	} else {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return elfSizes{}, fmt.Errorf("read %s: %w", artifact.Name, err)
+		}
+		readerAt = bytes.NewReader(content)
+	}
+
+	file, err := elf.NewFile(readerAt)
+	if err != nil {
+		return elfSizes{}, fmt.Errorf("parse %s: %w", artifact.Name, err)
+	}
+	defer file.Close()
+	return elfSizesFromFile(file)
+}
+
+func elfSizesFromFile(file *elf.File) (elfSizes, error) {
+	sizes := elfSizes{
+		sections:      make(map[string]int64),
+		imageSections: make(map[string]bool),
+		symbols:       make(map[string]int64),
+	}
+
+	for _, section := range file.Sections {
+		if section.Flags&elf.SHF_ALLOC == 0 || section.Size == 0 {
+			continue
+		}
+		sizes.sections[section.Name] += int64(section.Size)
+		if section.Type != elf.SHT_NOBITS {
+			sizes.imageSections[section.Name] = true
+		}
+	}
+
+	symbols, err := file.Symbols()
+	if err != nil && !errors.Is(err, elf.ErrNoSymbols) {
+		return elfSizes{}, fmt.Errorf("read symbols: %w", err)
+	}
+	for _, symbol := range symbols {
+		kind := elf.ST_TYPE(symbol.Info)
+		if symbol.Size == 0 || (kind != elf.STT_FUNC && kind != elf.STT_OBJECT) {
+			continue
+		}
+		sizes.symbols[symbol.Name] += int64(symbol.Size)
+	}
+	return sizes, nil
+}
+
+// diffSizeMaps returns the changed entries sorted by absolute delta, largest
+// first, then by name.
+func diffSizeMaps(before map[string]int64, after map[string]int64) []SizeDiffEntry {
+	entries := make([]SizeDiffEntry, 0)
+	for name, size := range after {
+		if previous := before[name]; previous != size {
+			entries = append(entries, SizeDiffEntry{Name: name, Before: previous, After: size, Delta: size - previous})
+		}
+	}
+	for name, size := range before {
+		if _, ok := after[name]; !ok {
+			entries = append(entries, SizeDiffEntry{Name: name, Before: size, Delta: -size})
+		}
+	}
+
+	sort.Slice(entries, func(i int, j int) bool {
+		left, right := absInt64(entries[i].Delta), absInt64(entries[j].Delta)
+		if left != right {
+			return left > right
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func absInt64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
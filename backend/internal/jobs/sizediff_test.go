@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"debug/elf"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDiffSizeMaps(t *testing.T) {
+	t.Parallel()
+
+	before := map[string]int64{"setup": 100, "loop": 50, "removed": 30}
+	after := map[string]int64{"setup": 120, "loop": 50, "added": 400}
+
+	entries := diffSizeMaps(before, after)
+	if len(entries) != 3 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	want := []SizeDiffEntry{
+		{Name: "added", Before: 0, After: 400, Delta: 400},
+		{Name: "removed", Before: 30, After: 0, Delta: -30},
+		{Name: "setup", Before: 100, After: 120, Delta: 20},
+	}
+	for index, entry := range want {
+		if entries[index] != entry {
+			t.Fatalf("entry %d: got=%+v want=%+v", index, entries[index], entry)
+		}
+	}
+}
+
+func TestManagerSizeDiff(t *testing.T) {
+	t.Parallel()
+
+	executable, err := os.Executable()
+	if err != nil {
+		t.Skipf("test binary unavailable: %v", err)
+	}
+	if file, err := elf.Open(executable); err != nil {
+		t.Skipf("test binary is not an ELF file: %v", err)
+	} else {
+		file.Close()
+	}
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	newFinishedJob := func(id string, device string, flashUsed int64) *Job {
+		job := newJob(id, "https://github.com/example/repo.git", "main", device, BuildOptions{}, "", now, "")
+		job.setSizeReport(&SizeReport{Flash: &MemoryUsage{Used: flashUsed, Total: 1 << 20}})
+		job.markSuccess(now, []Artifact{{ID: "1", Name: "firmware.elf", RelativePath: "firmware.elf", absPath: executable}})
+		return job
+	}
+
+	mgr := &Manager{jobs: map[string]*Job{
+		"base":  newFinishedJob("base", "tbeam", 1000),
+		"next":  newFinishedJob("next", "tbeam", 1500),
+		"other": newFinishedJob("other", "rak4631", 1000),
+	}}
+
+	diff, err := mgr.SizeDiff("next", "base", 10)
+	if err != nil {
+		t.Fatalf("SizeDiff failed: %v", err)
+	}
+	if diff.ImageDelta != 0 || len(diff.Sections) != 0 || len(diff.Symbols) != 0 {
+		t.Fatalf("identical ELF files must not differ: %+v", diff)
+	}
+	if diff.FlashDelta == nil || *diff.FlashDelta != 500 {
+		t.Fatalf("unexpected flash delta: %v", diff.FlashDelta)
+	}
+
+	if _, err := mgr.SizeDiff("next", "other", 10); !errors.Is(err, ErrDeviceMismatch) {
+		t.Fatalf("expected device mismatch, got %v", err)
+	}
+	if _, err := mgr.SizeDiff("next", "missing", 10); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected missing job, got %v", err)
+	}
+}
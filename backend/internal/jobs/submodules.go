@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const maxSubmoduleOverrides = 8
+
+var (
+	submodulePathPattern   = regexp.MustCompile(`^[A-Za-z0-9._/-]{1,256}$`)
+	submoduleCommitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// SubmoduleOverride points the submodule at Path to another URL and/or
+// pins it to a commit for one job. Without Commit the commit recorded in
+// the superproject is fetched from URL; without URL the commit is fetched
+// from the submodule's own remote.
+type SubmoduleOverride struct {
+	Path   string `json:"path"`
+	URL    string `json:"url,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+func normalizeSubmoduleOverrides(raw []SubmoduleOverride) ([]SubmoduleOverride, error) {
+	if len(raw) > maxSubmoduleOverrides {
+		return nil, fmt.Errorf("submodules supports up to %d entries", maxSubmoduleOverrides)
+	}
+
+	result := make([]SubmoduleOverride, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, item := range raw {
+		override := SubmoduleOverride{
+			Path:   strings.Trim(strings.TrimSpace(item.Path), "/"),
+			URL:    NormalizeRepoURL(item.URL),
+			Commit: strings.ToLower(strings.TrimSpace(item.Commit)),
+		}
+		if !submodulePathPattern.MatchString(override.Path) || strings.Contains(override.Path, "..") || strings.Contains(override.Path, "//") {
+			return nil, fmt.Errorf("submodule path %q is invalid", item.Path)
+		}
+		if seen[override.Path] {
+			return nil, fmt.Errorf("submodule %q is overridden more than once", override.Path)
+		}
+		seen[override.Path] = true
+		if override.URL == "" && override.Commit == "" {
+			return nil, fmt.Errorf("submodule %q override needs url or commit", override.Path)
+		}
+		if override.URL != "" {
+			if err := ValidateRepoURL(override.URL); err != nil {
+				return nil, fmt.Errorf("submodule %q: %w", override.Path, err)
+			}
+		}
+		if override.Commit != "" && !submoduleCommitPattern.MatchString(override.Commit) {
+			return nil, fmt.Errorf("submodule %q commit must be a full 40-character SHA", override.Path)
+		}
+		result = append(result, override)
+	}
+	return result, nil
+}
+
+func cloneSubmoduleOverrides(overrides []SubmoduleOverride) []SubmoduleOverride {
+	if len(overrides) == 0 {
+		return nil
+	}
+	return append([]SubmoduleOverride(nil), overrides...)
+}
+
+// applySubmoduleOverrides re-points and re-checks out overridden
+// submodules of an already initialized checkout, including their nested
+// submodules.
+func applySubmoduleOverrides(ctx context.Context, repoPath string, overrides []SubmoduleOverride, onLine func(string)) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	paths, err := runGitCapture(ctx, "-C", repoPath, "config", "--file", ".gitmodules", "--get-regexp", `^submodule\..*\.path$`)
+	if err != nil {
+		return errors.New("repository has no submodules to override")
+	}
+	known := make(map[string]bool)
+	for _, line := range strings.Split(paths, "\n") {
+		if _, value, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			known[strings.Trim(value, "/")] = true
+		}
+	}
+
+	for _, override := range overrides {
+		if !known[override.Path] {
+			return fmt.Errorf("submodule %q not found in .gitmodules", override.Path)
+		}
+
+		commit := override.Commit
+		if commit == "" {
+			recorded, err := runGitCapture(ctx, "-C", repoPath, "rev-parse", "HEAD:"+override.Path)
+			if err != nil {
+				return fmt.Errorf("resolve submodule %q commit: %w", override.Path, err)
+			}
+			commit = strings.TrimSpace(recorded)
+		}
+
+		if override.URL != "" {
+			if err := runGit(ctx, onLine, "-C", repoPath, "submodule", "set-url", "--", override.Path, override.URL); err != nil {
+				return fmt.Errorf("set submodule %q url: %w", override.Path, err)
+			}
+			if err := runGit(ctx, onLine, "-C", repoPath, "submodule", "sync", "--", override.Path); err != nil {
+				return fmt.Errorf("sync submodule %q: %w", override.Path, err)
+			}
+		}
+
+		submodulePath := filepath.Join(repoPath, filepath.FromSlash(override.Path))
+		if err := runGit(ctx, onLine, "-C", submodulePath, "fetch", "--depth", "1", "origin", commit); err != nil {
+			return fmt.Errorf("fetch submodule %q commit %s: %w", override.Path, shortCommit(commit), err)
+		}
+		if err := runGit(ctx, onLine, "-C", submodulePath, "checkout", "--force", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("checkout submodule %q: %w", override.Path, err)
+		}
+		if err := runGit(ctx, onLine, "-C", submodulePath, "submodule", "update", "--init", "--recursive", "--depth", "1"); err != nil {
+			return fmt.Errorf("update nested submodules of %q: %w", override.Path, err)
+		}
+	}
+	return nil
+}
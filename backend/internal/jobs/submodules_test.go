@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSubmoduleOverrides(t *testing.T) {
+	t.Parallel()
+
+	commit := strings.Repeat("a", 40)
+	overrides, err := normalizeSubmoduleOverrides([]SubmoduleOverride{
+		{Path: " protobufs/ ", URL: "acme/protobufs"},
+		{Path: "src/lib", Commit: strings.ToUpper(commit)},
+	})
+	if err != nil {
+		t.Fatalf("normalize overrides: %v", err)
+	}
+	if overrides[0].Path != "protobufs" || overrides[0].URL != "https://github.com/acme/protobufs" {
+		t.Fatalf("unexpected url override: %+v", overrides[0])
+	}
+	if overrides[1].Commit != commit {
+		t.Fatalf("unexpected commit override: %+v", overrides[1])
+	}
+
+	invalid := [][]SubmoduleOverride{
+		{{Path: "protobufs"}},
+		{{Path: "../protobufs", URL: "acme/protobufs"}},
+		{{Path: "protobufs", URL: "file:///tmp/protobufs"}},
+		{{Path: "protobufs", Commit: "abc123"}},
+		{{Path: "protobufs", Commit: commit}, {Path: "protobufs/", URL: "acme/protobufs"}},
+	}
+	for _, raw := range invalid {
+		if _, err := normalizeSubmoduleOverrides(raw); err == nil {
+			t.Fatalf("expected error for overrides %+v", raw)
+		}
+	}
+}
+
+func TestApplySubmoduleOverridesRejectsUnknownPath(t *testing.T) {
+	t.Parallel()
+
+	repoPath := initTestRepository(t, map[string]string{
+		".gitmodules": "[submodule \"protobufs\"]\n\tpath = protobufs\n\turl = https://github.com/meshtastic/protobufs.git\n",
+	})
+	err := applySubmoduleOverrides(context.Background(), repoPath, []SubmoduleOverride{{Path: "src/lib", Commit: strings.Repeat("a", 40)}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("unexpected error for unknown submodule: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Symbolic refs resolved to a concrete release tag when a job is created.
+const (
+	RefLatestRelease    = "latest-release"
+	RefLatestBeta       = "latest-beta"
+	RefLatestPrerelease = "latest-prerelease"
+)
+
+// symbolicRefTimeout bounds the tag listing behind a symbolic ref.
+const symbolicRefTimeout = 30 * time.Second
+
+// ErrNoMatchingTag is returned when a symbolic ref matches no tag.
+var ErrNoMatchingTag = errors.New("no release tag matches")
+
+// versionTagPattern parses release tags: "v2.6.11.60ec05e" (Meshtastic),
+// "v2.6.0", and pre-releases such as "v2.7.0-beta.2" or "v2.7.0.abc1234-alpha".
+var versionTagPattern = regexp.MustCompile(`(?i)^v?(\d+)\.(\d+)(?:\.(\d+))?(?:\.[0-9a-f]{7,40})?(?:[-_.]?(alpha|beta|rc)[-_.]?(\d+)?)?$`)
+
+// Pre-release channels from least to most stable.
+const (
+	channelAlpha = iota
+	channelBeta
+	channelRC
+	channelStable
+)
+
+type versionTag struct {
+	name    string
+	version [3]int
+	channel int
+	number  int
+}
+
+func parseVersionTag(name string) (versionTag, bool) {
+	match := versionTagPattern.FindStringSubmatch(name)
+	if match == nil {
+		return versionTag{}, false
+	}
+	tag := versionTag{name: name, channel: channelStable}
+	for i := 0; i < 3; i++ {
+		tag.version[i], _ = strconv.Atoi(match[i+1])
+	}
+	switch strings.ToLower(match[4]) {
+	case "alpha":
+		tag.channel = channelAlpha
+	case "beta":
+		tag.channel = channelBeta
+	case "rc":
+		tag.channel = channelRC
+	}
+	tag.number, _ = strconv.Atoi(match[5])
+	return tag, true
+}
+
+func (t versionTag) newerThan(other versionTag) bool {
+	for i := range t.version {
+		if t.version[i] != other.version[i] {
+			return t.version[i] > other.version[i]
+		}
+	}
+	if t.channel != other.channel {
+		return t.channel > other.channel
+	}
+	return t.number > other.number
+}
+
+// IsSymbolicRef reports whether ref is resolved to a tag at job creation.
+func IsSymbolicRef(ref string) bool {
+	switch strings.ToLower(strings.TrimSpace(ref)) {
+	case RefLatestRelease, RefLatestBeta, RefLatestPrerelease:
+		return true
+	}
+	return false
+}
+
+// selectSymbolicTag picks the newest tag for ref: latest-release only
+// considers tags without a pre-release suffix, latest-beta also beta and rc
+// tags, latest-prerelease every version tag.
+func selectSymbolicTag(ref string, tags []RepoRef) (string, error) {
+	minChannel := channelStable
+	switch strings.ToLower(strings.TrimSpace(ref)) {
+	case RefLatestBeta:
+		minChannel = channelBeta
+	case RefLatestPrerelease:
+		minChannel = channelAlpha
+	}
+
+	var best versionTag
+	found := false
+	for _, ref := range tags {
+		tag, ok := parseVersionTag(ref.Name)
+		if !ok || tag.channel < minChannel {
+			continue
+		}
+		if !found || tag.newerThan(best) {
+			best = tag
+			found = true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("%w %s", ErrNoMatchingTag, ref)
+	}
+	return best.name, nil
+}
+
+// resolveSymbolicRef returns ref unchanged unless it is a symbolic ref, in
+// which case the repository's tags are listed and the matching tag returned.
+func (m *Manager) resolveSymbolicRef(repoURL string, ref string) (string, error) {
+	if !IsSymbolicRef(ref) {
+		return ref, nil
+	}
+
+	parent := m.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, symbolicRefTimeout)
+	defer cancel()
+
+	output, err := runGitCapture(ctx, "ls-remote", "--tags", "--refs", repoURL)
+	if err != nil {
+		return "", fmt.Errorf("list tags for %s: %w", ref, err)
+	}
+	return selectSymbolicTag(ref, parseLsRemoteRefs(output, "refs/tags/"))
+}
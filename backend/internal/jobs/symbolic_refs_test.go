@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSelectSymbolicTag(t *testing.T) {
+	t.Parallel()
+
+	tags := []RepoRef{
+		{Name: "v2.5.20.4c97351"},
+		{Name: "v2.6.9.f223b8a"},
+		{Name: "v2.6.10.9ce4455"},
+		{Name: "v2.7.0-beta.1"},
+		{Name: "v2.7.0-beta.2"},
+		{Name: "v2.7.1.abc1234-alpha"},
+		{Name: "nightly"},
+	}
+	tests := map[string]string{
+		RefLatestRelease:    "v2.6.10.9ce4455",
+		RefLatestBeta:       "v2.7.0-beta.2",
+		RefLatestPrerelease: "v2.7.1.abc1234-alpha",
+		"Latest-Release":    "v2.6.10.9ce4455",
+	}
+	for ref, want := range tests {
+		got, err := selectSymbolicTag(ref, tags)
+		if err != nil || got != want {
+			t.Fatalf("unexpected tag for %s: got=%q want=%q err=%v", ref, got, want, err)
+		}
+	}
+
+	if _, err := selectSymbolicTag(RefLatestRelease, []RepoRef{{Name: "v2.7.0-rc1"}, {Name: "main"}}); !errors.Is(err, ErrNoMatchingTag) {
+		t.Fatalf("unexpected error without stable tags: got=%v want=%v", err, ErrNoMatchingTag)
+	}
+}
+
+func TestResolveSymbolicRef(t *testing.T) {
+	t.Parallel()
+
+	source := initTestRepository(t, map[string]string{"README.md": "firmware\n"})
+	for _, tag := range []string{"v2.5.20.4c97351", "v2.6.0.1a2b3c4", "v2.7.0-beta"} {
+		if output, err := exec.Command("git", "-C", source, "tag", tag).CombinedOutput(); err != nil {
+			t.Skipf("git tag unavailable: %v (%s)", err, output)
+		}
+	}
+
+	manager := &Manager{}
+	if got, err := manager.resolveSymbolicRef(source, "main"); err != nil || got != "main" {
+		t.Fatalf("expected concrete refs to pass through: got=%q err=%v", got, err)
+	}
+	got, err := manager.resolveSymbolicRef(source, RefLatestRelease)
+	if err != nil || got != "v2.6.0.1a2b3c4" {
+		t.Fatalf("unexpected latest release: got=%q err=%v", got, err)
+	}
+	if got, err = manager.resolveSymbolicRef(source, RefLatestBeta); err != nil || !strings.HasSuffix(got, "-beta") {
+		t.Fatalf("unexpected latest beta: got=%q err=%v", got, err)
+	}
+}
@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/secrets"
+)
+
+// Tag signature statuses recorded in a job's provenance.
+const (
+	TagSignatureVerified  = "verified"
+	TagSignatureUnsigned  = "unsigned"
+	TagSignatureUntrusted = "untrusted"
+	TagSignatureError     = "error"
+)
+
+// ErrTagNotTrusted fails builds of tags without a trusted signature when
+// APP_TAG_SIGNATURE_MODE=require.
+var ErrTagNotTrusted = errors.New("tag signature is not trusted")
+
+var (
+	gpgSignerPattern = regexp.MustCompile(`Good signature from "([^"]+)"`)
+	sshSignerPattern = regexp.MustCompile(`Good "git" signature for (\S+) with`)
+)
+
+// Provenance records where a job's sources came from and how they were
+// verified.
+type Provenance struct {
+	Commit       string        `json:"commit"`
+	Tag          string        `json:"tag,omitempty"`
+	TagSignature *TagSignature `json:"tagSignature,omitempty"`
+}
+
+// TagSignature is the result of verifying the tag a job builds. Format is
+// "gpg" or "ssh"; Signer is the key owner or principal of a good signature.
+type TagSignature struct {
+	Status  string `json:"status"`
+	Format  string `json:"format,omitempty"`
+	Signer  string `json:"signer,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (p *Provenance) clone() *Provenance {
+	if p == nil {
+		return nil
+	}
+	cloned := *p
+	if p.TagSignature != nil {
+		signature := *p.TagSignature
+		cloned.TagSignature = &signature
+	}
+	return &cloned
+}
+
+// verifyTagSignature checks the signature of tag ref in the checkout at
+// repoPath against the trusted keys: the armored or binary keyrings in
+// gpgKeyrings and the git allowed-signers file sshAllowedSigners. It returns nil when ref is
+// not a tag (a branch or commit), since only tags carry signatures.
+func verifyTagSignature(ctx context.Context, repoPath string, ref string, gpgKeyrings []string, sshAllowedSigners string) (*TagSignature, error) {
+	tagRef := "refs/tags/" + ref
+	if _, err := runGitCapture(ctx, "-C", repoPath, "fetch", "--no-tags", "origin", "+"+tagRef+":"+tagRef); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, nil
+	}
+
+	tagged, err := runGitCapture(ctx, "-C", repoPath, "rev-parse", tagRef+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("resolve tag %s: %w", ref, err)
+	}
+	head, err := runGitCapture(ctx, "-C", repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve checkout: %w", err)
+	}
+	if strings.TrimSpace(tagged) != strings.TrimSpace(head) {
+		return &TagSignature{Status: TagSignatureUntrusted, Message: "tag does not point at the built commit"}, nil
+	}
+
+	objectType, err := runGitCapture(ctx, "-C", repoPath, "cat-file", "-t", tagRef)
+	if err != nil {
+		return nil, fmt.Errorf("inspect tag %s: %w", ref, err)
+	}
+	if strings.TrimSpace(objectType) != "tag" {
+		return &TagSignature{Status: TagSignatureUnsigned, Message: "lightweight tag"}, nil
+	}
+	object, err := runGitCapture(ctx, "-C", repoPath, "cat-file", "tag", tagRef)
+	if err != nil {
+		return nil, fmt.Errorf("read tag %s: %w", ref, err)
+	}
+
+	signature := &TagSignature{}
+	switch {
+	case strings.Contains(object, "-----BEGIN PGP SIGNATURE-----"):
+		signature.Format = "gpg"
+	case strings.Contains(object, "-----BEGIN SSH SIGNATURE-----"):
+		signature.Format = "ssh"
+	default:
+		return &TagSignature{Status: TagSignatureUnsigned, Message: "annotated tag without signature"}, nil
+	}
+
+	args := []string{"-C", repoPath}
+	if sshAllowedSigners != "" {
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+sshAllowedSigners)
+	}
+	args = append(args, "verify-tag", tagRef)
+	cmd := exec.CommandContext(ctx, "git", args...)
+
+	// GPG only knows the configured keys: a throwaway home holds the
+	// imported keyring, so signatures by any other key fail.
+	gnupgHome, err := os.MkdirTemp(filepath.Dir(repoPath), "gnupg-")
+	if err != nil {
+		return nil, fmt.Errorf("create gpg home: %w", err)
+	}
+	defer os.RemoveAll(gnupgHome)
+	if signature.Format == "gpg" && len(gpgKeyrings) > 0 {
+		importArgs := append([]string{"--batch", "--homedir", gnupgHome, "--import"}, gpgKeyrings...)
+		importCmd := exec.CommandContext(ctx, "gpg", importArgs...)
+		if output, err := importCmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("import gpg keyring: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	cmd.Env = append(os.Environ(), "GNUPGHOME="+gnupgHome)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	text := string(output)
+	if err != nil {
+		signature.Status = TagSignatureUntrusted
+		signature.Message = lastOutputLine(text)
+		return signature, nil
+	}
+
+	signature.Status = TagSignatureVerified
+	pattern := gpgSignerPattern
+	if signature.Format == "ssh" {
+		pattern = sshSignerPattern
+	}
+	if match := pattern.FindStringSubmatch(text); match != nil {
+		signature.Signer = match[1]
+	}
+	return signature, nil
+}
+
+func lastOutputLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// verifyJobTag records the tag signature of job's ref in its provenance and
+// reports an error when the configured mode requires a trusted signature.
+func (m *Manager) verifyJobTag(ctx context.Context, job *Job, repoPath string, commit string) error {
+	provenance := Provenance{Commit: commit}
+	gpgKeyrings, sshAllowedSigners, cleanup, err := m.trustedSigningKeys(filepath.Dir(repoPath))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	signature, err := verifyTagSignature(ctx, repoPath, job.Ref, gpgKeyrings, sshAllowedSigners)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		signature = &TagSignature{Status: TagSignatureError, Message: err.Error()}
+	}
+	if signature != nil {
+		provenance.Tag = job.Ref
+		provenance.TagSignature = signature
+		detail := signature.Status
+		if signature.Signer != "" {
+			detail += " (" + signature.Signer + ")"
+		} else if signature.Message != "" {
+			detail += ": " + signature.Message
+		}
+		job.appendLog(m.cfg.MaxLogLines, fmt.Sprintf("tag %s signature: %s", job.Ref, detail))
+	}
+	job.setProvenance(&provenance)
+
+	if m.cfg.TagSignatureMode == "require" && signature != nil && signature.Status != TagSignatureVerified {
+		return fmt.Errorf("%w: %s is %s", ErrTagNotTrusted, job.Ref, signature.Status)
+	}
+	return nil
+}
+
+func (j *Job) setProvenance(provenance *Provenance) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Provenance = provenance.clone()
+}
+
+// trustedSigningKeys returns the keyrings and allowed-signers file to verify
+// tags against: the configured files plus the signing keys in the credential
+// store, which are written to temporary files in dir. cleanup removes them.
+func (m *Manager) trustedSigningKeys(dir string) ([]string, string, func(), error) {
+	var gpgKeyrings []string
+	if m.cfg.TagGPGKeyring != "" {
+		gpgKeyrings = append(gpgKeyrings, m.cfg.TagGPGKeyring)
+	}
+	sshAllowedSigners := m.cfg.TagSSHAllowedSigners
+
+	keys, err := m.secrets.Secrets(secrets.KindSigningKey)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("read signing keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return gpgKeyrings, sshAllowedSigners, func() {}, nil
+	}
+
+	keyDir, err := os.MkdirTemp(dir, "signing-keys-")
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("create signing key directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(keyDir) }
+
+	var signers strings.Builder
+	if sshAllowedSigners != "" {
+		content, err := os.ReadFile(sshAllowedSigners)
+		if err != nil {
+			cleanup()
+			return nil, "", nil, fmt.Errorf("read allowed signers: %w", err)
+		}
+		signers.Write(content)
+		signers.WriteString("\n")
+	}
+	for _, key := range keys {
+		if secrets.SigningKeyFormat(key.Value) == "ssh" {
+			signers.WriteString(strings.TrimSpace(key.Value) + "\n")
+			continue
+		}
+		path := filepath.Join(keyDir, key.Name+".asc")
+		if err := os.WriteFile(path, []byte(key.Value), 0o600); err != nil {
+			cleanup()
+			return nil, "", nil, fmt.Errorf("write signing key %s: %w", key.Name, err)
+		}
+		gpgKeyrings = append(gpgKeyrings, path)
+	}
+	if signers.Len() > 0 {
+		sshAllowedSigners = filepath.Join(keyDir, "allowed_signers")
+		if err := os.WriteFile(sshAllowedSigners, []byte(signers.String()), 0o600); err != nil {
+			cleanup()
+			return nil, "", nil, fmt.Errorf("write allowed signers: %w", err)
+		}
+	}
+	return gpgKeyrings, sshAllowedSigners, cleanup, nil
+}
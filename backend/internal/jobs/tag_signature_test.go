@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyTagSignatureSSH(t *testing.T) {
+	t.Parallel()
+
+	source := initTestRepository(t, map[string]string{"README.md": "firmware\n"})
+	keyDir := t.TempDir()
+	keyPath := filepath.Join(keyDir, "signing")
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "release@example.com", "-f", keyPath).CombinedOutput(); err != nil {
+		t.Skipf("ssh-keygen unavailable: %v (%s)", err, output)
+	}
+	publicKey, err := os.ReadFile(keyPath + ".pub")
+	if err != nil {
+		t.Fatalf("read public key: %v", err)
+	}
+	allowedSigners := filepath.Join(keyDir, "allowed_signers")
+	if err := os.WriteFile(allowedSigners, []byte("release@example.com "+string(publicKey)), 0o644); err != nil {
+		t.Fatalf("write allowed signers: %v", err)
+	}
+
+	commands := [][]string{
+		{"-c", "user.name=test", "-c", "user.email=release@example.com", "-c", "gpg.format=ssh", "-c", "user.signingkey=" + keyPath, "tag", "-s", "-m", "signed", "v1.0.0"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "tag", "-a", "-m", "annotated", "v1.0.1"},
+		{"tag", "v1.0.2"},
+	}
+	for _, args := range commands {
+		cmd := exec.Command("git", append([]string{"-C", source}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %s unavailable: %v (%s)", strings.Join(args, " "), err, output)
+		}
+	}
+
+	tests := []struct {
+		ref     string
+		signers string
+		want    string
+	}{
+		{ref: "v1.0.0", signers: allowedSigners, want: TagSignatureVerified},
+		{ref: "v1.0.0", signers: "", want: TagSignatureUntrusted},
+		{ref: "v1.0.1", signers: allowedSigners, want: TagSignatureUnsigned},
+		{ref: "v1.0.2", signers: allowedSigners, want: TagSignatureUnsigned},
+		{ref: "main", signers: allowedSigners, want: ""},
+	}
+	for _, tt := range tests {
+		repoPath := filepath.Join(t.TempDir(), "repo")
+		if err := cloneRepository(context.Background(), source, tt.ref, repoPath, cloneStrategy{}, nil); err != nil {
+			t.Fatalf("clone %s: %v", tt.ref, err)
+		}
+		signature, err := verifyTagSignature(context.Background(), repoPath, tt.ref, nil, tt.signers)
+		if err != nil {
+			t.Fatalf("verify %s: %v", tt.ref, err)
+		}
+		got := ""
+		if signature != nil {
+			got = signature.Status
+		}
+		if got != tt.want {
+			t.Fatalf("unexpected status for %s (signers=%q): got=%q want=%q (%+v)", tt.ref, tt.signers, got, tt.want, signature)
+		}
+		if got == TagSignatureVerified && (signature.Format != "ssh" || signature.Signer != "release@example.com") {
+			t.Fatalf("unexpected verified signature: %+v", signature)
+		}
+	}
+}
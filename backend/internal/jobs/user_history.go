@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"sort"
+	"time"
+)
+
+// UserJob is one entry of a signed-in user's build history. Retained
+// reports whether the job is still held in memory, i.e. whether its
+// artifacts and logs can still be fetched through the job API. Node names
+// the cluster node that built a job found in the shared job registry.
+type UserJob struct {
+	JobID      string     `json:"jobId"`
+	RepoURL    string     `json:"repoUrl"`
+	Ref        string     `json:"ref,omitempty"`
+	Device     string     `json:"device"`
+	Status     Status     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Retained   bool       `json:"retained"`
+	Node       string     `json:"node,omitempty"`
+}
+
+// UserJobs returns the jobs created by userID, newest first: the retained
+// jobs, the persisted build logs of jobs that have expired and, with the
+// job registry, the jobs built by other nodes. limit <= 0 returns all.
+func (m *Manager) UserJobs(userID string, limit int) ([]UserJob, error) {
+	result := make([]UserJob, 0)
+	if userID == "" {
+		return result, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, state := range m.ListJobs() {
+		if state.UserID != userID {
+			continue
+		}
+		seen[state.ID] = true
+		result = append(result, UserJob{
+			JobID:      state.ID,
+			RepoURL:    state.RepoURL,
+			Ref:        state.Ref,
+			Device:     state.Device,
+			Status:     state.Status,
+			CreatedAt:  state.CreatedAt,
+			FinishedAt: state.FinishedAt,
+			Error:      state.Error,
+			Retained:   true,
+		})
+	}
+
+	if m.buildLogs != nil {
+		entries, err := m.buildLogs.List(0)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.UserID != userID || seen[entry.JobID] {
+				continue
+			}
+			result = append(result, UserJob{
+				JobID:      entry.JobID,
+				RepoURL:    entry.RepoURL,
+				Ref:        entry.Ref,
+				Device:     entry.Device,
+				Status:     Status(entry.Status),
+				CreatedAt:  entry.CreatedAt,
+				FinishedAt: entry.FinishedAt,
+				Error:      entry.Error,
+			})
+		}
+	}
+
+	remote, err := m.remoteUserJobs(userID, limit)
+	if err != nil {
+		// The local history is still accurate; only other nodes are missing.
+		m.logger.Warn("user jobs: remote history unavailable", "error", err)
+	}
+	for _, entry := range remote {
+		result = append(result, UserJob{
+			JobID:      entry.JobID,
+			RepoURL:    entry.RepoURL,
+			Ref:        entry.Ref,
+			Device:     entry.Device,
+			Status:     entry.Status,
+			CreatedAt:  entry.CreatedAt,
+			FinishedAt: entry.FinishedAt,
+			Error:      entry.Error,
+			Node:       entry.Node,
+		})
+	}
+
+	sort.SliceStable(result, func(i int, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
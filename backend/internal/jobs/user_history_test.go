@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/buildlogs"
+)
+
+func TestUserJobs(t *testing.T) {
+	t.Parallel()
+
+	const repoURL = "https://github.com/example/repo.git"
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	job := func(id string, userID string, createdAt time.Time) *Job {
+		created := newJob(id, repoURL, "main", "tbeam", BuildOptions{}, "/tmp/"+id, createdAt, "")
+		created.UserID = userID
+		return created
+	}
+
+	store := buildlogs.NewStore(t.TempDir())
+	for _, log := range []buildlogs.BuildLog{
+		{JobID: "expired", RepoURL: repoURL, Device: "rak4631", UserID: "github:1", Status: "success", CreatedAt: now.Add(-time.Hour)},
+		{JobID: "retained", RepoURL: repoURL, Device: "tbeam", UserID: "github:1", Status: "success", CreatedAt: now},
+		{JobID: "someone-else", RepoURL: repoURL, Device: "tbeam", UserID: "github:2", Status: "success", CreatedAt: now},
+		{JobID: "anonymous", RepoURL: repoURL, Device: "tbeam", Status: "success", CreatedAt: now},
+	} {
+		if err := store.Save(log); err != nil {
+			t.Fatalf("save build log: %v", err)
+		}
+	}
+
+	mgr := &Manager{buildLogs: store, jobs: map[string]*Job{
+		"retained": job("retained", "github:1", now),
+		"queued":   job("queued", "github:1", now.Add(time.Minute)),
+		"other":    job("other", "github:2", now.Add(time.Minute)),
+	}}
+
+	history, err := mgr.UserJobs("github:1", 0)
+	if err != nil {
+		t.Fatalf("user jobs: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 jobs, got %+v", history)
+	}
+	for index, want := range []struct {
+		id       string
+		retained bool
+	}{{"queued", true}, {"retained", true}, {"expired", false}} {
+		if history[index].JobID != want.id || history[index].Retained != want.retained {
+			t.Fatalf("entry %d: want %s (retained=%v), got %+v", index, want.id, want.retained, history[index])
+		}
+	}
+
+	limited, err := mgr.UserJobs("github:1", 1)
+	if err != nil || len(limited) != 1 || limited[0].JobID != "queued" {
+		t.Fatalf("expected the limit to keep the newest job: %+v, %v", limited, err)
+	}
+
+	anonymous, err := mgr.UserJobs("", 0)
+	if err != nil || len(anonymous) != 0 {
+		t.Fatalf("expected no history without a user: %+v, %v", anonymous, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
 	"regexp"
 	"strings"
 )
@@ -13,13 +14,41 @@ var (
 	devicePattern      = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
 	devicePathPattern  = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
 	refPattern         = regexp.MustCompile(`^[A-Za-z0-9._/-]{1,128}$`)
+	repoShorthand      = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*/[A-Za-z0-9._-]+$`)
+	hostRepoShorthand  = regexp.MustCompile(`^[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)+(/[A-Za-z0-9._-]+){2,}$`)
 )
 
 const (
 	maxBuildOptionItems  = 128
 	maxBuildOptionLength = 512
+	maxArtifactPatterns  = 16
 )
 
+// NormalizeRepoURL canonicalizes a repository given by the user: "owner/name"
+// becomes a GitHub https URL and "host/owner/name" an https URL on that
+// host. Trailing slashes and a ".git" suffix are dropped, as repoKey does,
+// so both spellings share caches, mirrors and discovery. Anything else is
+// returned trimmed and left to ValidateRepoURL.
+func NormalizeRepoURL(raw string) string {
+	value := trimRepoSuffix(strings.TrimSpace(raw))
+	if strings.Contains(value, "..") {
+		return value
+	}
+	switch {
+	case repoShorthand.MatchString(value) && !strings.Contains(strings.SplitN(value, "/", 2)[0], "."):
+		return "https://github.com/" + value
+	case hostRepoShorthand.MatchString(value):
+		return "https://" + value
+	}
+	return value
+}
+
+// trimRepoSuffix drops the trailing slashes and ".git" that git hosts
+// accept on any repository URL.
+func trimRepoSuffix(value string) string {
+	return strings.TrimSuffix(strings.TrimRight(value, "/"), ".git")
+}
+
 func ValidateRepoURL(raw string) error {
 	value := strings.TrimSpace(raw)
 	if value == "" {
@@ -119,12 +148,48 @@ func NormalizeBuildOptions(raw BuildOptions) (BuildOptions, error) {
 		}
 	}
 
+	artifactPatterns, err := normalizeBuildOptionValues("artifactPatterns", raw.ArtifactPatterns)
+	if err != nil {
+		return BuildOptions{}, err
+	}
+	if len(artifactPatterns) > maxArtifactPatterns {
+		return BuildOptions{}, fmt.Errorf("artifactPatterns supports up to %d entries", maxArtifactPatterns)
+	}
+	for _, pattern := range artifactPatterns {
+		if err := validateArtifactPattern(pattern); err != nil {
+			return BuildOptions{}, err
+		}
+	}
+
+	patch, err := normalizePatch(raw.Patch)
+	if err != nil {
+		return BuildOptions{}, err
+	}
+
+	submodules, err := normalizeSubmoduleOverrides(raw.Submodules)
+	if err != nil {
+		return BuildOptions{}, err
+	}
+
 	return BuildOptions{
-		BuildFlags: buildFlags,
-		LibDeps:    libDeps,
+		BuildFlags:       buildFlags,
+		LibDeps:          libDeps,
+		ArtifactPatterns: artifactPatterns,
+		Patch:            patch,
+		Submodules:       submodules,
 	}, nil
 }
 
+func validateArtifactPattern(pattern string) error {
+	if strings.HasPrefix(pattern, "/") || strings.Contains(pattern, "..") || strings.Contains(pattern, "\\") {
+		return fmt.Errorf("artifactPatterns entry %q must be a relative path pattern", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("artifactPatterns entry %q is not a valid glob", pattern)
+	}
+	return nil
+}
+
 func normalizeBuildOptionValues(field string, items []string) ([]string, error) {
 	if len(items) > maxBuildOptionItems {
 		return nil, fmt.Errorf("%s supports up to %d entries", field, maxBuildOptionItems)
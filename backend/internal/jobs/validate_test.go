@@ -100,4 +100,54 @@ func TestNormalizeBuildOptions(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected validation error for multi-line value")
 	}
+
+	options, err = NormalizeBuildOptions(BuildOptions{ArtifactPatterns: []string{" *.map ", "littlefs/*.bin"}})
+	if err != nil {
+		t.Fatalf("unexpected artifact pattern error: %v", err)
+	}
+	if len(options.ArtifactPatterns) != 2 || options.ArtifactPatterns[0] != "*.map" {
+		t.Fatalf("unexpected artifact patterns: %v", options.ArtifactPatterns)
+	}
+
+	for _, pattern := range []string{"../secrets/*", "/etc/*", "[", "dir\\*.bin"} {
+		if _, err := NormalizeBuildOptions(BuildOptions{ArtifactPatterns: []string{pattern}}); err == nil {
+			t.Fatalf("expected validation error for artifact pattern %q", pattern)
+		}
+	}
+}
+
+func TestNormalizeRepoURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"meshtastic/firmware":                        "https://github.com/meshtastic/firmware",
+		" meshtastic/firmware/ ":                     "https://github.com/meshtastic/firmware",
+		"github.com/user/fork":                       "https://github.com/user/fork",
+		"gitlab.com/group/sub/firmware.git":          "https://gitlab.com/group/sub/firmware",
+		"https://github.com/meshtastic/firmware.git": "https://github.com/meshtastic/firmware",
+		"https://github.com/meshtastic/firmware/":    "https://github.com/meshtastic/firmware",
+		"git@github.com:meshtastic/firmware.git":     "git@github.com:meshtastic/firmware",
+		"github.com/meshtastic":                      "github.com/meshtastic",
+		"../../firmware":                             "../../firmware",
+		"meshtastic/..":                              "meshtastic/..",
+	}
+	for raw, want := range tests {
+		if got := NormalizeRepoURL(raw); got != want {
+			t.Fatalf("unexpected normalized URL for %q: got=%q want=%q", raw, got, want)
+		}
+	}
+
+	// Both spellings of a repository must share cache keys and policies.
+	short, full := NormalizeRepoURL("meshtastic/firmware"), NormalizeRepoURL("https://github.com/meshtastic/firmware.git")
+	if short != full || repoKey(short) != repoKey(full) || repoKey(full) != "github.com/meshtastic/firmware" {
+		t.Fatalf("expected one canonical repository, got %q (%q) and %q (%q)", short, repoKey(short), full, repoKey(full))
+	}
+	shortKey, err := buildFirmwareCacheKey(short, "0123456789abcdef0123456789abcdef01234567", "tbeam", BuildOptions{})
+	if err != nil {
+		t.Fatalf("cache key: %v", err)
+	}
+	fullKey, err := buildFirmwareCacheKey(full, "0123456789abcdef0123456789abcdef01234567", "tbeam", BuildOptions{})
+	if err != nil || shortKey != fullKey {
+		t.Fatalf("expected both spellings to share the cache key, got %q and %q (%v)", shortKey, fullKey, err)
+	}
 }
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"crypto/subtle"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/notify"
+)
+
+// JobOwner identifies who created a job and who may read it.
+type JobOwner struct {
+	ClientIP string
+	// UserID is the signed-in creator; the job is recorded in their history.
+	UserID string
+	// Private jobs are only visible to UserID and to callers presenting
+	// AccessToken; public jobs to anyone who knows the ID.
+	Private     bool
+	AccessToken string
+	// Anonymous marks jobs requested without an account, which
+	// APP_MODERATE_ANONYMOUS_BUILDS holds for operator approval.
+	Anonymous bool
+	// Trusted marks jobs requested by the operator's own integrations, such
+	// as signed git webhooks. Only their git commands use the stored git
+	// credentials, and they are always private.
+	Trusted bool
+	// Notify adds recipients for the outcome of the job when the
+	// deployment allows per-job notifications.
+	Notify notify.Target
+}
+
+// VisibleTo reports whether a caller signed in as userID, presenting
+// accessToken, may read the job. Either may be empty.
+func (s State) VisibleTo(userID string, accessToken string) bool {
+	if !s.Private {
+		return true
+	}
+	if userID != "" && userID == s.UserID {
+		return true
+	}
+	return accessToken != "" && s.AccessToken != "" &&
+		subtle.ConstantTimeCompare([]byte(accessToken), []byte(s.AccessToken)) == 1
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import "testing"
+
+func TestStateVisibleTo(t *testing.T) {
+	t.Parallel()
+
+	public := State{UserID: "github:1"}
+	private := State{UserID: "github:1", Private: true, AccessToken: "secret"}
+	anonymous := State{Private: true, AccessToken: "secret"}
+
+	for name, test := range map[string]struct {
+		state  State
+		userID string
+		token  string
+		want   bool
+	}{
+		"public":            {public, "", "", true},
+		"private anonymous": {private, "", "", false},
+		"private owner":     {private, "github:1", "", true},
+		"private other":     {private, "github:2", "", false},
+		"private token":     {private, "", "secret", true},
+		"private bad token": {private, "", "guess", false},
+		"no owner no token": {anonymous, "", "", false},
+		"no owner token":    {anonymous, "", "secret", true},
+	} {
+		if got := test.state.VisibleTo(test.userID, test.token); got != test.want {
+			t.Errorf("%s: VisibleTo = %v, want %v", name, got, test.want)
+		}
+	}
+}
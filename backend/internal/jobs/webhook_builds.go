@@ -0,0 +1,20 @@
+package jobs
+
+import "path"
+
+// WebhookDevices returns the devices to build when a git webhook reports a
+// new commit or release of repoURL, in configuration order.
+func (m *Manager) WebhookDevices(repoURL string) []string {
+	key := repoKey(repoURL)
+	devices := make([]string, 0, len(m.cfg.GitWebhookBuilds))
+	seen := make(map[string]bool, len(m.cfg.GitWebhookBuilds))
+	for _, build := range m.cfg.GitWebhookBuilds {
+		matched, err := path.Match(repoKey(build.Repo), key)
+		if err != nil || !matched || seen[build.Device] {
+			continue
+		}
+		seen[build.Device] = true
+		devices = append(devices, build.Device)
+	}
+	return devices
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skrashevich/meshtastic-firmware-builder/backend/internal/config"
+)
+
+func TestWebhookDevices(t *testing.T) {
+	t.Parallel()
+
+	manager := &Manager{cfg: config.Config{GitWebhookBuilds: []config.WebhookBuild{
+		{Repo: "github.com/meshtastic/firmware", Device: "tbeam"},
+		{Repo: "github.com/meshtastic/*", Device: "heltec-v3"},
+		{Repo: "github.com/acme/firmware", Device: "rak4631"},
+		{Repo: "github.com/meshtastic/*", Device: "tbeam"},
+	}}}
+
+	got := manager.WebhookDevices("https://github.com/Meshtastic/firmware.git")
+	if want := []string{"tbeam", "heltec-v3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected webhook devices: got=%v want=%v", got, want)
+	}
+	if got := manager.WebhookDevices("https://gitlab.com/other/repo.git"); len(got) != 0 {
+		t.Fatalf("expected no devices for unconfigured repo: got=%v", got)
+	}
+}
@@ -0,0 +1,81 @@
+// Package logging builds the structured logger of the backend: text or JSON
+// records with a level that can be changed at runtime, plus fields such as
+// the request ID carried by a context.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// Formats accepted by APP_LOG_FORMAT.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// level is shared by every logger built by New, so SetLevel applies to the
+// whole process.
+var level slog.LevelVar
+
+// New returns a logger writing format records to w at the current level.
+func New(w io.Writer, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: &level, ReplaceAttr: utcTime}
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// utcTime writes record times in UTC whatever the local time zone.
+func utcTime(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.TimeKey && len(groups) == 0 && attr.Value.Kind() == slog.KindTime {
+		attr.Value = slog.TimeValue(attr.Value.Time().UTC())
+	}
+	return attr
+}
+
+// Level returns the minimum level of the loggers built by New.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes the minimum level of the loggers built by New.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+type attrsKey struct{}
+
+// With returns a copy of ctx whose records, when logged with a *Context
+// method, carry attrs in addition to the ones already in ctx.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	combined := make([]slog.Attr, 0, len(existing)+len(attrs))
+	combined = append(append(combined, existing...), attrs...)
+	return context.WithValue(ctx, attrsKey{}, combined)
+}
+
+// contextHandler adds the attributes stored by With to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewJSONWithContext(t *testing.T) {
+	var output bytes.Buffer
+	logger := New(&output, FormatJSON)
+	ctx := With(context.Background(), slog.String("requestId", "req-1"))
+	ctx = With(ctx, slog.String("client", "203.0.113.9"))
+
+	logger.With("component", "test").InfoContext(ctx, "job created", "jobId", "job-1")
+	var record map[string]any
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", output.String(), err)
+	}
+	for key, want := range map[string]string{
+		"level": "INFO", "msg": "job created", "jobId": "job-1", "requestId": "req-1", "client": "203.0.113.9", "component": "test",
+	} {
+		if record[key] != want {
+			t.Fatalf("expected %s=%q, got %v in %s", key, want, record[key], output.String())
+		}
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var output bytes.Buffer
+	logger := New(&output, FormatText)
+	t.Cleanup(func() { SetLevel(slog.LevelInfo) })
+
+	logger.Debug("hidden")
+	SetLevel(slog.LevelDebug)
+	if Level() != slog.LevelDebug {
+		t.Fatalf("expected the debug level, got %v", Level())
+	}
+	logger.Debug("shown", "attempt", 2)
+	if text := output.String(); strings.Contains(text, "hidden") || !strings.Contains(text, "level=DEBUG msg=shown attempt=2") {
+		t.Fatalf("unexpected output %q", text)
+	}
+}
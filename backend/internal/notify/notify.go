@@ -0,0 +1,313 @@
+// Package notify tells chat channels and mailboxes when a build finishes.
+// Deliveries are queued and sent by one goroutine, so a slow or unreachable
+// service never delays a build; failures are logged and not retried.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Outcomes a notification can be sent for.
+const (
+	OnSuccess   = "success"
+	OnFailure   = "failure"
+	OnCancelled = "cancelled"
+)
+
+const (
+	queueSize       = 256
+	deliveryTimeout = 15 * time.Second
+	flushTimeout    = 5 * time.Second
+	// maxErrorLength keeps messages within the limits of every channel;
+	// Discord accepts 2000 characters.
+	maxErrorLength = 1000
+
+	defaultTelegramAPIURL = "https://api.telegram.org"
+)
+
+// ErrPerJobDisabled is returned by Check when a job asks for its own
+// recipients but the deployment does not allow them.
+var ErrPerJobDisabled = errors.New("per-job notifications are disabled")
+
+var (
+	telegramChatPattern = regexp.MustCompile(`^(-?[0-9]{1,20}|@[A-Za-z][A-Za-z0-9_]{4,31})$`)
+	// Per-job webhooks are limited to the chat services, so a build request
+	// cannot make the server post to arbitrary URLs.
+	slackWebhookPrefixes   = []string{"https://hooks.slack.com/"}
+	discordWebhookPrefixes = []string{"https://discord.com/api/webhooks/", "https://discordapp.com/api/webhooks/"}
+)
+
+// Event is a finished build. Outcome is OnSuccess, OnFailure or
+// OnCancelled.
+type Event struct {
+	JobID    string
+	Node     string
+	RepoURL  string
+	Ref      string
+	Device   string
+	Outcome  string
+	Error    string
+	Duration time.Duration
+}
+
+// Target is where the notifications of one job go, in addition to the
+// deployment channels. On selects the outcomes; empty uses the deployment's
+// selection. Email and TelegramChatID use the deployment's SMTP server and
+// bot.
+type Target struct {
+	On                []string `json:"on,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	TelegramChatID    string   `json:"telegramChatId,omitempty"`
+	SlackWebhookURL   string   `json:"slackWebhookUrl,omitempty"`
+	DiscordWebhookURL string   `json:"discordWebhookUrl,omitempty"`
+}
+
+// IsEmpty reports whether the target names no recipient.
+func (t Target) IsEmpty() bool {
+	return t.Email == "" && t.TelegramChatID == "" && t.SlackWebhookURL == "" && t.DiscordWebhookURL == ""
+}
+
+// Options configures the deployment channels. A channel is enabled by its
+// URL, chat or recipients; SMTP settings and the Telegram bot token are also
+// used for per-job recipients when PerJob is set.
+type Options struct {
+	On                []string
+	PerJob            bool
+	SlackWebhookURL   string
+	DiscordWebhookURL string
+	TelegramBotToken  string
+	TelegramChatID    string
+	// TelegramAPIURL defaults to https://api.telegram.org.
+	TelegramAPIURL string
+	SMTPAddr       string
+	SMTPUsername   string
+	SMTPPassword   string
+	SMTPFrom       string
+	EmailTo        []string
+}
+
+// Notifier sends build outcomes to the configured channels.
+type Notifier struct {
+	opts     Options
+	client   *http.Client
+	channels []sender
+	queue    chan delivery
+	logger   *slog.Logger
+}
+
+type sender interface {
+	channel() string
+	send(ctx context.Context, event Event) error
+}
+
+type delivery struct {
+	sender sender
+	event  Event
+}
+
+// New returns nil when no deployment channel is configured and per-job
+// recipients are not allowed; a nil *Notifier sends nothing.
+func New(opts Options, logger *slog.Logger) *Notifier {
+	if opts.TelegramAPIURL == "" {
+		opts.TelegramAPIURL = defaultTelegramAPIURL
+	}
+	n := &Notifier{
+		opts:   opts,
+		client: &http.Client{Timeout: deliveryTimeout},
+		queue:  make(chan delivery, queueSize),
+		logger: logger,
+	}
+	if opts.SlackWebhookURL != "" {
+		n.channels = append(n.channels, n.slack(opts.SlackWebhookURL))
+	}
+	if opts.DiscordWebhookURL != "" {
+		n.channels = append(n.channels, n.discord(opts.DiscordWebhookURL))
+	}
+	if opts.TelegramBotToken != "" && opts.TelegramChatID != "" {
+		n.channels = append(n.channels, n.telegram(opts.TelegramChatID))
+	}
+	if opts.SMTPAddr != "" && len(opts.EmailTo) > 0 {
+		n.channels = append(n.channels, n.email(opts.EmailTo))
+	}
+	if len(n.channels) == 0 && !opts.PerJob {
+		return nil
+	}
+	return n
+}
+
+// Check validates the recipients a job asks for.
+func (n *Notifier) Check(target Target) error {
+	if target.IsEmpty() {
+		if len(target.On) > 0 {
+			return errors.New("notify: no recipient given")
+		}
+		return nil
+	}
+	if n == nil || !n.opts.PerJob {
+		return ErrPerJobDisabled
+	}
+	for _, outcome := range target.On {
+		if !ValidOutcome(outcome) {
+			return fmt.Errorf("notify: unknown outcome %q, expected %s, %s or %s", outcome, OnSuccess, OnFailure, OnCancelled)
+		}
+	}
+	if target.Email != "" {
+		if n.opts.SMTPAddr == "" {
+			return errors.New("notify: email notifications are not configured")
+		}
+		if address, err := mail.ParseAddress(target.Email); err != nil || address.Name != "" || address.Address != target.Email {
+			return fmt.Errorf("notify: invalid email address %q", target.Email)
+		}
+	}
+	if target.TelegramChatID != "" {
+		if n.opts.TelegramBotToken == "" {
+			return errors.New("notify: telegram notifications are not configured")
+		}
+		if !telegramChatPattern.MatchString(target.TelegramChatID) {
+			return fmt.Errorf("notify: invalid telegram chat %q", target.TelegramChatID)
+		}
+	}
+	if target.SlackWebhookURL != "" && !validWebhookURL(target.SlackWebhookURL, slackWebhookPrefixes) {
+		return errors.New("notify: slackWebhookUrl must be a https://hooks.slack.com/ URL")
+	}
+	if target.DiscordWebhookURL != "" && !validWebhookURL(target.DiscordWebhookURL, discordWebhookPrefixes) {
+		return errors.New("notify: discordWebhookUrl must be a https://discord.com/api/webhooks/ URL")
+	}
+	return nil
+}
+
+// ValidOutcome reports whether outcome is one of the outcomes notifications
+// can be selected for.
+func ValidOutcome(outcome string) bool {
+	return outcome == OnSuccess || outcome == OnFailure || outcome == OnCancelled
+}
+
+func validWebhookURL(raw string, prefixes []string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.User != nil || parsed.Port() != "" {
+		return false
+	}
+	return slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(raw, prefix) })
+}
+
+// Notify queues event for the deployment channels and for target, each when
+// it selected the outcome. Deliveries are dropped with a log line when the
+// queue is full.
+func (n *Notifier) Notify(event Event, target Target) {
+	if n == nil {
+		return
+	}
+	var senders []sender
+	if slices.Contains(n.opts.On, event.Outcome) {
+		senders = append(senders, n.channels...)
+	}
+	on := target.On
+	if len(on) == 0 {
+		on = n.opts.On
+	}
+	if n.opts.PerJob && slices.Contains(on, event.Outcome) {
+		senders = append(senders, n.targetSenders(target)...)
+	}
+	for _, sender := range senders {
+		select {
+		case n.queue <- delivery{sender: sender, event: event}:
+		default:
+			n.logger.Warn("notify: queue full, dropped notification", "channel", sender.channel(), "jobId", event.JobID)
+		}
+	}
+}
+
+func (n *Notifier) targetSenders(target Target) []sender {
+	var senders []sender
+	if target.SlackWebhookURL != "" {
+		senders = append(senders, n.slack(target.SlackWebhookURL))
+	}
+	if target.DiscordWebhookURL != "" {
+		senders = append(senders, n.discord(target.DiscordWebhookURL))
+	}
+	if target.TelegramChatID != "" && n.opts.TelegramBotToken != "" {
+		senders = append(senders, n.telegram(target.TelegramChatID))
+	}
+	if target.Email != "" && n.opts.SMTPAddr != "" {
+		senders = append(senders, n.email([]string{target.Email}))
+	}
+	return senders
+}
+
+// Run sends queued notifications until ctx ends, then for a few seconds
+// more, so the outcomes of builds finished during a shutdown still go out.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case item := <-n.queue:
+			n.deliver(context.Background(), item)
+		case <-ctx.Done():
+			deadline, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			defer cancel()
+			for {
+				select {
+				case item := <-n.queue:
+					n.deliver(deadline, item)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (n *Notifier) deliver(ctx context.Context, item delivery) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	if err := item.sender.send(ctx, item.event); err != nil {
+		n.logger.Warn("notify: delivery failed", "channel", item.sender.channel(), "jobId", item.event.JobID, "error", err)
+	}
+}
+
+// title is the one-line summary of event, also used as the email subject.
+func (e Event) title() string {
+	verb := "Build failed"
+	switch e.Outcome {
+	case OnSuccess:
+		verb = "Build succeeded"
+	case OnCancelled:
+		verb = "Build cancelled"
+	}
+	return fmt.Sprintf("%s: %s", verb, e.Device)
+}
+
+// text is the plain-text body shared by every channel.
+func (e Event) text() string {
+	lines := []string{e.title()}
+	source := e.RepoURL
+	if e.Ref != "" {
+		source += " @ " + e.Ref
+	}
+	lines = append(lines, "Repository: "+source)
+	job := "Job: " + e.JobID
+	if e.Node != "" {
+		job += " on " + e.Node
+	}
+	lines = append(lines, job)
+	if e.Duration > 0 {
+		lines = append(lines, "Duration: "+e.Duration.Round(time.Second).String())
+	}
+	if e.Error != "" {
+		message := e.Error
+		if len(message) > maxErrorLength {
+			message = strings.ToValidUTF8(message[:maxErrorLength], "") + "…"
+		}
+		lines = append(lines, "Error: "+message)
+	}
+	return strings.Join(lines, "\n")
+}
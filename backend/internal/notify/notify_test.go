@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts mail without authentication or TLS and sends each
+// message, with its recipients, to messages.
+func fakeSMTP(t *testing.T, messages chan<- string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+				reply("220 localhost ESMTP")
+				var message strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+						reply("250 localhost")
+					case strings.HasPrefix(command, "RCPT TO:"):
+						message.WriteString(strings.TrimSpace(line) + "\n")
+						reply("250 OK")
+					case command == "DATA":
+						reply("354 go ahead")
+						for {
+							line, err := reader.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if line == ".\r\n" {
+								break
+							}
+							message.WriteString(line)
+						}
+						messages <- message.String()
+						message.Reset()
+						reply("250 OK")
+					case command == "QUIT":
+						reply("221 bye")
+						return
+					default:
+						reply("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestNotifierDeliversToChannels(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	posts := make(map[string]map[string]any)
+	received := make(chan string, 8)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		posts[r.URL.Path] = payload
+		mu.Unlock()
+		received <- r.URL.Path
+	}))
+	defer api.Close()
+	mails := make(chan string, 4)
+
+	notifier := New(Options{
+		On:                []string{OnFailure},
+		PerJob:            true,
+		SlackWebhookURL:   api.URL + "/slack",
+		DiscordWebhookURL: api.URL + "/discord",
+		TelegramBotToken:  "123:secret",
+		TelegramChatID:    "-1001",
+		TelegramAPIURL:    api.URL,
+		SMTPAddr:          fakeSMTP(t, mails),
+		SMTPFrom:          "builder@example.com",
+		EmailTo:           []string{"ops@example.com"},
+	}, slog.New(slog.DiscardHandler))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		notifier.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	failure := Event{
+		JobID:    "job1",
+		Node:     "builder-1",
+		RepoURL:  "https://github.com/example/repo.git",
+		Ref:      "main",
+		Device:   "tbeam",
+		Outcome:  OnFailure,
+		Error:    "pio run failed",
+		Duration: 95 * time.Second,
+	}
+	notifier.Notify(failure, Target{})
+
+	wantPaths := map[string]bool{"/slack": true, "/discord": true, "/bot123:secret/sendMessage": true}
+	for range wantPaths {
+		select {
+		case path := <-received:
+			if !wantPaths[path] {
+				t.Fatalf("unexpected post to %s", path)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for webhook deliveries")
+		}
+	}
+	select {
+	case message := <-mails:
+		for _, want := range []string{"<ops@example.com>", "Subject: Build failed: tbeam", "Repository: https://github.com/example/repo.git @ main", "Error: pio run failed"} {
+			if !strings.Contains(message, want) {
+				t.Fatalf("email misses %q:\n%s", want, message)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the email")
+	}
+	mu.Lock()
+	slackText, _ := posts["/slack"]["text"].(string)
+	discordText, _ := posts["/discord"]["content"].(string)
+	telegram := posts["/bot123:secret/sendMessage"]
+	mu.Unlock()
+	want := "Build failed: tbeam\nRepository: https://github.com/example/repo.git @ main\nJob: job1 on builder-1\nDuration: 1m35s\nError: pio run failed"
+	if slackText != want || discordText != want || telegram["text"] != want || telegram["chat_id"] != "-1001" {
+		t.Fatalf("unexpected payloads: slack=%q discord=%q telegram=%v", slackText, discordText, telegram)
+	}
+
+	// Successes only reach the job's own recipients that asked for them.
+	success := failure
+	success.Outcome, success.Error = OnSuccess, ""
+	notifier.Notify(success, Target{On: []string{OnSuccess}, TelegramChatID: "@build_watchers", Email: "dev@example.com"})
+	select {
+	case path := <-received:
+		mu.Lock()
+		chat := posts[path]["chat_id"]
+		mu.Unlock()
+		if path != "/bot123:secret/sendMessage" || chat != "@build_watchers" {
+			t.Fatalf("unexpected per-job delivery to %s (chat %v)", path, chat)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the per-job telegram message")
+	}
+	select {
+	case message := <-mails:
+		if !strings.Contains(message, "<dev@example.com>") || strings.Contains(message, "ops@example.com") || !strings.Contains(message, "Build succeeded: tbeam") {
+			t.Fatalf("unexpected per-job email:\n%s", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the per-job email")
+	}
+	select {
+	case path := <-received:
+		t.Fatalf("successes must not reach the deployment channels, got a post to %s", path)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNotifierCheck(t *testing.T) {
+	t.Parallel()
+
+	notifier := New(Options{On: []string{OnFailure}, PerJob: true, TelegramBotToken: "123:secret"}, slog.New(slog.DiscardHandler))
+	cases := []struct {
+		target Target
+		valid  bool
+	}{
+		{Target{}, true},
+		{Target{TelegramChatID: "-1001234"}, true},
+		{Target{TelegramChatID: "@channel_name", On: []string{OnSuccess, OnCancelled}}, true},
+		{Target{SlackWebhookURL: "https://hooks.slack.com/services/T0/B0/x"}, true},
+		{Target{DiscordWebhookURL: "https://discord.com/api/webhooks/1/abc"}, true},
+		{Target{On: []string{OnFailure}}, false},
+		{Target{TelegramChatID: "-1001", On: []string{"started"}}, false},
+		{Target{TelegramChatID: "chat; rm"}, false},
+		{Target{Email: "dev@example.com"}, false},
+		{Target{SlackWebhookURL: "https://example.com/services/T0/B0/x"}, false},
+		{Target{SlackWebhookURL: "http://hooks.slack.com/services/T0/B0/x"}, false},
+		{Target{DiscordWebhookURL: "https://discord.com:8443/api/webhooks/1/abc"}, false},
+		{Target{DiscordWebhookURL: "https://discord.com.evil.test/api/webhooks/1/abc"}, false},
+	}
+	for _, tc := range cases {
+		if err := notifier.Check(tc.target); (err == nil) != tc.valid {
+			t.Fatalf("Check(%+v) = %v, want valid=%v", tc.target, err, tc.valid)
+		}
+	}
+
+	email := New(Options{PerJob: true, SMTPAddr: "127.0.0.1:25", SMTPFrom: "builder@example.com"}, slog.New(slog.DiscardHandler))
+	for address, valid := range map[string]bool{"dev@example.com": true, "Dev <dev@example.com>": false, "not-an-address": false, "a@b.c\r\nBcc: x@y.z": false} {
+		if err := email.Check(Target{Email: address}); (err == nil) != valid {
+			t.Fatalf("Check(email %q) = %v, want valid=%v", address, err, valid)
+		}
+	}
+
+	deploymentOnly := New(Options{On: []string{OnFailure}, SlackWebhookURL: "https://hooks.slack.com/services/x"}, slog.New(slog.DiscardHandler))
+	if err := deploymentOnly.Check(Target{TelegramChatID: "-1001"}); !errors.Is(err, ErrPerJobDisabled) {
+		t.Fatalf("expected ErrPerJobDisabled, got %v", err)
+	}
+	var disabled *Notifier
+	if err := disabled.Check(Target{TelegramChatID: "-1001"}); !errors.Is(err, ErrPerJobDisabled) {
+		t.Fatalf("expected ErrPerJobDisabled from a nil notifier, got %v", err)
+	}
+	if New(Options{On: []string{OnFailure}}, slog.New(slog.DiscardHandler)) != nil {
+		t.Fatalf("expected no notifier without channels")
+	}
+	disabled.Notify(Event{Outcome: OnFailure}, Target{})
+}
+
+func TestPostJSONHidesTokens(t *testing.T) {
+	t.Parallel()
+
+	api := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	api.Close()
+	err := postJSON(context.Background(), http.DefaultClient, api.URL+"/bot123:secret/sendMessage", map[string]string{})
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("expected an error without the token, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webhookSender posts the message to a Slack or Discord incoming webhook;
+// they only differ in the name of the text field.
+type webhookSender struct {
+	name   string
+	url    string
+	field  string
+	client *http.Client
+}
+
+func (n *Notifier) slack(url string) sender {
+	return &webhookSender{name: "slack", url: url, field: "text", client: n.client}
+}
+
+func (n *Notifier) discord(url string) sender {
+	return &webhookSender{name: "discord", url: url, field: "content", client: n.client}
+}
+
+func (s *webhookSender) channel() string { return s.name }
+
+func (s *webhookSender) send(ctx context.Context, event Event) error {
+	return postJSON(ctx, s.client, s.url, map[string]string{s.field: event.text()})
+}
+
+// telegramSender sends the message through the Bot API.
+type telegramSender struct {
+	apiURL string
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func (n *Notifier) telegram(chatID string) sender {
+	return &telegramSender{apiURL: n.opts.TelegramAPIURL, token: n.opts.TelegramBotToken, chatID: chatID, client: n.client}
+}
+
+func (s *telegramSender) channel() string { return "telegram" }
+
+func (s *telegramSender) send(ctx context.Context, event Event) error {
+	return postJSON(ctx, s.client, s.apiURL+"/bot"+s.token+"/sendMessage", map[string]any{
+		"chat_id":                  s.chatID,
+		"text":                     event.text(),
+		"disable_web_page_preview": true,
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		// The error quotes the URL, which holds the webhook or bot token.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("post notification: %w", urlErr.Err)
+		}
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("notification rejected with %s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// emailSender sends a plain-text mail through the SMTP server, upgrading
+// to TLS when the server offers STARTTLS.
+type emailSender struct {
+	addr     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func (n *Notifier) email(to []string) sender {
+	return &emailSender{addr: n.opts.SMTPAddr, username: n.opts.SMTPUsername, password: n.opts.SMTPPassword, from: n.opts.SMTPFrom, to: to}
+}
+
+func (s *emailSender) channel() string { return "email" }
+
+func (s *emailSender) send(ctx context.Context, event Event) error {
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("smtp address: %w", err)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	for _, recipient := range s.to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(s.message(event)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *emailSender) message(event Event) []byte {
+	var buffer bytes.Buffer
+	headers := [][2]string{
+		{"From", s.from},
+		{"To", strings.Join(s.to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", event.title())},
+		{"Date", time.Now().UTC().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&buffer, "%s: %s\r\n", header[0], headerValue(header[1]))
+	}
+	buffer.WriteString("\r\n")
+	buffer.WriteString(strings.ReplaceAll(event.text(), "\n", "\r\n"))
+	buffer.WriteString("\r\n")
+	return buffer.Bytes()
+}
+
+var headerEscaper = strings.NewReplacer("\r", " ", "\n", " ")
+
+func headerValue(value string) string {
+	return headerEscaper.Replace(value)
+}
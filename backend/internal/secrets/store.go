@@ -0,0 +1,361 @@
+// Package secrets keeps operator-provisioned credentials encrypted at rest.
+//
+// Every value is sealed with AES-256-GCM under the key from APP_SECRETS_KEY;
+// the entry's name, kind, scope, username and version are authenticated as
+// additional data, so an entry edited or swapped on disk fails to open.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is what a stored secret is used for.
+type Kind string
+
+const (
+	// KindGitCredential authenticates clones and fetches of https
+	// repositories under its scope ("host" or "host/owner").
+	KindGitCredential Kind = "git-credential"
+	// KindWebhookSecret is accepted for POST /api/webhooks/git alongside
+	// APP_GIT_WEBHOOK_SECRET.
+	KindWebhookSecret Kind = "webhook-secret"
+	// KindSigningKey is a trusted tag signing key: an armored GPG public key
+	// or git allowed-signers lines for SSH signatures.
+	KindSigningKey Kind = "signing-key"
+)
+
+const maxValueBytes = 64 << 10
+
+var (
+	ErrNotFound = errors.New("secret not found")
+
+	namePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+	scopePattern = regexp.MustCompile(`^[a-z0-9.-]+(:[0-9]+)?(/[a-z0-9._-]+)?$`)
+)
+
+// Entry describes a stored secret without its value. Version counts the
+// rotations since the entry was created.
+type Entry struct {
+	Name      string    `json:"name"`
+	Kind      Kind      `json:"kind"`
+	Scope     string    `json:"scope,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ValidationError is returned by Put for an entry that is rejected before
+// anything is written.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string { return e.Err.Error() }
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// Secret is an entry with its decrypted value.
+type Secret struct {
+	Entry
+	Value string
+}
+
+type sealedEntry struct {
+	Entry
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type storeFile struct {
+	Entries []sealedEntry `json:"entries"`
+}
+
+// Store holds the sealed entries in memory and rewrites the file at path on
+// every change. A nil *Store is an empty, read-only store.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	aead    cipher.AEAD
+	entries map[string]sealedEntry
+	now     func() time.Time
+}
+
+// Open loads the store at path, which may not exist yet, with the base64
+// key. Every entry is opened once, so a wrong key fails here rather than on
+// first use.
+func Open(path string, key string) (*Store, error) {
+	rawKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(rawKey) != 32 {
+		return nil, errors.New("key must be 32 bytes encoded as base64")
+	}
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		path:    path,
+		aead:    aead,
+		entries: make(map[string]sealedEntry),
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for _, entry := range file.Entries {
+		if _, err := s.open(entry); err != nil {
+			return nil, fmt.Errorf("open secret %q: %w", entry.Name, err)
+		}
+		s.entries[entry.Name] = entry
+	}
+	return s, nil
+}
+
+// Put stores value under name, creating the entry or rotating it: a
+// rotation keeps CreatedAt and increments Version. It reports whether the
+// entry was created.
+func (s *Store) Put(name string, kind Kind, scope string, username string, value string) (Entry, bool, error) {
+	if s == nil {
+		return Entry{}, false, errors.New("credential store is disabled")
+	}
+	entry, err := normalizeEntry(name, kind, scope, username, value)
+	if err != nil {
+		return Entry{}, false, &ValidationError{Err: err}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	previous, exists := s.entries[entry.Name]
+	entry.Version, entry.CreatedAt, entry.UpdatedAt = 1, now, now
+	if exists {
+		entry.Version, entry.CreatedAt = previous.Version+1, previous.CreatedAt
+	}
+	sealed, err := s.seal(entry, value)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	s.entries[entry.Name] = sealed
+	if err := s.saveLocked(); err != nil {
+		if exists {
+			s.entries[entry.Name] = previous
+		} else {
+			delete(s.entries, entry.Name)
+		}
+		return Entry{}, false, err
+	}
+	return entry, !exists, nil
+}
+
+// Delete removes name and returns the removed entry, or ErrNotFound.
+func (s *Store) Delete(name string) (Entry, error) {
+	if s == nil {
+		return Entry{}, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.entries[name]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	delete(s.entries, name)
+	if err := s.saveLocked(); err != nil {
+		s.entries[name] = previous
+		return Entry{}, err
+	}
+	return previous.Entry, nil
+}
+
+// List returns every entry without values, sorted by name.
+func (s *Store) List() []Entry {
+	entries := make([]Entry, 0)
+	if s == nil {
+		return entries
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sealed := range s.entries {
+		entries = append(entries, sealed.Entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Secrets returns the decrypted secrets of kind, sorted by name.
+func (s *Store) Secrets(kind Kind) ([]Secret, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var secrets []Secret
+	for _, sealed := range s.entries {
+		if sealed.Kind != kind {
+			continue
+		}
+		value, err := s.open(sealed)
+		if err != nil {
+			return nil, fmt.Errorf("open secret %q: %w", sealed.Name, err)
+		}
+		secrets = append(secrets, Secret{Entry: sealed.Entry, Value: value})
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets, nil
+}
+
+func (s *Store) seal(entry Entry, value string) (sealedEntry, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return sealedEntry{}, err
+	}
+	return sealedEntry{
+		Entry:      entry,
+		Nonce:      nonce,
+		Ciphertext: s.aead.Seal(nil, nonce, []byte(value), additionalData(entry)),
+	}, nil
+}
+
+func (s *Store) open(sealed sealedEntry) (string, error) {
+	if len(sealed.Nonce) != s.aead.NonceSize() {
+		return "", errors.New("invalid nonce")
+	}
+	value, err := s.aead.Open(nil, sealed.Nonce, sealed.Ciphertext, additionalData(sealed.Entry))
+	if err != nil {
+		return "", errors.New("wrong key or tampered entry")
+	}
+	return string(value), nil
+}
+
+// saveLocked writes the store to a temporary file and renames it over path,
+// so a crash never leaves a half-written store behind.
+func (s *Store) saveLocked() error {
+	file := storeFile{Entries: make([]sealedEntry, 0, len(s.entries))}
+	for _, sealed := range s.entries {
+		file.Entries = append(file.Entries, sealed)
+	}
+	sort.Slice(file.Entries, func(i, j int) bool { return file.Entries[i].Name < file.Entries[j].Name })
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(s.path), err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(s.path), ".secrets-*")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	tempPath := temp.Name()
+	defer os.Remove(tempPath)
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func additionalData(entry Entry) []byte {
+	return []byte(strings.Join([]string{entry.Name, string(entry.Kind), entry.Scope, entry.Username, strconv.Itoa(entry.Version)}, "\x00"))
+}
+
+func normalizeEntry(name string, kind Kind, scope string, username string, value string) (Entry, error) {
+	entry := Entry{
+		Name:     strings.ToLower(strings.TrimSpace(name)),
+		Kind:     kind,
+		Scope:    strings.Trim(strings.ToLower(strings.TrimSpace(scope)), "/"),
+		Username: strings.TrimSpace(username),
+	}
+	if !namePattern.MatchString(entry.Name) {
+		return Entry{}, errors.New("name must be 1-64 lowercase letters, digits, '.', '_' or '-'")
+	}
+	if strings.TrimSpace(value) == "" {
+		return Entry{}, errors.New("value is required")
+	}
+	if len(value) > maxValueBytes {
+		return Entry{}, fmt.Errorf("value must be at most %d bytes", maxValueBytes)
+	}
+
+	switch kind {
+	case KindGitCredential:
+		if !scopePattern.MatchString(entry.Scope) {
+			return Entry{}, errors.New("git credentials need a scope of the form host or host/owner")
+		}
+		if strings.ContainsAny(entry.Username, ":\r\n") {
+			return Entry{}, errors.New("username must not contain ':' or line breaks")
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return Entry{}, errors.New("git credential must be a single line")
+		}
+	case KindWebhookSecret, KindSigningKey:
+		if entry.Scope != "" || entry.Username != "" {
+			return Entry{}, fmt.Errorf("%s entries take no scope or username", kind)
+		}
+		if kind == KindSigningKey && SigningKeyFormat(value) == "" {
+			return Entry{}, errors.New("signing key must be an armored GPG public key or git allowed-signers lines")
+		}
+	default:
+		return Entry{}, fmt.Errorf("kind must be one of: %s, %s, %s", KindGitCredential, KindWebhookSecret, KindSigningKey)
+	}
+	return entry, nil
+}
+
+// SigningKeyFormat reports whether value is an armored GPG public key
+// ("gpg"), allowed-signers lines for SSH signatures ("ssh") or neither ("").
+func SigningKeyFormat(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+		return "gpg"
+	}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, " ssh-") && !strings.Contains(line, " ecdsa-") && !strings.Contains(line, " sk-") {
+			return ""
+		}
+	}
+	if value == "" {
+		return ""
+	}
+	return "ssh"
+}
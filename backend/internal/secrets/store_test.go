@@ -0,0 +1,154 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestStorePutRotateDelete(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	store, err := Open(path, testKey)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	entry, created, err := store.Put("GitHub-Org", KindGitCredential, "GitHub.com/Example/", "bot", "ghp_first")
+	if err != nil || !created || entry.Name != "github-org" || entry.Scope != "github.com/example" || entry.Version != 1 {
+		t.Fatalf("unexpected put: %+v created=%v err=%v", entry, created, err)
+	}
+	rotated, created, err := store.Put("github-org", KindGitCredential, "github.com/example", "bot", "ghp_second")
+	if err != nil || created || rotated.Version != 2 || !rotated.CreatedAt.Equal(entry.CreatedAt) {
+		t.Fatalf("unexpected rotation: %+v created=%v err=%v", rotated, created, err)
+	}
+	if _, _, err := store.Put("hook", KindWebhookSecret, "", "", "whsec_value"); err != nil {
+		t.Fatalf("put webhook secret: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	if strings.Contains(string(content), "ghp_second") || strings.Contains(string(content), "whsec_value") {
+		t.Fatalf("values must not be stored in plain text: %s", content)
+	}
+
+	reopened, err := Open(path, testKey)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	secrets, err := reopened.Secrets(KindGitCredential)
+	if err != nil || len(secrets) != 1 || secrets[0].Value != "ghp_second" || secrets[0].Username != "bot" {
+		t.Fatalf("unexpected git credentials: %+v err=%v", secrets, err)
+	}
+	if entries := reopened.List(); len(entries) != 2 || entries[0].Name != "github-org" || entries[1].Name != "hook" {
+		t.Fatalf("unexpected list: %+v", entries)
+	}
+
+	if _, err := reopened.Delete("hook"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := reopened.Delete("hook"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if secrets, _ := reopened.Secrets(KindWebhookSecret); len(secrets) != 0 {
+		t.Fatalf("expected the webhook secret to be gone, got %+v", secrets)
+	}
+}
+
+func TestStoreRejectsWrongKeyAndTampering(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	store, err := Open(path, testKey)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, _, err := store.Put("gitlab", KindGitCredential, "gitlab.com", "", "glpat"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	otherKey := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+	if _, err := Open(path, otherKey); err == nil {
+		t.Fatalf("expected a wrong key to fail")
+	}
+	if _, err := Open(path, "short"); err == nil {
+		t.Fatalf("expected an invalid key to fail")
+	}
+
+	// Widening the scope on disk must not let the credential reach other hosts.
+	content, _ := os.ReadFile(path)
+	var file storeFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		t.Fatalf("parse store: %v", err)
+	}
+	file.Entries[0].Scope = "evil.example"
+	content, _ = json.Marshal(file)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("write store: %v", err)
+	}
+	if _, err := Open(path, testKey); err == nil {
+		t.Fatalf("expected a tampered entry to fail")
+	}
+}
+
+func TestStoreValidation(t *testing.T) {
+	t.Parallel()
+
+	store, err := Open(filepath.Join(t.TempDir(), "secrets.json"), testKey)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	cases := []struct {
+		name, scope, username, value string
+		kind                         Kind
+	}{
+		{"../escape", "github.com", "", "token", KindGitCredential},
+		{"no-scope", "", "", "token", KindGitCredential},
+		{"bad-scope", "https://github.com", "", "token", KindGitCredential},
+		{"bad-user", "github.com", "a:b", "token", KindGitCredential},
+		{"multiline", "github.com", "", "a\nb", KindGitCredential},
+		{"empty", "github.com", "", " ", KindGitCredential},
+		{"scoped-hook", "github.com", "", "secret", KindWebhookSecret},
+		{"not-a-key", "", "", "hello", KindSigningKey},
+		{"unknown", "", "", "value", Kind("password")},
+	}
+	for _, tc := range cases {
+		if _, _, err := store.Put(tc.name, tc.kind, tc.scope, tc.username, tc.value); err == nil {
+			t.Fatalf("expected %q to be rejected", tc.name)
+		}
+	}
+	if entries := store.List(); len(entries) != 0 {
+		t.Fatalf("rejected entries must not be stored: %+v", entries)
+	}
+
+	if SigningKeyFormat("-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nabc\n-----END PGP PUBLIC KEY BLOCK-----") != "gpg" {
+		t.Fatalf("expected an armored key to be gpg")
+	}
+	if SigningKeyFormat("# release signers\ndev@example.com ssh-ed25519 AAAAC3Nza") != "ssh" {
+		t.Fatalf("expected allowed-signers lines to be ssh")
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	t.Parallel()
+
+	var store *Store
+	if entries := store.List(); entries == nil || len(entries) != 0 {
+		t.Fatalf("expected an empty list, got %+v", entries)
+	}
+	if secrets, err := store.Secrets(KindGitCredential); err != nil || len(secrets) != 0 {
+		t.Fatalf("expected no secrets, got %+v %v", secrets, err)
+	}
+	if _, _, err := store.Put("a", KindWebhookSecret, "", "", "b"); err == nil {
+		t.Fatalf("expected a nil store to refuse writes")
+	}
+}
@@ -3,7 +3,7 @@ package stats
 import (
 	"bufio"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"os"
 	"sort"
 	"sync"
@@ -58,11 +58,11 @@ type Summary struct {
 type Collector struct {
 	filePath string
 	mu       sync.Mutex
-	logger   *log.Logger
+	logger   *slog.Logger
 	file     *os.File
 }
 
-func NewCollector(filePath string, logger *log.Logger) *Collector {
+func NewCollector(filePath string, logger *slog.Logger) *Collector {
 	return &Collector{
 		filePath: filePath,
 		logger:   logger,
@@ -90,7 +90,7 @@ func (c *Collector) Record(event Event) {
 
 	line, err := json.Marshal(event)
 	if err != nil {
-		c.logger.Printf("stats: marshal event: %v", err)
+		c.logger.Error("stats: marshal event", "error", err)
 		return
 	}
 
@@ -99,12 +99,12 @@ func (c *Collector) Record(event Event) {
 
 	f, err := c.getFile()
 	if err != nil {
-		c.logger.Printf("stats: open file: %v", err)
+		c.logger.Error("stats: open file", "error", err)
 		return
 	}
 
 	if _, err := f.Write(append(line, '\n')); err != nil {
-		c.logger.Printf("stats: write event: %v", err)
+		c.logger.Error("stats: write event", "error", err)
 	}
 }
 
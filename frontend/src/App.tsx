@@ -1,12 +1,17 @@
 import { FormEvent, useEffect, useMemo, useRef, useState } from "react";
 import {
   ArtifactItem,
+  AuthStatus,
   CaptchaChallenge,
   DiscoverBuildOptions,
+  JobProgress,
   JobState,
   JobStatus,
+  ProofOfWorkSolution,
+  QuotaPeriod,
   RepoRefsResponse,
   ServerHealth,
+  UserJob,
   apiUrl,
   createBuildJob,
   createLogStream,
@@ -14,8 +19,15 @@ import {
   discoverRepoRefs,
   getCaptchaChallenge,
   getArtifacts,
+  getAuthStatus,
   getJob,
+  getMyJobs,
+  getProofOfWorkChallenge,
   getServerHealth,
+  jobPath,
+  loginUrl,
+  logout,
+  solveProofOfWork,
 } from "./api";
 import {
   collectRefSuggestions,
@@ -29,6 +41,7 @@ import {
   syncFormValuesToURL,
 } from "./appUtils";
 import { Locale, dict } from "./i18n";
+import CaptchaWidget from "./CaptchaWidget";
 import StatsPage from "./StatsPage";
 
 const finalStatuses = new Set<JobStatus>(["success", "failed", "cancelled"]);
@@ -69,6 +82,8 @@ function MainApp() {
   const [captchaRequired, setCaptchaRequired] = useState(true);
   const [statsEnabled, setStatsEnabled] = useState(false);
   const [health, setHealth] = useState<ServerHealth | null>(null);
+  const [auth, setAuth] = useState<AuthStatus | null>(null);
+  const [myJobs, setMyJobs] = useState<UserJob[]>([]);
   const [devices, setDevices] = useState<string[]>([]);
   const [deviceOptions, setDeviceOptions] = useState<Record<string, DiscoverBuildOptions>>({});
   const [selectedDevice, setSelectedDevice] = useState("");
@@ -77,9 +92,11 @@ function MainApp() {
 
   const [discovering, setDiscovering] = useState(false);
   const [startingBuild, setStartingBuild] = useState(false);
+  const [solvingProofOfWork, setSolvingProofOfWork] = useState(false);
 
   const [job, setJob] = useState<JobState | null>(null);
   const [logs, setLogs] = useState<string[]>([]);
+  const [progress, setProgress] = useState<JobProgress | null>(null);
   const [artifacts, setArtifacts] = useState<ArtifactItem[]>([]);
   const [autoScroll, setAutoScroll] = useState(true);
   const [error, setError] = useState("");
@@ -100,6 +117,31 @@ function MainApp() {
     };
   }, []);
 
+  useEffect(() => {
+    getAuthStatus()
+      .then(setAuth)
+      .catch(() => setAuth(null));
+  }, []);
+
+  const signedInUserId = auth?.user?.id ?? "";
+  useEffect(() => {
+    if (!signedInUserId) {
+      setMyJobs([]);
+      return;
+    }
+    let cancelled = false;
+    getMyJobs(10)
+      .then((items) => {
+        if (!cancelled) {
+          setMyJobs(items);
+        }
+      })
+      .catch(() => undefined);
+    return () => {
+      cancelled = true;
+    };
+  }, [signedInUserId, job?.id, job?.status]);
+
   useEffect(() => {
     if (!autoScroll) {
       return;
@@ -290,7 +332,7 @@ function MainApp() {
     }
 
     const hasCaptchaSession = captchaSessionToken.trim() !== "";
-    if (captchaRequired && !hasCaptchaSession && (!captcha?.captchaId || !captchaAnswer.trim())) {
+    if (captchaRequired && !hasCaptchaSession && (!(captcha?.captchaId || captcha?.siteKey) || !captchaAnswer.trim())) {
       setError(t.captchaRequired);
       return;
     }
@@ -305,6 +347,9 @@ function MainApp() {
         saveCaptchaSessionToken(result.captchaSessionToken);
       }
 
+      if (result.repoUrl) {
+        setRepoUrl(result.repoUrl);
+      }
       setDeviceOptions(result.deviceOptions ?? {});
       setDevices(result.devices);
       setSelectedDevice(result.devices[0] ?? "");
@@ -336,7 +381,10 @@ function MainApp() {
     }
 
     const hasCaptchaSession = captchaSessionToken.trim() !== "";
-    if (captchaRequired && !hasCaptchaSession && (!captcha?.captchaId || !captchaAnswer.trim())) {
+    const captchaSolved = !!(captcha?.captchaId || captcha?.siteKey) && captchaAnswer.trim() !== "";
+    // Without a captcha answer, a proof of work stands in for it when offered.
+    const useProofOfWork = captchaRequired && !hasCaptchaSession && !captchaSolved && !!health?.proofOfWork;
+    if (captchaRequired && !hasCaptchaSession && !captchaSolved && !useProofOfWork) {
       setError(t.captchaRequired);
       return;
     }
@@ -346,7 +394,28 @@ function MainApp() {
 
     setStartingBuild(true);
     try {
-      const created = hasCaptchaSession
+      let proofOfWork: ProofOfWorkSolution | undefined;
+      if (useProofOfWork) {
+        setSolvingProofOfWork(true);
+        const challenge = await getProofOfWorkChallenge();
+        if (challenge.required && challenge.challenge) {
+          proofOfWork = await solveProofOfWork(challenge.challenge, challenge.difficulty ?? 0);
+        }
+      }
+
+      const created = proofOfWork
+        ? await createBuildJob(
+            repoUrl.trim(),
+            ref.trim(),
+            selectedDevice,
+            buildFlags,
+            libDeps,
+            undefined,
+            undefined,
+            undefined,
+            proofOfWork,
+          )
+        : hasCaptchaSession
         ? await createBuildJob(
             repoUrl.trim(),
             ref.trim(),
@@ -372,6 +441,9 @@ function MainApp() {
       }
 
       setJob(created);
+      if (created.quota) {
+        setHealth((current) => (current ? { ...current, quota: created.quota } : current));
+      }
       setArtifacts([]);
       setLogs([]);
       openStream(created.id);
@@ -384,6 +456,7 @@ function MainApp() {
         void refreshCaptcha();
       }
     } finally {
+      setSolvingProofOfWork(false);
       setStartingBuild(false);
     }
   }
@@ -396,8 +469,16 @@ function MainApp() {
       const message = event as MessageEvent<string>;
       setLogs((current) => [...current, message.data]);
     });
+    stream.addEventListener("progress", (event) => {
+      const update = JSON.parse((event as MessageEvent<string>).data) as JobProgress;
+      setProgress(update.done ? null : update);
+    });
     stream.onerror = () => {
-      stream.close();
+      // While the connection is merely interrupted the browser reconnects
+      // with Last-Event-ID and the backend resumes after the last line.
+      if (stream.readyState !== EventSource.CLOSED) {
+        return;
+      }
       if (streamRef.current === stream) {
         streamRef.current = null;
       }
@@ -406,6 +487,7 @@ function MainApp() {
   }
 
   function closeStream() {
+    setProgress(null);
     if (!streamRef.current) {
       return;
     }
@@ -413,23 +495,45 @@ function MainApp() {
     streamRef.current = null;
   }
 
+  const onSignOut = async () => {
+    try {
+      setAuth(await logout());
+    } catch (signOutError) {
+      setError(errorToMessage(signOutError, t.unknownError));
+    }
+  };
+
   const statusLabel = job ? t.statuses[job.status] ?? job.status : "-";
   const queueNote =
     job?.status === "queued"
       ? typeof job.queuePosition === "number" && job.queuePosition > 0
         ? t.queueInfoWithPos.replace("{position}", String(job.queuePosition))
         : t.queueInfo
-      : "";
+      : job?.status === "pending"
+        ? t.pendingApprovalInfo
+        : "";
   const queueEtaNote =
     job?.status === "queued" && typeof job.queueEtaSeconds === "number" && job.queueEtaSeconds > 0
       ? t.queueEta.replace("{eta}", formatQueueETA(job.queueEtaSeconds, locale))
       : "";
+  const progressNote = progress
+    ? t.cloneProgress.replace("{stage}", progress.stage).replace("{percent}", String(progress.percent))
+    : "";
   const supportIntroParts = t.supportIntro.split("{chat}");
   const defaultBranchNote =
     repoRefs?.defaultBranch && repoRefs.defaultBranch.trim()
       ? t.refsDefaultBranch.replace("{branch}", repoRefs.defaultBranch)
       : "";
   const refsErrorNote = refsError ? t.refsLoadFailed.replace("{error}", refsError) : "";
+  const quotaPeriod = [health?.quota?.daily, health?.quota?.weekly]
+    .filter((period) => period !== undefined)
+    .reduce<QuotaPeriod | undefined>((tightest, period) => (!tightest || period.remaining < tightest.remaining ? period : tightest), undefined);
+  const quotaNote = quotaPeriod
+    ? t.quotaRemaining
+        .replace("{remaining}", String(quotaPeriod.remaining))
+        .replace("{limit}", String(quotaPeriod.limit))
+        .replace("{reset}", new Date(quotaPeriod.resetsAt).toLocaleString(locale))
+    : "";
   const branchSuggestions = repoRefs ? limitRefItems(repoRefs.recentBranches, 8) : [];
   const tagSuggestions = repoRefs ? limitRefItems(repoRefs.recentTags, 8) : [];
   const refInputSuggestions = collectRefSuggestions(repoRefs);
@@ -468,6 +572,20 @@ function MainApp() {
                 EN
               </button>
             </div>
+            {auth?.enabled ? (
+              <div className="auth-box">
+                {auth.user ? (
+                  <>
+                    <span>{t.signedInAs.replace("{name}", auth.user.name || auth.user.id)}</span>
+                    <button className="locale-btn" onClick={onSignOut} type="button">
+                      {t.signOut}
+                    </button>
+                  </>
+                ) : (
+                  <a href={loginUrl(window.location.href)}>{t.signIn}</a>
+                )}
+              </div>
+            ) : null}
           </div>
         </header>
 
@@ -545,12 +663,24 @@ function MainApp() {
               <p className="muted refs-meta">{t.captchaDisabled}</p>
             ) : captchaSessionToken ? (
               <p className="muted refs-meta">{t.captchaSessionActive}</p>
+            ) : captcha?.siteKey ? (
+              <label>
+                <span>{t.captchaLabel}</span>
+                <CaptchaWidget challenge={captcha} onToken={setCaptchaAnswer} />
+              </label>
             ) : (
               <label>
                 <span>{t.captchaLabel}</span>
                 <div className="captcha-row">
                   <div className="captcha-question" title={t.captchaTooltip}>
-                    {captchaLoading ? t.captchaLoading : captcha?.question ?? t.captchaLoading}
+                    {captchaLoading || !captcha ? (
+                      t.captchaLoading
+                    ) : (
+                      <>
+                        {captcha.question}
+                        {captcha.image ? <img src={`data:image/png;base64,${captcha.image}`} alt={t.captchaLabel} /> : null}
+                      </>
+                    )}
                   </div>
                   <button className="ghost" type="button" onClick={() => void refreshCaptcha()} disabled={captchaLoading}>
                     {t.captchaRefresh}
@@ -664,10 +794,13 @@ function MainApp() {
               disabled={
                 !selectedDevice ||
                 startingBuild ||
-                (captchaRequired && !captchaSessionToken && (captchaLoading || !captcha || !captchaAnswer.trim()))
+                (captchaRequired &&
+                  !captchaSessionToken &&
+                  !health?.proofOfWork &&
+                  (captchaLoading || !captcha || !captchaAnswer.trim()))
               }
             >
-              {startingBuild ? t.startingBuild : t.startBuild}
+              {solvingProofOfWork ? t.solvingProofOfWork : startingBuild ? t.startingBuild : t.startBuild}
             </button>
             <button className="ghost" type="button" onClick={() => setLogs([])}>
               {t.clearLogs}
@@ -676,18 +809,25 @@ function MainApp() {
               {autoScroll ? t.autoScrollOn : t.autoScrollOff}
             </button>
           </div>
+          {quotaNote ? <p className="muted refs-meta">{quotaNote}</p> : null}
         </section>
 
         <section className="panel logs-panel reveal-3">
           <div className="panel-head">
             <h2>{t.logs}</h2>
             <p>{t.logsHint}</p>
+            {job?.id ? (
+              <a href={apiUrl(jobPath(job.id, `/api/jobs/${job.id}/logs.txt`))} download>
+                {t.downloadLogs}
+              </a>
+            ) : null}
           </div>
           <pre className="logs-box">
-            {queueNote || queueEtaNote ? (
+            {queueNote || queueEtaNote || progressNote ? (
               <span className="logs-queue-note-wrap">
                 {queueNote ? <span className="logs-queue-note">{queueNote}</span> : null}
                 {queueEtaNote ? <span className="logs-queue-note">{queueEtaNote}</span> : null}
+                {progressNote ? <span className="logs-queue-note">{progressNote}</span> : null}
               </span>
             ) : null}
             {logs.join("\n")}
@@ -705,7 +845,7 @@ function MainApp() {
             <ul className="artifacts-list">
               {artifacts.map((artifact) => (
                 <li key={artifact.id}>
-                  <a href={apiUrl(artifact.downloadUrl)} target="_blank" rel="noreferrer">
+                  <a href={apiUrl(job ? jobPath(job.id, artifact.downloadUrl) : artifact.downloadUrl)} target="_blank" rel="noreferrer">
                     {artifact.relativePath}
                   </a>
                   <span>{formatSize(artifact.size)}</span>
@@ -715,6 +855,35 @@ function MainApp() {
           )}
         </section>
 
+        {auth?.user ? (
+          <section className="panel reveal-4">
+            <div className="panel-head">
+              <h2>{t.myBuilds}</h2>
+            </div>
+            {myJobs.length === 0 ? (
+              <p className="muted">{t.myBuildsEmpty}</p>
+            ) : (
+              <ul className="artifacts-list">
+                {myJobs.map((item) => (
+                  <li key={item.jobId}>
+                    <span>
+                      {item.device} · {item.ref ?? "-"} · {t.statuses[item.status] ?? item.status}
+                      {item.node ? ` · ${item.node}` : ""}
+                    </span>
+                    {item.retained && item.status === "success" ? (
+                      <a href={apiUrl(`/api/jobs/${item.jobId}/artifacts.zip`)} download>
+                        {t.myBuildsDownload}
+                      </a>
+                    ) : (
+                      <span>{new Date(item.createdAt).toLocaleString(locale)}</span>
+                    )}
+                  </li>
+                ))}
+              </ul>
+            )}
+          </section>
+        ) : null}
+
         {job?.status === "success" && (
           <section className="panel reveal-4" style={{ borderLeft: "3px solid var(--accent)" }}>
             <p style={{ margin: 0, fontSize: 14, lineHeight: 1.6 }}>
@@ -0,0 +1,97 @@
+import { useEffect, useRef } from "react";
+import { CaptchaChallenge } from "./api";
+
+type WidgetProvider = "turnstile" | "hcaptcha" | "recaptcha";
+
+interface WidgetApi {
+  render(element: HTMLElement, options: Record<string, unknown>): string | number;
+  remove?(widgetId: string | number): void;
+}
+
+const SCRIPTS: Record<WidgetProvider, { src: string; global: string }> = {
+  turnstile: { src: "https://challenges.cloudflare.com/turnstile/v0/api.js?render=explicit", global: "turnstile" },
+  hcaptcha: { src: "https://js.hcaptcha.com/1/api.js?render=explicit", global: "hcaptcha" },
+  recaptcha: { src: "https://www.google.com/recaptcha/api.js?render=explicit", global: "grecaptcha" },
+};
+
+const loaded = new Map<WidgetProvider, Promise<WidgetApi>>();
+
+function loadWidgetApi(provider: WidgetProvider): Promise<WidgetApi> {
+  const existing = loaded.get(provider);
+  if (existing) {
+    return existing;
+  }
+
+  const { src, global } = SCRIPTS[provider];
+  const promise = new Promise<WidgetApi>((resolve, reject) => {
+    const script = document.createElement("script");
+    script.src = src;
+    script.async = true;
+    script.onerror = () => {
+      loaded.delete(provider);
+      reject(new Error(`failed to load ${provider}`));
+    };
+    script.onload = () => {
+      // reCAPTCHA defines grecaptcha before it can render; ready() waits for it.
+      const api = (window as unknown as Record<string, WidgetApi & { ready?: (cb: () => void) => void }>)[global];
+      if (api?.ready) {
+        api.ready(() => resolve(api));
+      } else {
+        resolve(api);
+      }
+    };
+    document.head.appendChild(script);
+  });
+  loaded.set(provider, promise);
+  return promise;
+}
+
+interface CaptchaWidgetProps {
+  challenge: CaptchaChallenge;
+  onToken: (token: string) => void;
+}
+
+// CaptchaWidget renders a Turnstile, hCaptcha or reCAPTCHA widget and
+// reports its response token, which is sent as captchaAnswer. Tokens are
+// single-use, so every new challenge renders a fresh widget.
+export default function CaptchaWidget({ challenge, onToken }: CaptchaWidgetProps) {
+  const { provider, siteKey } = challenge;
+  const container = useRef<HTMLDivElement>(null);
+  const tokenHandler = useRef(onToken);
+  tokenHandler.current = onToken;
+
+  useEffect(() => {
+    if (!provider || !siteKey || !container.current) {
+      return;
+    }
+    let cancelled = false;
+    let widgetId: string | number | undefined;
+    let api: WidgetApi | undefined;
+    const element = container.current;
+
+    loadWidgetApi(provider)
+      .then((loadedApi) => {
+        if (cancelled) {
+          return;
+        }
+        api = loadedApi;
+        widgetId = loadedApi.render(element, {
+          sitekey: siteKey,
+          callback: (token: string) => tokenHandler.current(token),
+          "expired-callback": () => tokenHandler.current(""),
+          "error-callback": () => tokenHandler.current(""),
+        });
+      })
+      .catch(() => tokenHandler.current(""));
+
+    return () => {
+      cancelled = true;
+      if (api?.remove && widgetId !== undefined) {
+        api.remove(widgetId);
+      }
+      element.replaceChildren();
+    };
+  }, [challenge, provider, siteKey]);
+
+  return <div className="captcha-widget" ref={container} />;
+}
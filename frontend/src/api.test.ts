@@ -18,3 +18,38 @@ describe("apiUrl", () => {
     expect(apiUrl("/api/captcha")).toBe("http://api.example.com/api/captcha");
   });
 });
+
+describe("proof of work", () => {
+  it("counts leading zero bits", async () => {
+    const { leadingZeroBits } = await import("./api");
+    expect(leadingZeroBits(new Uint8Array([0, 0, 0x10, 0xff]))).toBe(19);
+    expect(leadingZeroBits(new Uint8Array([0x80]))).toBe(0);
+  });
+
+  it("finds a nonce meeting the difficulty", async () => {
+    const { leadingZeroBits, solveProofOfWork } = await import("./api");
+    const solution = await solveProofOfWork("challenge", 8);
+    const digest = await crypto.subtle.digest("SHA-256", new TextEncoder().encode(`challenge:${solution.powNonce}`));
+    expect(leadingZeroBits(new Uint8Array(digest))).toBeGreaterThanOrEqual(8);
+  });
+});
+
+describe("jobPath", () => {
+  afterEach(() => {
+    vi.unstubAllGlobals();
+    vi.resetModules();
+  });
+
+  it("adds the access token of private jobs", async () => {
+    vi.stubGlobal(
+      "fetch",
+      vi.fn(async () => new Response(JSON.stringify({ data: { id: "job1", accessToken: "a+b" } }), { status: 201 })),
+    );
+    const { createBuildJob, jobPath } = await import("./api");
+    expect(jobPath("job1", "/api/jobs/job1")).toBe("/api/jobs/job1");
+    await createBuildJob("owner/repo", "main", "tbeam");
+    expect(jobPath("job1", "/api/jobs/job1/logs")).toBe("/api/jobs/job1/logs?token=a%2Bb");
+    expect(jobPath("job1", "/api/jobs/job1/artifacts/x?download=1")).toBe("/api/jobs/job1/artifacts/x?download=1&token=a%2Bb");
+    expect(jobPath("job2", "/api/jobs/job2")).toBe("/api/jobs/job2");
+  });
+});
@@ -1,40 +1,249 @@
-export type JobStatus = "queued" | "running" | "success" | "failed" | "cancelled";
+export type JobStatus = "pending" | "queued" | "running" | "success" | "failed" | "cancelled";
+
+export type FailureKind =
+  | "clone_error"
+  | "submodule_error"
+  | "dependency_error"
+  | "compile_error"
+  | "timeout"
+  | "oom"
+  | "infra";
 
 export interface ArtifactItem {
   id: string;
   name: string;
   relativePath: string;
   size: number;
+  sha256?: string;
+  md5?: string;
+  url?: string;
+  downloads?: number;
+  lastDownloadAt?: string;
   downloadUrl: string;
 }
 
+export interface SubmoduleOverride {
+  path: string;
+  url?: string;
+  commit?: string;
+}
+
+export interface JobProgress {
+  phase: string;
+  stage: string;
+  percent: number;
+  current: number;
+  total: number;
+  done?: boolean;
+}
+
+export interface TagSignature {
+  status: "verified" | "unsigned" | "untrusted" | "error";
+  format?: "gpg" | "ssh";
+  signer?: string;
+  message?: string;
+}
+
+export interface JobProvenance {
+  commit: string;
+  tag?: string;
+  tagSignature?: TagSignature;
+}
+
 export interface JobState {
   id: string;
   repoUrl: string;
   ref?: string;
+  requestedRef?: string;
   device: string;
+  commit?: string;
   buildFlags?: string[];
   libDeps?: string[];
+  artifactPatterns?: string[];
+  patchSha256?: string;
+  submodules?: SubmoduleOverride[];
+  progress?: JobProgress;
+  provenance?: JobProvenance;
   status: JobStatus;
   captchaSessionToken?: string;
+  visibility?: "private" | "public";
+  accessToken?: string;
+  quota?: BuildQuota;
   queuePosition?: number;
   queueEtaSeconds?: number;
   createdAt: string;
   startedAt?: string;
   finishedAt?: string;
   error?: string;
+  failureKind?: FailureKind;
   logLines: number;
   artifacts: ArtifactItem[];
+  size?: SizeReport;
+}
+
+export interface QuotaPeriod {
+  limit: number;
+  used: number;
+  remaining: number;
+  resetsAt: string;
+}
+
+export interface BuildQuota {
+  daily?: QuotaPeriod;
+  weekly?: QuotaPeriod;
+}
+
+export interface MemoryUsage {
+  used: number;
+  total: number;
+  percent: number;
+}
+
+export interface SizeReport {
+  ram?: MemoryUsage;
+  flash?: MemoryUsage;
 }
 
 export interface DiscoverResponse {
   repoUrl: string;
   ref?: string;
+  commit?: string;
+  devices: string[];
+  deviceInfo?: DiscoveredDevice[];
+  platforms?: PlatformCount[];
+  deviceOptions?: Record<string, DiscoverBuildOptions>;
+  warnings?: DiscoveryWarning[];
+  captchaSessionToken?: string;
+}
+
+export interface DiscoveryWarning {
+  code: string;
+  file?: string;
+  line?: number;
+  env?: string;
+  message: string;
+}
+
+export interface DeviceCatalogEntry {
+  repoUrl: string;
+  ref: string;
+  commit?: string;
+  release: boolean;
+  refreshedAt: string;
+  error?: string;
   devices: string[];
+  deviceInfo: DiscoveredDevice[];
+  platforms: PlatformCount[];
   deviceOptions?: Record<string, DiscoverBuildOptions>;
+}
+
+export interface DeviceCatalogResponse {
+  refreshedAt?: string;
+  repos: DeviceCatalogEntry[];
+}
+
+export interface DeviceSearchResult extends DiscoveredDevice {
+  aliases?: string[];
+  matchedOn: string;
+  score: number;
+  refs: { repoUrl: string; ref: string }[];
+}
+
+export interface DeviceSearchResponse {
+  query: string;
+  results: DeviceSearchResult[];
+}
+
+export interface DeviceCatalogChange {
+  repoUrl: string;
+  ref: string;
+  previousCommit: string;
+  commit: string;
+  detectedAt: string;
+  added: string[];
+  removed: string[];
+}
+
+export interface DeviceChangesResponse {
+  changes: DeviceCatalogChange[];
+}
+
+export interface CompareDevicesRequest {
+  repoUrl: string;
+  baseRef: string;
+  headRef: string;
+  captchaId?: string;
+  captchaAnswer?: string;
   captchaSessionToken?: string;
 }
 
+export interface DeviceDiff {
+  name: string;
+  fields: string[];
+  buildFlagsAdded: string[];
+  buildFlagsRemoved: string[];
+  libDepsAdded: string[];
+  libDepsRemoved: string[];
+  before: DiscoveredDevice;
+  after: DiscoveredDevice;
+}
+
+export interface CompareDevicesResponse {
+  repoUrl: string;
+  baseRef: string;
+  headRef: string;
+  baseCommit?: string;
+  headCommit?: string;
+  added: DiscoveredDevice[];
+  removed: DiscoveredDevice[];
+  changed: DeviceDiff[];
+  unchanged: number;
+  captchaSessionToken?: string;
+}
+
+export interface TagAvailability {
+  tag: string;
+  commit?: string;
+  available: boolean;
+  error?: string;
+}
+
+export interface DeviceAvailabilityResponse {
+  repoUrl: string;
+  device: string;
+  firstAvailable?: string;
+  tags: TagAvailability[];
+}
+
+export interface PlatformCount {
+  platform: string;
+  count: number;
+}
+
+export interface DiscoveredDevice {
+  name: string;
+  group?: string;
+  relativePath?: string;
+  platform?: string;
+  board?: string;
+  mcu?: string;
+  hasDisplay?: boolean;
+  hasGps?: boolean;
+  radios?: string[];
+  tags?: string[];
+  lastSuccessfulBuild?: LastSuccessfulBuild;
+}
+
+export interface LastSuccessfulBuild {
+  jobId: string;
+  ref?: string;
+  commit?: string;
+  finishedAt: string;
+  current: boolean;
+  downloadUrl: string;
+  artifacts: ArtifactItem[];
+}
+
 export interface DiscoverBuildOptions {
   buildFlags?: string[];
   libDeps?: string[];
@@ -55,17 +264,69 @@ export interface RepoRefsResponse {
 
 export interface CaptchaChallenge {
   captchaRequired?: boolean;
+  provider?: "turnstile" | "hcaptcha" | "recaptcha";
+  siteKey?: string;
   captchaId?: string;
   question?: string;
+  image?: string;
   expiresAt?: string;
 }
 
+export interface ProofOfWorkChallenge {
+  required: boolean;
+  challenge?: string;
+  algorithm?: string;
+  difficulty?: number;
+  expiresAt?: string;
+}
+
+export interface ProofOfWorkSolution {
+  powChallenge: string;
+  powNonce: string;
+}
+
+export interface DiscoveryLoad {
+  active: number;
+  queued: number;
+  limit: number;
+}
+
 export interface ServerHealth {
   status: string;
   captchaRequired: boolean;
   statsEnabled: boolean;
+  buildRateLimitPerMinute?: number;
   version?: string;
   commit?: string;
+  discovery?: DiscoveryLoad;
+  proofOfWork?: boolean;
+  quota?: BuildQuota;
+}
+
+export interface AuthUser {
+  id: string;
+  provider: string;
+  name?: string;
+  email?: string;
+}
+
+export interface AuthStatus {
+  enabled: boolean;
+  provider?: string;
+  user?: AuthUser;
+}
+
+export interface UserJob {
+  jobId: string;
+  repoUrl: string;
+  ref?: string;
+  device: string;
+  status: JobStatus;
+  createdAt: string;
+  finishedAt?: string;
+  error?: string;
+  retained: boolean;
+  node?: string;
 }
 
 export interface LogsSnapshot {
@@ -188,6 +449,31 @@ export async function discoverDevices(
   });
 }
 
+export async function getDeviceCatalog(signal?: AbortSignal): Promise<DeviceCatalogResponse> {
+  return request<DeviceCatalogResponse>("/api/devices", { signal });
+}
+
+export async function searchDevices(query: string, signal?: AbortSignal): Promise<DeviceSearchResponse> {
+  return request<DeviceSearchResponse>(`/api/devices/search?q=${encodeURIComponent(query)}`, { signal });
+}
+
+export async function getDeviceChanges(signal?: AbortSignal): Promise<DeviceChangesResponse> {
+  return request<DeviceChangesResponse>("/api/devices/changes", { signal });
+}
+
+export async function compareDevices(payload: CompareDevicesRequest, signal?: AbortSignal): Promise<CompareDevicesResponse> {
+  return request<CompareDevicesResponse>("/api/repos/compare-devices", {
+    method: "POST",
+    body: JSON.stringify(payload),
+    signal,
+  });
+}
+
+export async function getDeviceAvailability(device: string, repoUrl?: string, signal?: AbortSignal): Promise<DeviceAvailabilityResponse> {
+  const query = repoUrl ? `?repoUrl=${encodeURIComponent(repoUrl)}` : "";
+  return request<DeviceAvailabilityResponse>(`/api/devices/${device.split("/").map(encodeURIComponent).join("/")}/availability${query}`, { signal });
+}
+
 export async function discoverRepoRefs(repoUrl: string, signal?: AbortSignal): Promise<RepoRefsResponse> {
   return request<RepoRefsResponse>("/api/repos/refs", {
     method: "POST",
@@ -205,6 +491,7 @@ export async function createBuildJob(
   captchaId?: string,
   captchaAnswer?: string,
   captchaSessionToken?: string,
+  proofOfWork?: ProofOfWorkSolution,
 ): Promise<JobState> {
   const payload: {
     repoUrl: string;
@@ -215,7 +502,9 @@ export async function createBuildJob(
     captchaId?: string;
     captchaAnswer?: string;
     captchaSessionToken?: string;
-  } = { repoUrl, ref, device };
+    powChallenge?: string;
+    powNonce?: string;
+  } = { repoUrl, ref, device, ...proofOfWork };
   if (buildFlags && buildFlags.length > 0) {
     payload.buildFlags = buildFlags;
   }
@@ -232,10 +521,58 @@ export async function createBuildJob(
     payload.captchaSessionToken = captchaSessionToken;
   }
 
-  return request<JobState>("/api/jobs", {
+  const job = await request<JobState>("/api/jobs", {
     method: "POST",
     body: JSON.stringify(payload),
   });
+  if (job.accessToken) {
+    jobTokens.set(job.id, job.accessToken);
+  }
+  return job;
+}
+
+// Private jobs are only readable with the access token returned when they
+// were created (or by their signed-in creator).
+const jobTokens = new Map<string, string>();
+
+// jobPath adds the access token of a private job to one of its API paths,
+// so it also works for download links and EventSource, which cannot send
+// the X-Job-Token header.
+export function jobPath(jobId: string, path: string): string {
+  const token = jobTokens.get(jobId);
+  if (!token) {
+    return path;
+  }
+  return `${path}${path.includes("?") ? "&" : "?"}token=${encodeURIComponent(token)}`;
+}
+
+export async function getProofOfWorkChallenge(): Promise<ProofOfWorkChallenge> {
+  return request<ProofOfWorkChallenge>("/api/pow", {
+    method: "GET",
+  });
+}
+
+// solveProofOfWork searches for a nonce whose SHA-256 of
+// `${challenge}:${nonce}` starts with `difficulty` zero bits.
+export async function solveProofOfWork(challenge: string, difficulty: number): Promise<ProofOfWorkSolution> {
+  const encoder = new TextEncoder();
+  for (let nonce = 0; ; nonce++) {
+    const digest = new Uint8Array(await crypto.subtle.digest("SHA-256", encoder.encode(`${challenge}:${nonce}`)));
+    if (leadingZeroBits(digest) >= difficulty) {
+      return { powChallenge: challenge, powNonce: String(nonce) };
+    }
+  }
+}
+
+export function leadingZeroBits(digest: Uint8Array): number {
+  let count = 0;
+  for (const byte of digest) {
+    if (byte !== 0) {
+      return count + Math.clz32(byte) - 24;
+    }
+    count += 8;
+  }
+  return count;
 }
 
 export async function getCaptchaChallenge(): Promise<CaptchaChallenge> {
@@ -250,16 +587,41 @@ export async function getServerHealth(): Promise<ServerHealth> {
   });
 }
 
+export async function getAuthStatus(): Promise<AuthStatus> {
+  return request<AuthStatus>("/api/auth/me");
+}
+
+// loginUrl starts a sign-in that returns to returnTo. The backend accepts a
+// path on its own origin or a URL on one of its allowed origins.
+export function loginUrl(returnTo: string): string {
+  const target = new URL(returnTo, window.location.href);
+  const api = new URL(apiUrl("/"), window.location.href);
+  const redirect = target.origin === api.origin ? `${target.pathname}${target.search}${target.hash}` : target.href;
+  return apiUrl(`/api/auth/login?redirect=${encodeURIComponent(redirect)}`);
+}
+
+export async function logout(): Promise<AuthStatus> {
+  return request<AuthStatus>("/api/auth/logout", {
+    method: "POST",
+  });
+}
+
+export async function getMyJobs(limit?: number): Promise<UserJob[]> {
+  const qs = limit ? `?limit=${limit}` : "";
+  const result = await request<{ jobs: UserJob[] }>(`/api/me/jobs${qs}`);
+  return result.jobs;
+}
+
 export async function getJob(jobId: string): Promise<JobState> {
-  return request<JobState>(`/api/jobs/${jobId}`);
+  return request<JobState>(jobPath(jobId, `/api/jobs/${jobId}`));
 }
 
 export async function getLogs(jobId: string): Promise<LogsSnapshot> {
-  return request<LogsSnapshot>(`/api/jobs/${jobId}/logs`);
+  return request<LogsSnapshot>(jobPath(jobId, `/api/jobs/${jobId}/logs`));
 }
 
 export async function getArtifacts(jobId: string): Promise<ArtifactItem[]> {
-  const response = await request<{ artifacts: ArtifactItem[] }>(`/api/jobs/${jobId}/artifacts`);
+  const response = await request<{ artifacts: ArtifactItem[] }>(jobPath(jobId, `/api/jobs/${jobId}/artifacts`));
   return response.artifacts;
 }
 
@@ -296,14 +658,18 @@ export async function getBuildLog(password: string, jobId: string): Promise<Buil
 }
 
 export function createLogStream(jobId: string): EventSource {
-  return new EventSource(apiUrl(`/api/jobs/${jobId}/logs/stream`));
+  return new EventSource(apiUrl(jobPath(jobId, `/api/jobs/${jobId}/logs/stream`)));
 }
 
 async function request<T>(path: string, init?: RequestInit): Promise<T> {
   const response = await fetch(apiUrl(path), {
     headers: {
       "Content-Type": "application/json",
+      // Lets the API accept the captcha session cookie for this request.
+      "X-MFB-CSRF": "1",
     },
+    // Sends the sign-in session cookie when the API is on another origin.
+    credentials: "include",
     ...init,
   });
 